@@ -8,6 +8,7 @@ project {
   
     header_ignore = [
     ".github/**",
-    "coverage/**"
+    "coverage/**",
+    "**/*_gen.go"
   ]
 }
\ No newline at end of file
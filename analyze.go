@@ -0,0 +1,107 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// NonSargableDiagnostic reports a comparison whose where clause can't use an
+// index on its column: a LIKE pattern with a leading wildcard or a column
+// wrapped in a function (ie: by a converter).
+const NonSargableDiagnostic DiagnosticKind = "non-sargable"
+
+// functionCallRegexp matches the name of a function called with a column as
+// its first arg (ie: lower(name)).  The column is appended to the pattern.
+const functionCallRegexp = `(?i)\b([a-z_][a-z0-9_]*)\(\s*`
+
+// Analyze will use the provided database model to validate the expr tree (ie:
+// returned by ParseExpr or built using C) and return diagnostics about
+// predicates which are likely expensive or never match, so users can be warned
+// before running the filter: non-sargable comparisons, duplicate conditions and
+// contradictions (ie: age>10 and age<5).  Diagnostics are ordered by their
+// position.  An error is returned if the expr is invalid.  Supported options:
+// the same options as Parse.
+func Analyze(e Expr, model any, opt ...Option) ([]Diagnostic, error) {
+	const op = "mql.Analyze"
+	switch {
+	case isNil(e):
+		return nil, fmt.Errorf("%s: missing expression: %w", op, ErrInvalidParameter)
+	case isNil(model):
+		return nil, fmt.Errorf("%s: missing model: %w", op, ErrInvalidParameter)
+	}
+	opt, err := withModelTable(model, opt)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	opts, err := getOpts(opt...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	fValidators, err := modelValidators(model, opt...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if _, err := exprToWhereClause(e, fValidators, opt...); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var diags []Diagnostic
+	walkExpr(e, func(e Expr) {
+		c, ok := e.(*ComparisonExpr)
+		if !ok {
+			return
+		}
+		// the whole tree was converted, so the comparison is valid
+		w, err := exprToWhereClause(c, fValidators, opt...)
+		if err != nil || w == nil {
+			return
+		}
+		diags = append(diags, sargabilityDiagnostics(c, w, opts)...)
+	})
+	l := linter{opts: opts, validators: fValidators}
+	l.lintExpr(e)
+	diags = append(diags, l.diags...)
+	sort.SliceStable(diags, func(i, j int) bool { return diags[i].Pos < diags[j].Pos })
+	return diags, nil
+}
+
+// sargabilityDiagnostics reports if the comparison's where clause uses a LIKE
+// pattern with a leading wildcard or wraps the comparison's column in a
+// function.
+func sargabilityDiagnostics(c *ComparisonExpr, w *WhereClause, opts options) []Diagnostic {
+	var diags []Diagnostic
+	if strings.Contains(strings.ToLower(w.Condition), " like ") {
+		for _, a := range w.Args {
+			if s, ok := a.(string); ok && (strings.HasPrefix(s, "%") || strings.HasPrefix(s, "_")) {
+				diags = append(diags, Diagnostic{
+					Kind:    NonSargableDiagnostic,
+					Message: fmt.Sprintf("%s uses a LIKE pattern with a leading wildcard, which can't use an index", c.MQL()),
+					Pos:     c.pos,
+				})
+				break
+			}
+		}
+	}
+	columnName := strings.ToLower(c.Column)
+	if n, ok := opts.withColumnMap[columnName]; ok {
+		columnName = n
+	}
+	// a column may be qualified by the converter, so the qualifier is optional
+	re, err := regexp.Compile(functionCallRegexp + `(?:[a-z0-9_]+\.)?` + regexp.QuoteMeta(columnName) + `\b`)
+	if err != nil {
+		return diags
+	}
+	if m := re.FindStringSubmatch(w.Condition); m != nil {
+		diags = append(diags, Diagnostic{
+			Kind:    NonSargableDiagnostic,
+			Message: fmt.Sprintf("%s wraps column %s in the %s function, which can't use an index on the column", c.MQL(), columnName, m[1]),
+			Pos:     c.pos,
+		})
+	}
+	return diags
+}
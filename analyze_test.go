@@ -0,0 +1,171 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/mql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnalyze(t *testing.T) {
+	t.Parallel()
+	lowerName := func(columnName string, comparisonOp mql.ComparisonOp, value *string) (*mql.WhereClause, error) {
+		return &mql.WhereClause{
+			Condition: fmt.Sprintf("lower(%s)%s?", columnName, comparisonOp),
+			Args:      []any{*value},
+		}, nil
+	}
+	tests := []struct {
+		name            string
+		expr            mql.Expr
+		query           string
+		model           any
+		opts            []mql.Option
+		want            []mql.Diagnostic
+		wantErrIs       error
+		wantErrContains string
+	}{
+		{
+			name:  "no-problems",
+			query: `name="alice" and (age > 21 or age < 10)`,
+		},
+		{
+			name:  "leading-wildcard",
+			query: `age > 21 and name % "ali"`,
+			want: []mql.Diagnostic{
+				{Kind: mql.NonSargableDiagnostic, Message: `name%"ali" uses a LIKE pattern with a leading wildcard, which can't use an index`, Pos: 13},
+			},
+		},
+		{
+			name:  "glob-with-leading-wildcard",
+			query: `name="*ce"`,
+			opts:  []mql.Option{mql.WithGlobPatterns()},
+			want: []mql.Diagnostic{
+				{Kind: mql.NonSargableDiagnostic, Message: `name="*ce" uses a LIKE pattern with a leading wildcard, which can't use an index`, Pos: 0},
+			},
+		},
+		{
+			name:  "glob-with-trailing-wildcard",
+			query: `name="al*"`,
+			opts:  []mql.Option{mql.WithGlobPatterns()},
+		},
+		{
+			name:  "function-wrapped-column",
+			query: `name="alice"`,
+			opts:  []mql.Option{mql.WithConverter("name", lowerName)},
+			want: []mql.Diagnostic{
+				{Kind: mql.NonSargableDiagnostic, Message: `name="alice" wraps column name in the lower function, which can't use an index on the column`, Pos: 0},
+			},
+		},
+		{
+			name:  "function-wrapped-qualified-column",
+			query: `name="alice"`,
+			opts:  []mql.Option{mql.WithDefaultConverter(lowerName), mql.WithTableAlias("u")},
+			want: []mql.Diagnostic{
+				{Kind: mql.NonSargableDiagnostic, Message: `name="alice" wraps column name in the lower function, which can't use an index on the column`, Pos: 0},
+			},
+		},
+		{
+			name:  "duplicate-condition",
+			query: `name="alice" or age > 21 or name="alice"`,
+			want: []mql.Diagnostic{
+				{Kind: mql.DuplicateConditionDiagnostic, Message: `duplicate condition name="alice"`, Pos: 28},
+			},
+		},
+		{
+			name:  "contradictory-range",
+			query: `age > 10 and age < 5`,
+			want: []mql.Diagnostic{
+				{Kind: mql.AlwaysFalseDiagnostic, Message: `age>10 and age<5 can never both be true`, Pos: 0},
+			},
+		},
+		{
+			name:  "contradictory-equal-and-range",
+			query: `length = 1.5 and length > 2`,
+			want: []mql.Diagnostic{
+				{Kind: mql.AlwaysFalseDiagnostic, Message: `length=1.5 and length>2 can never both be true`, Pos: 0},
+			},
+		},
+		{
+			name:  "contradictory-exclusive-bounds",
+			query: `created_at > "2024-01-02T00:00:00Z" and created_at < "2024-01-02T00:00:00Z"`,
+			want: []mql.Diagnostic{
+				{Kind: mql.AlwaysFalseDiagnostic, Message: `created_at>"2024-01-02T00:00:00Z" and created_at<"2024-01-02T00:00:00Z" can never both be true`, Pos: 0},
+			},
+		},
+		{
+			name:  "dates-match-the-whole-day",
+			query: `created_at > "2024-01-01T12:00:00Z" and created_at <= "2024-01-01"`,
+		},
+		{
+			name:  "durations",
+			query: `timeout >= "1h" and timeout < "30m"`,
+			model: durationModel{},
+			want: []mql.Diagnostic{
+				{Kind: mql.AlwaysFalseDiagnostic, Message: `timeout>="1h" and timeout<"30m" can never both be true`, Pos: 0},
+			},
+		},
+		{
+			name:  "ranges-with-or",
+			query: `age > 10 or age < 5`,
+		},
+		{
+			name:  "ordered-by-position",
+			query: `name % "ali" and age < 5 and age > 10`,
+			want: []mql.Diagnostic{
+				{Kind: mql.NonSargableDiagnostic, Message: `name%"ali" uses a LIKE pattern with a leading wildcard, which can't use an index`, Pos: 0},
+				{Kind: mql.AlwaysFalseDiagnostic, Message: `age<5 and age>10 can never both be true`, Pos: 17},
+			},
+		},
+		{
+			name: "builder",
+			expr: mql.C("age").Gt(10).And(mql.C("age").Lt(5)),
+			want: []mql.Diagnostic{
+				{Kind: mql.AlwaysFalseDiagnostic, Message: `age>10 and age<5 can never both be true`, Pos: 0},
+			},
+		},
+		{
+			name:            "err-missing-expr",
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: "missing expression",
+		},
+		{
+			name:            "err-invalid-column",
+			query:           `nickname="alice"`,
+			wantErrIs:       mql.ErrInvalidColumn,
+			wantErrContains: `invalid column "nickname"`,
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert, require := assert.New(t), require.New(t)
+			e := tc.expr
+			if tc.query != "" {
+				var err error
+				e, err = mql.ParseExpr(tc.query)
+				require.NoError(err)
+			}
+			model := tc.model
+			if model == nil {
+				model = testModel{}
+			}
+			got, err := mql.Analyze(e, model, tc.opts...)
+			if tc.wantErrContains != "" {
+				require.Error(err)
+				assert.Empty(got)
+				assert.ErrorIs(err, tc.wantErrIs)
+				assert.ErrorContains(err, tc.wantErrContains)
+				return
+			}
+			require.NoError(err)
+			assert.Equal(tc.want, got)
+		})
+	}
+}
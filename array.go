@@ -0,0 +1,66 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// arrayTypes maps the array types of github.com/lib/pq to the type of their
+// elements
+var arrayTypes = map[string]string{
+	"pq.StringArray":  "string",
+	"pq.Int64Array":   "int64",
+	"pq.Int32Array":   "int32",
+	"pq.Float64Array": "float64",
+	"pq.Float32Array": "float32",
+	"pq.BoolArray":    "bool",
+}
+
+// arrayElemType returns the type of the elements when the string rep of a Go
+// type is a slice or array type (excluding []byte, which is a binary value).
+func arrayElemType(fType string) (string, bool) {
+	if elem, ok := arrayTypes[fType]; ok {
+		return elem, true
+	}
+	if !strings.HasPrefix(fType, "[]") {
+		return "", false
+	}
+	elem := strings.TrimPrefix(strings.TrimPrefix(fType, "[]"), "*")
+	if elem == "byte" || elem == "uint8" {
+		return "", false
+	}
+	return elem, true
+}
+
+// arrayCondition returns the where clause which reports if the array column
+// contains the value.  Postgres arrays use: column @> ARRAY[?] and arrays
+// stored in a json column (see WithJsonArrayColumns) use: column @> ? with a
+// json array arg (ie: ["prod"]), which is generated using the dialect (see
+// Dialect.JsonArrayContains).  Postgres arrays are only supported by dialects
+// with the postgres operators.
+func arrayCondition(d Dialect, columnName string, comparisonOp ComparisonOp, value any, jsonColumn bool) (*WhereClause, error) {
+	const op = "mql.arrayCondition"
+	switch {
+	case comparisonOp != ArrayContainsOp:
+		return nil, fmt.Errorf("%s: %w %q for array column %q (expected %s)", op, ErrInvalidComparisonOp, comparisonOp, columnName, ArrayContainsOp)
+	case !jsonColumn && !hasPgOperators(d):
+		return nil, fmt.Errorf("%s: array column %q is not supported by the %s dialect (see WithJsonArrayColumns): %w", op, columnName, d.Name(), ErrInvalidParameter)
+	case !jsonColumn:
+		return &WhereClause{
+			Condition: fmt.Sprintf("%s @> ARRAY[?]", columnName),
+			Args:      []any{value},
+		}, nil
+	}
+	b, err := json.Marshal([]any{value})
+	if err != nil {
+		return nil, fmt.Errorf("%s: unable to encode %v as a json array: %w", op, value, ErrInvalidParameter)
+	}
+	return &WhereClause{
+		Condition: d.JsonArrayContains(columnName),
+		Args:      []any{string(b)},
+	}, nil
+}
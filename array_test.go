@@ -0,0 +1,142 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql_test
+
+import (
+	"testing"
+
+	"github.com/hashicorp/mql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type deploymentModel struct {
+	Name    string
+	Tags    []string
+	Ports   []int
+	Regions *[]string
+	Labels  []string
+}
+
+func TestParse_array(t *testing.T) {
+	t.Parallel()
+	describer := staticDescriber{
+		fields: []mql.FieldDescriptor{
+			{Name: "Tags", Type: "pq.StringArray"},
+			{Name: "Ports", Type: "pq.Int64Array"},
+		},
+	}
+	tests := []struct {
+		name            string
+		query           string
+		model           any
+		opts            []mql.Option
+		want            *mql.WhereClause
+		wantErrIs       error
+		wantErrContains string
+	}{
+		{
+			name:  "slices",
+			query: `tags @> "prod" and ports@>443 or regions @> "us-east-1"`,
+			model: deploymentModel{},
+			want: &mql.WhereClause{
+				Condition: "((tags @> ARRAY[?] and ports @> ARRAY[?]) or regions @> ARRAY[?])",
+				Args:      []any{"prod", 443, "us-east-1"},
+			},
+		},
+		{
+			name:  "pq-arrays",
+			query: `tags @> "prod" and ports @> 443`,
+			model: struct{}{},
+			opts:  []mql.Option{mql.WithModelDescriber(describer)},
+			want: &mql.WhereClause{
+				Condition: "(tags @> ARRAY[?] and ports @> ARRAY[?])",
+				Args:      []any{"prod", 443},
+			},
+		},
+		{
+			name:  "WithJsonArrayColumns",
+			query: `labels @> "prod" and tags @> "web"`,
+			model: deploymentModel{},
+			opts:  []mql.Option{mql.WithJsonArrayColumns("labels")},
+			want: &mql.WhereClause{
+				Condition: "(labels @> ? and tags @> ARRAY[?])",
+				Args:      []any{`["prod"]`, "web"},
+			},
+		},
+		{
+			name:            "err-invalid-elem",
+			query:           `ports @> "https"`,
+			model:           deploymentModel{},
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: `"https" in (comparisonExpr: ports @> https)`,
+		},
+		{
+			name:            "err-invalid-op",
+			query:           `tags = "prod"`,
+			model:           deploymentModel{},
+			wantErrIs:       mql.ErrInvalidComparisonOp,
+			wantErrContains: `invalid comparison operator "=" for array column "tags" (expected @>)`,
+		},
+		{
+			name:            "err-not-an-array",
+			query:           `name @> "prod"`,
+			model:           deploymentModel{},
+			wantErrIs:       mql.ErrInvalidComparisonOp,
+			wantErrContains: `invalid comparison operator "@>" for column "name" (only supported for array columns)`,
+		},
+		{
+			name:            "err-WithJsonArrayColumns-missing-column",
+			query:           `tags @> "prod"`,
+			model:           deploymentModel{},
+			opts:            []mql.Option{mql.WithJsonArrayColumns("")},
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: "missing column",
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert, require := assert.New(t), require.New(t)
+			got, err := mql.Parse(tc.query, tc.model, tc.opts...)
+			if tc.wantErrContains != "" {
+				require.Error(err)
+				assert.Empty(got)
+				assert.ErrorIs(err, tc.wantErrIs)
+				assert.ErrorContains(err, tc.wantErrContains)
+				return
+			}
+			require.NoError(err)
+			assert.Equal(tc.want, got)
+		})
+	}
+}
+
+func TestMatch_array(t *testing.T) {
+	t.Parallel()
+	item := deploymentModel{
+		Name:  "web",
+		Tags:  []string{"prod", "web"},
+		Ports: []int{80, 443},
+	}
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{query: `tags @> "prod"`, want: true},
+		{query: `tags @> "dev"`, want: false},
+		{query: `ports @> 443`, want: true},
+		{query: `regions @> "us-east-1"`, want: false},
+		{query: `labels @> "prod"`, want: false},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.query, func(t *testing.T) {
+			got, err := mql.Match(tc.query, item)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql_test
+
+import (
+	"testing"
+
+	"github.com/hashicorp/mql"
+	"github.com/stretchr/testify/assert"
+)
+
+// benchQueries are the queries used by the benchmarks along with their
+// allocation budgets, which are enforced by TestParse_allocs.  The budgets have
+// some headroom, but a change which exceeds one should be a deliberate
+// decision (and the README's benchmark results should be updated).
+var benchQueries = []struct {
+	name      string
+	query     string
+	opts      []mql.Option
+	maxAllocs float64
+}{
+	{name: "simple", query: `name="alice"`, maxAllocs: 25},
+	{name: "number", query: `age>21`, maxAllocs: 25},
+	{name: "and", query: `name="alice" and age>21`, maxAllocs: 40},
+	{name: "map", query: `labels.env="prod"`, maxAllocs: 30},
+	{name: "pg-placeholders", query: `name="alice" and age>21`, opts: []mql.Option{mql.WithPgPlaceholders()}, maxAllocs: 45},
+	{
+		name:      "complex",
+		query:     `(name="alice" and age>21) or (email%"@example.com" and created_at>"2023-01-01")`,
+		maxAllocs: 90,
+	},
+}
+
+func BenchmarkParse(b *testing.B) {
+	for _, bq := range benchQueries {
+		bq := bq
+		b.Run(bq.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := mql.Parse(bq.query, testModel{}, bq.opts...); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkParseExpr(b *testing.B) {
+	for _, bq := range benchQueries {
+		bq := bq
+		b.Run(bq.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := mql.ParseExpr(bq.query); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestParse_allocs(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping allocation budgets in short mode")
+	}
+	for _, bq := range benchQueries {
+		bq := bq
+		t.Run(bq.name, func(t *testing.T) {
+			allocs := testing.AllocsPerRun(100, func() {
+				if _, err := mql.Parse(bq.query, testModel{}, bq.opts...); err != nil {
+					t.Fatal(err)
+				}
+			})
+			assert.LessOrEqualf(t, allocs, bq.maxAllocs, "%s: %v allocs exceeds the budget of %v", bq.query, allocs, bq.maxAllocs)
+		})
+	}
+}
+
+func BenchmarkParser_Parse(b *testing.B) {
+	for _, bq := range benchQueries {
+		bq := bq
+		p, err := mql.NewParser(testModel{}, bq.opts...)
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.Run(bq.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := p.Parse(bq.query); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
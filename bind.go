@@ -0,0 +1,151 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ArgMap returns the where clause's args keyed by their column with an
+// occurrence suffix (ie: {"name_1": "alice", "age_1": 21}), which are the
+// same names used by WithNamedParams.  It's helpful for logging and caching
+// where clauses, and for APIs which require named binds.  The names of the
+// args are their NamedArgs when using WithNamedParams, otherwise they're
+// named using the where clause's Metadata (see WithMetadata).  It returns an
+// empty map when the where clause doesn't have any args (ie: when using
+// WithInlineValues).
+func (w *WhereClause) ArgMap() (map[string]any, error) {
+	const op = "mql.(WhereClause).ArgMap"
+	switch {
+	case w == nil:
+		return nil, fmt.Errorf("%s: missing where clause: %w", op, ErrInvalidParameter)
+	case len(w.NamedArgs) > 0:
+		args := make(map[string]any, len(w.NamedArgs))
+		for k, v := range w.NamedArgs {
+			args[k] = v
+		}
+		return args, nil
+	case len(w.Args) == 0:
+		return map[string]any{}, nil
+	case w.Metadata == nil:
+		return nil, fmt.Errorf("%s: missing metadata to name the args (see WithMetadata): %w", op, ErrInvalidParameter)
+	case len(w.Metadata.Args) != len(w.Args):
+		return nil, fmt.Errorf("%s: metadata describes %d args and there are %d args: %w", op, len(w.Metadata.Args), len(w.Args), ErrInvalidParameter)
+	}
+	columns := make([]string, 0, len(w.Metadata.Args))
+	for _, a := range w.Metadata.Args {
+		columns = append(columns, a.Column)
+	}
+	names := namedParams(columns)
+	args := make(map[string]any, len(w.Args))
+	for i, a := range w.Args {
+		// the args of WithSqlNamedArgs are named p1, p2, etc
+		if n, ok := a.(sql.NamedArg); ok {
+			a = n.Value
+		}
+		args[names[i]] = a
+	}
+	return args, nil
+}
+
+// BindArgs binds the where clause's args, named using ArgMap, to dst which
+// is either a map[string]any (or a pointer to one) or a pointer to a struct.
+// A struct's fields are matched to the names of the args using the same
+// rules as the columns of a model: case insensitive and ignoring underscores
+// (ie: the arg name_1 is bound to the field Name1).  Every arg must be bound
+// to a field which can hold its value and fields without an arg are left
+// unchanged.
+func (w *WhereClause) BindArgs(dst any) error {
+	const op = "mql.(WhereClause).BindArgs"
+	args, err := w.ArgMap()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	switch d := dst.(type) {
+	case nil:
+		return fmt.Errorf("%s: missing destination: %w", op, ErrInvalidParameter)
+	case map[string]any:
+		if d == nil {
+			return fmt.Errorf("%s: nil destination map: %w", op, ErrInvalidParameter)
+		}
+		for k, v := range args {
+			d[k] = v
+		}
+		return nil
+	case *map[string]any:
+		if d == nil {
+			return fmt.Errorf("%s: nil destination: %w", op, ErrInvalidParameter)
+		}
+		if *d == nil {
+			*d = make(map[string]any, len(args))
+		}
+		for k, v := range args {
+			(*d)[k] = v
+		}
+		return nil
+	}
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("%s: destination %T is not a map[string]any or a pointer to a struct: %w", op, dst, ErrInvalidParameter)
+	}
+	s := rv.Elem()
+	fields := make(map[string]int, s.NumField())
+	for i := 0; i < s.NumField(); i++ {
+		if f := s.Type().Field(i); f.IsExported() {
+			fields[strings.ToLower(strings.ReplaceAll(f.Name, "_", ""))] = i
+		}
+	}
+	names := make([]string, 0, len(args))
+	for k := range args {
+		names = append(names, k)
+	}
+	// bind the args in order, so the same error is returned every time
+	sort.Strings(names)
+	for _, name := range names {
+		i, ok := fields[strings.ReplaceAll(name, "_", "")]
+		if !ok {
+			return fmt.Errorf("%s: %T doesn't have a field for the arg %q: %w", op, dst, name, ErrInvalidParameter)
+		}
+		f := s.Field(i)
+		v := reflect.ValueOf(args[name])
+		switch {
+		case !v.IsValid():
+			f.Set(reflect.Zero(f.Type()))
+		case v.Type().AssignableTo(f.Type()):
+			f.Set(v)
+		case setNumber(f, v):
+			// ints are validated as an int, so they're converted to the
+			// field's type (ie: an int64) as long as they fit it
+		default:
+			return fmt.Errorf("%s: field %s of %T (%s) can't hold the arg %q (%T): %w", op, s.Type().Field(i).Name, dst, f.Type(), name, args[name], ErrInvalidParameter)
+		}
+	}
+	return nil
+}
+
+// setNumber sets the int or uint field to the int or uint v, or the float
+// field to the float v.  It reports false when v can't be converted to the
+// field's type or doesn't fit the field.
+func setNumber(f, v reflect.Value) bool {
+	switch {
+	case v.CanInt() && f.CanInt() && !f.OverflowInt(v.Int()):
+		f.SetInt(v.Int())
+	case v.CanInt() && f.CanUint() && v.Int() >= 0 && !f.OverflowUint(uint64(v.Int())):
+		f.SetUint(uint64(v.Int()))
+	case v.CanUint() && f.CanUint() && !f.OverflowUint(v.Uint()):
+		f.SetUint(v.Uint())
+	case v.CanUint() && f.CanInt() && v.Uint() <= math.MaxInt64 && !f.OverflowInt(int64(v.Uint())):
+		f.SetInt(int64(v.Uint()))
+	case v.CanFloat() && f.CanFloat() && !f.OverflowFloat(v.Float()):
+		f.SetFloat(v.Float())
+	default:
+		return false
+	}
+	return true
+}
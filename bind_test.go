@@ -0,0 +1,141 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql_test
+
+import (
+	"testing"
+
+	"github.com/hashicorp/mql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWhereClause_ArgMap(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name            string
+		query           string
+		opts            []mql.Option
+		want            map[string]any
+		wantErrIs       error
+		wantErrContains string
+	}{
+		{
+			name:  "metadata",
+			query: `name="alice" or (name="bob" and age>21)`,
+			opts:  []mql.Option{mql.WithMetadata()},
+			want:  map[string]any{"name_1": "alice", "name_2": "bob", "age_1": 21},
+		},
+		{
+			name:  "named-params",
+			query: `name="alice" and age>21`,
+			opts:  []mql.Option{mql.WithNamedParams(":")},
+			want:  map[string]any{"name_1": "alice", "age_1": 21},
+		},
+		{
+			name:  "sql-named-args",
+			query: `name="alice" and labels.env="prod"`,
+			opts:  []mql.Option{mql.WithMetadata(), mql.WithSqlNamedArgs()},
+			want:  map[string]any{"name_1": "alice", "labels_1": "env", "labels_2": "prod"},
+		},
+		{
+			name:  "pg-placeholders",
+			query: `age>=21`,
+			opts:  []mql.Option{mql.WithMetadata(), mql.WithPgPlaceholders()},
+			want:  map[string]any{"age_1": 21},
+		},
+		{
+			name:  "inline-values",
+			query: `name="alice"`,
+			opts:  []mql.Option{mql.WithInlineValues()},
+			want:  map[string]any{},
+		},
+		{
+			name:            "err-missing-metadata",
+			query:           `name="alice"`,
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: "missing metadata to name the args (see WithMetadata)",
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert, require := assert.New(t), require.New(t)
+			w, err := mql.Parse(tc.query, testModel{}, tc.opts...)
+			require.NoError(err)
+			got, err := w.ArgMap()
+			if tc.wantErrIs != nil {
+				require.Error(err)
+				assert.ErrorIs(err, tc.wantErrIs)
+				assert.ErrorContains(err, tc.wantErrContains)
+				assert.Nil(got)
+				return
+			}
+			require.NoError(err)
+			assert.Equal(tc.want, got)
+		})
+	}
+	t.Run("nil", func(t *testing.T) {
+		var w *mql.WhereClause
+		_, err := w.ArgMap()
+		assert.ErrorIs(t, err, mql.ErrInvalidParameter)
+	})
+}
+
+func TestWhereClause_BindArgs(t *testing.T) {
+	t.Parallel()
+	w, err := mql.Parse(`name="alice" or (name="bob" and age>21)`, testModel{}, mql.WithMetadata())
+	require.NoError(t, err)
+
+	t.Run("struct", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		var got struct {
+			Name1  string
+			Name_2 string
+			Age1   int64
+			Other  string
+		}
+		got.Other = "unchanged"
+		require.NoError(w.BindArgs(&got))
+		assert.Equal("alice", got.Name1)
+		assert.Equal("bob", got.Name_2)
+		assert.Equal(int64(21), got.Age1)
+		assert.Equal("unchanged", got.Other)
+	})
+	t.Run("map", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		var got map[string]any
+		require.NoError(w.BindArgs(&got))
+		assert.Equal(map[string]any{"name_1": "alice", "name_2": "bob", "age_1": 21}, got)
+
+		existing := map[string]any{"tenant_id": "t1"}
+		require.NoError(w.BindArgs(existing))
+		assert.Len(existing, 4)
+	})
+	t.Run("err-missing-field", func(t *testing.T) {
+		var got struct{ Name1, Name2 string }
+		err := w.BindArgs(&got)
+		assert.ErrorIs(t, err, mql.ErrInvalidParameter)
+		assert.ErrorContains(t, err, `doesn't have a field for the arg "age_1"`)
+	})
+	t.Run("err-field-type", func(t *testing.T) {
+		var got struct {
+			Name1, Name2 string
+			Age1         int8
+		}
+		w, err := mql.Parse(`name="alice" or (name="bob" and age>200)`, testModel{}, mql.WithMetadata())
+		require.NoError(t, err)
+		err = w.BindArgs(&got)
+		assert.ErrorIs(t, err, mql.ErrInvalidParameter)
+		assert.ErrorContains(t, err, `field Age1 of *struct { Name1 string; Name2 string; Age1 int8 } (int8) can't hold the arg "age_1" (int)`)
+	})
+	t.Run("err-destination", func(t *testing.T) {
+		assert := assert.New(t)
+		var s struct{}
+		for _, dst := range []any{nil, s, &[]string{}, map[string]any(nil)} {
+			assert.ErrorIs(w.BindArgs(dst), mql.ErrInvalidParameter, "%T", dst)
+		}
+	})
+}
@@ -0,0 +1,82 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql
+
+import (
+	"fmt"
+	"strings"
+)
+
+// BlindIndexFunc returns the blind index of a value (ie: its HMAC), which is
+// compared to the blind index column of an encrypted column.  See
+// WithBlindIndex
+type BlindIndexFunc func(value string) (any, error)
+
+// blindIndex is the blind index column of an encrypted column and the func
+// which returns the blind index of its values (see WithBlindIndex)
+type blindIndex struct {
+	column string
+	fn     BlindIndexFunc
+}
+
+// WithBlindIndex compares the encrypted column (database column or model
+// field name) using its blind index column, which stores the blind index of
+// its values (ie: an HMAC): ssn="123-45-6789" is converted to: ssn_hmac=?
+// with the arg returned by the BlindIndexFunc for "123-45-6789".  Only = and
+// != are supported and the comparisons which use other operators return an
+// ErrInvalidComparisonOp.  The values of the column are never included in
+// the errors, since they're typically PII.
+func WithBlindIndex(column, indexColumn string, fn BlindIndexFunc) Option {
+	const op = "mql.WithBlindIndex"
+	return func(o *options) error {
+		switch {
+		case column == "":
+			return fmt.Errorf("%s: missing column: %w", op, ErrInvalidParameter)
+		case !isIdentifier(indexColumn):
+			return fmt.Errorf("%s: index column %q of column %q isn't an identifier: %w", op, indexColumn, column, ErrInvalidParameter)
+		case fn == nil:
+			return fmt.Errorf("%s: missing blind index func for column %q: %w", op, column, ErrInvalidParameter)
+		}
+		if o.withBlindIndexes == nil {
+			o.withBlindIndexes = make(map[string]blindIndex)
+		}
+		o.withBlindIndexes[strings.ToLower(strings.ReplaceAll(column, "_", ""))] = blindIndex{column: indexColumn, fn: fn}
+		return nil
+	}
+}
+
+// blindIndexCondition returns the where clause which compares the blind index
+// of the comparison's value to the blind index column of its column.  It
+// reports false when the column doesn't have a blind index.  Supported
+// options: WithBlindIndex, WithColumnMap, WithTableAlias, WithTableName,
+// WithDialect
+func blindIndexCondition(e *ComparisonExpr, opts options) (*WhereClause, bool, error) {
+	const op = "mql.blindIndexCondition"
+	if len(opts.withBlindIndexes) == 0 {
+		return nil, false, nil
+	}
+	columnName := strings.ToLower(e.Column)
+	if n, ok := opts.withColumnMap[columnName]; ok {
+		columnName = n
+	}
+	bi, ok := opts.withBlindIndexes[strings.ToLower(strings.ReplaceAll(columnName, "_", ""))]
+	switch {
+	case !ok:
+		return nil, false, nil
+	case e.ComparisonOp != EqualOp && e.ComparisonOp != NotEqualOp:
+		return nil, true, fmt.Errorf("%s: %w %q for encrypted column %q (expected = or !=)", op, ErrInvalidComparisonOp, e.ComparisonOp, columnName)
+	case e.Value == nil:
+		return nil, true, fmt.Errorf("%s: %w", op, ErrMissingComparisonValue)
+	}
+	arg, err := bi.fn(*e.Value)
+	if err != nil {
+		// the error of the func isn't wrapped, since it may include the value
+		return nil, true, fmt.Errorf("%s: blind index of the value of column %q: %w", op, columnName, ErrInvalidParameter)
+	}
+	return &WhereClause{
+		Condition:  qualifyColumn(bi.column, opts) + string(e.ComparisonOp) + "?",
+		Args:       []any{arg},
+		argColumns: []string{columnName},
+	}, true, nil
+}
@@ -0,0 +1,110 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"testing"
+
+	"github.com/hashicorp/mql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type patientModel struct {
+	Name string
+	Ssn  string
+}
+
+func TestParse_WithBlindIndex(t *testing.T) {
+	t.Parallel()
+	hmacFn := func(v string) (any, error) {
+		if v == "invalid" {
+			return nil, errors.New("invalid ssn")
+		}
+		h := hmac.New(sha256.New, []byte("key"))
+		h.Write([]byte(v))
+		return h.Sum(nil), nil
+	}
+	mac := func(v string) []byte {
+		b, err := hmacFn(v)
+		require.NoError(t, err)
+		return b.([]byte)
+	}
+	tests := []struct {
+		name            string
+		query           string
+		opts            []mql.Option
+		want            *mql.WhereClause
+		wantErrIs       error
+		wantErrContains string
+	}{
+		{
+			name:  "equal",
+			query: `name="alice" and ssn="123-45-6789"`,
+			want: &mql.WhereClause{
+				Condition: "(name=? and ssn_hmac=?)",
+				Args:      []any{"alice", mac("123-45-6789")},
+			},
+		},
+		{
+			name:  "not-equal",
+			query: `SSN!="123-45-6789"`,
+			want:  &mql.WhereClause{Condition: "ssn_hmac!=?", Args: []any{mac("123-45-6789")}},
+		},
+		{
+			name:  "postgres-table-alias",
+			query: `ssn="123-45-6789"`,
+			opts:  []mql.Option{mql.WithDialect(mql.PostgresDialect{}), mql.WithPgPlaceholders(), mql.WithTableAlias("p")},
+			want:  &mql.WhereClause{Condition: `"p"."ssn_hmac"=$1`, Args: []any{mac("123-45-6789")}},
+		},
+		{
+			name:  "named-params",
+			query: `ssn="123-45-6789"`,
+			opts:  []mql.Option{mql.WithNamedParams(":")},
+			want:  &mql.WhereClause{Condition: "ssn_hmac=:ssn_1", NamedArgs: map[string]any{"ssn_1": mac("123-45-6789")}},
+		},
+		{
+			name:      "err-comparison-op",
+			query:     `ssn%"123"`,
+			wantErrIs: mql.ErrInvalidComparisonOp,
+		},
+		{
+			name:            "err-blind-index",
+			query:           `ssn="invalid"`,
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: `blind index of the value of column "ssn"`,
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert, require := assert.New(t), require.New(t)
+			got, err := mql.Parse(tc.query, patientModel{}, append(tc.opts, mql.WithBlindIndex("ssn", "ssn_hmac", hmacFn))...)
+			if tc.wantErrIs != nil {
+				require.Error(err)
+				assert.ErrorIs(err, tc.wantErrIs)
+				assert.ErrorContains(err, tc.wantErrContains)
+				assert.NotContains(err.Error(), "invalid ssn")
+				assert.Nil(got)
+				return
+			}
+			require.NoError(err)
+			assert.Equal(tc.want, got)
+		})
+	}
+	t.Run("err-options", func(t *testing.T) {
+		for _, o := range []mql.Option{
+			mql.WithBlindIndex("", "ssn_hmac", hmacFn),
+			mql.WithBlindIndex("ssn", "ssn hmac", hmacFn),
+			mql.WithBlindIndex("ssn", "ssn_hmac", nil),
+		} {
+			_, err := mql.Parse(`ssn="123-45-6789"`, patientModel{}, o)
+			assert.ErrorIs(t, err, mql.ErrInvalidParameter)
+		}
+	})
+}
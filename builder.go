@@ -0,0 +1,131 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Col is a column used to build a comparison (see C), so filters can be
+// constructed in code without concatenating and re-parsing query strings:
+//
+//	e := mql.C("age").Gt(21).And(mql.C("name").Contains("ali"))
+//	w, err := mql.ToWhereClause(e, User{})
+type Col string
+
+// C returns the column used to build a comparison.  The column is validated
+// against the model when the expr is converted (see ToWhereClause).
+func C(column string) Col {
+	return Col(column)
+}
+
+// Eq returns the comparison: column = v
+func (c Col) Eq(v any) *ComparisonExpr { return c.Cmp(EqualOp, v) }
+
+// Ne returns the comparison: column != v
+func (c Col) Ne(v any) *ComparisonExpr { return c.Cmp(NotEqualOp, v) }
+
+// Gt returns the comparison: column > v
+func (c Col) Gt(v any) *ComparisonExpr { return c.Cmp(GreaterThanOp, v) }
+
+// Gte returns the comparison: column >= v
+func (c Col) Gte(v any) *ComparisonExpr { return c.Cmp(GreaterThanOrEqualOp, v) }
+
+// Lt returns the comparison: column < v
+func (c Col) Lt(v any) *ComparisonExpr { return c.Cmp(LessThanOp, v) }
+
+// Lte returns the comparison: column <= v
+func (c Col) Lte(v any) *ComparisonExpr { return c.Cmp(LessThanOrEqualOp, v) }
+
+// Contains returns the comparison: column % v
+func (c Col) Contains(v any) *ComparisonExpr { return c.Cmp(ContainsOp, v) }
+
+// ContainedBy returns the comparison: column << v (see ContainedByOp)
+func (c Col) ContainedBy(v any) *ComparisonExpr { return c.Cmp(ContainedByOp, v) }
+
+// ArrayContains returns the comparison: column @> v (see ArrayContainsOp)
+func (c Col) ArrayContains(v any) *ComparisonExpr { return c.Cmp(ArrayContainsOp, v) }
+
+// SimilarTo returns the comparison: column ~% v (see SimilarToOp)
+func (c Col) SimilarTo(v any) *ComparisonExpr { return c.Cmp(SimilarToOp, v) }
+
+// Cmp returns the comparison of the column to the value using the comparison
+// operator.  The value is converted to the string it would be in a query:
+// numbers and bools are formatted using strconv, a time.Time using RFC3339 (with
+// nanoseconds), a time.Duration and a fmt.Stringer using String() and anything
+// else using fmt.Sprint.
+func (c Col) Cmp(op ComparisonOp, v any) *ComparisonExpr {
+	s := valueString(v)
+	return &ComparisonExpr{Column: string(c), ComparisonOp: op, Value: &s}
+}
+
+// valueString converts a value of a builder's comparison to a string
+func valueString(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case int:
+		return strconv.Itoa(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case uint64:
+		return strconv.FormatUint(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
+	case bool:
+		return strconv.FormatBool(v)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case fmt.Stringer:
+		// includes time.Duration
+		return v.String()
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// And returns the logical expr: e and right
+func (e *ComparisonExpr) And(right Expr) *LogicalExpr {
+	return &LogicalExpr{LeftExpr: e, LogicalOp: AndOp, RightExpr: right}
+}
+
+// Or returns the logical expr: e or right
+func (e *ComparisonExpr) Or(right Expr) *LogicalExpr {
+	return &LogicalExpr{LeftExpr: e, LogicalOp: OrOp, RightExpr: right}
+}
+
+// And returns the logical expr: l and right.  l is the left side, so it's
+// grouped: (l) and right
+func (l *LogicalExpr) And(right Expr) *LogicalExpr {
+	return &LogicalExpr{LeftExpr: l, LogicalOp: AndOp, RightExpr: right}
+}
+
+// Or returns the logical expr: l or right.  l is the left side, so it's
+// grouped: (l) or right
+func (l *LogicalExpr) Or(right Expr) *LogicalExpr {
+	return &LogicalExpr{LeftExpr: l, LogicalOp: OrOp, RightExpr: right}
+}
+
+// ToWhereClause will use the provided database model to validate the expr tree
+// (ie: built using C or returned by ParseExpr) and convert it to a where
+// clause, just like Parse does for a query.  Supported options: the same
+// options as Parse, except WithAllowEmptyQuery.
+func ToWhereClause(e Expr, model any, opt ...Option) (*WhereClause, error) {
+	const op = "mql.ToWhereClause"
+	switch {
+	case isNil(e):
+		return nil, fmt.Errorf("%s: missing expression: %w", op, ErrInvalidParameter)
+	case isNil(model):
+		return nil, fmt.Errorf("%s: missing model: %w", op, ErrInvalidParameter)
+	}
+	w, err := whereClause(e, model, opt...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return w, nil
+}
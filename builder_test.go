@@ -0,0 +1,119 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/mql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToWhereClause(t *testing.T) {
+	t.Parallel()
+	createdAt := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		name            string
+		expr            mql.Expr
+		model           any
+		opts            []mql.Option
+		query           string // the equivalent query, which Parse must convert to the same where clause
+		wantErrIs       error
+		wantErrContains string
+	}{
+		{
+			name:  "and",
+			expr:  mql.C("age").Gt(21).And(mql.C("name").Contains("ali")),
+			model: testModel{},
+			query: `age>21 and name%"ali"`,
+		},
+		{
+			name:  "every-op",
+			expr:  mql.C("name").Eq("alice").Or(mql.C("name").Ne("bob").And(mql.C("age").Gte(uint8(18)).And(mql.C("age").Lt(65).And(mql.C("length").Lte(1.5))))),
+			model: testModel{},
+			query: `name="alice" or (name!="bob" and (age>=18 and (age<65 and length<=1.5)))`,
+		},
+		{
+			name:  "left-is-grouped",
+			expr:  mql.C("name").Eq("alice").Or(mql.C("name").Eq("bob")).And(mql.C("age").Gt(21)),
+			model: testModel{},
+			opts:  []mql.Option{mql.WithPgPlaceholders()},
+			query: `(name="alice" or name="bob") and age>21`,
+		},
+		{
+			name:  "quotes",
+			expr:  mql.C("name").Eq(`al"ice's`),
+			model: testModel{},
+			query: `name="al\"ice's"`,
+		},
+		{
+			name: "time-and-duration",
+			expr: mql.C("created_at").Gte(createdAt).And(mql.C("timeout").Lt(90 * time.Second)),
+			model: struct {
+				CreatedAt time.Time
+				Timeout   time.Duration
+			}{},
+			query: `created_at>="2023-01-02T03:04:05Z" and timeout<"1m30s"`,
+		},
+		{
+			name:  "bool",
+			expr:  mql.C("enabled").Eq(true),
+			model: boolModel{},
+			query: `enabled=true`,
+		},
+		{
+			name:            "err-invalid-column",
+			expr:            mql.C("not").Eq("alice"),
+			model:           testModel{},
+			wantErrIs:       mql.ErrInvalidColumn,
+			wantErrContains: `invalid column "not"`,
+		},
+		{
+			name:            "err-invalid-value",
+			expr:            mql.C("age").Eq("old"),
+			model:           testModel{},
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: `"old" in (comparisonExpr: age = old)`,
+		},
+		{
+			name:            "err-missing-expr",
+			model:           testModel{},
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: "missing expression",
+		},
+		{
+			name:            "err-missing-model",
+			expr:            mql.C("name").Eq("alice"),
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: "missing model",
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert, require := assert.New(t), require.New(t)
+			got, err := mql.ToWhereClause(tc.expr, tc.model, tc.opts...)
+			if tc.wantErrContains != "" {
+				require.Error(err)
+				assert.Empty(got)
+				assert.ErrorIs(err, tc.wantErrIs)
+				assert.ErrorContains(err, tc.wantErrContains)
+				return
+			}
+			require.NoError(err)
+			want, err := mql.Parse(tc.query, tc.model, tc.opts...)
+			require.NoError(err)
+			assert.Equal(want, got)
+		})
+	}
+}
+
+func TestC_MQL(t *testing.T) {
+	t.Parallel()
+	e := mql.C("name").Eq("alice").Or(mql.C("name").Eq("bob")).And(mql.C("age").Gt(21))
+	assert.Equal(t, `name="alice" or name="bob" and age>21`, e.MQL())
+}
@@ -0,0 +1,225 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/exp/slices"
+)
+
+// QueryCheck is the result of CheckQuery.  It's designed to be marshaled as
+// JSON and sent to a UI (ie: a frontend using mql compiled to wasm), so it
+// only includes plain data.
+type QueryCheck struct {
+	// Valid reports if the query is valid
+	Valid bool `json:"valid"`
+
+	// Errors are the problems which make the query invalid
+	Errors []QueryIssue `json:"errors,omitempty"`
+
+	// Warnings are the lint diagnostics of a valid query (see Lint)
+	Warnings []QueryIssue `json:"warnings,omitempty"`
+
+	// Columns are the columns which can be used in a query, which can be
+	// used to suggest columns and operators as the query is typed
+	Columns []ColumnInfo `json:"columns"`
+}
+
+// QueryIssue is an error or warning about a query
+type QueryIssue struct {
+	// Message describing the issue
+	Message string `json:"message"`
+
+	// Kind of the issue, which is the DiagnosticKind of a warning and empty
+	// for errors
+	Kind string `json:"kind,omitempty"`
+
+	// Pos is the byte offset in the query where the issue starts and it's -1
+	// when the issue isn't about a part of the query (ie: a missing query)
+	Pos int `json:"pos"`
+}
+
+// ColumnInfo describes a column which can be used in a query
+type ColumnInfo struct {
+	// Column is the name used in a query, which is the field's name in
+	// snake_case (ie: created_at for CreatedAt).  Column names are case
+	// insensitive and their underscores are optional.
+	Column string `json:"column"`
+
+	// Field is the model's field
+	Field string `json:"field"`
+
+	// Type of the column's values: string, int, float, decimal, bool, time,
+	// duration, ip or the name of a registered field type (see
+	// RegisterFieldType).  It's the type of the elements for map and array
+	// columns.
+	Type string `json:"type"`
+
+	// Map reports if the column is a map, which is queried by key (ie:
+	// labels.env)
+	Map bool `json:"map,omitempty"`
+
+	// Array reports if the column is an array, which is queried using @>
+	Array bool `json:"array,omitempty"`
+
+	// ComparisonOps are the comparison operators which can be used with the
+	// column
+	ComparisonOps []ComparisonOp `json:"comparison_ops"`
+}
+
+// CheckQuery will validate the query using the fields of a model (rather than
+// a Go struct) and return every error with its position in the query, the
+// warnings of a valid query and the columns which can be used in a query.
+// After a syntax error, it skips to the next logical operator and continues
+// (see ParseExprRecover), so every error of the query is returned.  It's
+// designed to be compiled to wasm (GOOS=js GOARCH=wasm), so a frontend can
+// validate a query using the same code as the server (see examples/wasm).
+// Supported options: the same options as Parse.
+func CheckQuery(query string, fields []FieldDescriptor, opt ...Option) QueryCheck {
+	const op = "mql.CheckQuery"
+	var c QueryCheck
+	addErr := func(err error, pos int) {
+		c.Errors = append(c.Errors, QueryIssue{Message: fmt.Sprintf("%s: %s", op, err), Pos: pos})
+	}
+	opt = append([]Option{WithModelDescriber(fieldsDescriber(fields))}, opt...)
+	opts, err := getOpts(opt...)
+	if err != nil {
+		addErr(err, -1)
+		return c
+	}
+	fValidators, err := modelValidators(struct{}{}, opt...)
+	if err != nil {
+		addErr(err, -1)
+		return c
+	}
+	c.Columns = columnInfos(fValidators)
+
+	switch {
+	case strings.TrimSpace(query) == "" && opts.withAllowEmptyQuery:
+		c.Valid = true
+		return c
+	case query == "":
+		addErr(fmt.Errorf("missing query: %w", ErrInvalidParameter), -1)
+		return c
+	}
+	e, pErrs := recoverExpr(query, opts)
+	for _, pErr := range pErrs {
+		addErr(pErr, pErr.Pos)
+	}
+	walkExpr(e, func(e Expr) {
+		v, ok := e.(*ComparisonExpr)
+		if !ok {
+			return
+		}
+		if _, err := exprToWhereClause(v, fValidators, opt...); err != nil {
+			addErr(err, v.pos)
+		}
+	})
+	if len(c.Errors) > 0 {
+		sort.SliceStable(c.Errors, func(i, j int) bool { return c.Errors[i].Pos < c.Errors[j].Pos })
+		return c
+	}
+	c.Valid = true
+	diags, err := Lint(query, struct{}{}, opt...)
+	if err != nil {
+		return c
+	}
+	for _, d := range diags {
+		c.Warnings = append(c.Warnings, QueryIssue{Message: d.Message, Kind: string(d.Kind), Pos: d.Pos})
+	}
+	return c
+}
+
+// fieldsDescriber is a ModelDescriber which describes any model using the
+// fields
+type fieldsDescriber []FieldDescriptor
+
+// DescribeModel returns the fields
+func (d fieldsDescriber) DescribeModel(any) ([]FieldDescriptor, error) {
+	return d, nil
+}
+
+// columnInfos returns the columns of the validators sorted by their name
+func columnInfos(fValidators map[string]validator) []ColumnInfo {
+	columns := make([]ColumnInfo, 0, len(fValidators))
+	for _, v := range fValidators {
+		columns = append(columns, columnInfo(v))
+	}
+	sort.Slice(columns, func(i, j int) bool { return columns[i].Column < columns[j].Column })
+	return columns
+}
+
+// columnInfo returns the column of the validator
+func columnInfo(v validator) ColumnInfo {
+	c := ColumnInfo{
+		Column: snakeCase(v.field.Name),
+		Field:  v.field.Name,
+		Type:   v.typ,
+		Map:    v.typ == "map",
+		Array:  v.typ == "array",
+	}
+	if c.Map || c.Array {
+		c.Type = v.elemTyp
+	}
+	c.ComparisonOps = typeComparisonOps(c.Type)
+	if c.Array {
+		c.ComparisonOps = []ComparisonOp{ArrayContainsOp}
+	}
+	if c.Type == "default" {
+		c.Type = "string"
+	}
+	return c
+}
+
+// typeComparisonOps returns the comparison operators which can be used with
+// a validator type
+func typeComparisonOps(typ string) []ComparisonOp {
+	if ft, ok := lookupFieldType(typ); ok {
+		var ops []ComparisonOp
+		for _, o := range ComparisonOps() {
+			switch {
+			case o == ArrayContainsOp:
+			case (o == ContainedByOp || o == SimilarToOp) && !slices.Contains(ft.handler.ComparisonOps, o):
+			case ft.allows(o):
+				ops = append(ops, o)
+			}
+		}
+		return ops
+	}
+	ordered := []ComparisonOp{EqualOp, NotEqualOp, GreaterThanOp, GreaterThanOrEqualOp, LessThanOp, LessThanOrEqualOp}
+	switch typ {
+	case "bool":
+		return []ComparisonOp{EqualOp, NotEqualOp}
+	case "time", "duration", "decimal":
+		return ordered
+	case "ip":
+		return append(ordered, ContainedByOp)
+	case "default":
+		return append(ordered, ContainsOp, SimilarToOp)
+	default:
+		return append(ordered, ContainsOp)
+	}
+}
+
+// snakeCase returns the name in snake_case (ie: CreatedAt is created_at and
+// UserID is user_id)
+func snakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) && runes[i-1] != '_' {
+			prevLower := unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1])
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if prevLower || (unicode.IsUpper(runes[i-1]) && nextLower) {
+				b.WriteRune('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
@@ -0,0 +1,126 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/hashicorp/mql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckQuery(t *testing.T) {
+	t.Parallel()
+	fields := []mql.FieldDescriptor{
+		{Name: "Name", Type: "string"},
+		{Name: "Age", Type: "int"},
+		{Name: "UserID", Type: "uint64"},
+		{Name: "CreatedAt", Type: "*time.Time"},
+		{Name: "IPAddress", Type: "netip.Addr"},
+		{Name: "Active", Type: "bool"},
+		{Name: "Labels", Type: "map[string]string"},
+		{Name: "Tags", Type: "[]string"},
+	}
+	ordered := []mql.ComparisonOp{mql.EqualOp, mql.NotEqualOp, mql.GreaterThanOp, mql.GreaterThanOrEqualOp, mql.LessThanOp, mql.LessThanOrEqualOp}
+	wantColumns := []mql.ColumnInfo{
+		{Column: "active", Field: "Active", Type: "bool", ComparisonOps: []mql.ComparisonOp{mql.EqualOp, mql.NotEqualOp}},
+		{Column: "age", Field: "Age", Type: "int", ComparisonOps: append(ordered, mql.ContainsOp)},
+		{Column: "created_at", Field: "CreatedAt", Type: "time", ComparisonOps: ordered},
+		{Column: "ip_address", Field: "IPAddress", Type: "ip", ComparisonOps: append(ordered, mql.ContainedByOp)},
+		{Column: "labels", Field: "Labels", Type: "string", Map: true, ComparisonOps: append(ordered, mql.ContainsOp, mql.SimilarToOp)},
+		{Column: "name", Field: "Name", Type: "string", ComparisonOps: append(ordered, mql.ContainsOp, mql.SimilarToOp)},
+		{Column: "tags", Field: "Tags", Type: "string", Array: true, ComparisonOps: []mql.ComparisonOp{mql.ArrayContainsOp}},
+		{Column: "user_id", Field: "UserID", Type: "int", ComparisonOps: append(ordered, mql.ContainsOp)},
+	}
+	tests := []struct {
+		name               string
+		query              string
+		opts               []mql.Option
+		wantValid          bool
+		wantErrors         []mql.QueryIssue
+		wantErrorsContain  []string
+		wantWarnings       []mql.QueryIssue
+		wantColumnsMissing bool
+	}{
+		{
+			name:      "valid",
+			query:     `name="alice" and user_id=1 and labels.env="prod"`,
+			wantValid: true,
+		},
+		{
+			name:         "valid-with-warning",
+			query:        `(name="alice")`,
+			wantValid:    true,
+			wantWarnings: []mql.QueryIssue{{Message: "parentheses around the entire query are redundant", Kind: string(mql.RedundantParensDiagnostic), Pos: 0}},
+		},
+		{
+			name:      "empty-query-allowed",
+			query:     " ",
+			opts:      []mql.Option{mql.WithAllowEmptyQuery()},
+			wantValid: true,
+		},
+		{
+			name:              "missing-query",
+			wantErrors:        []mql.QueryIssue{{Pos: -1}},
+			wantErrorsContain: []string{"missing query"},
+		},
+		{
+			name:              "parse-error",
+			query:             `name="alice" and age>`,
+			wantErrors:        []mql.QueryIssue{{Pos: 21}},
+			wantErrorsContain: []string{"missing comparison value"},
+		},
+		{
+			name:              "every-syntax-error",
+			query:             `email="alice" and name= or age>`,
+			wantErrors:        []mql.QueryIssue{{Pos: 0}, {Pos: 24}, {Pos: 31}},
+			wantErrorsContain: []string{`invalid column "email"`, "missing comparison value", "missing comparison value"},
+		},
+		{
+			name:              "every-invalid-comparison",
+			query:             `email="alice" and age="old" and name="alice"`,
+			wantErrors:        []mql.QueryIssue{{Pos: 0}, {Pos: 18}},
+			wantErrorsContain: []string{`invalid column "email"`, `"old" in`},
+		},
+		{
+			name:               "invalid-option",
+			query:              `name="alice"`,
+			opts:               []mql.Option{mql.WithTableName("")},
+			wantErrors:         []mql.QueryIssue{{Pos: -1}},
+			wantErrorsContain:  []string{"missing table"},
+			wantColumnsMissing: true,
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			assert, require := assert.New(t), require.New(t)
+			got := mql.CheckQuery(tc.query, fields, tc.opts...)
+			assert.Equal(tc.wantValid, got.Valid)
+			require.Len(got.Errors, len(tc.wantErrors))
+			for i, e := range tc.wantErrors {
+				assert.Equal(e.Pos, got.Errors[i].Pos)
+				assert.Contains(got.Errors[i].Message, tc.wantErrorsContain[i])
+			}
+			assert.Equal(tc.wantWarnings, got.Warnings)
+			if tc.wantColumnsMissing {
+				assert.Empty(got.Columns)
+				return
+			}
+			assert.Equal(wantColumns, got.Columns)
+		})
+	}
+	t.Run("json", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		b, err := json.Marshal(mql.CheckQuery(`age>`, []mql.FieldDescriptor{{Name: "Age", Type: "int"}}))
+		require.NoError(err)
+		assert.JSONEq(`{
+			"valid": false,
+			"errors": [{"message": "mql.CheckQuery: mql.(parser).parse: parseLogicalExpr: mql.(parser).parseComparisonExpr: missing comparison value in: \"age>\"", "pos": 4}],
+			"columns": [{"column": "age", "field": "Age", "type": "int", "comparison_ops": ["=", "!=", ">", ">=", "<", "<=", "%"]}]
+		}`, string(b))
+	})
+}
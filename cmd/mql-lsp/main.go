@@ -0,0 +1,54 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+// mql-lsp is a Language Server Protocol server for mql queries, so editors
+// (and embedded editors like Monaco) can offer diagnostics, hovers and
+// completions for filters using the same parser as the server.  Every
+// document is a query and it communicates using JSON-RPC over stdin/stdout.
+//
+// The model is described by a JSON schema file:
+//
+//	{
+//	  "fields": [{"name": "Name", "type": "string"}, {"name": "Status", "type": "string"}],
+//	  "enums": {"status": ["active", "archived"]},
+//	  "column_map": {"nickname": "name"}
+//	}
+//
+// or by the -fields flag (ie: -fields Name:string,Age:int):
+//
+//	go run github.com/hashicorp/mql/cmd/mql-lsp -schema schema.json
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+)
+
+var (
+	errInvalidSchema = errors.New("invalid schema")
+	errInvalidFields = errors.New("invalid fields")
+)
+
+func main() {
+	schemaFile := flag.String("schema", "", "path of the JSON file which describes the model (fields, enums and column_map)")
+	fields := flag.String("fields", "", "comma separated list of the model's fields and their go types (ie: Name:string,Age:int), which are added to the schema's fields")
+	flag.Parse()
+
+	s, err := loadSchema(*schemaFile, *fields)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "mql-lsp: %s\n", err)
+		os.Exit(1)
+	}
+	srv, err := newServer(bufio.NewReader(os.Stdin), os.Stdout, s)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "mql-lsp: %s\n", err)
+		os.Exit(1)
+	}
+	if err := srv.run(); err != nil {
+		fmt.Fprintf(os.Stderr, "mql-lsp: %s\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/mql"
+)
+
+// schema describes the model of the queries
+type schema struct {
+	// Fields of the model, which are decoded from {"name": ..., "type": ...}
+	Fields []mql.FieldDescriptor `json:"fields"`
+
+	// Enums are the values of the enum columns (see mql.WithEnum)
+	Enums map[string][]string `json:"enums"`
+
+	// ColumnMap maps query columns to columns (see mql.WithColumnMap)
+	ColumnMap map[string]string `json:"column_map"`
+}
+
+// loadSchema reads the schema from the file (if one is provided) and adds the
+// fields, which are a comma separated list of name:type
+func loadSchema(file, fields string) (*schema, error) {
+	const op = "loadSchema"
+	s := &schema{}
+	if file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		if err := json.Unmarshal(data, s); err != nil {
+			return nil, fmt.Errorf("%s: %s: %w: %s", op, file, errInvalidSchema, err)
+		}
+	}
+	f, err := parseFields(fields)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	s.Fields = append(s.Fields, f...)
+	if len(s.Fields) == 0 {
+		return nil, fmt.Errorf("%s: missing fields (see -schema and -fields): %w", op, errInvalidSchema)
+	}
+	for _, f := range s.Fields {
+		if f.Name == "" || f.Type == "" {
+			return nil, fmt.Errorf("%s: field %q of type %q (expected a name and type): %w", op, f.Name, f.Type, errInvalidSchema)
+		}
+	}
+	return s, nil
+}
+
+// options returns the mql options of the schema
+func (s *schema) options() []mql.Option {
+	opts := []mql.Option{mql.WithAllowEmptyQuery()}
+	if len(s.ColumnMap) > 0 {
+		opts = append(opts, mql.WithColumnMap(s.ColumnMap))
+	}
+	for column, values := range s.Enums {
+		opts = append(opts, mql.WithEnum(column, values))
+	}
+	return opts
+}
+
+// parseFields parses a comma separated list of the model's fields and their
+// go types (ie: Name:string,Age:int)
+func parseFields(s string) ([]mql.FieldDescriptor, error) {
+	const op = "parseFields"
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	var fields []mql.FieldDescriptor
+	for _, f := range strings.Split(s, ",") {
+		name, typ, ok := strings.Cut(strings.TrimSpace(f), ":")
+		name, typ = strings.TrimSpace(name), strings.TrimSpace(typ)
+		if !ok || name == "" || typ == "" {
+			return nil, fmt.Errorf("%s: %q (expected name:type): %w", op, f, errInvalidFields)
+		}
+		fields = append(fields, mql.FieldDescriptor{Name: name, Type: typ})
+	}
+	return fields, nil
+}
+
+// fieldsDescriber is a mql.ModelDescriber which describes any model using the
+// fields of the schema
+type fieldsDescriber []mql.FieldDescriptor
+
+// DescribeModel returns the fields
+func (d fieldsDescriber) DescribeModel(any) ([]mql.FieldDescriptor, error) {
+	return d, nil
+}
@@ -0,0 +1,504 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/hashicorp/mql"
+)
+
+var (
+	errExitWithoutShutdown = errors.New("exit without shutdown")
+	errInvalidMessage      = errors.New("invalid message")
+)
+
+// JSON-RPC error codes
+const (
+	codeParseError     = -32700
+	codeInvalidParams  = -32602
+	codeMethodNotFound = -32601
+)
+
+// LSP enums
+const (
+	textDocumentSyncFull = 1
+
+	severityError   = 1
+	severityWarning = 2
+
+	completionItemKindValue    = 12
+	completionItemKindKeyword  = 14
+	completionItemKindField    = 5
+	completionItemKindOperator = 24
+)
+
+// opPrefix matches the op of the errors returned by mql (ie: "mql.Parse: "),
+// which isn't useful in an editor
+var opPrefix = regexp.MustCompile(`mql\.[\w().]+: `)
+
+// message is a JSON-RPC request or notification (which doesn't have an ID)
+type message struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id,omitempty"`
+	Method  string           `json:"method"`
+	Params  json.RawMessage  `json:"params,omitempty"`
+}
+
+// response is a JSON-RPC response, which has either a result (which may be
+// null) or an error
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *responseError  `json:"error,omitempty"`
+}
+
+type responseError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+type textRange struct {
+	Start position `json:"start"`
+	End   position `json:"end"`
+}
+
+type textDocumentIdentifier struct {
+	URI string `json:"uri"`
+}
+
+type textDocumentPositionParams struct {
+	TextDocument textDocumentIdentifier `json:"textDocument"`
+	Position     position               `json:"position"`
+}
+
+type diagnostic struct {
+	Range    textRange `json:"range"`
+	Severity int       `json:"severity"`
+	Code     string    `json:"code,omitempty"`
+	Source   string    `json:"source"`
+	Message  string    `json:"message"`
+}
+
+type completionItem struct {
+	Label    string   `json:"label"`
+	Kind     int      `json:"kind"`
+	Detail   string   `json:"detail,omitempty"`
+	TextEdit textEdit `json:"textEdit"`
+}
+
+type textEdit struct {
+	Range   textRange `json:"range"`
+	NewText string    `json:"newText"`
+}
+
+type hover struct {
+	Contents markupContent `json:"contents"`
+	Range    textRange     `json:"range"`
+}
+
+type markupContent struct {
+	Kind  string `json:"kind"`
+	Value string `json:"value"`
+}
+
+// server is a LSP server for mql queries, where every document is a query
+type server struct {
+	in  *bufio.Reader
+	out io.Writer
+
+	schema  *schema
+	opts    []mql.Option
+	columns []mql.ColumnInfo
+
+	// docs are the text of the open documents by their URI
+	docs     map[string]string
+	shutdown bool
+}
+
+// newServer returns a server which reads messages from in and writes them to
+// out.  The schema's options are validated against its fields.
+func newServer(in *bufio.Reader, out io.Writer, s *schema) (*server, error) {
+	const op = "newServer"
+	opts := append(s.options(), mql.WithModelDescriber(fieldsDescriber(s.Fields)))
+	if err := mql.ValidateOptions(struct{}{}, opts...); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return &server{
+		in:      in,
+		out:     out,
+		schema:  s,
+		opts:    opts,
+		columns: mql.CheckQuery("", s.Fields, opts...).Columns,
+		docs:    map[string]string{},
+	}, nil
+}
+
+// run reads and handles messages until the exit notification is received or
+// the input ends
+func (s *server) run() error {
+	const op = "run"
+	for {
+		m, err := s.read()
+		switch {
+		case errors.Is(err, io.EOF):
+			return nil
+		case errors.Is(err, errInvalidMessage):
+			if err := s.reply(nil, nil, &responseError{Code: codeParseError, Message: err.Error()}); err != nil {
+				return fmt.Errorf("%s: %w", op, err)
+			}
+			continue
+		case err != nil:
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		if m.Method == "exit" {
+			if !s.shutdown {
+				return fmt.Errorf("%s: %w", op, errExitWithoutShutdown)
+			}
+			return nil
+		}
+		if err := s.handle(m); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+}
+
+// handle dispatches a request or notification.  Unknown notifications are
+// ignored.
+func (s *server) handle(m *message) error {
+	var (
+		result any
+		rErr   *responseError
+	)
+	switch m.Method {
+	case "initialize":
+		result = map[string]any{
+			"capabilities": map[string]any{
+				"textDocumentSync": textDocumentSyncFull,
+				"hoverProvider":    true,
+				"completionProvider": map[string]any{
+					"triggerCharacters": []string{" ", "(", "=", "<", ">", "!", "\"", "'"},
+				},
+			},
+			"serverInfo": map[string]any{"name": "mql-lsp"},
+		}
+	case "shutdown":
+		s.shutdown = true
+	case "textDocument/didOpen":
+		var p struct {
+			TextDocument struct {
+				URI  string `json:"uri"`
+				Text string `json:"text"`
+			} `json:"textDocument"`
+		}
+		if err := json.Unmarshal(m.Params, &p); err != nil {
+			return nil
+		}
+		s.docs[p.TextDocument.URI] = p.TextDocument.Text
+		return s.publishDiagnostics(p.TextDocument.URI)
+	case "textDocument/didChange":
+		var p struct {
+			TextDocument   textDocumentIdentifier `json:"textDocument"`
+			ContentChanges []struct {
+				Text string `json:"text"`
+			} `json:"contentChanges"`
+		}
+		if err := json.Unmarshal(m.Params, &p); err != nil || len(p.ContentChanges) == 0 {
+			return nil
+		}
+		// documents are synced in full, so the last change is the document
+		s.docs[p.TextDocument.URI] = p.ContentChanges[len(p.ContentChanges)-1].Text
+		return s.publishDiagnostics(p.TextDocument.URI)
+	case "textDocument/didClose":
+		var p struct {
+			TextDocument textDocumentIdentifier `json:"textDocument"`
+		}
+		if err := json.Unmarshal(m.Params, &p); err != nil {
+			return nil
+		}
+		delete(s.docs, p.TextDocument.URI)
+		return s.notify("textDocument/publishDiagnostics", map[string]any{"uri": p.TextDocument.URI, "diagnostics": []diagnostic{}})
+	case "textDocument/hover", "textDocument/completion":
+		var p textDocumentPositionParams
+		if err := json.Unmarshal(m.Params, &p); err != nil {
+			rErr = &responseError{Code: codeInvalidParams, Message: err.Error()}
+			break
+		}
+		doc := s.docs[p.TextDocument.URI]
+		offset := offsetOf(doc, p.Position)
+		if m.Method == "textDocument/hover" {
+			result = s.hover(doc, offset)
+		} else {
+			result = s.complete(doc, offset)
+		}
+	default:
+		if m.ID == nil {
+			return nil
+		}
+		rErr = &responseError{Code: codeMethodNotFound, Message: fmt.Sprintf("method not found: %s", m.Method)}
+	}
+	if m.ID == nil {
+		return nil
+	}
+	return s.reply(m.ID, result, rErr)
+}
+
+// publishDiagnostics publishes the errors and warnings of the document's query
+func (s *server) publishDiagnostics(uri string) error {
+	doc := s.docs[uri]
+	check := mql.CheckQuery(doc, s.schema.Fields, s.opts...)
+	diags := []diagnostic{}
+	add := func(issues []mql.QueryIssue, severity int) {
+		for _, i := range issues {
+			start := i.Pos
+			if start < 0 || start > len(doc) {
+				start = 0
+			}
+			end := start + len(wordAt(doc, start))
+			if end == start && end < len(doc) {
+				_, size := utf8.DecodeRuneInString(doc[end:])
+				end += size
+			}
+			diags = append(diags, diagnostic{
+				Range:    textRange{Start: positionOf(doc, start), End: positionOf(doc, end)},
+				Severity: severity,
+				Code:     i.Kind,
+				Source:   "mql",
+				Message:  opPrefix.ReplaceAllString(i.Message, ""),
+			})
+		}
+	}
+	add(check.Errors, severityError)
+	add(check.Warnings, severityWarning)
+	return s.notify("textDocument/publishDiagnostics", map[string]any{"uri": uri, "diagnostics": diags})
+}
+
+// hover returns the type info of the column at the offset and nil when there
+// isn't a column at the offset
+func (s *server) hover(doc string, offset int) *hover {
+	start := offset
+	for start > 0 {
+		r, size := utf8.DecodeLastRuneInString(doc[:start])
+		if !isWordRune(r) {
+			break
+		}
+		start -= size
+	}
+	word := wordAt(doc, start)
+	if word == "" {
+		return nil
+	}
+	column, _, _ := strings.Cut(word, ".")
+	c, ok := s.column(column)
+	if !ok {
+		return nil
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "**%s** `%s`", c.Column, c.Type)
+	switch {
+	case c.Map:
+		b.WriteString(" (map, queried by key: " + c.Column + ".<key>)")
+	case c.Array:
+		b.WriteString(" (array)")
+	}
+	fmt.Fprintf(&b, "\n\nfield: `%s`\n\noperators:", c.Field)
+	for _, o := range c.ComparisonOps {
+		fmt.Fprintf(&b, " `%s`", o)
+	}
+	for enumColumn, values := range s.schema.Enums {
+		if normalize(enumColumn) == normalize(c.Field) {
+			b.WriteString("\n\nvalues: " + strings.Join(values, ", "))
+		}
+	}
+	return &hover{
+		Contents: markupContent{Kind: "markdown", Value: b.String()},
+		Range:    textRange{Start: positionOf(doc, start), End: positionOf(doc, start+len(word))},
+	}
+}
+
+// column returns the column info of a query column, which may be mapped to
+// a column (see schema.ColumnMap)
+func (s *server) column(queryColumn string) (mql.ColumnInfo, bool) {
+	for k, v := range s.schema.ColumnMap {
+		if strings.EqualFold(k, queryColumn) {
+			queryColumn = v
+			break
+		}
+	}
+	for _, c := range s.columns {
+		if normalize(c.Column) == normalize(queryColumn) {
+			return c, true
+		}
+	}
+	return mql.ColumnInfo{}, false
+}
+
+// complete returns the completion items at the offset of the document
+func (s *server) complete(doc string, offset int) []completionItem {
+	items := []completionItem{}
+	for _, c := range mql.Complete(doc, offset, struct{}{}, s.opts...) {
+		item := completionItem{
+			Label:  strings.TrimSpace(c.Text),
+			Detail: c.Detail,
+			TextEdit: textEdit{
+				Range:   textRange{Start: positionOf(doc, c.Start), End: positionOf(doc, c.End)},
+				NewText: c.Text,
+			},
+		}
+		switch c.Kind {
+		case mql.ColumnCompletion:
+			item.Kind = completionItemKindField
+		case mql.ComparisonOpCompletion:
+			item.Kind = completionItemKindOperator
+		case mql.ValueCompletion:
+			item.Kind = completionItemKindValue
+		case mql.LogicalOpCompletion:
+			item.Kind = completionItemKindKeyword
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+// read reads a message, which has a Content-Length header
+func (s *server) read() (*message, error) {
+	const op = "read"
+	header, err := textproto.NewReader(s.in).ReadMIMEHeader()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, io.EOF
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	length, err := strconv.Atoi(header.Get("Content-Length"))
+	if err != nil || length < 0 {
+		return nil, fmt.Errorf("%s: %w: invalid Content-Length %q", op, errInvalidMessage, header.Get("Content-Length"))
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(s.in, body); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	var m message
+	if err := json.Unmarshal(body, &m); err != nil {
+		return nil, fmt.Errorf("%s: %w: %s", op, errInvalidMessage, err)
+	}
+	return &m, nil
+}
+
+// reply writes the response to a request, whose ID is null when the request
+// couldn't be read
+func (s *server) reply(id *json.RawMessage, result any, rErr *responseError) error {
+	const op = "reply"
+	r := response{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: rErr}
+	if id != nil {
+		r.ID = *id
+	}
+	if rErr == nil {
+		var err error
+		if r.Result, err = json.Marshal(result); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+	return s.write(r)
+}
+
+// notify writes a notification
+func (s *server) notify(method string, params any) error {
+	const op = "notify"
+	p, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return s.write(message{JSONRPC: "2.0", Method: method, Params: p})
+}
+
+// write writes the message with its Content-Length header
+func (s *server) write(m any) error {
+	const op = "write"
+	body, err := json.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if _, err := fmt.Fprintf(s.out, "Content-Length: %d\r\n\r\n%s", len(body), body); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// offsetOf returns the byte offset of the position (whose character is in
+// UTF-16 code units) in the text
+func offsetOf(text string, p position) int {
+	offset := 0
+	for line := 0; line < p.Line; line++ {
+		i := strings.IndexByte(text[offset:], '\n')
+		if i < 0 {
+			return len(text)
+		}
+		offset += i + 1
+	}
+	for units := 0; offset < len(text) && units < p.Character; {
+		r, size := utf8.DecodeRuneInString(text[offset:])
+		if r == '\n' {
+			break
+		}
+		units += utf16.RuneLen(r)
+		offset += size
+	}
+	return offset
+}
+
+// positionOf returns the position (whose character is in UTF-16 code units)
+// of the byte offset in the text
+func positionOf(text string, offset int) position {
+	var p position
+	for _, r := range text[:offset] {
+		if r == '\n' {
+			p.Line++
+			p.Character = 0
+			continue
+		}
+		p.Character += utf16.RuneLen(r)
+	}
+	return p
+}
+
+// wordAt returns the word (ie: a column) which starts at the offset
+func wordAt(text string, offset int) string {
+	end := offset
+	for end < len(text) {
+		r, size := utf8.DecodeRuneInString(text[end:])
+		if !isWordRune(r) {
+			break
+		}
+		end += size
+	}
+	return text[offset:end]
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '.'
+}
+
+// normalize returns the column in lowercase without underscores, since
+// columns are case insensitive and their underscores are optional
+func normalize(column string) string {
+	return strings.ToLower(strings.ReplaceAll(column, "_", ""))
+}
@@ -0,0 +1,233 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/mql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testSchema() *schema {
+	return &schema{
+		Fields: []mql.FieldDescriptor{
+			{Name: "Name", Type: "string"},
+			{Name: "Status", Type: "string"},
+			{Name: "Age", Type: "int"},
+			{Name: "Labels", Type: "map[string]string"},
+		},
+		Enums:     map[string][]string{"status": {"active", "archived"}},
+		ColumnMap: map[string]string{"nickname": "name"},
+	}
+}
+
+// session runs the server with the messages (which are framed using their
+// Content-Length) and returns the messages it wrote
+func session(t *testing.T, s *schema, msgs ...string) ([]map[string]any, error) {
+	t.Helper()
+	var in bytes.Buffer
+	for _, m := range msgs {
+		fmt.Fprintf(&in, "Content-Length: %d\r\n\r\n%s", len(m), m)
+	}
+	var out bytes.Buffer
+	srv, err := newServer(bufio.NewReader(&in), &out, s)
+	require.NoError(t, err)
+	runErr := srv.run()
+
+	var written []map[string]any
+	r := bufio.NewReader(&out)
+	for {
+		var length int
+		if _, err := fmt.Fscanf(r, "Content-Length: %d\r\n\r\n", &length); err != nil {
+			break
+		}
+		body := make([]byte, length)
+		_, err := io.ReadFull(r, body)
+		require.NoError(t, err)
+		var m map[string]any
+		require.NoError(t, json.Unmarshal(body, &m))
+		written = append(written, m)
+	}
+	return written, runErr
+}
+
+func Test_server(t *testing.T) {
+	t.Parallel()
+	const (
+		initialize = `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`
+		shutdown   = `{"jsonrpc":"2.0","id":99,"method":"shutdown"}`
+		exit       = `{"jsonrpc":"2.0","method":"exit"}`
+	)
+	open := func(text string) string {
+		b, _ := json.Marshal(text)
+		return fmt.Sprintf(`{"jsonrpc":"2.0","method":"textDocument/didOpen","params":{"textDocument":{"uri":"file:///q.mql","text":%s}}}`, b)
+	}
+	t.Run("lifecycle", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		got, err := session(t, testSchema(), initialize, `{"jsonrpc":"2.0","method":"initialized","params":{}}`, shutdown, exit)
+		require.NoError(err)
+		require.Len(got, 2)
+		caps := got[0]["result"].(map[string]any)["capabilities"].(map[string]any)
+		assert.Equal(float64(textDocumentSyncFull), caps["textDocumentSync"])
+		assert.Equal(true, caps["hoverProvider"])
+		assert.Contains(got[1], "result")
+		assert.Nil(got[1]["result"])
+		assert.Equal(float64(99), got[1]["id"])
+	})
+	t.Run("exit-without-shutdown", func(t *testing.T) {
+		_, err := session(t, testSchema(), initialize, exit)
+		assert.ErrorIs(t, err, errExitWithoutShutdown)
+	})
+	t.Run("diagnostics", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		got, err := session(t, testSchema(),
+			open("name=\"alice\" and\n  emal=\"x\""),
+			`{"jsonrpc":"2.0","method":"textDocument/didChange","params":{"textDocument":{"uri":"file:///q.mql"},"contentChanges":[{"text":"name=\"alice\""}]}}`,
+			`{"jsonrpc":"2.0","method":"textDocument/didClose","params":{"textDocument":{"uri":"file:///q.mql"}}}`,
+		)
+		require.NoError(err)
+		require.Len(got, 3)
+		for _, m := range got {
+			assert.Equal("textDocument/publishDiagnostics", m["method"])
+		}
+		diags := got[0]["params"].(map[string]any)["diagnostics"].([]any)
+		require.Len(diags, 1)
+		d := diags[0].(map[string]any)
+		assert.Equal(float64(severityError), d["severity"])
+		assert.Equal(map[string]any{
+			"start": map[string]any{"line": float64(1), "character": float64(2)},
+			"end":   map[string]any{"line": float64(1), "character": float64(6)},
+		}, d["range"])
+		assert.True(strings.HasPrefix(d["message"].(string), `invalid column "emal"`), d["message"])
+		assert.Empty(got[1]["params"].(map[string]any)["diagnostics"])
+		assert.Empty(got[2]["params"].(map[string]any)["diagnostics"])
+	})
+	t.Run("hover", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		got, err := session(t, testSchema(),
+			open(`nickname="alice" and status="active"`),
+			`{"jsonrpc":"2.0","id":2,"method":"textDocument/hover","params":{"textDocument":{"uri":"file:///q.mql"},"position":{"line":0,"character":3}}}`,
+			`{"jsonrpc":"2.0","id":3,"method":"textDocument/hover","params":{"textDocument":{"uri":"file:///q.mql"},"position":{"line":0,"character":23}}}`,
+			`{"jsonrpc":"2.0","id":4,"method":"textDocument/hover","params":{"textDocument":{"uri":"file:///q.mql"},"position":{"line":0,"character":16}}}`,
+		)
+		require.NoError(err)
+		require.Len(got, 4)
+		h := got[1]["result"].(map[string]any)
+		assert.Equal("**name** `string`\n\nfield: `Name`\n\noperators: `=` `!=` `>` `>=` `<` `<=` `%` `~%`", h["contents"].(map[string]any)["value"])
+		assert.Equal(map[string]any{
+			"start": map[string]any{"line": float64(0), "character": float64(0)},
+			"end":   map[string]any{"line": float64(0), "character": float64(8)},
+		}, h["range"])
+		h = got[2]["result"].(map[string]any)
+		assert.Contains(h["contents"].(map[string]any)["value"], "values: active, archived")
+		assert.Nil(got[3]["result"])
+	})
+	t.Run("completion", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		got, err := session(t, testSchema(),
+			open(`status="active" and n`),
+			`{"jsonrpc":"2.0","id":2,"method":"textDocument/completion","params":{"textDocument":{"uri":"file:///q.mql"},"position":{"line":0,"character":21}}}`,
+		)
+		require.NoError(err)
+		require.Len(got, 2)
+		items := got[1]["result"].([]any)
+		require.Len(items, 2)
+		assert.Equal(map[string]any{
+			"label":  "name",
+			"kind":   float64(completionItemKindField),
+			"detail": "string",
+			"textEdit": map[string]any{
+				"range": map[string]any{
+					"start": map[string]any{"line": float64(0), "character": float64(20)},
+					"end":   map[string]any{"line": float64(0), "character": float64(21)},
+				},
+				"newText": "name",
+			},
+		}, items[0])
+		assert.Equal("nickname", items[1].(map[string]any)["label"])
+	})
+	t.Run("errors", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		got, err := session(t, testSchema(),
+			`{"jsonrpc":"2.0","id":2,"method":"textDocument/definition","params":{}}`,
+			`{"jsonrpc":"2.0","method":"$/cancelRequest","params":{"id":1}}`,
+			`{"jsonrpc":"2.0","id":3,"method":"textDocument/hover","params":[]}`,
+			`not json`,
+		)
+		require.NoError(err)
+		require.Len(got, 3)
+		assert.Equal(float64(codeMethodNotFound), got[0]["error"].(map[string]any)["code"])
+		assert.NotContains(got[0], "result")
+		assert.Equal(float64(codeInvalidParams), got[1]["error"].(map[string]any)["code"])
+		assert.Equal(float64(codeParseError), got[2]["error"].(map[string]any)["code"])
+		assert.Nil(got[2]["id"])
+	})
+}
+
+func Test_offsetOf(t *testing.T) {
+	t.Parallel()
+	text := "a=\"é😀\"\nb=1"
+	for offset := 0; offset <= len(text); offset++ {
+		p := positionOf(text, offset)
+		// offsets inside a multi-byte rune are rounded up to the next rune
+		assert.GreaterOrEqual(t, offsetOf(text, p), offset)
+	}
+	assert.Equal(t, position{Line: 0, Character: 6}, positionOf(text, len("a=\"é😀")))
+	assert.Equal(t, len("a=\"é😀"), offsetOf(text, position{Line: 0, Character: 6}))
+	assert.Equal(t, len(text), offsetOf(text, position{Line: 5}))
+	assert.Equal(t, len("a=\"é😀\""), offsetOf(text, position{Line: 0, Character: 99}))
+}
+
+func Test_loadSchema(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	write := func(name, data string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(data), 0o644))
+		return path
+	}
+	t.Run("file-and-fields", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		path := write("schema.json", `{"fields":[{"name":"Name","type":"string"}],"enums":{"name":["a"]},"column_map":{"n":"name"}}`)
+		got, err := loadSchema(path, "Age:int")
+		require.NoError(err)
+		assert.Equal(&schema{
+			Fields:    []mql.FieldDescriptor{{Name: "Name", Type: "string"}, {Name: "Age", Type: "int"}},
+			Enums:     map[string][]string{"name": {"a"}},
+			ColumnMap: map[string]string{"n": "name"},
+		}, got)
+	})
+	t.Run("err-invalid-json", func(t *testing.T) {
+		_, err := loadSchema(write("invalid.json", `{"fields":`), "")
+		assert.ErrorIs(t, err, errInvalidSchema)
+	})
+	t.Run("err-missing-fields", func(t *testing.T) {
+		_, err := loadSchema("", "")
+		assert.ErrorIs(t, err, errInvalidSchema)
+	})
+	t.Run("err-missing-type", func(t *testing.T) {
+		_, err := loadSchema(write("missing-type.json", `{"fields":[{"name":"Name"}]}`), "")
+		assert.ErrorIs(t, err, errInvalidSchema)
+	})
+	t.Run("err-invalid-fields", func(t *testing.T) {
+		_, err := loadSchema("", "Name")
+		assert.ErrorIs(t, err, errInvalidFields)
+	})
+	t.Run("err-invalid-column-map", func(t *testing.T) {
+		s := testSchema()
+		s.ColumnMap = map[string]string{"nickname": "nick_name"}
+		_, err := newServer(bufio.NewReader(strings.NewReader("")), &bytes.Buffer{}, s)
+		assert.ErrorIs(t, err, mql.ErrInvalidColumnMap)
+	})
+}
@@ -0,0 +1,348 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"unicode/utf8"
+
+	"github.com/hashicorp/mql"
+)
+
+const (
+	prompt             = "mql> "
+	continuationPrompt = "...> "
+	continuationDelim  = `\`
+)
+
+var (
+	errInvalidFields = errors.New("invalid fields")
+	errMissingTable  = errors.New("missing table")
+)
+
+// repl reads queries and prints their tokens, expr tree and where clause.
+// Queries are executed when it has a db.
+type repl struct {
+	out io.Writer
+
+	// fields describe the model used to validate queries.  Queries are only
+	// parsed (not validated) when there are no fields.
+	fields []mql.FieldDescriptor
+	opts   []mql.Option
+
+	db    *sql.DB
+	table string
+	limit int
+
+	showTokens bool
+	showExpr   bool
+}
+
+// fieldsDescriber describes the repl's model using the fields provided via the
+// -fields flag
+type fieldsDescriber []mql.FieldDescriptor
+
+// DescribeModel returns the fields
+func (d fieldsDescriber) DescribeModel(any) ([]mql.FieldDescriptor, error) {
+	return d, nil
+}
+
+// parseFields parses a comma separated list of fields and their types using
+// the same format as reflect.Type.String() (ie: Name:string,Age:int)
+func parseFields(s string) ([]mql.FieldDescriptor, error) {
+	const op = "parseFields"
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	var fields []mql.FieldDescriptor
+	for _, f := range strings.Split(s, ",") {
+		name, typ, ok := strings.Cut(strings.TrimSpace(f), ":")
+		name, typ = strings.TrimSpace(name), strings.TrimSpace(typ)
+		if !ok || name == "" || typ == "" {
+			return nil, fmt.Errorf("%s: %q (expected name:type): %w", op, f, errInvalidFields)
+		}
+		fields = append(fields, mql.FieldDescriptor{Name: name, Type: typ})
+	}
+	return fields, nil
+}
+
+// run reads queries (and commands) until the input ends or the .quit command
+// is read.  A line ending with a backslash is continued on the next line.
+func (r *repl) run(ctx context.Context, in io.Reader) error {
+	const op = "run"
+	s := bufio.NewScanner(in)
+	var query strings.Builder
+	fmt.Fprint(r.out, prompt)
+	for s.Scan() {
+		line := s.Text()
+		if strings.HasSuffix(line, continuationDelim) {
+			query.WriteString(strings.TrimSuffix(line, continuationDelim))
+			query.WriteString("\n")
+			fmt.Fprint(r.out, continuationPrompt)
+			continue
+		}
+		query.WriteString(line)
+		q := query.String()
+		query.Reset()
+		if quit := r.eval(ctx, q); quit {
+			return nil
+		}
+		fmt.Fprint(r.out, prompt)
+	}
+	if err := s.Err(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	fmt.Fprintln(r.out)
+	return nil
+}
+
+// eval evaluates a line, which is either a command (ie: .help) or a query,
+// and reports if the repl should quit
+func (r *repl) eval(ctx context.Context, line string) bool {
+	trimmed := strings.TrimSpace(line)
+	switch {
+	case trimmed == "":
+		return false
+	case strings.HasPrefix(trimmed, "."):
+		return r.command(trimmed)
+	}
+	r.query(ctx, line)
+	return false
+}
+
+// command runs a repl command and reports if the repl should quit
+func (r *repl) command(cmd string) bool {
+	switch cmd {
+	case ".quit", ".exit":
+		return true
+	case ".tokens":
+		r.showTokens = !r.showTokens
+		fmt.Fprintf(r.out, "tokens: %s\n", onOff(r.showTokens))
+	case ".expr":
+		r.showExpr = !r.showExpr
+		fmt.Fprintf(r.out, "expr: %s\n", onOff(r.showExpr))
+	case ".fields":
+		if len(r.fields) == 0 {
+			fmt.Fprintln(r.out, "no fields (queries are parsed, but not validated)")
+		}
+		for _, f := range r.fields {
+			fmt.Fprintf(r.out, "%s %s\n", f.Name, f.Type)
+		}
+	case ".help":
+		fmt.Fprint(r.out, help)
+	default:
+		fmt.Fprintf(r.out, "unknown command %s (see .help)\n", cmd)
+	}
+	return false
+}
+
+const help = `Enter a query (end a line with \ to continue it on the next line) or a command:
+  .tokens  toggle printing the query's tokens
+  .expr    toggle printing the query's expr tree
+  .fields  print the model's fields
+  .help    print this help
+  .quit    exit
+`
+
+func onOff(b bool) string {
+	if b {
+		return "on"
+	}
+	return "off"
+}
+
+// query prints the query's tokens, expr tree, where clause and diagnostics
+// and executes it (when the repl has a db)
+func (r *repl) query(ctx context.Context, query string) {
+	tokens := &tokenLogger{}
+	e, err := mql.ParseExpr(query, append(r.opts, mql.WithDebugLogger(tokens))...)
+	if r.showTokens {
+		r.printTokens(tokens.tokens)
+	}
+	if err != nil {
+		r.printError(query, err)
+		return
+	}
+	if r.showExpr {
+		fmt.Fprintln(r.out, "expr:")
+		printExpr(r.out, e, 1)
+	}
+	if len(r.fields) == 0 {
+		return
+	}
+	opts := append([]mql.Option{mql.WithModelDescriber(fieldsDescriber(r.fields))}, r.opts...)
+	if errs := mql.Validate(query, struct{}{}, opts...); len(errs) > 0 {
+		for _, err := range errs {
+			r.printError(query, err)
+		}
+		return
+	}
+	w, err := mql.Parse(query, struct{}{}, opts...)
+	if err != nil {
+		r.printError(query, err)
+		return
+	}
+	fmt.Fprintf(r.out, "where: %s\n", w.Condition)
+	fmt.Fprintf(r.out, "args: %v\n", w.Args)
+	if diags, err := mql.Lint(query, struct{}{}, opts...); err == nil {
+		for _, d := range diags {
+			r.printCaret(query, d.Pos)
+			fmt.Fprintf(r.out, "warning: %s: %s\n", d.Kind, d.Message)
+		}
+	}
+	if r.db != nil {
+		if err := r.exec(ctx, w); err != nil {
+			fmt.Fprintf(r.out, "error: %s\n", err)
+		}
+	}
+}
+
+// printError prints the error and a caret pointing where parsing failed
+// (when it's a *mql.ParseError)
+func (r *repl) printError(query string, err error) {
+	var pErr *mql.ParseError
+	if errors.As(err, &pErr) {
+		r.printCaret(query, pErr.Pos)
+	}
+	fmt.Fprintf(r.out, "error: %s\n", err)
+}
+
+// printCaret prints the line of the query which includes the byte offset pos
+// and a caret under it
+func (r *repl) printCaret(query string, pos int) {
+	if pos < 0 {
+		pos = 0
+	}
+	if pos > len(query) {
+		pos = len(query)
+	}
+	start := strings.LastIndex(query[:pos], "\n") + 1
+	end := len(query)
+	if i := strings.Index(query[pos:], "\n"); i >= 0 {
+		end = pos + i
+	}
+	fmt.Fprintf(r.out, "  %s\n", query[start:end])
+	fmt.Fprintf(r.out, "  %s^\n", strings.Repeat(" ", utf8.RuneCountInString(query[start:pos])))
+}
+
+// printTokens prints the tokens (excluding whitespace) with their byte
+// offsets
+func (r *repl) printTokens(tokens []token) {
+	fmt.Fprintln(r.out, "tokens:")
+	tw := tabwriter.NewWriter(r.out, 0, 0, 2, ' ', 0)
+	for _, t := range tokens {
+		if t.typ == "ws" || t.typ == "eof" {
+			continue
+		}
+		fmt.Fprintf(tw, "  %d\t%s\t%s\n", t.pos, t.typ, t.value)
+	}
+	_ = tw.Flush()
+}
+
+// printExpr prints the expr tree, with each level indented
+func printExpr(w io.Writer, e mql.Expr, depth int) {
+	indent := strings.Repeat("  ", depth)
+	switch v := e.(type) {
+	case *mql.LogicalExpr:
+		fmt.Fprintf(w, "%s%s\n", indent, v.LogicalOp)
+		printExpr(w, v.LeftExpr, depth+1)
+		printExpr(w, v.RightExpr, depth+1)
+	case *mql.ComparisonExpr:
+		fmt.Fprintf(w, "%s%s\n", indent, v.MQL())
+	default:
+		fmt.Fprintf(w, "%s%v\n", indent, v)
+	}
+}
+
+// exec runs a select of the repl's table using the where clause and prints
+// the rows found (up to the repl's limit)
+func (r *repl) exec(ctx context.Context, w *mql.WhereClause) error {
+	const op = "exec"
+	if r.table == "" {
+		return fmt.Errorf("%s: %w (see -table)", op, errMissingTable)
+	}
+	q := fmt.Sprintf("select * from %s where %s limit %d", r.table, w.Condition, r.limit)
+	rows, err := r.db.QueryContext(ctx, q, w.Args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	tw := tabwriter.NewWriter(r.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(columns, "\t"))
+	var found int
+	for rows.Next() {
+		values := make([]any, len(columns))
+		ptrs := make([]any, len(columns))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		s := make([]string, len(values))
+		for i, v := range values {
+			switch v := v.(type) {
+			case nil:
+				s[i] = "NULL"
+			case []byte:
+				s[i] = string(v)
+			default:
+				s[i] = fmt.Sprint(v)
+			}
+		}
+		fmt.Fprintln(tw, strings.Join(s, "\t"))
+		found++
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := tw.Flush(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	fmt.Fprintf(r.out, "(%d rows)\n", found)
+	return nil
+}
+
+// token is a token emitted by the lexer
+type token struct {
+	typ   string
+	value string
+	pos   int
+}
+
+// tokenLogger is a mql.DebugLogger which collects the tokens emitted by the
+// lexer
+type tokenLogger struct {
+	tokens []token
+}
+
+// Debug collects the tokens from the lexer's "emitted token" messages
+func (l *tokenLogger) Debug(msg string, args ...any) {
+	if msg != "mql: lexer emitted token" {
+		return
+	}
+	var t token
+	for i := 0; i+1 < len(args); i += 2 {
+		switch args[i] {
+		case "type":
+			t.typ, _ = args[i+1].(string)
+		case "value":
+			t.value, _ = args[i+1].(string)
+		case "pos":
+			t.pos, _ = args[i+1].(int)
+		}
+	}
+	l.tokens = append(l.tokens, t)
+}
@@ -0,0 +1,187 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/mql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_repl(t *testing.T) {
+	t.Parallel()
+	testCtx := context.Background()
+	fields := []mql.FieldDescriptor{{Name: "Name", Type: "string"}, {Name: "Age", Type: "int"}}
+	tests := []struct {
+		name         string
+		fields       []mql.FieldDescriptor
+		opts         []mql.Option
+		input        string
+		wantContains []string
+		wantMissing  []string
+	}{
+		{
+			name:  "tokens-and-expr",
+			input: `name="alice" and age>21`,
+			wantContains: []string{
+				"tokens:\n  0   symbol  name\n  4   eq      =\n  5   str     alice\n  13  and     and\n  17  symbol  age\n  20  gt      >\n  21  num     21\n",
+				"expr:\n  and\n    name=\"alice\"\n    age>21\n",
+			},
+			wantMissing: []string{"where:"},
+		},
+		{
+			name:  "parse-error-caret",
+			input: `name="alice" and`,
+			wantContains: []string{
+				"  name=\"alice\" and\n                  ^\nerror: mql.ParseExpr:",
+			},
+		},
+		{
+			name:  "continued-line",
+			input: "name=\"alice\" and \\\nage>",
+			wantContains: []string{
+				continuationPrompt,
+				"  age>\n      ^\nerror: mql.ParseExpr:",
+			},
+		},
+		{
+			name:   "where",
+			fields: fields,
+			opts:   []mql.Option{mql.WithDialect(mql.PostgresDialect{})},
+			input:  `name="alice" and age>21`,
+			wantContains: []string{
+				`where: ("name"=$1 and "age">$2)`,
+				"args: [alice 21]",
+			},
+		},
+		{
+			name:         "invalid-column",
+			fields:       fields,
+			input:        `email="alice@example.com"`,
+			wantContains: []string{"error: mql.Validate:", `invalid column "email"`},
+			wantMissing:  []string{"where:"},
+		},
+		{
+			name:   "lint-warning",
+			fields: fields,
+			input:  `age>21 and (name="alice")`,
+			wantContains: []string{
+				"  age>21 and (name=\"alice\")\n             ^\nwarning: redundant-parens:",
+			},
+		},
+		{
+			name:         "toggle-tokens-and-expr",
+			input:        ".tokens\n.expr\nname=\"alice\"",
+			wantContains: []string{"tokens: off", "expr: off"},
+			wantMissing:  []string{"symbol", "  name=\"alice\"\n"},
+		},
+		{
+			name:         "fields",
+			fields:       fields,
+			input:        ".fields",
+			wantContains: []string{"Name string\nAge int\n"},
+		},
+		{
+			name:         "unknown-command",
+			input:        ".bad",
+			wantContains: []string{"unknown command .bad (see .help)"},
+		},
+		{
+			name:         "quit",
+			input:        ".quit\nname=\"alice\"",
+			wantMissing:  []string{"tokens:"},
+			wantContains: []string{prompt},
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			assert, require := assert.New(t), require.New(t)
+			var out bytes.Buffer
+			r := &repl{out: &out, fields: tc.fields, opts: tc.opts, showTokens: true, showExpr: true}
+			require.NoError(r.run(testCtx, strings.NewReader(tc.input)))
+			for _, s := range tc.wantContains {
+				assert.Contains(out.String(), s)
+			}
+			for _, s := range tc.wantMissing {
+				assert.NotContains(out.String(), s)
+			}
+		})
+	}
+}
+
+func Test_replExec(t *testing.T) {
+	t.Parallel()
+	assert, require := assert.New(t), require.New(t)
+	testCtx := context.Background()
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "mql.db"))
+	require.NoError(err)
+	t.Cleanup(func() { _ = db.Close() })
+	_, err = db.ExecContext(testCtx, `create table users (name text, age integer, email text)`)
+	require.NoError(err)
+	_, err = db.ExecContext(testCtx, `insert into users values ('alice', 22, null), ('bob', 20, 'bob@example.com')`)
+	require.NoError(err)
+
+	var out bytes.Buffer
+	r := &repl{
+		out:    &out,
+		fields: []mql.FieldDescriptor{{Name: "Name", Type: "string"}, {Name: "Age", Type: "int"}},
+		opts:   []mql.Option{mql.WithDialect(mql.SqliteDialect{})},
+		db:     db,
+		table:  "users",
+		limit:  20,
+	}
+	require.NoError(r.run(testCtx, strings.NewReader("age>21\nage>100")))
+	assert.Contains(out.String(), "name   age  email\nalice  22   NULL\n(1 rows)\n")
+	assert.Contains(out.String(), "name  age  email\n(0 rows)\n")
+
+	out.Reset()
+	r.table = ""
+	require.NoError(r.run(testCtx, strings.NewReader("age>21")))
+	assert.Contains(out.String(), "error: exec: missing table (see -table)")
+}
+
+func Test_parseFields(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		fields  string
+		want    []mql.FieldDescriptor
+		wantErr bool
+	}{
+		{name: "empty", fields: " "},
+		{
+			name:   "valid",
+			fields: "Name:string, CreatedAt:time.Time,Labels:map[string]string",
+			want: []mql.FieldDescriptor{
+				{Name: "Name", Type: "string"},
+				{Name: "CreatedAt", Type: "time.Time"},
+				{Name: "Labels", Type: "map[string]string"},
+			},
+		},
+		{name: "missing-type", fields: "Name", wantErr: true},
+		{name: "missing-name", fields: "Name:string,:int", wantErr: true},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			assert, require := assert.New(t), require.New(t)
+			got, err := parseFields(tc.fields)
+			if tc.wantErr {
+				require.Error(err)
+				assert.ErrorIs(err, errInvalidFields)
+				return
+			}
+			require.NoError(err)
+			assert.Equal(tc.want, got)
+		})
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+// Package testmodels contains models used to test the code generated by mqlgen
+package testmodels
+
+//go:generate go run github.com/hashicorp/mql/cmd/mqlgen -type Group
+
+import (
+	"database/sql"
+	nethttp "net/http"
+	"time"
+)
+
+// Status of a user
+type Status string
+
+// Base is embedded in other models
+type Base struct {
+	ID        uint
+	CreatedAt time.Time
+}
+
+// User is a model annotated for mqlgen
+//
+//mql:model
+type User struct {
+	Base
+	Name         string
+	Email        *string
+	Age          uint8
+	Length       float32
+	Status       Status
+	Nickname     sql.NullString
+	Labels       map[string]string
+	Scores       map[string]int
+	Header       nethttp.Header
+	Avatar       []byte
+	Secret       string `mql:"-"`
+	First, Last  string
+	Extra        any
+	updatedCount int
+}
+
+// Group is selected with the -type flag
+type Group struct {
+	*Base
+	Name    string
+	Members []User
+}
+
+// notAModel isn't annotated, so it's not described
+type notAModel struct {
+	Name string
+}
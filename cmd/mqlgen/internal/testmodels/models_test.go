@@ -0,0 +1,52 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package testmodels
+
+import (
+	"testing"
+
+	"github.com/hashicorp/mql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_mqlDescriber(t *testing.T) {
+	t.Parallel()
+	t.Run("same-as-reflection", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		for _, m := range []any{User{}, &User{}, Group{}, &Group{}} {
+			want, err := mql.ReflectDescriber{}.DescribeModel(m)
+			require.NoError(err)
+			got, err := mqlDescriber{}.DescribeModel(m)
+			require.NoError(err)
+			// fields tagged with `mql:"-"` are omitted
+			for i, f := range want {
+				if f.Name == "Secret" {
+					want = append(want[:i], want[i+1:]...)
+					break
+				}
+			}
+			assert.Equal(want, got)
+		}
+	})
+	t.Run("parse", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		w, err := mql.Parse(`name="alice" and age > 21 and labels.env="prod"`, User{}, mql.WithModelDescriber(mqlDescriber{}))
+		require.NoError(err)
+		assert.Equal(&mql.WhereClause{
+			Condition: "((name=? and age>?) and labels->>?=?)",
+			Args:      []any{"alice", 21, "env", "prod"},
+		}, w)
+
+		_, err = mql.Parse(`secret="shh"`, User{}, mql.WithModelDescriber(mqlDescriber{}))
+		require.Error(err)
+		assert.ErrorIs(err, mql.ErrInvalidColumn)
+	})
+	t.Run("err-unsupported-model", func(t *testing.T) {
+		_, err := mqlDescriber{}.DescribeModel(notAModel{})
+		require.Error(t, err)
+		assert.ErrorIs(t, err, mql.ErrInvalidParameter)
+		assert.ErrorContains(t, err, "unsupported model testmodels.notAModel")
+	})
+}
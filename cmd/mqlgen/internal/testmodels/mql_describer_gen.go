@@ -0,0 +1,50 @@
+// Code generated by mqlgen. DO NOT EDIT.
+
+package testmodels
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/mql"
+)
+
+// mqlDescriber is a mql.ModelDescriber which describes models without reflection
+type mqlDescriber struct{}
+
+var _ mql.ModelDescriber = mqlDescriber{}
+
+var mqlDescriberGroupFields = []mql.FieldDescriptor{
+	{Name: "Base", Type: "*testmodels.Base"},
+	{Name: "Name", Type: "string"},
+	{Name: "Members", Type: "[]testmodels.User"},
+}
+
+var mqlDescriberUserFields = []mql.FieldDescriptor{
+	{Name: "Base", Type: "testmodels.Base"},
+	{Name: "Name", Type: "string"},
+	{Name: "Email", Type: "*string"},
+	{Name: "Age", Type: "uint8"},
+	{Name: "Length", Type: "float32"},
+	{Name: "Status", Type: "testmodels.Status"},
+	{Name: "Nickname", Type: "sql.NullString"},
+	{Name: "Labels", Type: "map[string]string"},
+	{Name: "Scores", Type: "map[string]int"},
+	{Name: "Header", Type: "http.Header"},
+	{Name: "Avatar", Type: "[]uint8"},
+	{Name: "First", Type: "string"},
+	{Name: "Last", Type: "string"},
+	{Name: "Extra", Type: "interface {}"},
+	{Name: "updatedCount", Type: "int"},
+}
+
+// DescribeModel returns the fields of the model
+func (mqlDescriber) DescribeModel(model any) ([]mql.FieldDescriptor, error) {
+	switch model.(type) {
+	case Group, *Group:
+		return mqlDescriberGroupFields, nil
+	case User, *User:
+		return mqlDescriberUserFields, nil
+	default:
+		return nil, fmt.Errorf("mqlDescriber.DescribeModel: unsupported model %T: %w", model, mql.ErrInvalidParameter)
+	}
+}
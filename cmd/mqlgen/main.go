@@ -0,0 +1,341 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+// mqlgen generates a mql.ModelDescriber with static field descriptor tables
+// for the structs of a package, so models can be described without runtime
+// reflection.
+//
+// Structs are selected by annotating them with a "//mql:model" comment or by
+// using the -type flag.  Fields tagged with `mql:"-"` are omitted. Typically,
+// it's run via go:generate:
+//
+//	//go:generate go run github.com/hashicorp/mql/cmd/mqlgen
+//
+// and the generated describer is used via:
+//
+//	w, err := mql.Parse(query, User{}, mql.WithModelDescriber(mqlDescriber{}))
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// annotation is used to select the structs of a package
+const annotation = "mql:model"
+
+var (
+	errNoModels        = errors.New("no models found")
+	errUnsupportedType = errors.New("unsupported field type")
+)
+
+func main() {
+	dir := flag.String("dir", ".", "directory of the package to scan")
+	output := flag.String("output", "mql_describer_gen.go", "name of the generated file (written to dir)")
+	types := flag.String("type", "", "comma separated list of struct names (in addition to annotated structs)")
+	describer := flag.String("describer", "mqlDescriber", "name of the generated describer type")
+	flag.Parse()
+
+	var typeNames []string
+	if *types != "" {
+		typeNames = strings.Split(*types, ",")
+	}
+	src, err := generate(*dir, *output, *describer, typeNames)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "mqlgen: %s\n", err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(filepath.Join(*dir, *output), src, 0o644); err != nil {
+		fmt.Fprintf(os.Stderr, "mqlgen: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+// model is a struct found in the package
+type model struct {
+	name   string
+	fields []field
+}
+
+// field is the name and the reflect.Type.String() of a struct's field
+type field struct {
+	name string
+	typ  string
+}
+
+// generate will scan the go files in dir (excluding tests and the output file)
+// and return the formatted source of a describer for the selected structs.
+func generate(dir, output, describer string, typeNames []string) ([]byte, error) {
+	const op = "generate"
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	sort.Strings(files)
+	fset := token.NewFileSet()
+	var pkgName string
+	var models []model
+	for _, f := range files {
+		if strings.HasSuffix(f, "_test.go") || filepath.Base(f) == output {
+			continue
+		}
+		file, err := parser.ParseFile(fset, f, nil, parser.ParseComments)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		if pkgName == "" {
+			pkgName = file.Name.Name
+		}
+		m, err := fileModels(file, typeNames)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s: %w", op, filepath.Base(f), err)
+		}
+		models = append(models, m...)
+	}
+	if len(models) == 0 {
+		return nil, fmt.Errorf("%s: %w in %s", op, errNoModels, dir)
+	}
+	sort.Slice(models, func(i, j int) bool { return models[i].name < models[j].name })
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by mqlgen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&b, "package %s\n\n", pkgName)
+	fmt.Fprintf(&b, "import (\n\"fmt\"\n\n\"github.com/hashicorp/mql\"\n)\n\n")
+	fmt.Fprintf(&b, "// %s is a mql.ModelDescriber which describes models without reflection\n", describer)
+	fmt.Fprintf(&b, "type %s struct{}\n\n", describer)
+	fmt.Fprintf(&b, "var _ mql.ModelDescriber = %s{}\n\n", describer)
+	for _, m := range models {
+		fmt.Fprintf(&b, "var %s%sFields = []mql.FieldDescriptor{\n", describer, m.name)
+		for _, f := range m.fields {
+			fmt.Fprintf(&b, "{Name: %q, Type: %q},\n", f.name, f.typ)
+		}
+		fmt.Fprintf(&b, "}\n\n")
+	}
+	fmt.Fprintf(&b, "// DescribeModel returns the fields of the model\n")
+	fmt.Fprintf(&b, "func (%s) DescribeModel(model any) ([]mql.FieldDescriptor, error) {\n", describer)
+	fmt.Fprintf(&b, "switch model.(type) {\n")
+	for _, m := range models {
+		fmt.Fprintf(&b, "case %s, *%s:\nreturn %s%sFields, nil\n", m.name, m.name, describer, m.name)
+	}
+	fmt.Fprintf(&b, "default:\n")
+	fmt.Fprintf(&b, "return nil, fmt.Errorf(\"%s.DescribeModel: unsupported model %%T: %%w\", model, mql.ErrInvalidParameter)\n", describer)
+	fmt.Fprintf(&b, "}\n}\n")
+
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return src, nil
+}
+
+// fileModels returns the structs of the file which are annotated or named in
+// typeNames
+func fileModels(file *ast.File, typeNames []string) ([]model, error) {
+	const op = "fileModels"
+	imports := make(map[string]string, len(file.Imports))
+	for _, imp := range file.Imports {
+		path, err := strconv.Unquote(imp.Path.Value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		name := importName(path)
+		alias := name
+		if imp.Name != nil {
+			alias = imp.Name.Name
+		}
+		imports[alias] = name
+	}
+
+	var models []model
+	for _, d := range file.Decls {
+		gd, ok := d.(*ast.GenDecl)
+		if !ok || gd.Tok != token.TYPE {
+			continue
+		}
+		for _, s := range gd.Specs {
+			ts := s.(*ast.TypeSpec)
+			st, ok := ts.Type.(*ast.StructType)
+			if !ok || ts.TypeParams != nil {
+				continue
+			}
+			doc := ts.Doc
+			if doc == nil && len(gd.Specs) == 1 {
+				doc = gd.Doc
+			}
+			if !isAnnotated(doc) && !contains(typeNames, ts.Name.Name) {
+				continue
+			}
+			m := model{name: ts.Name.Name}
+			for _, f := range st.Fields.List {
+				if f.Tag != nil {
+					tag, err := strconv.Unquote(f.Tag.Value)
+					if err != nil {
+						return nil, fmt.Errorf("%s: %w", op, err)
+					}
+					if reflect.StructTag(tag).Get("mql") == "-" {
+						continue
+					}
+				}
+				typ, err := typeString(f.Type, file.Name.Name, imports)
+				if err != nil {
+					return nil, fmt.Errorf("%s: %s: %w", op, ts.Name.Name, err)
+				}
+				names := f.Names
+				if len(names) == 0 {
+					// embedded fields are named after their type
+					names = []*ast.Ident{ast.NewIdent(embeddedName(f.Type))}
+				}
+				for _, n := range names {
+					m.fields = append(m.fields, field{name: n.Name, typ: typ})
+				}
+			}
+			models = append(models, m)
+		}
+	}
+	return models, nil
+}
+
+func isAnnotated(doc *ast.CommentGroup) bool {
+	if doc == nil {
+		return false
+	}
+	for _, c := range doc.List {
+		if strings.TrimSpace(strings.TrimPrefix(c.Text, "//")) == annotation {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(s []string, v string) bool {
+	for _, e := range s {
+		if strings.TrimSpace(e) == v {
+			return true
+		}
+	}
+	return false
+}
+
+// importName returns the package name for an import path, assuming the
+// package name matches the last element of the path (ignoring any major
+// version suffix like /v2 or .v3 and a go- prefix).  Imports which don't
+// follow this convention will generate the wrong type for their fields.
+func importName(path string) string {
+	elems := strings.Split(path, "/")
+	name := elems[len(elems)-1]
+	if len(elems) > 1 && len(name) > 1 && name[0] == 'v' && isDigits(name[1:]) {
+		name = elems[len(elems)-2]
+	}
+	if i := strings.Index(name, ".v"); i > 0 && isDigits(name[i+2:]) {
+		name = name[:i]
+	}
+	return strings.ReplaceAll(strings.TrimPrefix(name, "go-"), "-", "")
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// embeddedName returns the field name of an embedded field
+func embeddedName(e ast.Expr) string {
+	switch t := e.(type) {
+	case *ast.StarExpr:
+		return embeddedName(t.X)
+	case *ast.SelectorExpr:
+		return t.Sel.Name
+	case *ast.Ident:
+		return t.Name
+	default:
+		return ""
+	}
+}
+
+// typeString returns the same string as reflect.Type.String() for the field
+// type expression.
+func typeString(e ast.Expr, pkgName string, imports map[string]string) (string, error) {
+	const op = "typeString"
+	switch t := e.(type) {
+	case *ast.Ident:
+		switch t.Name {
+		case "byte":
+			return "uint8", nil
+		case "rune":
+			return "int32", nil
+		case "any":
+			return "interface {}", nil
+		case "bool", "string", "error", "uintptr",
+			"int", "int8", "int16", "int32", "int64",
+			"uint", "uint8", "uint16", "uint32", "uint64",
+			"float32", "float64", "complex64", "complex128":
+			return t.Name, nil
+		default:
+			return pkgName + "." + t.Name, nil
+		}
+	case *ast.StarExpr:
+		s, err := typeString(t.X, pkgName, imports)
+		if err != nil {
+			return "", err
+		}
+		return "*" + s, nil
+	case *ast.SelectorExpr:
+		x, ok := t.X.(*ast.Ident)
+		if !ok {
+			return "", fmt.Errorf("%s: %w", op, errUnsupportedType)
+		}
+		name, ok := imports[x.Name]
+		if !ok {
+			return "", fmt.Errorf("%s: unknown package %q: %w", op, x.Name, errUnsupportedType)
+		}
+		return name + "." + t.Sel.Name, nil
+	case *ast.ArrayType:
+		elem, err := typeString(t.Elt, pkgName, imports)
+		if err != nil {
+			return "", err
+		}
+		if t.Len == nil {
+			return "[]" + elem, nil
+		}
+		l, ok := t.Len.(*ast.BasicLit)
+		if !ok || l.Kind != token.INT {
+			return "", fmt.Errorf("%s: array length must be an int literal: %w", op, errUnsupportedType)
+		}
+		return "[" + l.Value + "]" + elem, nil
+	case *ast.MapType:
+		k, err := typeString(t.Key, pkgName, imports)
+		if err != nil {
+			return "", err
+		}
+		v, err := typeString(t.Value, pkgName, imports)
+		if err != nil {
+			return "", err
+		}
+		return "map[" + k + "]" + v, nil
+	case *ast.InterfaceType:
+		if len(t.Methods.List) > 0 {
+			return "", fmt.Errorf("%s: non-empty interface: %w", op, errUnsupportedType)
+		}
+		return "interface {}", nil
+	default:
+		return "", fmt.Errorf("%s: %T: %w", op, e, errUnsupportedType)
+	}
+}
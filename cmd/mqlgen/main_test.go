@@ -0,0 +1,94 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"go/parser"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_generate(t *testing.T) {
+	t.Parallel()
+	t.Run("testmodels-up-to-date", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		dir := filepath.Join("internal", "testmodels")
+		got, err := generate(dir, "mql_describer_gen.go", "mqlDescriber", []string{"Group"})
+		require.NoError(err)
+		want, err := os.ReadFile(filepath.Join(dir, "mql_describer_gen.go"))
+		require.NoError(err)
+		assert.Equal(string(want), string(got), "run go generate ./... to update the generated code")
+	})
+	t.Run("err-no-models", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "a.go"), []byte("package a\n\ntype A struct{}\n"), 0o644))
+		_, err := generate(dir, "mql_describer_gen.go", "mqlDescriber", nil)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, errNoModels)
+	})
+	t.Run("err-unsupported-type", func(t *testing.T) {
+		dir := t.TempDir()
+		src := "package a\n\n//mql:model\ntype A struct{\n\tFn func()\n}\n"
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "a.go"), []byte(src), 0o644))
+		_, err := generate(dir, "mql_describer_gen.go", "mqlDescriber", nil)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, errUnsupportedType)
+		assert.ErrorContains(t, err, "a.go: fileModels: A: typeString: *ast.FuncType")
+	})
+}
+
+func Test_typeString(t *testing.T) {
+	t.Parallel()
+	imports := map[string]string{"time": "time", "sql": "sql", "pb": "structpb"}
+	tests := []struct {
+		expr    string
+		want    string
+		wantErr bool
+	}{
+		{expr: "int", want: "int"},
+		{expr: "*int", want: "*int"},
+		{expr: "byte", want: "uint8"},
+		{expr: "[]rune", want: "[]int32"},
+		{expr: "any", want: "interface {}"},
+		{expr: "interface{}", want: "interface {}"},
+		{expr: "Status", want: "models.Status"},
+		{expr: "*time.Time", want: "*time.Time"},
+		{expr: "pb.Value", want: "structpb.Value"},
+		{expr: "[4]string", want: "[4]string"},
+		{expr: "map[string]*sql.NullString", want: "map[string]*sql.NullString"},
+		{expr: "unknown.Type", wantErr: true},
+		{expr: "interface{ String() string }", wantErr: true},
+		{expr: "chan int", wantErr: true},
+		{expr: "[n]int", wantErr: true},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.expr, func(t *testing.T) {
+			assert, require := assert.New(t), require.New(t)
+			e, err := parser.ParseExpr(tc.expr)
+			require.NoError(err)
+			got, err := typeString(e, "models", imports)
+			if tc.wantErr {
+				require.Error(err)
+				assert.ErrorIs(err, errUnsupportedType)
+				return
+			}
+			require.NoError(err)
+			assert.Equal(tc.want, got)
+		})
+	}
+}
+
+func Test_importName(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, "time", importName("time"))
+	assert.Equal(t, "http", importName("net/http"))
+	assert.Equal(t, "mql", importName("github.com/hashicorp/mql/v2"))
+	assert.Equal(t, "yaml", importName("gopkg.in/yaml.v3"))
+	assert.Equal(t, "dbw", importName("github.com/hashicorp/go-dbw"))
+}
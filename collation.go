@@ -0,0 +1,107 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql
+
+import (
+	"fmt"
+	"strings"
+)
+
+// mySqlAccentInsensitiveCollation is the collation of accent insensitive
+// comparisons on mysql, which is case insensitive as well
+const mySqlAccentInsensitiveCollation = "utf8mb4_0900_ai_ci"
+
+// WithCollation provides a collation for the =, != and % comparisons of the
+// string columns (database column or model field name), or of every string
+// column when no columns are provided: name="jose" is converted to: name
+// COLLATE "und-x-icu"=?.  A case or accent insensitive collation is helpful
+// for user facing name searches.  The collation is used as is, so it must be
+// quoted when the database requires it (see WithStringRangeCollation for the
+// collation of range comparisons).  Postgres doesn't support LIKE (%) with a
+// nondeterministic collation.
+func WithCollation(collation string, columns ...string) Option {
+	const op = "mql.WithCollation"
+	return func(o *options) error {
+		if !collationRegexp.MatchString(collation) {
+			return fmt.Errorf("%s: invalid collation %q: %w", op, collation, ErrInvalidParameter)
+		}
+		o.withCollation = collation
+		if len(columns) == 0 {
+			o.withCollationColumns = nil
+			return nil
+		}
+		o.withCollationColumns = make(map[string]struct{}, len(columns))
+		for _, c := range columns {
+			if c == "" {
+				return fmt.Errorf("%s: missing column: %w", op, ErrInvalidParameter)
+			}
+			o.withCollationColumns[strings.ToLower(strings.ReplaceAll(c, "_", ""))] = struct{}{}
+		}
+		return nil
+	}
+}
+
+// WithAccentInsensitive will ignore accents for the =, != and % comparisons
+// of the string columns (database column or model field name), or of every
+// string column when no columns are provided, so "José" matches "jose".  It
+// uses unaccent() on postgres, which requires the unaccent extension:
+// name="José" is converted to: unaccent(name)=unaccent(?).  On mysql, it uses
+// the utf8mb4_0900_ai_ci collation, which is case insensitive as well.  Other
+// dialects don't support it and their comparisons return an
+// ErrInvalidParameter.
+func WithAccentInsensitive(columns ...string) Option {
+	const op = "mql.WithAccentInsensitive"
+	return func(o *options) error {
+		if len(columns) == 0 {
+			o.withAllAccentInsensitive = true
+			return nil
+		}
+		if o.withAccentInsensitive == nil {
+			o.withAccentInsensitive = make(map[string]struct{}, len(columns))
+		}
+		for _, c := range columns {
+			if c == "" {
+				return fmt.Errorf("%s: missing column: %w", op, ErrInvalidParameter)
+			}
+			o.withAccentInsensitive[strings.ToLower(strings.ReplaceAll(c, "_", ""))] = struct{}{}
+		}
+		return nil
+	}
+}
+
+// collatedColumn returns the column of the =, != or % comparison of a string
+// column using its collation (see WithCollation) and whether it's accent
+// insensitive (see WithAccentInsensitive), in which case the placeholders of
+// its condition must be unaccented too (see unaccentArgs).  Supported options:
+// WithCollation, WithAccentInsensitive, WithDialect
+func collatedColumn(columnName string, v validator, opts options) (string, bool, error) {
+	const op = "mql.collatedColumn"
+	if opts.withCollation == "" && !opts.withAllAccentInsensitive && len(opts.withAccentInsensitive) == 0 {
+		return columnName, false, nil
+	}
+	field := strings.ToLower(strings.ReplaceAll(v.field.Name, "_", ""))
+	if _, ok := opts.withCollationColumns[field]; opts.withCollation != "" && (ok || opts.withCollationColumns == nil) {
+		columnName += " COLLATE " + opts.withCollation
+	}
+	if _, ok := opts.withAccentInsensitive[field]; !ok && !opts.withAllAccentInsensitive {
+		return columnName, false, nil
+	}
+	switch d := dialectOf(opts); {
+	case hasPgOperators(d):
+		return "unaccent(" + columnName + ")", true, nil
+	case d.Name() == (MySqlDialect{}).Name():
+		return columnName + " COLLATE " + mySqlAccentInsensitiveCollation, false, nil
+	default:
+		return "", false, fmt.Errorf("%s: accent insensitive comparisons of column %q aren't supported by the %s dialect: %w", op, columnName, d.Name(), ErrInvalidParameter)
+	}
+}
+
+// unaccentArgs returns the where clause with its placeholders unaccented (ie:
+// unaccent(?)), so they're compared to an unaccented column
+func unaccentArgs(w *WhereClause) *WhereClause {
+	w.Condition = replacePlaceholders(w.Condition, len(w.Args), func(int) string {
+		return "unaccent(?)"
+	})
+	return w
+}
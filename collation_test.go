@@ -0,0 +1,123 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql_test
+
+import (
+	"testing"
+
+	"github.com/hashicorp/mql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse_WithCollation(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name      string
+		query     string
+		opts      []mql.Option
+		want      *mql.WhereClause
+		wantErrIs error
+	}{
+		{
+			name:  "every-column",
+			query: `name="jose" and email%"example" and age=21`,
+			opts:  []mql.Option{mql.WithCollation(`"und-x-icu"`)},
+			want: &mql.WhereClause{
+				Condition: `((name COLLATE "und-x-icu"=? and email COLLATE "und-x-icu" like ? escape '\') and age=?)`,
+				Args:      []any{"jose", "%example%", 21},
+			},
+		},
+		{
+			name:  "columns",
+			query: `name!="jose" and email="jose@example.com"`,
+			opts:  []mql.Option{mql.WithCollation("utf8mb4_0900_ai_ci", "name")},
+			want: &mql.WhereClause{
+				Condition: "(name COLLATE utf8mb4_0900_ai_ci!=? and email=?)",
+				Args:      []any{"jose", "jose@example.com"},
+			},
+		},
+		{
+			name:  "not-ranges",
+			query: `name>"m"`,
+			opts:  []mql.Option{mql.WithCollation(`"und-x-icu"`)},
+			want:  &mql.WhereClause{Condition: "name>?", Args: []any{"m"}},
+		},
+		{
+			name:  "accent-insensitive",
+			query: `name="José" and email%"jose"`,
+			opts:  []mql.Option{mql.WithAccentInsensitive()},
+			want: &mql.WhereClause{
+				Condition: `(unaccent(name)=unaccent(?) and unaccent(email) like unaccent(?) escape '\')`,
+				Args:      []any{"José", "%jose%"},
+			},
+		},
+		{
+			name:  "accent-insensitive-columns",
+			query: `name="José" and email="jose@example.com"`,
+			opts:  []mql.Option{mql.WithAccentInsensitive("name"), mql.WithDialect(mql.PostgresDialect{}), mql.WithPgPlaceholders()},
+			want: &mql.WhereClause{
+				Condition: `(unaccent("name")=unaccent($1) and "email"=$2)`,
+				Args:      []any{"José", "jose@example.com"},
+			},
+		},
+		{
+			name:  "accent-insensitive-glob",
+			query: `name="Jos*"`,
+			opts:  []mql.Option{mql.WithAccentInsensitive(), mql.WithGlobPatterns()},
+			want:  &mql.WhereClause{Condition: `unaccent(name) like unaccent(?) escape '\'`, Args: []any{"Jos%"}},
+		},
+		{
+			name:  "accent-insensitive-mysql",
+			query: `name="José"`,
+			opts:  []mql.Option{mql.WithAccentInsensitive(), mql.WithDialect(mql.MySqlDialect{})},
+			want:  &mql.WhereClause{Condition: "`name` COLLATE utf8mb4_0900_ai_ci=?", Args: []any{"José"}},
+		},
+		{
+			name:  "accent-insensitive-inline-values",
+			query: `name="José"`,
+			opts:  []mql.Option{mql.WithAccentInsensitive(), mql.WithInlineValues()},
+			want:  &mql.WhereClause{Condition: "unaccent(name)=unaccent('José')"},
+		},
+		{
+			name:      "err-accent-insensitive-sqlite",
+			query:     `name="José"`,
+			opts:      []mql.Option{mql.WithAccentInsensitive(), mql.WithDialect(mql.SqliteDialect{})},
+			wantErrIs: mql.ErrInvalidParameter,
+		},
+		{
+			name:      "err-invalid-collation",
+			query:     `name="jose"`,
+			opts:      []mql.Option{mql.WithCollation("C; drop table users")},
+			wantErrIs: mql.ErrInvalidParameter,
+		},
+		{
+			name:      "err-missing-column",
+			query:     `name="jose"`,
+			opts:      []mql.Option{mql.WithAccentInsensitive("")},
+			wantErrIs: mql.ErrInvalidParameter,
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert, require := assert.New(t), require.New(t)
+			got, err := mql.Parse(tc.query, testModel{}, tc.opts...)
+			if tc.wantErrIs != nil {
+				require.Error(err)
+				assert.ErrorIs(err, tc.wantErrIs)
+				assert.Nil(got)
+				return
+			}
+			require.NoError(err)
+			assert.Equal(tc.want, got)
+		})
+	}
+	t.Run("validate-options", func(t *testing.T) {
+		err := mql.ValidateOptions(testModel{}, mql.WithCollation(`"C"`, "nickname"), mql.WithAccentInsensitive("name"))
+		require.Error(t, err)
+		assert.ErrorContains(t, err, `WithCollation column "nickname" isn't a column of the model`)
+	})
+}
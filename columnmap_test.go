@@ -0,0 +1,125 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/hashicorp/mql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse_columnMap(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name            string
+		query           string
+		opts            []mql.Option
+		want            *mql.WhereClause
+		wantErrIs       error
+		wantErrContains string
+	}{
+		{
+			name:  "many-query-columns-to-one-column",
+			query: `nickname="alice" or handle="bob"`,
+			opts:  []mql.Option{mql.WithColumnMap(map[string]string{"nickname": "name", "handle": "name"})},
+			want:  &mql.WhereClause{Condition: "(name=? or name=?)", Args: []any{"alice", "bob"}},
+		},
+		{
+			name:  "aliases",
+			query: `nickname="alice" or handle="bob"`,
+			opts:  []mql.Option{mql.WithColumnAliases("name", "nickname", "handle")},
+			want:  &mql.WhereClause{Condition: "(name=? or name=?)", Args: []any{"alice", "bob"}},
+		},
+		{
+			name:  "merged",
+			query: `nickname="alice" and years>21`,
+			opts: []mql.Option{
+				mql.WithColumnMap(map[string]string{"nickname": "name"}),
+				mql.WithColumnMap(map[string]string{"years": "age"}),
+				mql.WithColumnAliases("name", "nickname"),
+			},
+			want: &mql.WhereClause{Condition: "(name=? and age>?)", Args: []any{"alice", 21}},
+		},
+		{
+			name:  "case-insensitive-query-columns",
+			query: `NICKNAME="alice"`,
+			opts:  []mql.Option{mql.WithColumnMap(map[string]string{"NickName": "name"})},
+			want:  &mql.WhereClause{Condition: "name=?", Args: []any{"alice"}},
+		},
+		{
+			name:            "err-column-isn't-a-field",
+			query:           `name="alice"`,
+			opts:            []mql.Option{mql.WithColumnMap(map[string]string{"nickname": "nick_name", "handle": "name"})},
+			wantErrIs:       mql.ErrInvalidColumnMap,
+			wantErrContains: `invalid column map: columns which aren't fields of the model: "nickname" -> "nick_name"`,
+		},
+		{
+			name:            "err-ignored-field",
+			query:           `name="alice"`,
+			opts:            []mql.Option{mql.WithIgnoredFields("Email"), mql.WithColumnAliases("email", "mail")},
+			wantErrIs:       mql.ErrInvalidColumnMap,
+			wantErrContains: `"mail" -> "email"`,
+		},
+		{
+			name:  "err-mapped-to-different-columns",
+			query: `name="alice"`,
+			opts: []mql.Option{
+				mql.WithColumnMap(map[string]string{"nickname": "name"}),
+				mql.WithColumnAliases("email", "NickName"),
+			},
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: `query column "NickName" is mapped to both "name" and "email"`,
+		},
+		{
+			name:            "err-missing-aliases",
+			query:           `name="alice"`,
+			opts:            []mql.Option{mql.WithColumnAliases("name")},
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: `missing aliases of column "name"`,
+		},
+		{
+			name:            "err-missing-query-column",
+			query:           `name="alice"`,
+			opts:            []mql.Option{mql.WithColumnMap(map[string]string{"": "name"})},
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: `missing query column of column "name"`,
+		},
+		{
+			name:            "err-missing-column",
+			query:           `name="alice"`,
+			opts:            []mql.Option{mql.WithColumnMap(map[string]string{"nickname": ""})},
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: `missing column of query column "nickname"`,
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert, require := assert.New(t), require.New(t)
+			got, err := mql.Parse(tc.query, testModel{}, tc.opts...)
+			if tc.wantErrContains != "" {
+				require.Error(err)
+				assert.Nil(got)
+				assert.ErrorIs(err, tc.wantErrIs)
+				assert.ErrorContains(err, tc.wantErrContains)
+				return
+			}
+			require.NoError(err)
+			assert.Equal(tc.want, got)
+		})
+	}
+	t.Run("ColumnMapError", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		_, err := mql.NewParser(testModel{}, mql.WithColumnMap(map[string]string{"nickname": "nick", "handle": "handle", "mail": "email"}))
+		require.Error(err)
+		assert.ErrorIs(err, mql.ErrInvalidParameter)
+		var cmErr *mql.ColumnMapError
+		require.True(errors.As(err, &cmErr))
+		assert.Equal(map[string]string{"nickname": "nick", "handle": "handle"}, cmErr.Mappings)
+	})
+}
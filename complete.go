@@ -0,0 +1,353 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// CompletionKind is the kind of a completion
+type CompletionKind string
+
+const (
+	// ColumnCompletion is a column of the model (or a query column mapped to
+	// one via WithColumnMap).  The completion of a map column ends with a dot,
+	// since it's queried by key (ie: labels.).
+	ColumnCompletion CompletionKind = "column"
+
+	// ComparisonOpCompletion is a comparison operator supported by the column
+	ComparisonOpCompletion CompletionKind = "comparison_op"
+
+	// ValueCompletion is a value of the column: one of its enum values (see
+	// WithEnum) or true and false for a bool column.  Values are quoted
+	// unless they're bools.
+	ValueCompletion CompletionKind = "value"
+
+	// LogicalOpCompletion is a logical operator (and, or)
+	LogicalOpCompletion CompletionKind = "logical_op"
+)
+
+// Completion is a candidate for the text at the cursor of a query being typed
+// (see Complete).  It's designed to be marshaled as JSON and sent to a UI.
+type Completion struct {
+	// Kind of the completion
+	Kind CompletionKind `json:"kind"`
+
+	// Text replaces the query's text between Start and End
+	Text string `json:"text"`
+
+	// Detail describes the completion: the type of a column's values (see
+	// ColumnInfo.Type) and it's empty for other kinds.
+	Detail string `json:"detail,omitempty"`
+
+	// Start is the byte offset in the query of the partial text (ie: the
+	// beginning of a column) which is replaced by the completion
+	Start int `json:"start"`
+
+	// End is the byte offset in the query of the cursor
+	End int `json:"end"`
+}
+
+// completionState is what's expected at the cursor of a query being typed
+type completionState int
+
+const (
+	expectColumn completionState = iota
+	expectComparisonOp
+	expectValue
+	expectLogicalOp
+	expectNothing
+)
+
+// Complete returns the candidates for the text at the cursor (a byte offset)
+// of a query being typed, using the query before the cursor: the model's
+// columns where a column is expected, the column's comparison operators after
+// a column, the column's values (enums and bools) after an operator and the
+// logical operators after a comparison.  Candidates are filtered by the
+// partial text at the cursor (ie: the beginning of a column) and columns or
+// operators which aren't authorized (see WithFieldAuthorizer) are omitted.
+// Nil is returned when there are no candidates, which includes when the
+// cursor, model or options are invalid.  Supported options: the same options
+// as Parse.
+func Complete(query string, cursor int, model any, opt ...Option) []Completion {
+	if cursor < 0 || cursor > len(query) || isNil(model) {
+		return nil
+	}
+	opts, err := getOpts(opt...)
+	if err != nil {
+		return nil
+	}
+	fValidators, err := modelValidators(model, opt...)
+	if err != nil {
+		return nil
+	}
+	prefix := query[:cursor]
+	start, partial, quoted := partialText(prefix)
+	tokens, _, err := tokenize(prefix[:start])
+	if err != nil {
+		return nil
+	}
+	state, column := completionStateOf(tokens)
+
+	c := completer{opts: opts, validators: fValidators, start: start, end: cursor}
+	if !quoted && partial != "" && isComparisonOpChar(partial[0]) {
+		if state != expectComparisonOp {
+			return nil
+		}
+		if !completeOp(ComparisonOp(partial)) {
+			return c.comparisonOps(column, partial)
+		}
+		// the operator is complete, so its column's values are expected
+		state, c.start, partial = expectValue, cursor, ""
+	}
+	switch state {
+	case expectColumn:
+		if quoted {
+			return nil
+		}
+		return c.columns(partial)
+	case expectComparisonOp:
+		if partial != "" {
+			return nil
+		}
+		return c.comparisonOps(column, "")
+	case expectValue:
+		return c.values(column, partial)
+	case expectLogicalOp:
+		if quoted {
+			return nil
+		}
+		// a logical operator must be separated from the value before it
+		space := ""
+		if r, _ := utf8.DecodeLastRuneInString(prefix[:start]); start > 0 && !isSpace(r) {
+			space = " "
+		}
+		var completions []Completion
+		for _, l := range []LogicalOp{AndOp, OrOp} {
+			if strings.HasPrefix(string(l), strings.ToLower(partial)) {
+				completions = append(completions, c.completion(LogicalOpCompletion, space+string(l), ""))
+			}
+		}
+		return completions
+	default:
+		return nil
+	}
+}
+
+// partialText returns the partial text at the end of the query being typed
+// and its byte offset: an unterminated quoted value (which starts at its
+// opening quote), the beginning of a comparison operator or a word (ie: a
+// column).  quoted reports if the partial text is a quoted value.
+func partialText(query string) (start int, partial string, quoted bool) {
+	quote, quoteStart := rune(0), -1
+	escaped := false
+	for i, r := range query {
+		switch {
+		case quote == 0 && isDelimiter(r):
+			quote, quoteStart = r, i
+		case quote == 0:
+		case escaped:
+			escaped = false
+		case r == backslash:
+			escaped = true
+		case r == quote:
+			quote, quoteStart = 0, -1
+		}
+	}
+	if quoteStart >= 0 {
+		return quoteStart, query[quoteStart+1:], true
+	}
+	start = len(query)
+	for start > 0 && isComparisonOpChar(query[start-1]) {
+		start--
+	}
+	if start < len(query) {
+		return start, query[start:], false
+	}
+	for start > 0 {
+		r, size := utf8.DecodeLastRuneInString(query[:start])
+		if isSpace(r) || isSpecial(r) || isDelimiter(r) {
+			break
+		}
+		start -= size
+	}
+	return start, query[start:], false
+}
+
+// completionStateOf returns what's expected after the tokens, along with the
+// column of the last comparison
+func completionStateOf(tokens []token) (completionState, string) {
+	state, column := expectColumn, ""
+	for _, tk := range tokens {
+		switch tk.Type {
+		case whitespaceToken:
+		case startLogicalExprToken, andToken, orToken:
+			state = expectColumn
+		case endLogicalExprToken:
+			state = expectLogicalOp
+		case stringToken, numberToken, symbolToken:
+			switch state {
+			case expectColumn:
+				state, column = expectComparisonOp, tk.Value
+			case expectValue:
+				state = expectLogicalOp
+			default:
+				state = expectNothing
+			}
+		case greaterThanToken, greaterThanOrEqualToken, lessThanToken, lessThanOrEqualToken, equalToken,
+			notEqualToken, containsToken, containedByToken, arrayContainsToken, similarToken:
+			if state != expectComparisonOp {
+				return expectNothing, ""
+			}
+			state = expectValue
+		default:
+			return expectNothing, ""
+		}
+	}
+	return state, column
+}
+
+// isComparisonOpChar reports if c is a character of a comparison operator
+func isComparisonOpChar(c byte) bool {
+	return strings.IndexByte("=!<>%@~", c) >= 0
+}
+
+// completeOp reports if the operator is a supported operator which isn't the
+// beginning of another operator (ie: < is the beginning of <=)
+func completeOp(o ComparisonOp) bool {
+	if !o.Valid() {
+		return false
+	}
+	for _, s := range supportedComparisonOps {
+		if s != o && strings.HasPrefix(string(s), string(o)) {
+			return false
+		}
+	}
+	return true
+}
+
+// completer returns the completions of a query being typed
+type completer struct {
+	opts       options
+	validators map[string]validator
+	start, end int
+}
+
+func (c completer) completion(k CompletionKind, text, detail string) Completion {
+	return Completion{Kind: k, Text: text, Detail: detail, Start: c.start, End: c.end}
+}
+
+// columns returns the columns (and mapped query columns) which begin with
+// the partial column (ignoring case), sorted by their name
+func (c completer) columns(partial string) []Completion {
+	if strings.Contains(partial, ".") {
+		// map keys are unknown
+		return nil
+	}
+	partial = strings.ToLower(partial)
+	var completions []Completion
+	add := func(column string, info ColumnInfo) {
+		if !strings.HasPrefix(strings.ToLower(column), partial) || len(c.authorizedOps(column, info.ComparisonOps)) == 0 {
+			return
+		}
+		if info.Map {
+			column += "."
+		}
+		completions = append(completions, c.completion(ColumnCompletion, column, info.Type))
+	}
+	for _, info := range columnInfos(c.validators) {
+		add(info.Column, info)
+	}
+	for queryColumn := range c.opts.withColumnMap {
+		if v, ok := c.lookup(queryColumn); ok {
+			add(queryColumn, columnInfo(v))
+		}
+	}
+	sort.SliceStable(completions, func(i, j int) bool { return completions[i].Text < completions[j].Text })
+	return completions
+}
+
+// comparisonOps returns the comparison operators of the column which begin
+// with the partial operator
+func (c completer) comparisonOps(column, partial string) []Completion {
+	v, ok := c.lookup(column)
+	if !ok {
+		return nil
+	}
+	var completions []Completion
+	for _, o := range c.authorizedOps(column, columnInfo(v).ComparisonOps) {
+		if strings.HasPrefix(string(o), partial) {
+			completions = append(completions, c.completion(ComparisonOpCompletion, string(o), ""))
+		}
+	}
+	return completions
+}
+
+// values returns the enum values or bools of the column which begin with the
+// partial value
+func (c completer) values(column, partial string) []Completion {
+	v, ok := c.lookup(column)
+	if !ok {
+		return nil
+	}
+	var completions []Completion
+	if values, ok := c.opts.withEnums[strings.ToLower(strings.ReplaceAll(c.columnName(column), "_", ""))]; ok {
+		for _, value := range values {
+			if strings.HasPrefix(value, partial) {
+				completions = append(completions, c.completion(ValueCompletion, quoteString(value), ""))
+			}
+		}
+		return completions
+	}
+	if columnInfo(v).Type == "bool" {
+		for _, value := range []string{"true", "false"} {
+			if strings.HasPrefix(value, strings.ToLower(partial)) {
+				completions = append(completions, c.completion(ValueCompletion, value, ""))
+			}
+		}
+	}
+	return completions
+}
+
+// authorizedOps returns the operators which are authorized for the column
+// (see WithFieldAuthorizer)
+func (c completer) authorizedOps(column string, ops []ComparisonOp) []ComparisonOp {
+	if c.opts.withFieldAuthorizer == nil {
+		return ops
+	}
+	var authorized []ComparisonOp
+	for _, o := range ops {
+		if c.opts.withFieldAuthorizer(c.columnName(column), o) == nil {
+			authorized = append(authorized, o)
+		}
+	}
+	return authorized
+}
+
+// columnName returns the column mapped to the query column (see
+// WithColumnMap) or the lowercase query column when it isn't mapped
+func (c completer) columnName(column string) string {
+	columnName := strings.ToLower(column)
+	if n, ok := c.opts.withColumnMap[columnName]; ok {
+		columnName = n
+	}
+	return columnName
+}
+
+// lookup returns the validator of the query column, which may be the key of
+// a map column (ie: labels.env)
+func (c completer) lookup(column string) (validator, bool) {
+	if v, ok := c.validators[strings.ToLower(strings.ReplaceAll(c.columnName(column), "_", ""))]; ok {
+		return v, true
+	}
+	if prefix, _, found := strings.Cut(column, "."); found {
+		if v, ok := c.validators[strings.ToLower(strings.ReplaceAll(c.columnName(prefix), "_", ""))]; ok && v.typ == "map" {
+			// the key's values are the map's elements
+			return validator{typ: v.elemTyp, field: v.field}, true
+		}
+	}
+	return validator{}, false
+}
@@ -0,0 +1,219 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/mql"
+	"github.com/stretchr/testify/assert"
+)
+
+type completeModel struct {
+	Name      string
+	Status    string
+	Age       int
+	Active    bool
+	CreatedAt string
+	Labels    map[string]bool
+	Secret    string
+}
+
+func TestComplete(t *testing.T) {
+	t.Parallel()
+	opts := []mql.Option{
+		mql.WithEnum("status", []string{"active", "archived", "deleted"}),
+		mql.WithColumnAliases("name", "nickname"),
+		mql.WithIgnoredFields("CreatedAt"),
+		mql.WithFieldAuthorizer(func(column string, op mql.ComparisonOp) error {
+			if strings.EqualFold(column, "secret") {
+				return errors.New("not authorized")
+			}
+			if column == "age" && op == mql.EqualOp {
+				return errors.New("not authorized")
+			}
+			return nil
+		}),
+	}
+	texts := func(completions []mql.Completion) []string {
+		var got []string
+		for _, c := range completions {
+			got = append(got, c.Text)
+		}
+		return got
+	}
+	tests := []struct {
+		name      string
+		query     string
+		cursor    int // defaults to the end of the query
+		want      []string
+		wantKind  mql.CompletionKind
+		wantStart int
+	}{
+		{
+			name:     "empty",
+			query:    ``,
+			want:     []string{"active", "age", "labels.", "name", "nickname", "status"},
+			wantKind: mql.ColumnCompletion,
+		},
+		{
+			name:      "partial-column",
+			query:     `name="alice" and (NA`,
+			want:      []string{"name"},
+			wantKind:  mql.ColumnCompletion,
+			wantStart: 18,
+		},
+		{
+			name:      "cursor-before-the-end",
+			query:     `ni="alice"`,
+			cursor:    2,
+			want:      []string{"nickname"},
+			wantKind:  mql.ColumnCompletion,
+			wantStart: 0,
+		},
+		{
+			name:      "comparison-ops",
+			query:     `active `,
+			want:      []string{"=", "!="},
+			wantKind:  mql.ComparisonOpCompletion,
+			wantStart: 7,
+		},
+		{
+			name:      "authorized-comparison-ops",
+			query:     `age `,
+			want:      []string{"!=", ">", ">=", "<", "<=", "%"},
+			wantKind:  mql.ComparisonOpCompletion,
+			wantStart: 4,
+		},
+		{
+			name:      "partial-comparison-op",
+			query:     `nickname<`,
+			want:      []string{"<", "<="},
+			wantKind:  mql.ComparisonOpCompletion,
+			wantStart: 8,
+		},
+		{
+			name:      "enum-values",
+			query:     `status = `,
+			want:      []string{`"active"`, `"archived"`, `"deleted"`},
+			wantKind:  mql.ValueCompletion,
+			wantStart: 9,
+		},
+		{
+			name:      "complete-comparison-op",
+			query:     `status=`,
+			want:      []string{`"active"`, `"archived"`, `"deleted"`},
+			wantKind:  mql.ValueCompletion,
+			wantStart: 7,
+		},
+		{
+			name:      "partial-quoted-enum-value",
+			query:     `status="ar`,
+			want:      []string{`"archived"`},
+			wantKind:  mql.ValueCompletion,
+			wantStart: 7,
+		},
+		{
+			name:      "partial-enum-value",
+			query:     `status=a`,
+			want:      []string{`"active"`, `"archived"`},
+			wantKind:  mql.ValueCompletion,
+			wantStart: 7,
+		},
+		{
+			name:      "bool-values",
+			query:     `active!=t`,
+			want:      []string{"true"},
+			wantKind:  mql.ValueCompletion,
+			wantStart: 8,
+		},
+		{
+			name:      "map-values",
+			query:     `labels.env=`,
+			want:      []string{"true", "false"},
+			wantKind:  mql.ValueCompletion,
+			wantStart: 11,
+		},
+		{
+			name:      "logical-ops",
+			query:     `name="alice" `,
+			want:      []string{"and", "or"},
+			wantKind:  mql.LogicalOpCompletion,
+			wantStart: 13,
+		},
+		{
+			name:      "logical-ops-without-whitespace",
+			query:     `(name="alice")`,
+			want:      []string{" and", " or"},
+			wantKind:  mql.LogicalOpCompletion,
+			wantStart: 14,
+		},
+		{
+			name:      "partial-logical-op",
+			query:     `age>21 AN`,
+			want:      []string{"and"},
+			wantKind:  mql.LogicalOpCompletion,
+			wantStart: 7,
+		},
+		{
+			name:  "unknown-column",
+			query: `email=`,
+		},
+		{
+			name:  "unauthorized-column",
+			query: `sec`,
+		},
+		{
+			name:  "ignored-column",
+			query: `created`,
+		},
+		{
+			name:  "map-keys",
+			query: `labels.e`,
+		},
+		{
+			name:  "no-values",
+			query: `name="al`,
+		},
+		{
+			name:  "invalid-query",
+			query: `name="alice" = `,
+		},
+		{
+			name:   "invalid-cursor",
+			query:  `name`,
+			cursor: 5,
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert := assert.New(t)
+			cursor := tc.cursor
+			if cursor == 0 {
+				cursor = len(tc.query)
+			}
+			got := mql.Complete(tc.query, cursor, completeModel{}, opts...)
+			assert.Equal(tc.want, texts(got))
+			for _, c := range got {
+				assert.Equal(tc.wantKind, c.Kind)
+				assert.Equal(tc.wantStart, c.Start)
+				assert.Equal(cursor, c.End)
+			}
+		})
+	}
+	t.Run("details", func(t *testing.T) {
+		got := mql.Complete(`a`, 1, completeModel{}, opts...)
+		assert.Equal(t, []mql.Completion{
+			{Kind: mql.ColumnCompletion, Text: "active", Detail: "bool", Start: 0, End: 1},
+			{Kind: mql.ColumnCompletion, Text: "age", Detail: "int", Start: 0, End: 1},
+		}, got)
+	})
+	t.Run("missing-model", func(t *testing.T) {
+		assert.Nil(t, mql.Complete(`a`, 1, nil))
+	})
+}
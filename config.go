@@ -0,0 +1,90 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the filter policy of a model, which can be loaded from a YAML or
+// JSON document (see LoadConfig), so it can be adjusted without recompiling
+// the services which use it.  It's converted into options by Options.
+type Config struct {
+	// ColumnMap maps query columns to the model's columns (see WithColumnMap)
+	ColumnMap map[string]string `json:"column_map,omitempty" yaml:"column_map"`
+	// IgnoredFields are the model's fields which can't be queried (see
+	// WithIgnoredFields)
+	IgnoredFields []string `json:"ignored_fields,omitempty" yaml:"ignored_fields"`
+	// Operators are the comparison operators allowed for a column (see
+	// WithColumnOperators)
+	Operators map[string][]ComparisonOp `json:"operators,omitempty" yaml:"operators"`
+	// DisabledOperators are the comparison operators which can't be used by
+	// any column (see WithDisabledOperators)
+	DisabledOperators []ComparisonOp `json:"disabled_operators,omitempty" yaml:"disabled_operators"`
+	// Enums are the values allowed for a column (see WithEnum)
+	Enums map[string][]string `json:"enums,omitempty" yaml:"enums"`
+}
+
+// LoadConfig will load the filter policy of a model from a YAML or JSON
+// document (JSON is valid YAML), like:
+//
+//	column_map:
+//	  nickname: name
+//	ignored_fields: [Password]
+//	operators:
+//	  email: ["=", "!="]
+//	disabled_operators: ["%"]
+//	enums:
+//	  status: [active, disabled]
+//
+// Unknown keys and invalid operators are an ErrInvalidParameter, so typos
+// aren't silently ignored, and the config's options are validated (see
+// Options).  The columns and fields of the config can be validated against
+// the model using ValidateOptions.
+func LoadConfig(r io.Reader) (*Config, error) {
+	const op = "mql.LoadConfig"
+	if isNil(r) {
+		return nil, fmt.Errorf("%s: missing reader: %w", op, ErrInvalidParameter)
+	}
+	dec := yaml.NewDecoder(r)
+	dec.KnownFields(true)
+	var c Config
+	switch err := dec.Decode(&c); {
+	case errors.Is(err, io.EOF):
+		return nil, fmt.Errorf("%s: empty config: %w", op, ErrInvalidParameter)
+	case err != nil:
+		return nil, fmt.Errorf("%s: %w: %w", op, ErrInvalidParameter, err)
+	}
+	if _, err := getOpts(c.Options()...); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return &c, nil
+}
+
+// Options returns the options of the config, which can be provided to Parse
+// (or NewProfile) along with any other options.  The options of its maps are
+// sorted by column, so they're always in the same order.
+func (c *Config) Options() []Option {
+	var opts []Option
+	if len(c.ColumnMap) > 0 {
+		opts = append(opts, WithColumnMap(c.ColumnMap))
+	}
+	if len(c.IgnoredFields) > 0 {
+		opts = append(opts, WithIgnoredFields(c.IgnoredFields...))
+	}
+	for _, column := range sortedKeys(c.Operators) {
+		opts = append(opts, WithColumnOperators(column, c.Operators[column]...))
+	}
+	if len(c.DisabledOperators) > 0 {
+		opts = append(opts, WithDisabledOperators(c.DisabledOperators...))
+	}
+	for _, column := range sortedKeys(c.Enums) {
+		opts = append(opts, WithEnum(column, c.Enums[column]))
+	}
+	return opts
+}
@@ -0,0 +1,102 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/mql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfig(t *testing.T) {
+	t.Parallel()
+	const yamlConfig = `
+column_map:
+  nickname: name
+ignored_fields: [Email]
+operators:
+  age: [">", "<"]
+disabled_operators: ["%"]
+enums:
+  name: [alice, bob]
+`
+	const jsonConfig = `{
+	"column_map": {"nickname": "name"},
+	"ignored_fields": ["Email"],
+	"operators": {"age": [">", "<"]},
+	"disabled_operators": ["%"],
+	"enums": {"name": ["alice", "bob"]}
+}`
+	want := &mql.Config{
+		ColumnMap:         map[string]string{"nickname": "name"},
+		IgnoredFields:     []string{"Email"},
+		Operators:         map[string][]mql.ComparisonOp{"age": {mql.GreaterThanOp, mql.LessThanOp}},
+		DisabledOperators: []mql.ComparisonOp{mql.ContainsOp},
+		Enums:             map[string][]string{"name": {"alice", "bob"}},
+	}
+	tests := []struct {
+		name      string
+		config    string
+		want      *mql.Config
+		wantErrIs error
+	}{
+		{name: "yaml", config: yamlConfig, want: want},
+		{name: "json", config: jsonConfig, want: want},
+		{name: "partial", config: `ignored_fields: [Email]`, want: &mql.Config{IgnoredFields: []string{"Email"}}},
+		{name: "err-empty", config: ``, wantErrIs: mql.ErrInvalidParameter},
+		{name: "err-syntax", config: `{"column_map": `, wantErrIs: mql.ErrInvalidParameter},
+		{name: "err-unknown-key", config: `ignore_fields: [Email]`, wantErrIs: mql.ErrInvalidParameter},
+		{name: "err-invalid-op", config: `disabled_operators: ["=="]`, wantErrIs: mql.ErrInvalidParameter},
+		{name: "err-empty-enum", config: `enums: {name: []}`, wantErrIs: mql.ErrInvalidParameter},
+		{name: "err-empty-column", config: `operators: {"": ["="]}`, wantErrIs: mql.ErrInvalidParameter},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert, require := assert.New(t), require.New(t)
+			got, err := mql.LoadConfig(strings.NewReader(tc.config))
+			if tc.wantErrIs != nil {
+				require.Error(err)
+				assert.ErrorIs(err, tc.wantErrIs)
+				assert.Nil(got)
+				return
+			}
+			require.NoError(err)
+			assert.Equal(tc.want, got)
+		})
+	}
+	t.Run("missing-reader", func(t *testing.T) {
+		_, err := mql.LoadConfig(nil)
+		assert.ErrorIs(t, err, mql.ErrInvalidParameter)
+	})
+	t.Run("options", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		c, err := mql.LoadConfig(strings.NewReader(yamlConfig))
+		require.NoError(err)
+		require.NoError(mql.ValidateOptions(testModel{}, c.Options()...))
+
+		got, err := mql.Parse(`nickname="alice" and age>21`, testModel{}, c.Options()...)
+		require.NoError(err)
+		assert.Equal(&mql.WhereClause{Condition: "(name=? and age>?)", Args: []any{"alice", 21}}, got)
+
+		_, err = mql.Parse(`nickname="carol"`, testModel{}, c.Options()...)
+		assert.ErrorIs(err, mql.ErrInvalidEnumValue)
+		_, err = mql.Parse(`age=21`, testModel{}, c.Options()...)
+		assert.ErrorIs(err, mql.ErrInvalidComparisonOp)
+		_, err = mql.Parse(`name%"alice"`, testModel{}, c.Options()...)
+		assert.ErrorIs(err, mql.ErrInvalidComparisonOp)
+		_, err = mql.Parse(`email="alice@example.com"`, testModel{}, c.Options()...)
+		assert.ErrorIs(err, mql.ErrInvalidColumn)
+
+		profile, err := mql.NewProfile(c.Options()...)
+		require.NoError(err)
+		got, err = mql.Parse(`nickname="bob"`, testModel{}, mql.WithProfile(profile))
+		require.NoError(err)
+		assert.Equal("name=?", got.Condition)
+	})
+}
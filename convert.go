@@ -0,0 +1,119 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql
+
+import (
+	"fmt"
+)
+
+// ParseExpr will parse the query and return its expr tree without validating
+// it against a model.  Parse errors are returned as a *ParseError.  See
+// ConvertExpr.  Supported options: WithMaxQueryLength, WithMaxTokens,
+// WithMaxStringLength
+func ParseExpr(query string, opt ...Option) (Expr, error) {
+	const op = "mql.ParseExpr"
+	opts, err := getOpts(opt...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if query == "" {
+		return nil, fmt.Errorf("%s: missing query: %w", op, ErrInvalidParameter)
+	}
+	p := newParser(query)
+	p.configure(opts)
+	e, err := p.parse()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return e, nil
+}
+
+// ExprConverter converts an expr tree into another representation, like the
+// expressions of a query builder.  See ConvertExpr
+type ExprConverter[T any] interface {
+	// Comparison converts a comparison, which has already been validated and
+	// converted into a where clause with ? placeholders.
+	Comparison(w *WhereClause) (T, error)
+
+	// Logical combines the converted left and right sides of a logical expr.
+	Logical(op LogicalOp, left, right T) (T, error)
+}
+
+// ConvertExpr will use the model to validate the expr tree (see ParseExpr) and
+// then convert it using the converter, from its comparisons up to its root.
+// Every comparison is converted into a where clause using the same rules as
+// Parse, but placeholders are left to the converter, so WithPgPlaceholders,
+// WithSqlNamedArgs, WithNamedParams and WithInlineValues are not supported.
+// Supported options: WithColumnMap, WithIgnoreFields, WithConverter,
+// WithModelDescriber
+func ConvertExpr[T any](e Expr, model any, c ExprConverter[T], opt ...Option) (T, error) {
+	const op = "mql.ConvertExpr"
+	var zero T
+	opts, err := getOpts(opt...)
+	if err != nil {
+		return zero, fmt.Errorf("%s: %w", op, err)
+	}
+	switch {
+	case isNil(e):
+		return zero, fmt.Errorf("%s: missing expression: %w", op, ErrInvalidParameter)
+	case isNil(model):
+		return zero, fmt.Errorf("%s: missing model: %w", op, ErrInvalidParameter)
+	case isNil(c):
+		return zero, fmt.Errorf("%s: missing converter: %w", op, ErrInvalidParameter)
+	case opts.withPgPlaceholder || opts.withSqlNamedArgs || opts.withNamedParams != "" || opts.withInlineValues:
+		return zero, fmt.Errorf("%s: placeholder options are not supported when converting an expr: %w", op, ErrInvalidParameter)
+	}
+	if opt, err = withModelTable(model, opt); err != nil {
+		return zero, fmt.Errorf("%s: %w", op, err)
+	}
+	fValidators, err := modelValidators(model, opt...)
+	if err != nil {
+		return zero, fmt.Errorf("%s: %w", op, err)
+	}
+	if opts.withOptimize {
+		e = optimizeExpr(e, fValidators, opts)
+	}
+	converted, err := convertExpr(e, fValidators, c, opt...)
+	if err != nil {
+		return zero, fmt.Errorf("%s: %w", op, err)
+	}
+	return converted, nil
+}
+
+func convertExpr[T any](e Expr, fValidators map[string]validator, c ExprConverter[T], opt ...Option) (T, error) {
+	const op = "mql.convertExpr"
+	var zero T
+	switch v := e.(type) {
+	case *ComparisonExpr:
+		w, err := exprToWhereClause(v, fValidators, opt...)
+		if err != nil {
+			return zero, fmt.Errorf("%s: %w", op, err)
+		}
+		w.argColumns = nil
+		converted, err := c.Comparison(w)
+		if err != nil {
+			return zero, fmt.Errorf("%s: %w", op, err)
+		}
+		return converted, nil
+	case *LogicalExpr:
+		if v.LogicalOp == "" {
+			return zero, fmt.Errorf("%s: %w", op, ErrMissingLogicalOp)
+		}
+		left, err := convertExpr(v.LeftExpr, fValidators, c, opt...)
+		if err != nil {
+			return zero, fmt.Errorf("%s: invalid left expr: %w", op, err)
+		}
+		right, err := convertExpr(v.RightExpr, fValidators, c, opt...)
+		if err != nil {
+			return zero, fmt.Errorf("%s: invalid right expr: %w", op, err)
+		}
+		converted, err := c.Logical(v.LogicalOp, left, right)
+		if err != nil {
+			return zero, fmt.Errorf("%s: %w", op, err)
+		}
+		return converted, nil
+	default:
+		return zero, fmt.Errorf("%s: unexpected expr type %T: %w", op, v, ErrInternal)
+	}
+}
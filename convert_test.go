@@ -0,0 +1,121 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/mql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// whereClauseConverter converts an expr tree back into a where clause, so the
+// results of ConvertExpr can be compared to the results of Parse.
+type whereClauseConverter struct{}
+
+func (whereClauseConverter) Comparison(w *mql.WhereClause) (*mql.WhereClause, error) {
+	return w, nil
+}
+
+func (whereClauseConverter) Logical(op mql.LogicalOp, left, right *mql.WhereClause) (*mql.WhereClause, error) {
+	return &mql.WhereClause{
+		Condition: fmt.Sprintf("(%s %s %s)", left.Condition, op, right.Condition),
+		Args:      append(left.Args, right.Args...),
+	}, nil
+}
+
+// errConverter fails every conversion
+type errConverter struct{}
+
+func (errConverter) Comparison(*mql.WhereClause) (string, error) {
+	return "", errors.New("comparison failed")
+}
+
+func (errConverter) Logical(mql.LogicalOp, string, string) (string, error) {
+	return "", errors.New("logical failed")
+}
+
+func TestConvertExpr(t *testing.T) {
+	t.Parallel()
+	t.Run("same-as-parse", func(t *testing.T) {
+		opts := []mql.Option{mql.WithColumnMap(map[string]string{"nickname": "name"})}
+		for _, query := range []string{
+			`name="alice"`,
+			`nickname="alice" and age > 21`,
+			`(name="alice" or email%"example") and created_at > "2023-01-01"`,
+			`labels.env="prod" or scores.math >= 90 and length < 1.5`,
+		} {
+			want, err := mql.Parse(query, testModel{}, opts...)
+			require.NoError(t, err)
+			e, err := mql.ParseExpr(query)
+			require.NoError(t, err)
+			got, err := mql.ConvertExpr[*mql.WhereClause](e, testModel{}, whereClauseConverter{}, opts...)
+			require.NoError(t, err)
+			assert.Equal(t, want, got, query)
+		}
+	})
+	t.Run("jsonapi-expr", func(t *testing.T) {
+		e, err := mql.JsonApiFilterExpr(map[string][]string{"filter[age][gt]": {"21"}})
+		require.NoError(t, err)
+		got, err := mql.ConvertExpr[*mql.WhereClause](e, testModel{}, whereClauseConverter{})
+		require.NoError(t, err)
+		assert.Equal(t, &mql.WhereClause{Condition: "age>?", Args: []any{21}}, got)
+	})
+	t.Run("err-invalid-column", func(t *testing.T) {
+		e, err := mql.ParseExpr(`name="alice" and nickname="alice"`)
+		require.NoError(t, err)
+		_, err = mql.ConvertExpr[*mql.WhereClause](e, testModel{}, whereClauseConverter{})
+		require.Error(t, err)
+		assert.ErrorIs(t, err, mql.ErrInvalidColumn)
+		assert.ErrorContains(t, err, "invalid right expr")
+	})
+	t.Run("err-converter", func(t *testing.T) {
+		e, err := mql.ParseExpr(`name="alice"`)
+		require.NoError(t, err)
+		_, err = mql.ConvertExpr[string](e, testModel{}, errConverter{})
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "comparison failed")
+	})
+	t.Run("err-placeholder-option", func(t *testing.T) {
+		e, err := mql.ParseExpr(`name="alice"`)
+		require.NoError(t, err)
+		_, err = mql.ConvertExpr[*mql.WhereClause](e, testModel{}, whereClauseConverter{}, mql.WithPgPlaceholders())
+		require.Error(t, err)
+		assert.ErrorIs(t, err, mql.ErrInvalidParameter)
+		assert.ErrorContains(t, err, "placeholder options are not supported")
+	})
+	t.Run("err-missing-params", func(t *testing.T) {
+		e, err := mql.ParseExpr(`name="alice"`)
+		require.NoError(t, err)
+		_, err = mql.ConvertExpr[*mql.WhereClause](nil, testModel{}, whereClauseConverter{})
+		assert.ErrorContains(t, err, "missing expression")
+		_, err = mql.ConvertExpr[*mql.WhereClause](e, nil, whereClauseConverter{})
+		assert.ErrorContains(t, err, "missing model")
+		_, err = mql.ConvertExpr[*mql.WhereClause](e, testModel{}, nil)
+		assert.ErrorContains(t, err, "missing converter")
+	})
+}
+
+func TestParseExpr(t *testing.T) {
+	t.Parallel()
+	e, err := mql.ParseExpr(`name="alice" and nickname="bob"`)
+	require.NoError(t, err)
+	want := &mql.LogicalExpr{
+		LeftExpr:  &mql.ComparisonExpr{Column: "name", ComparisonOp: mql.EqualOp, Value: pointer("alice")},
+		LogicalOp: mql.AndOp,
+		RightExpr: &mql.ComparisonExpr{Column: "nickname", ComparisonOp: mql.EqualOp, Value: pointer("bob")},
+	}
+	assert.Equal(t, want.String(), e.String())
+
+	_, err = mql.ParseExpr(`name="alice" and`)
+	require.Error(t, err)
+	var pErr *mql.ParseError
+	assert.ErrorAs(t, err, &pErr)
+
+	_, err = mql.ParseExpr("")
+	assert.ErrorIs(t, err, mql.ErrInvalidParameter)
+}
@@ -0,0 +1,141 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql
+
+import (
+	"fmt"
+	"strings"
+)
+
+// UnindexedColumnCost is the cost of comparing a column without a cost hint
+// (see WithCostHints), which is assumed to require scanning every row.
+const UnindexedColumnCost = 100
+
+// comparisonOpCosts are the multipliers of the cost of a column by comparison
+// operator, since the less selective the operator is, the more rows are read
+// (ie: a range reads more rows than an equality).
+var comparisonOpCosts = map[ComparisonOp]int{
+	EqualOp:              1,
+	ArrayContainsOp:      2,
+	ContainedByOp:        2,
+	GreaterThanOp:        3,
+	GreaterThanOrEqualOp: 3,
+	LessThanOp:           3,
+	LessThanOrEqualOp:    3,
+}
+
+// unindexedOps are the comparison operators which can't use an index on the
+// column (ie: not equal or a like pattern with a leading wildcard), so their
+// column always costs UnindexedColumnCost.
+var unindexedOps = map[ComparisonOp]int{
+	NotEqualOp:  10,
+	ContainsOp:  10,
+	SimilarToOp: 10,
+}
+
+// WithCostHints provides the cost of comparing columns (database column or
+// model field name) for EstimateCost, which is typically lower for indexed
+// columns (ie: {"id": 1, "email": 5}).  Columns without a hint cost
+// UnindexedColumnCost.  Hints must be positive.
+func WithCostHints(hints map[string]int) Option {
+	const op = "mql.WithCostHints"
+	return func(o *options) error {
+		if len(hints) == 0 {
+			return fmt.Errorf("%s: missing hints: %w", op, ErrInvalidParameter)
+		}
+		if o.withCostHints == nil {
+			o.withCostHints = make(map[string]int, len(hints))
+		}
+		for c, cost := range hints {
+			switch {
+			case c == "":
+				return fmt.Errorf("%s: missing column: %w", op, ErrInvalidParameter)
+			case cost <= 0:
+				return fmt.Errorf("%s: cost %d of column %q isn't positive: %w", op, cost, c, ErrInvalidParameter)
+			}
+			o.withCostHints[strings.ToLower(strings.ReplaceAll(c, "_", ""))] = cost
+		}
+		return nil
+	}
+}
+
+// EstimateCost will use the provided database model to validate the expr tree
+// (ie: returned by ParseExpr or built using C) and return its estimated cost,
+// so services can reject or queue expensive filters before running them.  The
+// cost of a comparison is the cost of its column (see WithCostHints)
+// multiplied by the selectivity of its operator: 1 for =, 2 for @> and <<, 3
+// for range comparisons, while !=, % and ~% can't use an index so they're 10
+// times UnindexedColumnCost.  An "and" costs as much as its cheapest operand,
+// since the database can use its index and filter the rows it reads, while an
+// "or" costs the sum of its operands.  Costs are relative, so they're only
+// meaningful compared to other costs (ie: a maximum cost of a service).
+// Supported options: WithCostHints and the same options as Parse.
+func EstimateCost(e Expr, model any, opt ...Option) (int, error) {
+	const op = "mql.EstimateCost"
+	switch {
+	case isNil(e):
+		return 0, fmt.Errorf("%s: missing expression: %w", op, ErrInvalidParameter)
+	case isNil(model):
+		return 0, fmt.Errorf("%s: missing model: %w", op, ErrInvalidParameter)
+	}
+	opt, err := withModelTable(model, opt)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	opts, err := getOpts(opt...)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	fValidators, err := modelValidators(model, opt...)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	if _, err := exprToWhereClause(e, fValidators, opt...); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return exprCost(e, fValidators, opts), nil
+}
+
+// exprCost returns the cost of the valid expr tree (see EstimateCost)
+func exprCost(e Expr, fValidators map[string]validator, opts options) int {
+	switch v := e.(type) {
+	case *ComparisonExpr:
+		if cost, ok := unindexedOps[v.ComparisonOp]; ok {
+			return cost * UnindexedColumnCost
+		}
+		cost, ok := comparisonOpCosts[v.ComparisonOp]
+		if !ok {
+			cost = 1
+		}
+		return cost * columnCost(v, fValidators, opts)
+	case *LogicalExpr:
+		left, right := exprCost(v.LeftExpr, fValidators, opts), exprCost(v.RightExpr, fValidators, opts)
+		if v.LogicalOp == OrOp {
+			return left + right
+		}
+		if left < right {
+			return left
+		}
+		return right
+	default:
+		return 0
+	}
+}
+
+// columnCost returns the cost hint of the comparison's column (see
+// WithCostHints) or UnindexedColumnCost when it doesn't have one.  Map and
+// relationship columns (ie: labels.env) use the hint of their column when
+// they don't have their own.
+func columnCost(c *ComparisonExpr, fValidators map[string]validator, opts options) int {
+	column := resolveColumn(c, fValidators, opts).Column
+	if cost, ok := opts.withCostHints[strings.ToLower(strings.ReplaceAll(column, "_", ""))]; ok {
+		return cost
+	}
+	if prefix, _, found := strings.Cut(column, "."); found {
+		if cost, ok := opts.withCostHints[strings.ToLower(strings.ReplaceAll(prefix, "_", ""))]; ok {
+			return cost
+		}
+	}
+	return UnindexedColumnCost
+}
@@ -0,0 +1,120 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql_test
+
+import (
+	"testing"
+
+	"github.com/hashicorp/mql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEstimateCost(t *testing.T) {
+	t.Parallel()
+	hints := mql.WithCostHints(map[string]int{"id": 1, "Email": 5, "member_number": 2, "labels": 20})
+	tests := []struct {
+		name            string
+		query           string
+		opts            []mql.Option
+		want            int
+		wantErrIs       error
+		wantErrContains string
+	}{
+		{
+			name:  "unindexed",
+			query: `name="alice"`,
+			want:  mql.UnindexedColumnCost,
+		},
+		{
+			name:  "indexed",
+			query: `id=1`,
+			opts:  []mql.Option{hints},
+			want:  1,
+		},
+		{
+			name:  "range",
+			query: `email>"m"`,
+			opts:  []mql.Option{hints},
+			want:  15,
+		},
+		{
+			name:  "and-uses-cheapest",
+			query: `name="alice" and memberNumber="7" and age>21`,
+			opts:  []mql.Option{hints},
+			want:  2,
+		},
+		{
+			name:  "or-sums",
+			query: `id=1 or email="alice@example.com" or name="alice"`,
+			opts:  []mql.Option{hints},
+			want:  106,
+		},
+		{
+			name:  "unindexed-ops",
+			query: `id!=1 or email%"alice"`,
+			opts:  []mql.Option{hints},
+			want:  2000,
+		},
+		{
+			name:  "map-column",
+			query: `labels.env="prod"`,
+			opts:  []mql.Option{hints},
+			want:  20,
+		},
+		{
+			name:  "column-map",
+			query: `user_id=1`,
+			opts:  []mql.Option{hints, mql.WithColumnMap(map[string]string{"user_id": "id"})},
+			want:  1,
+		},
+		{
+			name:      "err-invalid-column",
+			query:     `nickname="alice"`,
+			wantErrIs: mql.ErrInvalidColumn,
+		},
+		{
+			name:            "err-invalid-hint",
+			query:           `id=1`,
+			opts:            []mql.Option{mql.WithCostHints(map[string]int{"id": 0})},
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: `cost 0 of column "id" isn't positive`,
+		},
+		{
+			name:      "err-missing-hints",
+			query:     `id=1`,
+			opts:      []mql.Option{mql.WithCostHints(nil)},
+			wantErrIs: mql.ErrInvalidParameter,
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert, require := assert.New(t), require.New(t)
+			e, err := mql.ParseExpr(tc.query)
+			require.NoError(err)
+			got, err := mql.EstimateCost(e, testModel{}, tc.opts...)
+			if tc.wantErrIs != nil {
+				require.Error(err)
+				assert.ErrorIs(err, tc.wantErrIs)
+				if tc.wantErrContains != "" {
+					assert.ErrorContains(err, tc.wantErrContains)
+				}
+				assert.Zero(got)
+				return
+			}
+			require.NoError(err)
+			assert.Equal(tc.want, got)
+		})
+	}
+	t.Run("missing-expr", func(t *testing.T) {
+		_, err := mql.EstimateCost(nil, testModel{})
+		assert.ErrorIs(t, err, mql.ErrInvalidParameter)
+	})
+	t.Run("ValidateOptions", func(t *testing.T) {
+		err := mql.ValidateOptions(testModel{}, mql.WithCostHints(map[string]int{"nickname": 1}))
+		assert.ErrorContains(t, err, `WithCostHints column "nickname" isn't a column of the model`)
+	})
+}
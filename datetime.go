@@ -0,0 +1,190 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dateLayout is the layout of a date literal
+const dateLayout = "2006-01-02"
+
+// timeLayouts are the layouts supported for date/time literals.  Literals
+// without a time zone are in UTC.
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04",
+	"2006-01-02T15:04",
+}
+
+// parseTimeLiteral will parse a date (YYYY-MM-DD), a date/time (RFC3339 or
+// YYYY-MM-DD HH:MM[:SS]) or a relative time (see parseRelativeTime) literal and
+// report if it was just a date.  Relative times are resolved using now.
+func parseTimeLiteral(s string, now time.Time) (time.Time, bool, error) {
+	const op = "mql.parseTimeLiteral"
+	if t, err := time.Parse(dateLayout, s); err == nil {
+		return t, true, nil
+	}
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, false, nil
+		}
+	}
+	if t, dateOnly, ok := parseRelativeTime(s, now); ok {
+		return t, dateOnly, nil
+	}
+	return time.Time{}, false, fmt.Errorf("%s: value %q is not a date/time: %w", op, s, ErrInvalidParameter)
+}
+
+// parseRelativeTime will parse a relative time literal: now or today
+// optionally followed by + or - and an offset, which is either a Go duration
+// (now-24h, now-1h30m) or a number of days or weeks (today-7d, now+2w).  today
+// is the start of now's day (in now's location) and it's a date, unless it's
+// offset by a duration.  Keywords are case insensitive.
+func parseRelativeTime(s string, now time.Time) (time.Time, bool, bool) {
+	s = strings.ToLower(s)
+	var t time.Time
+	var dateOnly bool
+	switch {
+	case strings.HasPrefix(s, "now"):
+		t, s = now, strings.TrimPrefix(s, "now")
+	case strings.HasPrefix(s, "today"):
+		y, m, d := now.Date()
+		t, dateOnly, s = time.Date(y, m, d, 0, 0, 0, 0, now.Location()), true, strings.TrimPrefix(s, "today")
+	default:
+		return time.Time{}, false, false
+	}
+	if s == "" {
+		return t, dateOnly, true
+	}
+	sign := 1
+	switch s[0] {
+	case '+':
+	case '-':
+		sign = -1
+	default:
+		return time.Time{}, false, false
+	}
+	offset := s[1:]
+	if offset == "" || offset[0] < '0' || offset[0] > '9' {
+		return time.Time{}, false, false
+	}
+	switch unit := offset[len(offset)-1]; unit {
+	case 'd', 'w':
+		n, err := strconv.Atoi(offset[:len(offset)-1])
+		if err != nil {
+			return time.Time{}, false, false
+		}
+		if unit == 'w' {
+			n *= 7
+		}
+		return t.AddDate(0, 0, sign*n), dateOnly, true
+	}
+	d, err := time.ParseDuration(offset)
+	if err != nil {
+		return time.Time{}, false, false
+	}
+	return t.Add(time.Duration(sign) * d), false, true
+}
+
+// isRelativeTimeLiteral reports if s is a relative time literal (see
+// parseRelativeTime)
+func isRelativeTimeLiteral(s string) bool {
+	_, _, ok := parseRelativeTime(s, time.Time{})
+	return ok
+}
+
+// timeValidator returns a validateFunc which converts a date/time literal to
+// a time.Time, resolving relative times using now
+func timeValidator(now time.Time) validateFunc {
+	const op = "mql.validateTime"
+	return func(s string) (any, error) {
+		t, _, err := parseTimeLiteral(s, now)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		return t, nil
+	}
+}
+
+// isDateLiteral reports if the literal is a date (which matches a whole day)
+// rather than a date/time.  This doesn't depend on when relative times are
+// resolved.
+func isDateLiteral(s string) bool {
+	_, dateOnly, err := parseTimeLiteral(s, time.Time{})
+	return err == nil && dateOnly
+}
+
+// timeCondition returns the where clause which compares the lhs (a column or
+// an expression with lhsArgs) to the time literal.  A date/time is compared
+// as is, but a date matches the whole day, so name="2023-01-02" is converted
+// to: (lhs>=? and lhs<?) with the args 2023-01-02 and 2023-01-03.  Only
+// standard comparison operators are used, so the condition works with every
+// dialect.
+func timeCondition(lhs string, lhsArgs []any, comparisonOp ComparisonOp, t time.Time, dateOnly bool) (*WhereClause, error) {
+	const op = "mql.timeCondition"
+	cmp := func(cmpOp string, v time.Time) *WhereClause {
+		return &WhereClause{
+			Condition: fmt.Sprintf("%s%s?", lhs, cmpOp),
+			Args:      append(append([]any{}, lhsArgs...), v),
+		}
+	}
+	if comparisonOp == ContainsOp {
+		return nil, fmt.Errorf("%s: %w %q for a date/time", op, ErrInvalidComparisonOp, comparisonOp)
+	}
+	if !dateOnly {
+		return cmp(string(comparisonOp), t), nil
+	}
+	nextDay := t.AddDate(0, 0, 1)
+	switch comparisonOp {
+	case EqualOp:
+		return and(cmp(">=", t), cmp("<", nextDay)), nil
+	case NotEqualOp:
+		return or(cmp("<", t), cmp(">=", nextDay)), nil
+	case GreaterThanOp:
+		return cmp(">=", nextDay), nil
+	case GreaterThanOrEqualOp:
+		return cmp(">=", t), nil
+	case LessThanOp:
+		return cmp("<", t), nil
+	case LessThanOrEqualOp:
+		return cmp("<", nextDay), nil
+	default:
+		return nil, fmt.Errorf("%s: %w %q", op, ErrInvalidComparisonOp, comparisonOp)
+	}
+}
+
+// and returns the where clauses combined with and
+func and(left, right *WhereClause) *WhereClause {
+	return &WhereClause{
+		Condition: fmt.Sprintf("(%s and %s)", left.Condition, right.Condition),
+		Args:      append(left.Args, right.Args...),
+	}
+}
+
+// or returns the where clauses combined with or
+func or(left, right *WhereClause) *WhereClause {
+	return &WhereClause{
+		Condition: fmt.Sprintf("(%s or %s)", left.Condition, right.Condition),
+		Args:      append(left.Args, right.Args...),
+	}
+}
+
+// compareTime compares the time to the time of a date/time literal just like
+// the condition from timeCondition: a date/time is compared as is and a date is
+// compared to the time's date (in the date's location).
+func compareTime(t, q time.Time, dateOnly bool) int {
+	if !dateOnly {
+		return t.Compare(q)
+	}
+	ty, tm, td := t.In(q.Location()).Date()
+	qy, qm, qd := q.Date()
+	return compareOrdered(ty*10000+int(tm)*100+td, qy*10000+int(qm)*100+qd)
+}
@@ -0,0 +1,42 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+// DebugLogger is used to trace how a query is lexed and parsed (see
+// WithDebugLogger).  It's satisfied by an hclog.Logger and a *slog.Logger and
+// the args are key/value pairs.
+type DebugLogger interface {
+	Debug(msg string, args ...any)
+}
+
+// WithDebugLogger provides an optional logger which traces the tokens emitted
+// by the lexer, the lexer's state transitions and the expressions built by the
+// parser, so you can diagnose why a query isn't parsed as expected.  It's very
+// verbose and shouldn't be used in production.
+func WithDebugLogger(l DebugLogger) Option {
+	const op = "mql.WithDebugLogger"
+	return func(o *options) error {
+		if isNil(l) {
+			return fmt.Errorf("%s: missing logger: %w", op, ErrInvalidParameter)
+		}
+		o.withDebugLogger = l
+		return nil
+	}
+}
+
+// stateName returns the name of the lexer's state func (ie: lexStringState)
+func stateName(fn lexStateFunc) string {
+	if fn == nil {
+		return ""
+	}
+	name := runtime.FuncForPC(reflect.ValueOf(fn).Pointer()).Name()
+	return name[strings.LastIndex(name, ".")+1:]
+}
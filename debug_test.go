@@ -0,0 +1,54 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/mql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testLogger records every debug message along with its args
+type testLogger struct {
+	lines []string
+}
+
+func (l *testLogger) Debug(msg string, args ...any) {
+	l.lines = append(l.lines, strings.TrimSpace(fmt.Sprintln(append([]any{msg}, args...)...)))
+}
+
+func TestParse_WithDebugLogger(t *testing.T) {
+	t.Parallel()
+	t.Run("success", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		l := &testLogger{}
+		_, err := mql.Parse(`name="alice" and (age>21 or age<10)`, testModel{}, mql.WithDebugLogger(l))
+		require.NoError(err)
+		assert.Contains(l.lines, "mql: lexer state transition from lexStartState to lexStringState pos 5")
+		assert.Contains(l.lines, "mql: lexer emitted token type str value alice pos 5")
+		assert.Contains(l.lines, "mql: parser built comparison expr name=\"alice\" depth 0")
+		assert.Contains(l.lines, "mql: parser found logical operator op and depth 0")
+		assert.Contains(l.lines, "mql: parser grouped operands operands 2 expr age>21 or age<10 depth 1")
+		assert.Contains(l.lines, "mql: parser grouped operands operands 2 expr name=\"alice\" and (age>21 or age<10) depth 0")
+	})
+	t.Run("lexer-error", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		l := &testLogger{}
+		_, err := mql.Parse(`name="alice`, testModel{}, mql.WithDebugLogger(l))
+		require.Error(err)
+		require.NotEmpty(l.lines)
+		assert.True(strings.HasPrefix(l.lines[len(l.lines)-1], "mql: lexer failed state lexStringState"), l.lines[len(l.lines)-1])
+	})
+	t.Run("err-missing-logger", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		_, err := mql.Parse(`name="alice"`, testModel{}, mql.WithDebugLogger(nil))
+		require.Error(err)
+		assert.ErrorIs(err, mql.ErrInvalidParameter)
+		assert.ErrorContains(err, "missing logger")
+	})
+}
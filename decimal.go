@@ -0,0 +1,65 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+)
+
+// decimalTypes are the types of fields which are validated as decimals (see
+// typeValidator)
+var decimalTypes = []string{
+	"decimal.Decimal", // github.com/shopspring/decimal
+	"decimal.NullDecimal",
+	"big.Rat",
+	"big.Float",
+}
+
+// decimalRegexp matches a decimal literal: an optional sign, digits with an
+// optional fraction and an optional exponent (ie: -12.50, .5, 1e-3)
+var decimalRegexp = regexp.MustCompile(`^[+-]?([0-9]+(\.[0-9]*)?|\.[0-9]+)([eE][+-]?[0-9]+)?$`)
+
+// validateDecimal validates a decimal literal and returns it as a string
+// without its underscores (ie: 1_000.50 is "1000.50"), so it's never converted
+// to a float and doesn't lose any precision.  Drivers convert a string arg to
+// the column's numeric/decimal type.
+func validateDecimal(s string) (any, error) {
+	const op = "mql.validateDecimal"
+	n, hex, ok := numberLiteral(s)
+	switch {
+	case ok && hex:
+		return nil, fmt.Errorf("%s: value %q is not a decimal (hex numbers are only supported for int columns): %w", op, s, ErrInvalidParameter)
+	case ok:
+		s = n
+	}
+	if !decimalRegexp.MatchString(s) {
+		return nil, fmt.Errorf("%s: value %q is not a decimal: %w", op, s, ErrInvalidParameter)
+	}
+	return s, nil
+}
+
+// ratValue returns the field's value of a decimal field as a big.Rat
+func ratValue(fieldVal any) (*big.Rat, bool) {
+	switch v := fieldVal.(type) {
+	case string:
+		return new(big.Rat).SetString(v)
+	case big.Rat:
+		return &v, true
+	case big.Float:
+		r, _ := v.Rat(nil)
+		return r, r != nil
+	case int64:
+		return new(big.Rat).SetInt64(v), true
+	case uint64:
+		return new(big.Rat).SetUint64(v), true
+	case float64:
+		return new(big.Rat).SetFloat64(v), true
+	case fmt.Stringer:
+		return new(big.Rat).SetString(v.String())
+	default:
+		return nil, false
+	}
+}
@@ -0,0 +1,165 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/hashicorp/mql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type invoiceModel struct {
+	Name   string
+	Total  *big.Rat
+	Tax    big.Float
+	Amount string
+	Rates  map[string]string
+}
+
+func TestParse_decimal(t *testing.T) {
+	t.Parallel()
+	describer := staticDescriber{
+		fields: []mql.FieldDescriptor{
+			{Name: "Price", Type: "decimal.Decimal"},
+			{Name: "Discount", Type: "decimal.NullDecimal"},
+		},
+	}
+	tests := []struct {
+		name            string
+		query           string
+		model           any
+		opts            []mql.Option
+		want            *mql.WhereClause
+		wantErrIs       error
+		wantErrContains string
+	}{
+		{
+			name:  "big-types",
+			query: `total >= "12345678901234567890.123456789" and tax < .07`,
+			model: invoiceModel{},
+			want: &mql.WhereClause{
+				Condition: "(total>=? and tax<?)",
+				Args:      []any{"12345678901234567890.123456789", ".07"},
+			},
+		},
+		{
+			name:  "WithDecimalColumns",
+			query: `amount = 10.50 and rates.usd != "-1.1e-3"`,
+			model: invoiceModel{},
+			opts:  []mql.Option{mql.WithDecimalColumns("amount", "rates")},
+			want: &mql.WhereClause{
+				Condition: "(amount=? and (rates->>?)::numeric!=?)",
+				Args:      []any{"10.50", "usd", "-1.1e-3"},
+			},
+		},
+		{
+			name:  "string-without-WithDecimalColumns",
+			query: `amount % "10"`,
+			model: invoiceModel{},
+			want: &mql.WhereClause{
+				Condition: "amount like ? escape '\\'",
+				Args:      []any{"%10%"},
+			},
+		},
+		{
+			name:  "shopspring-decimal",
+			query: `price > 0.10 or discount = "5"`,
+			model: struct{}{},
+			opts:  []mql.Option{mql.WithModelDescriber(describer)},
+			want: &mql.WhereClause{
+				Condition: "(price>? or discount=?)",
+				Args:      []any{"0.10", "5"},
+			},
+		},
+		{
+			name:            "err-invalid-decimal",
+			query:           `total = "1/3"`,
+			model:           invoiceModel{},
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: `"1/3" in (comparisonExpr: total = 1/3)`,
+		},
+		{
+			name:            "err-invalid-decimal-column",
+			query:           `amount = "ten"`,
+			model:           invoiceModel{},
+			opts:            []mql.Option{mql.WithDecimalColumns("amount")},
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: `"ten" in (comparisonExpr: amount = ten)`,
+		},
+		{
+			name:            "err-contains",
+			query:           `total % "1"`,
+			model:           invoiceModel{},
+			wantErrIs:       mql.ErrInvalidComparisonOp,
+			wantErrContains: `invalid comparison operator "%" for decimal column "total"`,
+		},
+		{
+			name:            "err-map-contains",
+			query:           `rates.usd % "1"`,
+			model:           invoiceModel{},
+			opts:            []mql.Option{mql.WithDecimalColumns("rates")},
+			wantErrIs:       mql.ErrInvalidComparisonOp,
+			wantErrContains: `invalid comparison operator "%" for decimal column rates.usd`,
+		},
+		{
+			name:            "err-WithDecimalColumns-missing-column",
+			query:           `amount = 1`,
+			model:           invoiceModel{},
+			opts:            []mql.Option{mql.WithDecimalColumns("")},
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: "missing column",
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert, require := assert.New(t), require.New(t)
+			got, err := mql.Parse(tc.query, tc.model, tc.opts...)
+			if tc.wantErrContains != "" {
+				require.Error(err)
+				assert.Empty(got)
+				assert.ErrorIs(err, tc.wantErrIs)
+				assert.ErrorContains(err, tc.wantErrContains)
+				return
+			}
+			require.NoError(err)
+			assert.Equal(tc.want, got)
+		})
+	}
+}
+
+func TestMatch_decimal(t *testing.T) {
+	t.Parallel()
+	item := invoiceModel{
+		Total:  big.NewRat(1001, 100),
+		Tax:    *big.NewFloat(0.5),
+		Amount: "10.50",
+		Rates:  map[string]string{"usd": "1.10"},
+	}
+	tests := []struct {
+		query string
+		opts  []mql.Option
+		want  bool
+	}{
+		{query: `total = 10.01`, want: true},
+		{query: `total > "10.009999999999999999999"`, want: true},
+		{query: `tax <= .5`, want: true},
+		{query: `amount = 10.5`, opts: []mql.Option{mql.WithDecimalColumns("amount")}, want: true},
+		{query: `amount = 10.5`, want: false},
+		{query: `rates.usd >= 1.1`, opts: []mql.Option{mql.WithDecimalColumns("rates")}, want: true},
+		{query: `rates.eur >= 0`, opts: []mql.Option{mql.WithDecimalColumns("rates"), mql.WithNullSemantics(mql.ZeroValueNulls)}, want: true},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.query, func(t *testing.T) {
+			got, err := mql.Match(tc.query, item, tc.opts...)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
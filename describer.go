@@ -0,0 +1,122 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// FieldDescriptor describes a field of a model which can be used in a query.
+type FieldDescriptor struct {
+	// Name of the field in the Go struct (ie: CreatedAt)
+	Name string
+	// Type of the field using the same format as reflect.Type.String() (ie:
+	// int, *time.Time, sql.NullString, map[string]string)
+	Type string
+}
+
+// ModelDescriber describes the fields of a model.  By default, models are
+// described using reflection, but you can provide your own ModelDescriber via
+// WithModelDescriber(...).  For example, a describer generated by cmd/mqlgen
+// which uses static tables and doesn't require any reflection.
+type ModelDescriber interface {
+	// DescribeModel returns the fields of the model which can be used in a
+	// query or an error if the model isn't supported.
+	DescribeModel(model any) ([]FieldDescriptor, error)
+}
+
+// ReflectDescriber is the default ModelDescriber and it uses reflection to
+// describe the fields of a struct (or a pointer to a struct).
+type ReflectDescriber struct{}
+
+// DescribeModel returns the fields of the model
+func (ReflectDescriber) DescribeModel(model any) ([]FieldDescriptor, error) {
+	const op = "mql.(ReflectDescriber).DescribeModel"
+	fields, err := reflectFields(reflect.ValueOf(model))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return fields, nil
+}
+
+// reflectFields returns a FieldDescriptor for every field of the model, which
+// must be a struct or a pointer to a struct.
+func reflectFields(model reflect.Value) ([]FieldDescriptor, error) {
+	const op = "mql.reflectFields"
+	switch {
+	case !model.IsValid():
+		return nil, fmt.Errorf("%s: missing model: %w", op, ErrInvalidParameter)
+	case (model.Kind() != reflect.Struct && model.Kind() != reflect.Pointer),
+		model.Kind() == reflect.Pointer && model.Elem().Kind() != reflect.Struct:
+		return nil, fmt.Errorf("%s: model must be a struct or a pointer to a struct: %w", op, ErrInvalidParameter)
+	}
+	var m reflect.Value = model
+	if m.Kind() != reflect.Struct {
+		m = model.Elem()
+	}
+	fields := make([]FieldDescriptor, 0, m.NumField())
+	for i := 0; i < m.NumField(); i++ {
+		if m.Type().Field(i).Name == "_" {
+			// blank fields can't hold values (ie: _ struct{} `mql:"table=users"`)
+			continue
+		}
+		fields = append(fields, FieldDescriptor{
+			Name: m.Type().Field(i).Name,
+			Type: m.Type().Field(i).Type.String(),
+		})
+	}
+	return fields, nil
+}
+
+// modelFields are the fields of a model described using reflection
+type modelFields struct {
+	fields []FieldDescriptor
+	// keys are the lowercase names of the fields (see descriptorValidators)
+	keys []string
+	// types are the reflect.Types of the fields by their lowercase name
+	types map[string]reflect.Type
+}
+
+// modelCache caches the modelFields of models by their reflect.Type, so
+// repeatedly parsing queries for the same model only reflects on it once.
+var modelCache sync.Map // map[reflect.Type]*modelFields
+
+// cachedModelFields returns the fields of the model, which are cached by its
+// type unless the cache is disabled.  The returned fields are shared, so
+// they must not be modified.  Supported options: WithoutModelCache
+func cachedModelFields(model reflect.Value, opts options) (*modelFields, error) {
+	const op = "mql.cachedModelFields"
+	if !opts.withoutModelCache && model.IsValid() && !(model.Kind() == reflect.Pointer && model.IsNil()) {
+		if m, ok := modelCache.Load(model.Type()); ok {
+			return m.(*modelFields), nil
+		}
+	}
+	fields, err := reflectFields(model)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	t := model.Type()
+	m := &modelFields{
+		fields: fields,
+		keys:   make([]string, 0, len(fields)),
+		types:  make(map[string]reflect.Type, len(fields)),
+	}
+	for _, f := range fields {
+		m.keys = append(m.keys, strings.ToLower(f.Name))
+	}
+	st := t
+	if st.Kind() == reflect.Pointer {
+		st = st.Elem()
+	}
+	for i := 0; i < st.NumField(); i++ {
+		m.types[strings.ToLower(st.Field(i).Name)] = st.Field(i).Type
+	}
+	if !opts.withoutModelCache {
+		modelCache.Store(t, m)
+	}
+	return m, nil
+}
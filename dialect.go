@@ -0,0 +1,438 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// CastType is a type which an expression can be cast to (see Dialect.Cast)
+type CastType string
+
+const (
+	CastInt     CastType = "int"
+	CastFloat   CastType = "float"
+	CastDecimal CastType = "decimal"
+	CastBool    CastType = "bool"
+	CastTime    CastType = "time"
+	CastIP      CastType = "ip"
+)
+
+// Dialect defines how where clauses are generated for a database.  Conditions
+// are generated using ? placeholders, which are replaced using the dialect's
+// Placeholder once the where clause is complete.  The built-in dialects are
+// PostgresDialect, CockroachDialect, MySqlDialect, SqliteDialect and
+// SpannerDialect and other dialects can be registered using RegisterDialect
+// (see WithDialect).  A dialect of a postgres compatible database can embed
+// PostgresDialect and only override the methods which differ, and it then
+// also supports the postgres operators: array contains (@>) for postgres
+// arrays, containment (<<) for inet columns and trigram similarity (~%).
+// Other dialects convert ~% into a case insensitive contains (see ILike).
+type Dialect interface {
+	// Name of the dialect (ie: postgres), which is used to register it
+	Name() string
+
+	// Placeholder returns the placeholder of the nth arg, starting at 1 (ie:
+	// ? or $1)
+	Placeholder(n int) string
+
+	// QuoteIdentifier returns the quoted identifier (ie: "name"), which is used
+	// for every column and table of a where clause.
+	QuoteIdentifier(name string) string
+
+	// Like returns the condition which matches the expr (ie: a column) with a
+	// LIKE pattern arg (?) whose wildcards are escaped using a backslash.
+	Like(expr string, not bool) string
+
+	// ILike returns the condition which matches the expr with a LIKE pattern
+	// arg (?) ignoring case, whose wildcards are escaped using a backslash.
+	ILike(expr string) string
+
+	// JsonLookup returns the expr which looks up the text value of a key (an
+	// arg: ?) in a json column.
+	JsonLookup(column string) string
+
+	// JsonArrayContains returns the condition which reports if the json array
+	// column contains the elements of a json array arg (?).
+	JsonArrayContains(column string) string
+
+	// Cast returns the expr cast to the type.  An error is returned when the
+	// dialect doesn't support the type.
+	Cast(expr string, typ CastType) (string, error)
+
+	// BoolLiteral returns the literal of the bool (see WithInlineValues)
+	BoolLiteral(b bool) string
+}
+
+var (
+	dialectsMu sync.RWMutex
+	// dialects are the registered dialects by their name
+	dialects = map[string]Dialect{
+		PostgresDialect{}.Name():  PostgresDialect{},
+		CockroachDialect{}.Name(): CockroachDialect{},
+		MySqlDialect{}.Name():     MySqlDialect{},
+		SqliteDialect{}.Name():    SqliteDialect{},
+		SpannerDialect{}.Name():   SpannerDialect{},
+	}
+)
+
+// RegisterDialect registers a dialect, so it can be found by its name using
+// LookupDialect.  A dialect's name can only be registered once.  It's
+// typically called from an init() func.
+func RegisterDialect(d Dialect) error {
+	const op = "mql.RegisterDialect"
+	switch {
+	case isNil(d):
+		return fmt.Errorf("%s: missing dialect: %w", op, ErrInvalidParameter)
+	case d.Name() == "":
+		return fmt.Errorf("%s: missing dialect name: %w", op, ErrInvalidParameter)
+	}
+	dialectsMu.Lock()
+	defer dialectsMu.Unlock()
+	if _, exists := dialects[d.Name()]; exists {
+		return fmt.Errorf("%s: dialect %q is already registered: %w", op, d.Name(), ErrInvalidParameter)
+	}
+	dialects[d.Name()] = d
+	return nil
+}
+
+// LookupDialect returns the registered dialect (see RegisterDialect) or a
+// built-in dialect by its name: postgres, cockroach, mysql, sqlite or spanner.
+func LookupDialect(name string) (Dialect, error) {
+	const op = "mql.LookupDialect"
+	dialectsMu.RLock()
+	defer dialectsMu.RUnlock()
+	d, ok := dialects[name]
+	if !ok {
+		return nil, fmt.Errorf("%s: unknown dialect %q: %w", op, name, ErrInvalidParameter)
+	}
+	return d, nil
+}
+
+// WithDialect provides the dialect used to generate where clauses: its
+// placeholders, quoted identifiers, LIKE conditions, json lookups, casts and
+// bool literals.  By default, where clauses use ? placeholders, unquoted
+// identifiers and postgres for everything else.  The placeholder options
+// (ie: WithPgPlaceholders) take precedence over the dialect's placeholders.
+func WithDialect(d Dialect) Option {
+	const op = "mql.WithDialect"
+	return func(o *options) error {
+		if isNil(d) {
+			return fmt.Errorf("%s: missing dialect: %w", op, ErrInvalidParameter)
+		}
+		o.withDialect = d
+		return nil
+	}
+}
+
+// dialectOf returns the dialect provided via WithDialect or the default dialect
+func dialectOf(opts options) Dialect {
+	if opts.withDialect != nil {
+		return opts.withDialect
+	}
+	return defaultDialect{}
+}
+
+// pgOperatorsDialect is implemented by PostgresDialect and the dialects which
+// embed it, which support the postgres operators: array contains (@>) for
+// postgres arrays, containment (<<) for inet columns and trigram similarity.
+type pgOperatorsDialect interface {
+	pgOperators()
+}
+
+// hasPgOperators reports if the dialect supports the postgres operators
+func hasPgOperators(d Dialect) bool {
+	_, ok := d.(pgOperatorsDialect)
+	return ok
+}
+
+// quoteIdentifiers quotes every part of a qualified identifier (ie:
+// public.users) using the dialect
+func quoteIdentifiers(d Dialect, identifier string) string {
+	parts := strings.Split(identifier, ".")
+	for i, p := range parts {
+		parts[i] = d.QuoteIdentifier(p)
+	}
+	return strings.Join(parts, ".")
+}
+
+// defaultDialect is used when no dialect is provided: it's postgres with ?
+// placeholders and unquoted identifiers.
+type defaultDialect struct {
+	PostgresDialect
+}
+
+// Placeholder returns: ?
+func (defaultDialect) Placeholder(int) string { return "?" }
+
+// QuoteIdentifier returns the identifier unquoted
+func (defaultDialect) QuoteIdentifier(name string) string { return name }
+
+// PostgresDialect generates where clauses for postgres
+type PostgresDialect struct{}
+
+func (PostgresDialect) pgOperators() {}
+
+// Name returns: postgres
+func (PostgresDialect) Name() string { return "postgres" }
+
+// Placeholder returns: $n
+func (PostgresDialect) Placeholder(n int) string { return fmt.Sprintf("$%d", n) }
+
+// QuoteIdentifier returns the identifier within double quotes
+func (PostgresDialect) QuoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// Like returns: expr like ? escape '\'
+func (PostgresDialect) Like(expr string, not bool) string {
+	return likeCondition(expr, not)
+}
+
+// ILike returns: expr ilike ? escape '\'
+func (PostgresDialect) ILike(expr string) string {
+	return fmt.Sprintf("%s ilike ? escape '%s'", expr, likeEscapeChar)
+}
+
+// JsonLookup returns: column->>?
+func (PostgresDialect) JsonLookup(column string) string {
+	return fmt.Sprintf("%s->>?", column)
+}
+
+// JsonArrayContains returns: column @> ?
+func (PostgresDialect) JsonArrayContains(column string) string {
+	return fmt.Sprintf("%s @> ?", column)
+}
+
+// Cast returns: (expr)::type
+func (PostgresDialect) Cast(expr string, typ CastType) (string, error) {
+	const op = "mql.(PostgresDialect).Cast"
+	pgTypes := map[CastType]string{
+		CastInt:     "bigint",
+		CastFloat:   "float8",
+		CastDecimal: "numeric",
+		CastBool:    "boolean",
+		CastTime:    "timestamptz",
+		CastIP:      "inet",
+	}
+	t, ok := pgTypes[typ]
+	if !ok {
+		return "", fmt.Errorf("%s: unsupported cast type %q: %w", op, typ, ErrInvalidParameter)
+	}
+	return fmt.Sprintf("(%s)::%s", expr, t), nil
+}
+
+// BoolLiteral returns: true or false
+func (PostgresDialect) BoolLiteral(b bool) string { return fmt.Sprint(b) }
+
+// CockroachDialect generates where clauses for CockroachDB, which is postgres
+// compatible.  Its LIKE conditions don't have an escape clause and rely on the
+// default escape char (a backslash) instead.  CockroachDB doesn't support LIKE
+// for collated strings, so a converter (see WithConverter) is needed to
+// compare them using %.
+type CockroachDialect struct {
+	PostgresDialect
+}
+
+// Name returns: cockroach
+func (CockroachDialect) Name() string { return "cockroach" }
+
+// Like returns: expr like ?
+func (CockroachDialect) Like(expr string, not bool) string {
+	if not {
+		return fmt.Sprintf("%s not like ?", expr)
+	}
+	return fmt.Sprintf("%s like ?", expr)
+}
+
+// ILike returns: expr ilike ?
+func (CockroachDialect) ILike(expr string) string {
+	return fmt.Sprintf("%s ilike ?", expr)
+}
+
+// MySqlDialect generates where clauses for mysql.  Its LIKE conditions use
+// mysql's default escape char (a backslash) and whether they ignore case
+// depends on the column's collation.
+type MySqlDialect struct{}
+
+// Name returns: mysql
+func (MySqlDialect) Name() string { return "mysql" }
+
+// Placeholder returns: ?
+func (MySqlDialect) Placeholder(int) string { return "?" }
+
+// QuoteIdentifier returns the identifier within backticks
+func (MySqlDialect) QuoteIdentifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+// Like returns: expr like ?
+func (MySqlDialect) Like(expr string, not bool) string {
+	if not {
+		return fmt.Sprintf("%s not like ?", expr)
+	}
+	return fmt.Sprintf("%s like ?", expr)
+}
+
+// ILike returns: lower(expr) like lower(?)
+func (MySqlDialect) ILike(expr string) string {
+	return fmt.Sprintf("lower(%s) like lower(?)", expr)
+}
+
+// JsonLookup returns: json_unquote(json_extract(column, concat('$."', ?, '"')))
+func (MySqlDialect) JsonLookup(column string) string {
+	return fmt.Sprintf(`json_unquote(json_extract(%s, concat('$."', ?, '"')))`, column)
+}
+
+// JsonArrayContains returns: json_contains(column, ?)
+func (MySqlDialect) JsonArrayContains(column string) string {
+	return fmt.Sprintf("json_contains(%s, ?)", column)
+}
+
+// Cast returns: cast(expr as type).  Bools are compared to the json literal
+// true, since mysql can't cast it.  IP addresses are not supported.
+func (MySqlDialect) Cast(expr string, typ CastType) (string, error) {
+	const op = "mql.(MySqlDialect).Cast"
+	switch typ {
+	case CastInt:
+		return fmt.Sprintf("cast(%s as signed)", expr), nil
+	case CastFloat:
+		return fmt.Sprintf("cast(%s as double)", expr), nil
+	case CastDecimal:
+		return fmt.Sprintf("cast(%s as decimal(65,30))", expr), nil
+	case CastBool:
+		return fmt.Sprintf("(%s='true')", expr), nil
+	case CastTime:
+		return fmt.Sprintf("cast(%s as datetime(6))", expr), nil
+	default:
+		return "", fmt.Errorf("%s: unsupported cast type %q: %w", op, typ, ErrInvalidParameter)
+	}
+}
+
+// BoolLiteral returns: true or false
+func (MySqlDialect) BoolLiteral(b bool) string { return fmt.Sprint(b) }
+
+// SqliteDialect generates where clauses for sqlite.  Its LIKE conditions
+// ignore the case of ascii characters, which is sqlite's default.
+type SqliteDialect struct{}
+
+// Name returns: sqlite
+func (SqliteDialect) Name() string { return "sqlite" }
+
+// Placeholder returns: ?
+func (SqliteDialect) Placeholder(int) string { return "?" }
+
+// QuoteIdentifier returns the identifier within double quotes
+func (SqliteDialect) QuoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// Like returns: expr like ? escape '\'
+func (SqliteDialect) Like(expr string, not bool) string {
+	return likeCondition(expr, not)
+}
+
+// ILike returns: expr like ? escape '\'
+func (SqliteDialect) ILike(expr string) string {
+	return likeCondition(expr, false)
+}
+
+// JsonLookup returns: json_extract(column, '$."' || ? || '"')
+func (SqliteDialect) JsonLookup(column string) string {
+	return fmt.Sprintf(`json_extract(%s, '$."' || ? || '"')`, column)
+}
+
+// JsonArrayContains returns the condition which reports if every element of
+// the json array arg is an element of the column (using json_each)
+func (SqliteDialect) JsonArrayContains(column string) string {
+	return fmt.Sprintf("not exists (select 1 from json_each(?) as e where e.value not in (select value from json_each(%s)))", column)
+}
+
+// Cast returns: cast(expr as type).  json_extract returns bools as 1 or 0, so
+// they're not cast, and times are compared as text.  IP addresses are not
+// supported.
+func (SqliteDialect) Cast(expr string, typ CastType) (string, error) {
+	const op = "mql.(SqliteDialect).Cast"
+	switch typ {
+	case CastInt:
+		return fmt.Sprintf("cast(%s as integer)", expr), nil
+	case CastFloat:
+		return fmt.Sprintf("cast(%s as real)", expr), nil
+	case CastDecimal:
+		return fmt.Sprintf("cast(%s as numeric)", expr), nil
+	case CastBool, CastTime:
+		return expr, nil
+	default:
+		return "", fmt.Errorf("%s: unsupported cast type %q: %w", op, typ, ErrInvalidParameter)
+	}
+}
+
+// BoolLiteral returns: 1 or 0
+func (SqliteDialect) BoolLiteral(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
+
+// SpannerDialect generates where clauses for Cloud Spanner (GoogleSQL), which
+// uses @p1 placeholders and cast(... as ...) instead of :: casts.  Args are
+// positional, which the spanner database/sql driver binds to the @pN
+// placeholders.  Its LIKE conditions use the default escape char (a
+// backslash).
+type SpannerDialect struct{}
+
+// Name returns: spanner
+func (SpannerDialect) Name() string { return "spanner" }
+
+// Placeholder returns: @pn
+func (SpannerDialect) Placeholder(n int) string { return fmt.Sprintf("@p%d", n) }
+
+// QuoteIdentifier returns the identifier within backticks
+func (SpannerDialect) QuoteIdentifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "\\`") + "`"
+}
+
+// Like returns: expr like ?
+func (SpannerDialect) Like(expr string, not bool) string {
+	return MySqlDialect{}.Like(expr, not)
+}
+
+// ILike returns: lower(expr) like lower(?)
+func (SpannerDialect) ILike(expr string) string {
+	return MySqlDialect{}.ILike(expr)
+}
+
+// JsonLookup returns: json_value(column[?])
+func (SpannerDialect) JsonLookup(column string) string {
+	return fmt.Sprintf("json_value(%s[?])", column)
+}
+
+// JsonArrayContains returns the condition which reports if every element of
+// the json array arg is an element of the column (using json_query_array)
+func (SpannerDialect) JsonArrayContains(column string) string {
+	return fmt.Sprintf("not exists (select 1 from unnest(json_query_array(parse_json(?))) as e where to_json_string(e) not in (select to_json_string(v) from unnest(json_query_array(%s)) as v))", column)
+}
+
+// Cast returns: cast(expr as type).  IP addresses are not supported.
+func (SpannerDialect) Cast(expr string, typ CastType) (string, error) {
+	const op = "mql.(SpannerDialect).Cast"
+	spannerTypes := map[CastType]string{
+		CastInt:     "int64",
+		CastFloat:   "float64",
+		CastDecimal: "numeric",
+		CastBool:    "bool",
+		CastTime:    "timestamp",
+	}
+	t, ok := spannerTypes[typ]
+	if !ok {
+		return "", fmt.Errorf("%s: unsupported cast type %q: %w", op, typ, ErrInvalidParameter)
+	}
+	return fmt.Sprintf("cast(%s as %s)", expr, t), nil
+}
+
+// BoolLiteral returns: true or false
+func (SpannerDialect) BoolLiteral(b bool) string { return fmt.Sprint(b) }
@@ -0,0 +1,379 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/mql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// caseInsensitiveDialect is a postgres compatible dialect whose like
+// conditions ignore case
+type caseInsensitiveDialect struct {
+	mql.PostgresDialect
+}
+
+func (caseInsensitiveDialect) Name() string { return "case-insensitive" }
+
+func (caseInsensitiveDialect) Like(expr string, not bool) string {
+	if not {
+		return fmt.Sprintf("%s not ilike ? escape '\\'", expr)
+	}
+	return fmt.Sprintf("%s ilike ? escape '\\'", expr)
+}
+
+func TestWithDialect(t *testing.T) {
+	t.Parallel()
+	var (
+		pg      = mql.WithDialect(mql.PostgresDialect{})
+		mysql   = mql.WithDialect(mql.MySqlDialect{})
+		sqlite  = mql.WithDialect(mql.SqliteDialect{})
+		spanner = mql.WithDialect(mql.SpannerDialect{})
+	)
+	tests := []struct {
+		name            string
+		query           string
+		model           any
+		opts            []mql.Option
+		want            *mql.WhereClause
+		wantErrIs       error
+		wantErrContains string
+	}{
+		{
+			name:  "postgres",
+			query: `name="alice" and age>21`,
+			opts:  []mql.Option{pg},
+			want:  &mql.WhereClause{Condition: `("name"=$1 and "age">$2)`, Args: []any{"alice", 21}},
+		},
+		{
+			name:  "mysql",
+			query: `name="alice" and age>21`,
+			opts:  []mql.Option{mysql},
+			want:  &mql.WhereClause{Condition: "(`name`=? and `age`>?)", Args: []any{"alice", 21}},
+		},
+		{
+			name:  "sqlite",
+			query: `name="alice" and age>21`,
+			opts:  []mql.Option{sqlite},
+			want:  &mql.WhereClause{Condition: `("name"=? and "age">?)`, Args: []any{"alice", 21}},
+		},
+		{
+			name:  "cockroach",
+			query: `name%"ali" and labels.env="prod"`,
+			opts:  []mql.Option{mql.WithDialect(mql.CockroachDialect{})},
+			want: &mql.WhereClause{
+				Condition: `("name" like $1 and "labels"->>$2=$3)`,
+				Args:      []any{"%ali%", "env", "prod"},
+			},
+		},
+		{
+			name:  "cockroach-similarity",
+			query: `name~%"alise"`,
+			opts:  []mql.Option{mql.WithDialect(mql.CockroachDialect{})},
+			want:  &mql.WhereClause{Condition: `"name" % $1`, Args: []any{"alise"}},
+		},
+		{
+			name:  "spanner",
+			query: `name="alice" and age>21`,
+			opts:  []mql.Option{spanner},
+			want:  &mql.WhereClause{Condition: "(`name`=@p1 and `age`>@p2)", Args: []any{"alice", 21}},
+		},
+		{
+			name:  "spanner-map-casts",
+			query: `scores.math>90 and labels.env%"pro"`,
+			opts:  []mql.Option{spanner},
+			want: &mql.WhereClause{
+				Condition: "(cast(json_value(`scores`[@p1]) as int64)>@p2 and json_value(`labels`[@p3]) like @p4)",
+				Args:      []any{"math", 90, "env", "%pro%"},
+			},
+		},
+		{
+			name:  "spanner-similarity-fallback",
+			query: `name~%"alise"`,
+			opts:  []mql.Option{spanner},
+			want:  &mql.WhereClause{Condition: "lower(`name`) like lower(@p1)", Args: []any{"%alise%"}},
+		},
+		{
+			name:  "spanner-inline-values",
+			query: `enabled=true and name="alice"`,
+			model: boolModel{},
+			opts:  []mql.Option{spanner, mql.WithInlineValues()},
+			want:  &mql.WhereClause{Condition: "(`enabled`=true and `name`='alice')"},
+		},
+		{
+			name:            "err-spanner-map-ip",
+			query:           `addrs.gw="10.0.0.1"`,
+			model:           hostModel{},
+			opts:            []mql.Option{spanner},
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: `unsupported cast type "ip"`,
+		},
+		{
+			name:  "placeholder-option-takes-precedence",
+			query: `name="alice"`,
+			opts:  []mql.Option{mysql, mql.WithPgPlaceholders()},
+			want:  &mql.WhereClause{Condition: "`name`=$1", Args: []any{"alice"}},
+		},
+		{
+			name:  "qualified-column",
+			query: `name="alice"`,
+			opts:  []mql.Option{mysql, mql.WithTableName("app.users")},
+			want:  &mql.WhereClause{Condition: "`app`.`users`.`name`=?", Args: []any{"alice"}},
+		},
+		{
+			name:  "null",
+			query: `email=""`,
+			opts:  []mql.Option{pg, mql.WithEmptyStringAsNull("email")},
+			want:  &mql.WhereClause{Condition: `"email" is null`},
+		},
+		{
+			name:  "contains-postgres",
+			query: `name%"ali"`,
+			opts:  []mql.Option{pg},
+			want:  &mql.WhereClause{Condition: `"name" like $1 escape '\'`, Args: []any{"%ali%"}},
+		},
+		{
+			name:  "contains-mysql",
+			query: `name%"a_i"`,
+			opts:  []mql.Option{mysql},
+			want:  &mql.WhereClause{Condition: "`name` like ?", Args: []any{`%a\_i%`}},
+		},
+		{
+			name:  "glob-mysql",
+			query: `name!="al*"`,
+			opts:  []mql.Option{mysql, mql.WithGlobPatterns()},
+			want:  &mql.WhereClause{Condition: "`name` not like ?", Args: []any{"al%"}},
+		},
+		{
+			name:  "custom-dialect-like",
+			query: `name%"ali"`,
+			opts:  []mql.Option{mql.WithDialect(caseInsensitiveDialect{})},
+			want:  &mql.WhereClause{Condition: `"name" ilike $1 escape '\'`, Args: []any{"%ali%"}},
+		},
+		{
+			name:  "similarity-postgres",
+			query: `name~%"alise"`,
+			opts:  []mql.Option{pg},
+			want:  &mql.WhereClause{Condition: `"name" % $1`, Args: []any{"alise"}},
+		},
+		{
+			name:  "similarity-custom-postgres-dialect",
+			query: `name~%"alise"`,
+			opts:  []mql.Option{mql.WithDialect(caseInsensitiveDialect{}), mql.WithSimilarityThreshold(0.5)},
+			want:  &mql.WhereClause{Condition: `similarity("name", $1) > $2`, Args: []any{"alise", 0.5}},
+		},
+		{
+			name:  "similarity-mysql-fallback",
+			query: `name~%"ali_e"`,
+			opts:  []mql.Option{mysql},
+			want:  &mql.WhereClause{Condition: "lower(`name`) like lower(?)", Args: []any{`%ali\_e%`}},
+		},
+		{
+			name:  "similarity-sqlite-fallback",
+			query: `name~%"alise"`,
+			opts:  []mql.Option{sqlite},
+			want:  &mql.WhereClause{Condition: `"name" like ? escape '\'`, Args: []any{"%alise%"}},
+		},
+		{
+			name:  "map-postgres",
+			query: `scores.math>90 and labels.env="prod"`,
+			opts:  []mql.Option{pg},
+			want: &mql.WhereClause{
+				Condition: `(("scores"->>$1)::bigint>$2 and "labels"->>$3=$4)`,
+				Args:      []any{"math", 90, "env", "prod"},
+			},
+		},
+		{
+			name:  "map-mysql",
+			query: `scores.math>90 and labels.env="prod"`,
+			opts:  []mql.Option{mysql},
+			want: &mql.WhereClause{
+				Condition: "(cast(json_unquote(json_extract(`scores`, concat('$.\"', ?, '\"'))) as signed)>? and json_unquote(json_extract(`labels`, concat('$.\"', ?, '\"')))=?)",
+				Args:      []any{"math", 90, "env", "prod"},
+			},
+		},
+		{
+			name:  "map-sqlite",
+			query: `scores.math>90`,
+			opts:  []mql.Option{sqlite},
+			want: &mql.WhereClause{
+				Condition: `cast(json_extract("scores", '$."' || ? || '"') as integer)>?`,
+				Args:      []any{"math", 90},
+			},
+		},
+		{
+			name:  "map-bool-mysql",
+			query: `flags.beta=true`,
+			model: boolModel{},
+			opts:  []mql.Option{mysql},
+			want: &mql.WhereClause{
+				Condition: "(json_unquote(json_extract(`flags`, concat('$.\"', ?, '\"')))='true')=?",
+				Args:      []any{"beta", true},
+			},
+		},
+		{
+			name:  "map-time-mysql",
+			query: `dates.start>"2024-01-02T03:04:05Z"`,
+			model: struct{ Dates map[string]time.Time }{},
+			opts:  []mql.Option{mysql},
+			want: &mql.WhereClause{
+				Condition: "cast(json_unquote(json_extract(`dates`, concat('$.\"', ?, '\"'))) as datetime(6))>?",
+				Args:      []any{"start", time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)},
+			},
+		},
+		{
+			name:  "array-postgres",
+			query: `tags@>"prod"`,
+			model: deploymentModel{},
+			opts:  []mql.Option{pg},
+			want:  &mql.WhereClause{Condition: `"tags" @> ARRAY[$1]`, Args: []any{"prod"}},
+		},
+		{
+			name:  "json-array-mysql",
+			query: `labels@>"prod"`,
+			model: deploymentModel{},
+			opts:  []mql.Option{mysql, mql.WithJsonArrayColumns("labels")},
+			want:  &mql.WhereClause{Condition: "json_contains(`labels`, ?)", Args: []any{`["prod"]`}},
+		},
+		{
+			name:  "json-array-sqlite",
+			query: `labels@>"prod"`,
+			model: deploymentModel{},
+			opts:  []mql.Option{sqlite, mql.WithJsonArrayColumns("labels")},
+			want: &mql.WhereClause{
+				Condition: `not exists (select 1 from json_each(?) as e where e.value not in (select value from json_each("labels")))`,
+				Args:      []any{`["prod"]`},
+			},
+		},
+		{
+			name:  "ip-postgres",
+			query: `ip<<"10.0.0.0/8"`,
+			model: hostModel{},
+			opts:  []mql.Option{pg},
+			want:  &mql.WhereClause{Condition: `"ip"<<$1`, Args: []any{"10.0.0.0/8"}},
+		},
+		{
+			name:  "binary-ip-mysql",
+			query: `ip<<"10.0.0.0/8"`,
+			model: hostModel{},
+			opts:  []mql.Option{mysql, mql.WithBinaryIPs()},
+			want: &mql.WhereClause{
+				Condition: "(`ip`>=? and `ip`<=?)",
+				Args:      []any{[]byte{10, 0, 0, 0}, []byte{10, 255, 255, 255}},
+			},
+		},
+		{
+			name:  "inline-bool-sqlite",
+			query: `enabled=true`,
+			model: boolModel{},
+			opts:  []mql.Option{sqlite, mql.WithInlineValues()},
+			want:  &mql.WhereClause{Condition: `"enabled"=1`},
+		},
+		{
+			name:  "relationship",
+			query: `roles.name="admin"`,
+			model: userModel{},
+			opts: []mql.Option{
+				pg,
+				mql.WithRelationship("roles", mql.Relationship{Model: roleModel{}, ForeignKey: "user_id"}),
+			},
+			want: &mql.WhereClause{
+				Condition: `exists (select 1 from "roles" where "roles"."user_id"="users"."id" and "roles"."name"=$1)`,
+				Args:      []any{"admin"},
+			},
+		},
+		{
+			name:            "err-array-mysql",
+			query:           `tags@>"prod"`,
+			model:           deploymentModel{},
+			opts:            []mql.Option{mysql},
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: "is not supported by the mysql dialect",
+		},
+		{
+			name:            "err-ip-containment-sqlite",
+			query:           `ip<<"10.0.0.0/8"`,
+			model:           hostModel{},
+			opts:            []mql.Option{sqlite},
+			wantErrIs:       mql.ErrInvalidComparisonOp,
+			wantErrContains: `not supported by the sqlite dialect`,
+		},
+		{
+			name:            "err-map-ip-mysql",
+			query:           `addrs.gw="10.0.0.1"`,
+			model:           hostModel{},
+			opts:            []mql.Option{mysql},
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: `unsupported cast type "ip"`,
+		},
+		{
+			name:            "err-missing-dialect",
+			query:           `name="alice"`,
+			opts:            []mql.Option{mql.WithDialect(nil)},
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: "missing dialect",
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert, require := assert.New(t), require.New(t)
+			model := tc.model
+			if model == nil {
+				model = testModel{}
+			}
+			got, err := mql.Parse(tc.query, model, tc.opts...)
+			if tc.wantErrContains != "" {
+				require.Error(err)
+				assert.Empty(got)
+				assert.ErrorIs(err, tc.wantErrIs)
+				assert.ErrorContains(err, tc.wantErrContains)
+				return
+			}
+			require.NoError(err)
+			assert.Equal(tc.want, got)
+		})
+	}
+}
+
+func TestWithDialect_ParseOrderBy(t *testing.T) {
+	t.Parallel()
+	assert, require := assert.New(t), require.New(t)
+	got, err := mql.ParseOrderBy("name, created_at desc", testModel{}, mql.WithDialect(mql.MySqlDialect{}), mql.WithTableAlias("u"))
+	require.NoError(err)
+	assert.Equal("`u`.`name` asc, `u`.`created_at` desc", got.Clause)
+}
+
+func TestRegisterDialect(t *testing.T) {
+	t.Parallel()
+	assert, require := assert.New(t), require.New(t)
+
+	require.NoError(mql.RegisterDialect(caseInsensitiveDialect{}))
+	got, err := mql.LookupDialect("case-insensitive")
+	require.NoError(err)
+	assert.Equal(caseInsensitiveDialect{}, got)
+
+	err = mql.RegisterDialect(caseInsensitiveDialect{})
+	assert.ErrorIs(err, mql.ErrInvalidParameter)
+	assert.ErrorContains(err, `dialect "case-insensitive" is already registered`)
+
+	err = mql.RegisterDialect(nil)
+	assert.ErrorIs(err, mql.ErrInvalidParameter)
+	assert.ErrorContains(err, "missing dialect")
+
+	for _, name := range []string{"postgres", "cockroach", "mysql", "sqlite", "spanner"} {
+		d, err := mql.LookupDialect(name)
+		require.NoError(err)
+		assert.Equal(name, d.Name())
+	}
+	_, err = mql.LookupDialect("oracle")
+	assert.ErrorIs(err, mql.ErrInvalidParameter)
+	assert.ErrorContains(err, `unknown dialect "oracle"`)
+}
@@ -48,7 +48,8 @@
 // Comparison operators can have optional leading/trailing whitespace.
 //
 // The % operator allows you to do partial string matching using LIKE and this
-// matching is case insensitive.
+// matching is case insensitive.  LIKE wildcards (% and _) in the value are
+// matched literally, unless WithRawLikePatterns is used.
 //
 // The = equality operator is case insensitive when used with string fields.
 //
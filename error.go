@@ -3,7 +3,12 @@
 
 package mql
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
 
 var (
 	ErrInternal                         = errors.New("internal error")
@@ -29,4 +34,72 @@ var (
 	ErrMissingEndOfStringTokenDelimiter = errors.New("missing end of stringToken delimiter")
 	ErrInvalidTrailingBackslash         = errors.New("invalid trailing backslash")
 	ErrInvalidDelimiter                 = errors.New("invalid delimiter")
+	ErrInvalidJsonApiFilter             = errors.New("invalid JSON:API filter")
+	ErrInvalidOrderBy                   = errors.New("invalid order by")
+	ErrInvalidCursor                    = errors.New("invalid cursor")
+	ErrInvalidEnumValue                 = errors.New("invalid enum value")
+	ErrLimitExceeded                    = errors.New("limit exceeded")
+	ErrQueryTooLong                     = errors.New("query too long")
+	ErrTooManyTokens                    = errors.New("too many tokens")
+	ErrStringTooLong                    = errors.New("string too long")
+	ErrInvalidColumnMap                 = errors.New("invalid column map")
+	ErrValueOutOfRange                  = errors.New("value out of range")
+	ErrInvalidFields                    = errors.New("invalid fields")
+	ErrDisabledComparisonOp             = errors.New("disabled comparison operator")
 )
+
+// ParseError is returned when a query can't be parsed.  Along with the
+// underlying error, it includes where in the query parsing failed and the
+// expression tree parsed before the failure, so editors can keep highlighting
+// the valid part of a query while flagging the rest.
+type ParseError struct {
+	// Err is the underlying error
+	Err error
+	// Pos is the byte offset in the query where parsing failed
+	Pos int
+	// Partial is the expression tree of the longest part of the query which
+	// ends before a logical operator and could be parsed (any open parens
+	// are closed).  It's nil when there isn't one.
+	Partial Expr
+}
+
+// Error returns the underlying error's message
+func (e *ParseError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("parse error at %d", e.Pos)
+	}
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// ColumnMapError is returned when a column map (see WithColumnMap) maps query
+// columns to columns which aren't fields of the model, which is an
+// ErrInvalidColumnMap and an ErrInvalidParameter.
+type ColumnMapError struct {
+	// Mappings are the invalid mappings of query columns (lowercase) to
+	// columns
+	Mappings map[string]string
+}
+
+// Error returns the invalid mappings in order
+func (e *ColumnMapError) Error() string {
+	queryColumns := make([]string, 0, len(e.Mappings))
+	for k := range e.Mappings {
+		queryColumns = append(queryColumns, k)
+	}
+	sort.Strings(queryColumns)
+	mappings := make([]string, 0, len(queryColumns))
+	for _, k := range queryColumns {
+		mappings = append(mappings, fmt.Sprintf("%q -> %q", k, e.Mappings[k]))
+	}
+	return fmt.Sprintf("%s: columns which aren't fields of the model: %s", ErrInvalidColumnMap, strings.Join(mappings, ", "))
+}
+
+// Unwrap returns ErrInvalidColumnMap and ErrInvalidParameter
+func (e *ColumnMapError) Unwrap() []error {
+	return []error{ErrInvalidColumnMap, ErrInvalidParameter}
+}
@@ -0,0 +1,180 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// ErrorCode is a stable, machine readable code of an error (ie: MQL-001 for
+// ErrUnexpectedToken), so API servers can map errors to localized messages
+// and metrics without matching their text, which may change between releases.
+// Codes are never reused or renumbered.
+type ErrorCode string
+
+const (
+	CodeUnexpectedToken             ErrorCode = "MQL-001"
+	CodeMissingExpr                 ErrorCode = "MQL-002"
+	CodeUnexpectedExpr              ErrorCode = "MQL-003"
+	CodeUnexpectedClosingParen      ErrorCode = "MQL-004"
+	CodeMissingClosingParen         ErrorCode = "MQL-005"
+	CodeUnexpectedOpeningParen      ErrorCode = "MQL-006"
+	CodeUnexpectedLogicalOp         ErrorCode = "MQL-007"
+	CodeInvalidComparisonOp         ErrorCode = "MQL-008"
+	CodeMissingComparisonOp         ErrorCode = "MQL-009"
+	CodeMissingColumn               ErrorCode = "MQL-010"
+	CodeInvalidLogicalOp            ErrorCode = "MQL-011"
+	CodeMissingLogicalOp            ErrorCode = "MQL-012"
+	CodeMissingRightSideExpr        ErrorCode = "MQL-013"
+	CodeMissingComparisonValue      ErrorCode = "MQL-014"
+	CodeInvalidColumn               ErrorCode = "MQL-015"
+	CodeInvalidNumber               ErrorCode = "MQL-016"
+	CodeInvalidComparisonValueType  ErrorCode = "MQL-017"
+	CodeMissingEndOfStringDelimiter ErrorCode = "MQL-018"
+	CodeInvalidTrailingBackslash    ErrorCode = "MQL-019"
+	CodeInvalidDelimiter            ErrorCode = "MQL-020"
+	CodeInvalidNotEqual             ErrorCode = "MQL-021"
+	CodeInvalidEnumValue            ErrorCode = "MQL-022"
+	CodeQueryTooLong                ErrorCode = "MQL-023"
+	CodeTooManyTokens               ErrorCode = "MQL-024"
+	CodeStringTooLong               ErrorCode = "MQL-025"
+	CodeLimitExceeded               ErrorCode = "MQL-026"
+	CodeInvalidJsonApiFilter        ErrorCode = "MQL-027"
+	CodeInvalidOrderBy              ErrorCode = "MQL-028"
+	CodeInvalidCursor               ErrorCode = "MQL-029"
+	CodeInvalidColumnMap            ErrorCode = "MQL-030"
+	CodeCanceled                    ErrorCode = "MQL-031"
+	CodeDeadlineExceeded            ErrorCode = "MQL-032"
+	CodeInvalidParameter            ErrorCode = "MQL-033"
+	CodeInternal                    ErrorCode = "MQL-034"
+	CodeValueOutOfRange             ErrorCode = "MQL-035"
+	CodeInvalidFields               ErrorCode = "MQL-036"
+	CodeDisabledComparisonOp        ErrorCode = "MQL-037"
+)
+
+// ErrorCodeInfo is an entry of the registry of error codes (see ErrorCodes)
+type ErrorCodeInfo struct {
+	// Code of the error
+	Code ErrorCode
+
+	// Err is the sentinel error which has the code (see errors.Is)
+	Err error
+
+	// Description is a default (English) message for the code
+	Description string
+}
+
+// errorCodes is the registry of error codes, ordered from the most specific
+// error to the least specific, since an error may wrap more than one sentinel
+// error (ie: ErrInvalidEnumValue and ErrInvalidParameter).
+var errorCodes = []ErrorCodeInfo{
+	{Code: CodeCanceled, Err: context.Canceled, Description: "the query was canceled"},
+	{Code: CodeDeadlineExceeded, Err: context.DeadlineExceeded, Description: "the query's deadline was exceeded"},
+	{Code: CodeQueryTooLong, Err: ErrQueryTooLong, Description: "the query is too long"},
+	{Code: CodeTooManyTokens, Err: ErrTooManyTokens, Description: "the query has too many tokens"},
+	{Code: CodeStringTooLong, Err: ErrStringTooLong, Description: "a value of the query is too long"},
+	{Code: CodeLimitExceeded, Err: ErrLimitExceeded, Description: "the query exceeds a limit"},
+	{Code: CodeUnexpectedToken, Err: ErrUnexpectedToken, Description: "unexpected token"},
+	{Code: CodeMissingExpr, Err: ErrMissingExpr, Description: "missing expression"},
+	{Code: CodeUnexpectedExpr, Err: ErrUnexpectedExpr, Description: "unexpected expression"},
+	{Code: CodeUnexpectedClosingParen, Err: ErrUnexpectedClosingParen, Description: "unexpected closing paren"},
+	{Code: CodeMissingClosingParen, Err: ErrMissingClosingParen, Description: "missing closing paren"},
+	{Code: CodeUnexpectedOpeningParen, Err: ErrUnexpectedOpeningParen, Description: "unexpected opening paren"},
+	{Code: CodeUnexpectedLogicalOp, Err: ErrUnexpectedLogicalOp, Description: "unexpected logical operator"},
+	{Code: CodeDisabledComparisonOp, Err: ErrDisabledComparisonOp, Description: "the comparison operator is disabled"},
+	{Code: CodeInvalidComparisonOp, Err: ErrInvalidComparisonOp, Description: "invalid comparison operator for the column"},
+	{Code: CodeMissingComparisonOp, Err: ErrMissingComparisonOp, Description: "missing comparison operator"},
+	{Code: CodeMissingColumn, Err: ErrMissingColumn, Description: "missing column"},
+	{Code: CodeInvalidLogicalOp, Err: ErrInvalidLogicalOp, Description: "invalid logical operator"},
+	{Code: CodeMissingLogicalOp, Err: ErrMissingLogicalOp, Description: "missing logical operator"},
+	{Code: CodeMissingRightSideExpr, Err: ErrMissingRightSideExpr, Description: "logical operator without a right side expression"},
+	{Code: CodeMissingComparisonValue, Err: ErrMissingComparisonValue, Description: "missing comparison value"},
+	{Code: CodeInvalidColumn, Err: ErrInvalidColumn, Description: "invalid column"},
+	{Code: CodeInvalidNumber, Err: ErrInvalidNumber, Description: "invalid number"},
+	{Code: CodeInvalidComparisonValueType, Err: ErrInvalidComparisonValueType, Description: "invalid comparison value type"},
+	{Code: CodeMissingEndOfStringDelimiter, Err: ErrMissingEndOfStringTokenDelimiter, Description: "missing closing quote of a string"},
+	{Code: CodeInvalidTrailingBackslash, Err: ErrInvalidTrailingBackslash, Description: "invalid trailing backslash"},
+	{Code: CodeInvalidDelimiter, Err: ErrInvalidDelimiter, Description: "invalid delimiter"},
+	{Code: CodeInvalidNotEqual, Err: ErrInvalidNotEqual, Description: `invalid "!=" operator`},
+	{Code: CodeValueOutOfRange, Err: ErrValueOutOfRange, Description: "the value is out of range for the column"},
+	{Code: CodeInvalidEnumValue, Err: ErrInvalidEnumValue, Description: "invalid value for the column"},
+	{Code: CodeInvalidJsonApiFilter, Err: ErrInvalidJsonApiFilter, Description: "invalid JSON:API filter"},
+	{Code: CodeInvalidOrderBy, Err: ErrInvalidOrderBy, Description: "invalid order by"},
+	{Code: CodeInvalidFields, Err: ErrInvalidFields, Description: "invalid fields"},
+	{Code: CodeInvalidCursor, Err: ErrInvalidCursor, Description: "invalid cursor"},
+	{Code: CodeInvalidColumnMap, Err: ErrInvalidColumnMap, Description: "invalid column map"},
+	{Code: CodeInvalidParameter, Err: ErrInvalidParameter, Description: "invalid parameter"},
+	{Code: CodeInternal, Err: ErrInternal, Description: "internal error"},
+}
+
+// ErrorCodes returns the registry of error codes, ordered by their code
+func ErrorCodes() []ErrorCodeInfo {
+	codes := make([]ErrorCodeInfo, len(errorCodes))
+	copy(codes, errorCodes)
+	sort.Slice(codes, func(i, j int) bool { return codes[i].Code < codes[j].Code })
+	return codes
+}
+
+// ErrorCodeOf returns the code of the most specific error of the registry (see
+// ErrorCodes) which err is (see errors.Is).  It returns an empty code when err
+// is nil or it isn't one of the errors of the registry (ie: an error returned
+// by a converter).
+func ErrorCodeOf(err error) ErrorCode {
+	if err == nil {
+		return ""
+	}
+	for _, c := range errorCodes {
+		if errors.Is(err, c.Err) {
+			return c.Code
+		}
+	}
+	return ""
+}
+
+// CodedError is an error along with its code (see ErrorCodeOf) and position
+// in the query, which can be returned by an API server as structured data.
+type CodedError struct {
+	// Code of the error, which is empty when it's unknown
+	Code ErrorCode
+
+	// Pos is the byte offset in the query where parsing failed (see
+	// ParseError) and it's -1 when it's unknown
+	Pos int
+
+	// Err is the underlying error
+	Err error
+
+	// Message is the user facing message of the error (see ErrorMessage)
+	Message string
+}
+
+// NewCodedError returns the error along with its code, position and user
+// facing message and nil when err is nil.
+func NewCodedError(err error) *CodedError {
+	if err == nil {
+		return nil
+	}
+	e := &CodedError{Code: ErrorCodeOf(err), Pos: -1, Err: err, Message: ErrorMessage(err)}
+	var pErr *ParseError
+	if errors.As(err, &pErr) {
+		e.Pos = pErr.Pos
+	}
+	return e
+}
+
+// Error returns the code and the underlying error's message
+func (e *CodedError) Error() string {
+	if e.Code == "" {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("%s: %s", e.Code, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *CodedError) Unwrap() error {
+	return e.Err
+}
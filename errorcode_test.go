@@ -0,0 +1,118 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/hashicorp/mql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorCodes(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+	codes := mql.ErrorCodes()
+	assert.True(sort.SliceIsSorted(codes, func(i, j int) bool { return codes[i].Code < codes[j].Code }))
+	seen := map[mql.ErrorCode]bool{}
+	for _, c := range codes {
+		assert.Regexp(`^MQL-\d{3}$`, c.Code)
+		assert.False(seen[c.Code], "duplicate code %s", c.Code)
+		seen[c.Code] = true
+		assert.NotNil(c.Err)
+		assert.NotEmpty(c.Description)
+		// every sentinel error has its own code
+		assert.Equal(c.Code, mql.ErrorCodeOf(fmt.Errorf("wrapped: %w", c.Err)))
+	}
+
+	// the registry is copied
+	codes[0].Code = "modified"
+	assert.NotEqual(mql.ErrorCode("modified"), mql.ErrorCodes()[0].Code)
+}
+
+func TestErrorCodeOf(t *testing.T) {
+	t.Parallel()
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	tests := []struct {
+		name  string
+		parse func() error
+		want  mql.ErrorCode
+	}{
+		{
+			name:  "unexpected-token",
+			parse: func() error { _, err := mql.Parse(`name="alice" )`, testModel{}); return err },
+			want:  mql.CodeUnexpectedClosingParen,
+		},
+		{
+			name:  "invalid-column",
+			parse: func() error { _, err := mql.Parse(`nickname="alice"`, testModel{}); return err },
+			want:  mql.CodeInvalidColumn,
+		},
+		{
+			name: "enum-over-invalid-parameter",
+			parse: func() error {
+				_, err := mql.Parse(`name="carol"`, testModel{}, mql.WithEnum("name", []string{"alice"}))
+				return err
+			},
+			want: mql.CodeInvalidEnumValue,
+		},
+		{
+			name:  "limit",
+			parse: func() error { _, err := mql.Parse(`name="alice"`, testModel{}, mql.WithMaxQueryLength(5)); return err },
+			want:  mql.CodeQueryTooLong,
+		},
+		{
+			name:  "canceled",
+			parse: func() error { _, err := mql.ParseContext(canceled, `name="alice"`, testModel{}); return err },
+			want:  mql.CodeCanceled,
+		},
+		{
+			name:  "unknown",
+			parse: func() error { return errors.New("converter failed") },
+		},
+		{
+			name:  "nil",
+			parse: func() error { return nil },
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.want, mql.ErrorCodeOf(tc.parse()))
+		})
+	}
+}
+
+func TestNewCodedError(t *testing.T) {
+	t.Parallel()
+	t.Run("parse-error", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		_, err := mql.Parse(`name="alice" and`, testModel{})
+		require.Error(err)
+		got := mql.NewCodedError(err)
+		assert.Equal(mql.CodeMissingRightSideExpr, got.Code)
+		assert.Equal(16, got.Pos)
+		assert.Equal("MQL-013: "+err.Error(), got.Error())
+		assert.ErrorIs(got, mql.ErrMissingRightSideExpr)
+		var pErr *mql.ParseError
+		assert.True(errors.As(got, &pErr))
+	})
+	t.Run("unknown", func(t *testing.T) {
+		assert := assert.New(t)
+		err := errors.New("converter failed")
+		got := mql.NewCodedError(err)
+		assert.Equal(&mql.CodedError{Pos: -1, Err: err}, got)
+		assert.Equal("converter failed", got.Error())
+	})
+	t.Run("nil", func(t *testing.T) {
+		assert.Nil(t, mql.NewCodedError(nil))
+	})
+}
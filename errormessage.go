@@ -0,0 +1,113 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrorMessageFunc returns the user facing message (ie: a translated message)
+// of an error with the code (see ErrorCodeOf), which can be empty when the
+// code is unknown.  Returning an empty message falls back to the messages of
+// WithErrorMessages.
+type ErrorMessageFunc func(code ErrorCode, err error) string
+
+// WithErrorMessages provides optional user facing messages (ie: translated or
+// branded messages) of the error codes, which are returned by ErrorMessage
+// for the errors of Parse, ParseContext, ParseFor, ParseReader and Parser.
+// The errors themselves are unchanged, so they can still be handled using
+// errors.Is and errors.As.  The maps of multiple options are merged.
+func WithErrorMessages(messages map[ErrorCode]string) Option {
+	const op = "mql.WithErrorMessages"
+	return func(o *options) error {
+		if len(messages) == 0 {
+			return fmt.Errorf("%s: missing messages: %w", op, ErrInvalidParameter)
+		}
+		if o.withErrorMessages == nil {
+			o.withErrorMessages = make(map[ErrorCode]string, len(messages))
+		}
+		for code, msg := range messages {
+			if code == "" {
+				return fmt.Errorf("%s: missing code of message %q: %w", op, msg, ErrInvalidParameter)
+			}
+			o.withErrorMessages[code] = msg
+		}
+		return nil
+	}
+}
+
+// WithErrorMessageFunc provides an optional ErrorMessageFunc which returns the
+// user facing messages of errors, when they depend on more than the error's
+// code (ie: the user's locale or the error's ParseError).  See
+// WithErrorMessages.
+func WithErrorMessageFunc(fn ErrorMessageFunc) Option {
+	const op = "mql.WithErrorMessageFunc"
+	return func(o *options) error {
+		if fn == nil {
+			return fmt.Errorf("%s: missing message func: %w", op, ErrInvalidParameter)
+		}
+		o.withErrorMessageFunc = fn
+		return nil
+	}
+}
+
+// ErrorMessage returns the user facing message of err, which is its message
+// provided via WithErrorMessages or WithErrorMessageFunc, or else the default
+// description of its code (see ErrorCodes).  It returns an empty message when
+// err is nil or it has neither (ie: an error returned by a converter), so the
+// error's text, which is meant for logs, isn't returned to end users.
+func ErrorMessage(err error) string {
+	if err == nil {
+		return ""
+	}
+	var mErr *messageError
+	if errors.As(err, &mErr) {
+		return mErr.message
+	}
+	code := ErrorCodeOf(err)
+	for _, c := range errorCodes {
+		if c.Code == code {
+			return c.Description
+		}
+	}
+	return ""
+}
+
+// messageError is an error along with its user facing message, which doesn't
+// change the error's text (see ErrorMessage)
+type messageError struct {
+	err     error
+	message string
+}
+
+// Error returns the underlying error's message
+func (e *messageError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the underlying error
+func (e *messageError) Unwrap() error {
+	return e.err
+}
+
+// withErrorMessage returns err along with its user facing message (see
+// WithErrorMessages) or err itself when there's no message for it.
+func withErrorMessage(err error, opts options) error {
+	if err == nil || (opts.withErrorMessageFunc == nil && opts.withErrorMessages == nil) {
+		return err
+	}
+	code := ErrorCodeOf(err)
+	var msg string
+	if opts.withErrorMessageFunc != nil {
+		msg = opts.withErrorMessageFunc(code, err)
+	}
+	if msg == "" && code != "" {
+		msg = opts.withErrorMessages[code]
+	}
+	if msg == "" {
+		return err
+	}
+	return &messageError{err: err, message: msg}
+}
@@ -0,0 +1,136 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql_test
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/mql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse_WithErrorMessages(t *testing.T) {
+	t.Parallel()
+	messages := map[mql.ErrorCode]string{
+		mql.CodeInvalidColumn:        "colonne invalide",
+		mql.CodeMissingRightSideExpr: "expression manquante",
+	}
+	posFunc := func(code mql.ErrorCode, err error) string {
+		var pErr *mql.ParseError
+		if code == mql.CodeMissingRightSideExpr && errors.As(err, &pErr) {
+			return "expression manquante à la position " + strconv.Itoa(pErr.Pos)
+		}
+		return ""
+	}
+	tests := []struct {
+		name        string
+		query       string
+		opts        []mql.Option
+		msgOpts     []mql.Option
+		wantMessage string
+		wantErrIs   error
+	}{
+		{
+			name:        "message",
+			query:       `nickname="alice"`,
+			msgOpts:     []mql.Option{mql.WithErrorMessages(messages)},
+			wantMessage: "colonne invalide",
+			wantErrIs:   mql.ErrInvalidColumn,
+		},
+		{
+			name:        "default-description",
+			query:       `name="alice" or`,
+			wantMessage: "logical operator without a right side expression",
+			wantErrIs:   mql.ErrMissingRightSideExpr,
+		},
+		{
+			name:        "missing-message",
+			query:       `name=`,
+			msgOpts:     []mql.Option{mql.WithErrorMessages(messages)},
+			wantMessage: "missing comparison value",
+			wantErrIs:   mql.ErrMissingComparisonValue,
+		},
+		{
+			name:        "merged-messages",
+			query:       `name=`,
+			msgOpts:     []mql.Option{mql.WithErrorMessages(messages), mql.WithErrorMessages(map[mql.ErrorCode]string{mql.CodeMissingComparisonValue: "valeur manquante"})},
+			wantMessage: "valeur manquante",
+			wantErrIs:   mql.ErrMissingComparisonValue,
+		},
+		{
+			name:        "func",
+			query:       `name="alice" or`,
+			msgOpts:     []mql.Option{mql.WithErrorMessages(messages), mql.WithErrorMessageFunc(posFunc)},
+			wantMessage: "expression manquante à la position 15",
+			wantErrIs:   mql.ErrMissingRightSideExpr,
+		},
+		{
+			name:        "func-falls-back-to-messages",
+			query:       `nickname="alice"`,
+			msgOpts:     []mql.Option{mql.WithErrorMessages(messages), mql.WithErrorMessageFunc(posFunc)},
+			wantMessage: "colonne invalide",
+			wantErrIs:   mql.ErrInvalidColumn,
+		},
+		{
+			name:    "unknown-code",
+			query:   `name="alice"`,
+			msgOpts: []mql.Option{mql.WithErrorMessages(messages)},
+			opts: []mql.Option{mql.WithConverter("name", func(string, mql.ComparisonOp, *string) (*mql.WhereClause, error) {
+				return nil, errors.New("converter failed")
+			})},
+			wantMessage: "",
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert, require := assert.New(t), require.New(t)
+			_, err := mql.Parse(tc.query, testModel{}, append(tc.opts, tc.msgOpts...)...)
+			require.Error(err)
+			assert.Equal(tc.wantMessage, mql.ErrorMessage(err))
+			assert.Equal(tc.wantMessage, mql.NewCodedError(err).Message)
+			if tc.wantErrIs != nil {
+				assert.ErrorIs(err, tc.wantErrIs)
+			}
+			// the error's text is unchanged
+			_, errWithout := mql.Parse(tc.query, testModel{}, tc.opts...)
+			assert.Equal(mql.ErrorCodeOf(errWithout), mql.ErrorCodeOf(err))
+			if tc.wantMessage != mql.ErrorMessage(errWithout) {
+				assert.NotContains(err.Error(), tc.wantMessage)
+			}
+		})
+	}
+	t.Run("parse-reader", func(t *testing.T) {
+		_, err := mql.ParseReader(strings.NewReader(`name="alice"`), testModel{}, mql.WithMaxQueryLength(5), mql.WithErrorMessages(map[mql.ErrorCode]string{mql.CodeQueryTooLong: "requête trop longue"}))
+		require.Error(t, err)
+		assert.Equal(t, "requête trop longue", mql.ErrorMessage(err))
+	})
+	t.Run("parser", func(t *testing.T) {
+		p, err := mql.NewParser(testModel{}, mql.WithErrorMessages(messages))
+		require.NoError(t, err)
+		_, err = p.Parse(`nickname="alice"`)
+		require.Error(t, err)
+		assert.Equal(t, "colonne invalide", mql.ErrorMessage(err))
+	})
+	t.Run("nil", func(t *testing.T) {
+		assert.Empty(t, mql.ErrorMessage(nil))
+	})
+	t.Run("err-missing-messages", func(t *testing.T) {
+		_, err := mql.Parse(`name="alice"`, testModel{}, mql.WithErrorMessages(nil))
+		assert.ErrorIs(t, err, mql.ErrInvalidParameter)
+	})
+	t.Run("err-missing-code", func(t *testing.T) {
+		_, err := mql.Parse(`name="alice"`, testModel{}, mql.WithErrorMessages(map[mql.ErrorCode]string{"": "message"}))
+		assert.ErrorIs(t, err, mql.ErrInvalidParameter)
+	})
+	t.Run("err-missing-func", func(t *testing.T) {
+		_, err := mql.Parse(`name="alice"`, testModel{}, mql.WithErrorMessageFunc(nil))
+		assert.ErrorIs(t, err, mql.ErrInvalidParameter)
+	})
+}
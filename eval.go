@@ -0,0 +1,612 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math/big"
+	"net/netip"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Match will evaluate the query against the item (a struct or a pointer to a
+// struct) in memory and report if the item matches the query.  Comparisons
+// follow the same rules as the where clauses returned by Parse: time fields
+// are compared to a date by their date, contains (%) is a case sensitive substring match and
+// by default a nil/invalid value (think: NULL) never matches a comparison (see
+// WithNullSemantics).  Supported options: WithColumnMap, WithIgnoreFields,
+// WithModelDescriber, WithNullSemantics, WithAllowEmptyQuery
+func Match(query string, item any, opt ...Option) (bool, error) {
+	const op = "mql.Match"
+	ev, err := newEvaluator(query, item, opt...)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	ok, err := ev.match(reflect.ValueOf(item))
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	return ok, nil
+}
+
+// Filter will evaluate the query in memory against every item and return only
+// the items which match.  The query is parsed and validated once using the
+// item type as its model.  Supported options: the same options as Match.
+func Filter[T any](query string, items []T, opt ...Option) ([]T, error) {
+	const op = "mql.Filter"
+	var model any
+	switch {
+	case len(items) > 0:
+		model = items[0]
+	default:
+		model = zeroModel[T]()
+	}
+	ev, err := newEvaluator(query, model, opt...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	filtered := make([]T, 0, len(items))
+	for i := range items {
+		ok, err := ev.match(reflect.ValueOf(items[i]))
+		if err != nil {
+			return nil, fmt.Errorf("%s: item %d: %w", op, i, err)
+		}
+		if ok {
+			filtered = append(filtered, items[i])
+		}
+	}
+	return filtered, nil
+}
+
+// NullSemantics defines how Match and Filter compare a nil value (a nil
+// pointer, an invalid sql.Null* value or a missing map key).  See
+// WithNullSemantics
+type NullSemantics int
+
+const (
+	// SqlNulls will never match a comparison with a nil value (for every
+	// operator, including !=), just like NULL in a sql where clause.  It's the
+	// default, so in memory filtering matches database filtering.
+	SqlNulls NullSemantics = iota
+
+	// ZeroValueNulls will compare a nil value as the zero value of the field's
+	// type (0, "" or the zero time.Time), just like Go.
+	ZeroValueNulls
+
+	// NullsFirst will order a nil value before every other value: it matches
+	// !=, < and <= comparisons and never matches =, >, >= or contains.
+	NullsFirst
+
+	// NullsLast will order a nil value after every other value: it matches
+	// !=, > and >= comparisons and never matches =, <, <= or contains.
+	NullsLast
+)
+
+func (s NullSemantics) valid() bool {
+	return s >= SqlNulls && s <= NullsLast
+}
+
+// zeroModel returns a zero value of T which can be used as a model, so
+// pointers to structs are allocated.
+func zeroModel[T any]() any {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if t.Kind() == reflect.Pointer {
+		return reflect.New(t.Elem()).Interface()
+	}
+	return reflect.New(t).Elem().Interface()
+}
+
+// evaluator evaluates a parsed query against items in memory
+type evaluator struct {
+	expr       Expr
+	validators map[string]validator
+	opts       options
+	// fields caches the index of struct fields by their normalized name for
+	// every type evaluated.
+	fields map[reflect.Type]map[string]int
+}
+
+// newEvaluator will parse the query and validate it using the model.
+func newEvaluator(query string, model any, opt ...Option) (*evaluator, error) {
+	const op = "mql.newEvaluator"
+	opts, err := getOpts(opt...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	switch {
+	case query == "" && !opts.withAllowEmptyQuery:
+		return nil, fmt.Errorf("%s: missing query: %w", op, ErrInvalidParameter)
+	case isNil(model):
+		return nil, fmt.Errorf("%s: missing model: %w", op, ErrInvalidParameter)
+	case len(opts.withValidateConvertFns) > 0 || len(opts.withContextConvertFns) > 0 || opts.withDefaultConverter != nil:
+		return nil, fmt.Errorf("%s: converters are not supported when evaluating in memory: %w", op, ErrInvalidParameter)
+	case len(opts.withRelationships) > 0:
+		return nil, fmt.Errorf("%s: relationships are not supported when evaluating in memory: %w", op, ErrInvalidParameter)
+	}
+	ev := &evaluator{opts: opts, fields: map[reflect.Type]map[string]int{}}
+	if opts.withAllowEmptyQuery && strings.TrimSpace(query) == "" {
+		return ev, nil
+	}
+	p := newParser(query)
+	p.configure(opts)
+	if ev.expr, err = p.parse(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if ev.validators, err = modelValidators(model, opt...); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	// the where clause isn't needed, but converting the expr validates every
+	// column and value the same way Parse does.
+	if _, err := exprToWhereClause(ev.expr, ev.validators, opt...); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return ev, nil
+}
+
+// match reports if the item matches the evaluator's expr
+func (ev *evaluator) match(item reflect.Value) (bool, error) {
+	const op = "mql.(evaluator).match"
+	if ev.expr == nil {
+		// an empty query matches everything
+		return true, nil
+	}
+	for item.Kind() == reflect.Pointer || item.Kind() == reflect.Interface {
+		if item.IsNil() {
+			return false, fmt.Errorf("%s: missing item: %w", op, ErrInvalidParameter)
+		}
+		item = item.Elem()
+	}
+	if item.Kind() != reflect.Struct {
+		return false, fmt.Errorf("%s: item must be a struct or a pointer to a struct: %w", op, ErrInvalidParameter)
+	}
+	return ev.matchExpr(ev.expr, item)
+}
+
+func (ev *evaluator) matchExpr(e Expr, item reflect.Value) (bool, error) {
+	const op = "mql.(evaluator).matchExpr"
+	switch v := e.(type) {
+	case *ComparisonExpr:
+		return ev.matchComparison(v, item)
+	case *LogicalExpr:
+		left, err := ev.matchExpr(v.LeftExpr, item)
+		if err != nil {
+			return false, fmt.Errorf("%s: invalid left expr: %w", op, err)
+		}
+		switch v.LogicalOp {
+		case AndOp:
+			if !left {
+				return false, nil
+			}
+		case OrOp:
+			if left {
+				return true, nil
+			}
+		default:
+			return false, fmt.Errorf("%s: %w", op, ErrMissingLogicalOp)
+		}
+		right, err := ev.matchExpr(v.RightExpr, item)
+		if err != nil {
+			return false, fmt.Errorf("%s: invalid right expr: %w", op, err)
+		}
+		return right, nil
+	default:
+		return false, fmt.Errorf("%s: unexpected expr type %T: %w", op, v, ErrInternal)
+	}
+}
+
+// matchComparison resolves the comparison's column to a field of the item
+// (or a key of a map field) and compares its value.
+func (ev *evaluator) matchComparison(e *ComparisonExpr, item reflect.Value) (bool, error) {
+	const op = "mql.(evaluator).matchComparison"
+	e, err := resolveVariable(e, ev.opts)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	if e, err = transformValue(e, ev.opts); err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	columnName := strings.ToLower(e.Column)
+	if n, ok := ev.opts.withColumnMap[columnName]; ok {
+		columnName = n
+	}
+	fName := strings.ToLower(strings.ReplaceAll(columnName, "_", ""))
+	v, ok := ev.validators[fName]
+	var key string
+	if !ok {
+		var found bool
+		fName, key, found = strings.Cut(e.Column, ".")
+		if !found {
+			return false, fmt.Errorf("%s: %w %q", op, ErrInvalidColumn, columnName)
+		}
+		fName = strings.ToLower(fName)
+		if n, ok := ev.opts.withColumnMap[fName]; ok {
+			fName = n
+		}
+		fName = strings.ToLower(strings.ReplaceAll(fName, "_", ""))
+		if v, ok = ev.validators[fName]; !ok || v.typ != "map" {
+			return false, fmt.Errorf("%s: %w %q", op, ErrInvalidColumn, columnName)
+		}
+	}
+	field, err := ev.field(item, fName)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	typ := v.typ
+	if typ == "map" {
+		typ = v.elemTyp
+		// a nil map is handled like a missing key
+		if m := indirect(field); m.IsValid() && m.Kind() == reflect.Map {
+			field = m.MapIndex(reflect.ValueOf(key))
+		} else {
+			field = reflect.Value{}
+		}
+	}
+	if typ == "array" {
+		matched, err := matchArray(e, v, field)
+		if err != nil {
+			return false, fmt.Errorf("%s: %w", op, err)
+		}
+		return matched, nil
+	}
+	fv, ok := fieldValue(field)
+	if _, isNull, err := nullCondition(e, ev.opts); isNull || err != nil {
+		if err != nil {
+			return false, fmt.Errorf("%s: %w", op, err)
+		}
+		// the column is compared to NULL (see WithEmptyStringAsNull)
+		return ok == (e.ComparisonOp == NotEqualOp), nil
+	}
+	if _, registered := lookupFieldType(typ); ok && registered {
+		// registered types are compared using their own value rather than
+		// their driver.Value
+		fv = indirect(field).Interface()
+	}
+	if !ok {
+		matched, err := ev.matchNull(fName, typ, e, v.fn)
+		if err != nil {
+			return false, fmt.Errorf("%s: %w", op, err)
+		}
+		return matched, nil
+	}
+	matched, err := matchValue(e, typ, fv, v.fn, ev.opts)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	return matched, nil
+}
+
+// matchNull matches a comparison for a missing value (nil pointers, invalid
+// sql.Null* values and missing map keys) using the NullSemantics for the field
+func (ev *evaluator) matchNull(fName, typ string, e *ComparisonExpr, fn validateFunc) (bool, error) {
+	const op = "mql.(evaluator).matchNull"
+	if ev.opts.withNullSafeNotEqual && e.ComparisonOp == NotEqualOp {
+		// a missing value is never equal to the value (see
+		// WithNullSafeNotEqual)
+		return true, nil
+	}
+	semantics := ev.opts.withNullSemantics
+	if s, ok := ev.opts.withColumnNullSemantics[fName]; ok {
+		semantics = s
+	}
+	switch semantics {
+	case ZeroValueNulls:
+		matched, err := matchValue(e, typ, zeroValue(typ), fn, ev.opts)
+		if err != nil {
+			return false, fmt.Errorf("%s: %w", op, err)
+		}
+		return matched, nil
+	case NullsFirst:
+		switch e.ComparisonOp {
+		case NotEqualOp, LessThanOp, LessThanOrEqualOp:
+			return true, nil
+		}
+		return false, nil
+	case NullsLast:
+		switch e.ComparisonOp {
+		case NotEqualOp, GreaterThanOp, GreaterThanOrEqualOp:
+			return true, nil
+		}
+		return false, nil
+	default:
+		// a missing value never matches, just like NULL in sql
+		return false, nil
+	}
+}
+
+// zeroValue returns the zero value used by ZeroValueNulls for the validator
+// type
+func zeroValue(typ string) any {
+	if ft, ok := lookupFieldType(typ); ok {
+		return reflect.Zero(ft.typ).Interface()
+	}
+	switch typ {
+	case "int":
+		return int64(0)
+	case "float":
+		return float64(0)
+	case "duration":
+		return int64(0)
+	case "decimal":
+		return "0"
+	case "time":
+		return time.Time{}
+	case "bool":
+		return false
+	case "ip":
+		return netip.Addr{}
+	default:
+		return ""
+	}
+}
+
+// matchValue matches a comparison for the field's value.  Supported options:
+// WithSimilarityThreshold
+func matchValue(e *ComparisonExpr, typ string, fv any, fn validateFunc, opts options) (bool, error) {
+	const op = "mql.matchValue"
+	switch e.ComparisonOp {
+	case ContainsOp:
+		return strings.Contains(fmt.Sprint(fv), *e.Value), nil
+	case SimilarToOp:
+		return similar(fmt.Sprint(fv), *e.Value, opts.withSimilarityThreshold), nil
+	}
+	if typ == "ip" {
+		a, _ := ipValue(fv)
+		matched, err := compareIP(a, e.ComparisonOp, *e.Value)
+		if err != nil {
+			return false, fmt.Errorf("%s: %s: %w", op, e.String(), err)
+		}
+		return matched, nil
+	}
+	cmp, err := compareValue(typ, fv, *e.Value, fn)
+	if err != nil {
+		return false, fmt.Errorf("%s: %s: %w", op, e.String(), err)
+	}
+	switch e.ComparisonOp {
+	case EqualOp:
+		return cmp == 0, nil
+	case NotEqualOp:
+		return cmp != 0, nil
+	case GreaterThanOp:
+		return cmp > 0, nil
+	case GreaterThanOrEqualOp:
+		return cmp >= 0, nil
+	case LessThanOp:
+		return cmp < 0, nil
+	case LessThanOrEqualOp:
+		return cmp <= 0, nil
+	default:
+		return false, fmt.Errorf("%s: %w %q", op, ErrInvalidComparisonOp, e.ComparisonOp)
+	}
+}
+
+// matchArray matches a comparison for an array field, which matches when any
+// of its elements is equal to the comparison's value.  A nil array never
+// matches, just like NULL in sql.
+func matchArray(e *ComparisonExpr, v validator, field reflect.Value) (bool, error) {
+	const op = "mql.matchArray"
+	if e.ComparisonOp != ArrayContainsOp {
+		return false, fmt.Errorf("%s: %w %q for array column %q", op, ErrInvalidComparisonOp, e.ComparisonOp, e.Column)
+	}
+	arr := indirect(field)
+	if !arr.IsValid() || (arr.Kind() != reflect.Slice && arr.Kind() != reflect.Array) {
+		return false, nil
+	}
+	elemExpr := &ComparisonExpr{Column: e.Column, ComparisonOp: EqualOp, Value: e.Value}
+	for i := 0; i < arr.Len(); i++ {
+		fv, ok := fieldValue(arr.Index(i))
+		if !ok {
+			continue
+		}
+		if _, registered := lookupFieldType(v.elemTyp); registered {
+			fv = indirect(arr.Index(i)).Interface()
+		}
+		matched, err := matchValue(elemExpr, v.elemTyp, fv, v.fn, options{})
+		if err != nil {
+			return false, fmt.Errorf("%s: %w", op, err)
+		}
+		if matched {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// field returns the item's field for the normalized field name
+func (ev *evaluator) field(item reflect.Value, fName string) (reflect.Value, error) {
+	const op = "mql.(evaluator).field"
+	idx, ok := ev.fields[item.Type()]
+	if !ok {
+		idx = make(map[string]int, item.NumField())
+		for i := 0; i < item.NumField(); i++ {
+			idx[strings.ToLower(item.Type().Field(i).Name)] = i
+		}
+		ev.fields[item.Type()] = idx
+	}
+	i, ok := idx[fName]
+	if !ok {
+		return reflect.Value{}, fmt.Errorf("%s: %w %q not found in %s", op, ErrInvalidColumn, fName, item.Type())
+	}
+	return item.Field(i), nil
+}
+
+// indirect will dereference pointers and interfaces, returning an invalid
+// value for nil.
+func indirect(v reflect.Value) reflect.Value {
+	for v.IsValid() && (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+// fieldValue returns the underlying value of the field and false when the
+// field is nil or invalid (think: sql.NullString{Valid: false})
+func fieldValue(field reflect.Value) (any, bool) {
+	field = indirect(field)
+	if !field.IsValid() {
+		return nil, false
+	}
+	if t, ok := wrappedType(field.Type().String()); ok && strings.HasPrefix(field.Type().String(), "wrapperspb.") {
+		// protobuf wrappers (ie: wrapperspb.StringValue) store their value
+		// in their Value field
+		if v := field.FieldByName("Value"); v.IsValid() && v.Type().String() == t {
+			return fieldValue(v)
+		}
+	}
+	if field.CanInterface() {
+		if valuer, ok := field.Interface().(driver.Valuer); ok {
+			v, err := valuer.Value()
+			if err != nil || v == nil {
+				return nil, false
+			}
+			// the value is normalized when its type differs, since a
+			// sql.Null[T] returns its T (ie: an int)
+			if rv := reflect.ValueOf(v); rv.Type() != field.Type() {
+				return fieldValue(rv)
+			}
+			return v, true
+		}
+	}
+	switch field.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return field.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return field.Uint(), true
+	case reflect.Float32, reflect.Float64:
+		return field.Float(), true
+	case reflect.String:
+		return field.String(), true
+	}
+	if field.CanInterface() {
+		return field.Interface(), true
+	}
+	return nil, false
+}
+
+// compareValue compares the field's value with the query's value and returns
+// -1, 0 or +1 when the field's value is less than, equal to or greater than
+// the query's value.
+func compareValue(typ string, fieldVal any, queryVal string, fn validateFunc) (int, error) {
+	const op = "mql.compareValue"
+	if ft, ok := lookupFieldType(typ); ok {
+		qv, err := fn(queryVal)
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+		cmp, err := ft.compare(fieldVal, qv)
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+		return cmp, nil
+	}
+	switch typ {
+	case "int":
+		qv, err := fn(queryVal)
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+		if cmp, ok := compareInts(fieldVal, qv); ok {
+			return cmp, nil
+		}
+	case "float":
+		qv, err := fn(queryVal)
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+		q := qv.(float64)
+		switch fv := fieldVal.(type) {
+		case float64:
+			return compareOrdered(fv, q), nil
+		case int64:
+			return compareOrdered(float64(fv), q), nil
+		case uint64:
+			return compareOrdered(float64(fv), q), nil
+		}
+	case "duration":
+		fv, ok := fieldVal.(int64)
+		if !ok {
+			break
+		}
+		// the query's value was validated using the duration unit, but the
+		// field's value is always in nanoseconds
+		q, err := time.ParseDuration(queryVal)
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+		return compareOrdered(fv, int64(q)), nil
+	case "decimal":
+		fv, ok := ratValue(fieldVal)
+		if !ok {
+			break
+		}
+		qv, err := fn(queryVal)
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+		q, ok := new(big.Rat).SetString(fmt.Sprint(qv))
+		if !ok {
+			return 0, fmt.Errorf("%s: value %q is not a decimal: %w", op, queryVal, ErrInvalidParameter)
+		}
+		return fv.Cmp(q), nil
+	case "bool":
+		fv, ok := fieldVal.(bool)
+		if !ok {
+			break
+		}
+		qv, err := fn(queryVal)
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+		// only = and != are valid for bools, so any difference will do
+		if fv != qv.(bool) {
+			return 1, nil
+		}
+		return 0, nil
+	case "time":
+		fv, ok := fieldVal.(time.Time)
+		if !ok {
+			break
+		}
+		qv, err := fn(queryVal)
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+		return compareTime(fv, qv.(time.Time), isDateLiteral(queryVal)), nil
+	}
+	var fv string
+	switch v := fieldVal.(type) {
+	case string:
+		fv = v
+	case int64:
+		fv = strconv.FormatInt(v, 10)
+	case uint64:
+		fv = strconv.FormatUint(v, 10)
+	case float64:
+		fv = strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		fv = fmt.Sprint(v)
+	}
+	return strings.Compare(fv, queryVal), nil
+}
+
+type ordered interface {
+	~int | ~int64 | ~uint64 | ~float64
+}
+
+func compareOrdered[T ordered](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,386 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql_test
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/mql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatch(t *testing.T) {
+	t.Parallel()
+	birthday := time.Date(2000, 1, 15, 10, 30, 0, 0, time.UTC)
+	alice := testModel{
+		ID:           1,
+		Name:         "alice",
+		Email:        pointer("alice@example.com"),
+		Age:          30,
+		Length:       1.5,
+		Birthday:     &birthday,
+		MemberNumber: sql.NullString{String: "m-100", Valid: true},
+		CreatedAt:    time.Date(2023, 6, 1, 23, 0, 0, 0, time.UTC),
+		Labels:       map[string]string{"env": "prod"},
+		Scores:       map[string]int{"math": 90},
+	}
+	tests := []struct {
+		name            string
+		query           string
+		item            any
+		opts            []mql.Option
+		want            bool
+		wantErrIs       error
+		wantErrContains string
+	}{
+		{name: "equal", query: `name="alice"`, item: alice, want: true},
+		{name: "equal-pointer-item", query: `name="alice"`, item: &alice, want: true},
+		{name: "not-equal", query: `name!="alice"`, item: alice, want: false},
+		{name: "contains", query: `email%"example"`, item: alice, want: true},
+		{name: "contains-case-sensitive", query: `email%"EXAMPLE"`, item: alice, want: false},
+		{name: "int-greater-than", query: `age > 21`, item: alice, want: true},
+		{name: "int-less-than-or-equal", query: `age <= 21`, item: alice, want: false},
+		{name: "float-equal", query: `length = 1.5`, item: alice, want: true},
+		{name: "float-greater-than-or-equal", query: `length >= 2`, item: alice, want: false},
+		{name: "time-by-date", query: `birthday = "2000-01-15"`, item: alice, want: true},
+		{name: "time-by-date-ignores-time-of-day", query: `created_at <= "2023-06-01"`, item: alice, want: true},
+		{name: "time-by-date-not-equal", query: `created_at != "2023-06-01"`, item: alice, want: false},
+		{name: "time-by-date-in-literal-zone", query: `created_at = "2023-06-02T00:00:00+02:00"`, item: alice, want: false},
+		{name: "time-by-datetime", query: `created_at < "2023-06-01T23:59:00Z"`, item: alice, want: true},
+		{name: "time-by-datetime-with-zone", query: `created_at = "2023-06-02T01:00:00+02:00"`, item: alice, want: true},
+		{name: "time-by-datetime-without-zone", query: `created_at >= "2023-06-01 23:00:00"`, item: alice, want: true},
+		{name: "time-greater-than", query: `created_at > "2023-05-31"`, item: alice, want: true},
+		{name: "time-relative-today", query: `created_at = today-1d`, item: alice, opts: []mql.Option{mql.WithTimeNowFunc(fixedNow)}, want: true},
+		{name: "time-relative-now", query: `created_at > now-1h`, item: alice, opts: []mql.Option{mql.WithTimeNowFunc(fixedNow)}, want: false},
+		{name: "null-string-valuer", query: `member_number = "m-100"`, item: alice, want: true},
+		{name: "invalid-null-time-never-matches", query: `activated_at != "2023-01-01"`, item: alice, want: false},
+		{name: "nil-pointer-never-matches", query: `email != "bob@example.com"`, item: testModel{}, want: false},
+		{name: "map-key", query: `labels.env = "prod"`, item: alice, want: true},
+		{name: "map-int-key", query: `scores.math > 80`, item: alice, want: true},
+		{name: "map-missing-key-never-matches", query: `labels.region != "us"`, item: alice, want: false},
+		{name: "and", query: `name="alice" and age > 40`, item: alice, want: false},
+		{name: "or", query: `name="bob" or (age > 21 and labels.env="prod")`, item: alice, want: true},
+		{
+			name:  "column-map",
+			query: `nickname="alice"`,
+			item:  alice,
+			opts:  []mql.Option{mql.WithColumnMap(map[string]string{"nickname": "name"})},
+			want:  true,
+		},
+		{
+			name:  "empty-query",
+			query: " ",
+			item:  alice,
+			opts:  []mql.Option{mql.WithAllowEmptyQuery()},
+			want:  true,
+		},
+		{
+			name:            "err-invalid-column",
+			query:           `nickname="alice"`,
+			item:            alice,
+			wantErrIs:       mql.ErrInvalidColumn,
+			wantErrContains: `invalid column "nickname"`,
+		},
+		{
+			name:            "err-invalid-value",
+			query:           `age > "old"`,
+			item:            alice,
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: `"old"`,
+		},
+		{
+			name:            "err-WithEnum",
+			query:           `name="alcie"`,
+			item:            alice,
+			opts:            []mql.Option{mql.WithEnum("name", []string{"alice", "bob"})},
+			wantErrIs:       mql.ErrInvalidEnumValue,
+			wantErrContains: "expected one of: alice, bob",
+		},
+		{
+			name:  "WithValueTransform",
+			query: `email="ALICE@example.com"`,
+			item:  alice,
+			opts: []mql.Option{mql.WithValueTransform("email", func(v string) (string, error) {
+				return strings.ToLower(v), nil
+			})},
+			want: true,
+		},
+		{
+			name:  "err-WithFieldAuthorizer",
+			query: `name="alice" and email%"example.com"`,
+			item:  alice,
+			opts: []mql.Option{mql.WithFieldAuthorizer(func(column string, _ mql.ComparisonOp) error {
+				if column == "email" {
+					return errors.New("email is private")
+				}
+				return nil
+			})},
+			wantErrIs:       mql.ErrInvalidColumn,
+			wantErrContains: `invalid column "email": email is private`,
+		},
+		{
+			name:            "err-invalid-date",
+			query:           `created_at > "yesterday"`,
+			item:            alice,
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: `"yesterday" in (comparisonExpr: created_at > yesterday)`,
+		},
+		{
+			name:            "err-missing-query",
+			item:            alice,
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: "missing query",
+		},
+		{
+			name:            "err-missing-item",
+			query:           `name="alice"`,
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: "missing model",
+		},
+		{
+			name:            "err-converter",
+			query:           `name="alice"`,
+			item:            alice,
+			opts:            []mql.Option{mql.WithConverter("name", func(string, mql.ComparisonOp, *string) (*mql.WhereClause, error) { return nil, nil })},
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: "converters are not supported",
+		},
+		{
+			name:            "err-default-converter",
+			query:           `name="alice"`,
+			item:            alice,
+			opts:            []mql.Option{mql.WithDefaultConverter(func(string, mql.ComparisonOp, *string) (*mql.WhereClause, error) { return nil, nil })},
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: "converters are not supported",
+		},
+		{
+			name:            "err-relationship",
+			query:           `name="alice"`,
+			item:            alice,
+			opts:            []mql.Option{mql.WithRelationship("roles", mql.Relationship{Model: roleModel{}, ForeignKey: "user_id"})},
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: "relationships are not supported",
+		},
+		{
+			name:            "err-syntax",
+			query:           `(name="alice"`,
+			item:            alice,
+			wantErrIs:       mql.ErrMissingClosingParen,
+			wantErrContains: "missing closing paren",
+		},
+		{
+			name:            "err-uint-negative-value",
+			query:           `id > "-1"`,
+			item:            alice,
+			wantErrIs:       mql.ErrValueOutOfRange,
+			wantErrContains: `"-1" isn't in the range of uint`,
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			assert, require := assert.New(t), require.New(t)
+			got, err := mql.Match(tc.query, tc.item, tc.opts...)
+			if tc.wantErrContains != "" {
+				require.Error(err)
+				assert.False(got)
+				assert.ErrorIs(err, tc.wantErrIs)
+				assert.ErrorContains(err, tc.wantErrContains)
+				return
+			}
+			require.NoError(err)
+			assert.Equal(tc.want, got)
+		})
+	}
+}
+
+func TestMatch_bool(t *testing.T) {
+	t.Parallel()
+	item := boolModel{
+		Enabled:  true,
+		Verified: sql.NullBool{Bool: false, Valid: true},
+		Flags:    map[string]bool{"beta": true},
+	}
+	tests := []struct {
+		query string
+		opts  []mql.Option
+		want  bool
+	}{
+		{query: `enabled=true`, want: true},
+		{query: `enabled!=true`, want: false},
+		{query: `verified=false`, want: true},
+		{query: `flags.beta=true and enabled="TRUE"`, want: true},
+		{query: `deleted=false`, want: false},
+		{query: `deleted=false`, opts: []mql.Option{mql.WithNullSemantics(mql.ZeroValueNulls)}, want: true},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.query, func(t *testing.T) {
+			got, err := mql.Match(tc.query, item, tc.opts...)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+	_, err := mql.Match(`enabled < true`, item)
+	assert.ErrorIs(t, err, mql.ErrInvalidComparisonOp)
+}
+
+func TestMatch_duration(t *testing.T) {
+	t.Parallel()
+	item := durationModel{
+		Timeout: 90 * time.Second,
+		Limits:  map[string]time.Duration{"read": time.Millisecond},
+	}
+	tests := []struct {
+		query string
+		opts  []mql.Option
+		want  bool
+	}{
+		{query: `timeout > "1m"`, want: true},
+		{query: `timeout = "1m30s"`, want: true},
+		{query: `timeout = "90s"`, opts: []mql.Option{mql.WithDurationUnit(time.Second)}, want: true},
+		{query: `limits.read <= "1ms"`, want: true},
+		{query: `ttl < "1h"`, want: false},
+		{query: `ttl < "1h"`, opts: []mql.Option{mql.WithNullSemantics(mql.ZeroValueNulls)}, want: true},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.query, func(t *testing.T) {
+			got, err := mql.Match(tc.query, item, tc.opts...)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+	_, err := mql.Match(`timeout > 90`, item)
+	assert.ErrorIs(t, err, mql.ErrInvalidParameter)
+}
+
+func TestFilter(t *testing.T) {
+	t.Parallel()
+	users := []testModel{
+		{ID: 1, Name: "alice", Age: 30},
+		{ID: 2, Name: "bob", Age: 17},
+		{ID: 3, Name: "carol", Age: 45},
+	}
+	t.Run("structs", func(t *testing.T) {
+		got, err := mql.Filter(`age > 21`, users)
+		require.NoError(t, err)
+		assert.Equal(t, []testModel{users[0], users[2]}, got)
+	})
+	t.Run("pointers", func(t *testing.T) {
+		got, err := mql.Filter(`name="bob"`, []*testModel{&users[0], &users[1]})
+		require.NoError(t, err)
+		assert.Equal(t, []*testModel{&users[1]}, got)
+	})
+	t.Run("no-matches", func(t *testing.T) {
+		got, err := mql.Filter(`name="dave"`, users)
+		require.NoError(t, err)
+		assert.Empty(t, got)
+	})
+	t.Run("no-items-still-validated", func(t *testing.T) {
+		got, err := mql.Filter(`nickname="dave"`, []*testModel{})
+		require.Error(t, err)
+		assert.Empty(t, got)
+		assert.ErrorIs(t, err, mql.ErrInvalidColumn)
+	})
+	t.Run("err-nil-item", func(t *testing.T) {
+		got, err := mql.Filter(`name="bob"`, []*testModel{&users[0], nil})
+		require.Error(t, err)
+		assert.Empty(t, got)
+		assert.ErrorIs(t, err, mql.ErrInvalidParameter)
+		assert.ErrorContains(t, err, "item 1")
+	})
+}
+
+func TestMatch_nullSemantics(t *testing.T) {
+	t.Parallel()
+	item := testModel{Scores: map[string]int{}}
+	ops := []string{"=", "!=", ">", ">=", "<", "<=", "%"}
+	tests := []struct {
+		semantics mql.NullSemantics
+		want      []bool // indexed by ops
+	}{
+		{semantics: mql.SqlNulls, want: []bool{false, false, false, false, false, false, false}},
+		{semantics: mql.ZeroValueNulls, want: []bool{true, false, false, true, false, true, true}},
+		{semantics: mql.NullsFirst, want: []bool{false, true, false, false, true, true, false}},
+		{semantics: mql.NullsLast, want: []bool{false, true, true, true, false, false, false}},
+	}
+	for _, tc := range tests {
+		tc := tc
+		for i, cmpOp := range ops {
+			query := "scores.missing " + cmpOp + " 0"
+			t.Run(fmt.Sprintf("%d/%s", tc.semantics, query), func(t *testing.T) {
+				got, err := mql.Match(query, item, mql.WithNullSemantics(tc.semantics))
+				require.NoError(t, err)
+				assert.Equal(t, tc.want[i], got)
+			})
+		}
+	}
+	t.Run("zero-values", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		zero := mql.WithNullSemantics(mql.ZeroValueNulls)
+		for _, query := range []string{
+			`email = ""`,
+			`member_number = ""`,
+			`birthday < "2000-01-01"`,
+			`labels.env != "prod"`,
+		} {
+			got, err := mql.Match(query, testModel{}, zero)
+			require.NoError(err, query)
+			assert.True(got, query)
+		}
+	})
+	t.Run("per-column", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		opts := []mql.Option{
+			mql.WithNullSemantics(mql.NullsLast),
+			mql.WithNullSemantics(mql.ZeroValueNulls, "member_number", "Labels"),
+		}
+		got, err := mql.Match(`member_number = "" and labels.env = ""`, testModel{}, opts...)
+		require.NoError(err)
+		assert.True(got)
+		got, err = mql.Match(`email > "z"`, testModel{}, opts...)
+		require.NoError(err)
+		assert.True(got)
+		got, err = mql.Match(`email = ""`, testModel{}, opts...)
+		require.NoError(err)
+		assert.False(got)
+	})
+	t.Run("filter", func(t *testing.T) {
+		items := []testModel{{ID: 1}, {ID: 2, Email: pointer("bob@example.com")}}
+		got, err := mql.Filter(`email != "alice@example.com"`, items)
+		require.NoError(t, err)
+		assert.Equal(t, items[1:], got)
+		got, err = mql.Filter(`email != "alice@example.com"`, items, mql.WithNullSemantics(mql.ZeroValueNulls, "email"))
+		require.NoError(t, err)
+		assert.Equal(t, items, got)
+	})
+	t.Run("WithEmptyStringAsNull", func(t *testing.T) {
+		items := []testModel{{ID: 1}, {ID: 2, Email: pointer("")}, {ID: 3, Email: pointer("bob@example.com")}}
+		got, err := mql.Filter(`email = ""`, items, mql.WithEmptyStringAsNull("email"))
+		require.NoError(t, err)
+		assert.Equal(t, items[:1], got)
+		got, err = mql.Filter(`email != ""`, items, mql.WithEmptyStringAsNull("email"))
+		require.NoError(t, err)
+		assert.Equal(t, items[1:], got)
+	})
+	t.Run("err-invalid-semantics", func(t *testing.T) {
+		_, err := mql.Match(`name="alice"`, testModel{}, mql.WithNullSemantics(mql.NullSemantics(-1)))
+		require.Error(t, err)
+		assert.ErrorIs(t, err, mql.ErrInvalidParameter)
+		assert.ErrorContains(t, err, "invalid null semantics -1")
+	})
+	t.Run("err-missing-column", func(t *testing.T) {
+		_, err := mql.Match(`name="alice"`, testModel{}, mql.WithNullSemantics(mql.NullsFirst, ""))
+		require.Error(t, err)
+		assert.ErrorIs(t, err, mql.ErrInvalidParameter)
+		assert.ErrorContains(t, err, "missing column")
+	})
+}
@@ -0,0 +1,52 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+//go:build js && wasm
+
+// wasm is an example of validating queries in a browser using the same code
+// as the server.  It registers a global mqlCheck(query, fields) func, where
+// fields is a JSON array of the model's fields (ie: [{"Name": "Age", "Type":
+// "int"}]), which returns the JSON of a mql.QueryCheck.  Build it using:
+//
+//	GOOS=js GOARCH=wasm go build -o mql.wasm ./examples/wasm
+//	cp "$(go env GOROOT)/misc/wasm/wasm_exec.js" ./examples/wasm
+//
+// and serve the directory (see index.html).
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"syscall/js"
+
+	"github.com/hashicorp/mql"
+)
+
+func main() {
+	js.Global().Set("mqlCheck", js.FuncOf(check))
+	// block forever, so the func can be called
+	select {}
+}
+
+// check validates the query (args[0]) using the JSON fields (args[1]) and
+// returns the JSON of the mql.QueryCheck
+func check(_ js.Value, args []js.Value) any {
+	if len(args) != 2 {
+		return errorJson(fmt.Errorf("expected 2 args (query, fields) and got %d", len(args)))
+	}
+	var fields []mql.FieldDescriptor
+	if err := json.Unmarshal([]byte(args[1].String()), &fields); err != nil {
+		return errorJson(fmt.Errorf("invalid fields: %w", err))
+	}
+	b, err := json.Marshal(mql.CheckQuery(args[0].String(), fields))
+	if err != nil {
+		return errorJson(err)
+	}
+	return string(b)
+}
+
+// errorJson returns the JSON of an invalid mql.QueryCheck with the error
+func errorJson(err error) string {
+	b, _ := json.Marshal(mql.QueryCheck{Errors: []mql.QueryIssue{{Message: err.Error(), Pos: -1}}})
+	return string(b)
+}
@@ -4,7 +4,12 @@
 package mql
 
 import (
+	"errors"
 	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/exp/slices"
 )
 
 type exprType int
@@ -15,69 +20,219 @@ const (
 	logicalExprType
 )
 
-type expr interface {
+// Expr is a node in the expression tree of a parsed query.  It's either a
+// *ComparisonExpr or a *LogicalExpr.
+type Expr interface {
 	Type() exprType
 	String() string
+	// MQL returns the expr as canonical mql text
+	MQL() string
 }
 
 // ComparisonOp defines a set of comparison operators
 type ComparisonOp string
 
 const (
-	GreaterThanOp        ComparisonOp = ">"
+	// GreaterThanOp reports if the column is greater than the value
+	GreaterThanOp ComparisonOp = ">"
+	// GreaterThanOrEqualOp reports if the column is greater than or equal to
+	// the value
 	GreaterThanOrEqualOp ComparisonOp = ">="
-	LessThanOp           ComparisonOp = "<"
-	LessThanOrEqualOp    ComparisonOp = "<="
-	EqualOp              ComparisonOp = "="
-	NotEqualOp           ComparisonOp = "!="
-	ContainsOp           ComparisonOp = "%"
+	// LessThanOp reports if the column is less than the value
+	LessThanOp ComparisonOp = "<"
+	// LessThanOrEqualOp reports if the column is less than or equal to the
+	// value
+	LessThanOrEqualOp ComparisonOp = "<="
+	// EqualOp reports if the column is equal to the value
+	EqualOp ComparisonOp = "="
+	// NotEqualOp reports if the column isn't equal to the value
+	NotEqualOp ComparisonOp = "!="
+	// ContainsOp reports if the column contains the value (ie: name % "ali"),
+	// which is converted to a LIKE condition
+	ContainsOp ComparisonOp = "%"
+	// ContainedByOp is only supported for IP address columns and reports if
+	// the address is contained by a CIDR (ie: ip << "10.0.0.0/8")
+	ContainedByOp ComparisonOp = "<<"
+	// ArrayContainsOp is only supported for array columns and reports if the
+	// array contains the value (ie: tags @> "prod")
+	ArrayContainsOp ComparisonOp = "@>"
+	// SimilarToOp is only supported for string columns and reports if the
+	// column is similar to the value using trigram similarity, which is
+	// tolerant of typos (ie: name ~% "alise").  It requires the postgres
+	// pg_trgm extension (see WithSimilarityThreshold).
+	SimilarToOp ComparisonOp = "~%"
 )
 
+// supportedComparisonOps is every supported comparison operator, in the order
+// they're documented.
+var supportedComparisonOps = []ComparisonOp{
+	EqualOp,
+	NotEqualOp,
+	GreaterThanOp,
+	GreaterThanOrEqualOp,
+	LessThanOp,
+	LessThanOrEqualOp,
+	ContainsOp,
+	ContainedByOp,
+	ArrayContainsOp,
+	SimilarToOp,
+}
+
+// ComparisonOps returns every supported comparison operator
+func ComparisonOps() []ComparisonOp {
+	ops := make([]ComparisonOp, len(supportedComparisonOps))
+	copy(ops, supportedComparisonOps)
+	return ops
+}
+
+// ParseComparisonOp will parse the comparison operator's symbol (ie: ">=")
+func ParseComparisonOp(s string) (ComparisonOp, error) {
+	const op = "mql.ParseComparisonOp"
+	c, err := newComparisonOp(s)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	return c, nil
+}
+
 func newComparisonOp(s string) (ComparisonOp, error) {
 	const op = "newComparisonOp"
-	switch ComparisonOp(s) {
-	case
-		GreaterThanOp,
-		GreaterThanOrEqualOp,
-		LessThanOp,
-		LessThanOrEqualOp,
-		EqualOp,
-		NotEqualOp,
-		ContainsOp:
-		return ComparisonOp(s), nil
-	default:
+	if !ComparisonOp(s).Valid() {
 		return "", fmt.Errorf("%s: %w %q", op, ErrInvalidComparisonOp, s)
 	}
+	return ComparisonOp(s), nil
+}
+
+// Valid reports if the comparison operator is supported
+func (c ComparisonOp) Valid() bool {
+	for _, o := range supportedComparisonOps {
+		if c == o {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns the comparison operator's symbol
+func (c ComparisonOp) String() string {
+	return string(c)
+}
+
+// MarshalText implements encoding.TextMarshaler, so the comparison operator is
+// encoded as its symbol (ie: in JSON).  It returns an error if the comparison
+// operator isn't supported.
+func (c ComparisonOp) MarshalText() ([]byte, error) {
+	const op = "mql.(ComparisonOp).MarshalText"
+	if !c.Valid() {
+		return nil, fmt.Errorf("%s: %w %q", op, ErrInvalidComparisonOp, string(c))
+	}
+	return []byte(c), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler and returns an error if the
+// text isn't a supported comparison operator.
+func (c *ComparisonOp) UnmarshalText(text []byte) error {
+	const op = "mql.(ComparisonOp).UnmarshalText"
+	parsed, err := newComparisonOp(string(text))
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	*c = parsed
+	return nil
+}
+
+// SQL returns the SQL operator which compares a column to an arg using the
+// dialect (or the default dialect when it's nil), so converters don't have to
+// switch on the operators' symbols (see WithConverter).  The arg of
+// ContainsOp ("like") is a pattern with escaped wildcards (see Dialect.Like).
+// ContainedByOp, ArrayContainsOp and SimilarToOp ("%" using the pg_trgm
+// extension) are only supported by dialects with the postgres operators (see
+// Dialect).  An ErrInvalidComparisonOp is returned when the comparison
+// operator isn't supported by the dialect.
+func (c ComparisonOp) SQL(d Dialect) (string, error) {
+	const op = "mql.(ComparisonOp).SQL"
+	if d == nil {
+		d = defaultDialect{}
+	}
+	switch c {
+	case EqualOp, NotEqualOp, GreaterThanOp, GreaterThanOrEqualOp, LessThanOp, LessThanOrEqualOp:
+		return string(c), nil
+	case ContainsOp:
+		return "like", nil
+	case ContainedByOp, ArrayContainsOp, SimilarToOp:
+		if !hasPgOperators(d) {
+			return "", fmt.Errorf("%s: %w %q isn't supported by the %s dialect", op, ErrInvalidComparisonOp, string(c), d.Name())
+		}
+		if c == SimilarToOp {
+			return "%", nil
+		}
+		return string(c), nil
+	default:
+		return "", fmt.Errorf("%s: %w %q", op, ErrInvalidComparisonOp, string(c))
+	}
 }
 
-type comparisonExpr struct {
-	column       string
-	comparisonOp ComparisonOp
-	value        *string
+// ComparisonExpr is an expr which compares a column to a value, like: name="alice"
+type ComparisonExpr struct {
+	// Column is the column identifier used in the query
+	Column string
+	// ComparisonOp is the comparison operator
+	ComparisonOp ComparisonOp
+	// Value is the value the column is compared to
+	Value *string
+
+	pos int // byte offset of the column in the query
+	// quotedColumn reports if the column was quoted in the query (ie:
+	// "labels.env"="prod"), so its characters are validated (see
+	// WithQuotedColumnChars)
+	quotedColumn bool
+	// variable reports if the value is the name of a variable (ie:
+	// :current_user), which is resolved when the comparison is converted (see
+	// WithVariables)
+	variable bool
 }
 
 // Type returns the expr type
-func (e *comparisonExpr) Type() exprType {
+func (e *ComparisonExpr) Type() exprType {
 	return comparisonExprType
 }
 
 // String returns a string rep of the expr
-func (e *comparisonExpr) String() string {
-	switch e.value {
+func (e *ComparisonExpr) String() string {
+	switch e.Value {
 	case nil:
-		return fmt.Sprintf("(comparisonExpr: %s %s nil)", e.column, e.comparisonOp)
+		return fmt.Sprintf("(comparisonExpr: %s %s nil)", e.Column, e.ComparisonOp)
 	default:
-		return fmt.Sprintf("(comparisonExpr: %s %s %s)", e.column, e.comparisonOp, *e.value)
+		return fmt.Sprintf("(comparisonExpr: %s %s %s)", e.Column, e.ComparisonOp, *e.Value)
 	}
 }
 
-func (e *comparisonExpr) isComplete() bool {
-	return e.column != "" && e.comparisonOp != "" && e.value != nil
+// MQL returns the comparison as canonical mql text, without any whitespace
+// and with its value quoted unless it's a number: name="alice" or age>21
+func (e *ComparisonExpr) MQL() string {
+	column := e.Column
+	if !isSymbolLiteral(column) {
+		column = quoteString(column)
+	}
+	switch {
+	case e.Value == nil:
+		return column + string(e.ComparisonOp)
+	case e.variable:
+		return column + string(e.ComparisonOp) + ":" + *e.Value
+	case isNumberLiteral(*e.Value):
+		return column + string(e.ComparisonOp) + *e.Value
+	default:
+		return column + string(e.ComparisonOp) + quoteString(*e.Value)
+	}
+}
+
+func (e *ComparisonExpr) isComplete() bool {
+	return e.Column != "" && e.ComparisonOp != "" && e.Value != nil
 }
 
 // defaultValidateConvert will validate the comparison expr value, and then convert the
-// expr to its SQL equivalence.
-func defaultValidateConvert(columnName string, comparisonOp ComparisonOp, columnValue *string, validator validator, opt ...Option) (*WhereClause, error) {
+// expr to its SQL equivalence using the options.
+func defaultValidateConvert(columnName string, comparisonOp ComparisonOp, columnValue *string, validator validator, opts options) (*WhereClause, error) {
 	const op = "mql.(comparisonExpr).convertToSql"
 	switch {
 	case columnName == "":
@@ -93,87 +248,386 @@ func defaultValidateConvert(columnName string, comparisonOp ComparisonOp, column
 	}
 
 	// everything was validated at the start, so we know this is a valid/complete comparisonExpr
-	e := &comparisonExpr{
-		column:       columnName,
-		comparisonOp: comparisonOp,
-		value:        columnValue,
+	e := &ComparisonExpr{
+		Column:       columnName,
+		ComparisonOp: comparisonOp,
+		Value:        columnValue,
 	}
 
-	v, err := validator.fn(*e.value)
-	if err != nil {
-		return nil, fmt.Errorf("%s: %q in %s: %w", op, *e.value, e.String(), ErrInvalidParameter)
+	v, err := validator.fn(*e.Value)
+	switch {
+	case errors.Is(err, ErrValueOutOfRange):
+		return nil, fmt.Errorf("%s: %s: %w", op, e.String(), err)
+	case err != nil:
+		return nil, fmt.Errorf("%s: %q in %s: %w", op, *e.Value, e.String(), ErrInvalidParameter)
+	}
+	if validator.typ == "array" {
+		w, err := arrayCondition(dialectOf(opts), columnName, e.ComparisonOp, v, validator.json)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		return w, nil
+	}
+	if e.ComparisonOp == ArrayContainsOp {
+		return nil, fmt.Errorf("%s: %w %q for column %q (only supported for array columns)", op, ErrInvalidComparisonOp, e.ComparisonOp, columnName)
+	}
+	if e.ComparisonOp == ContainedByOp && validator.typ != "ip" {
+		if ft, ok := lookupFieldType(validator.typ); !ok || !slices.Contains(ft.handler.ComparisonOps, ContainedByOp) {
+			return nil, fmt.Errorf("%s: %w %q for column %q (only supported for IP address columns)", op, ErrInvalidComparisonOp, e.ComparisonOp, columnName)
+		}
+	}
+	if e.ComparisonOp == SimilarToOp && validator.typ != "default" {
+		if ft, ok := lookupFieldType(validator.typ); !ok || !slices.Contains(ft.handler.ComparisonOps, SimilarToOp) {
+			return nil, fmt.Errorf("%s: %w %q for column %q (only supported for string columns)", op, ErrInvalidComparisonOp, e.ComparisonOp, columnName)
+		}
+	}
+	if validator.typ == "bool" && e.ComparisonOp != EqualOp && e.ComparisonOp != NotEqualOp {
+		return nil, fmt.Errorf("%s: %w %q for bool column %q (expected = or !=)", op, ErrInvalidComparisonOp, e.ComparisonOp, columnName)
+	}
+	if validator.typ == "default" && isRangeOp(e.ComparisonOp) {
+		if columnName, err = stringRangeColumn(columnName, e.ComparisonOp, validator, opts); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+	// accent insensitive comparisons compare the unaccented column to their
+	// unaccented args (see collatedColumn)
+	var unaccent bool
+	if validator.typ == "default" && (e.ComparisonOp == EqualOp || e.ComparisonOp == NotEqualOp || e.ComparisonOp == ContainsOp) {
+		if columnName, unaccent, err = collatedColumn(columnName, validator, opts); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+	if ft, ok := lookupFieldType(validator.typ); ok && !ft.allows(e.ComparisonOp) {
+		return nil, fmt.Errorf("%s: %w %q for %s column %q (expected one of: %s)", op, ErrInvalidComparisonOp, e.ComparisonOp, validator.typ, columnName, joinOps(ft.handler.ComparisonOps))
+	}
+	if (validator.typ == "duration" || validator.typ == "decimal") && e.ComparisonOp == ContainsOp {
+		return nil, fmt.Errorf("%s: %w %q for %s column %q", op, ErrInvalidComparisonOp, e.ComparisonOp, validator.typ, columnName)
+	}
+	if validator.typ == "ip" {
+		if d := dialectOf(opts); e.ComparisonOp == ContainedByOp && !opts.withBinaryIPs && !hasPgOperators(d) {
+			return nil, fmt.Errorf("%s: %w %q for column %q (not supported by the %s dialect, see WithBinaryIPs)", op, ErrInvalidComparisonOp, e.ComparisonOp, columnName, d.Name())
+		}
+		w, err := ipCondition(columnName, e.ComparisonOp, *e.Value, opts.withBinaryIPs)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		return w, nil
 	}
 	if validator.typ == "time" {
-		columnName = fmt.Sprintf("%s::date", columnName)
+		w, err := timeCondition(columnName, nil, e.ComparisonOp, v.(time.Time), isDateLiteral(*e.Value))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		return w, nil
+	}
+	switch e.ComparisonOp {
+	case ContainsOp:
+		condition, arg, err := containsCondition(columnName, v, opts)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		w := &WhereClause{
+			Condition: condition,
+			Args:      []any{arg},
+		}
+		if unaccent {
+			w = unaccentArgs(w)
+		}
+		return w, nil
+	case SimilarToOp:
+		return similarityCondition(columnName, v, opts), nil
+	default:
+		var w *WhereClause
+		var ok bool
+		if opts.withGlobPatterns && validator.typ == "default" {
+			w, ok = globCondition(dialectOf(opts), columnName, e.ComparisonOp, *e.Value)
+		}
+		if !ok {
+			w = &WhereClause{
+				Condition: columnName + string(e.ComparisonOp) + "?",
+				Args:      []any{v},
+			}
+		}
+		if unaccent {
+			w = unaccentArgs(w)
+		}
+		return w, nil
+	}
+}
+
+// mapValidateConvert will validate the comparison value using the map's element
+// validator and then convert the expr to its SQL equivalence, which is a
+// lookup of the key in a json column.  The key is passed as an arg, so it's
+// never part of the condition.  The lookup and its casts are generated using
+// the dialect.  Supported options: WithRawLikePatterns, WithGlobPatterns,
+// WithDialect
+func mapValidateConvert(columnName string, key string, comparisonOp ComparisonOp, columnValue *string, validator validator, opts options) (*WhereClause, error) {
+	const op = "mql.mapValidateConvert"
+	switch {
+	case columnName == "":
+		return nil, fmt.Errorf("%s: %w", op, ErrMissingColumn)
+	case key == "":
+		return nil, fmt.Errorf("%s: missing key for %q: %w", op, columnName, ErrInvalidColumn)
+	case comparisonOp == "":
+		return nil, fmt.Errorf("%s: %w", op, ErrMissingComparisonOp)
+	case isNil(columnValue):
+		return nil, fmt.Errorf("%s: %w", op, ErrMissingComparisonValue)
+	case validator.fn == nil:
+		return nil, fmt.Errorf("%s: missing validator function: %w", op, ErrInvalidParameter)
+	}
+	v, err := validator.fn(*columnValue)
+	switch {
+	case errors.Is(err, ErrValueOutOfRange):
+		return nil, fmt.Errorf("%s: %s.%s: %w", op, columnName, key, err)
+	case err != nil:
+		return nil, fmt.Errorf("%s: %q in %s.%s: %w", op, *columnValue, columnName, key, ErrInvalidParameter)
+	}
+	if ft, ok := lookupFieldType(validator.elemTyp); ok && !ft.allows(comparisonOp) {
+		return nil, fmt.Errorf("%s: %w %q for %s column %s.%s (expected one of: %s)", op, ErrInvalidComparisonOp, comparisonOp, validator.elemTyp, columnName, key, joinOps(ft.handler.ComparisonOps))
+	}
+	if comparisonOp == ArrayContainsOp {
+		return nil, fmt.Errorf("%s: %w %q for column %s.%s (only supported for array columns)", op, ErrInvalidComparisonOp, comparisonOp, columnName, key)
+	}
+	if comparisonOp == ContainedByOp && validator.elemTyp != "ip" {
+		return nil, fmt.Errorf("%s: %w %q for column %s.%s (only supported for IP address columns)", op, ErrInvalidComparisonOp, comparisonOp, columnName, key)
+	}
+	if comparisonOp == SimilarToOp && validator.elemTyp != "default" {
+		return nil, fmt.Errorf("%s: %w %q for column %s.%s (only supported for string columns)", op, ErrInvalidComparisonOp, comparisonOp, columnName, key)
+	}
+	d := dialectOf(opts)
+	lookup := d.JsonLookup(columnName)
+	var cast CastType
+	switch validator.elemTyp {
+	case "int":
+		cast = CastInt
+	case "duration", "decimal":
+		if comparisonOp == ContainsOp {
+			return nil, fmt.Errorf("%s: %w %q for %s column %s.%s", op, ErrInvalidComparisonOp, comparisonOp, validator.elemTyp, columnName, key)
+		}
+		cast = CastInt
+		if validator.elemTyp == "decimal" {
+			cast = CastDecimal
+		}
+	case "float":
+		cast = CastFloat
+	case "time":
+		cast = CastTime
+	case "ip":
+		cast = CastIP
+	case "bool":
+		if comparisonOp != EqualOp && comparisonOp != NotEqualOp {
+			return nil, fmt.Errorf("%s: %w %q for bool column %s.%s (expected = or !=)", op, ErrInvalidComparisonOp, comparisonOp, columnName, key)
+		}
+		cast = CastBool
+	}
+	if cast != "" {
+		var err error
+		if lookup, err = d.Cast(lookup, cast); err != nil {
+			return nil, fmt.Errorf("%s: %s column %s.%s: %w", op, validator.elemTyp, columnName, key, err)
+		}
+	}
+	switch validator.elemTyp {
+	case "time":
+		w, err := timeCondition(lookup, []any{key}, comparisonOp, v.(time.Time), isDateLiteral(*columnValue))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		return w, nil
+	case "ip":
+		w, err := ipCondition(lookup, comparisonOp, *columnValue, false)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		w.Args = append([]any{key}, w.Args...)
+		return w, nil
 	}
-	switch e.comparisonOp {
+	switch comparisonOp {
 	case ContainsOp:
+		condition, arg, err := containsCondition(lookup, v, opts)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
 		return &WhereClause{
-			Condition: fmt.Sprintf("%s like ?", columnName),
-			Args:      []any{fmt.Sprintf("%%%s%%", v)},
+			Condition: condition,
+			Args:      []any{key, arg},
 		}, nil
+	case SimilarToOp:
+		w := similarityCondition(lookup, v, opts)
+		w.Args = append([]any{key}, w.Args...)
+		return w, nil
 	default:
+		if opts.withGlobPatterns && validator.elemTyp == "default" {
+			if w, ok := globCondition(dialectOf(opts), lookup, comparisonOp, *columnValue); ok {
+				w.Args = append([]any{key}, w.Args...)
+				return w, nil
+			}
+		}
 		return &WhereClause{
-			Condition: fmt.Sprintf("%s%s?", columnName, e.comparisonOp),
-			Args:      []any{v},
+			Condition: fmt.Sprintf("%s%s?", lookup, comparisonOp),
+			Args:      []any{key, v},
 		}, nil
 	}
 }
 
-type logicalOp string
+// LogicalOp defines a set of logical operators
+type LogicalOp string
 
 const (
-	andOp logicalOp = "and"
-	orOp  logicalOp = "or"
+	// AndOp reports if both of its operands are true
+	AndOp LogicalOp = "and"
+	// OrOp reports if either of its operands is true
+	OrOp LogicalOp = "or"
 )
 
-func newLogicalOp(s string) (logicalOp, error) {
+// supportedLogicalOps is every supported logical operator
+var supportedLogicalOps = []LogicalOp{AndOp, OrOp}
+
+// LogicalOps returns every supported logical operator
+func LogicalOps() []LogicalOp {
+	ops := make([]LogicalOp, len(supportedLogicalOps))
+	copy(ops, supportedLogicalOps)
+	return ops
+}
+
+// ParseLogicalOp will parse the logical operator, ignoring case just like
+// queries do (ie: "AND")
+func ParseLogicalOp(s string) (LogicalOp, error) {
+	const op = "mql.ParseLogicalOp"
+	l, err := newLogicalOp(strings.ToLower(s))
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	return l, nil
+}
+
+func newLogicalOp(s string) (LogicalOp, error) {
 	const op = "newLogicalOp"
-	switch logicalOp(s) {
-	case andOp, orOp:
-		return logicalOp(s), nil
-	default:
+	if !LogicalOp(s).Valid() {
 		return "", fmt.Errorf("%s: %w %q", op, ErrInvalidLogicalOp, s)
 	}
+	return LogicalOp(s), nil
+}
+
+// Valid reports if the logical operator is supported
+func (l LogicalOp) Valid() bool {
+	for _, o := range supportedLogicalOps {
+		if l == o {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns the logical operator's keyword
+func (l LogicalOp) String() string {
+	return string(l)
+}
+
+// MarshalText implements encoding.TextMarshaler, so the logical operator is
+// encoded as its keyword (ie: in JSON).  It returns an error if the logical
+// operator isn't supported.
+func (l LogicalOp) MarshalText() ([]byte, error) {
+	const op = "mql.(LogicalOp).MarshalText"
+	if !l.Valid() {
+		return nil, fmt.Errorf("%s: %w %q", op, ErrInvalidLogicalOp, string(l))
+	}
+	return []byte(l), nil
+}
+
+// SQL returns the SQL operator which combines two conditions, which is the
+// same for every dialect.  An ErrInvalidLogicalOp is returned when the
+// logical operator isn't supported.
+func (l LogicalOp) SQL() (string, error) {
+	const op = "mql.(LogicalOp).SQL"
+	if !l.Valid() {
+		return "", fmt.Errorf("%s: %w %q", op, ErrInvalidLogicalOp, string(l))
+	}
+	return string(l), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler and returns an error if the
+// text isn't a supported logical operator.  Case is ignored.
+func (l *LogicalOp) UnmarshalText(text []byte) error {
+	const op = "mql.(LogicalOp).UnmarshalText"
+	parsed, err := newLogicalOp(strings.ToLower(string(text)))
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	*l = parsed
+	return nil
 }
 
-type logicalExpr struct {
-	leftExpr  expr
-	logicalOp logicalOp
-	rightExpr expr
+// LogicalExpr is an expr which combines two exprs with a logical operator, like:
+// name="alice" and age > 21
+type LogicalExpr struct {
+	// LeftExpr is the left side of the logical expr
+	LeftExpr Expr
+	// LogicalOp is the logical operator
+	LogicalOp LogicalOp
+	// RightExpr is the right side of the logical expr
+	RightExpr Expr
 }
 
 // Type returns the expr type
-func (l *logicalExpr) Type() exprType {
+func (l *LogicalExpr) Type() exprType {
 	return logicalExprType
 }
 
 // String returns a string rep of the expr
-func (l *logicalExpr) String() string {
-	return fmt.Sprintf("(logicalExpr: %s %s %s)", l.leftExpr, l.logicalOp, l.rightExpr)
+func (l *LogicalExpr) String() string {
+	return fmt.Sprintf("(logicalExpr: %s %s %s)", l.LeftExpr, l.LogicalOp, l.RightExpr)
+}
+
+// MQL returns the logical expr as canonical mql text, with a single space
+// around its operator and only the parens required to keep the tree's
+// grouping.  Logical operators have the same precedence and are grouped from
+// the left, so only a right side which is a logical expr is grouped by parens:
+// a and (b or c), but a or b and c
+func (l *LogicalExpr) MQL() string {
+	right := l.RightExpr.MQL()
+	if _, ok := l.RightExpr.(*LogicalExpr); ok {
+		right = "(" + right + ")"
+	}
+	return fmt.Sprintf("%s %s %s", l.LeftExpr.MQL(), l.LogicalOp, right)
+}
+
+// walkExpr will call fn for every expr in the tree, in the order they appear
+// in the query (left to right).
+func walkExpr(e Expr, fn func(Expr)) {
+	if isNil(e) {
+		return
+	}
+	switch v := e.(type) {
+	case *LogicalExpr:
+		walkExpr(v.LeftExpr, fn)
+		fn(v)
+		walkExpr(v.RightExpr, fn)
+	default:
+		fn(v)
+	}
 }
 
 // root will return the root of the expr tree
-func root(lExpr *logicalExpr, raw string) (expr, error) {
+func root(lExpr *LogicalExpr, raw string) (Expr, error) {
 	const op = "mql.root"
 	switch {
 	// intentionally not checking raw, since can be an empty string
 	case lExpr == nil:
 		return nil, fmt.Errorf("%s: %w (missing expression)", op, ErrInvalidParameter)
 	}
-	logicalOp := lExpr.logicalOp
-	if logicalOp != "" && lExpr.rightExpr == nil {
+	logicalOp := lExpr.LogicalOp
+	if logicalOp != "" && lExpr.RightExpr == nil {
 		return nil, fmt.Errorf("%s: %w in: %q", op, ErrMissingRightSideExpr, raw)
 	}
 
-	for lExpr.logicalOp == "" {
+	for lExpr.LogicalOp == "" {
 		switch {
-		case lExpr.leftExpr == nil:
+		case lExpr.LeftExpr == nil:
 			return nil, fmt.Errorf("%s: %w nil in: %q", op, ErrMissingExpr, raw)
-		case lExpr.leftExpr.Type() == comparisonExprType:
-			return lExpr.leftExpr, nil
+		case lExpr.LeftExpr.Type() == comparisonExprType:
+			return lExpr.LeftExpr, nil
 		default:
-			lExpr = lExpr.leftExpr.(*logicalExpr)
+			lExpr = lExpr.LeftExpr.(*LogicalExpr)
 		}
 	}
 	return lExpr, nil
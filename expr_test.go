@@ -4,6 +4,7 @@
 package mql
 
 import (
+	"encoding/json"
 	"reflect"
 	"testing"
 
@@ -22,10 +23,10 @@ func Test_root(t *testing.T) {
 		assert.ErrorContains(t, err, "invalid parameter (missing expression)")
 	})
 	t.Run("missing-left-expr", func(t *testing.T) {
-		e, err := root(&logicalExpr{
-			leftExpr:  nil,
-			logicalOp: "",
-			rightExpr: &comparisonExpr{},
+		e, err := root(&LogicalExpr{
+			LeftExpr:  nil,
+			LogicalOp: "",
+			RightExpr: &ComparisonExpr{},
 		}, "raw")
 		require.Error(t, err)
 		assert.Empty(t, e)
@@ -60,10 +61,10 @@ func Test_newComparisonOp(t *testing.T) {
 
 func Test_comparisonExprString(t *testing.T) {
 	t.Run("nil-value", func(t *testing.T) {
-		e := &comparisonExpr{
-			column:       "name",
-			comparisonOp: "=",
-			value:        nil,
+		e := &ComparisonExpr{
+			Column:       "name",
+			ComparisonOp: "=",
+			Value:        nil,
 		}
 		assert.Equal(t, "(comparisonExpr: name = nil)", e.String())
 	})
@@ -71,17 +72,17 @@ func Test_comparisonExprString(t *testing.T) {
 
 func Test_logicalExprString(t *testing.T) {
 	t.Run("String", func(t *testing.T) {
-		e := &logicalExpr{
-			leftExpr: &comparisonExpr{
-				column:       "name",
-				comparisonOp: "=",
-				value:        pointer("alice"),
+		e := &LogicalExpr{
+			LeftExpr: &ComparisonExpr{
+				Column:       "name",
+				ComparisonOp: "=",
+				Value:        pointer("alice"),
 			},
-			logicalOp: andOp,
-			rightExpr: &comparisonExpr{
-				column:       "name",
-				comparisonOp: "=",
-				value:        pointer("alice"),
+			LogicalOp: AndOp,
+			RightExpr: &ComparisonExpr{
+				Column:       "name",
+				ComparisonOp: "=",
+				Value:        pointer("alice"),
 			},
 		}
 		assert.Equal(t, "(logicalExpr: (comparisonExpr: name = alice) and (comparisonExpr: name = alice))", e.String())
@@ -94,38 +95,225 @@ func Test_defaultValidateConvert(t *testing.T) {
 	fValidators, err := fieldValidators(reflect.ValueOf(testModel{}))
 	require.NoError(t, err)
 	t.Run("missing-column", func(t *testing.T) {
-		e, err := defaultValidateConvert("", EqualOp, pointer("alice"), fValidators["name"])
+		e, err := defaultValidateConvert("", EqualOp, pointer("alice"), fValidators["name"], getDefaultOptions())
 		require.Error(t, err)
 		assert.Empty(t, e)
 		assert.ErrorIs(t, err, ErrMissingColumn)
 		assert.ErrorContains(t, err, "missing column")
 	})
 	t.Run("missing-comparison-op", func(t *testing.T) {
-		e, err := defaultValidateConvert("name", "", pointer("alice"), fValidators["name"])
+		e, err := defaultValidateConvert("name", "", pointer("alice"), fValidators["name"], getDefaultOptions())
 		require.Error(t, err)
 		assert.Empty(t, e)
 		assert.ErrorIs(t, err, ErrMissingComparisonOp)
 		assert.ErrorContains(t, err, "missing comparison operator")
 	})
 	t.Run("missing-value", func(t *testing.T) {
-		e, err := defaultValidateConvert("name", EqualOp, nil, fValidators["name"])
+		e, err := defaultValidateConvert("name", EqualOp, nil, fValidators["name"], getDefaultOptions())
 		require.Error(t, err)
 		assert.Empty(t, e)
 		assert.ErrorIs(t, err, ErrMissingComparisonValue)
 		assert.ErrorContains(t, err, "missing comparison value")
 	})
 	t.Run("missing-validator-func", func(t *testing.T) {
-		e, err := defaultValidateConvert("name", EqualOp, pointer("alice"), validator{typ: "string"})
+		e, err := defaultValidateConvert("name", EqualOp, pointer("alice"), validator{typ: "string"}, getDefaultOptions())
 		require.Error(t, err)
 		assert.Empty(t, e)
 		assert.ErrorIs(t, err, ErrInvalidParameter)
 		assert.ErrorContains(t, err, "missing validator function")
 	})
 	t.Run("missing-validator-typ", func(t *testing.T) {
-		e, err := defaultValidateConvert("name", EqualOp, pointer("alice"), validator{fn: fValidators["name"].fn})
+		e, err := defaultValidateConvert("name", EqualOp, pointer("alice"), validator{fn: fValidators["name"].fn}, getDefaultOptions())
 		require.Error(t, err)
 		assert.Empty(t, e)
 		assert.ErrorIs(t, err, ErrInvalidParameter)
 		assert.ErrorContains(t, err, "missing validator type")
 	})
 }
+
+func TestExpr_MQL(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{query: `name = "alice"`, want: `name="alice"`},
+		{query: `age >   21`, want: `age>21`},
+		{query: `length<=.5`, want: `length<=.5`},
+		{query: `name % 'al"ice'`, want: `name%"al\"ice"`},
+		{query: `enabled=TRUE`, want: `enabled="true"`},
+		{query: `labels.env != "prod"`, want: `labels.env!="prod"`},
+		{query: `"labels.env" != "prod"`, want: `labels.env!="prod"`},
+		{query: "`user name`=\"alice\"", want: `"user name"="alice"`},
+		{query: `"and"="alice"`, want: `"and"="alice"`},
+		{query: `name="alice" AND age>21`, want: `name="alice" and age>21`},
+		{query: `(name="alice" and age>21) or age<10`, want: `name="alice" and age>21 or age<10`},
+		{query: `name="alice" and (age>21 or age<10)`, want: `name="alice" and (age>21 or age<10)`},
+		{query: `((name="alice"))`, want: `name="alice"`},
+		{query: `((a=1 or b=2) and c=3) or d=4`, want: `a=1 or b=2 and c=3 or d=4`},
+		{query: `a=1 or (b=2 and (c=3 or d=4))`, want: `a=1 or (b=2 and (c=3 or d=4))`},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.query, func(t *testing.T) {
+			t.Parallel()
+			assert, require := assert.New(t), require.New(t)
+			e, err := newParser(tc.query).parse()
+			require.NoError(err)
+			assert.Equal(tc.want, e.MQL())
+
+			// the canonical text is parsed into the same tree
+			roundTrip, err := newParser(e.MQL()).parse()
+			require.NoError(err)
+			assert.Equal(e.String(), roundTrip.String())
+		})
+	}
+}
+
+func Test_walkExpr(t *testing.T) {
+	t.Parallel()
+	p := newParser(`name="alice" and (age > 21 or length < 1.5)`)
+	e, err := p.parse()
+	require.NoError(t, err)
+	var got []string
+	walkExpr(e, func(e Expr) {
+		switch v := e.(type) {
+		case *ComparisonExpr:
+			got = append(got, v.Column)
+		case *LogicalExpr:
+			got = append(got, string(v.LogicalOp))
+		}
+	})
+	assert.Equal(t, []string{"name", "and", "age", "or", "length"}, got)
+	walkExpr(nil, func(Expr) { t.Fatal("unexpected call for a nil expr") })
+}
+
+func TestParseComparisonOp(t *testing.T) {
+	t.Parallel()
+	for _, want := range ComparisonOps() {
+		got, err := ParseComparisonOp(want.String())
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+	for _, s := range []string{"", "==", "eq", " =", "<>"} {
+		got, err := ParseComparisonOp(s)
+		require.Error(t, err)
+		assert.Empty(t, got)
+		assert.ErrorIs(t, err, ErrInvalidComparisonOp)
+	}
+}
+
+func TestParseLogicalOp(t *testing.T) {
+	t.Parallel()
+	for _, s := range []string{"and", "AND", "And"} {
+		got, err := ParseLogicalOp(s)
+		require.NoError(t, err)
+		assert.Equal(t, AndOp, got)
+	}
+	got, err := ParseLogicalOp("or")
+	require.NoError(t, err)
+	assert.Equal(t, OrOp, got)
+	for _, s := range []string{"", "not", "&&"} {
+		got, err := ParseLogicalOp(s)
+		require.Error(t, err)
+		assert.Empty(t, got)
+		assert.ErrorIs(t, err, ErrInvalidLogicalOp)
+	}
+}
+
+func TestOps_json(t *testing.T) {
+	t.Parallel()
+	t.Run("round-trip", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		type ops struct {
+			Comparison ComparisonOp
+			Logical    LogicalOp
+			Counts     map[ComparisonOp]int
+		}
+		want := ops{Comparison: GreaterThanOrEqualOp, Logical: OrOp, Counts: map[ComparisonOp]int{ContainsOp: 1}}
+		b, err := json.Marshal(want)
+		require.NoError(err)
+		assert.JSONEq(`{"Comparison":">=","Logical":"or","Counts":{"%":1}}`, string(b))
+		var got ops
+		require.NoError(json.Unmarshal(b, &got))
+		assert.Equal(want, got)
+		require.NoError(json.Unmarshal([]byte(`{"Logical":"AND"}`), &got))
+		assert.Equal(AndOp, got.Logical)
+	})
+	t.Run("err-marshal-invalid", func(t *testing.T) {
+		_, err := json.Marshal(ComparisonOp("=="))
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrInvalidComparisonOp)
+		_, err = json.Marshal(LogicalOp("not"))
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrInvalidLogicalOp)
+	})
+	t.Run("err-unmarshal-invalid", func(t *testing.T) {
+		var c ComparisonOp
+		err := json.Unmarshal([]byte(`"=="`), &c)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrInvalidComparisonOp)
+		var l LogicalOp
+		err = json.Unmarshal([]byte(`"not"`), &l)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrInvalidLogicalOp)
+	})
+	t.Run("ops-are-copies", func(t *testing.T) {
+		ops := ComparisonOps()
+		ops[0] = "=="
+		assert.Equal(t, EqualOp, ComparisonOps()[0])
+	})
+}
+
+func TestOps_SQL(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name      string
+		op        ComparisonOp
+		dialect   Dialect
+		want      string
+		wantErrIs error
+	}{
+		{name: "equal", op: EqualOp, want: "="},
+		{name: "not-equal", op: NotEqualOp, dialect: MySqlDialect{}, want: "!="},
+		{name: "greater-than-or-equal", op: GreaterThanOrEqualOp, dialect: SqliteDialect{}, want: ">="},
+		{name: "contains", op: ContainsOp, dialect: MySqlDialect{}, want: "like"},
+		{name: "contained-by", op: ContainedByOp, dialect: PostgresDialect{}, want: "<<"},
+		{name: "array-contains", op: ArrayContainsOp, dialect: CockroachDialect{}, want: "@>"},
+		{name: "similar-to", op: SimilarToOp, want: "%"},
+		{name: "err-similar-to-mysql", op: SimilarToOp, dialect: MySqlDialect{}, wantErrIs: ErrInvalidComparisonOp},
+		{name: "err-array-contains-sqlite", op: ArrayContainsOp, dialect: SqliteDialect{}, wantErrIs: ErrInvalidComparisonOp},
+		{name: "err-invalid", op: "==", wantErrIs: ErrInvalidComparisonOp},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert, require := assert.New(t), require.New(t)
+			got, err := tc.op.SQL(tc.dialect)
+			if tc.wantErrIs != nil {
+				require.Error(err)
+				assert.ErrorIs(err, tc.wantErrIs)
+				assert.Empty(got)
+				return
+			}
+			require.NoError(err)
+			assert.Equal(tc.want, got)
+		})
+	}
+	t.Run("every-op", func(t *testing.T) {
+		for _, o := range ComparisonOps() {
+			_, err := o.SQL(PostgresDialect{})
+			assert.NoError(t, err, o)
+		}
+	})
+	t.Run("logical", func(t *testing.T) {
+		for _, o := range LogicalOps() {
+			got, err := o.SQL()
+			require.NoError(t, err)
+			assert.Equal(t, string(o), got)
+		}
+		_, err := LogicalOp("not").SQL()
+		assert.ErrorIs(t, err, ErrInvalidLogicalOp)
+	})
+}
@@ -0,0 +1,161 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	jsonComparisonExprType = "comparison"
+	jsonLogicalExprType    = "logical"
+)
+
+// jsonComparisonExpr is the JSON encoding of a ComparisonExpr
+type jsonComparisonExpr struct {
+	Type   string       `json:"type"`
+	Column string       `json:"column"`
+	Op     ComparisonOp `json:"op"`
+	Value  *string      `json:"value"`
+	// Variable reports if the value is the name of a variable (see
+	// WithVariables)
+	Variable bool `json:"variable,omitempty"`
+}
+
+// jsonLogicalExpr is the JSON encoding of a LogicalExpr
+type jsonLogicalExpr struct {
+	Type  string    `json:"type"`
+	Op    LogicalOp `json:"op"`
+	Left  Expr      `json:"left"`
+	Right Expr      `json:"right"`
+}
+
+// jsonExpr is used to decode either a comparison or a logical expr, so its
+// type can be checked before decoding the rest of it.
+type jsonExpr struct {
+	Type     string          `json:"type"`
+	Column   string          `json:"column"`
+	Op       string          `json:"op"`
+	Value    *string         `json:"value"`
+	Variable bool            `json:"variable"`
+	Left     json.RawMessage `json:"left"`
+	Right    json.RawMessage `json:"right"`
+}
+
+// MarshalJSON implements json.Marshaler and encodes the comparison as:
+//
+//	{"type":"comparison","column":"name","op":"=","value":"alice"}
+//
+// The value is always a string, just like it is in the expr.  The value of a
+// variable (see WithVariables) is its name and "variable" is true.  See
+// UnmarshalExpr
+func (e *ComparisonExpr) MarshalJSON() ([]byte, error) {
+	const op = "mql.(ComparisonExpr).MarshalJSON"
+	b, err := json.Marshal(jsonComparisonExpr{
+		Type:     jsonComparisonExprType,
+		Column:   e.Column,
+		Op:       e.ComparisonOp,
+		Value:    e.Value,
+		Variable: e.variable,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return b, nil
+}
+
+// MarshalJSON implements json.Marshaler and encodes the logical expr as:
+//
+//	{"type":"logical","op":"and","left":{...},"right":{...}}
+//
+// See UnmarshalExpr
+func (l *LogicalExpr) MarshalJSON() ([]byte, error) {
+	const op = "mql.(LogicalExpr).MarshalJSON"
+	b, err := json.Marshal(jsonLogicalExpr{
+		Type:  jsonLogicalExprType,
+		Op:    l.LogicalOp,
+		Left:  l.LeftExpr,
+		Right: l.RightExpr,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return b, nil
+}
+
+// UnmarshalExpr will decode an expr tree encoded as JSON by its MarshalJSON
+// (see ComparisonExpr.MarshalJSON and LogicalExpr.MarshalJSON), so saved
+// filters can be stored structurally and converted later using ConvertExpr or
+// the mql syntax.  The tree is complete when it's returned: every comparison
+// has a column, a supported operator and a value, and every logical expr has a
+// supported operator and both sides.  Unknown fields are an error.
+func UnmarshalExpr(data []byte) (Expr, error) {
+	const op = "mql.UnmarshalExpr"
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, fmt.Errorf("%s: missing data: %w", op, ErrInvalidParameter)
+	}
+	e, err := unmarshalExpr(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return e, nil
+}
+
+func unmarshalExpr(data []byte) (Expr, error) {
+	const op = "mql.unmarshalExpr"
+	var raw jsonExpr
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&raw); err != nil {
+		return nil, fmt.Errorf("%s: %w: %w", op, ErrInvalidParameter, err)
+	}
+	switch raw.Type {
+	case jsonComparisonExprType:
+		switch {
+		case raw.Column == "":
+			return nil, fmt.Errorf("%s: %w", op, ErrMissingColumn)
+		case raw.Op == "":
+			return nil, fmt.Errorf("%s: %w", op, ErrMissingComparisonOp)
+		case raw.Value == nil:
+			return nil, fmt.Errorf("%s: %w for %q", op, ErrMissingComparisonValue, raw.Column)
+		case raw.Left != nil || raw.Right != nil:
+			return nil, fmt.Errorf("%s: comparison can't have a left or right expr: %w", op, ErrInvalidParameter)
+		case raw.Variable && !isVariable(":"+*raw.Value):
+			return nil, fmt.Errorf("%s: variable %q isn't an identifier: %w", op, *raw.Value, ErrInvalidParameter)
+		}
+		cmpOp, err := newComparisonOp(raw.Op)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		return &ComparisonExpr{Column: raw.Column, ComparisonOp: cmpOp, Value: raw.Value, variable: raw.Variable}, nil
+	case jsonLogicalExprType:
+		switch {
+		case raw.Op == "":
+			return nil, fmt.Errorf("%s: %w", op, ErrMissingLogicalOp)
+		case raw.Left == nil:
+			return nil, fmt.Errorf("%s: %w", op, ErrMissingExpr)
+		case raw.Right == nil:
+			return nil, fmt.Errorf("%s: %w", op, ErrMissingRightSideExpr)
+		case raw.Column != "" || raw.Value != nil || raw.Variable:
+			return nil, fmt.Errorf("%s: logical expr can't have a column or value: %w", op, ErrInvalidParameter)
+		}
+		var logicalOp LogicalOp
+		if err := logicalOp.UnmarshalText([]byte(raw.Op)); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		left, err := unmarshalExpr(raw.Left)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid left expr: %w", op, err)
+		}
+		right, err := unmarshalExpr(raw.Right)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid right expr: %w", op, err)
+		}
+		return &LogicalExpr{LeftExpr: left, LogicalOp: logicalOp, RightExpr: right}, nil
+	default:
+		return nil, fmt.Errorf("%s: unknown expr type %q (expected %q or %q): %w", op, raw.Type, jsonComparisonExprType, jsonLogicalExprType, ErrInvalidParameter)
+	}
+}
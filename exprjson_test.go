@@ -0,0 +1,153 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/hashicorp/mql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpr_MarshalJSON(t *testing.T) {
+	t.Parallel()
+	e, err := mql.ParseExpr(`name="alice" and (age > 21 or email % "@example.com")`)
+	require.NoError(t, err)
+	data, err := json.Marshal(e)
+	require.NoError(t, err)
+	t.Run("format", func(t *testing.T) {
+		// json.Marshal escapes <, > and & in strings
+		want := `{"type":"logical","op":"and",` +
+			`"left":{"type":"comparison","column":"name","op":"=","value":"alice"},` +
+			`"right":{"type":"logical","op":"or",` +
+			`"left":{"type":"comparison","column":"age","op":"\u003e","value":"21"},` +
+			`"right":{"type":"comparison","column":"email","op":"%","value":"@example.com"}}}`
+		assert.Equal(t, want, string(data))
+	})
+	t.Run("round-trip", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		got, err := mql.UnmarshalExpr(data)
+		require.NoError(err)
+		assert.Equal(e.String(), got.String())
+		w, err := mql.ConvertExpr[*mql.WhereClause](got, testModel{}, whereClauseConverter{})
+		require.NoError(err)
+		assert.Equal("(name=? and (age>? or email like ? escape '\\'))", w.Condition)
+	})
+	t.Run("err-invalid-op", func(t *testing.T) {
+		_, err := json.Marshal(&mql.ComparisonExpr{Column: "name", ComparisonOp: "==", Value: pointer("alice")})
+		require.Error(t, err)
+		assert.ErrorIs(t, err, mql.ErrInvalidComparisonOp)
+	})
+}
+
+func TestUnmarshalExpr(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name            string
+		data            string
+		want            mql.Expr
+		wantErrIs       error
+		wantErrContains string
+	}{
+		{
+			name: "comparison",
+			data: `{"type":"comparison","column":"name","op":"=","value":"alice"}`,
+			want: &mql.ComparisonExpr{Column: "name", ComparisonOp: mql.EqualOp, Value: pointer("alice")},
+		},
+		{
+			name: "logical-op-ignores-case",
+			data: `{"type":"logical","op":"OR","left":{"type":"comparison","column":"name","op":"=","value":"alice"},"right":{"type":"comparison","column":"age","op":">=","value":"21"}}`,
+			want: &mql.LogicalExpr{
+				LeftExpr:  &mql.ComparisonExpr{Column: "name", ComparisonOp: mql.EqualOp, Value: pointer("alice")},
+				LogicalOp: mql.OrOp,
+				RightExpr: &mql.ComparisonExpr{Column: "age", ComparisonOp: mql.GreaterThanOrEqualOp, Value: pointer("21")},
+			},
+		},
+		{
+			name:            "err-missing-data",
+			data:            " ",
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: "missing data",
+		},
+		{
+			name:            "err-invalid-json",
+			data:            `{"type":`,
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: "unexpected EOF",
+		},
+		{
+			name:            "err-unknown-field",
+			data:            `{"type":"comparison","column":"name","op":"=","value":"alice","not":true}`,
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: `unknown field "not"`,
+		},
+		{
+			name:            "err-unknown-type",
+			data:            `{"type":"not","column":"name","op":"=","value":"alice"}`,
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: `unknown expr type "not" (expected "comparison" or "logical")`,
+		},
+		{
+			name:            "err-missing-column",
+			data:            `{"type":"comparison","op":"=","value":"alice"}`,
+			wantErrIs:       mql.ErrMissingColumn,
+			wantErrContains: "missing column",
+		},
+		{
+			name:            "err-missing-value",
+			data:            `{"type":"comparison","column":"name","op":"="}`,
+			wantErrIs:       mql.ErrMissingComparisonValue,
+			wantErrContains: `missing comparison value for "name"`,
+		},
+		{
+			name:            "err-invalid-comparison-op",
+			data:            `{"type":"comparison","column":"name","op":"==","value":"alice"}`,
+			wantErrIs:       mql.ErrInvalidComparisonOp,
+			wantErrContains: `invalid comparison operator "=="`,
+		},
+		{
+			name:            "err-invalid-logical-op",
+			data:            `{"type":"logical","op":"xor","left":{"type":"comparison","column":"name","op":"=","value":"alice"},"right":{"type":"comparison","column":"name","op":"=","value":"bob"}}`,
+			wantErrIs:       mql.ErrInvalidLogicalOp,
+			wantErrContains: `invalid logical operator "xor"`,
+		},
+		{
+			name:            "err-missing-right",
+			data:            `{"type":"logical","op":"and","left":{"type":"comparison","column":"name","op":"=","value":"alice"}}`,
+			wantErrIs:       mql.ErrMissingRightSideExpr,
+			wantErrContains: "logical operator without a right side expr",
+		},
+		{
+			name:            "err-invalid-left",
+			data:            `{"type":"logical","op":"and","left":{"type":"comparison","column":"name","op":"="},"right":{"type":"comparison","column":"name","op":"=","value":"bob"}}`,
+			wantErrIs:       mql.ErrMissingComparisonValue,
+			wantErrContains: "invalid left expr",
+		},
+		{
+			name:            "err-comparison-with-left",
+			data:            `{"type":"comparison","column":"name","op":"=","value":"alice","left":{}}`,
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: "comparison can't have a left or right expr",
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert, require := assert.New(t), require.New(t)
+			got, err := mql.UnmarshalExpr([]byte(tc.data))
+			if tc.wantErrContains != "" {
+				require.Error(err)
+				assert.Nil(got)
+				assert.ErrorIs(err, tc.wantErrIs)
+				assert.ErrorContains(err, tc.wantErrContains)
+				return
+			}
+			require.NoError(err)
+			assert.Equal(tc.want, got)
+		})
+	}
+}
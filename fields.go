@@ -0,0 +1,125 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Field is a field of a select list
+type Field struct {
+	// Column is the database column
+	Column string
+	// Key is the key of a map field (ie: city for address.city) and it's
+	// empty for other fields
+	Key string
+}
+
+// FieldsClause contains the select list of a SQL select statement
+type FieldsClause struct {
+	// Clause is the select list without the "select" keyword, like: name,
+	// email.  The keys of map fields are looked up using the dialect and
+	// named after their field and key (ie: address->>'city' as address_city).
+	Clause string
+	// Fields are the fields of the clause, in order
+	Fields []Field
+}
+
+// ParseFields will parse the comma separated list of fields and use the
+// provided database model to validate them and create a select list, so the
+// columns returned by a query can be selected by users (ie: the fields query
+// parameter: fields=name,email,address.city).  Each field is a column or the
+// key of a map field (ie: address.city).  Columns and keys can only contain
+// letters, digits and underscores, columns must be a field of the model and
+// fields can only be selected once.  Supported options: WithColumnMap,
+// WithIgnoreFields, WithModelDescriber, WithAllowEmptyQuery (empty fields
+// return an empty clause), WithTableAlias and WithTableName (only the
+// clause's columns are qualified, not its Fields), WithDialect
+func ParseFields(fields string, model any, opt ...Option) (*FieldsClause, error) {
+	const op = "mql.ParseFields"
+	opts, err := getOpts(opt...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	switch {
+	case strings.TrimSpace(fields) == "" && !opts.withAllowEmptyQuery:
+		return nil, fmt.Errorf("%s: missing fields: %w", op, ErrInvalidParameter)
+	case isNil(model):
+		return nil, fmt.Errorf("%s: missing model: %w", op, ErrInvalidParameter)
+	case strings.TrimSpace(fields) == "":
+		return &FieldsClause{}, nil
+	}
+	if opt, err = withModelTable(model, opt); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if opts, err = getOpts(opt...); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	fValidators, err := modelValidators(model, opt...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	d := dialectOf(opts)
+	items := strings.Split(fields, ",")
+	f := &FieldsClause{Fields: make([]Field, 0, len(items))}
+	used := make(map[Field]bool, len(items))
+	clauses := make([]string, 0, len(items))
+	for _, item := range items {
+		field, err := parseField(item)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		columnName := strings.ToLower(field.Column)
+		if n, ok := opts.withColumnMap[columnName]; ok {
+			columnName = n
+		}
+		fName := strings.ToLower(strings.ReplaceAll(columnName, "_", ""))
+		v, ok := fValidators[fName]
+		switch {
+		case !ok:
+			return nil, fmt.Errorf("%s: %w %q", op, ErrInvalidColumn, columnName)
+		case field.Key != "" && v.typ != "map":
+			return nil, fmt.Errorf("%s: %w %q: only map fields have keys", op, ErrInvalidColumn, columnName+"."+field.Key)
+		}
+		field.Column = columnName
+		if used[Field{Column: fName, Key: field.Key}] {
+			return nil, fmt.Errorf("%s: %w %q: field is selected more than once", op, ErrInvalidFields, strings.TrimSpace(item))
+		}
+		used[Field{Column: fName, Key: field.Key}] = true
+		f.Fields = append(f.Fields, field)
+		column := qualifyColumn(field.Column, opts)
+		if field.Key != "" {
+			// the key is an identifier, so it's safe to use as a literal
+			key, err := sqlLiteral(field.Key, d)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", op, err)
+			}
+			lookup := replacePlaceholders(d.JsonLookup(column), 1, func(int) string { return key })
+			column = fmt.Sprintf("%s as %s", lookup, d.QuoteIdentifier(field.Column+"_"+field.Key))
+		}
+		clauses = append(clauses, column)
+	}
+	f.Clause = strings.Join(clauses, ", ")
+	return f, nil
+}
+
+// parseField will parse a single field: column or column.key
+func parseField(s string) (Field, error) {
+	const op = "mql.parseField"
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Field{}, fmt.Errorf("%s: %w", op, ErrMissingColumn)
+	}
+	column, key, hasKey := strings.Cut(s, ".")
+	switch {
+	case strings.IndexFunc(s, isSpace) >= 0:
+		return Field{}, fmt.Errorf("%s: %w %q: expected a single column", op, ErrInvalidFields, s)
+	case column == "" || !isIdentifier(column):
+		return Field{}, fmt.Errorf("%s: %w %q: columns can only contain letters, digits and underscores", op, ErrInvalidColumn, s)
+	case hasKey && (key == "" || !isIdentifier(key)):
+		return Field{}, fmt.Errorf("%s: %w %q: keys can only contain letters, digits and underscores", op, ErrInvalidColumn, s)
+	}
+	return Field{Column: column, Key: key}, nil
+}
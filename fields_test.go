@@ -0,0 +1,154 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql_test
+
+import (
+	"testing"
+
+	"github.com/hashicorp/mql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFields(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name            string
+		fields          string
+		model           any
+		opts            []mql.Option
+		want            *mql.FieldsClause
+		wantErrIs       error
+		wantErrContains string
+	}{
+		{
+			name:   "columns",
+			fields: " Name, email ,member_number",
+			model:  testModel{},
+			want: &mql.FieldsClause{
+				Clause: "name, email, member_number",
+				Fields: []mql.Field{{Column: "name"}, {Column: "email"}, {Column: "member_number"}},
+			},
+		},
+		{
+			name:   "map-key",
+			fields: "name,labels.city",
+			model:  testModel{},
+			want: &mql.FieldsClause{
+				Clause: "name, labels->>'city' as labels_city",
+				Fields: []mql.Field{{Column: "name"}, {Column: "labels", Key: "city"}},
+			},
+		},
+		{
+			name:   "map-key-dialect",
+			fields: "labels.city",
+			model:  testModel{},
+			opts:   []mql.Option{mql.WithDialect(mql.MySqlDialect{})},
+			want: &mql.FieldsClause{
+				Clause: "json_unquote(json_extract(`labels`, concat('$.\"', 'city', '\"'))) as `labels_city`",
+				Fields: []mql.Field{{Column: "labels", Key: "city"}},
+			},
+		},
+		{
+			name:   "whole-map",
+			fields: "labels",
+			model:  testModel{},
+			want:   &mql.FieldsClause{Clause: "labels", Fields: []mql.Field{{Column: "labels"}}},
+		},
+		{
+			name:   "column-map-and-table",
+			fields: "nickname,age",
+			model:  testModel{},
+			opts:   []mql.Option{mql.WithColumnMap(map[string]string{"nickname": "name"}), mql.WithTableAlias("u")},
+			want: &mql.FieldsClause{
+				Clause: "u.name, u.age",
+				Fields: []mql.Field{{Column: "name"}, {Column: "age"}},
+			},
+		},
+		{
+			name:  "empty-allowed",
+			model: testModel{},
+			opts:  []mql.Option{mql.WithAllowEmptyQuery()},
+			want:  &mql.FieldsClause{},
+		},
+		{
+			name:      "err-empty",
+			model:     testModel{},
+			wantErrIs: mql.ErrInvalidParameter,
+		},
+		{
+			name:      "err-missing-model",
+			fields:    "name",
+			wantErrIs: mql.ErrInvalidParameter,
+		},
+		{
+			name:            "err-invalid-column",
+			fields:          "name,password",
+			model:           testModel{},
+			wantErrIs:       mql.ErrInvalidColumn,
+			wantErrContains: `"password"`,
+		},
+		{
+			name:            "err-ignored-field",
+			fields:          "name,email",
+			model:           testModel{},
+			opts:            []mql.Option{mql.WithIgnoredFields("Email")},
+			wantErrIs:       mql.ErrInvalidColumn,
+			wantErrContains: `"email"`,
+		},
+		{
+			name:            "err-key-of-non-map",
+			fields:          "name.first",
+			model:           testModel{},
+			wantErrIs:       mql.ErrInvalidColumn,
+			wantErrContains: `"name.first": only map fields have keys`,
+		},
+		{
+			name:            "err-injection",
+			fields:          "name,labels.city') as x, password --",
+			model:           testModel{},
+			wantErrIs:       mql.ErrInvalidFields,
+			wantErrContains: "expected a single column",
+		},
+		{
+			name:            "err-invalid-key",
+			fields:          "labels.c'ty",
+			model:           testModel{},
+			wantErrIs:       mql.ErrInvalidColumn,
+			wantErrContains: "keys can only contain letters, digits and underscores",
+		},
+		{
+			name:      "err-missing-column",
+			fields:    "name,,email",
+			model:     testModel{},
+			wantErrIs: mql.ErrMissingColumn,
+		},
+		{
+			name:            "err-duplicate",
+			fields:          "name,Name",
+			model:           testModel{},
+			wantErrIs:       mql.ErrInvalidFields,
+			wantErrContains: `"Name": field is selected more than once`,
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert, require := assert.New(t), require.New(t)
+			got, err := mql.ParseFields(tc.fields, tc.model, tc.opts...)
+			if tc.wantErrIs != nil {
+				require.Error(err)
+				assert.ErrorIs(err, tc.wantErrIs)
+				if tc.wantErrContains != "" {
+					assert.ErrorContains(err, tc.wantErrContains)
+				}
+				assert.Nil(got)
+				return
+			}
+			require.NoError(err)
+			assert.Equal(tc.want, got)
+		})
+	}
+}
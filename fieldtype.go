@@ -0,0 +1,133 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"golang.org/x/exp/slices"
+)
+
+// TypeHandler validates and converts the query values of fields with a
+// user-defined type (think: decimal.Decimal, netip.Addr, civil.Date or a custom
+// ID type).  See RegisterFieldType.
+type TypeHandler struct {
+	// Validate will validate a query's value and convert it to the arg used
+	// in the where clause (ie: a decimal.Decimal).  It's required.
+	Validate func(value string) (any, error)
+
+	// ComparisonOps are the comparison operators allowed for the type.  Every
+	// operator is allowed when it's empty.
+	ComparisonOps []ComparisonOp
+
+	// Compare is used by Match and Filter to compare a field's value with a
+	// query's value (returned by Validate) and it returns -1, 0 or +1 when
+	// the field's value is less than, equal to or greater than the query's
+	// value.  When it's nil, their string representations (fmt.Sprint) are
+	// compared.
+	Compare func(fieldValue, queryValue any) (int, error)
+}
+
+// fieldType is a registered TypeHandler along with its type
+type fieldType struct {
+	typ     reflect.Type
+	handler TypeHandler
+}
+
+var (
+	fieldTypesMu sync.RWMutex
+	// fieldTypes are the registered field types by their type's string
+	// representation, which is how a FieldDescriptor describes a field's
+	// type.
+	fieldTypes = map[string]fieldType{}
+)
+
+// RegisterFieldType registers a TypeHandler for a user-defined type, which is
+// used to validate and convert the query values of fields with the type (or a
+// pointer to it, or maps with elements of the type) instead of treating them
+// as strings.  The type must be a named type which isn't a pointer and it can
+// only be registered once.  Types are identified using the same format as
+// reflect.Type.String() (ie: decimal.Decimal), which is how a FieldDescriptor
+// describes a field's type.  It's typically called from an init() func.
+func RegisterFieldType(t reflect.Type, h TypeHandler) error {
+	const op = "mql.RegisterFieldType"
+	switch {
+	case t == nil:
+		return fmt.Errorf("%s: missing type: %w", op, ErrInvalidParameter)
+	case t.Kind() == reflect.Pointer:
+		return fmt.Errorf("%s: type %s can't be a pointer: %w", op, t, ErrInvalidParameter)
+	case t.PkgPath() == "":
+		return fmt.Errorf("%s: type %s must be a named type: %w", op, t, ErrInvalidParameter)
+	case h.Validate == nil:
+		return fmt.Errorf("%s: missing validate func for type %s: %w", op, t, ErrInvalidParameter)
+	}
+	for _, o := range h.ComparisonOps {
+		if !o.Valid() {
+			return fmt.Errorf("%s: %w %q for type %s", op, ErrInvalidComparisonOp, o, t)
+		}
+	}
+	fieldTypesMu.Lock()
+	defer fieldTypesMu.Unlock()
+	if _, exists := fieldTypes[t.String()]; exists {
+		return fmt.Errorf("%s: type %s is already registered: %w", op, t, ErrInvalidParameter)
+	}
+	h.ComparisonOps = slices.Clone(h.ComparisonOps)
+	fieldTypes[t.String()] = fieldType{typ: t, handler: h}
+	return nil
+}
+
+// lookupFieldType returns the registered field type for the string rep of a
+// Go type
+func lookupFieldType(typ string) (fieldType, bool) {
+	fieldTypesMu.RLock()
+	defer fieldTypesMu.RUnlock()
+	ft, ok := fieldTypes[typ]
+	return ft, ok
+}
+
+// validateFieldType validates the value using the handler of the registered
+// field type
+func validateFieldType(typ string, s string) (any, error) {
+	const op = "mql.validateFieldType"
+	ft, ok := lookupFieldType(typ)
+	if !ok {
+		return nil, fmt.Errorf("%s: type %s isn't registered: %w", op, typ, ErrInternal)
+	}
+	v, err := ft.handler.Validate(s)
+	if err != nil {
+		return nil, fmt.Errorf("%s: value %q is not a valid %s: %w: %w", op, s, typ, ErrInvalidParameter, err)
+	}
+	return v, nil
+}
+
+// allows reports if the comparison operator is allowed for the field type
+func (ft fieldType) allows(o ComparisonOp) bool {
+	return len(ft.handler.ComparisonOps) == 0 || slices.Contains(ft.handler.ComparisonOps, o)
+}
+
+// compare compares a field's value with a query's value using the handler's
+// Compare func or their string reps
+func (ft fieldType) compare(fieldValue, queryValue any) (int, error) {
+	const op = "mql.(fieldType).compare"
+	if ft.handler.Compare == nil {
+		return strings.Compare(fmt.Sprint(fieldValue), fmt.Sprint(queryValue)), nil
+	}
+	cmp, err := ft.handler.Compare(fieldValue, queryValue)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return cmp, nil
+}
+
+// joinOps returns the comparison operators separated by spaces
+func joinOps(ops []ComparisonOp) string {
+	s := make([]string, 0, len(ops))
+	for _, o := range ops {
+		s = append(s, string(o))
+	}
+	return strings.Join(s, " ")
+}
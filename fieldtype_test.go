@@ -0,0 +1,230 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql_test
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/mql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// money is a user-defined type stored as cents
+type money struct {
+	cents int64
+}
+
+// accountID is a user-defined ID type which must start with "acct_"
+type accountID string
+
+type ledgerModel struct {
+	ID      accountID
+	Balance money
+	Limit   *money
+	Limits  map[string]money
+}
+
+func init() {
+	if err := mql.RegisterFieldType(reflect.TypeOf(money{}), mql.TypeHandler{
+		Validate: func(value string) (any, error) {
+			f, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%q is not an amount", value)
+			}
+			return money{cents: int64(f * 100)}, nil
+		},
+		ComparisonOps: []mql.ComparisonOp{mql.EqualOp, mql.NotEqualOp, mql.GreaterThanOp, mql.GreaterThanOrEqualOp, mql.LessThanOp, mql.LessThanOrEqualOp},
+		Compare: func(fieldValue, queryValue any) (int, error) {
+			f, q := fieldValue.(money), queryValue.(money)
+			switch {
+			case f.cents < q.cents:
+				return -1, nil
+			case f.cents > q.cents:
+				return 1, nil
+			default:
+				return 0, nil
+			}
+		},
+	}); err != nil {
+		panic(err)
+	}
+	if err := mql.RegisterFieldType(reflect.TypeOf(accountID("")), mql.TypeHandler{
+		Validate: func(value string) (any, error) {
+			if !strings.HasPrefix(value, "acct_") {
+				return nil, fmt.Errorf("%q is not an account id", value)
+			}
+			return accountID(value), nil
+		},
+	}); err != nil {
+		panic(err)
+	}
+}
+
+func TestRegisterFieldType(t *testing.T) {
+	t.Parallel()
+	validate := func(value string) (any, error) { return value, nil }
+	tests := []struct {
+		name            string
+		typ             reflect.Type
+		handler         mql.TypeHandler
+		wantErrIs       error
+		wantErrContains string
+	}{
+		{
+			name:            "err-missing-type",
+			handler:         mql.TypeHandler{Validate: validate},
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: "missing type",
+		},
+		{
+			name:            "err-pointer",
+			typ:             reflect.TypeOf(&money{}),
+			handler:         mql.TypeHandler{Validate: validate},
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: "type *mql_test.money can't be a pointer",
+		},
+		{
+			name:            "err-unnamed-type",
+			typ:             reflect.TypeOf([]int{}),
+			handler:         mql.TypeHandler{Validate: validate},
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: "type []int must be a named type",
+		},
+		{
+			name:            "err-predeclared-type",
+			typ:             reflect.TypeOf(""),
+			handler:         mql.TypeHandler{Validate: validate},
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: "type string must be a named type",
+		},
+		{
+			name:            "err-missing-validate",
+			typ:             reflect.TypeOf(ledgerModel{}),
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: "missing validate func",
+		},
+		{
+			name:            "err-invalid-op",
+			typ:             reflect.TypeOf(mql.TypeHandler{}),
+			handler:         mql.TypeHandler{Validate: validate, ComparisonOps: []mql.ComparisonOp{"~"}},
+			wantErrIs:       mql.ErrInvalidComparisonOp,
+			wantErrContains: `invalid comparison operator "~"`,
+		},
+		{
+			name:            "err-already-registered",
+			typ:             reflect.TypeOf(money{}),
+			handler:         mql.TypeHandler{Validate: validate},
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: "type mql_test.money is already registered",
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			err := mql.RegisterFieldType(tc.typ, tc.handler)
+			require.Error(t, err)
+			assert.ErrorIs(t, err, tc.wantErrIs)
+			assert.ErrorContains(t, err, tc.wantErrContains)
+		})
+	}
+}
+
+func TestParse_registeredFieldType(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name            string
+		query           string
+		want            *mql.WhereClause
+		wantErrIs       error
+		wantErrContains string
+	}{
+		{
+			name:  "success",
+			query: `id="acct_1" and balance >= 12.5 and limit < "100" and limits.daily != 50`,
+			want: &mql.WhereClause{
+				Condition: "(((id=? and balance>=?) and limit<?) and limits->>?!=?)",
+				Args:      []any{accountID("acct_1"), money{cents: 1250}, money{cents: 10000}, "daily", money{cents: 5000}},
+			},
+		},
+		{
+			name:  "success-all-ops-allowed",
+			query: `id % "acct_"`,
+			want: &mql.WhereClause{
+				Condition: "id like ? escape '\\'",
+				Args:      []any{`%acct\_%`},
+			},
+		},
+		{
+			name:            "err-invalid-value",
+			query:           `id="1"`,
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: `"1" in (comparisonExpr: id = 1)`,
+		},
+		{
+			name:            "err-invalid-op",
+			query:           `balance % "1"`,
+			wantErrIs:       mql.ErrInvalidComparisonOp,
+			wantErrContains: `invalid comparison operator "%" for mql_test.money column "balance" (expected one of: = != > >= < <=)`,
+		},
+		{
+			name:            "err-invalid-map-op",
+			query:           `limits.daily % "1"`,
+			wantErrIs:       mql.ErrInvalidComparisonOp,
+			wantErrContains: `invalid comparison operator "%" for mql_test.money column limits.daily`,
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert, require := assert.New(t), require.New(t)
+			got, err := mql.Parse(tc.query, ledgerModel{})
+			if tc.wantErrContains != "" {
+				require.Error(err)
+				assert.Empty(got)
+				assert.ErrorIs(err, tc.wantErrIs)
+				assert.ErrorContains(err, tc.wantErrContains)
+				return
+			}
+			require.NoError(err)
+			assert.Equal(tc.want, got)
+		})
+	}
+}
+
+func TestMatch_registeredFieldType(t *testing.T) {
+	t.Parallel()
+	item := ledgerModel{
+		ID:      "acct_1",
+		Balance: money{cents: 1250},
+		Limits:  map[string]money{"daily": {cents: 5000}},
+	}
+	tests := []struct {
+		query string
+		opts  []mql.Option
+		want  bool
+	}{
+		{query: `balance > 12`, want: true},
+		{query: `balance = "12.50"`, want: true},
+		{query: `limits.daily <= 49.99`, want: false},
+		{query: `id = "acct_1"`, want: true},
+		{query: `id < "acct_0"`, want: false},
+		{query: `limit < 1`, want: false},
+		{query: `limit < 1`, opts: []mql.Option{mql.WithNullSemantics(mql.ZeroValueNulls)}, want: true},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.query, func(t *testing.T) {
+			got, err := mql.Match(tc.query, item, tc.opts...)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql
+
+import (
+	"fmt"
+	"strings"
+)
+
+// filterPrefix is the prefix of the name of a saved filter which is used as an
+// operand of a query (ie: $base_filter)
+const filterPrefix = "$"
+
+// FilterResolverFunc returns the query of a saved filter using its name
+// (without its $ prefix).  See WithFilterResolver
+type FilterResolverFunc func(name string) (string, error)
+
+// WithFilterResolver provides an optional FilterResolverFunc which resolves
+// the saved filters used as operands of a query, which are their name
+// prefixed with a $ (ie: `$base_filter and region="us"`).  The query of a
+// saved filter is parsed and used as a group, so `$base_filter and
+// region="us"` where base_filter is `status="active" or status="pending"` is
+// parsed as ((status="active" or status="pending") and region="us").  Saved
+// filters can use other saved filters (and macros, see WithMacro), but a saved
+// filter which is used by itself is an ErrInvalidParameter.  Names are case
+// sensitive and they must only contain letters, digits and underscores.  The
+// query of a saved filter counts towards the limits of the query (see
+// WithMaxQueryLength and WithMaxTokens).
+func WithFilterResolver(fn FilterResolverFunc) Option {
+	const op = "mql.WithFilterResolver"
+	return func(o *options) error {
+		if fn == nil {
+			return fmt.Errorf("%s: missing filter resolver: %w", op, ErrInvalidParameter)
+		}
+		o.withFilterResolver = fn
+		return nil
+	}
+}
+
+// resolveFilter returns the query of the saved filter using the parser's
+// FilterResolverFunc.  The name includes its $ prefix.
+func (p *parser) resolveFilter(name string) (string, error) {
+	const op = "mql.(parser).resolveFilter"
+	if p.filterResolver == nil {
+		return "", fmt.Errorf("%s: unable to resolve filter %q without a filter resolver: %w", op, name, ErrInvalidParameter)
+	}
+	query, err := p.filterResolver(strings.TrimPrefix(name, filterPrefix))
+	if err != nil {
+		return "", fmt.Errorf("%s: unable to resolve filter %q: %w: %w", op, name, ErrInvalidParameter, err)
+	}
+	if strings.TrimSpace(query) == "" {
+		return "", fmt.Errorf("%s: filter %q is empty: %w", op, name, ErrInvalidParameter)
+	}
+	if err := p.limits.checkQuery(query); err != nil {
+		return "", fmt.Errorf("%s: filter %q: %w", op, name, err)
+	}
+	return query, nil
+}
@@ -0,0 +1,125 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/mql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse_WithFilterResolver(t *testing.T) {
+	t.Parallel()
+	errNotFound := errors.New("filter not found")
+	filters := map[string]string{
+		"named":       `name="alice" or name="bob"`,
+		"adult_named": `age>=18 and $named`,
+		"with_macro":  `adult or $named`,
+		"loop":        `age>1 and $loop_again`,
+		"loop_again":  `$loop`,
+		"empty":       ` `,
+		"long":        `name="` + strings.Repeat("a", 100) + `"`,
+	}
+	resolver := mql.WithFilterResolver(func(name string) (string, error) {
+		q, ok := filters[name]
+		if !ok {
+			return "", fmt.Errorf("%q: %w", name, errNotFound)
+		}
+		return q, nil
+	})
+	tests := []struct {
+		name      string
+		query     string
+		opts      []mql.Option
+		want      *mql.WhereClause
+		wantErrIs error
+	}{
+		{
+			name:  "filter",
+			query: `$named`,
+			want:  &mql.WhereClause{Condition: "(name=? or name=?)", Args: []any{"alice", "bob"}},
+		},
+		{
+			name:  "filter-and-comparison",
+			query: `$named and age<65`,
+			want:  &mql.WhereClause{Condition: "((name=? or name=?) and age<?)", Args: []any{"alice", "bob", 65}},
+		},
+		{
+			name:  "grouped",
+			query: `age<65 and ($named)`,
+			want:  &mql.WhereClause{Condition: "(age<? and (name=? or name=?))", Args: []any{65, "alice", "bob"}},
+		},
+		{
+			name:  "nested-filters",
+			query: `$adult_named or age>65`,
+			want:  &mql.WhereClause{Condition: "((age>=? and (name=? or name=?)) or age>?)", Args: []any{18, "alice", "bob", 65}},
+		},
+		{
+			name:  "filter-with-macro",
+			query: `$with_macro`,
+			opts:  []mql.Option{mql.WithMacro("adult", `age>=18`)},
+			want:  &mql.WhereClause{Condition: "(age>=? or (name=? or name=?))", Args: []any{18, "alice", "bob"}},
+		},
+		{
+			name:      "err-not-found",
+			query:     `$missing and age<65`,
+			wantErrIs: errNotFound,
+		},
+		{
+			name:      "err-case-sensitive",
+			query:     `$Named`,
+			wantErrIs: mql.ErrInvalidParameter,
+		},
+		{
+			name:      "err-cycle",
+			query:     `$loop`,
+			wantErrIs: mql.ErrInvalidParameter,
+		},
+		{
+			name:      "err-empty",
+			query:     `$empty`,
+			wantErrIs: mql.ErrInvalidParameter,
+		},
+		{
+			name:      "err-too-long",
+			query:     `$long`,
+			opts:      []mql.Option{mql.WithMaxQueryLength(50)},
+			wantErrIs: mql.ErrQueryTooLong,
+		},
+		{
+			name:      "err-invalid-name",
+			query:     `$named.x`,
+			wantErrIs: mql.ErrMissingComparisonOp,
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert, require := assert.New(t), require.New(t)
+			got, err := mql.Parse(tc.query, testModel{}, append([]mql.Option{resolver}, tc.opts...)...)
+			if tc.wantErrIs != nil {
+				require.Error(err)
+				assert.ErrorIs(err, tc.wantErrIs)
+				assert.Nil(got)
+				return
+			}
+			require.NoError(err)
+			assert.Equal(tc.want, got)
+		})
+	}
+	t.Run("err-without-resolver", func(t *testing.T) {
+		_, err := mql.Parse(`$named`, testModel{})
+		assert.ErrorIs(t, err, mql.ErrInvalidParameter)
+	})
+	t.Run("err-options", func(t *testing.T) {
+		_, err := mql.Parse(`age>1`, testModel{}, mql.WithFilterResolver(nil))
+		assert.ErrorIs(t, err, mql.ErrInvalidParameter)
+	})
+}
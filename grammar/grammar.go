@@ -0,0 +1,219 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+// Package grammar describes the mql language (keywords, tokens, operators and
+// their precedence, and productions), so UIs can generate syntax help,
+// autocomplete rules and client-side validators which stay in sync with the
+// Go parser.  The operators are the ones supported by the mql package and the
+// grammar can be exported as EBNF (see Grammar.EBNF), which can be used to
+// generate railroad diagrams.
+//
+//	g := grammar.Spec()
+//	for _, o := range g.ComparisonOperators {
+//	  fmt.Println(o.Symbol, o.Description)
+//	}
+//	fmt.Println(g.EBNF())
+package grammar
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/mql"
+)
+
+// Grammar describes the mql language
+type Grammar struct {
+	// Keywords are the case insensitive keywords of the language
+	Keywords []string `json:"keywords"`
+
+	// Tokens are the tokens scanned by the lexer
+	Tokens []Token `json:"tokens"`
+
+	// ComparisonOperators are the operators used to compare a column to a
+	// value (ie: name = "alice")
+	ComparisonOperators []Operator `json:"comparison_operators"`
+
+	// LogicalOperators are the operators used to combine comparisons (ie:
+	// name = "alice" and age > 21)
+	LogicalOperators []Operator `json:"logical_operators"`
+
+	// Productions are the rules of the grammar, starting with the query's
+	// condition
+	Productions []Production `json:"productions"`
+}
+
+// Token describes a token scanned by the lexer
+type Token struct {
+	// Name of the token (ie: gte)
+	Name string `json:"name"`
+
+	// Literal is the token's text when the token is always the same text (ie:
+	// >=) and it's empty otherwise (ie: a string).
+	Literal string `json:"literal,omitempty"`
+
+	// Description of the token
+	Description string `json:"description"`
+}
+
+// Associativity defines how a sequence of operators with the same precedence
+// is grouped
+type Associativity string
+
+const (
+	// NonAssociative operators can't be used in a sequence (ie: a = b = c)
+	NonAssociative Associativity = "none"
+
+	// LeftAssociative operators are grouped from the left: a and b or c is
+	// grouped as (a and b) or c
+	LeftAssociative Associativity = "left"
+)
+
+// Operator describes a comparison or logical operator
+type Operator struct {
+	// Symbol of the operator as written in a query (ie: >=)
+	Symbol string `json:"symbol"`
+
+	// Name of the operator's token (ie: gte)
+	Name string `json:"name"`
+
+	// Precedence of the operator, where operators with a higher precedence
+	// are grouped first.  Comparison operators have a higher precedence than
+	// logical operators.
+	Precedence int `json:"precedence"`
+
+	// Associativity of the operator
+	Associativity Associativity `json:"associativity"`
+
+	// Description of the operator
+	Description string `json:"description"`
+}
+
+// Production describes a rule of the grammar
+type Production struct {
+	// Name of the production (ie: comparison_expr)
+	Name string `json:"name"`
+
+	// Rule is the production's definition using EBNF (see Grammar.EBNF)
+	Rule string `json:"rule"`
+
+	// Description of the production
+	Description string `json:"description"`
+}
+
+const (
+	logicalPrecedence    = 1
+	comparisonPrecedence = 2
+)
+
+// comparisonOperators describes the comparison operators of the mql package
+// by their symbol
+var comparisonOperators = map[mql.ComparisonOp]Operator{
+	mql.EqualOp:              {Name: "eq", Description: "equal to"},
+	mql.NotEqualOp:           {Name: "ne", Description: "not equal to"},
+	mql.GreaterThanOp:        {Name: "gt", Description: "greater than"},
+	mql.GreaterThanOrEqualOp: {Name: "gte", Description: "greater than or equal to"},
+	mql.LessThanOp:           {Name: "lt", Description: "less than"},
+	mql.LessThanOrEqualOp:    {Name: "lte", Description: "less than or equal to"},
+	mql.ContainsOp:           {Name: "contains", Description: "contains the value (not supported for date/time, duration, decimal and IP address columns)"},
+	mql.ContainedByOp:        {Name: "containedby", Description: "the IP address is contained by the CIDR (only supported for IP address columns)"},
+	mql.ArrayContainsOp:      {Name: "arraycontains", Description: "the array contains the value (only supported for array columns)"},
+	mql.SimilarToOp:          {Name: "similar", Description: "similar to the value using trigram similarity, which is tolerant of typos (only supported for string columns)"},
+}
+
+// logicalOperators describes the logical operators of the mql package
+var logicalOperators = map[mql.LogicalOp]Operator{
+	mql.AndOp: {Name: "and", Description: "both comparisons must be true"},
+	mql.OrOp:  {Name: "or", Description: "either comparison must be true"},
+}
+
+// Spec returns the grammar of the mql language
+func Spec() Grammar {
+	g := Grammar{
+		Keywords: []string{"and", "or", "any", "true", "false", "now", "today"},
+		Tokens: []Token{
+			{Name: "lparen", Literal: "(", Description: "starts a group of comparisons"},
+			{Name: "rparen", Literal: ")", Description: "ends a group of comparisons"},
+			{Name: "comma", Literal: ",", Description: "separates the values of a list of values"},
+			{Name: "quote", Literal: `"`, Description: "delimits a quoted string (single-quotes and backticks are also supported)"},
+			{Name: "string", Description: "a quoted string, where quotes and backslashes are escaped with a backslash"},
+			{Name: "number", Description: "an int or float (ie: 21, -1.5, .5, 1e6, 1_000, 0xff)"},
+			{Name: "symbol", Description: "an unquoted string (ie: a column name)"},
+		},
+	}
+	for _, o := range mql.ComparisonOps() {
+		desc, ok := comparisonOperators[o]
+		if !ok {
+			desc = Operator{Name: string(o)}
+		}
+		desc.Symbol, desc.Precedence, desc.Associativity = string(o), comparisonPrecedence, NonAssociative
+		g.ComparisonOperators = append(g.ComparisonOperators, desc)
+		g.Tokens = append(g.Tokens, Token{Name: desc.Name, Literal: desc.Symbol, Description: desc.Description})
+	}
+	for _, o := range mql.LogicalOps() {
+		desc, ok := logicalOperators[o]
+		if !ok {
+			desc = Operator{Name: string(o)}
+		}
+		desc.Symbol, desc.Precedence, desc.Associativity = string(o), logicalPrecedence, LeftAssociative
+		g.LogicalOperators = append(g.LogicalOperators, desc)
+	}
+	g.Productions = []Production{
+		{Name: "condition", Rule: "logical_expr", Description: "a query, which must be satisfied by every resource returned"},
+		{Name: "logical_expr", Rule: "operand ( logical_operator operand )*", Description: "comparisons combined by logical operators, which have the same precedence and are grouped from the left"},
+		{Name: "operand", Rule: `comparison_expr | "(" logical_expr ")" | macro`, Description: "a comparison, a group of comparisons or a macro"},
+		{Name: "macro", Rule: `"$"? [a-zA-Z0-9_]+`, Description: "the name of a query (or a saved filter when it's prefixed with $) which is expanded as a group of comparisons (see mql.WithMacro and mql.WithFilterResolver)"},
+		{Name: "comparison_expr", Rule: `column ( comparison_operator ( value | value_list ) | "any" value_list )`, Description: "compares a column to a value or to each value of a list of values"},
+		{Name: "column", Rule: `symbol ( "." symbol )? | quoted_string`, Description: "a column of the model or a key of a map column (ie: labels.env), which can be quoted"},
+		{Name: "comparison_operator", Rule: alternatives(g.ComparisonOperators), Description: "an operator which compares a column to a value"},
+		{Name: "logical_operator", Rule: alternatives(g.LogicalOperators), Description: "an operator which combines comparisons (case insensitive)"},
+		{Name: "value_list", Rule: `"(" value ( "," value )* ")"`, Description: "a list of values, which is only supported by = (or any) which matches any of the values and != which matches none of them"},
+		{Name: "value", Rule: "quoted_string | number | bool | relative_time | variable", Description: "a value which must be valid for the column's type"},
+		{Name: "variable", Rule: `":" [a-zA-Z_] [a-zA-Z0-9_]*`, Description: "a variable whose value is provided when the query is converted (see mql.WithVariables)"},
+		{Name: "quoted_string", Rule: `'"' ( [^"\] | '\' . )* '"' | "'" ( [^'\] | '\' . )* "'" | '` + "`" + `' ( [^` + "`" + `\] | '\' . )* '` + "`" + `'`, Description: "a string delimited by quotes"},
+		{Name: "number", Rule: `"-"? ( "0x" hex_digits | ( digits ( "." [0-9]* )? | "." digits ) ( [eE] [+-]? digits )? )`, Description: "an int or float, optionally with underscores between its digits; hex numbers are only valid for int columns"},
+		{Name: "digits", Rule: `[0-9]+ ( "_" [0-9]+ )*`, Description: "decimal digits"},
+		{Name: "hex_digits", Rule: `[0-9a-fA-F]+ ( "_" [0-9a-fA-F]+ )*`, Description: "hex digits"},
+		{Name: "bool", Rule: `"true" | "false"`, Description: "a bool, which can be compared to bool columns using = or !="},
+		{Name: "relative_time", Rule: `( "now" | "today" ) ( ( "+" | "-" ) offset )?`, Description: "a time relative to when the query is parsed, which can be compared to date/time columns"},
+		{Name: "offset", Rule: `[0-9]+ ( "d" | "w" ) | duration`, Description: "a number of days or weeks, or a Go duration (ie: 24h, 1h30m)"},
+		{Name: "duration", Rule: `( [0-9]+ ( "." [0-9]+ )? ( "ns" | "us" | "ms" | "s" | "m" | "h" ) )+`, Description: "a Go duration (see time.ParseDuration)"},
+		{Name: "symbol", Rule: `[^ #x9#xA#xD=<>!()%,"'` + "`" + `]+`, Description: "an unquoted string"},
+	}
+	return g
+}
+
+// alternatives returns the EBNF alternatives of the operators' symbols
+func alternatives(ops []Operator) string {
+	s := make([]string, 0, len(ops))
+	for _, o := range ops {
+		s = append(s, quote(o.Symbol))
+	}
+	return strings.Join(s, " | ")
+}
+
+// quote returns the EBNF string literal of s
+func quote(s string) string {
+	if strings.Contains(s, `"`) {
+		return fmt.Sprintf("'%s'", s)
+	}
+	return fmt.Sprintf("%q", s)
+}
+
+// EBNF returns the grammar's productions using the W3C EBNF notation (name ::=
+// rule), which is supported by railroad diagram generators (ie:
+// https://www.bottlecaps.de/rr/ui).  The description of each production is
+// included as a comment.
+func (g Grammar) EBNF() string {
+	var b strings.Builder
+	for i, p := range g.Productions {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		if p.Description != "" {
+			fmt.Fprintf(&b, "/* %s */\n", p.Description)
+		}
+		fmt.Fprintf(&b, "%s ::= %s\n", p.Name, p.Rule)
+	}
+	return b.String()
+}
@@ -0,0 +1,79 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package grammar_test
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/mql"
+	"github.com/hashicorp/mql/grammar"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpec(t *testing.T) {
+	t.Parallel()
+	g := grammar.Spec()
+	t.Run("comparison-operators", func(t *testing.T) {
+		assert := assert.New(t)
+		ops := mql.ComparisonOps()
+		require.Len(t, g.ComparisonOperators, len(ops))
+		for i, o := range ops {
+			got := g.ComparisonOperators[i]
+			assert.Equal(string(o), got.Symbol)
+			assert.NotEqual(got.Symbol, got.Name, "missing description of %q", o)
+			assert.NotEmpty(got.Description, "missing description of %q", o)
+			assert.Equal(grammar.NonAssociative, got.Associativity)
+		}
+	})
+	t.Run("logical-operators", func(t *testing.T) {
+		assert := assert.New(t)
+		ops := mql.LogicalOps()
+		require.Len(t, g.LogicalOperators, len(ops))
+		for i, o := range ops {
+			got := g.LogicalOperators[i]
+			assert.Equal(string(o), got.Symbol)
+			assert.NotEmpty(got.Description, "missing description of %q", o)
+			assert.Equal(grammar.LeftAssociative, got.Associativity)
+			assert.Less(got.Precedence, g.ComparisonOperators[0].Precedence)
+		}
+	})
+	t.Run("productions-are-defined", func(t *testing.T) {
+		// every name referenced by a rule (after removing its literals and
+		// character classes) must be a production
+		literals := regexp.MustCompile(`"[^"]*"|'[^']*'|\[[^\]]*\]`)
+		names := regexp.MustCompile(`[a-z_]+`)
+		defined := map[string]bool{}
+		for _, p := range g.Productions {
+			assert.False(t, defined[p.Name], "duplicated production %q", p.Name)
+			defined[p.Name] = true
+		}
+		for _, p := range g.Productions {
+			for _, n := range names.FindAllString(literals.ReplaceAllString(p.Rule, ""), -1) {
+				assert.True(t, defined[n], "undefined %q in production %q", n, p.Name)
+			}
+		}
+	})
+	t.Run("json", func(t *testing.T) {
+		b, err := json.Marshal(g)
+		require.NoError(t, err)
+		var got grammar.Grammar
+		require.NoError(t, json.Unmarshal(b, &got))
+		assert.Equal(t, g, got)
+		assert.Contains(t, string(b), `"comparison_operators":[{"symbol":"=","name":"eq"`)
+	})
+}
+
+func TestGrammar_EBNF(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+	ebnf := grammar.Spec().EBNF()
+	assert.True(strings.HasPrefix(ebnf, "/* a query, which must be satisfied by every resource returned */\ncondition ::= logical_expr\n"))
+	assert.Contains(ebnf, `comparison_operator ::= "=" | "!=" | ">" | ">=" | "<" | "<=" | "%" | "<<" | "@>" | "~%"`+"\n")
+	assert.Contains(ebnf, `logical_operator ::= "and" | "or"`+"\n")
+	assert.Contains(ebnf, "\n\n/* a comparison, a group of comparisons or a macro */\noperand ::= ")
+}
@@ -0,0 +1,141 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/mql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type groupingModel struct {
+	A, B, C, D, E int
+}
+
+// TestParse_grouping pins the SQL shape of the generated conditions, since
+// downstream users may depend on it (think: pinned query plans).  See the
+// grouping contract in the README.
+func TestParse_grouping(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{query: "a=1", want: "a=?"},
+		{query: "(a=1)", want: "a=?"},
+		{query: "(((a=1)))", want: "a=?"},
+		{query: "a=1 and b=1", want: "(a=? and b=?)"},
+		{query: "a=1 and b=1 and c=1", want: "((a=? and b=?) and c=?)"},
+		{query: "a=1 and b=1 and c=1 and d=1", want: "(((a=? and b=?) and c=?) and d=?)"},
+		{query: "a=1 and b=1 or c=1", want: "((a=? and b=?) or c=?)"},
+		{query: "a=1 or b=1 and c=1", want: "((a=? or b=?) and c=?)"},
+		{query: "(a=1 and b=1) or c=1", want: "((a=? and b=?) or c=?)"},
+		{query: "a=1 and (b=1 or c=1)", want: "(a=? and (b=? or c=?))"},
+		{query: "a=1 and (b=1)", want: "(a=? and b=?)"},
+		{query: "(a=1) and b=1", want: "(a=? and b=?)"},
+		{query: "(a=1) and (b=1) or (c=1)", want: "((a=? and b=?) or c=?)"},
+		{query: "a=1 and ((b=1))", want: "(a=? and b=?)"},
+		{query: "((a=1)) and b=1", want: "(a=? and b=?)"},
+		{query: "((a=1 and b=1)) or c=1", want: "((a=? and b=?) or c=?)"},
+		{query: "a=1 or ((b=1 and c=1))", want: "(a=? or (b=? and c=?))"},
+		{query: "(a=1 or b=1) and (c=1 or d=1)", want: "((a=? or b=?) and (c=? or d=?))"},
+		{query: "(a=1 or b=1) and c=1 or d=1", want: "(((a=? or b=?) and c=?) or d=?)"},
+		{query: "a=1 and (b=1 or c=1) and d=1", want: "((a=? and (b=? or c=?)) and d=?)"},
+		{query: "a=1 and (b=1 or c=1) or (d=1 and e=1)", want: "((a=? and (b=? or c=?)) or (d=? and e=?))"},
+		{query: "a=1 and (b=1 and (c=1 or d=1)) or e=1", want: "((a=? and (b=? and (c=? or d=?))) or e=?)"},
+		{query: "(a=1 and (b=1 or (c=1 and d=1)))", want: "(a=? and (b=? or (c=? and d=?)))"},
+		{query: "((a=1 and b=1) or c=1) and d=1", want: "(((a=? and b=?) or c=?) and d=?)"},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.query, func(t *testing.T) {
+			w, err := mql.Parse(tc.query, groupingModel{})
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, w.Condition)
+		})
+	}
+}
+
+// groupingTree is a binary tree of comparisons used to generate every
+// operator/paren permutation of a query
+type groupingTree struct {
+	column      string
+	left, right *groupingTree
+	op          string
+}
+
+// sql returns the condition expected by the grouping contract
+func (g *groupingTree) sql() string {
+	if g.left == nil {
+		return g.column + "=?"
+	}
+	return fmt.Sprintf("(%s %s %s)", g.left.sql(), g.op, g.right.sql())
+}
+
+// minimal returns the query with only the parens required by the grouping
+// contract: a logical expr on the right side must be grouped, but one on the
+// left side never needs to be.
+func (g *groupingTree) minimal() string {
+	if g.left == nil {
+		return g.column + "=1"
+	}
+	right := g.right.minimal()
+	if g.right.left != nil {
+		right = "(" + right + ")"
+	}
+	return fmt.Sprintf("%s %s %s", g.left.minimal(), g.op, right)
+}
+
+// explicit returns the query with every logical expr grouped and the
+// comparisons wrapped in wrap number of parens.
+func (g *groupingTree) explicit(wrap int) string {
+	if g.left == nil {
+		return strings.Repeat("(", wrap) + g.column + "=1" + strings.Repeat(")", wrap)
+	}
+	return fmt.Sprintf("(%s %s %s)", g.left.explicit(wrap), g.op, g.right.explicit(wrap))
+}
+
+// groupingTrees returns every tree with the columns as its leaves (in order)
+func groupingTrees(columns []string) []*groupingTree {
+	if len(columns) == 1 {
+		return []*groupingTree{{column: columns[0]}}
+	}
+	var trees []*groupingTree
+	for i := 1; i < len(columns); i++ {
+		for _, l := range groupingTrees(columns[:i]) {
+			for _, r := range groupingTrees(columns[i:]) {
+				for _, op := range []string{"and", "or"} {
+					trees = append(trees, &groupingTree{left: l, right: r, op: op})
+				}
+			}
+		}
+	}
+	return trees
+}
+
+// TestParse_groupingPermutations will exhaustively check the grouping
+// contract over every operator/paren permutation of up to 5 comparisons.
+func TestParse_groupingPermutations(t *testing.T) {
+	t.Parallel()
+	columns := []string{"a", "b", "c", "d", "e"}
+	for n := 1; n <= len(columns); n++ {
+		for _, tree := range groupingTrees(columns[:n]) {
+			want := tree.sql()
+			for _, query := range []string{
+				tree.minimal(),
+				tree.explicit(0),
+				tree.explicit(1),
+				"(" + tree.explicit(2) + ")",
+			} {
+				w, err := mql.Parse(query, groupingModel{})
+				require.NoErrorf(t, err, "query: %s", query)
+				assert.Equalf(t, want, w.Condition, "query: %s", query)
+			}
+		}
+	}
+}
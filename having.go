@@ -0,0 +1,229 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// havingSeparator separates the where and having conditions while their
+// placeholders are replaced together, which can't be part of a condition
+// since values can't contain a NUL character.
+const havingSeparator = "\x00"
+
+// aggregateOps are the comparison operators supported by aggregates
+var aggregateOps = map[ComparisonOp]bool{
+	EqualOp:              true,
+	NotEqualOp:           true,
+	GreaterThanOp:        true,
+	GreaterThanOrEqualOp: true,
+	LessThanOp:           true,
+	LessThanOrEqualOp:    true,
+}
+
+// WithAggregate marks the query column as an aggregate of a grouped query
+// (ie: WithAggregate("count", "count(*)")), so its comparisons are returned
+// in the Having clause of the WhereClause rather than its Condition (ie:
+// `status="active" and count>5` is the condition "status=?" having
+// "count(*)>?").  Aggregates are compared to numbers using =, !=, <, <=, >
+// or >= and they can only be combined with the other columns of the query
+// using "and", since a condition can't be both part of the where and having
+// clauses.  The aggregate is used as is, so it must never be provided by
+// users.
+func WithAggregate(column, aggregate string) Option {
+	const op = "mql.WithAggregate"
+	return func(o *options) error {
+		switch {
+		case column == "":
+			return fmt.Errorf("%s: missing column: %w", op, ErrInvalidParameter)
+		case !isIdentifier(column):
+			return fmt.Errorf("%s: column %q isn't an identifier: %w", op, column, ErrInvalidParameter)
+		case strings.TrimSpace(aggregate) == "":
+			return fmt.Errorf("%s: missing aggregate for column %q: %w", op, column, ErrInvalidParameter)
+		}
+		if o.withAggregates == nil {
+			o.withAggregates = make(map[string]string)
+		}
+		o.withAggregates[strings.ToLower(column)] = aggregate
+		return nil
+	}
+}
+
+// aggregateCondition returns the having condition of the comparison of an
+// aggregate (see WithAggregate)
+func aggregateCondition(aggregate string, c *ComparisonExpr, opts options) (*WhereClause, error) {
+	const op = "mql.aggregateCondition"
+	if !aggregateOps[c.ComparisonOp] {
+		return nil, fmt.Errorf("%s: %w %q for aggregate %q (expected one of: =, !=, <, <=, >, >=)", op, ErrInvalidComparisonOp, c.ComparisonOp, c.Column)
+	}
+	validator := validator{fn: validateAggregate(opts.withTypedArgs), typ: "float"}
+	w, err := defaultValidateConvert(aggregate, c.ComparisonOp, c.Value, validator, opts)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	w.argColumns = argColumns(strings.ToLower(c.Column), len(w.Args))
+	return w, nil
+}
+
+// validateAggregate returns the validator of the value of an aggregate, which
+// is an int when it's a whole number, otherwise it's a float
+func validateAggregate(typed bool) validateFunc {
+	intFn := intValidator("int64", typed)
+	return func(s string) (any, error) {
+		if v, err := intFn(s); err == nil {
+			return v, nil
+		}
+		return validateFloat(s)
+	}
+}
+
+// isAggregate reports whether the comparison is of an aggregate (see
+// WithAggregate)
+func isAggregate(c *ComparisonExpr, opts options) bool {
+	_, ok := opts.withAggregates[strings.ToLower(c.Column)]
+	return ok
+}
+
+// splitAggregates splits the expr tree into the expr of the where clause and
+// the expr of the having clause, either of which is nil when it's empty.
+// Only the operands of an "and" can be split, so an "or" of an aggregate and
+// another column is an error.
+func splitAggregates(e Expr, opts options) (where Expr, having Expr, err error) {
+	const op = "mql.splitAggregates"
+	switch v := e.(type) {
+	case *ComparisonExpr:
+		if isAggregate(v, opts) {
+			return nil, v, nil
+		}
+		return v, nil, nil
+	case *LogicalExpr:
+		lWhere, lHaving, err := splitAggregates(v.LeftExpr, opts)
+		if err != nil {
+			return nil, nil, err
+		}
+		rWhere, rHaving, err := splitAggregates(v.RightExpr, opts)
+		if err != nil {
+			return nil, nil, err
+		}
+		if v.LogicalOp == OrOp {
+			switch {
+			case lHaving == nil && rHaving == nil:
+				return v, nil, nil
+			case lWhere == nil && rWhere == nil:
+				return nil, v, nil
+			default:
+				return nil, nil, fmt.Errorf("%s: aggregates can't be combined with other columns using %q: %w", op, OrOp, ErrInvalidParameter)
+			}
+		}
+		return andExprs(v, lWhere, rWhere), andExprs(v, lHaving, rHaving), nil
+	default:
+		return e, nil, nil
+	}
+}
+
+// andExprs returns the "and" of the left and right exprs, which are either
+// nil or a part of the logical expr e
+func andExprs(e *LogicalExpr, left, right Expr) Expr {
+	switch {
+	case left == nil:
+		return right
+	case right == nil:
+		return left
+	case left == e.LeftExpr && right == e.RightExpr:
+		return e
+	default:
+		return &LogicalExpr{LeftExpr: left, LogicalOp: AndOp, RightExpr: right}
+	}
+}
+
+// havingClause converts the expr tree to a where clause and its Having
+// clause, which are both validated and converted using the model's
+// validators.  The placeholders of the having clause follow the placeholders
+// of the where clause (ie: $1 and $2 for where and $3 for having), since
+// they're part of the same statement.
+func havingClause(expr Expr, fValidators map[string]validator, opts options, opt []Option) (*WhereClause, error) {
+	const op = "mql.havingClause"
+	whereExpr, havingExpr, err := splitAggregates(expr, opts)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	w := &WhereClause{Condition: matchAllCondition}
+	if whereExpr != nil {
+		if w, err = exprToWhereClause(whereExpr, fValidators, opt...); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+	if havingExpr == nil {
+		if opts.withMetadata && whereExpr != nil {
+			w.Metadata = clauseMetadata(whereExpr, w, fValidators, opts)
+		}
+		if w, err = applyPlaceholders(w, opts); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		return w, nil
+	}
+	h, err := exprToWhereClause(havingExpr, fValidators, opt...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if opts.withMetadata {
+		if whereExpr != nil {
+			w.Metadata = clauseMetadata(whereExpr, w, fValidators, opts)
+		}
+		h.Metadata = clauseMetadata(havingExpr, h, fValidators, opts)
+	}
+	// the placeholders are replaced as a single clause, so they're numbered
+	// (or named) as the args of a single statement
+	nWhere := len(w.Args)
+	columns := append(append([]string{}, w.argColumns...), h.argColumns...)
+	var names []string
+	if opts.withNamedParams != "" {
+		names = namedParams(columns)
+	}
+	both := &WhereClause{
+		Condition:  w.Condition + havingSeparator + h.Condition,
+		Args:       append(append([]any{}, w.Args...), h.Args...),
+		argColumns: columns,
+	}
+	if both, err = applyPlaceholders(both, opts); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	w.Condition, h.Condition, _ = strings.Cut(both.Condition, havingSeparator)
+	w.argColumns, h.argColumns = nil, nil
+	switch {
+	case names != nil:
+		w.Args, h.Args = nil, nil
+		w.NamedArgs, h.NamedArgs = namedArgs(names[:nWhere], both.NamedArgs), namedArgs(names[nWhere:], both.NamedArgs)
+		if h.Metadata != nil {
+			for i := range h.Metadata.Args {
+				h.Metadata.Args[i].Name = names[nWhere+i]
+			}
+		}
+	case both.Args == nil:
+		w.Args, h.Args = nil, nil
+	default:
+		w.Args, h.Args = both.Args[:nWhere:nWhere], both.Args[nWhere:]
+		if len(w.Args) == 0 {
+			w.Args = nil
+		}
+		if opts.withSqlNamedArgs && h.Metadata != nil {
+			for i := range h.Metadata.Args {
+				h.Metadata.Args[i].Name = both.Args[nWhere+i].(sql.NamedArg).Name
+			}
+		}
+	}
+	w.Having = h
+	return w, nil
+}
+
+// namedArgs returns the named args with the names
+func namedArgs(names []string, args map[string]any) map[string]any {
+	m := make(map[string]any, len(names))
+	for _, n := range names {
+		m[n] = args[n]
+	}
+	return m
+}
@@ -0,0 +1,141 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql_test
+
+import (
+	"testing"
+
+	"github.com/hashicorp/mql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse_WithAggregate(t *testing.T) {
+	t.Parallel()
+	aggregates := []mql.Option{
+		mql.WithAggregate("count", "count(*)"),
+		mql.WithAggregate("total", "sum(amount)"),
+	}
+	tests := []struct {
+		name      string
+		query     string
+		opts      []mql.Option
+		want      *mql.WhereClause
+		wantErrIs error
+	}{
+		{
+			name:  "where-and-having",
+			query: `name="alice" and count>5`,
+			want: &mql.WhereClause{
+				Condition: "name=?",
+				Args:      []any{"alice"},
+				Having:    &mql.WhereClause{Condition: "count(*)>?", Args: []any{5}},
+			},
+		},
+		{
+			name:  "only-having",
+			query: `COUNT>=2`,
+			want: &mql.WhereClause{
+				Condition: "1=1",
+				Having:    &mql.WhereClause{Condition: "count(*)>=?", Args: []any{2}},
+			},
+		},
+		{
+			name:  "only-where",
+			query: `name="alice"`,
+			want:  &mql.WhereClause{Condition: "name=?", Args: []any{"alice"}},
+		},
+		{
+			name:  "or-of-aggregates",
+			query: `age>21 and (count<2 or total>=10.5)`,
+			want: &mql.WhereClause{
+				Condition: "age>?",
+				Args:      []any{21},
+				Having:    &mql.WhereClause{Condition: "(count(*)<? or sum(amount)>=?)", Args: []any{2, 10.5}},
+			},
+		},
+		{
+			name:  "pg-placeholders",
+			query: `count>5 and name="alice" and total<1.5`,
+			opts:  []mql.Option{mql.WithPgPlaceholders()},
+			want: &mql.WhereClause{
+				Condition: "name=$1",
+				Args:      []any{"alice"},
+				Having:    &mql.WhereClause{Condition: "(count(*)>$2 and sum(amount)<$3)", Args: []any{5, 1.5}},
+			},
+		},
+		{
+			name:  "named-params",
+			query: `name="alice" and count>5 and count<10`,
+			opts:  []mql.Option{mql.WithNamedParams(":")},
+			want: &mql.WhereClause{
+				Condition: "name=:name_1",
+				NamedArgs: map[string]any{"name_1": "alice"},
+				Having: &mql.WhereClause{
+					Condition: "(count(*)>:count_1 and count(*)<:count_2)",
+					NamedArgs: map[string]any{"count_1": 5, "count_2": 10},
+				},
+			},
+		},
+		{
+			name:  "inline-values",
+			query: `name="alice" and count>5`,
+			opts:  []mql.Option{mql.WithInlineValues()},
+			want: &mql.WhereClause{
+				Condition: "name='alice'",
+				Having:    &mql.WhereClause{Condition: "count(*)>5"},
+			},
+		},
+		{
+			name:      "err-or-with-column",
+			query:     `name="alice" or count>5`,
+			wantErrIs: mql.ErrInvalidParameter,
+		},
+		{
+			name:      "err-comparison-op",
+			query:     `count%5`,
+			wantErrIs: mql.ErrInvalidComparisonOp,
+		},
+		{
+			name:      "err-value",
+			query:     `count>"five"`,
+			wantErrIs: mql.ErrInvalidParameter,
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert, require := assert.New(t), require.New(t)
+			got, err := mql.Parse(tc.query, testModel{}, append(tc.opts, aggregates...)...)
+			if tc.wantErrIs != nil {
+				require.Error(err)
+				assert.ErrorIs(err, tc.wantErrIs)
+				assert.Nil(got)
+				return
+			}
+			require.NoError(err)
+			assert.Equal(tc.want, got)
+		})
+	}
+	t.Run("sql-named-args", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		got, err := mql.Parse(`name="alice" and count>5`, testModel{}, mql.WithSqlNamedArgs(), mql.WithAggregate("count", "count(*)"))
+		require.NoError(err)
+		assert.Equal("name=@p1", got.Condition)
+		require.NotNil(got.Having)
+		assert.Equal("count(*)>@p2", got.Having.Condition)
+		require.Len(got.Having.Args, 1)
+	})
+	t.Run("err-options", func(t *testing.T) {
+		for _, o := range []mql.Option{
+			mql.WithAggregate("", "count(*)"),
+			mql.WithAggregate("count(*)", "count(*)"),
+			mql.WithAggregate("count", " "),
+		} {
+			_, err := mql.Parse(`count>1`, testModel{}, o)
+			assert.ErrorIs(t, err, mql.ErrInvalidParameter)
+		}
+	})
+}
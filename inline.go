@@ -0,0 +1,84 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sqlLiteral returns the value as an SQL literal which can be used in a
+// condition instead of a placeholder, where bools use the dialect's literals.
+// See: WithInlineValues
+func sqlLiteral(v any, d Dialect) (string, error) {
+	const op = "mql.sqlLiteral"
+	switch t := v.(type) {
+	case nil:
+		return "null", nil
+	case string:
+		s, err := quoteSqlString(t)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", op, err)
+		}
+		return s, nil
+	case int:
+		return strconv.FormatInt(int64(t), 10), nil
+	case int8:
+		return strconv.FormatInt(int64(t), 10), nil
+	case int16:
+		return strconv.FormatInt(int64(t), 10), nil
+	case int32:
+		return strconv.FormatInt(int64(t), 10), nil
+	case int64:
+		return strconv.FormatInt(t, 10), nil
+	case uint:
+		return strconv.FormatUint(uint64(t), 10), nil
+	case uint8:
+		return strconv.FormatUint(uint64(t), 10), nil
+	case uint16:
+		return strconv.FormatUint(uint64(t), 10), nil
+	case uint32:
+		return strconv.FormatUint(uint64(t), 10), nil
+	case uint64:
+		return strconv.FormatUint(t, 10), nil
+	case float32:
+		return formatSqlFloat(float64(t), 32)
+	case float64:
+		return formatSqlFloat(t, 64)
+	case bool:
+		return d.BoolLiteral(t), nil
+	case time.Time:
+		return quoteSqlString(t.Format(time.RFC3339Nano))
+	default:
+		return "", fmt.Errorf("%s: unsupported value type %T: %w", op, v, ErrInvalidParameter)
+	}
+}
+
+// quoteSqlString returns the string as a single quoted SQL literal with any
+// single quotes doubled.  Backslashes and NUL characters are rejected since
+// some databases (ie: mysql) treat backslashes as escapes and NUL characters
+// may be used to truncate the statement.
+func quoteSqlString(s string) (string, error) {
+	const op = "mql.quoteSqlString"
+	switch {
+	case strings.ContainsRune(s, '\\'):
+		return "", fmt.Errorf("%s: value %q contains a backslash which can't be inlined: %w", op, s, ErrInvalidParameter)
+	case strings.ContainsRune(s, 0):
+		return "", fmt.Errorf("%s: value %q contains a NUL character which can't be inlined: %w", op, s, ErrInvalidParameter)
+	}
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'", nil
+}
+
+// formatSqlFloat returns the float as a numeric literal.  NaN and infinity
+// don't have a numeric literal, so they're rejected.
+func formatSqlFloat(f float64, bitSize int) (string, error) {
+	const op = "mql.formatSqlFloat"
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return "", fmt.Errorf("%s: value %v can't be inlined: %w", op, f, ErrInvalidParameter)
+	}
+	return strconv.FormatFloat(f, 'g', -1, bitSize), nil
+}
@@ -0,0 +1,25 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+// Package wrapperspb mirrors the layout of the protobuf wrapper types (see
+// google.golang.org/protobuf/types/known/wrapperspb), so wrapper fields can be
+// tested without depending on protobuf.
+package wrapperspb
+
+// StringValue wraps a string
+type StringValue struct {
+	state struct{} //nolint:unused // mirrors the generated message's layout
+	Value string
+}
+
+// Int64Value wraps an int64
+type Int64Value struct {
+	state struct{} //nolint:unused // mirrors the generated message's layout
+	Value int64
+}
+
+// String wraps the string
+func String(v string) *StringValue { return &StringValue{Value: v} }
+
+// Int64 wraps the int64
+func Int64(v int64) *Int64Value { return &Int64Value{Value: v} }
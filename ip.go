@@ -0,0 +1,143 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+)
+
+// parseIPLiteral will parse an IP address (10.0.0.1, ::1) or a CIDR
+// (10.0.0.0/8) literal.  An address is returned as a single IP prefix
+// (10.0.0.1/32) and isPrefix reports if the literal was a CIDR.
+func parseIPLiteral(s string) (p netip.Prefix, isPrefix bool, err error) {
+	const op = "mql.parseIPLiteral"
+	if strings.Contains(s, "/") {
+		p, err := netip.ParsePrefix(s)
+		if err != nil {
+			return netip.Prefix{}, false, fmt.Errorf("%s: value %q is not a CIDR: %w", op, s, ErrInvalidParameter)
+		}
+		return p.Masked(), true, nil
+	}
+	a, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, false, fmt.Errorf("%s: value %q is not an IP address: %w", op, s, ErrInvalidParameter)
+	}
+	return netip.PrefixFrom(a, a.BitLen()), false, nil
+}
+
+// validateIP validates an IP address or CIDR literal and converts it to its
+// canonical string, which databases with an inet type (ie: postgres) convert
+// to the column's type.
+func validateIP(s string) (any, error) {
+	const op = "mql.validateIP"
+	p, isPrefix, err := parseIPLiteral(s)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if isPrefix {
+		return p.String(), nil
+	}
+	return p.Addr().String(), nil
+}
+
+// ipCondition returns the where clause which compares the column to the IP
+// literal.  When the column stores binary addresses (see WithBinaryIPs), the
+// args are the address bytes and ip<<"10.0.0.0/8" is converted to a range:
+// (ip>=? and ip<=?) with the first and last addresses of the CIDR.  Otherwise,
+// << is the postgres inet containment operator: ip<<?
+func ipCondition(columnName string, comparisonOp ComparisonOp, literal string, binary bool) (*WhereClause, error) {
+	const op = "mql.ipCondition"
+	p, isPrefix, err := parseIPLiteral(literal)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	switch {
+	case comparisonOp == ContainsOp:
+		return nil, fmt.Errorf("%s: %w %q for an IP address", op, ErrInvalidComparisonOp, comparisonOp)
+	case comparisonOp == ContainedByOp && !isPrefix:
+		return nil, fmt.Errorf("%s: value %q is not a CIDR (expected an address/bits like 10.0.0.0/8): %w", op, literal, ErrInvalidParameter)
+	case comparisonOp == ContainedByOp && binary:
+		return &WhereClause{
+			Condition: fmt.Sprintf("(%s>=? and %s<=?)", columnName, columnName),
+			Args:      []any{p.Addr().AsSlice(), lastAddr(p).AsSlice()},
+		}, nil
+	case binary && isPrefix:
+		return nil, fmt.Errorf("%s: value %q is a CIDR, which can only be used with %q for binary addresses: %w", op, literal, ContainedByOp, ErrInvalidParameter)
+	case binary:
+		return &WhereClause{
+			Condition: fmt.Sprintf("%s%s?", columnName, comparisonOp),
+			Args:      []any{p.Addr().AsSlice()},
+		}, nil
+	}
+	v := p.String()
+	if !isPrefix {
+		v = p.Addr().String()
+	}
+	return &WhereClause{
+		Condition: fmt.Sprintf("%s%s?", columnName, comparisonOp),
+		Args:      []any{v},
+	}, nil
+}
+
+// lastAddr returns the last address of the prefix (every host bit set)
+func lastAddr(p netip.Prefix) netip.Addr {
+	b := p.Masked().Addr().AsSlice()
+	for i := p.Bits(); i < len(b)*8; i++ {
+		b[i/8] |= 1 << (7 - i%8)
+	}
+	a, _ := netip.AddrFromSlice(b)
+	return a
+}
+
+// ipValue returns the field's value of an IP field as a netip.Addr
+func ipValue(fieldVal any) (netip.Addr, bool) {
+	switch v := fieldVal.(type) {
+	case netip.Addr:
+		return v, true
+	case net.IP:
+		a, ok := netip.AddrFromSlice(v)
+		if ok && v.To4() != nil {
+			a = a.Unmap()
+		}
+		return a, ok
+	case string:
+		a, err := netip.ParseAddr(v)
+		return a, err == nil
+	default:
+		return netip.Addr{}, false
+	}
+}
+
+// compareIP compares the field's address with an IP literal (using the
+// literal's network address when it's a CIDR) or reports if the address is
+// contained by the literal's CIDR when the operator is <<
+func compareIP(a netip.Addr, comparisonOp ComparisonOp, literal string) (bool, error) {
+	const op = "mql.compareIP"
+	p, _, err := parseIPLiteral(literal)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	cmp := a.Compare(p.Addr())
+	switch comparisonOp {
+	case ContainedByOp:
+		return p.Contains(a), nil
+	case EqualOp:
+		return cmp == 0, nil
+	case NotEqualOp:
+		return cmp != 0, nil
+	case GreaterThanOp:
+		return cmp > 0, nil
+	case GreaterThanOrEqualOp:
+		return cmp >= 0, nil
+	case LessThanOp:
+		return cmp < 0, nil
+	case LessThanOrEqualOp:
+		return cmp <= 0, nil
+	default:
+		return false, fmt.Errorf("%s: %w %q for an IP address", op, ErrInvalidComparisonOp, comparisonOp)
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql_test
+
+import (
+	"net"
+	"net/netip"
+	"testing"
+
+	"github.com/hashicorp/mql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type hostModel struct {
+	Name    string
+	IP      netip.Addr
+	Gateway net.IP
+	Proxy   *netip.Addr
+	Addrs   map[string]netip.Addr
+}
+
+func TestParse_ip(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name            string
+		query           string
+		opts            []mql.Option
+		want            *mql.WhereClause
+		wantErrIs       error
+		wantErrContains string
+	}{
+		{
+			name:  "contained-by",
+			query: `ip << "10.1.2.3/8" and gateway = "::1" and proxy != "192.168.0.1"`,
+			want: &mql.WhereClause{
+				Condition: "((ip<<? and gateway=?) and proxy!=?)",
+				Args:      []any{"10.0.0.0/8", "::1", "192.168.0.1"},
+			},
+		},
+		{
+			name:  "map",
+			query: `addrs.public << "2001:db8::/32" or addrs.private >= "10.0.0.1"`,
+			want: &mql.WhereClause{
+				Condition: "((addrs->>?)::inet<<? or (addrs->>?)::inet>=?)",
+				Args:      []any{"public", "2001:db8::/32", "private", "10.0.0.1"},
+			},
+		},
+		{
+			name:  "WithBinaryIPs",
+			query: `ip << "10.0.0.0/8" and gateway != "10.0.0.1"`,
+			opts:  []mql.Option{mql.WithBinaryIPs()},
+			want: &mql.WhereClause{
+				Condition: "((ip>=? and ip<=?) and gateway!=?)",
+				Args:      []any{[]byte{10, 0, 0, 0}, []byte{10, 255, 255, 255}, []byte{10, 0, 0, 1}},
+			},
+		},
+		{
+			name:            "err-invalid-ip",
+			query:           `ip = "10.0.0.256"`,
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: `"10.0.0.256" in (comparisonExpr: ip = 10.0.0.256)`,
+		},
+		{
+			name:            "err-contained-by-address",
+			query:           `ip << "10.0.0.1"`,
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: `value "10.0.0.1" is not a CIDR`,
+		},
+		{
+			name:            "err-binary-cidr",
+			query:           `ip = "10.0.0.0/8"`,
+			opts:            []mql.Option{mql.WithBinaryIPs()},
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: `value "10.0.0.0/8" is a CIDR, which can only be used with "<<"`,
+		},
+		{
+			name:            "err-contains",
+			query:           `ip % "10.0.0.1"`,
+			wantErrIs:       mql.ErrInvalidComparisonOp,
+			wantErrContains: `invalid comparison operator "%" for an IP address`,
+		},
+		{
+			name:            "err-contained-by-string",
+			query:           `name << "10.0.0.0/8"`,
+			wantErrIs:       mql.ErrInvalidComparisonOp,
+			wantErrContains: `invalid comparison operator "<<" for column "name" (only supported for IP address columns)`,
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert, require := assert.New(t), require.New(t)
+			got, err := mql.Parse(tc.query, hostModel{}, tc.opts...)
+			if tc.wantErrContains != "" {
+				require.Error(err)
+				assert.Empty(got)
+				assert.ErrorIs(err, tc.wantErrIs)
+				assert.ErrorContains(err, tc.wantErrContains)
+				return
+			}
+			require.NoError(err)
+			assert.Equal(tc.want, got)
+		})
+	}
+}
+
+func TestMatch_ip(t *testing.T) {
+	t.Parallel()
+	item := hostModel{
+		IP:      netip.MustParseAddr("10.1.2.3"),
+		Gateway: net.ParseIP("10.0.0.1"),
+		Addrs:   map[string]netip.Addr{"public": netip.MustParseAddr("2001:db8::1")},
+	}
+	tests := []struct {
+		query string
+		opts  []mql.Option
+		want  bool
+	}{
+		{query: `ip << "10.0.0.0/8"`, want: true},
+		{query: `ip << "10.2.0.0/16"`, want: false},
+		{query: `ip = "10.1.2.3"`, want: true},
+		{query: `ip > "10.1.2.2"`, want: true},
+		{query: `gateway << "10.0.0.0/24"`, want: true},
+		{query: `gateway = "10.0.0.1"`, want: true},
+		{query: `addrs.public << "2001:db8::/32"`, want: true},
+		{query: `addrs.private << "10.0.0.0/8"`, want: false},
+		{query: `proxy != "10.0.0.1"`, want: false},
+		{query: `proxy != "10.0.0.1"`, opts: []mql.Option{mql.WithNullSemantics(mql.ZeroValueNulls)}, want: true},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.query, func(t *testing.T) {
+			got, err := mql.Match(tc.query, item, tc.opts...)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// jsonApiFilterParam is the name of JSON:API filter query params
+const jsonApiFilterParam = "filter"
+
+// jsonApiOps maps the operators used in JSON:API filter query params to their
+// comparison operators.
+var jsonApiOps = map[string]ComparisonOp{
+	"eq":       EqualOp,
+	"ne":       NotEqualOp,
+	"gt":       GreaterThanOp,
+	"gte":      GreaterThanOrEqualOp,
+	"lt":       LessThanOp,
+	"lte":      LessThanOrEqualOp,
+	"contains": ContainsOp,
+}
+
+// ParseJsonApiFilter will convert the JSON:API style filter query params
+// (filter[name]=alice&filter[age][gt]=21) into a where clause using the
+// provided database model. See JsonApiFilterExpr for the supported syntax.
+// Supported options: the same options as Parse.
+func ParseJsonApiFilter(values url.Values, model any, opt ...Option) (*WhereClause, error) {
+	const op = "mql.ParseJsonApiFilter"
+	opts, err := getOpts(opt...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if isNil(model) {
+		return nil, fmt.Errorf("%s: missing model: %w", op, ErrInvalidParameter)
+	}
+	e, err := JsonApiFilterExpr(values)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if e == nil {
+		if !opts.withAllowEmptyQuery {
+			return nil, fmt.Errorf("%s: missing filter: %w", op, ErrInvalidParameter)
+		}
+		w, err := softDeleteCondition(&WhereClause{Condition: matchAllCondition}, nil, model, opts)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		return w, nil
+	}
+	w, err := whereClause(e, model, opt...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return w, nil
+}
+
+// JsonApiFilterExpr will convert the JSON:API style filter query params into
+// an expr tree.  A param of filter[column]=value is an equal comparison and
+// filter[column][op]=value uses one of the operators: eq, ne, gt, gte, lt, lte
+// or contains.  Repeating a param matches any of its values (or) and the
+// comparisons for different params must all match (and).  The params are
+// combined in the order of their names and params which aren't filters (ie:
+// sort or page[size]) are ignored.  It returns nil if there are no filter
+// params.
+func JsonApiFilterExpr(values url.Values) (Expr, error) {
+	const op = "mql.JsonApiFilterExpr"
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		if strings.HasPrefix(k, jsonApiFilterParam+"[") {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	var operands []Expr
+	var logicalOps []LogicalOp
+	for _, k := range keys {
+		column, cmpOp, err := parseJsonApiFilterKey(k)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		var alternatives []Expr
+		var orOps []LogicalOp
+		for _, v := range values[k] {
+			v := v
+			alternatives = append(alternatives, &ComparisonExpr{
+				Column:       column,
+				ComparisonOp: cmpOp,
+				Value:        &v,
+			})
+			orOps = append(orOps, OrOp)
+		}
+		if len(alternatives) == 0 {
+			return nil, fmt.Errorf("%s: %w for %q", op, ErrMissingComparisonValue, k)
+		}
+		operands = append(operands, group(alternatives, orOps[1:]))
+		logicalOps = append(logicalOps, AndOp)
+	}
+	if len(operands) == 0 {
+		return nil, nil
+	}
+	return group(operands, logicalOps[1:]), nil
+}
+
+// parseJsonApiFilterKey returns the column and comparison operator for a
+// filter[column] or filter[column][op] key.
+func parseJsonApiFilterKey(k string) (string, ComparisonOp, error) {
+	const op = "mql.parseJsonApiFilterKey"
+	rest := strings.TrimPrefix(k, jsonApiFilterParam)
+	var segments []string
+	for rest != "" {
+		if rest[0] != '[' {
+			return "", "", fmt.Errorf("%s: %w %q", op, ErrInvalidJsonApiFilter, k)
+		}
+		end := strings.IndexByte(rest, ']')
+		if end < 0 {
+			return "", "", fmt.Errorf("%s: %w %q (missing closing bracket)", op, ErrInvalidJsonApiFilter, k)
+		}
+		segments = append(segments, rest[1:end])
+		rest = rest[end+1:]
+	}
+	switch {
+	case len(segments) == 0 || len(segments) > 2:
+		return "", "", fmt.Errorf("%s: %w %q (expected filter[column] or filter[column][op])", op, ErrInvalidJsonApiFilter, k)
+	case segments[0] == "":
+		return "", "", fmt.Errorf("%s: %w %q: %w", op, ErrInvalidJsonApiFilter, k, ErrMissingColumn)
+	case len(segments) == 1:
+		return segments[0], EqualOp, nil
+	}
+	cmpOp, ok := jsonApiOps[strings.ToLower(segments[1])]
+	if !ok {
+		return "", "", fmt.Errorf("%s: %w %q in %q", op, ErrInvalidComparisonOp, segments[1], k)
+	}
+	return segments[0], cmpOp, nil
+}
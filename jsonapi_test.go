@@ -0,0 +1,171 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql_test
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/hashicorp/mql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseJsonApiFilter(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name            string
+		query           string
+		opts            []mql.Option
+		want            *mql.WhereClause
+		wantErrIs       error
+		wantErrContains string
+	}{
+		{
+			name:  "equal",
+			query: "filter[name]=alice",
+			want:  &mql.WhereClause{Condition: "name=?", Args: []any{"alice"}},
+		},
+		{
+			name:  "ops",
+			query: "filter[age][gt]=21&filter[age][LTE]=65&filter[name][contains]=ali&filter[email][ne]=eve@example.com&sort=-name&page[size]=10",
+			want: &mql.WhereClause{
+				Condition: "(((age<=? and age>?) and email!=?) and name like ? escape '\\')",
+				Args:      []any{65, 21, "eve@example.com", "%ali%"},
+			},
+		},
+		{
+			name:  "repeated-values",
+			query: "filter[name]=alice&filter[name]=bob&filter[age][gte]=21",
+			want: &mql.WhereClause{
+				Condition: "(age>=? and (name=? or name=?))",
+				Args:      []any{21, "alice", "bob"},
+			},
+		},
+		{
+			name:  "map-key",
+			query: "filter[labels.env]=prod",
+			want:  &mql.WhereClause{Condition: "labels->>?=?", Args: []any{"env", "prod"}},
+		},
+		{
+			name:  "values-are-args",
+			query: "filter[name]=" + url.QueryEscape(`alice" or 1=1 --`),
+			want:  &mql.WhereClause{Condition: "name=?", Args: []any{`alice" or 1=1 --`}},
+		},
+		{
+			name:  "with-options",
+			query: "filter[nickname]=alice&filter[age][lt]=21",
+			opts: []mql.Option{
+				mql.WithColumnMap(map[string]string{"nickname": "name"}),
+				mql.WithPgPlaceholders(),
+			},
+			want: &mql.WhereClause{Condition: "(age<$1 and name=$2)", Args: []any{21, "alice"}},
+		},
+		{
+			name:  "empty-WithAllowEmptyQuery",
+			query: "sort=name",
+			opts:  []mql.Option{mql.WithAllowEmptyQuery()},
+			want:  &mql.WhereClause{Condition: "1=1"},
+		},
+		{
+			name:            "err-empty",
+			query:           "sort=name",
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: "missing filter",
+		},
+		{
+			name:            "err-invalid-column",
+			query:           "filter[nickname]=alice",
+			wantErrIs:       mql.ErrInvalidColumn,
+			wantErrContains: `invalid column "nickname"`,
+		},
+		{
+			name:            "err-invalid-value",
+			query:           "filter[age][gt]=old",
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: `"old"`,
+		},
+		{
+			name:            "err-invalid-op",
+			query:           "filter[age][between]=1",
+			wantErrIs:       mql.ErrInvalidComparisonOp,
+			wantErrContains: `invalid comparison operator "between" in "filter[age][between]"`,
+		},
+		{
+			name:            "err-missing-closing-bracket",
+			query:           "filter[age=1",
+			wantErrIs:       mql.ErrInvalidJsonApiFilter,
+			wantErrContains: `invalid JSON:API filter "filter[age" (missing closing bracket)`,
+		},
+		{
+			name:            "err-too-many-segments",
+			query:           "filter[age][gt][x]=1",
+			wantErrIs:       mql.ErrInvalidJsonApiFilter,
+			wantErrContains: "expected filter[column] or filter[column][op]",
+		},
+		{
+			name:            "err-trailing-chars",
+			query:           "filter[age]x=1",
+			wantErrIs:       mql.ErrInvalidJsonApiFilter,
+			wantErrContains: `invalid JSON:API filter "filter[age]x"`,
+		},
+		{
+			name:            "err-missing-column",
+			query:           "filter[]=1",
+			wantErrIs:       mql.ErrMissingColumn,
+			wantErrContains: "missing column",
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			assert, require := assert.New(t), require.New(t)
+			values, err := url.ParseQuery(tc.query)
+			require.NoError(err)
+			got, err := mql.ParseJsonApiFilter(values, testModel{}, tc.opts...)
+			if tc.wantErrContains != "" {
+				require.Error(err)
+				assert.Empty(got)
+				assert.ErrorIs(err, tc.wantErrIs)
+				assert.ErrorContains(err, tc.wantErrContains)
+				return
+			}
+			require.NoError(err)
+			assert.Equal(tc.want, got)
+		})
+	}
+	t.Run("err-missing-model", func(t *testing.T) {
+		_, err := mql.ParseJsonApiFilter(url.Values{"filter[name]": {"alice"}}, nil)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, mql.ErrInvalidParameter)
+		assert.ErrorContains(t, err, "missing model")
+	})
+}
+
+func TestJsonApiFilterExpr(t *testing.T) {
+	t.Parallel()
+	e, err := mql.JsonApiFilterExpr(url.Values{
+		"filter[name]":    {"alice", "bob"},
+		"filter[age][gt]": {"21"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, &mql.LogicalExpr{
+		LeftExpr:  &mql.ComparisonExpr{Column: "age", ComparisonOp: mql.GreaterThanOp, Value: pointer("21")},
+		LogicalOp: mql.AndOp,
+		RightExpr: &mql.LogicalExpr{
+			LeftExpr:  &mql.ComparisonExpr{Column: "name", ComparisonOp: mql.EqualOp, Value: pointer("alice")},
+			LogicalOp: mql.OrOp,
+			RightExpr: &mql.ComparisonExpr{Column: "name", ComparisonOp: mql.EqualOp, Value: pointer("bob")},
+		},
+	}, e)
+
+	e, err = mql.JsonApiFilterExpr(url.Values{"page[size]": {"10"}})
+	require.NoError(t, err)
+	assert.Nil(t, e)
+
+	e, err = mql.JsonApiFilterExpr(url.Values{"filter[name]": {}})
+	require.Error(t, err)
+	assert.Nil(t, e)
+	assert.ErrorIs(t, err, mql.ErrMissingComparisonValue)
+}
@@ -0,0 +1,129 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql_test
+
+import (
+	"testing"
+
+	"github.com/hashicorp/mql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse_WithLenientParsing(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name          string
+		query         string
+		opts          []mql.Option
+		want          *mql.WhereClause
+		wantErrIs     error
+		wantStrictErr error
+	}{
+		{
+			name:          "trailing-logical-op",
+			query:         `name="alice" and `,
+			want:          &mql.WhereClause{Condition: "name=?", Args: []any{"alice"}},
+			wantStrictErr: mql.ErrMissingRightSideExpr,
+		},
+		{
+			name:          "trailing-logical-op-in-parens",
+			query:         `name="alice" and (age>21 or)`,
+			want:          &mql.WhereClause{Condition: "(name=? and age>?)", Args: []any{"alice", 21}},
+			wantStrictErr: mql.ErrUnexpectedClosingParen,
+		},
+		{
+			name:          "missing-closing-parens",
+			query:         `name="alice" and (age>21 or (length<1.5`,
+			want:          &mql.WhereClause{Condition: "(name=? and (age>? or length<?))", Args: []any{"alice", 21, 1.5}},
+			wantStrictErr: mql.ErrMissingClosingParen,
+		},
+		{
+			name:          "missing-closing-paren-after-logical-op",
+			query:         `(name="alice" or`,
+			want:          &mql.WhereClause{Condition: "name=?", Args: []any{"alice"}},
+			wantStrictErr: mql.ErrMissingRightSideExpr,
+		},
+		{
+			name:          "extra-closing-parens",
+			query:         `name="alice" and age>21 ))`,
+			want:          &mql.WhereClause{Condition: "(name=? and age>?)", Args: []any{"alice", 21}},
+			wantStrictErr: mql.ErrUnexpectedClosingParen,
+		},
+		{
+			name:          "empty-group",
+			query:         `name="alice" and () or age>21`,
+			want:          &mql.WhereClause{Condition: "(name=? or age>?)", Args: []any{"alice", 21}},
+			wantStrictErr: mql.ErrUnexpectedClosingParen,
+		},
+		{
+			name:          "leading-empty-group",
+			query:         `( ) and name="alice"`,
+			want:          &mql.WhereClause{Condition: "name=?", Args: []any{"alice"}},
+			wantStrictErr: mql.ErrUnexpectedClosingParen,
+		},
+		{
+			name:          "nested-empty-groups",
+			query:         `name="alice" or (() and ())`,
+			want:          &mql.WhereClause{Condition: "name=?", Args: []any{"alice"}},
+			wantStrictErr: mql.ErrUnexpectedClosingParen,
+		},
+		{
+			name:          "empty-query-allowed",
+			query:         `()`,
+			opts:          []mql.Option{mql.WithAllowEmptyQuery()},
+			want:          &mql.WhereClause{Condition: "1=1"},
+			wantStrictErr: mql.ErrUnexpectedClosingParen,
+		},
+		{
+			name:          "err-empty-query",
+			query:         `()`,
+			wantErrIs:     mql.ErrMissingExpr,
+			wantStrictErr: mql.ErrUnexpectedClosingParen,
+		},
+		{
+			name:          "err-closing-paren-before-more",
+			query:         `name="alice") and age>21`,
+			wantErrIs:     mql.ErrUnexpectedClosingParen,
+			wantStrictErr: mql.ErrUnexpectedClosingParen,
+		},
+		{
+			name:          "err-leading-logical-op",
+			query:         `and name="alice"`,
+			wantErrIs:     mql.ErrUnexpectedLogicalOp,
+			wantStrictErr: mql.ErrUnexpectedLogicalOp,
+		},
+		{
+			name:          "err-missing-comparison-value",
+			query:         `name=`,
+			wantErrIs:     mql.ErrMissingComparisonValue,
+			wantStrictErr: mql.ErrMissingComparisonValue,
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert, require := assert.New(t), require.New(t)
+			_, err := mql.Parse(tc.query, testModel{}, tc.opts...)
+			assert.ErrorIs(err, tc.wantStrictErr)
+
+			got, err := mql.Parse(tc.query, testModel{}, append(tc.opts, mql.WithLenientParsing())...)
+			if tc.wantErrIs != nil {
+				require.Error(err)
+				assert.ErrorIs(err, tc.wantErrIs)
+				assert.Nil(got)
+				return
+			}
+			require.NoError(err)
+			assert.Equal(tc.want, got)
+		})
+	}
+	t.Run("validate", func(t *testing.T) {
+		assert := assert.New(t)
+		query := `name="alice" and (age > 21 or `
+		assert.Empty(mql.Validate(query, testModel{}, mql.WithLenientParsing()))
+		assert.NotEmpty(mql.Validate(query, testModel{}))
+	})
+}
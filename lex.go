@@ -4,11 +4,10 @@
 package mql
 
 import (
-	"bufio"
-	"bytes"
 	"fmt"
 	"strings"
 	"unicode"
+	"unicode/utf8"
 )
 
 // Delimiter used to quote strings
@@ -25,19 +24,35 @@ const (
 type lexStateFunc func(*lexer) (lexStateFunc, error)
 
 type lexer struct {
-	source  *bufio.Reader
-	current stack[rune]
-	tokens  chan token
-	state   lexStateFunc
+	source string
+	state  lexStateFunc
+
+	// token is the last token emitted and emitted reports if it hasn't been
+	// returned by nextToken yet.  States emit at most one token.
+	token   token
+	emitted bool
+
+	pos      int // byte offset of the next rune to be read
+	lastSize int // size of the last rune read, so it can be unread
+	start    int // byte offset of the token being scanned
+	tokenPos int // byte offset of the last token emitted
+
+	// logger traces the tokens and state transitions, when it's not nil (see
+	// WithDebugLogger)
+	logger DebugLogger
 }
 
 func newLexer(s string) *lexer {
-	l := &lexer{
-		source: bufio.NewReader(strings.NewReader(s)),
+	return &lexer{
+		source: s,
 		state:  lexStartState,
-		tokens: make(chan token, 1), // define a ring buffer for emitted tokens
 	}
-	return l
+}
+
+// lastTokenPos returns the byte offset in the source of the last token returned
+// by nextToken.
+func (l *lexer) lastTokenPos() int {
+	return l.tokenPos
 }
 
 // nextToken is the external api for the lexer and it simply returns the next
@@ -45,16 +60,25 @@ func newLexer(s string) *lexer {
 // returning an eofToken no matter how many times you call nextToken.
 func (l *lexer) nextToken() (token, error) {
 	for {
-		select {
-		case tk := <-l.tokens: // return a token if one has been emitted
-			return tk, nil
-		default: // otherwise, keep scanning via the next state
-			var err error
-			if l.state, err = l.state(l); err != nil {
-				return token{}, err
+		if l.emitted { // return a token if one has been emitted
+			l.emitted = false
+			if l.logger != nil {
+				l.logger.Debug("mql: lexer emitted token", "type", l.token.Type.String(), "value", l.token.Value, "pos", l.tokenPos)
 			}
-
+			return l.token, nil
+		}
+		// otherwise, keep scanning via the next state
+		next, err := l.state(l)
+		if err != nil {
+			if l.logger != nil {
+				l.logger.Debug("mql: lexer failed", "state", stateName(l.state), "pos", l.pos, "error", err)
+			}
+			return token{}, err
 		}
+		if l.logger != nil && stateName(next) != stateName(l.state) {
+			l.logger.Debug("mql: lexer state transition", "from", stateName(l.state), "to", stateName(next), "pos", l.pos)
+		}
+		l.state = next
 	}
 }
 
@@ -63,6 +87,13 @@ func (l *lexer) nextToken() (token, error) {
 // lexStartState after they emit a token.
 func lexStartState(l *lexer) (lexStateFunc, error) {
 	panicIfNil(l, "lexStartState", "lexer")
+	l.start = l.pos
+	if l.peekIs("@>") {
+		return lexArrayContainsState, nil
+	}
+	if l.peekIs("~%") {
+		return lexSimilarState, nil
+	}
 	r := l.read()
 	switch {
 	// wait, if it's eof we're done
@@ -87,9 +118,11 @@ func lexStartState(l *lexer) (lexStateFunc, error) {
 		return lexRightParenState, nil
 	case r == '(':
 		return lexLeftParenState, nil
+	case r == ',':
+		return lexCommaState, nil
 	case isSpace(r):
 		return lexWhitespaceState, nil
-	case unicode.IsDigit(r) || r == '.':
+	case unicode.IsDigit(r) || r == '.' || (r == '-' && l.peekNumberStart()):
 		l.unread()
 		return lexNumberState, nil
 	case isDelimiter(r):
@@ -105,11 +138,6 @@ func lexStartState(l *lexer) (lexStateFunc, error) {
 func lexStringState(l *lexer) (lexStateFunc, error) {
 	const op = "mql.lexStringState"
 	panicIfNil(l, "lexStringState", "lexer")
-	defer l.current.clear()
-
-	// we'll push the runes we read into this buffer and when appropriate will
-	// emit tokens using the buffer's data.
-	var tokenBuf bytes.Buffer
 
 	// before we start looping, let's found out if we're scanning a quoted string
 	r := l.read()
@@ -117,6 +145,21 @@ func lexStringState(l *lexer) (lexStateFunc, error) {
 	if !isDelimiter(delimiter) {
 		return nil, fmt.Errorf("%s: %w %q", op, ErrInvalidDelimiter, delimiter)
 	}
+
+	// a valid UTF-8 string without any backslashes is just a slice of the
+	// source, so it doesn't need to be copied into a buffer.
+	rest := l.source[l.pos:]
+	if end := strings.IndexRune(rest, delimiter); end >= 0 && strings.IndexByte(rest[:end], backslash) < 0 && utf8.ValidString(rest[:end]) {
+		l.pos += end + 1 // delimiters are a single byte
+		l.lastSize = 0
+		l.emit(stringToken, rest[:end])
+		return lexStartState, nil
+	}
+
+	// we'll push the runes we read into this buffer and when appropriate will
+	// emit tokens using the buffer's data.
+	var tokenBuf strings.Builder
+	tokenBuf.Grow(len(rest))
 	finalDelimiter := false
 
 WriteToBuf:
@@ -161,11 +204,13 @@ WriteToBuf:
 func lexSymbolState(l *lexer) (lexStateFunc, error) {
 	const op = "mql.lexSymbolState"
 	panicIfNil(l, "lexSymbolState", "lexer")
-	defer l.current.clear()
 
 ReadRunes:
 	// keep reading runes into the buffer until we encounter eof of non-text runes.
 	for {
+		if l.peekIs("@>") || l.peekIs("~%") { // the start of an arrayContainsToken or similarToken
+			break ReadRunes
+		}
 		r := l.read()
 		switch {
 		case r == eof:
@@ -178,64 +223,63 @@ ReadRunes:
 		}
 	}
 
-	switch strings.ToLower(runesToString(l.current)) {
-	case "and":
+	switch symbol := l.source[l.start:l.pos]; {
+	case strings.EqualFold(symbol, "and"):
 		l.emit(andToken, "and")
 		return lexStartState, nil
-	case "or":
+	case strings.EqualFold(symbol, "or"):
 		l.emit(orToken, "or")
 		return lexStartState, nil
 	default:
-		l.emit(symbolToken, runesToString(l.current))
+		l.emit(symbolToken, symbol)
 		return lexStartState, nil
 	}
 }
 
 func lexNumberState(l *lexer) (lexStateFunc, error) {
 	const op = "mql.lexNumberState"
-	defer l.current.clear()
 
-	isFloat := false
-
-	// we'll push the runes we read into this buffer and when appropriate will
-	// emit tokens using the buffer's data.
-	var buf []rune
-WriteToBuf:
-	// keep reading runes into the buffer until we encounter eof of non-number runes.
-	for {
-		r := l.read()
-		switch {
-		case r == eof:
-			break WriteToBuf
-		case r == '.' && isFloat:
-			buf = append(buf, r)
-			return nil, fmt.Errorf("%s: %w in %q", op, ErrInvalidNumber, string(buf))
-		case r == '.' && !isFloat:
-			isFloat = true
-			buf = append(buf, r)
-		case unicode.IsDigit(r) || (r == '.' && len(buf) == 0):
-			buf = append(buf, r)
-		default:
-			l.unread()
-			break WriteToBuf
-		}
+	// the number is the runes read from start (see numberLen for the literals
+	// which are supported)
+	start := l.pos
+	n, ok := numberLen(l.source[start:])
+	if !ok {
+		return nil, fmt.Errorf("%s: %w in %q", op, ErrInvalidNumber, l.source[start:start+n+1])
 	}
-	l.emit(numberToken, string(buf))
+	for l.pos < start+n {
+		l.read()
+	}
+	l.emit(numberToken, l.source[start:l.pos])
 	return lexStartState, nil
 }
 
 // lexContainsState emits an containsToken and returns to the lexStartState
 func lexContainsState(l *lexer) (lexStateFunc, error) {
 	panicIfNil(l, "lexContainsState", "lexer")
-	defer l.current.clear()
 	l.emit(containsToken, "%")
 	return lexStartState, nil
 }
 
+// lexArrayContainsState emits an arrayContainsToken and returns to the
+// lexStartState
+func lexArrayContainsState(l *lexer) (lexStateFunc, error) {
+	panicIfNil(l, "lexArrayContainsState", "lexer")
+	_, _ = l.read(), l.read() // the "@>" which was peeked by the previous state
+	l.emit(arrayContainsToken, "@>")
+	return lexStartState, nil
+}
+
+// lexSimilarState emits a similarToken and returns to the lexStartState
+func lexSimilarState(l *lexer) (lexStateFunc, error) {
+	panicIfNil(l, "lexSimilarState", "lexer")
+	_, _ = l.read(), l.read() // the "~%" which was peeked by the previous state
+	l.emit(similarToken, "~%")
+	return lexStartState, nil
+}
+
 // lexEqualState emits an equalToken and returns to the lexStartState
 func lexEqualState(l *lexer) (lexStateFunc, error) {
 	panicIfNil(l, "lexEqualState", "lexer")
-	defer l.current.clear()
 	l.emit(equalToken, "=")
 	return lexStartState, nil
 }
@@ -245,7 +289,6 @@ func lexEqualState(l *lexer) (lexStateFunc, error) {
 func lexNotEqualState(l *lexer) (lexStateFunc, error) {
 	const op = "mql.lexNotEqualState"
 	panicIfNil(l, "lexNotEqualState", "lexer")
-	defer l.current.clear()
 	nextRune := l.read()
 	switch nextRune {
 	case '=':
@@ -260,8 +303,7 @@ func lexNotEqualState(l *lexer) (lexStateFunc, error) {
 // lexStartState
 func lexLeftParenState(l *lexer) (lexStateFunc, error) {
 	panicIfNil(l, "lexLeftParenState", "lexer")
-	defer l.current.clear()
-	l.emit(startLogicalExprToken, runesToString(l.current))
+	l.emit(startLogicalExprToken, l.source[l.start:l.pos])
 	return lexStartState, nil
 }
 
@@ -269,15 +311,21 @@ func lexLeftParenState(l *lexer) (lexStateFunc, error) {
 // lexStartState
 func lexRightParenState(l *lexer) (lexStateFunc, error) {
 	panicIfNil(l, "lexRightParenState", "lexer")
-	defer l.current.clear()
-	l.emit(endLogicalExprToken, runesToString(l.current))
+	l.emit(endLogicalExprToken, l.source[l.start:l.pos])
+	return lexStartState, nil
+}
+
+// lexCommaState emits a commaToken, which separates the values of a list, and
+// returns to the lexStartState
+func lexCommaState(l *lexer) (lexStateFunc, error) {
+	panicIfNil(l, "lexCommaState", "lexer")
+	l.emit(commaToken, ",")
 	return lexStartState, nil
 }
 
 // lexWhitespaceState emits a whitespaceToken and returns to the lexStartState
 func lexWhitespaceState(l *lexer) (lexStateFunc, error) {
 	panicIfNil(l, "lexWhitespaceState", "lexer")
-	defer l.current.clear()
 ReadWhitespace:
 	for {
 		ch := l.read()
@@ -297,7 +345,6 @@ ReadWhitespace:
 // greaterThanOrEqualToken and return to the lexStartState
 func lexGreaterState(l *lexer) (lexStateFunc, error) {
 	panicIfNil(l, "lexGreaterState", "lexer")
-	defer l.current.clear()
 	next := l.read()
 	switch next {
 	case '=':
@@ -310,16 +357,18 @@ func lexGreaterState(l *lexer) (lexStateFunc, error) {
 	}
 }
 
-// lexLesserState will emit either a lessThanToken or a lessThanOrEqualToken and
-// return to the lexStartState
+// lexLesserState will emit either a lessThanToken, a lessThanOrEqualToken or a
+// containedByToken and return to the lexStartState
 func lexLesserState(l *lexer) (lexStateFunc, error) {
 	panicIfNil(l, "lexLesserState", "lexer")
-	defer l.current.clear()
 	next := l.read()
 	switch next {
 	case '=':
 		l.emit(lessThanOrEqualToken, "<=")
 		return lexStartState, nil
+	case '<':
+		l.emit(containedByToken, "<<")
+		return lexStartState, nil
 	default:
 		l.unread()
 		l.emit(lessThanToken, "<")
@@ -330,16 +379,19 @@ func lexLesserState(l *lexer) (lexStateFunc, error) {
 // lexEofState will emit an eofToken and returns right back to the lexEofState
 func lexEofState(l *lexer) (lexStateFunc, error) {
 	panicIfNil(l, "lexEofState", "lexer")
+	l.start = l.pos
 	l.emit(eofToken, "")
 	return lexEofState, nil
 }
 
-// emit send a token to the lexer's token channel
+// emit sets the lexer's token, which is returned by the next call to nextToken
 func (l *lexer) emit(t tokenType, v string) {
-	l.tokens <- token{
+	l.tokenPos = l.start
+	l.token = token{
 		Type:  t,
 		Value: v,
 	}
+	l.emitted = true
 }
 
 // isSpace reports if r is a space
@@ -349,25 +401,39 @@ func isSpace(r rune) bool {
 
 // isSpecial reports r is special rune
 func isSpecial(r rune) bool {
-	return r == '=' || r == '>' || r == '!' || r == '<' || r == '(' || r == ')' || r == '%'
+	return r == '=' || r == '>' || r == '!' || r == '<' || r == '(' || r == ')' || r == '%' || r == ','
 }
 
 // read the next rune
 func (l *lexer) read() rune {
-	ch, _, err := l.source.ReadRune()
-	if err != nil {
+	if l.pos >= len(l.source) {
+		l.lastSize = 0
 		return eof
 	}
-	l.current.push(ch)
+	ch, size := utf8.DecodeRuneInString(l.source[l.pos:])
+	l.pos += size
+	l.lastSize = size
 	return ch
 }
 
+// peekIs reports if the next runes are s without reading them.
+func (l *lexer) peekIs(s string) bool {
+	return strings.HasPrefix(l.source[l.pos:], s)
+}
+
+// peekNumberStart reports if the next rune starts the digits of a number
+// literal without reading it.
+func (l *lexer) peekNumberStart() bool {
+	r, _ := utf8.DecodeRuneInString(l.source[l.pos:])
+	return isNumberStart(r)
+}
+
 // unread the last rune read which means that rune will be returned the next
-// time lexer.read() is called.  unread also removes the last rune from the
-// lexer's stack of current runes
+// time lexer.read() is called.  Only the last rune read can be unread.
 func (l *lexer) unread() {
-	_ = l.source.UnreadRune() // error ignore which only occurs when nothing has been previously read
-	_, _ = l.current.pop()
+	// there's nothing to unread when the last read didn't return a rune (eof)
+	l.pos -= l.lastSize
+	l.lastSize = 0
 }
 
 func isDelimiter(r rune) bool {
@@ -378,3 +444,78 @@ func isDelimiter(r rune) bool {
 		return false
 	}
 }
+
+// tokenize will scan all the tokens in s (excluding the eofToken) along with
+// their byte offsets in s.
+func tokenize(s string) ([]token, []int, error) {
+	const op = "mql.tokenize"
+	l := newLexer(s)
+	var (
+		tokens    []token
+		positions []int
+	)
+	for {
+		tk, err := l.nextToken()
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", op, err)
+		}
+		if tk.Type == eofToken {
+			return tokens, positions, nil
+		}
+		tokens = append(tokens, tk)
+		positions = append(positions, l.lastTokenPos())
+	}
+}
+
+// quoteString will double quote s, escaping any backslashes and double quotes
+// so it's scanned as a single stringToken with a value of s.
+func quoteString(s string) string {
+	s = strings.ReplaceAll(s, string(backslash), `\\`)
+	s = strings.ReplaceAll(s, string(DoubleQuote), `\"`)
+	return string(DoubleQuote) + s + string(DoubleQuote)
+}
+
+// isBoolLiteral reports if s is a bool literal (true or false, ignoring case)
+func isBoolLiteral(s string) bool {
+	switch strings.ToLower(s) {
+	case "true", "false":
+		return true
+	default:
+		return false
+	}
+}
+
+// isNumberLiteral reports if s would be scanned as a single numberToken
+func isNumberLiteral(s string) bool {
+	_, _, ok := numberLiteral(s)
+	return ok
+}
+
+// isNumberStart reports if r starts the digits of a number literal, so a "-"
+// followed by r is a negative number rather than a symbol
+func isNumberStart(r rune) bool {
+	return unicode.IsDigit(r) || r == '.'
+}
+
+// isSymbolLiteral reports if s would be scanned as a single symbolToken, so it
+// can be used as a column without quotes
+func isSymbolLiteral(s string) bool {
+	switch strings.ToLower(s) {
+	case "", "and", "or":
+		return false
+	}
+	if strings.Contains(s, "@>") || strings.Contains(s, "~%") {
+		return false
+	}
+	for i, r := range s {
+		switch {
+		case isSpace(r) || isSpecial(r):
+			return false
+		case i == 0 && (unicode.IsDigit(r) || r == '.' || isDelimiter(r)):
+			return false
+		case i == 0 && r == '-' && len(s) > 1 && isNumberStart(rune(s[1])):
+			return false
+		}
+	}
+	return true
+}
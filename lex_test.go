@@ -4,8 +4,6 @@
 package mql
 
 import (
-	"bufio"
-	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -52,6 +50,52 @@ func Test_lexKeywordState(t *testing.T) {
 				{Type: endLogicalExprToken, Value: ")"},
 			},
 		},
+		{
+			name: "valid-number-formats",
+			raw:  `-1 1e6 -1.5E-3 1_000_000 0xff 21and 1e`,
+			want: []token{
+				{Type: numberToken, Value: "-1"},
+				{Type: whitespaceToken, Value: ""},
+				{Type: numberToken, Value: "1e6"},
+				{Type: whitespaceToken, Value: ""},
+				{Type: numberToken, Value: "-1.5E-3"},
+				{Type: whitespaceToken, Value: ""},
+				{Type: numberToken, Value: "1_000_000"},
+				{Type: whitespaceToken, Value: ""},
+				{Type: numberToken, Value: "0xff"},
+				{Type: whitespaceToken, Value: ""},
+				{Type: numberToken, Value: "21"},
+				{Type: andToken, Value: "and"},
+				{Type: whitespaceToken, Value: ""},
+				{Type: numberToken, Value: "1"},
+				{Type: symbolToken, Value: "e"},
+			},
+		},
+		{
+			name: "valid-symbol-with-dash",
+			raw:  `-a`,
+			want: []token{
+				{Type: symbolToken, Value: "-a"},
+			},
+		},
+		{
+			name:            "invalid-number-underscores",
+			raw:             `1__000`,
+			wantErrIs:       ErrInvalidNumber,
+			wantErrContains: `invalid number in "1_"`,
+		},
+		{
+			name:            "invalid-number-trailing-underscore",
+			raw:             `1_ `,
+			wantErrIs:       ErrInvalidNumber,
+			wantErrContains: `invalid number in "1_"`,
+		},
+		{
+			name:            "invalid-number-exponent-fraction",
+			raw:             `1e6.5`,
+			wantErrIs:       ErrInvalidNumber,
+			wantErrContains: `invalid number in "1e6."`,
+		},
 		{
 			name: "just-eof",
 			raw:  ``,
@@ -240,6 +284,56 @@ func Test_lexKeywordState(t *testing.T) {
 				{Type: eofToken, Value: ""},
 			},
 		},
+		{
+			name: "containedBy",
+			raw:  "<<",
+			want: []token{
+				{Type: containedByToken, Value: "<<"},
+				{Type: eofToken, Value: ""},
+			},
+		},
+		{
+			name: "arrayContains",
+			raw:  "tags@>prod",
+			want: []token{
+				{Type: symbolToken, Value: "tags"},
+				{Type: arrayContainsToken, Value: "@>"},
+				{Type: symbolToken, Value: "prod"},
+				{Type: eofToken, Value: ""},
+			},
+		},
+		{
+			name: "similar",
+			raw:  "name~%alice",
+			want: []token{
+				{Type: symbolToken, Value: "name"},
+				{Type: similarToken, Value: "~%"},
+				{Type: symbolToken, Value: "alice"},
+				{Type: eofToken, Value: ""},
+			},
+		},
+		{
+			name: "value-list",
+			raw:  `name=("alice",1)`,
+			want: []token{
+				{Type: symbolToken, Value: "name"},
+				{Type: equalToken, Value: "="},
+				{Type: startLogicalExprToken, Value: "("},
+				{Type: stringToken, Value: "alice"},
+				{Type: commaToken, Value: ","},
+				{Type: numberToken, Value: "1"},
+				{Type: endLogicalExprToken, Value: ")"},
+				{Type: eofToken, Value: ""},
+			},
+		},
+		{
+			name: "symbol-with-at",
+			raw:  "alice@example.com",
+			want: []token{
+				{Type: symbolToken, Value: "alice@example.com"},
+				{Type: eofToken, Value: ""},
+			},
+		},
 		{
 			name: "equal",
 			raw:  "=",
@@ -327,9 +421,8 @@ func Test_lexKeywordState(t *testing.T) {
 	}
 	t.Run("invalid-delimiter", func(t *testing.T) {
 		lex := &lexer{
-			source: bufio.NewReader(strings.NewReader("|alice|")),
+			source: "|alice|",
 			state:  lexStringState,
-			tokens: make(chan token, 1), // define a ring buffer for emitted tokens
 		}
 		s, err := lexStringState(lex)
 		require.Error(t, err)
@@ -431,3 +524,39 @@ func Fuzz_lexerNextToken(f *testing.F) {
 		}
 	})
 }
+
+func Test_tokenize(t *testing.T) {
+	t.Parallel()
+	t.Run("success", func(t *testing.T) {
+		tokens, positions, err := tokenize(`(name="é" and  age>=21)`)
+		require.NoError(t, err)
+		assert.Equal(t, []token{
+			{Type: startLogicalExprToken, Value: "("},
+			{Type: symbolToken, Value: "name"},
+			{Type: equalToken, Value: "="},
+			{Type: stringToken, Value: "é"},
+			{Type: whitespaceToken, Value: ""},
+			{Type: andToken, Value: "and"},
+			{Type: whitespaceToken, Value: ""},
+			{Type: symbolToken, Value: "age"},
+			{Type: greaterThanOrEqualToken, Value: ">="},
+			{Type: numberToken, Value: "21"},
+			{Type: endLogicalExprToken, Value: ")"},
+		}, tokens)
+		assert.Equal(t, []int{0, 1, 5, 6, 10, 11, 14, 16, 19, 21, 23}, positions)
+	})
+	t.Run("err", func(t *testing.T) {
+		_, _, err := tokenize(`name="alice`)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrMissingEndOfStringTokenDelimiter)
+	})
+}
+
+func Test_quoteString(t *testing.T) {
+	t.Parallel()
+	for _, s := range []string{"alice", `alice "eve"`, `c:\path\`, `\"`, ""} {
+		tokens, _, err := tokenize(quoteString(s))
+		require.NoError(t, err)
+		assert.Equal(t, []token{{Type: stringToken, Value: s}}, tokens)
+	}
+}
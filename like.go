@@ -0,0 +1,98 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql
+
+import (
+	"fmt"
+	"strings"
+)
+
+// likeEscapeChar is the escape char of the patterns used by the contains
+// operator, which is set using the like's escape clause.
+const likeEscapeChar = `\`
+
+// globWildcard matches any characters in a value when using WithGlobPatterns
+const globWildcard = '*'
+
+// likeEscaper escapes the LIKE wildcards (% and _) and the escape char itself
+var likeEscaper = strings.NewReplacer(
+	likeEscapeChar, likeEscapeChar+likeEscapeChar,
+	"%", likeEscapeChar+"%",
+	"_", likeEscapeChar+"_",
+)
+
+// containsCondition returns the like condition and its pattern arg used by the
+// contains operator for the column (or json lookup).  The value's LIKE
+// wildcards are escaped, so they're matched literally, unless raw patterns are
+// used (see WithRawLikePatterns).  Supported options: WithRawLikePatterns,
+// WithGlobPatterns, WithDialect
+func containsCondition(columnName string, v any, opts options) (string, any, error) {
+	const op = "mql.containsCondition"
+	switch {
+	case opts.withRawLikePatterns && opts.withGlobPatterns:
+		return "", nil, fmt.Errorf("%s: WithRawLikePatterns and WithGlobPatterns are mutually exclusive: %w", op, ErrInvalidParameter)
+	case opts.withRawLikePatterns:
+		return fmt.Sprintf("%s like ?", columnName), fmt.Sprintf("%%%s%%", v), nil
+	case opts.withGlobPatterns:
+		pattern, _ := globPattern(fmt.Sprintf("%s", v))
+		return dialectOf(opts).Like(columnName, false), "%" + pattern + "%", nil
+	default:
+		return dialectOf(opts).Like(columnName, false), "%" + likeEscaper.Replace(fmt.Sprintf("%s", v)) + "%", nil
+	}
+}
+
+// globCondition returns the like (or not like) condition and its pattern arg
+// when the value of an = (or !=) comparison contains a glob wildcard (see
+// WithGlobPatterns).  When the value doesn't contain a wildcard, the value is
+// compared as is (once any \* is unescaped) and it reports false when there's
+// nothing to convert.  The like condition is generated using the dialect.
+func globCondition(d Dialect, columnName string, comparisonOp ComparisonOp, s string) (*WhereClause, bool) {
+	if comparisonOp != EqualOp && comparisonOp != NotEqualOp {
+		return nil, false
+	}
+	pattern, hasWildcard := globPattern(s)
+	switch {
+	case !hasWildcard && strings.Contains(s, `\*`):
+		return &WhereClause{
+			Condition: fmt.Sprintf("%s%s?", columnName, comparisonOp),
+			Args:      []any{strings.ReplaceAll(s, `\*`, string(globWildcard))},
+		}, true
+	case !hasWildcard:
+		return nil, false
+	}
+	return &WhereClause{
+		Condition: d.Like(columnName, comparisonOp == NotEqualOp),
+		Args:      []any{pattern},
+	}, true
+}
+
+// likeCondition returns the like condition (with its escape clause) for the
+// column
+func likeCondition(columnName string, not bool) string {
+	if not {
+		return fmt.Sprintf("%s not like ? escape '%s'", columnName, likeEscapeChar)
+	}
+	return fmt.Sprintf("%s like ? escape '%s'", columnName, likeEscapeChar)
+}
+
+// globPattern converts a glob value into a LIKE pattern, where * matches any
+// characters (\* matches an asterisk) and the LIKE wildcards are escaped.  It
+// reports if the value contains a wildcard.
+func globPattern(s string) (string, bool) {
+	var b strings.Builder
+	hasWildcard := false
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && i+1 < len(s) && s[i+1] == globWildcard:
+			b.WriteByte(globWildcard)
+			i++
+		case s[i] == globWildcard:
+			b.WriteByte('%')
+			hasWildcard = true
+		default:
+			b.WriteString(likeEscaper.Replace(s[i : i+1]))
+		}
+	}
+	return b.String(), hasWildcard
+}
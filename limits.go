@@ -0,0 +1,127 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	// DefaultMaxQueryLength is the largest query (in bytes) which is parsed
+	// by default.  See WithMaxQueryLength
+	DefaultMaxQueryLength = 8192
+
+	// DefaultMaxTokens is the largest number of tokens (excluding whitespace)
+	// in a query which is parsed by default.  See WithMaxTokens
+	DefaultMaxTokens = 1000
+
+	// DefaultMaxStringLength is the largest string or unquoted value (in
+	// bytes) in a query which is parsed by default.  See WithMaxStringLength
+	DefaultMaxStringLength = 1024
+)
+
+// limits bound the resources used to parse a query, so queries taken straight
+// from a request can't be used to amplify memory or cpu use.  A limit of 0
+// means the limit is disabled.
+type limits struct {
+	maxQueryLength  int
+	maxTokens       int
+	maxStringLength int
+}
+
+// defaultLimits returns the limits used when they're not provided via options
+func defaultLimits() limits {
+	return limits{
+		maxQueryLength:  DefaultMaxQueryLength,
+		maxTokens:       DefaultMaxTokens,
+		maxStringLength: DefaultMaxStringLength,
+	}
+}
+
+// checkQuery returns an error when the query is longer than the max query
+// length
+func (l limits) checkQuery(query string) error {
+	const op = "mql.(limits).checkQuery"
+	if l.maxQueryLength > 0 && len(query) > l.maxQueryLength {
+		return fmt.Errorf("%s: %w: %w: %d bytes (max %d)", op, ErrLimitExceeded, ErrQueryTooLong, len(query), l.maxQueryLength)
+	}
+	return nil
+}
+
+// readQuery reads the query from r, returning an error once it's longer than
+// the max query length (without reading the rest of it)
+func (l limits) readQuery(r io.Reader) (string, error) {
+	const op = "mql.(limits).readQuery"
+	if l.maxQueryLength > 0 {
+		r = io.LimitReader(r, int64(l.maxQueryLength)+1)
+	}
+	var b strings.Builder
+	if _, err := io.Copy(&b, r); err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	if l.maxQueryLength > 0 && b.Len() > l.maxQueryLength {
+		return "", fmt.Errorf("%s: %w: %w: more than %d bytes", op, ErrLimitExceeded, ErrQueryTooLong, l.maxQueryLength)
+	}
+	return b.String(), nil
+}
+
+// checkToken returns an error when the token is the n-th (excluding
+// whitespace) and n is greater than the max number of tokens, or when the
+// token is a string or symbol longer than the max string length
+func (l limits) checkToken(t token, n int) error {
+	const op = "mql.(limits).checkToken"
+	switch {
+	case l.maxTokens > 0 && n > l.maxTokens:
+		return fmt.Errorf("%s: %w: %w (max %d)", op, ErrLimitExceeded, ErrTooManyTokens, l.maxTokens)
+	case (t.Type == stringToken || t.Type == symbolToken) && l.maxStringLength > 0 && len(t.Value) > l.maxStringLength:
+		return fmt.Errorf("%s: %w: %w: %d bytes (max %d)", op, ErrLimitExceeded, ErrStringTooLong, len(t.Value), l.maxStringLength)
+	}
+	return nil
+}
+
+// WithMaxQueryLength provides an optional max length (in bytes) of the
+// queries which are parsed, which defaults to DefaultMaxQueryLength.  A max of
+// 0 disables the limit and longer queries return an ErrQueryTooLong.
+func WithMaxQueryLength(max int) Option {
+	const op = "mql.WithMaxQueryLength"
+	return func(o *options) error {
+		if max < 0 {
+			return fmt.Errorf("%s: max query length %d can't be negative: %w", op, max, ErrInvalidParameter)
+		}
+		o.withLimits.maxQueryLength = max
+		return nil
+	}
+}
+
+// WithMaxTokens provides an optional max number of tokens (excluding
+// whitespace) of the queries which are parsed, which defaults to
+// DefaultMaxTokens.  A max of 0 disables the limit and queries with more
+// tokens return an ErrTooManyTokens.
+func WithMaxTokens(max int) Option {
+	const op = "mql.WithMaxTokens"
+	return func(o *options) error {
+		if max < 0 {
+			return fmt.Errorf("%s: max tokens %d can't be negative: %w", op, max, ErrInvalidParameter)
+		}
+		o.withLimits.maxTokens = max
+		return nil
+	}
+}
+
+// WithMaxStringLength provides an optional max length (in bytes) of the
+// strings and unquoted values of the queries which are parsed, which defaults
+// to DefaultMaxStringLength.  A max of 0 disables the limit and longer strings
+// return an ErrStringTooLong.
+func WithMaxStringLength(max int) Option {
+	const op = "mql.WithMaxStringLength"
+	return func(o *options) error {
+		if max < 0 {
+			return fmt.Errorf("%s: max string length %d can't be negative: %w", op, max, ErrInvalidParameter)
+		}
+		o.withLimits.maxStringLength = max
+		return nil
+	}
+}
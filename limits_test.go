@@ -0,0 +1,141 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/mql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse_limits(t *testing.T) {
+	t.Parallel()
+	// comparisons returns a query with n comparisons (3 tokens each) joined
+	// by and
+	comparisons := func(n int) string {
+		c := make([]string, 0, n)
+		for i := 0; i < n; i++ {
+			c = append(c, `name="alice"`)
+		}
+		return strings.Join(c, " and ")
+	}
+	tests := []struct {
+		name            string
+		query           string
+		opts            []mql.Option
+		wantErrIs       error
+		wantErrContains string
+	}{
+		{
+			name:  "at-limits",
+			query: `name="` + strings.Repeat("a", 10) + `" and age=21`,
+			opts:  []mql.Option{mql.WithMaxQueryLength(28), mql.WithMaxTokens(7), mql.WithMaxStringLength(10)},
+		},
+		{
+			name:  "disabled",
+			query: comparisons(500),
+			opts:  []mql.Option{mql.WithMaxQueryLength(0), mql.WithMaxTokens(0), mql.WithMaxStringLength(0)},
+		},
+		{
+			name:            "err-default-query-length",
+			query:           `name="` + strings.Repeat("a", mql.DefaultMaxQueryLength) + `"`,
+			opts:            []mql.Option{mql.WithMaxStringLength(0)},
+			wantErrIs:       mql.ErrQueryTooLong,
+			wantErrContains: "limit exceeded: query too long",
+		},
+		{
+			name:            "err-default-tokens",
+			query:           comparisons(mql.DefaultMaxTokens/3 + 1),
+			wantErrIs:       mql.ErrTooManyTokens,
+			wantErrContains: "limit exceeded: too many tokens (max 1000)",
+		},
+		{
+			name:            "err-default-string-length",
+			query:           `name="` + strings.Repeat("a", mql.DefaultMaxStringLength+1) + `"`,
+			wantErrIs:       mql.ErrStringTooLong,
+			wantErrContains: "limit exceeded: string too long: 1025 bytes (max 1024)",
+		},
+		{
+			name:            "err-query-length",
+			query:           `name="alice"`,
+			opts:            []mql.Option{mql.WithMaxQueryLength(11)},
+			wantErrIs:       mql.ErrQueryTooLong,
+			wantErrContains: "query too long: 12 bytes (max 11)",
+		},
+		{
+			name:            "err-tokens",
+			query:           `name="alice" and age=21`,
+			opts:            []mql.Option{mql.WithMaxTokens(6)},
+			wantErrIs:       mql.ErrTooManyTokens,
+			wantErrContains: "too many tokens (max 6)",
+		},
+		{
+			name:            "err-unquoted-value-length",
+			query:           `name=alice`,
+			opts:            []mql.Option{mql.WithMaxStringLength(4)},
+			wantErrIs:       mql.ErrStringTooLong,
+			wantErrContains: "string too long: 5 bytes (max 4)",
+		},
+		{
+			name:            "err-negative-max-query-length",
+			query:           `name="alice"`,
+			opts:            []mql.Option{mql.WithMaxQueryLength(-1)},
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: "max query length -1 can't be negative",
+		},
+		{
+			name:            "err-negative-max-tokens",
+			query:           `name="alice"`,
+			opts:            []mql.Option{mql.WithMaxTokens(-1)},
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: "max tokens -1 can't be negative",
+		},
+		{
+			name:            "err-negative-max-string-length",
+			query:           `name="alice"`,
+			opts:            []mql.Option{mql.WithMaxStringLength(-1)},
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: "max string length -1 can't be negative",
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert, require := assert.New(t), require.New(t)
+			got, err := mql.Parse(tc.query, testModel{}, tc.opts...)
+			if tc.wantErrContains != "" {
+				require.Error(err)
+				assert.Nil(got)
+				assert.ErrorIs(err, tc.wantErrIs)
+				assert.ErrorContains(err, tc.wantErrContains)
+				if !errors.Is(err, mql.ErrInvalidParameter) {
+					assert.ErrorIs(err, mql.ErrLimitExceeded)
+					var pErr *mql.ParseError
+					require.ErrorAs(err, &pErr)
+					assert.Nil(pErr.Partial)
+				}
+				return
+			}
+			require.NoError(err)
+			assert.NotNil(got)
+		})
+	}
+}
+
+func TestParseExpr_limits(t *testing.T) {
+	t.Parallel()
+	assert, require := assert.New(t), require.New(t)
+	_, err := mql.ParseExpr(`name="alice" and age=21`, mql.WithMaxTokens(3))
+	require.Error(err)
+	assert.ErrorIs(err, mql.ErrTooManyTokens)
+
+	e, err := mql.ParseExpr(`name="alice" and age=21`)
+	require.NoError(err)
+	assert.Equal(`name="alice" and age=21`, e.MQL())
+}
@@ -0,0 +1,486 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"golang.org/x/exp/slices"
+)
+
+// DiagnosticKind defines the kinds of problems reported by Lint
+type DiagnosticKind string
+
+const (
+	// RedundantParensDiagnostic reports parentheses which don't change how the
+	// query is evaluated.
+	RedundantParensDiagnostic DiagnosticKind = "redundant-parens"
+	// AlwaysTrueDiagnostic reports an expression which is always true.
+	AlwaysTrueDiagnostic DiagnosticKind = "always-true"
+	// AlwaysFalseDiagnostic reports an expression which is always false.
+	AlwaysFalseDiagnostic DiagnosticKind = "always-false"
+	// DuplicateConditionDiagnostic reports a comparison which is repeated
+	// within the same logical expression.
+	DuplicateConditionDiagnostic DiagnosticKind = "duplicate-condition"
+	// EnumCaseMismatchDiagnostic reports a comparison whose value only matches
+	// one of its column's enum values (see WithEnum) when ignoring case.
+	EnumCaseMismatchDiagnostic DiagnosticKind = "enum-case-mismatch"
+)
+
+// Diagnostic is a warning about a query which is valid, but is likely not what
+// the user intended.
+type Diagnostic struct {
+	// Kind of problem found
+	Kind DiagnosticKind
+	// Message describing the problem
+	Message string
+	// Pos is the byte offset in the query where the problem starts
+	Pos int
+}
+
+// Lint will check the query using the provided database model and return
+// warnings about parts of the query that are valid but likely not what the
+// user intended.  An error is returned if the query is invalid, except for a
+// value which only matches an enum value when ignoring case (see WithEnum),
+// which is reported as an EnumCaseMismatchDiagnostic.  Supported options: the
+// same options as Parse.
+func Lint(query string, model any, opt ...Option) ([]Diagnostic, error) {
+	const op = "mql.Lint"
+	opt = append(opt[:len(opt):len(opt)], withEnumFoldCase())
+	if errs := Validate(query, model, opt...); len(errs) > 0 {
+		return nil, fmt.Errorf("%s: %w", op, errors.Join(errs...))
+	}
+	opts, err := getOpts(opt...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if strings.TrimSpace(query) == "" {
+		return nil, nil
+	}
+	if err := opts.withLimits.checkQuery(query); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	p := newParser(query)
+	p.configure(opts)
+	e, err := p.parse()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	fValidators, err := modelValidators(model, opt...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	diags, err := lintQuery(query, e, fValidators, opts)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return diags, nil
+}
+
+// lintQuery returns the diagnostics of the query and its parsed expr tree
+func lintQuery(query string, e Expr, fValidators map[string]validator, opts options) ([]Diagnostic, error) {
+	const op = "mql.lintQuery"
+	tokens, positions, err := tokenize(query)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	diags := lintParens(tokens, positions)
+	l := linter{opts: opts, validators: fValidators}
+	l.lintExpr(e)
+	walkExpr(e, func(e Expr) {
+		if c, ok := e.(*ComparisonExpr); ok {
+			l.lintEnum(c)
+		}
+	})
+	return append(diags, l.diags...), nil
+}
+
+// lintParens reports parens which wrap the whole query, wrap another pair of
+// parens, or wrap a single comparison.
+func lintParens(tokens []token, positions []int) []Diagnostic {
+	// we only care about the significant tokens
+	var tks []token
+	var pos []int
+	for i, tk := range tokens {
+		if tk.Type != whitespaceToken {
+			tks = append(tks, tk)
+			pos = append(pos, positions[i])
+		}
+	}
+	closing := make(map[int]int, len(tks)) // index of opening paren -> index of its closing paren
+	var open stack[int]
+	for i, tk := range tks {
+		switch tk.Type {
+		case startLogicalExprToken:
+			open.push(i)
+		case endLogicalExprToken:
+			if o, ok := open.pop(); ok {
+				closing[o] = i
+			}
+		}
+	}
+
+	var diags []Diagnostic
+	for o := range tks {
+		c, ok := closing[o]
+		if !ok {
+			continue
+		}
+		var msg string
+		switch {
+		case o == 0 && c == len(tks)-1:
+			msg = "parentheses around the entire query are redundant"
+		case tks[o+1].Type == startLogicalExprToken && closing[o+1] == c-1:
+			msg = "double parentheses are redundant"
+		case !hasLogicalOp(tks[o+1 : c]):
+			msg = "parentheses around a single comparison are redundant"
+		default:
+			continue
+		}
+		diags = append(diags, Diagnostic{Kind: RedundantParensDiagnostic, Message: msg, Pos: pos[o]})
+	}
+	return diags
+}
+
+// hasLogicalOp reports if the tokens contain a logical operator that's not
+// nested within parens
+func hasLogicalOp(tks []token) bool {
+	depth := 0
+	for _, tk := range tks {
+		switch tk.Type {
+		case startLogicalExprToken:
+			depth++
+		case endLogicalExprToken:
+			depth--
+		case andToken, orToken:
+			if depth == 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+type linter struct {
+	opts       options
+	validators map[string]validator
+	diags      []Diagnostic
+}
+
+// lintExpr will lint every chain of logical exprs using the same logical
+// operator (a and b and c) in the tree.
+func (l *linter) lintExpr(e Expr) {
+	le, ok := e.(*LogicalExpr)
+	if !ok {
+		return
+	}
+	var operands []Expr
+	chainOperands(le, le.LogicalOp, &operands)
+	var cmps []*ComparisonExpr
+	for _, o := range operands {
+		switch v := o.(type) {
+		case *ComparisonExpr:
+			cmps = append(cmps, v)
+		default:
+			l.lintExpr(v)
+		}
+	}
+	l.lintComparisons(le.LogicalOp, cmps)
+}
+
+// lintComparisons reports duplicates and contradictions within comparisons
+// that are combined with the same logical operator.
+func (l *linter) lintComparisons(lOp LogicalOp, cmps []*ComparisonExpr) {
+	for i, c := range cmps {
+		for _, prev := range cmps[:i] {
+			if l.column(prev) != l.column(c) {
+				continue
+			}
+			switch {
+			case prev.ComparisonOp == c.ComparisonOp && l.sameValue(prev, c):
+				l.diags = append(l.diags, Diagnostic{
+					Kind:    DuplicateConditionDiagnostic,
+					Message: fmt.Sprintf("duplicate condition %s", c.MQL()),
+					Pos:     c.pos,
+				})
+			case lOp == AndOp && prev.ComparisonOp == EqualOp && c.ComparisonOp == EqualOp && l.exactEqual(prev, c):
+				l.diags = append(l.diags, Diagnostic{
+					Kind:    AlwaysFalseDiagnostic,
+					Message: fmt.Sprintf("%s and %s can never both be true", prev.MQL(), c.MQL()),
+					Pos:     prev.pos,
+				})
+			case lOp == AndOp && isNegation(prev, c) && l.sameValue(prev, c):
+				l.diags = append(l.diags, Diagnostic{
+					Kind:    AlwaysFalseDiagnostic,
+					Message: fmt.Sprintf("%s and %s can never both be true", prev.MQL(), c.MQL()),
+					Pos:     prev.pos,
+				})
+			case lOp == AndOp && l.emptyRange(prev, c):
+				l.diags = append(l.diags, Diagnostic{
+					Kind:    AlwaysFalseDiagnostic,
+					Message: fmt.Sprintf("%s and %s can never both be true", prev.MQL(), c.MQL()),
+					Pos:     prev.pos,
+				})
+			case lOp == OrOp && isNegation(prev, c) && l.sameValue(prev, c):
+				l.diags = append(l.diags, Diagnostic{
+					Kind:    AlwaysTrueDiagnostic,
+					Message: fmt.Sprintf("%s or %s is always true", prev.MQL(), c.MQL()),
+					Pos:     prev.pos,
+				})
+			default:
+				continue
+			}
+			break
+		}
+	}
+}
+
+// lintEnum reports a comparison whose value doesn't match any of its column's
+// enum values (see WithEnum), but does match one of them when ignoring case.
+func (l *linter) lintEnum(c *ComparisonExpr) {
+	values, ok := l.opts.withEnums[l.column(c)]
+	if !ok || c.Value == nil || c.variable {
+		return
+	}
+	match := func(v, value string) bool { return v == value }
+	matchFold := strings.EqualFold
+	if c.ComparisonOp == ContainsOp {
+		match = strings.Contains
+		matchFold = func(v, value string) bool {
+			return strings.Contains(strings.ToLower(v), strings.ToLower(value))
+		}
+	}
+	for _, v := range values {
+		if match(v, *c.Value) {
+			return
+		}
+	}
+	for _, v := range values {
+		if matchFold(v, *c.Value) {
+			l.diags = append(l.diags, Diagnostic{
+				Kind:    EnumCaseMismatchDiagnostic,
+				Message: fmt.Sprintf("%s doesn't match the case of the enum value %q", c.MQL(), v),
+				Pos:     c.pos,
+			})
+			return
+		}
+	}
+}
+
+// caseSensitiveDialects are the dialects which compare strings using a case
+// sensitive collation by default (unlike mysql)
+var caseSensitiveDialects = []string{
+	PostgresDialect{}.Name(),
+	CockroachDialect{}.Name(),
+	SqliteDialect{}.Name(),
+	SpannerDialect{}.Name(),
+}
+
+// exactEqual reports if the = comparisons of the same column only match their
+// exact values, so the column can't be equal to both of their different
+// values.  A date (which matches the whole day) or relative time may match
+// the same times as another time, decimals are equal when their numeric
+// values are (1.0 and 1.00), and the values of registered field types (see
+// RegisterFieldType) can't be compared by their text.  Strings are only
+// compared exactly when the dialect's collation is known to be case sensitive
+// and the column doesn't use a collation (see WithCollation) or ignore accents
+// (see WithAccentInsensitive), since "alice" can match "Alice" or "alicé"
+// otherwise.
+func (l *linter) exactEqual(a, b *ComparisonExpr) bool {
+	v, ok := l.validators[l.column(a)]
+	if !ok || a.Value == nil || b.Value == nil {
+		return false
+	}
+	if _, registered := lookupFieldType(v.typ); registered {
+		return false
+	}
+	switch v.typ {
+	case "time":
+		for _, s := range []string{*a.Value, *b.Value} {
+			if isDateLiteral(s) || isRelativeTimeLiteral(s) {
+				return false
+			}
+		}
+		return true
+	case "decimal":
+		av, aErr := v.fn(*a.Value)
+		bv, bErr := v.fn(*b.Value)
+		if aErr != nil || bErr != nil {
+			return false
+		}
+		aRat, aOk := ratValue(av)
+		bRat, bOk := ratValue(bv)
+		return aOk && bOk && aRat.Cmp(bRat) != 0
+	case "default":
+	default:
+		return true
+	}
+	if !slices.Contains(caseSensitiveDialects, dialectOf(l.opts).Name()) {
+		return false
+	}
+	field := l.column(a)
+	if v.field.Name != "" {
+		field = strings.ToLower(strings.ReplaceAll(v.field.Name, "_", ""))
+	}
+	if _, ok := l.opts.withCollationColumns[field]; l.opts.withCollation != "" && (ok || l.opts.withCollationColumns == nil) {
+		return false
+	}
+	if _, ok := l.opts.withAccentInsensitive[field]; ok || l.opts.withAllAccentInsensitive {
+		return false
+	}
+	return true
+}
+
+// column returns the normalized model column for the comparison
+func (l *linter) column(c *ComparisonExpr) string {
+	col := strings.ToLower(c.Column)
+	if n, ok := l.opts.withColumnMap[col]; ok {
+		col = n
+	}
+	return strings.ToLower(strings.ReplaceAll(col, "_", ""))
+}
+
+// sameValue reports if the comparisons have the same value, once they've been
+// validated for the column (so 1.0 and 1 are the same float)
+func (l *linter) sameValue(a, b *ComparisonExpr) bool {
+	if a.variable || b.variable {
+		// variables are resolved once the query is converted
+		return a.variable && b.variable && *a.Value == *b.Value
+	}
+	v, ok := l.validators[l.column(a)]
+	if !ok || v.fn == nil {
+		return *a.Value == *b.Value
+	}
+	av, aErr := v.fn(*a.Value)
+	bv, bErr := v.fn(*b.Value)
+	if aErr != nil || bErr != nil {
+		return *a.Value == *b.Value
+	}
+	return reflect.DeepEqual(av, bv)
+}
+
+// rangeBound is the lower or upper bound of the values matched by a comparison
+type rangeBound struct {
+	value     any
+	inclusive bool
+}
+
+// emptyRange reports if the comparisons of the same column bound it to a range
+// which doesn't contain any value (age>10 and age<5).  Only int, float,
+// duration and time columns are compared, and times are only compared when
+// neither value is a date (a date matches the whole day).
+func (l *linter) emptyRange(a, b *ComparisonExpr) bool {
+	aLower, aUpper, ok := l.rangeBounds(a)
+	if !ok {
+		return false
+	}
+	bLower, bUpper, ok := l.rangeBounds(b)
+	if !ok {
+		return false
+	}
+	return isEmptyRange(aLower, bUpper) || isEmptyRange(bLower, aUpper)
+}
+
+// rangeBounds returns the bounds of the values matched by the comparison, where
+// a nil bound is unbounded.
+func (l *linter) rangeBounds(c *ComparisonExpr) (lower, upper *rangeBound, ok bool) {
+	v, found := l.validators[l.column(c)]
+	switch {
+	case !found || v.fn == nil || c.Value == nil || c.variable:
+		return nil, nil, false
+	case v.typ == "time" && isDateLiteral(*c.Value):
+		return nil, nil, false
+	case v.typ != "int" && v.typ != "float" && v.typ != "duration" && v.typ != "time":
+		return nil, nil, false
+	}
+	val, err := v.fn(*c.Value)
+	if err != nil {
+		return nil, nil, false
+	}
+	switch c.ComparisonOp {
+	case EqualOp:
+		return &rangeBound{val, true}, &rangeBound{val, true}, true
+	case GreaterThanOp:
+		return &rangeBound{val, false}, nil, true
+	case GreaterThanOrEqualOp:
+		return &rangeBound{val, true}, nil, true
+	case LessThanOp:
+		return nil, &rangeBound{val, false}, true
+	case LessThanOrEqualOp:
+		return nil, &rangeBound{val, true}, true
+	default:
+		return nil, nil, false
+	}
+}
+
+// isEmptyRange reports if there's no value between the lower and upper bounds
+func isEmptyRange(lower, upper *rangeBound) bool {
+	if lower == nil || upper == nil {
+		return false
+	}
+	cmp, ok := compareValues(lower.value, upper.value)
+	switch {
+	case !ok:
+		return false
+	case cmp == 0:
+		return !lower.inclusive || !upper.inclusive
+	default:
+		return cmp > 0
+	}
+}
+
+// compareValues compares validated values of the same type and reports false
+// when they can't be compared
+func compareValues(a, b any) (int, bool) {
+	switch a := a.(type) {
+	case int, int64, uint64:
+		// ints are validated as an int (or as an int64/uint64 when they don't
+		// fit one or using WithTypedArgs) and durations are validated as an
+		// int64 number of units
+		return compareInts(a, b)
+	case float64:
+		if b, ok := b.(float64); ok {
+			return cmpOrdered(a, b), true
+		}
+	case time.Time:
+		if b, ok := b.(time.Time); ok {
+			return a.Compare(b), true
+		}
+	}
+	return 0, false
+}
+
+// cmpOrdered returns -1, 0 or +1 depending on whether a is less than, equal to
+// or greater than b
+func cmpOrdered[T int | int64 | float64](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// isNegation reports if one comparison uses = and the other uses !=
+func isNegation(a, b *ComparisonExpr) bool {
+	return (a.ComparisonOp == EqualOp && b.ComparisonOp == NotEqualOp) ||
+		(a.ComparisonOp == NotEqualOp && b.ComparisonOp == EqualOp)
+}
+
+// chainOperands collects the operands of a chain of logical exprs which all use
+// the same logical operator.
+func chainOperands(e Expr, lOp LogicalOp, operands *[]Expr) {
+	if le, ok := e.(*LogicalExpr); ok && le.LogicalOp == lOp {
+		chainOperands(le.LeftExpr, lOp, operands)
+		chainOperands(le.RightExpr, lOp, operands)
+		return
+	}
+	*operands = append(*operands, e)
+}
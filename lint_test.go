@@ -0,0 +1,217 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql_test
+
+import (
+	"testing"
+
+	"github.com/hashicorp/mql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLint(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name            string
+		query           string
+		opts            []mql.Option
+		want            []mql.Diagnostic
+		wantErrIs       error
+		wantErrContains string
+	}{
+		{
+			name:  "no-problems",
+			query: `name="alice" and (age > 21 or age < 10)`,
+		},
+		{
+			name:  "empty-query",
+			query: " ",
+			opts:  []mql.Option{mql.WithAllowEmptyQuery()},
+		},
+		{
+			name:  "parens-around-query",
+			query: `(name="alice" or name="bob")`,
+			want: []mql.Diagnostic{
+				{Kind: mql.RedundantParensDiagnostic, Message: "parentheses around the entire query are redundant", Pos: 0},
+			},
+		},
+		{
+			name:  "double-parens",
+			query: `((name="alice" or name="bob")) and age > 21`,
+			want: []mql.Diagnostic{
+				{Kind: mql.RedundantParensDiagnostic, Message: "double parentheses are redundant", Pos: 0},
+			},
+		},
+		{
+			name:  "parens-around-comparison",
+			query: `(name="alice") or age > 21`,
+			want: []mql.Diagnostic{
+				{Kind: mql.RedundantParensDiagnostic, Message: "parentheses around a single comparison are redundant", Pos: 0},
+			},
+		},
+		{
+			name:  "duplicate-condition",
+			query: `name="alice" or age > 21 or NAME = 'alice'`,
+			want: []mql.Diagnostic{
+				{Kind: mql.DuplicateConditionDiagnostic, Message: `duplicate condition NAME="alice"`, Pos: 28},
+			},
+		},
+		{
+			name:  "duplicate-condition-with-column-map",
+			query: `nickname="alice" and name="alice"`,
+			opts:  []mql.Option{mql.WithColumnMap(map[string]string{"nickname": "name"})},
+			want: []mql.Diagnostic{
+				{Kind: mql.DuplicateConditionDiagnostic, Message: `duplicate condition name="alice"`, Pos: 21},
+			},
+		},
+		{
+			name:  "duplicate-condition-validated-value",
+			query: `length=1 and length=1.0`,
+			want: []mql.Diagnostic{
+				{Kind: mql.DuplicateConditionDiagnostic, Message: `duplicate condition length=1.0`, Pos: 13},
+			},
+		},
+		{
+			name:  "always-false-different-values",
+			query: `name="alice" and name="bob"`,
+			want: []mql.Diagnostic{
+				{Kind: mql.AlwaysFalseDiagnostic, Message: `name="alice" and name="bob" can never both be true`, Pos: 0},
+			},
+		},
+		{
+			name:  "different-values-case-insensitive-dialect",
+			query: `name="alice" and name="Alice"`,
+			opts:  []mql.Option{mql.WithDialect(mql.MySqlDialect{})},
+		},
+		{
+			name:  "different-values-with-collation",
+			query: `name="alice" and name="Alice"`,
+			opts:  []mql.Option{mql.WithCollation(`"und-x-icu"`, "name")},
+		},
+		{
+			name:  "different-values-accent-insensitive",
+			query: `name="alice" and name="alicé"`,
+			opts:  []mql.Option{mql.WithAccentInsensitive()},
+		},
+		{
+			name:  "always-false-different-ints-case-insensitive-dialect",
+			query: `age=21 and age=22`,
+			opts:  []mql.Option{mql.WithDialect(mql.MySqlDialect{})},
+			want: []mql.Diagnostic{
+				{Kind: mql.AlwaysFalseDiagnostic, Message: `age=21 and age=22 can never both be true`, Pos: 0},
+			},
+		},
+		{
+			name:  "date-and-time-of-the-day",
+			query: `created_at="2023-01-02" and created_at="2023-01-02T10:00:00Z"`,
+		},
+		{
+			name:  "relative-times",
+			query: `created_at="today" and created_at="now"`,
+		},
+		{
+			name:  "same-decimal-value",
+			query: `member_number=1.0 and member_number=1.00`,
+			opts:  []mql.Option{mql.WithDecimalColumns("member_number")},
+		},
+		{
+			name:  "always-false-different-times",
+			query: `created_at="2023-01-02T10:00:00Z" and created_at="2023-01-02T11:00:00Z"`,
+			want: []mql.Diagnostic{
+				{Kind: mql.AlwaysFalseDiagnostic, Message: `created_at="2023-01-02T10:00:00Z" and created_at="2023-01-02T11:00:00Z" can never both be true`, Pos: 0},
+			},
+		},
+		{
+			name:  "always-false-different-decimals",
+			query: `member_number=1.0 and member_number=1.5`,
+			opts:  []mql.Option{mql.WithDecimalColumns("member_number")},
+			want: []mql.Diagnostic{
+				{Kind: mql.AlwaysFalseDiagnostic, Message: `member_number=1.0 and member_number=1.5 can never both be true`, Pos: 0},
+			},
+		},
+		{
+			name:  "always-false-negation",
+			query: `age=21 and (name="alice" or name="bob") and age!=21`,
+			want: []mql.Diagnostic{
+				{Kind: mql.AlwaysFalseDiagnostic, Message: `age=21 and age!=21 can never both be true`, Pos: 0},
+			},
+		},
+		{
+			name:  "always-false-range",
+			query: `age > 10 and name="alice" and age < 5`,
+			want: []mql.Diagnostic{
+				{Kind: mql.AlwaysFalseDiagnostic, Message: `age>10 and age<5 can never both be true`, Pos: 0},
+			},
+		},
+		{
+			name:  "range-with-equal-bounds",
+			query: `age >= 10 and age <= 10`,
+		},
+		{
+			name:  "always-true",
+			query: `name="alice" or name!="alice"`,
+			want: []mql.Diagnostic{
+				{Kind: mql.AlwaysTrueDiagnostic, Message: `name="alice" or name!="alice" is always true`, Pos: 0},
+			},
+		},
+		{
+			name:  "different-values-with-or",
+			query: `name="alice" or name="bob"`,
+		},
+		{
+			name:  "enum-case-mismatch",
+			query: `name="Alice" and age > 21`,
+			opts:  []mql.Option{mql.WithEnum("name", []string{"alice", "bob"})},
+			want: []mql.Diagnostic{
+				{Kind: mql.EnumCaseMismatchDiagnostic, Message: `name="Alice" doesn't match the case of the enum value "alice"`, Pos: 0},
+			},
+		},
+		{
+			name:  "enum-value",
+			query: `name="alice" or name="bob"`,
+			opts:  []mql.Option{mql.WithEnum("name", []string{"alice", "bob"})},
+		},
+		{
+			name:            "err-invalid-enum-value",
+			query:           `name="carol"`,
+			opts:            []mql.Option{mql.WithEnum("name", []string{"alice", "bob"})},
+			wantErrIs:       mql.ErrInvalidEnumValue,
+			wantErrContains: `invalid enum value "carol" for column "name" (expected one of: alice, bob)`,
+		},
+		{
+			name:            "err-invalid-column",
+			query:           `nickname="alice" and name="alice"`,
+			wantErrIs:       mql.ErrInvalidColumn,
+			wantErrContains: `invalid column "nickname"`,
+		},
+		{
+			name:            "err-syntax",
+			query:           `(name="alice"`,
+			wantErrIs:       mql.ErrMissingClosingParen,
+			wantErrContains: "missing closing paren",
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			assert, require := assert.New(t), require.New(t)
+			got, err := mql.Lint(tc.query, testModel{}, tc.opts...)
+			if tc.wantErrContains != "" {
+				require.Error(err)
+				assert.Empty(got)
+				assert.ErrorIs(err, tc.wantErrIs)
+				assert.ErrorContains(err, tc.wantErrContains)
+				return
+			}
+			require.NoError(err)
+			assert.Equal(tc.want, got)
+		})
+	}
+	t.Run("registered-field-type", func(t *testing.T) {
+		got, err := mql.Lint(`id="acct_1" and id="acct_2"`, ledgerModel{})
+		require.NoError(t, err)
+		assert.Empty(t, got)
+	})
+}
@@ -0,0 +1,168 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// The query string params used by ParseQueryString
+const (
+	FilterParam   = "filter"
+	SortParam     = "sort"
+	PageSizeParam = "page_size"
+	OffsetParam   = "offset"
+	CursorParam   = "cursor"
+)
+
+// ListRequest contains the user provided params of a list request.  Every
+// param is optional.
+type ListRequest struct {
+	// Filter is a mql query (see Parse)
+	Filter string
+	// Sort is a list of sort keys (see ParseOrderBy)
+	Sort string
+	// PageSize is the limit (see ParsePage)
+	PageSize string
+	// Offset is the number of rows to skip (see ParsePage)
+	Offset string
+	// Cursor is a keyset cursor (see KeysetCondition) and it can't be used
+	// with an Offset
+	Cursor string
+}
+
+// ListQuery contains the where clause, order by clause and page of a list
+// request
+type ListQuery struct {
+	// Where is the where clause for the filter and cursor.  Its condition is
+	// "1=1" when there isn't a filter or cursor.
+	Where *WhereClause
+	// OrderBy is the order by clause, its clause is empty when there isn't a
+	// sort.
+	OrderBy *OrderByClause
+	// Page is the limit and offset
+	Page *Page
+}
+
+// ParseQueryString will parse the query string of a list request
+// (filter=...&sort=...&page_size=...&offset=... or cursor=...) and use the
+// database model to validate it.  Each param can only be used once.  See
+// ParseListRequest.  Supported options: the same options as ParseListRequest.
+func ParseQueryString(rawQuery string, model any, opt ...Option) (*ListQuery, error) {
+	const op = "mql.ParseQueryString"
+	values, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w: %w", op, ErrInvalidParameter, err)
+	}
+	var req ListRequest
+	for param, field := range map[string]*string{
+		FilterParam:   &req.Filter,
+		SortParam:     &req.Sort,
+		PageSizeParam: &req.PageSize,
+		OffsetParam:   &req.Offset,
+		CursorParam:   &req.Cursor,
+	} {
+		switch v := values[param]; {
+		case len(v) > 1:
+			return nil, fmt.Errorf("%s: param %q can only be used once: %w", op, param, ErrInvalidParameter)
+		case len(v) == 1:
+			*field = v[0]
+		}
+	}
+	q, err := ParseListRequest(req, model, opt...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return q, nil
+}
+
+// ParseListRequest will use the database model to validate the list request
+// and return its where clause, order by clause and page.  When there's a
+// cursor, its keyset condition is combined with the filter's condition (and)
+// before any placeholder options are applied, so the args are numbered/named
+// across both.  Supported options: the same options as Parse, ParseOrderBy
+// and ParsePage.
+func ParseListRequest(req ListRequest, model any, opt ...Option) (*ListQuery, error) {
+	const op = "mql.ParseListRequest"
+	opts, err := getOpts(opt...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	switch {
+	case isNil(model):
+		return nil, fmt.Errorf("%s: missing model: %w", op, ErrInvalidParameter)
+	case req.Cursor != "" && strings.TrimSpace(req.Offset) != "":
+		return nil, fmt.Errorf("%s: a cursor can't be used with an offset: %w", op, ErrInvalidParameter)
+	case req.Cursor != "" && strings.TrimSpace(req.Sort) == "":
+		return nil, fmt.Errorf("%s: a cursor requires a sort: %w", op, ErrInvalidParameter)
+	}
+	q := &ListQuery{OrderBy: &OrderByClause{}}
+	if q.Page, err = ParsePage(req.PageSize, req.Offset, opt...); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if strings.TrimSpace(req.Sort) != "" {
+		if q.OrderBy, err = ParseOrderBy(req.Sort, model, opt...); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	var conditions []*WhereClause
+	var filter Expr
+	if strings.TrimSpace(req.Filter) != "" {
+		p := newParser(req.Filter)
+		p.configure(opts)
+		expr, err := p.parse()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		opt, err := withModelTable(model, opt)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		fValidators, err := modelValidators(model, opt...)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		if opts.withOptimize {
+			expr = optimizeExpr(expr, fValidators, opts)
+		}
+		w, err := exprToWhereClause(expr, fValidators, opt...)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		conditions = append(conditions, w)
+		filter = expr
+	}
+	if req.Cursor != "" {
+		w, err := keysetWhereClause(q.OrderBy, req.Cursor, model, opt...)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		conditions = append(conditions, w)
+	}
+	switch len(conditions) {
+	case 0:
+		if q.Where, err = softDeleteCondition(&WhereClause{Condition: matchAllCondition}, nil, model, opts); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		return q, nil
+	case 1:
+		q.Where = conditions[0]
+	default:
+		q.Where = &WhereClause{
+			Condition:  fmt.Sprintf("(%s and %s)", conditions[0].Condition, conditions[1].Condition),
+			Args:       append(conditions[0].Args, conditions[1].Args...),
+			argColumns: append(conditions[0].argColumns, conditions[1].argColumns...),
+		}
+	}
+	if q.Where, err = applyPlaceholders(q.Where, opts); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if q.Where, err = softDeleteCondition(q.Where, filter, model, opts); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return q, nil
+}
@@ -0,0 +1,170 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql_test
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/hashicorp/mql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseQueryString(t *testing.T) {
+	t.Parallel()
+	cursor, err := mql.EncodeCursor("bob", 7)
+	require.NoError(t, err)
+	tests := []struct {
+		name            string
+		query           string
+		opts            []mql.Option
+		want            *mql.ListQuery
+		wantErrIs       error
+		wantErrContains string
+	}{
+		{
+			name:  "empty",
+			query: "",
+			want: &mql.ListQuery{
+				Where:   &mql.WhereClause{Condition: "1=1"},
+				OrderBy: &mql.OrderByClause{},
+				Page:    &mql.Page{Limit: mql.DefaultPageLimit},
+			},
+		},
+		{
+			name: "filter-sort-page",
+			query: url.Values{
+				"filter":    {`name="alice" and age > 21`},
+				"sort":      {"-created_at"},
+				"page_size": {"10"},
+				"offset":    {"20"},
+			}.Encode(),
+			want: &mql.ListQuery{
+				Where: &mql.WhereClause{Condition: "(name=? and age>?)", Args: []any{"alice", 21}},
+				OrderBy: &mql.OrderByClause{
+					Clause:   "created_at desc",
+					SortKeys: []mql.SortKey{{Column: "created_at", Direction: mql.DescendingSort}},
+				},
+				Page: &mql.Page{Limit: 10, Offset: 20},
+			},
+		},
+		{
+			name: "filter-and-cursor-WithPgPlaceholders",
+			query: url.Values{
+				"filter": {`age > 21`},
+				"sort":   {"name desc, id"},
+				"cursor": {cursor},
+			}.Encode(),
+			opts: []mql.Option{mql.WithPgPlaceholders()},
+			want: &mql.ListQuery{
+				Where: &mql.WhereClause{
+					Condition: "(age>$1 and (name<$2 or (name=$3 and id>$4)))",
+					Args:      []any{21, "bob", "bob", 7},
+				},
+				OrderBy: &mql.OrderByClause{
+					Clause: "name desc, id asc",
+					SortKeys: []mql.SortKey{
+						{Column: "name", Direction: mql.DescendingSort},
+						{Column: "id", Direction: mql.AscendingSort},
+					},
+				},
+				Page: &mql.Page{Limit: mql.DefaultPageLimit},
+			},
+		},
+		{
+			name: "cursor-WithNamedParams",
+			query: url.Values{
+				"sort":   {"name desc, id"},
+				"cursor": {cursor},
+			}.Encode(),
+			opts: []mql.Option{mql.WithNamedParams(":")},
+			want: &mql.ListQuery{
+				Where: &mql.WhereClause{
+					Condition: "(name<:name_1 or (name=:name_2 and id>:id_1))",
+					NamedArgs: map[string]any{"name_1": "bob", "name_2": "bob", "id_1": 7},
+				},
+				OrderBy: &mql.OrderByClause{
+					Clause: "name desc, id asc",
+					SortKeys: []mql.SortKey{
+						{Column: "name", Direction: mql.DescendingSort},
+						{Column: "id", Direction: mql.AscendingSort},
+					},
+				},
+				Page: &mql.Page{Limit: mql.DefaultPageLimit},
+			},
+		},
+		{
+			name:            "err-invalid-filter",
+			query:           url.Values{"filter": {`nickname="alice"`}}.Encode(),
+			wantErrIs:       mql.ErrInvalidColumn,
+			wantErrContains: `invalid column "nickname"`,
+		},
+		{
+			name:            "err-invalid-sort",
+			query:           "sort=nickname",
+			wantErrIs:       mql.ErrInvalidColumn,
+			wantErrContains: `invalid column "nickname"`,
+		},
+		{
+			name:            "err-invalid-page-size",
+			query:           "page_size=5000",
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: "limit 5000 is greater than the max limit of 1000",
+		},
+		{
+			name:            "err-repeated-param",
+			query:           "sort=name&sort=id",
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: `param "sort" can only be used once`,
+		},
+		{
+			name:            "err-cursor-and-offset",
+			query:           "sort=name&offset=10&cursor=" + cursor,
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: "a cursor can't be used with an offset",
+		},
+		{
+			name:            "err-cursor-without-sort",
+			query:           "cursor=" + cursor,
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: "a cursor requires a sort",
+		},
+		{
+			name:            "err-invalid-cursor",
+			query:           "sort=name,id&cursor=abc",
+			wantErrIs:       mql.ErrInvalidCursor,
+			wantErrContains: "invalid cursor",
+		},
+		{
+			name:            "err-invalid-query-string",
+			query:           "filter=%zz",
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: "invalid URL escape",
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert, require := assert.New(t), require.New(t)
+			got, err := mql.ParseQueryString(tc.query, testModel{}, tc.opts...)
+			if tc.wantErrContains != "" {
+				require.Error(err)
+				assert.Empty(got)
+				assert.ErrorIs(err, tc.wantErrIs)
+				assert.ErrorContains(err, tc.wantErrContains)
+				return
+			}
+			require.NoError(err)
+			assert.Equal(tc.want, got)
+		})
+	}
+	t.Run("err-missing-model", func(t *testing.T) {
+		_, err := mql.ParseListRequest(mql.ListRequest{}, nil)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, mql.ErrInvalidParameter)
+		assert.ErrorContains(t, err, "missing model")
+	})
+}
@@ -0,0 +1,109 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/exp/slices"
+)
+
+// WithMacro registers a named query (ie: WithMacro("recent",
+// `created_at>"now-7d"`)) which can be used as an operand of other queries
+// (ie: `recent and status="open"`), so reusable filters don't have to be
+// repeated.  A macro is expanded as a group when the query is parsed, so
+// `recent and status="open"` is parsed as (created_at>"now-7d" and
+// status="open") and its comparisons are validated like any other
+// comparison.  Macros can use other macros, but a macro which is used by
+// itself (directly or not) is an ErrInvalidParameter.  Names are case
+// insensitive and they must be an identifier other than a keyword (and, or,
+// any).  Macros are provided by the application, so they must never be
+// provided by users.
+func WithMacro(name, query string) Option {
+	const op = "mql.WithMacro"
+	return func(o *options) error {
+		switch {
+		case name == "":
+			return fmt.Errorf("%s: missing name: %w", op, ErrInvalidParameter)
+		case !isIdentifier(name):
+			return fmt.Errorf("%s: name %q isn't an identifier: %w", op, name, ErrInvalidParameter)
+		case isKeyword(name):
+			return fmt.Errorf("%s: name %q is a keyword: %w", op, name, ErrInvalidParameter)
+		case strings.TrimSpace(query) == "":
+			return fmt.Errorf("%s: missing query for macro %q: %w", op, name, ErrInvalidParameter)
+		}
+		if o.withMacros == nil {
+			o.withMacros = make(map[string]string)
+		}
+		o.withMacros[strings.ToLower(name)] = query
+		return nil
+	}
+}
+
+// isKeyword reports if s is a keyword which can't be the name of a macro
+func isKeyword(s string) bool {
+	switch strings.ToLower(s) {
+	case "and", "or", "any":
+		return true
+	default:
+		return false
+	}
+}
+
+// isMacro reports if the incomplete comparison is a reference to a macro
+// (see WithMacro) or to a saved filter (see WithFilterResolver), which is an
+// unquoted column without an operator
+func (p *parser) isMacro(c *ComparisonExpr) bool {
+	if c.quotedColumn || c.ComparisonOp != "" {
+		return false
+	}
+	if name, ok := strings.CutPrefix(c.Column, filterPrefix); ok {
+		return name != "" && isIdentifier(name)
+	}
+	_, ok := p.macros[strings.ToLower(c.Column)]
+	return ok
+}
+
+// expandMacro parses the query of the macro (or saved filter) referenced by
+// the comparison's column.  The tokens of the macro count towards the limits
+// of the query and the comparisons of the macro are positioned at the
+// reference, since that's where they are in the query.
+func (p *parser) expandMacro(c *ComparisonExpr) (Expr, error) {
+	const op = "mql.(parser).expandMacro"
+	name := strings.ToLower(c.Column)
+	if strings.HasPrefix(c.Column, filterPrefix) {
+		// saved filters are named by the application, so their names are
+		// case sensitive
+		name = c.Column
+	}
+	if slices.Contains(p.expanding, name) {
+		return nil, fmt.Errorf("%s: %w: macro %q is used by itself: %s", op, ErrInvalidParameter, c.Column, strings.Join(append(p.expanding, name), " -> "))
+	}
+	query, ok := p.macros[name]
+	if !ok {
+		var err error
+		if query, err = p.resolveFilter(name); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+	m := newParser(query)
+	m.ctx, m.limits, m.tokens, m.macros, m.filterResolver = p.ctx, p.limits, p.tokens, p.macros, p.filterResolver
+	m.logger, m.l.logger = p.logger, p.l.logger
+	m.expanding = append(slices.Clone(p.expanding), name)
+	e, err := m.parseExpr()
+	p.tokens = m.tokens
+	if err != nil {
+		return nil, fmt.Errorf("%s: macro %q: %w", op, c.Column, err)
+	}
+	walkExpr(e, func(e Expr) {
+		if cmp, ok := e.(*ComparisonExpr); ok {
+			cmp.pos = c.pos
+		}
+	})
+	if p.logger != nil {
+		p.debug("expanded macro", "macro", name, "expr", e.MQL())
+	}
+	return e, nil
+}
@@ -0,0 +1,122 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql_test
+
+import (
+	"testing"
+
+	"github.com/hashicorp/mql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse_WithMacro(t *testing.T) {
+	t.Parallel()
+	macros := []mql.Option{
+		mql.WithMacro("adult", `age>=18`),
+		mql.WithMacro("Named", `name="alice" or name="bob"`),
+		mql.WithMacro("adult_named", `adult and named`),
+		mql.WithMacro("loop", `name="alice" and loop_again`),
+		mql.WithMacro("loop_again", `loop`),
+		mql.WithMacro("invalid", `name=`),
+	}
+	tests := []struct {
+		name      string
+		query     string
+		opts      []mql.Option
+		want      *mql.WhereClause
+		wantErrIs error
+	}{
+		{
+			name:  "macro",
+			query: `adult`,
+			want:  &mql.WhereClause{Condition: "age>=?", Args: []any{18}},
+		},
+		{
+			name:  "macro-and-comparison",
+			query: `named and age<65`,
+			want:  &mql.WhereClause{Condition: "((name=? or name=?) and age<?)", Args: []any{"alice", "bob", 65}},
+		},
+		{
+			name:  "comparison-or-macro",
+			query: `email="eve@example.com" or NAMED`,
+			want:  &mql.WhereClause{Condition: "(email=? or (name=? or name=?))", Args: []any{"eve@example.com", "alice", "bob"}},
+		},
+		{
+			name:  "grouped",
+			query: `(named ) and (adult)`,
+			want:  &mql.WhereClause{Condition: "((name=? or name=?) and age>=?)", Args: []any{"alice", "bob", 18}},
+		},
+		{
+			name:  "nested-macros",
+			query: `adult_named`,
+			want:  &mql.WhereClause{Condition: "(age>=? and (name=? or name=?))", Args: []any{18, "alice", "bob"}},
+		},
+		{
+			name:  "placeholders",
+			query: `name="eve" or adult`,
+			opts:  []mql.Option{mql.WithPgPlaceholders()},
+			want:  &mql.WhereClause{Condition: "(name=$1 or age>=$2)", Args: []any{"eve", 18}},
+		},
+		{
+			name:      "err-cycle",
+			query:     `loop`,
+			wantErrIs: mql.ErrInvalidParameter,
+		},
+		{
+			name:      "err-invalid-macro",
+			query:     `adult and invalid`,
+			wantErrIs: mql.ErrMissingComparisonValue,
+		},
+		{
+			name:      "err-quoted-macro",
+			query:     `"adult"`,
+			wantErrIs: mql.ErrMissingComparisonOp,
+		},
+		{
+			name:      "err-unknown-macro",
+			query:     `adults and age<65`,
+			wantErrIs: mql.ErrInvalidComparisonOp,
+		},
+		{
+			name:      "err-too-many-tokens",
+			query:     `adult_named`,
+			opts:      []mql.Option{mql.WithMaxTokens(5)},
+			wantErrIs: mql.ErrTooManyTokens,
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert, require := assert.New(t), require.New(t)
+			got, err := mql.Parse(tc.query, testModel{}, append(macros, tc.opts...)...)
+			if tc.wantErrIs != nil {
+				require.Error(err)
+				assert.ErrorIs(err, tc.wantErrIs)
+				assert.Nil(got)
+				return
+			}
+			require.NoError(err)
+			assert.Equal(tc.want, got)
+		})
+	}
+	t.Run("expr", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		e, err := mql.ParseExpr(`named and age<65`, macros...)
+		require.NoError(err)
+		assert.Equal(`name="alice" or name="bob" and age<65`, e.MQL())
+	})
+	t.Run("err-options", func(t *testing.T) {
+		for _, o := range []mql.Option{
+			mql.WithMacro("", `age>=18`),
+			mql.WithMacro("not adult", `age>=18`),
+			mql.WithMacro("AND", `age>=18`),
+			mql.WithMacro("adult", " "),
+		} {
+			_, err := mql.Parse(`age>1`, testModel{}, o)
+			assert.ErrorIs(t, err, mql.ErrInvalidParameter)
+		}
+	})
+}
@@ -0,0 +1,221 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// placeholderStyle is the style of the placeholders of a where clause
+type placeholderStyle int
+
+const (
+	noPlaceholders       placeholderStyle = iota // no args (ie: WithInlineValues)
+	questionPlaceholders                         // ? (the default)
+	pgPlaceholders                               // $1 (WithPgPlaceholders)
+	sqlNamedPlaceholders                         // @p1 (WithSqlNamedArgs)
+	atPlaceholders                               // @p1 with positional args (SpannerDialect)
+	namedPlaceholders                            // :name_1 (WithNamedParams)
+)
+
+var (
+	pgPlaceholderRegexp       = regexp.MustCompile(`\$(\d+)`)
+	sqlNamedPlaceholderRegexp = regexp.MustCompile(`@p(\d+)\b`)
+	namedParamSuffixRegexp    = regexp.MustCompile(`_\d+$`)
+)
+
+// And will combine the where clauses with the and logical operator, so
+// mandatory conditions (ie: tenant_id=?) can be added to a parsed query.  See
+// combineWhereClauses for how placeholders and args are combined.
+func And(clauses ...*WhereClause) (*WhereClause, error) {
+	const op = "mql.And"
+	w, err := combineWhereClauses(AndOp, clauses)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return w, nil
+}
+
+// Or will combine the where clauses with the or logical operator.  See
+// combineWhereClauses for how placeholders and args are combined.
+func Or(clauses ...*WhereClause) (*WhereClause, error) {
+	const op = "mql.Or"
+	w, err := combineWhereClauses(OrOp, clauses)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return w, nil
+}
+
+// combineWhereClauses will combine the where clauses using the logical
+// operator.  Every clause is grouped by parens and they must use the same
+// placeholders (clauses without any args can be combined with any clause):
+// args of ? placeholders are concatenated, $N (WithPgPlaceholders) and @pN
+// (WithSqlNamedArgs and SpannerDialect) placeholders are renumbered, and named params
+// (WithNamedParams) are renamed when their name is used by a previous clause
+// (ie: the second :tenant_id_1 is renamed :tenant_id_2).  Nil clauses are
+// ignored and so are clauses which match everything (see WithAllowEmptyQuery)
+// when using and, while they match everything when using or.  The clauses are
+// not modified.
+func combineWhereClauses(logicalOp LogicalOp, clauses []*WhereClause) (*WhereClause, error) {
+	const op = "mql.combineWhereClauses"
+	var (
+		filtered    []*WhereClause
+		style       = noPlaceholders
+		hasMatchAll bool
+	)
+	for _, c := range clauses {
+		switch {
+		case c == nil:
+			continue
+		case c.Condition == "":
+			return nil, fmt.Errorf("%s: missing condition: %w", op, ErrInvalidParameter)
+		case c.Condition == matchAllCondition && len(c.Args) == 0:
+			if logicalOp == OrOp {
+				return &WhereClause{Condition: matchAllCondition}, nil
+			}
+			hasMatchAll = true
+			continue
+		}
+		s := placeholderStyleOf(c)
+		switch {
+		case s == noPlaceholders:
+		case style == noPlaceholders:
+			style = s
+		case s != style:
+			return nil, fmt.Errorf("%s: clauses with different placeholders can't be combined: %w", op, ErrInvalidParameter)
+		}
+		filtered = append(filtered, c)
+	}
+	switch len(filtered) {
+	case 0:
+		if hasMatchAll {
+			return &WhereClause{Condition: matchAllCondition}, nil
+		}
+		return nil, fmt.Errorf("%s: missing where clauses: %w", op, ErrInvalidParameter)
+	case 1:
+		c := *filtered[0]
+		c.Args = append([]any(nil), c.Args...)
+		return &c, nil
+	}
+
+	var (
+		conditions = make([]string, 0, len(filtered))
+		combined   = &WhereClause{}
+	)
+	for _, c := range filtered {
+		condition := c.Condition
+		switch style {
+		case pgPlaceholders:
+			offset := len(combined.Args)
+			condition = pgPlaceholderRegexp.ReplaceAllStringFunc(condition, func(p string) string {
+				n, _ := strconv.Atoi(p[1:])
+				return fmt.Sprintf("$%d", n+offset)
+			})
+			combined.Args = append(combined.Args, c.Args...)
+		case sqlNamedPlaceholders:
+			offset := len(combined.Args)
+			condition = sqlNamedPlaceholderRegexp.ReplaceAllStringFunc(condition, func(p string) string {
+				n, _ := strconv.Atoi(p[2:])
+				return "@" + sqlArgName(n+offset-1)
+			})
+			for _, a := range c.Args {
+				named, ok := a.(sql.NamedArg)
+				if !ok {
+					return nil, fmt.Errorf("%s: arg %v is not a sql.NamedArg: %w", op, a, ErrInvalidParameter)
+				}
+				n, err := strconv.Atoi(strings.TrimPrefix(named.Name, "p"))
+				if err != nil {
+					return nil, fmt.Errorf("%s: unexpected sql named arg %q: %w", op, named.Name, ErrInvalidParameter)
+				}
+				combined.Args = append(combined.Args, sql.Named(sqlArgName(n+offset-1), named.Value))
+			}
+		case atPlaceholders:
+			offset := len(combined.Args)
+			condition = sqlNamedPlaceholderRegexp.ReplaceAllStringFunc(condition, func(p string) string {
+				n, _ := strconv.Atoi(p[2:])
+				return fmt.Sprintf("@p%d", n+offset)
+			})
+			combined.Args = append(combined.Args, c.Args...)
+		case namedPlaceholders:
+			if combined.NamedArgs == nil {
+				combined.NamedArgs = make(map[string]any, len(c.NamedArgs))
+			}
+			condition = renameNamedParams(condition, c.NamedArgs, combined.NamedArgs)
+		default:
+			combined.Args = append(combined.Args, c.Args...)
+		}
+		conditions = append(conditions, "("+condition+")")
+	}
+	combined.Condition = strings.Join(conditions, " "+string(logicalOp)+" ")
+	return combined, nil
+}
+
+// placeholderStyleOf returns the style of the where clause's placeholders
+func placeholderStyleOf(w *WhereClause) placeholderStyle {
+	switch {
+	case len(w.NamedArgs) > 0:
+		return namedPlaceholders
+	case len(w.Args) == 0:
+		return noPlaceholders
+	}
+	if _, ok := w.Args[0].(sql.NamedArg); ok {
+		return sqlNamedPlaceholders
+	}
+	if sqlNamedPlaceholderRegexp.MatchString(w.Condition) {
+		return atPlaceholders
+	}
+	if pgPlaceholderRegexp.MatchString(w.Condition) {
+		return pgPlaceholders
+	}
+	return questionPlaceholders
+}
+
+// renameNamedParams will add the named args to the combined named args and
+// rename the ones whose name is already used, by using the next unused number
+// for their column (see namedParams).  It returns the condition with the
+// renamed params.
+func renameNamedParams(condition string, args map[string]any, combined map[string]any) string {
+	names := make([]string, 0, len(args))
+	for name := range args {
+		names = append(names, name)
+	}
+	// sorted, so the params are always renamed the same way
+	sort.Strings(names)
+	renamed := make(map[string]string, len(names))
+	for _, name := range names {
+		if _, used := combined[name]; !used {
+			combined[name] = args[name]
+			continue
+		}
+		base := namedParamSuffixRegexp.ReplaceAllString(name, "")
+		newName := name
+		for i := 1; ; i++ {
+			newName = fmt.Sprintf("%s_%d", base, i)
+			_, used := combined[newName]
+			_, inClause := args[newName]
+			if !used && !inClause {
+				break
+			}
+		}
+		combined[newName] = args[name]
+		renamed[name] = newName
+	}
+	for _, name := range names {
+		newName, ok := renamed[name]
+		if !ok {
+			continue
+		}
+		// a param is preceded by its prefix (ie: ":"), while a column is
+		// preceded by whitespace or a paren.
+		re := regexp.MustCompile(`([^\w\s(])` + regexp.QuoteMeta(name) + `\b`)
+		condition = re.ReplaceAllString(condition, "${1}"+newName)
+	}
+	return condition
+}
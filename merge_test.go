@@ -0,0 +1,155 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/hashicorp/mql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnd(t *testing.T) {
+	t.Parallel()
+	parse := func(query string, opt ...mql.Option) *mql.WhereClause {
+		w, err := mql.Parse(query, testModel{}, opt...)
+		require.NoError(t, err)
+		return w
+	}
+	tests := []struct {
+		name            string
+		clauses         []*mql.WhereClause
+		want            *mql.WhereClause
+		wantErrIs       error
+		wantErrContains string
+	}{
+		{
+			name: "question-placeholders",
+			clauses: []*mql.WhereClause{
+				{Condition: "tenant_id=?", Args: []any{"t1"}},
+				parse(`name="alice" or age>21`),
+				{Condition: "deleted_at is null"},
+			},
+			want: &mql.WhereClause{
+				Condition: "(tenant_id=?) and ((name=? or age>?)) and (deleted_at is null)",
+				Args:      []any{"t1", "alice", 21},
+			},
+		},
+		{
+			name: "pg-placeholders",
+			clauses: []*mql.WhereClause{
+				parse(`name="alice" or age>21`, mql.WithPgPlaceholders()),
+				{Condition: "tenant_id=$1", Args: []any{"t1"}},
+			},
+			want: &mql.WhereClause{
+				Condition: "((name=$1 or age>$2)) and (tenant_id=$3)",
+				Args:      []any{"alice", 21, "t1"},
+			},
+		},
+		{
+			name: "sql-named-args",
+			clauses: []*mql.WhereClause{
+				parse(`name="alice"`, mql.WithSqlNamedArgs()),
+				parse(`age>21 and age<65`, mql.WithSqlNamedArgs()),
+			},
+			want: &mql.WhereClause{
+				Condition: "(name=@p1) and ((age>@p2 and age<@p3))",
+				Args:      []any{sql.Named("p1", "alice"), sql.Named("p2", 21), sql.Named("p3", 65)},
+			},
+		},
+		{
+			name: "spanner-placeholders",
+			clauses: []*mql.WhereClause{
+				parse(`name="alice"`, mql.WithDialect(mql.SpannerDialect{})),
+				parse(`age>21 and age<65`, mql.WithDialect(mql.SpannerDialect{})),
+			},
+			want: &mql.WhereClause{
+				Condition: "(`name`=@p1) and ((`age`>@p2 and `age`<@p3))",
+				Args:      []any{"alice", 21, 65},
+			},
+		},
+		{
+			name: "named-params",
+			clauses: []*mql.WhereClause{
+				parse(`name="alice" and age>21`, mql.WithNamedParams(":")),
+				parse(`name="bob" or name="eve"`, mql.WithNamedParams(":")),
+			},
+			want: &mql.WhereClause{
+				Condition: "((name=:name_1 and age>:age_1)) and ((name=:name_3 or name=:name_2))",
+				NamedArgs: map[string]any{"name_1": "alice", "age_1": 21, "name_3": "bob", "name_2": "eve"},
+			},
+		},
+		{
+			name: "match-all-is-ignored",
+			clauses: []*mql.WhereClause{
+				nil,
+				{Condition: "1=1"},
+				{Condition: "tenant_id=?", Args: []any{"t1"}},
+			},
+			want: &mql.WhereClause{Condition: "tenant_id=?", Args: []any{"t1"}},
+		},
+		{
+			name:    "only-match-all",
+			clauses: []*mql.WhereClause{{Condition: "1=1"}},
+			want:    &mql.WhereClause{Condition: "1=1"},
+		},
+		{
+			name: "err-different-placeholders",
+			clauses: []*mql.WhereClause{
+				parse(`name="alice"`, mql.WithPgPlaceholders()),
+				parse(`name="alice"`),
+			},
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: "clauses with different placeholders can't be combined",
+		},
+		{
+			name:            "err-missing-clauses",
+			clauses:         []*mql.WhereClause{nil},
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: "missing where clauses",
+		},
+		{
+			name:            "err-missing-condition",
+			clauses:         []*mql.WhereClause{{Args: []any{1}}},
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: "missing condition",
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert, require := assert.New(t), require.New(t)
+			got, err := mql.And(tc.clauses...)
+			if tc.wantErrContains != "" {
+				require.Error(err)
+				assert.Empty(got)
+				assert.ErrorIs(err, tc.wantErrIs)
+				assert.ErrorContains(err, tc.wantErrContains)
+				return
+			}
+			require.NoError(err)
+			assert.Equal(tc.want, got)
+		})
+	}
+}
+
+func TestOr(t *testing.T) {
+	t.Parallel()
+	t.Run("pg-placeholders", func(t *testing.T) {
+		got, err := mql.Or(
+			&mql.WhereClause{Condition: "owner_id=$1", Args: []any{"u1"}},
+			&mql.WhereClause{Condition: "public=$1", Args: []any{true}},
+		)
+		require.NoError(t, err)
+		assert.Equal(t, &mql.WhereClause{Condition: "(owner_id=$1) or (public=$2)", Args: []any{"u1", true}}, got)
+	})
+	t.Run("match-all", func(t *testing.T) {
+		got, err := mql.Or(&mql.WhereClause{Condition: "owner_id=?", Args: []any{"u1"}}, &mql.WhereClause{Condition: "1=1"})
+		require.NoError(t, err)
+		assert.Equal(t, &mql.WhereClause{Condition: "1=1"}, got)
+	})
+}
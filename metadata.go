@@ -0,0 +1,183 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql
+
+import (
+	"strings"
+
+	"golang.org/x/exp/slices"
+)
+
+// ClauseMetadata describes what a where clause references, so callers can
+// derive cache keys, audit queries or advise indexes without parsing the
+// clause's condition (see WithMetadata).
+type ClauseMetadata struct {
+	// Fields are the model's fields referenced by the query, in the order
+	// they're first referenced.  A map field is referenced by its name (ie:
+	// Labels for labels.env) and a relationship's column by the query's
+	// column (ie: roles.name).
+	Fields []string
+
+	// Columns are the database columns referenced by the query, in the order
+	// they're first referenced.
+	Columns []string
+
+	// ComparisonOps are the comparison operators used by the query, in the
+	// order they're first used.
+	ComparisonOps []ComparisonOp
+
+	// LogicalOps are the logical operators used by the query, in the order
+	// they're first used.
+	LogicalOps []LogicalOp
+
+	// Resolutions describe how the query's columns were resolved to the
+	// columns of the condition, in the order they're first referenced.
+	Resolutions []ColumnResolution
+
+	// Args describe the where clause's args, in the same order as Args.  When
+	// using WithNamedParams, they're in the order of the placeholders in the
+	// condition and their Name is the key of the arg in NamedArgs.
+	Args []ArgMetadata
+}
+
+// ArgMetadata describes an arg of a where clause
+type ArgMetadata struct {
+	// Column is the database column the arg is compared to
+	Column string
+
+	// Name is the name of the arg when using WithNamedParams (ie: name_1) or
+	// WithSqlNamedArgs (ie: p1) and it's empty otherwise
+	Name string
+}
+
+// ColumnResolution describes how a column of the query was resolved to a column
+// of the where clause's condition: query column -> column map -> model field
+// -> table -> SQL column.  It allows audit logs and UIs to show which columns
+// a filter touches.
+type ColumnResolution struct {
+	// Query is the column as it's written in the query (ie: nickname or
+	// labels.env)
+	Query string
+
+	// Mapped is the column which the query column is mapped to (see
+	// WithColumnMap) and it's empty when it isn't mapped
+	Mapped string
+
+	// Field is the model's field (see ClauseMetadata.Fields) and it's empty
+	// when it's unknown
+	Field string
+
+	// Column is the database column (see ClauseMetadata.Columns)
+	Column string
+
+	// Table qualifies the column: the table alias or name (see WithTableAlias
+	// and WithTableName) or the relationship's table.  It's empty when the
+	// column isn't qualified.
+	Table string
+
+	// SqlColumn is the column as it's written in the condition: qualified by
+	// its table and quoted using the dialect.  It's empty when the column has
+	// a converter (see WithConverter and WithContextConverter), which writes
+	// its own condition.
+	SqlColumn string
+}
+
+// WithMetadata will add a ClauseMetadata to the where clause returned by
+// Parse, ParseContext and ToWhereClause (see WhereClause.Metadata).
+func WithMetadata() Option {
+	return func(o *options) error {
+		o.withMetadata = true
+		return nil
+	}
+}
+
+// clauseMetadata returns the metadata of the where clause converted from the
+// expr tree, before its placeholders are applied.  Supported options:
+// WithColumnMap, WithRelationship, WithNamedParams, WithSqlNamedArgs,
+// WithInlineValues
+func clauseMetadata(e Expr, w *WhereClause, fValidators map[string]validator, opts options) *ClauseMetadata {
+	m := &ClauseMetadata{}
+	walkExpr(e, func(e Expr) {
+		switch v := e.(type) {
+		case *ComparisonExpr:
+			r := resolveColumn(v, fValidators, opts)
+			if r.Field != "" && !slices.Contains(m.Fields, r.Field) {
+				m.Fields = append(m.Fields, r.Field)
+			}
+			if !slices.Contains(m.Columns, r.Column) {
+				m.Columns = append(m.Columns, r.Column)
+			}
+			if !slices.ContainsFunc(m.Resolutions, func(c ColumnResolution) bool { return c.Query == r.Query }) {
+				m.Resolutions = append(m.Resolutions, r)
+			}
+			if !slices.Contains(m.ComparisonOps, v.ComparisonOp) {
+				m.ComparisonOps = append(m.ComparisonOps, v.ComparisonOp)
+			}
+		case *LogicalExpr:
+			if !slices.Contains(m.LogicalOps, v.LogicalOp) {
+				m.LogicalOps = append(m.LogicalOps, v.LogicalOp)
+			}
+		}
+	})
+	if opts.withInlineValues {
+		return m
+	}
+	var names []string
+	if opts.withNamedParams != "" {
+		names = namedParams(w.argColumns)
+	}
+	for i, c := range w.argColumns {
+		a := ArgMetadata{Column: c}
+		switch {
+		case names != nil:
+			a.Name = names[i]
+		case opts.withSqlNamedArgs:
+			a.Name = sqlArgName(i)
+		}
+		m.Args = append(m.Args, a)
+	}
+	return m
+}
+
+// resolveColumn returns how the comparison's column is resolved, using the
+// same rules as exprToWhereClause.  Supported options: WithColumnMap,
+// WithRelationship, WithConverter, WithContextConverter, WithTableAlias,
+// WithTableName, WithDialect
+func resolveColumn(e *ComparisonExpr, fValidators map[string]validator, opts options) ColumnResolution {
+	r := ColumnResolution{Query: e.Column}
+	columnName := strings.ToLower(e.Column)
+	if n, ok := opts.withColumnMap[columnName]; ok {
+		columnName = n
+		r.Mapped = n
+	}
+	r.Column = columnName
+	if v, ok := fValidators[strings.ToLower(strings.ReplaceAll(columnName, "_", ""))]; ok {
+		r.Field = v.field.Name
+	} else if prefix, key, found := strings.Cut(e.Column, "."); found {
+		prefix = strings.ToLower(prefix)
+		if rel, ok := opts.withRelationships[prefix]; ok {
+			// the relationship's column is compared in a subquery of its table
+			r.Field, r.Column, r.Table = prefix+"."+key, prefix+"."+key, rel.Table
+			r.SqlColumn = quoteIdentifiers(dialectOf(opts), rel.Table+"."+strings.ToLower(key))
+			return r
+		}
+		mapped := prefix
+		if n, ok := opts.withColumnMap[prefix]; ok {
+			mapped = n
+			r.Mapped = n
+		}
+		if v, ok := fValidators[strings.ToLower(strings.ReplaceAll(mapped, "_", ""))]; ok && v.typ == "map" {
+			r.Field, r.Column = v.field.Name, mapped
+		}
+	}
+	if _, ok := opts.withValidateConvertFns[e.Column]; ok {
+		return r
+	}
+	if _, ok := opts.withContextConvertFns[strings.ToLower(strings.ReplaceAll(r.Column, "_", ""))]; ok {
+		return r
+	}
+	r.Table = columnQualifier(opts)
+	r.SqlColumn = qualifyColumn(r.Column, opts)
+	return r
+}
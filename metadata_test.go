@@ -0,0 +1,136 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql_test
+
+import (
+	"testing"
+
+	"github.com/hashicorp/mql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse_WithMetadata(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name  string
+		query string
+		model any
+		opts  []mql.Option
+		want  *mql.ClauseMetadata
+	}{
+		{
+			name:  "fields-and-ops",
+			query: `name="alice" and (age>21 or name%"bob") and labels.env="prod"`,
+			model: testModel{},
+			want: &mql.ClauseMetadata{
+				Fields:        []string{"Name", "Age", "Labels"},
+				Columns:       []string{"name", "age", "labels"},
+				ComparisonOps: []mql.ComparisonOp{mql.EqualOp, mql.GreaterThanOp, mql.ContainsOp},
+				LogicalOps:    []mql.LogicalOp{mql.AndOp, mql.OrOp},
+				Resolutions: []mql.ColumnResolution{
+					{Query: "name", Field: "Name", Column: "name", SqlColumn: "name"},
+					{Query: "age", Field: "Age", Column: "age", SqlColumn: "age"},
+					{Query: "labels.env", Field: "Labels", Column: "labels", SqlColumn: "labels"},
+				},
+				Args: []mql.ArgMetadata{{Column: "name"}, {Column: "age"}, {Column: "name"}, {Column: "labels"}, {Column: "labels"}},
+			},
+		},
+		{
+			name:  "column-map-and-named-params",
+			query: `nickname="alice" or nickname="bob"`,
+			model: testModel{},
+			opts:  []mql.Option{mql.WithColumnMap(map[string]string{"nickname": "name"}), mql.WithNamedParams(":")},
+			want: &mql.ClauseMetadata{
+				Fields:        []string{"Name"},
+				Columns:       []string{"name"},
+				ComparisonOps: []mql.ComparisonOp{mql.EqualOp},
+				LogicalOps:    []mql.LogicalOp{mql.OrOp},
+				Resolutions:   []mql.ColumnResolution{{Query: "nickname", Mapped: "name", Field: "Name", Column: "name", SqlColumn: "name"}},
+				Args:          []mql.ArgMetadata{{Column: "name", Name: "name_1"}, {Column: "name", Name: "name_2"}},
+			},
+		},
+		{
+			name:  "sql-named-args",
+			query: `age>=21`,
+			model: testModel{},
+			opts:  []mql.Option{mql.WithSqlNamedArgs()},
+			want: &mql.ClauseMetadata{
+				Fields:        []string{"Age"},
+				Columns:       []string{"age"},
+				ComparisonOps: []mql.ComparisonOp{mql.GreaterThanOrEqualOp},
+				Resolutions:   []mql.ColumnResolution{{Query: "age", Field: "Age", Column: "age", SqlColumn: "age"}},
+				Args:          []mql.ArgMetadata{{Column: "age", Name: "p1"}},
+			},
+		},
+		{
+			name:  "inline-values",
+			query: `age>=21`,
+			model: testModel{},
+			opts:  []mql.Option{mql.WithInlineValues()},
+			want: &mql.ClauseMetadata{
+				Fields:        []string{"Age"},
+				Columns:       []string{"age"},
+				ComparisonOps: []mql.ComparisonOp{mql.GreaterThanOrEqualOp},
+				Resolutions:   []mql.ColumnResolution{{Query: "age", Field: "Age", Column: "age", SqlColumn: "age"}},
+			},
+		},
+		{
+			name:  "relationship",
+			query: `roles.name="admin"`,
+			model: userModel{},
+			opts:  []mql.Option{mql.WithRelationship("roles", mql.Relationship{Model: roleModel{}, ForeignKey: "user_id"})},
+			want: &mql.ClauseMetadata{
+				Fields:        []string{"roles.name"},
+				Columns:       []string{"roles.name"},
+				ComparisonOps: []mql.ComparisonOp{mql.EqualOp},
+				Resolutions:   []mql.ColumnResolution{{Query: "roles.name", Field: "roles.name", Column: "roles.name", Table: "roles", SqlColumn: "roles.name"}},
+				Args:          []mql.ArgMetadata{{Column: "roles.name"}},
+			},
+		},
+		{
+			name:  "resolutions",
+			query: `Nick="alice" and Tags.env="prod" and id>1 and score>2 and nick="bob"`,
+			model: userModel{},
+			opts: []mql.Option{
+				mql.WithColumnAliases("name", "nick"),
+				mql.WithColumnAliases("Labels", "tags"),
+				mql.WithTableAlias("u"),
+				mql.WithDialect(mql.MySqlDialect{}),
+				mql.WithConverter("score", func(columnName string, comparisonOp mql.ComparisonOp, value *string) (*mql.WhereClause, error) {
+					return &mql.WhereClause{Condition: "score(id)>?", Args: []any{*value}}, nil
+				}),
+			},
+			want: &mql.ClauseMetadata{
+				Fields:        []string{"Name", "Labels", "ID"},
+				Columns:       []string{"name", "Labels", "id", "score"},
+				ComparisonOps: []mql.ComparisonOp{mql.EqualOp, mql.GreaterThanOp},
+				LogicalOps:    []mql.LogicalOp{mql.AndOp},
+				Resolutions: []mql.ColumnResolution{
+					{Query: "Nick", Mapped: "name", Field: "Name", Column: "name", Table: "u", SqlColumn: "`u`.`name`"},
+					{Query: "Tags.env", Mapped: "Labels", Field: "Labels", Column: "Labels", Table: "u", SqlColumn: "`u`.`Labels`"},
+					{Query: "id", Field: "ID", Column: "id", Table: "u", SqlColumn: "`u`.`id`"},
+					{Query: "score", Column: "score"},
+					{Query: "nick", Mapped: "name", Field: "Name", Column: "name", Table: "u", SqlColumn: "`u`.`name`"},
+				},
+				Args: []mql.ArgMetadata{{Column: "name"}, {Column: "Labels"}, {Column: "Labels"}, {Column: "id"}, {Column: "score"}, {Column: "name"}},
+			},
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert, require := assert.New(t), require.New(t)
+			got, err := mql.Parse(tc.query, tc.model, append(tc.opts, mql.WithMetadata())...)
+			require.NoError(err)
+			assert.Equal(tc.want, got.Metadata)
+
+			// the metadata is only provided when requested
+			got, err = mql.Parse(tc.query, tc.model, tc.opts...)
+			require.NoError(err)
+			assert.Nil(got.Metadata)
+		})
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Migration is the result of migrating a stored query (see Migrate)
+type Migration struct {
+	// Query is the migrated query as canonical mql text (see Expr.MQL).  It's
+	// provided even when some of its comparisons are invalid, so they can be
+	// fixed by hand.
+	Query string
+
+	// Renamed reports if any of the query's columns were renamed
+	Renamed bool
+
+	// Invalid are the comparisons of the migrated query which aren't valid
+	// for the model, in the order they appear in the query.  The query can
+	// be parsed when it's empty.
+	Invalid []MigrationIssue
+}
+
+// MigrationIssue is a comparison of a migrated query which isn't valid for
+// the model (ie: its column was removed or its type changed)
+type MigrationIssue struct {
+	// Column is the comparison's column, after it was renamed
+	Column string
+
+	// Pos is the byte offset of the column in the original query
+	Pos int
+
+	// Err is the error returned when converting the comparison, which can
+	// be checked using errors.Is (ie: ErrInvalidColumn)
+	Err error
+}
+
+// Migrate will rename the columns of a stored query (ie: a customer's saved
+// filter) and then re-validate every comparison of the renamed query using
+// the model, so stored queries can be rewritten when the model changes.
+// Renames are keyed by the query's old column (case insensitive) and their
+// values are the new column.  The column of a map field or relationship is
+// renamed by its prefix (ie: a "labels" -> "tags" rename migrates
+// labels.env="prod" to tags.env="prod").  Comparisons which are invalid
+// after the renames are reported via Migration.Invalid rather than an error,
+// which is only returned when the query can't be parsed or a parameter is
+// invalid.  Supported options: the same options as Parse.
+func Migrate(query string, model any, renames map[string]string, opt ...Option) (*Migration, error) {
+	const op = "mql.Migrate"
+	if isNil(model) {
+		return nil, fmt.Errorf("%s: missing model: %w", op, ErrInvalidParameter)
+	}
+	lowerRenames := make(map[string]string, len(renames))
+	for from, to := range renames {
+		if from == "" || to == "" {
+			return nil, fmt.Errorf("%s: invalid rename %q -> %q: %w", op, from, to, ErrInvalidParameter)
+		}
+		lowerRenames[strings.ToLower(from)] = to
+	}
+	e, err := ParseExpr(query, opt...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	opt, err = withModelTable(model, opt)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	fValidators, err := modelValidators(model, opt...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	m := &Migration{}
+	walkExpr(e, func(e Expr) {
+		c, ok := e.(*ComparisonExpr)
+		if !ok {
+			return
+		}
+		if column, ok := renameColumn(c.Column, lowerRenames); ok {
+			c.Column = column
+			m.Renamed = true
+		}
+		if _, err := exprToWhereClause(c, fValidators, opt...); err != nil {
+			m.Invalid = append(m.Invalid, MigrationIssue{Column: c.Column, Pos: c.pos, Err: err})
+		}
+	})
+	m.Query = e.MQL()
+	return m, nil
+}
+
+// renameColumn returns the column renamed using the renames (keyed by their
+// lowercase old column) and false when it isn't renamed.  The whole column is
+// renamed before its prefix (ie: labels for labels.env).
+func renameColumn(column string, renames map[string]string) (string, bool) {
+	if to, ok := renames[strings.ToLower(column)]; ok {
+		return to, true
+	}
+	if prefix, key, found := strings.Cut(column, "."); found {
+		if to, ok := renames[strings.ToLower(prefix)]; ok {
+			return to + "." + key, true
+		}
+	}
+	return column, false
+}
@@ -0,0 +1,123 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql_test
+
+import (
+	"testing"
+
+	"github.com/hashicorp/mql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrate(t *testing.T) {
+	t.Parallel()
+	type invalid struct {
+		column string
+		pos    int
+	}
+	tests := []struct {
+		name            string
+		query           string
+		model           any
+		renames         map[string]string
+		opts            []mql.Option
+		wantQuery       string
+		wantRenamed     bool
+		wantInvalid     []invalid
+		wantErrIs       error
+		wantErrContains string
+	}{
+		{
+			name:        "renamed",
+			query:       `full_name="alice" and (years > 21 or FULL_NAME % "bob")`,
+			model:       testModel{},
+			renames:     map[string]string{"full_name": "name", "Years": "age"},
+			wantQuery:   `name="alice" and (age>21 or name%"bob")`,
+			wantRenamed: true,
+		},
+		{
+			name:        "map-prefix",
+			query:       `tags.env="prod" and tags="x"`,
+			model:       testModel{},
+			renames:     map[string]string{"tags": "labels", "tags.env": "labels.environment"},
+			wantQuery:   `labels.environment="prod" and labels="x"`,
+			wantRenamed: true,
+			wantInvalid: []invalid{{column: "labels", pos: 20}},
+		},
+		{
+			name:      "unchanged",
+			query:     `name = "alice"`,
+			model:     testModel{},
+			wantQuery: `name="alice"`,
+		},
+		{
+			name:        "invalid-columns",
+			query:       `name="alice" or nickname="bob" and age>"old"`,
+			model:       testModel{},
+			renames:     map[string]string{"name": "name"},
+			wantQuery:   `name="alice" or nickname="bob" and age>"old"`,
+			wantRenamed: true,
+			wantInvalid: []invalid{{column: "nickname", pos: 16}, {column: "age", pos: 35}},
+		},
+		{
+			name:        "options",
+			query:       `nick="alice"`,
+			model:       testModel{},
+			renames:     map[string]string{"nick": "display_name"},
+			opts:        []mql.Option{mql.WithColumnAliases("name", "display_name")},
+			wantQuery:   `display_name="alice"`,
+			wantRenamed: true,
+		},
+		{
+			name:            "err-missing-model",
+			query:           `name="alice"`,
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: "missing model",
+		},
+		{
+			name:            "err-invalid-rename",
+			query:           `name="alice"`,
+			model:           testModel{},
+			renames:         map[string]string{"name": ""},
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: `invalid rename "name" -> ""`,
+		},
+		{
+			name:            "err-syntax",
+			query:           `name="alice" and`,
+			model:           testModel{},
+			wantErrIs:       mql.ErrMissingRightSideExpr,
+			wantErrContains: "logical operator without a right side expr",
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert, require := assert.New(t), require.New(t)
+			got, err := mql.Migrate(tc.query, tc.model, tc.renames, tc.opts...)
+			if tc.wantErrContains != "" {
+				require.Error(err)
+				assert.Nil(got)
+				assert.ErrorIs(err, tc.wantErrIs)
+				assert.ErrorContains(err, tc.wantErrContains)
+				return
+			}
+			require.NoError(err)
+			assert.Equal(tc.wantQuery, got.Query)
+			assert.Equal(tc.wantRenamed, got.Renamed)
+			require.Len(got.Invalid, len(tc.wantInvalid))
+			for i, want := range tc.wantInvalid {
+				assert.Equal(want.column, got.Invalid[i].Column)
+				assert.Equal(want.pos, got.Invalid[i].Pos)
+				assert.Error(got.Invalid[i].Err)
+			}
+			if len(got.Invalid) == 0 {
+				_, err := mql.Parse(got.Query, tc.model, tc.opts...)
+				assert.NoError(err)
+			}
+		})
+	}
+}
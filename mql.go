@@ -4,9 +4,15 @@
 package mql
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"io"
 	"reflect"
 	"strings"
+	"time"
+	"unicode"
 )
 
 // WhereClause contains a SQL where clause condition and its arguments.
@@ -15,48 +21,474 @@ type WhereClause struct {
 	Condition string
 	// Args for the where clause condition
 	Args []any
+	// NamedArgs for the where clause condition when using WithNamedParams
+	NamedArgs map[string]any
+	// Metadata describes what the where clause references when using
+	// WithMetadata
+	Metadata *ClauseMetadata
+	// Having is the having clause of the comparisons of aggregates when
+	// using WithAggregate, which is nil when the query doesn't compare an
+	// aggregate
+	Having *WhereClause
+
+	// argColumns is the column of each arg, which is used to name them
+	argColumns []string
 }
 
+// matchAllCondition is the condition returned for an empty query when
+// WithAllowEmptyQuery is used.
+const matchAllCondition = "1=1"
+
 // Parse will parse the query and use the provided database model to create a
 // where clause. Supported options: WithColumnMap, WithIgnoreFields,
-// WithConverter, WithPgPlaceholder
+// WithConverter, WithPgPlaceholder, WithSqlNamedArgs, WithNamedParams,
+// WithInlineValues, WithAllowEmptyQuery, WithMaxQueryLength, WithMaxTokens,
+// WithMaxStringLength, WithObserver, WithErrorMessages, WithErrorMessageFunc,
+// WithLenientParsing
 func Parse(query string, model any, opt ...Option) (*WhereClause, error) {
 	const op = "mql.Parse"
+	w, err := parse(nil, query, model, opt...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return w, nil
+}
+
+// ParseContext is the same as Parse, except that it stops parsing the query
+// and converting it once the context is canceled or its deadline is exceeded,
+// returning the context's error.  The context is also provided to converters
+// via ConvertContext.Context (see WithContextConverter).  Supported options:
+// the same options as Parse.
+func ParseContext(ctx context.Context, query string, model any, opt ...Option) (*WhereClause, error) {
+	const op = "mql.ParseContext"
+	if ctx == nil {
+		return nil, fmt.Errorf("%s: missing context: %w", op, ErrInvalidParameter)
+	}
+	w, err := parse(ctx, query, model, append(opt[:len(opt):len(opt)], withContext(ctx))...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return w, nil
+}
+
+// ParseFor is the same as Parse, except that the model is provided as a type
+// parameter rather than a value (ie: mql.ParseFor[User](query)), so callers
+// can't pass a nil model and the model is checked by the compiler.  T must be
+// a struct or a pointer to a struct.  Supported options: the same options as
+// Parse.
+func ParseFor[T any](query string, opt ...Option) (*WhereClause, error) {
+	const op = "mql.ParseFor"
+	w, err := parse(nil, query, modelFor[T](), opt...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return w, nil
+}
+
+// modelFor returns a model of type T, which is a new struct when T is a
+// pointer to a struct, so the model is never a nil pointer.
+func modelFor[T any]() any {
+	var model T
+	if t := reflect.TypeOf((*T)(nil)).Elem(); t.Kind() == reflect.Pointer && t.Elem().Kind() == reflect.Struct {
+		return reflect.New(t.Elem()).Interface()
+	}
+	return model
+}
+
+// ParseReader is the same as Parse, except that the query is read from r.  The
+// query is read incrementally and at most one byte more than the max query
+// length (see WithMaxQueryLength) is read, so an oversized query returns an
+// ErrQueryTooLong without the rest of it being read or buffered.  Supported
+// options: the same options as Parse.
+func ParseReader(r io.Reader, model any, opt ...Option) (*WhereClause, error) {
+	const op = "mql.ParseReader"
+	if isNil(r) {
+		return nil, fmt.Errorf("%s: missing reader: %w", op, ErrInvalidParameter)
+	}
+	opts, err := getOpts(opt...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	query, err := opts.withLimits.readQuery(r)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, withErrorMessage(err, opts))
+	}
+	w, err := parse(nil, query, model, opt...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return w, nil
+}
+
+// parse will parse the query and create its where clause, checking the
+// context for cancellation when it's not nil.  Supported options: the same
+// options as Parse.
+func parse(ctx context.Context, query string, model any, opt ...Option) (*WhereClause, error) {
+	const op = "mql.parse"
+	opts, err := getOpts(opt...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	_, w, err := observeParse(ctx, query, model, opts, opt...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return w, nil
+}
+
+// observeParse will parse the query and create its where clause (see
+// parseWhereClause), reporting the parse to the observer (if there's one) and
+// localizing its error.  Supported options: the same options as Parse.
+func observeParse(ctx context.Context, query string, model any, opts options, opt ...Option) (Expr, *WhereClause, error) {
+	start := time.Now()
+	expr, w, err := parseWhereClause(ctx, query, model, opts, opt...)
+	if opts.withObserver != nil {
+		observed := ctx
+		if observed == nil {
+			observed = context.Background()
+		}
+		opts.withObserver.ObserveParse(observed, newParseStats(query, expr, start, err))
+	}
+	if err != nil {
+		return nil, nil, withErrorMessage(err, opts)
+	}
+	return expr, w, nil
+}
+
+// parseWhereClause will parse the query and create its where clause, returning
+// the parsed expr tree along with it (or with the error once it's parsed).
+// Supported options: the same options as Parse.
+func parseWhereClause(ctx context.Context, query string, model any, opts options, opt ...Option) (Expr, *WhereClause, error) {
+	const op = "mql.parseWhereClause"
 	switch {
-	case query == "":
-		return nil, fmt.Errorf("%s: missing query: %w", op, ErrInvalidParameter)
+	case query == "" && !opts.withAllowEmptyQuery:
+		return nil, nil, fmt.Errorf("%s: missing query: %w", op, ErrInvalidParameter)
 	case isNil(model):
-		return nil, fmt.Errorf("%s: missing model: %w", op, ErrInvalidParameter)
+		return nil, nil, fmt.Errorf("%s: missing model: %w", op, ErrInvalidParameter)
+	case opts.withAllowEmptyQuery && strings.TrimSpace(query) == "":
+		w, err := softDeleteCondition(&WhereClause{Condition: matchAllCondition}, nil, model, opts)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", op, err)
+		}
+		return nil, w, nil
 	}
 	p := newParser(query)
+	p.ctx = ctx
+	p.configure(opts)
 	expr, err := p.parse()
+	switch {
+	case err != nil && opts.withLenientParsing && opts.withAllowEmptyQuery && errors.Is(err, ErrMissingExpr):
+		// the query is empty once its mistakes are ignored
+		w, err := softDeleteCondition(&WhereClause{Condition: matchAllCondition}, nil, model, opts)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", op, err)
+		}
+		return nil, w, nil
+	case err != nil:
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+	w, err := whereClause(expr, model, opt...)
 	if err != nil {
-		return nil, fmt.Errorf("%s: %w", op, err)
+		return expr, nil, fmt.Errorf("%s: %w", op, err)
 	}
-	fValidators, err := fieldValidators(reflect.ValueOf(model), opt...)
+	return expr, w, nil
+}
+
+// whereClause will use the model to validate the expr tree and convert it to
+// a where clause. Supported options: the same options as Parse.
+func whereClause(expr Expr, model any, opt ...Option) (*WhereClause, error) {
+	const op = "mql.whereClause"
+	opts, err := getOpts(opt...)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
-	e, err := exprToWhereClause(expr, fValidators, opt...)
-	if err != nil {
+	if opt, err = withModelTable(model, opt); err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
-	opts, err := getOpts(opt...)
+	fValidators, err := modelValidators(model, opt...)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
-	if opts.withPgPlaceholder {
-		for i := 0; i < len(e.Args); i++ {
-			placeholder := fmt.Sprintf("$%d", i+1)
-			e.Condition = strings.Replace(e.Condition, "?", placeholder, 1)
+	if opts.withOptimize {
+		expr = optimizeExpr(expr, fValidators, opts)
+	}
+	var e *WhereClause
+	if len(opts.withAggregates) > 0 {
+		if e, err = havingClause(expr, fValidators, opts, opt); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	} else {
+		if e, err = exprToWhereClause(expr, fValidators, opt...); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		if opts.withMetadata {
+			e.Metadata = clauseMetadata(expr, e, fValidators, opts)
+		}
+		if e, err = applyPlaceholders(e, opts); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+	// the soft delete condition doesn't have any args, so it's added once the
+	// placeholders are replaced
+	if e, err = softDeleteCondition(e, expr, model, opts); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return e, nil
+}
+
+// applyPlaceholders will replace the where clause's ? placeholders using the
+// placeholder options: WithPgPlaceholders, WithSqlNamedArgs, WithNamedParams
+// and WithInlineValues, or the placeholders of the dialect (see WithDialect)
+func applyPlaceholders(e *WhereClause, opts options) (*WhereClause, error) {
+	const op = "mql.applyPlaceholders"
+	switch {
+	case opts.withPgPlaceholder && opts.withSqlNamedArgs:
+		return nil, fmt.Errorf("%s: WithPgPlaceholders and WithSqlNamedArgs are mutually exclusive: %w", op, ErrInvalidParameter)
+	case opts.withNamedParams != "" && (opts.withPgPlaceholder || opts.withSqlNamedArgs || opts.withInlineValues):
+		return nil, fmt.Errorf("%s: WithNamedParams cannot be used with WithPgPlaceholders, WithSqlNamedArgs or WithInlineValues: %w", op, ErrInvalidParameter)
+	case opts.withNamedParams != "":
+		names := namedParams(e.argColumns)
+		e.Condition = replacePlaceholders(e.Condition, len(names), func(i int) string {
+			return opts.withNamedParams + names[i]
+		})
+		e.NamedArgs = make(map[string]any, len(e.Args))
+		for i, a := range e.Args {
+			e.NamedArgs[names[i]] = a
+		}
+		e.Args = nil
+	case opts.withInlineValues && (opts.withPgPlaceholder || opts.withSqlNamedArgs):
+		return nil, fmt.Errorf("%s: WithInlineValues cannot be used with WithPgPlaceholders or WithSqlNamedArgs: %w", op, ErrInvalidParameter)
+	case opts.withInlineValues:
+		literals := make([]string, 0, len(e.Args))
+		for _, a := range e.Args {
+			l, err := sqlLiteral(a, dialectOf(opts))
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", op, err)
+			}
+			literals = append(literals, l)
 		}
+		e.Condition = replacePlaceholders(e.Condition, len(literals), func(i int) string {
+			return literals[i]
+		})
+		e.Args = nil
+	case opts.withPgPlaceholder:
+		e.Condition = replacePlaceholders(e.Condition, len(e.Args), func(i int) string {
+			return fmt.Sprintf("$%d", i+1)
+		})
+	case opts.withSqlNamedArgs:
+		e.Condition = replacePlaceholders(e.Condition, len(e.Args), func(i int) string {
+			return fmt.Sprintf("@%s", sqlArgName(i))
+		})
+		for i, a := range e.Args {
+			e.Args[i] = sql.Named(sqlArgName(i), a)
+		}
+	case opts.withDialect != nil:
+		e.Condition = replacePlaceholders(e.Condition, len(e.Args), func(i int) string {
+			return opts.withDialect.Placeholder(i + 1)
+		})
 	}
+	e.argColumns = nil
 	return e, nil
 }
 
+// argColumns returns the column of each of the n args of a comparison
+func argColumns(column string, n int) []string {
+	columns := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		columns = append(columns, column)
+	}
+	return columns
+}
+
+// namedParams returns a unique name for each arg based on its column, so the
+// args for the column "name" are named: name_1, name_2, etc.  Any characters
+// in the column which aren't valid in an identifier are replaced with '_'.
+func namedParams(columns []string) []string {
+	counts := make(map[string]int, len(columns))
+	names := make([]string, 0, len(columns))
+	for _, c := range columns {
+		c = strings.Map(func(r rune) rune {
+			switch {
+			case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '_':
+				return r
+			default:
+				return '_'
+			}
+		}, strings.ToLower(c))
+		counts[c]++
+		names = append(names, fmt.Sprintf("%s_%d", c, counts[c]))
+	}
+	return names
+}
+
+// sqlArgName returns the name of the i-th (zero based) arg when using
+// WithSqlNamedArgs
+func sqlArgName(i int) string {
+	return fmt.Sprintf("p%d", i+1)
+}
+
+// replacePlaceholders will replace the first n "?" placeholders in the
+// condition with the placeholder returned by fn for each one.
+func replacePlaceholders(condition string, n int, fn func(i int) string) string {
+	var b strings.Builder
+	b.Grow(len(condition))
+	for i := 0; i < n; i++ {
+		idx := strings.IndexByte(condition, '?')
+		if idx < 0 {
+			break
+		}
+		b.WriteString(condition[:idx])
+		b.WriteString(fn(i))
+		condition = condition[idx+1:]
+	}
+	b.WriteString(condition)
+	return b.String()
+}
+
+// Validate will validate the query using the provided database model without
+// generating a where clause.  Unlike Parse, it doesn't stop at the first
+// invalid column or value and returns every error it finds, which is helpful
+// when you want to give users feedback about their query as they type it.  An
+// empty result means the query is valid.  After a syntax error, it skips to
+// the next logical operator and continues (see ParseExprRecover), so every
+// syntax error is reported along with the invalid columns and values of the
+// comparisons which could be parsed.  Supported options: the same options as
+// Parse.
+func Validate(query string, model any, opt ...Option) []error {
+	const op = "mql.Validate"
+	opts, err := getOpts(opt...)
+	if err != nil {
+		return []error{fmt.Errorf("%s: %w", op, err)}
+	}
+	switch {
+	case query == "" && !opts.withAllowEmptyQuery:
+		return []error{fmt.Errorf("%s: missing query: %w", op, ErrInvalidParameter)}
+	case isNil(model):
+		return []error{fmt.Errorf("%s: missing model: %w", op, ErrInvalidParameter)}
+	case opts.withAllowEmptyQuery && strings.TrimSpace(query) == "":
+		return nil
+	}
+	var errs []error
+	e, pErrs := recoverExpr(query, opts)
+	for _, pErr := range pErrs {
+		errs = append(errs, fmt.Errorf("%s: %w", op, pErr))
+	}
+	if e == nil {
+		return errs
+	}
+	fValidators, err := modelValidators(model, opt...)
+	if err != nil {
+		return append(errs, fmt.Errorf("%s: %w", op, err))
+	}
+	walkExpr(e, func(e Expr) {
+		switch v := e.(type) {
+		case *ComparisonExpr:
+			if _, err := exprToWhereClause(v, fValidators, opt...); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", op, err))
+			}
+		case *LogicalExpr:
+			if v.LogicalOp == "" {
+				errs = append(errs, fmt.Errorf("%s: %w", op, ErrMissingLogicalOp))
+			}
+		}
+	})
+	return errs
+}
+
+// validateQuotedColumn will validate the characters of a quoted column, which
+// must be letters, digits, underscores or one of the quoted column chars.
+// Supported options: WithQuotedColumnChars
+func validateQuotedColumn(e *ComparisonExpr, opts options) error {
+	const op = "mql.validateQuotedColumn"
+	if !e.quotedColumn {
+		return nil
+	}
+	for _, r := range e.Column {
+		if r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune(opts.withQuotedColumnChars, r) {
+			continue
+		}
+		return fmt.Errorf("%s: %w %q contains %q (expected letters, digits, %q or one of %q)", op, ErrInvalidColumn, e.Column, r, '_', opts.withQuotedColumnChars)
+	}
+	return nil
+}
+
+// authorizeComparison will authorize the comparison using the FieldAuthorizer
+// (if one was provided).  Supported options: WithFieldAuthorizer,
+// WithColumnMap
+func authorizeComparison(e *ComparisonExpr, opts options) error {
+	const op = "mql.authorizeComparison"
+	if opts.withFieldAuthorizer == nil {
+		return nil
+	}
+	columnName := strings.ToLower(e.Column)
+	if n, ok := opts.withColumnMap[columnName]; ok {
+		columnName = n
+	}
+	if err := opts.withFieldAuthorizer(columnName, e.ComparisonOp); err != nil {
+		return fmt.Errorf("%s: %w %q: %w", op, ErrInvalidColumn, columnName, err)
+	}
+	return nil
+}
+
+// transformValue returns the comparison with its value transformed by the
+// ValueTransformFunc of its column (if it has one).  The comparison isn't
+// modified, so a copy is returned when its value is transformed.  Supported
+// options: WithValueTransform, WithColumnMap
+func transformValue(e *ComparisonExpr, opts options) (*ComparisonExpr, error) {
+	const op = "mql.transformValue"
+	if len(opts.withValueTransforms) == 0 || e.Value == nil {
+		return e, nil
+	}
+	columnName := strings.ToLower(e.Column)
+	if n, ok := opts.withColumnMap[columnName]; ok {
+		columnName = n
+	}
+	fn, ok := opts.withValueTransforms[strings.ToLower(strings.ReplaceAll(columnName, "_", ""))]
+	if !ok {
+		return e, nil
+	}
+	v, err := fn(*e.Value)
+	if err != nil {
+		return nil, fmt.Errorf("%s: unable to transform %q for column %q: %w: %w", op, *e.Value, e.Column, ErrInvalidParameter, err)
+	}
+	transformed := *e
+	transformed.Value = &v
+	return &transformed, nil
+}
+
+// nullCondition returns the where clause which compares the comparison's
+// column to NULL when its value is an empty string and the column is one of
+// the WithEmptyStringAsNull columns.  It reports if the comparison is against
+// NULL.  Supported options: WithEmptyStringAsNull, WithColumnMap,
+// WithTableAlias, WithTableName
+func nullCondition(e *ComparisonExpr, opts options) (*WhereClause, bool, error) {
+	const op = "mql.nullCondition"
+	if e.Value == nil || *e.Value != "" || len(opts.withEmptyStringAsNull) == 0 {
+		return nil, false, nil
+	}
+	columnName := strings.ToLower(e.Column)
+	if n, ok := opts.withColumnMap[columnName]; ok {
+		columnName = n
+	}
+	if _, ok := opts.withEmptyStringAsNull[strings.ToLower(strings.ReplaceAll(columnName, "_", ""))]; !ok {
+		return nil, false, nil
+	}
+	switch e.ComparisonOp {
+	case EqualOp:
+		return &WhereClause{Condition: fmt.Sprintf("%s is null", qualifyColumn(columnName, opts))}, true, nil
+	case NotEqualOp:
+		return &WhereClause{Condition: fmt.Sprintf("%s is not null", qualifyColumn(columnName, opts))}, true, nil
+	default:
+		return nil, false, fmt.Errorf("%s: %w %q for an empty string (null) value of column %q (expected = or !=)", op, ErrInvalidComparisonOp, e.ComparisonOp, columnName)
+	}
+}
+
 // exprToWhereClause generates the where clause condition along with its
-// required arguments. Supported options: WithColumnMap, WithConverter
-func exprToWhereClause(e expr, fValidators map[string]validator, opt ...Option) (*WhereClause, error) {
+// required arguments. Supported options: WithColumnMap, WithConverter,
+// WithContextConverter, WithDefaultConverter, WithEnum, WithEmptyStringAsNull,
+// WithFieldAuthorizer, WithValueTransform, WithQuotedColumnChars,
+// WithTableAlias, WithTableName, WithRelationship
+func exprToWhereClause(e Expr, fValidators map[string]validator, opt ...Option) (*WhereClause, error) {
 	const op = "mql.exprToWhereClause"
 	switch {
 	case isNil(e):
@@ -65,49 +497,183 @@ func exprToWhereClause(e expr, fValidators map[string]validator, opt ...Option)
 		return nil, fmt.Errorf("%s: missing validators: %w", op, ErrInvalidParameter)
 	}
 
+	opts, err := getOpts(opt...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return whereClauseOf(e, fValidators, opts, opt)
+}
+
+// whereClauseOf converts the expr tree to a where clause using the options,
+// which are only resolved once for the whole tree.  opt are the options which
+// were resolved, which are provided to the functions which resolve them.
+func whereClauseOf(e Expr, fValidators map[string]validator, opts options, opt []Option) (*WhereClause, error) {
+	// errors use the op of exprToWhereClause, since it's just the resolved
+	// options version of it
+	const op = "mql.exprToWhereClause"
 	switch v := e.(type) {
-	case *comparisonExpr:
-		opts, err := getOpts(opt...)
-		if err != nil {
+	case *ComparisonExpr:
+		var err error
+		if opts.withParseContext != nil {
+			if err := opts.withParseContext.Err(); err != nil {
+				return nil, fmt.Errorf("%s: %w", op, err)
+			}
+		}
+		if err := validateQuotedColumn(v, opts); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		if err := checkComparisonOp(v, opts); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		if err := authorizeComparison(v, opts); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		if v, err = resolveVariable(v, opts); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		if v, err = transformValue(v, opts); err != nil {
 			return nil, fmt.Errorf("%s: %w", op, err)
 		}
-		switch validateConvertFn, ok := opts.withValidateConvertFns[v.column]; {
+		if w, ok, err := nullCondition(v, opts); ok || err != nil {
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", op, err)
+			}
+			return w, nil
+		}
+		if err := validateEnum(v, opts); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		if w, ok, err := blindIndexCondition(v, opts); ok || err != nil {
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", op, err)
+			}
+			return w, nil
+		}
+		if aggregate, ok := opts.withAggregates[strings.ToLower(v.Column)]; ok {
+			w, err := aggregateCondition(aggregate, v, opts)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", op, err)
+			}
+			return w, nil
+		}
+		switch validateConvertFn, ok := opts.withValidateConvertFns[v.Column]; {
 		case ok && !isNil(validateConvertFn):
-			return validateConvertFn(v.column, v.comparisonOp, v.value)
+			w, err := validateConvertFn(v.Column, v.ComparisonOp, v.Value)
+			if err != nil || w == nil {
+				return w, err
+			}
+			w.argColumns = argColumns(v.Column, len(w.Args))
+			return w, nil
 		default:
-			columnName := strings.ToLower(v.column)
+			columnName := strings.ToLower(v.Column)
 			if n, ok := opts.withColumnMap[columnName]; ok {
 				columnName = n
 			}
 			validator, ok := fValidators[strings.ToLower(strings.ReplaceAll(columnName, "_", ""))]
 			if !ok {
+				// the column may be a column of a relationship (roles.name) or
+				// a key lookup in a map field (labels.env), where only the
+				// relationship or field part is case insensitive.
+				if fieldName, key, found := strings.Cut(v.Column, "."); found {
+					if r, ok := opts.withRelationships[strings.ToLower(fieldName)]; ok {
+						w, err := relationshipCondition(strings.ToLower(fieldName), key, v, r, opts)
+						if err != nil {
+							return nil, fmt.Errorf("%s: %w", op, err)
+						}
+						return w, nil
+					}
+					fieldName = strings.ToLower(fieldName)
+					if n, ok := opts.withColumnMap[fieldName]; ok {
+						fieldName = n
+					}
+					if validator, ok := fValidators[strings.ToLower(strings.ReplaceAll(fieldName, "_", ""))]; ok && validator.typ == "map" {
+						w, err := mapValidateConvert(qualifyColumn(fieldName, opts), key, v.ComparisonOp, v.Value, validator, opts)
+						if err != nil {
+							return nil, fmt.Errorf("%s: %w", op, err)
+						}
+						if opts.withNullSafeNotEqual {
+							// a missing key is null
+							w = nullSafeCondition(w, v.ComparisonOp, dialectOf(opts).JsonLookup(qualifyColumn(fieldName, opts)), []any{key}, opts)
+						}
+						w.argColumns = argColumns(fieldName, len(w.Args))
+						return w, nil
+					}
+				}
 				cols := make([]string, len(fValidators))
 				for c := range fValidators {
 					cols = append(cols, c)
 				}
 				return nil, fmt.Errorf("%s: %w %q %s", op, ErrInvalidColumn, columnName, cols)
 			}
-			w, err := defaultValidateConvert(columnName, v.comparisonOp, v.value, validator, opt...)
+			if validator.typ == "map" {
+				return nil, fmt.Errorf("%s: %w %q requires a key (%s.<key>)", op, ErrInvalidColumn, columnName, columnName)
+			}
+			if fn, ok := opts.withContextConvertFns[strings.ToLower(strings.ReplaceAll(columnName, "_", ""))]; ok {
+				ctx := ConvertContext{
+					Column:      v.Column,
+					ColumnName:  columnName,
+					FieldName:   validator.field.Name,
+					FieldType:   validator.field.Type,
+					ReflectType: validator.rType,
+					Pos:         v.pos,
+					Context:     opts.withParseContext,
+				}
+				if ctx.Context == nil {
+					ctx.Context = context.Background()
+				}
+				w, err := fn(ctx, v.ComparisonOp, v.Value)
+				if err != nil {
+					return nil, fmt.Errorf("%s: %w", op, err)
+				}
+				if w == nil {
+					return nil, fmt.Errorf("%s: converter for %q returned a nil where clause: %w", op, columnName, ErrInvalidParameter)
+				}
+				w.argColumns = argColumns(columnName, len(w.Args))
+				return w, nil
+			}
+			if opts.withDefaultConverter != nil {
+				w, err := opts.withDefaultConverter(columnName, v.ComparisonOp, v.Value)
+				if err != nil {
+					return nil, fmt.Errorf("%s: %w", op, err)
+				}
+				if w != nil {
+					w.argColumns = argColumns(columnName, len(w.Args))
+					return w, nil
+				}
+			}
+			w, err := defaultValidateConvert(qualifyColumn(columnName, opts), v.ComparisonOp, v.Value, validator, opts)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", op, err)
+			}
+			w = nullSafeCondition(w, v.ComparisonOp, qualifyColumn(columnName, opts), nil, opts)
+			w.argColumns = argColumns(columnName, len(w.Args))
+			return w, nil
+		}
+	case *LogicalExpr:
+		if opts.withOptimize {
+			w, err := chainWhereClause(v, fValidators, opt...)
 			if err != nil {
 				return nil, fmt.Errorf("%s: %w", op, err)
 			}
 			return w, nil
 		}
-	case *logicalExpr:
-		left, err := exprToWhereClause(v.leftExpr, fValidators, opt...)
+		left, err := whereClauseOf(v.LeftExpr, fValidators, opts, opt)
 		if err != nil {
 			return nil, fmt.Errorf("%s: invalid left expr: %w", op, err)
 		}
-		if v.logicalOp == "" {
+		if v.LogicalOp == "" {
 			return nil, fmt.Errorf("%s: %w that stated with left expr condition: %q args: %q", op, ErrMissingLogicalOp, left.Condition, left.Args)
 		}
-		right, err := exprToWhereClause(v.rightExpr, fValidators, opt...)
+		right, err := whereClauseOf(v.RightExpr, fValidators, opts, opt)
 		if err != nil {
 			return nil, fmt.Errorf("%s: invalid right expr: %w", op, err)
 		}
+		args := make([]any, 0, len(left.Args)+len(right.Args))
+		argColumns := make([]string, 0, len(left.argColumns)+len(right.argColumns))
 		return &WhereClause{
-			Condition: fmt.Sprintf("(%s %s %s)", left.Condition, v.logicalOp, right.Condition),
-			Args:      append(left.Args, right.Args...),
+			Condition:  "(" + left.Condition + " " + string(v.LogicalOp) + " " + right.Condition + ")",
+			Args:       append(append(args, left.Args...), right.Args...),
+			argColumns: append(append(argColumns, left.argColumns...), right.argColumns...),
 		}, nil
 	default:
 		return nil, fmt.Errorf("%s: unexpected expr type %T: %w", op, v, ErrInternal)
@@ -4,11 +4,15 @@
 package mql_test
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"io"
+	"reflect"
 	"strings"
 	"testing"
+	"testing/iotest"
 	"time"
 
 	"github.com/hashicorp/mql"
@@ -16,6 +20,9 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
+// errAccessDenied is returned by the field authorizers of tests
+var errAccessDenied = errors.New("access denied")
+
 type testModel struct {
 	ID           uint
 	Name         string
@@ -27,6 +34,23 @@ type testModel struct {
 	ActivatedAt  sql.NullTime
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
+	Labels       map[string]string
+	Scores       map[string]int
+}
+
+type boolModel struct {
+	Name     string
+	Enabled  bool
+	Deleted  *bool
+	Verified sql.NullBool
+	Flags    map[string]bool
+}
+
+type durationModel struct {
+	Name    string
+	Timeout time.Duration
+	TTL     *time.Duration
+	Limits  map[string]time.Duration
 }
 
 func TestParse(t *testing.T) {
@@ -72,7 +96,7 @@ func TestParse(t *testing.T) {
 			query: "(name=`alice`) and (email=`eve@example.com`) and (member_number = 1)",
 			model: &testModel{},
 			want: &mql.WhereClause{
-				Condition: "(name=? and (email=? and member_number=?))",
+				Condition: "((name=? and email=?) and member_number=?)",
 				Args:      []any{"alice", "eve@example.com", "1"},
 			},
 		},
@@ -81,7 +105,7 @@ func TestParse(t *testing.T) {
 			query: "(name=`alice`) and (email=`eve@example.com`) or (member_number = 1)",
 			model: &testModel{},
 			want: &mql.WhereClause{
-				Condition: "(name=? and (email=? or member_number=?))",
+				Condition: "((name=? and email=?) or member_number=?)",
 				Args:      []any{"alice", "eve@example.com", "1"},
 			},
 		},
@@ -99,234 +123,1195 @@ func TestParse(t *testing.T) {
 			query: "name%\"alice\"",
 			model: testModel{},
 			want: &mql.WhereClause{
-				Condition: "name like ?",
+				Condition: "name like ? escape '\\'",
 				Args:      []any{"%alice%"},
 			},
 		},
+		{
+			name:  "success-contains-escaped-wildcards",
+			query: `name%"50%_off\\" and labels.promo%"a_b"`,
+			model: testModel{},
+			want: &mql.WhereClause{
+				Condition: `(name like ? escape '\' and labels->>? like ? escape '\')`,
+				Args:      []any{`%50\%\_off\\%`, "promo", `%a\_b%`},
+			},
+		},
+		{
+			name:  "success-WithRawLikePatterns",
+			query: `name%"a_ice%" and labels.promo%"a_b"`,
+			model: testModel{},
+			opts:  []mql.Option{mql.WithRawLikePatterns()},
+			want: &mql.WhereClause{
+				Condition: "(name like ? and labels->>? like ?)",
+				Args:      []any{"%a_ice%%", "promo", "%a_b%"},
+			},
+		},
+		{
+			name:  "success-WithGlobPatterns",
+			query: `name%"al*ce_" and (email="*@example.com" or (name!="b\*b" or labels.team!="eng*"))`,
+			model: testModel{},
+			opts:  []mql.Option{mql.WithGlobPatterns()},
+			want: &mql.WhereClause{
+				Condition: `(name like ? escape '\' and (email like ? escape '\' or (name!=? or labels->>? not like ? escape '\')))`,
+				Args:      []any{`%al%ce\_%`, "%@example.com", "b*b", "team", "eng%"},
+			},
+		},
+		{
+			name:  "success-WithGlobPatterns-not-a-string",
+			query: `age="21"`,
+			model: testModel{},
+			opts:  []mql.Option{mql.WithGlobPatterns()},
+			want: &mql.WhereClause{
+				Condition: "age=?",
+				Args:      []any{21},
+			},
+		},
+		{
+			name:            "err-WithGlobPatterns-and-WithRawLikePatterns",
+			query:           `name%"al*ce"`,
+			model:           testModel{},
+			opts:            []mql.Option{mql.WithGlobPatterns(), mql.WithRawLikePatterns()},
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: "WithRawLikePatterns and WithGlobPatterns are mutually exclusive",
+		},
 		{
 			name:  "success-WithPgPlaceholder",
 			query: "name=\"bob\" or (name%\"alice\" or name=\"eve\")",
 			model: testModel{},
 			opts:  []mql.Option{mql.WithPgPlaceholders()},
 			want: &mql.WhereClause{
-				Condition: "(name=$1 or (name like $2 or name=$3))",
+				Condition: "(name=$1 or (name like $2 escape '\\' or name=$3))",
 				Args:      []any{"bob", "%alice%", "eve"},
 			},
 		},
 		{
-			name:  "success-dd",
-			query: "nAme%\"\"",
-			model: &testModel{},
+			name:  "success-WithSqlNamedArgs",
+			query: "name=\"bob\" or (name%\"alice\" or age>21)",
+			model: testModel{},
+			opts:  []mql.Option{mql.WithSqlNamedArgs()},
 			want: &mql.WhereClause{
-				Condition: "name like ?",
-				Args:      []any{"%%"},
+				Condition: "(name=@p1 or (name like @p2 escape '\\' or age>@p3))",
+				Args:      []any{sql.Named("p1", "bob"), sql.Named("p2", "%alice%"), sql.Named("p3", 21)},
 			},
 		},
 		{
-			name:            "err-leftExpr-without-op",
-			query:           "age (name=\"alice\")",
+			name:  "success-bool-literals",
+			query: `enabled=true and deleted != FALSE and verified="true" and flags.beta=true`,
+			model: boolModel{},
+			want: &mql.WhereClause{
+				Condition: "(((enabled=? and deleted!=?) and verified=?) and (flags->>?)::boolean=?)",
+				Args:      []any{true, false, true, "beta", true},
+			},
+		},
+		{
+			name:  "success-bool-literal-string-column",
+			query: `name=true`,
+			model: boolModel{},
+			want:  &mql.WhereClause{Condition: "name=?", Args: []any{"true"}},
+		},
+		{
+			name:            "err-bool-invalid-value",
+			query:           `enabled=1`,
+			model:           boolModel{},
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: `"1" in (comparisonExpr: enabled = 1)`,
+		},
+		{
+			name:            "err-bool-invalid-op",
+			query:           `enabled > false`,
+			model:           boolModel{},
+			wantErrIs:       mql.ErrInvalidComparisonOp,
+			wantErrContains: `invalid comparison operator ">" for bool column "enabled" (expected = or !=)`,
+		},
+		{
+			name:            "err-bool-map-invalid-op",
+			query:           `flags.beta % true`,
+			model:           boolModel{},
+			wantErrIs:       mql.ErrInvalidComparisonOp,
+			wantErrContains: `invalid comparison operator "%" for bool column flags.beta`,
+		},
+		{
+			name:  "success-duration-literals",
+			query: `timeout > "30s" and ttl <= "1h30m" and limits.read < "-1.5ms"`,
+			model: durationModel{},
+			want: &mql.WhereClause{
+				Condition: "((timeout>? and ttl<=?) and (limits->>?)::bigint<?)",
+				Args:      []any{int64(30 * time.Second), int64(90 * time.Minute), "read", int64(-1500 * time.Microsecond)},
+			},
+		},
+		{
+			name:  "success-duration-WithDurationUnit",
+			query: `timeout >= "2m" or ttl = "0"`,
+			model: durationModel{},
+			opts:  []mql.Option{mql.WithDurationUnit(time.Second)},
+			want: &mql.WhereClause{
+				Condition: "(timeout>=? or ttl=?)",
+				Args:      []any{int64(120), int64(0)},
+			},
+		},
+		{
+			name:            "err-duration-missing-unit",
+			query:           `timeout > 30`,
+			model:           durationModel{},
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: `"30" in (comparisonExpr: timeout > 30)`,
+		},
+		{
+			name:            "err-duration-not-whole-unit",
+			query:           `timeout > "1500ms"`,
+			model:           durationModel{},
+			opts:            []mql.Option{mql.WithDurationUnit(time.Second)},
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: `"1500ms" in (comparisonExpr: timeout > 1500ms)`,
+		},
+		{
+			name:            "err-duration-contains",
+			query:           `timeout % "1s"`,
+			model:           durationModel{},
+			wantErrIs:       mql.ErrInvalidComparisonOp,
+			wantErrContains: `invalid comparison operator "%" for duration column "timeout"`,
+		},
+		{
+			name:            "err-WithDurationUnit-zero",
+			query:           `timeout > "1s"`,
+			model:           durationModel{},
+			opts:            []mql.Option{mql.WithDurationUnit(0)},
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: "duration unit 0s must be greater than 0",
+		},
+		{
+			name:  "success-WithEnum",
+			query: `name="alice" or name%"bo"`,
+			model: testModel{},
+			opts:  []mql.Option{mql.WithEnum("name", []string{"alice", "bob"})},
+			want: &mql.WhereClause{
+				Condition: "(name=? or name like ? escape '\\')",
+				Args:      []any{"alice", "%bo%"},
+			},
+		},
+		{
+			name:            "err-WithEnum-invalid-value",
+			query:           `name="alcie"`,
 			model:           testModel{},
-			wantErrIs:       mql.ErrUnexpectedOpeningParen,
-			wantErrContains: `unexpected opening paren in: "age (name=\"alice\")"`,
+			opts:            []mql.Option{mql.WithEnum("name", []string{"alice", "bob"})},
+			wantErrIs:       mql.ErrInvalidEnumValue,
+			wantErrContains: `invalid enum value "alcie" for column "name" (expected one of: alice, bob)`,
 		},
 		{
-			name:            "err-int-model",
-			query:           "name=\"alice\"",
-			model:           1,
+			name:            "err-WithEnum-invalid-contains",
+			query:           `name%"eve"`,
+			model:           testModel{},
+			opts:            []mql.Option{mql.WithEnum("name", []string{"alice", "bob"})},
 			wantErrIs:       mql.ErrInvalidParameter,
-			wantErrContains: "model must be a struct or a pointer to a struct",
+			wantErrContains: `invalid enum value "eve" for column "name" (expected a part of one of: alice, bob)`,
 		},
 		{
-			name:            "err-*int-model",
-			query:           "name=\"alice\"",
-			model:           pointer(1),
+			name:            "err-WithEnum-WithColumnMap",
+			query:           `user_name="Alice"`,
+			model:           testModel{},
+			opts:            []mql.Option{mql.WithColumnMap(map[string]string{"user_name": "name"}), mql.WithEnum("name", []string{"alice", "bob"})},
+			wantErrIs:       mql.ErrInvalidEnumValue,
+			wantErrContains: `invalid enum value "Alice" for column "user_name"`,
+		},
+		{
+			name:            "err-WithEnum-missing-values",
+			query:           `name="alice"`,
+			model:           testModel{},
+			opts:            []mql.Option{mql.WithEnum("name", nil)},
 			wantErrIs:       mql.ErrInvalidParameter,
-			wantErrContains: "model must be a struct or a pointer to a struct",
+			wantErrContains: `missing values for "name"`,
 		},
 		{
-			name:  "err-interface-nil-pointer-model",
-			query: "name=\"alice\"",
-			model: func() any {
-				var r io.Reader
-				return r
-			}(),
+			name:            "err-WithEnum-duplicated",
+			query:           `name="alice"`,
+			model:           testModel{},
+			opts:            []mql.Option{mql.WithEnum("name", []string{"alice"}), mql.WithEnum("Name", []string{"bob"})},
 			wantErrIs:       mql.ErrInvalidParameter,
-			wantErrContains: "missing model: invalid parameter",
+			wantErrContains: `duplicated enum for "Name"`,
 		},
 		{
-			name:  "time",
-			query: "created_at=\"2023-01-02\"",
+			name:  "success-WithEmptyStringAsNull",
+			query: `email="" and (member_number!="" or name="")`,
 			model: testModel{},
+			opts:  []mql.Option{mql.WithEmptyStringAsNull("email", "member_number")},
 			want: &mql.WhereClause{
-				Condition: "created_at::date=?",
-				Args:      []any{"2023-01-02"},
+				Condition: "(email is null and (member_number is not null or name=?))",
+				Args:      []any{""},
 			},
 		},
 		{
-			name:  "success-with-column-map",
-			query: "custom_name=\"alice\"",
+			name:  "success-WithEmptyStringAsNull-int-column",
+			query: `age=""`,
 			model: testModel{},
-			opts:  []mql.Option{mql.WithColumnMap(map[string]string{"custom_name": "name"})},
+			opts:  []mql.Option{mql.WithEmptyStringAsNull("age")},
 			want: &mql.WhereClause{
-				Condition: "name=?",
-				Args:      []any{"alice"},
+				Condition: "age is null",
 			},
 		},
 		{
-			name:  "err-WithConverter-missing-field-name",
-			query: "name=\"alice\"",
+			name:            "err-WithEmptyStringAsNull-invalid-op",
+			query:           `email>""`,
+			model:           testModel{},
+			opts:            []mql.Option{mql.WithEmptyStringAsNull("email")},
+			wantErrIs:       mql.ErrInvalidComparisonOp,
+			wantErrContains: `invalid comparison operator ">" for an empty string (null) value of column "email" (expected = or !=)`,
+		},
+		{
+			name:            "err-WithEmptyStringAsNull-missing-column",
+			query:           `email=""`,
+			model:           testModel{},
+			opts:            []mql.Option{mql.WithEmptyStringAsNull("")},
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: "missing column",
+		},
+		{
+			name:  "success-WithFieldAuthorizer",
+			query: `user_name="alice" and age>21`,
 			model: testModel{},
 			opts: []mql.Option{
-				mql.WithConverter(
-					"",
-					func(columnName string, comparisonOp mql.ComparisonOp, value *string) (*mql.WhereClause, error) {
-						return &mql.WhereClause{Condition: "name=?", Args: []any{"alice"}}, nil
-					},
-				),
+				mql.WithColumnMap(map[string]string{"user_name": "name"}),
+				mql.WithFieldAuthorizer(func(column string, op mql.ComparisonOp) error {
+					if column == "name" && op == mql.EqualOp || column == "age" {
+						return nil
+					}
+					return errAccessDenied
+				}),
+			},
+			want: &mql.WhereClause{
+				Condition: "(name=? and age>?)",
+				Args:      []any{"alice", 21},
 			},
+		},
+		{
+			name:  "err-WithFieldAuthorizer",
+			query: `name="alice" or email%"@example.com"`,
+			model: testModel{},
+			opts: []mql.Option{mql.WithFieldAuthorizer(func(column string, op mql.ComparisonOp) error {
+				if column == "email" && op == mql.ContainsOp {
+					return errAccessDenied
+				}
+				return nil
+			})},
+			wantErrIs:       errAccessDenied,
+			wantErrContains: `invalid column "email": access denied`,
+		},
+		{
+			name:            "err-WithFieldAuthorizer-missing-authorizer",
+			query:           `name="alice"`,
+			model:           testModel{},
+			opts:            []mql.Option{mql.WithFieldAuthorizer(nil)},
 			wantErrIs:       mql.ErrInvalidParameter,
-			wantErrContains: "missing field name: invalid parameter",
+			wantErrContains: "missing field authorizer",
 		},
 		{
-			name:  "err-WithConverter-duplicated-converter",
-			query: "name=\"alice\" and email=\"eve@example.com\"",
+			name:  "success-WithValueTransform",
+			query: `email="  Alice@Example.com " and (name="ALICE" or age=" 21")`,
 			model: testModel{},
 			opts: []mql.Option{
-				mql.WithConverter(
-					"name",
-					func(columnName string, comparisonOp mql.ComparisonOp, value *string) (*mql.WhereClause, error) {
-						return &mql.WhereClause{Condition: "name=?", Args: []any{"alice"}}, nil
-					},
-				),
-				mql.WithConverter(
-					"email",
-					func(columnName string, comparisonOp mql.ComparisonOp, value *string) (*mql.WhereClause, error) {
-						return &mql.WhereClause{Condition: "email=?", Args: []any{"eve@example.com"}}, nil
-					},
-				),
-				mql.WithConverter(
-					"name",
-					func(columnName string, comparisonOp mql.ComparisonOp, value *string) (*mql.WhereClause, error) {
-						return &mql.WhereClause{Condition: "duplicated-Converter name=?", Args: []any{"alice"}}, nil
-					},
-				),
+				mql.WithValueTransform("email", func(v string) (string, error) {
+					return strings.ToLower(strings.TrimSpace(v)), nil
+				}),
+				mql.WithValueTransform("Age", func(v string) (string, error) {
+					return strings.TrimSpace(v), nil
+				}),
 			},
+			want: &mql.WhereClause{
+				Condition: "(email=? and (name=? or age=?))",
+				Args:      []any{"alice@example.com", "ALICE", 21},
+			},
+		},
+		{
+			name:  "err-WithValueTransform",
+			query: `email="alice"`,
+			model: testModel{},
+			opts: []mql.Option{mql.WithValueTransform("email", func(v string) (string, error) {
+				return "", errors.New("not an email")
+			})},
 			wantErrIs:       mql.ErrInvalidParameter,
-			wantErrContains: "duplicated convert: invalid parameter",
+			wantErrContains: `unable to transform "alice" for column "email": invalid parameter: not an email`,
 		},
 		{
-			name:  "success-WithConverter",
-			query: "(name = \"alice\" and email=\"eve@example.com\") or age > 21",
+			name:  "err-WithValueTransform-duplicated",
+			query: `email="alice"`,
 			model: testModel{},
 			opts: []mql.Option{
-				mql.WithConverter(
-					"name",
-					func(columnName string, comparisonOp mql.ComparisonOp, value *string) (*mql.WhereClause, error) {
-						return &mql.WhereClause{
-							// intentionally not the correct condition and
-							// args, but this makes verifying the test
-							// easier.
-							Condition: fmt.Sprintf("success-WithConverter: %s%s?", columnName, comparisonOp),
-							Args:      []any{"success-WithConverter: alice"},
-						}, nil
-					},
-				),
+				mql.WithValueTransform("email", func(v string) (string, error) { return v, nil }),
+				mql.WithValueTransform("Email", func(v string) (string, error) { return v, nil }),
 			},
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: `duplicated value transform for "Email"`,
+		},
+		{
+			name:            "err-WithValueTransform-missing-func",
+			query:           `email="alice"`,
+			model:           testModel{},
+			opts:            []mql.Option{mql.WithValueTransform("email", nil)},
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: `missing transform func for "email"`,
+		},
+		{
+			name:            "err-unquoted-symbol-value",
+			query:           `name=yes`,
+			model:           boolModel{},
+			wantErrIs:       mql.ErrInvalidComparisonValueType,
+			wantErrContains: "symbol == yes",
+		},
+		{
+			name:            "err-WithSqlNamedArgs-and-WithPgPlaceholders",
+			query:           "name=\"bob\"",
+			model:           testModel{},
+			opts:            []mql.Option{mql.WithSqlNamedArgs(), mql.WithPgPlaceholders()},
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: "WithPgPlaceholders and WithSqlNamedArgs are mutually exclusive",
+		},
+		{
+			name:  "success-WithNamedParams",
+			query: `name="alice" or (name%"bob" and age>21) or labels.env="prod"`,
+			model: testModel{},
+			opts:  []mql.Option{mql.WithNamedParams(":")},
 			want: &mql.WhereClause{
-				Condition: "((success-WithConverter: name=? and email=?) or age>?)",
-				Args:      []any{"success-WithConverter: alice", "eve@example.com", 21},
+				Condition: "((name=:name_1 or (name like :name_2 escape '\\' and age>:age_1)) or labels->>:labels_1=:labels_2)",
+				NamedArgs: map[string]any{
+					"name_1":   "alice",
+					"name_2":   "%bob%",
+					"age_1":    21,
+					"labels_1": "env",
+					"labels_2": "prod",
+				},
 			},
 		},
 		{
-			name:  "success-WithMultiConverters",
-			query: "(name = \"alice\" and email=\"eve@example.com\") or age > 21",
+			name:  "success-WithNamedParams-column-map-and-converter",
+			query: `nickname="alice" and email="eve@example.com"`,
 			model: testModel{},
 			opts: []mql.Option{
-				mql.WithConverter(
-					"name",
-					func(columnName string, comparisonOp mql.ComparisonOp, value *string) (*mql.WhereClause, error) {
-						return &mql.WhereClause{
-							// intentionally not the correct condition and
-							// args, but this makes verifying the test
-							// easier.
-							Condition: fmt.Sprintf("success-WithConverter: %s%s?", columnName, comparisonOp),
-							Args:      []any{"success-WithConverter: alice"},
-						}, nil
-					},
-				),
-				mql.WithConverter(
-					"email",
-					func(columnName string, comparisonOp mql.ComparisonOp, value *string) (*mql.WhereClause, error) {
-						return &mql.WhereClause{
-							// intentionally not the correct condition and
-							// args, but this makes verifying the test
-							// easier.
-							Condition: fmt.Sprintf("success-WithConverter: %s%s?", columnName, comparisonOp),
-							Args:      []any{"success-WithConverter: email=\"eva@example.com\""},
-						}, nil
-					},
-				),
+				mql.WithNamedParams("@"),
+				mql.WithColumnMap(map[string]string{"nickname": "name"}),
+				mql.WithConverter("email", func(columnName string, comparisonOp mql.ComparisonOp, value *string) (*mql.WhereClause, error) {
+					return &mql.WhereClause{Condition: "lower(email)=lower(?)", Args: []any{*value}}, nil
+				}),
 			},
 			want: &mql.WhereClause{
-				Condition: "((success-WithConverter: name=? and success-WithConverter: email=?) or age>?)",
-				Args:      []any{"success-WithConverter: alice", "success-WithConverter: email=\"eva@example.com\"", 21},
+				Condition: "(name=@name_1 and lower(email)=lower(@email_1))",
+				NamedArgs: map[string]any{"name_1": "alice", "email_1": "eve@example.com"},
 			},
 		},
 		{
-			name:            "err-ignored-field-used-in-query",
-			query:           "email=\"eve@example.com\" or name=\"alice\"",
-			model:           &testModel{},
-			opts:            []mql.Option{mql.WithIgnoredFields("Name")},
-			wantErrContains: `mql.exprToWhereClause: invalid right expr: mql.exprToWhereClause: invalid column "name"`,
+			name:            "err-WithNamedParams-and-WithPgPlaceholders",
+			query:           "name=\"bob\"",
+			model:           testModel{},
+			opts:            []mql.Option{mql.WithNamedParams(":"), mql.WithPgPlaceholders()},
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: "WithNamedParams cannot be used with WithPgPlaceholders, WithSqlNamedArgs or WithInlineValues",
 		},
 		{
-			name:            "err-missing-query",
-			query:           "",
+			name:            "err-WithNamedParams-missing-prefix",
+			query:           "name=\"bob\"",
+			model:           testModel{},
+			opts:            []mql.Option{mql.WithNamedParams("")},
 			wantErrIs:       mql.ErrInvalidParameter,
-			wantErrContains: "missing query: invalid parameter",
+			wantErrContains: "missing prefix",
 		},
 		{
-			name:            "err-model",
-			query:           "name=alice",
+			name:  "success-WithInlineValues",
+			query: `name="alice's" or (name%"bob" or (age>21 and length<1.5)) or labels.env="prod"`,
+			model: testModel{},
+			opts:  []mql.Option{mql.WithInlineValues()},
+			want: &mql.WhereClause{
+				Condition: "((name='alice''s' or (name like '%bob%' escape '\\' or (age>21 and length<1.5))) or labels->>'env'='prod')",
+			},
+		},
+		{
+			name:  "success-WithInlineValues-question-mark",
+			query: `name="?" and email="??"`,
+			model: testModel{},
+			opts:  []mql.Option{mql.WithInlineValues()},
+			want: &mql.WhereClause{
+				Condition: "(name='?' and email='??')",
+			},
+		},
+		{
+			name:            "err-WithInlineValues-backslash",
+			query:           `name="alice\\"`,
+			model:           testModel{},
+			opts:            []mql.Option{mql.WithInlineValues()},
 			wantErrIs:       mql.ErrInvalidParameter,
-			wantErrContains: "missing model: invalid parameter",
+			wantErrContains: "contains a backslash which can't be inlined",
 		},
 		{
-			name:            "err-invalid-query",
-			query:           "name!alice",
+			name:            "err-WithInlineValues-NaN",
+			query:           `length="NaN"`,
 			model:           testModel{},
-			wantErrIs:       mql.ErrInvalidNotEqual,
-			wantErrContains: `invalid "!=" token, got "!a"`,
+			opts:            []mql.Option{mql.WithInlineValues()},
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: "value NaN can't be inlined",
 		},
 		{
-			name:            "err-invalid-WithConverter-opt",
-			query:           "name=\"alice\"",
+			name:            "err-WithInlineValues-and-WithPgPlaceholders",
+			query:           "name=\"bob\"",
 			model:           testModel{},
-			opts:            []mql.Option{mql.WithConverter("TestColumn", nil)},
+			opts:            []mql.Option{mql.WithInlineValues(), mql.WithPgPlaceholders()},
 			wantErrIs:       mql.ErrInvalidParameter,
-			wantErrContains: "missing ConvertToSqlFunc: invalid parameter",
+			wantErrContains: "WithInlineValues cannot be used with WithPgPlaceholders or WithSqlNamedArgs",
 		},
-	}
-	for _, tc := range tests {
-		tc := tc
-		t.Run(tc.name, func(t *testing.T) {
-			assert, require := assert.New(t), require.New(t)
-			whereClause, err := mql.Parse(tc.query, tc.model, tc.opts...)
-			if tc.wantErrContains != "" {
-				require.Errorf(err, "expected err for %s, but got %v", tc.query, whereClause)
-				assert.Empty(whereClause)
-				if tc.wantErrIs != nil {
+		{
+			name:  "success-dd",
+			query: "nAme%\"\"",
+			model: &testModel{},
+			want: &mql.WhereClause{
+				Condition: "name like ? escape '\\'",
+				Args:      []any{"%%"},
+			},
+		},
+		{
+			name:            "err-leftExpr-without-op",
+			query:           "age (name=\"alice\")",
+			model:           testModel{},
+			wantErrIs:       mql.ErrUnexpectedOpeningParen,
+			wantErrContains: `unexpected opening paren in: "age (name=\"alice\")"`,
+		},
+		{
+			name:            "err-int-model",
+			query:           "name=\"alice\"",
+			model:           1,
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: "model must be a struct or a pointer to a struct",
+		},
+		{
+			name:            "err-*int-model",
+			query:           "name=\"alice\"",
+			model:           pointer(1),
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: "model must be a struct or a pointer to a struct",
+		},
+		{
+			name:  "err-interface-nil-pointer-model",
+			query: "name=\"alice\"",
+			model: func() any {
+				var r io.Reader
+				return r
+			}(),
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: "missing model: invalid parameter",
+		},
+		{
+			name:  "time",
+			query: "created_at=\"2023-01-02\"",
+			model: testModel{},
+			want: &mql.WhereClause{
+				Condition: "(created_at>=? and created_at<?)",
+				Args:      []any{time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC), time.Date(2023, 1, 3, 0, 0, 0, 0, time.UTC)},
+			},
+		},
+		{
+			name:  "time-not-equal",
+			query: "created_at!=\"2023-01-02\"",
+			model: testModel{},
+			want: &mql.WhereClause{
+				Condition: "(created_at<? or created_at>=?)",
+				Args:      []any{time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC), time.Date(2023, 1, 3, 0, 0, 0, 0, time.UTC)},
+			},
+		},
+		{
+			name:  "time-greater-than-date",
+			query: "created_at>\"2023-01-02\"",
+			model: testModel{},
+			want: &mql.WhereClause{
+				Condition: "created_at>=?",
+				Args:      []any{time.Date(2023, 1, 3, 0, 0, 0, 0, time.UTC)},
+			},
+		},
+		{
+			name:  "time-less-than-or-equal-date",
+			query: "created_at<=\"2023-01-02\"",
+			model: testModel{},
+			want: &mql.WhereClause{
+				Condition: "created_at<?",
+				Args:      []any{time.Date(2023, 1, 3, 0, 0, 0, 0, time.UTC)},
+			},
+		},
+		{
+			name:  "time-datetime",
+			query: "created_at>\"2023-01-02T10:00:00-05:00\"",
+			model: testModel{},
+			want: &mql.WhereClause{
+				Condition: "created_at>?",
+				Args:      []any{time.Date(2023, 1, 2, 10, 0, 0, 0, time.FixedZone("", -5*60*60))},
+			},
+		},
+		{
+			name:  "time-datetime-with-space",
+			query: "created_at<\"2023-01-02 10:00:00\"",
+			model: testModel{},
+			want: &mql.WhereClause{
+				Condition: "created_at<?",
+				Args:      []any{time.Date(2023, 1, 2, 10, 0, 0, 0, time.UTC)},
+			},
+		},
+		{
+			name:            "err-time-invalid-literal",
+			query:           "created_at=\"2023-13-02\"",
+			model:           testModel{},
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: `"2023-13-02" in (comparisonExpr: created_at = 2023-13-02)`,
+		},
+		{
+			name:  "time-relative-WithTimeNowFunc",
+			query: `created_at > "now-24h" and updated_at >= today`,
+			model: testModel{},
+			opts:  []mql.Option{mql.WithTimeNowFunc(fixedNow)},
+			want: &mql.WhereClause{
+				Condition: "(created_at>? and updated_at>=?)",
+				Args:      []any{time.Date(2023, 6, 1, 15, 30, 0, 0, time.UTC), time.Date(2023, 6, 2, 0, 0, 0, 0, time.UTC)},
+			},
+		},
+		{
+			name:  "time-relative-today-range",
+			query: `created_at = today-1d`,
+			model: testModel{},
+			opts:  []mql.Option{mql.WithTimeNowFunc(fixedNow)},
+			want: &mql.WhereClause{
+				Condition: "(created_at>=? and created_at<?)",
+				Args:      []any{time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC), time.Date(2023, 6, 2, 0, 0, 0, 0, time.UTC)},
+			},
+		},
+		{
+			name:            "err-time-relative-invalid-offset",
+			query:           `created_at > "now-1y"`,
+			model:           testModel{},
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: `"now-1y" in (comparisonExpr: created_at > now-1y)`,
+		},
+		{
+			name:            "err-WithTimeNowFunc-nil",
+			query:           `created_at > now`,
+			model:           testModel{},
+			opts:            []mql.Option{mql.WithTimeNowFunc(nil)},
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: "missing time now func",
+		},
+		{
+			name:            "err-time-contains",
+			query:           "created_at%\"2023-01-02\"",
+			model:           testModel{},
+			wantErrIs:       mql.ErrInvalidComparisonOp,
+			wantErrContains: `invalid comparison operator "%" for a date/time`,
+		},
+		{
+			name:  "success-map-key",
+			query: "labels.env=\"prod\" and labels.Team%\"core\"",
+			model: testModel{},
+			want: &mql.WhereClause{
+				Condition: "(labels->>?=? and labels->>? like ? escape '\\')",
+				Args:      []any{"env", "prod", "Team", "%core%"},
+			},
+		},
+		{
+			name:  "success-map-key-int-elem",
+			query: "scores.math >= 90",
+			model: testModel{},
+			want: &mql.WhereClause{
+				Condition: "(scores->>?)::bigint>=?",
+				Args:      []any{"math", 90},
+			},
+		},
+		{
+			name:  "success-map-key-with-column-map",
+			query: "tags.env=\"prod\"",
+			model: testModel{},
+			opts:  []mql.Option{mql.WithColumnMap(map[string]string{"tags": "labels"})},
+			want: &mql.WhereClause{
+				Condition: "labels->>?=?",
+				Args:      []any{"env", "prod"},
+			},
+		},
+		{
+			name:            "err-map-missing-key",
+			query:           "labels=\"prod\"",
+			model:           testModel{},
+			wantErrIs:       mql.ErrInvalidColumn,
+			wantErrContains: `invalid column "labels" requires a key (labels.<key>)`,
+		},
+		{
+			name:            "err-map-empty-key",
+			query:           "labels.=\"prod\"",
+			model:           testModel{},
+			wantErrIs:       mql.ErrInvalidColumn,
+			wantErrContains: `missing key for "labels"`,
+		},
+		{
+			name:            "err-map-invalid-elem-value",
+			query:           "scores.math=\"high\"",
+			model:           testModel{},
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: `"high" in scores.math`,
+		},
+		{
+			name:            "err-not-a-map-key",
+			query:           "name.first=\"alice\"",
+			model:           testModel{},
+			wantErrIs:       mql.ErrInvalidColumn,
+			wantErrContains: `invalid column "name.first"`,
+		},
+		{
+			name:  "success-with-column-map",
+			query: "custom_name=\"alice\"",
+			model: testModel{},
+			opts:  []mql.Option{mql.WithColumnMap(map[string]string{"custom_name": "name"})},
+			want: &mql.WhereClause{
+				Condition: "name=?",
+				Args:      []any{"alice"},
+			},
+		},
+		{
+			name:  "err-WithConverter-missing-field-name",
+			query: "name=\"alice\"",
+			model: testModel{},
+			opts: []mql.Option{
+				mql.WithConverter(
+					"",
+					func(columnName string, comparisonOp mql.ComparisonOp, value *string) (*mql.WhereClause, error) {
+						return &mql.WhereClause{Condition: "name=?", Args: []any{"alice"}}, nil
+					},
+				),
+			},
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: "missing field name: invalid parameter",
+		},
+		{
+			name:  "err-WithConverter-duplicated-converter",
+			query: "name=\"alice\" and email=\"eve@example.com\"",
+			model: testModel{},
+			opts: []mql.Option{
+				mql.WithConverter(
+					"name",
+					func(columnName string, comparisonOp mql.ComparisonOp, value *string) (*mql.WhereClause, error) {
+						return &mql.WhereClause{Condition: "name=?", Args: []any{"alice"}}, nil
+					},
+				),
+				mql.WithConverter(
+					"email",
+					func(columnName string, comparisonOp mql.ComparisonOp, value *string) (*mql.WhereClause, error) {
+						return &mql.WhereClause{Condition: "email=?", Args: []any{"eve@example.com"}}, nil
+					},
+				),
+				mql.WithConverter(
+					"name",
+					func(columnName string, comparisonOp mql.ComparisonOp, value *string) (*mql.WhereClause, error) {
+						return &mql.WhereClause{Condition: "duplicated-Converter name=?", Args: []any{"alice"}}, nil
+					},
+				),
+			},
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: "duplicated convert: invalid parameter",
+		},
+		{
+			name:  "success-WithConverter",
+			query: "(name = \"alice\" and email=\"eve@example.com\") or age > 21",
+			model: testModel{},
+			opts: []mql.Option{
+				mql.WithConverter(
+					"name",
+					func(columnName string, comparisonOp mql.ComparisonOp, value *string) (*mql.WhereClause, error) {
+						return &mql.WhereClause{
+							// intentionally not the correct condition and
+							// args, but this makes verifying the test
+							// easier.
+							Condition: fmt.Sprintf("success-WithConverter: %s%s?", columnName, comparisonOp),
+							Args:      []any{"success-WithConverter: alice"},
+						}, nil
+					},
+				),
+			},
+			want: &mql.WhereClause{
+				Condition: "((success-WithConverter: name=? and email=?) or age>?)",
+				Args:      []any{"success-WithConverter: alice", "eve@example.com", 21},
+			},
+		},
+		{
+			name:  "success-WithMultiConverters",
+			query: "(name = \"alice\" and email=\"eve@example.com\") or age > 21",
+			model: testModel{},
+			opts: []mql.Option{
+				mql.WithConverter(
+					"name",
+					func(columnName string, comparisonOp mql.ComparisonOp, value *string) (*mql.WhereClause, error) {
+						return &mql.WhereClause{
+							// intentionally not the correct condition and
+							// args, but this makes verifying the test
+							// easier.
+							Condition: fmt.Sprintf("success-WithConverter: %s%s?", columnName, comparisonOp),
+							Args:      []any{"success-WithConverter: alice"},
+						}, nil
+					},
+				),
+				mql.WithConverter(
+					"email",
+					func(columnName string, comparisonOp mql.ComparisonOp, value *string) (*mql.WhereClause, error) {
+						return &mql.WhereClause{
+							// intentionally not the correct condition and
+							// args, but this makes verifying the test
+							// easier.
+							Condition: fmt.Sprintf("success-WithConverter: %s%s?", columnName, comparisonOp),
+							Args:      []any{"success-WithConverter: email=\"eva@example.com\""},
+						}, nil
+					},
+				),
+			},
+			want: &mql.WhereClause{
+				Condition: "((success-WithConverter: name=? and success-WithConverter: email=?) or age>?)",
+				Args:      []any{"success-WithConverter: alice", "success-WithConverter: email=\"eva@example.com\"", 21},
+			},
+		},
+		{
+			name:  "success-WithConverters",
+			query: "(name = \"alice\" and email=\"eve@example.com\") or age > 21",
+			model: testModel{},
+			opts: []mql.Option{
+				mql.WithConverters(map[string]mql.ValidateConvertFunc{
+					"name": func(columnName string, comparisonOp mql.ComparisonOp, value *string) (*mql.WhereClause, error) {
+						return &mql.WhereClause{Condition: fmt.Sprintf("lower(%s)%slower(?)", columnName, comparisonOp), Args: []any{*value}}, nil
+					},
+					"age": func(columnName string, comparisonOp mql.ComparisonOp, value *string) (*mql.WhereClause, error) {
+						return &mql.WhereClause{Condition: fmt.Sprintf("%s%s?::int", columnName, comparisonOp), Args: []any{*value}}, nil
+					},
+				}),
+			},
+			want: &mql.WhereClause{
+				Condition: "((lower(name)=lower(?) and email=?) or age>?::int)",
+				Args:      []any{"alice", "eve@example.com", "21"},
+			},
+		},
+		{
+			name:  "err-WithConverters-duplicated-converter",
+			query: "name=\"alice\"",
+			model: testModel{},
+			opts: []mql.Option{
+				mql.WithConverter("name", func(string, mql.ComparisonOp, *string) (*mql.WhereClause, error) { return nil, nil }),
+				mql.WithConverters(map[string]mql.ValidateConvertFunc{
+					"name": func(string, mql.ComparisonOp, *string) (*mql.WhereClause, error) { return nil, nil },
+				}),
+			},
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: "duplicated convert: invalid parameter",
+		},
+		{
+			name:  "success-WithDefaultConverter",
+			query: "(name = \"alice\" and email=\"eve@example.com\") or age > 21",
+			model: testModel{},
+			opts: []mql.Option{
+				mql.WithConverter(
+					"name",
+					func(columnName string, comparisonOp mql.ComparisonOp, value *string) (*mql.WhereClause, error) {
+						return &mql.WhereClause{Condition: fmt.Sprintf("%s%s?", columnName, comparisonOp), Args: []any{"converter: " + *value}}, nil
+					},
+				),
+				mql.WithDefaultConverter(
+					func(columnName string, comparisonOp mql.ComparisonOp, value *string) (*mql.WhereClause, error) {
+						if columnName == "age" {
+							// use the default conversion
+							return nil, nil
+						}
+						return &mql.WhereClause{Condition: fmt.Sprintf("%s%s?::citext", columnName, comparisonOp), Args: []any{"default: " + *value}}, nil
+					},
+				),
+			},
+			want: &mql.WhereClause{
+				Condition: "((name=? and email=?::citext) or age>?)",
+				Args:      []any{"converter: alice", "default: eve@example.com", 21},
+			},
+		},
+		{
+			name:  "err-WithDefaultConverter",
+			query: "name=\"alice\"",
+			model: testModel{},
+			opts: []mql.Option{
+				mql.WithDefaultConverter(func(string, mql.ComparisonOp, *string) (*mql.WhereClause, error) {
+					return nil, mql.ErrInvalidParameter
+				}),
+			},
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: "mql.exprToWhereClause: invalid parameter",
+		},
+		{
+			name:            "err-WithDefaultConverter-missing-func",
+			query:           "name=\"alice\"",
+			model:           testModel{},
+			opts:            []mql.Option{mql.WithDefaultConverter(nil)},
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: "missing ConvertToSqlFunc: invalid parameter",
+		},
+		{
+			name:  "success-quoted-map-column",
+			query: `"labels.env"="prod"`,
+			model: testModel{},
+			want: &mql.WhereClause{
+				Condition: "labels->>?=?",
+				Args:      []any{"env", "prod"},
+			},
+		},
+		{
+			name:  "success-WithQuotedColumnChars",
+			query: "`member number`=\"1\" and 'Created-At'>\"2023-01-01\"",
+			model: testModel{},
+			opts: []mql.Option{
+				mql.WithQuotedColumnChars(" -"),
+				mql.WithColumnMap(map[string]string{"member number": "member_number", "created-at": "created_at"}),
+			},
+			want: &mql.WhereClause{
+				Condition: "(member_number=? and created_at>=?)",
+				Args:      []any{"1", time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC)},
+			},
+		},
+		{
+			name:            "err-quoted-column-invalid-char",
+			query:           "`member number`=\"1\"",
+			model:           testModel{},
+			opts:            []mql.Option{mql.WithColumnMap(map[string]string{"member number": "member_number"})},
+			wantErrIs:       mql.ErrInvalidColumn,
+			wantErrContains: `invalid column "member number" contains ' '`,
+		},
+		{
+			name:            "err-quoted-column-not-in-model",
+			query:           "`member number`=\"1\"",
+			model:           testModel{},
+			opts:            []mql.Option{mql.WithQuotedColumnChars(" ")},
+			wantErrIs:       mql.ErrInvalidColumn,
+			wantErrContains: `invalid column "member number"`,
+		},
+		{
+			name:  "success-WithTableAlias",
+			query: `name="alice" and (labels.env="prod" or email%"example")`,
+			model: testModel{},
+			opts:  []mql.Option{mql.WithTableAlias("u"), mql.WithNamedParams(":")},
+			want: &mql.WhereClause{
+				Condition: "(u.name=:name_1 and (u.labels->>:labels_1=:labels_2 or u.email like :email_1 escape '\\'))",
+				NamedArgs: map[string]any{"name_1": "alice", "labels_1": "env", "labels_2": "prod", "email_1": "%example%"},
+			},
+		},
+		{
+			name:  "success-WithTableAlias-empty-string-as-null",
+			query: `email=""`,
+			model: testModel{},
+			opts:  []mql.Option{mql.WithTableAlias("u"), mql.WithEmptyStringAsNull("email")},
+			want:  &mql.WhereClause{Condition: "u.email is null"},
+		},
+		{
+			name:            "err-WithTableAlias-invalid",
+			query:           `name="alice"`,
+			model:           testModel{},
+			opts:            []mql.Option{mql.WithTableAlias("u; drop table users")},
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: `alias "u; drop table users" can only contain letters, digits and underscores`,
+		},
+		{
+			name:            "err-WithTableAlias-missing",
+			query:           `name="alice"`,
+			model:           testModel{},
+			opts:            []mql.Option{mql.WithTableAlias("")},
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: "missing alias",
+		},
+		{
+			name:            "err-ignored-field-used-in-query",
+			query:           "email=\"eve@example.com\" or name=\"alice\"",
+			model:           &testModel{},
+			opts:            []mql.Option{mql.WithIgnoredFields("Name")},
+			wantErrContains: `mql.exprToWhereClause: invalid right expr: mql.exprToWhereClause: invalid column "name"`,
+		},
+		{
+			name:            "err-missing-query",
+			query:           "",
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: "missing query: invalid parameter",
+		},
+		{
+			name:  "success-WithAllowEmptyQuery",
+			query: "",
+			model: testModel{},
+			opts:  []mql.Option{mql.WithAllowEmptyQuery()},
+			want:  &mql.WhereClause{Condition: "1=1"},
+		},
+		{
+			name:  "success-WithAllowEmptyQuery-whitespace",
+			query: " \t\n ",
+			model: testModel{},
+			opts:  []mql.Option{mql.WithAllowEmptyQuery(), mql.WithPgPlaceholders()},
+			want:  &mql.WhereClause{Condition: "1=1"},
+		},
+		{
+			name:  "success-WithAllowEmptyQuery-non-empty-query",
+			query: "name=\"alice\"",
+			model: testModel{},
+			opts:  []mql.Option{mql.WithAllowEmptyQuery()},
+			want: &mql.WhereClause{
+				Condition: "name=?",
+				Args:      []any{"alice"},
+			},
+		},
+		{
+			name:            "err-WithAllowEmptyQuery-missing-model",
+			query:           "",
+			opts:            []mql.Option{mql.WithAllowEmptyQuery()},
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: "missing model: invalid parameter",
+		},
+		{
+			name:            "err-model",
+			query:           "name=alice",
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: "missing model: invalid parameter",
+		},
+		{
+			name:            "err-invalid-query",
+			query:           "name!alice",
+			model:           testModel{},
+			wantErrIs:       mql.ErrInvalidNotEqual,
+			wantErrContains: `invalid "!=" token, got "!a"`,
+		},
+		{
+			name:            "err-invalid-WithConverter-opt",
+			query:           "name=\"alice\"",
+			model:           testModel{},
+			opts:            []mql.Option{mql.WithConverter("TestColumn", nil)},
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: "missing ConvertToSqlFunc: invalid parameter",
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			assert, require := assert.New(t), require.New(t)
+			whereClause, err := mql.Parse(tc.query, tc.model, tc.opts...)
+			if tc.wantErrContains != "" {
+				require.Errorf(err, "expected err for %s, but got %v", tc.query, whereClause)
+				assert.Empty(whereClause)
+				if tc.wantErrIs != nil {
 					assert.ErrorIs(err, tc.wantErrIs)
 				}
 				assert.ErrorContains(err, tc.wantErrContains)
 				return
 			}
-			require.NoErrorf(err, "unexpected err for %s, but got %v", tc.query, whereClause)
+			require.NoErrorf(err, "unexpected err for %s, but got %v", tc.query, whereClause)
+			assert.Equal(tc.want, whereClause)
+		})
+	}
+}
+
+func TestParse_ParseError(t *testing.T) {
+	t.Parallel()
+	assert, require := assert.New(t), require.New(t)
+	w, err := mql.Parse(`name="alice" and (age > 21`, testModel{})
+	require.Error(err)
+	assert.Empty(w)
+	assert.ErrorIs(err, mql.ErrMissingClosingParen)
+	var pErr *mql.ParseError
+	require.ErrorAs(err, &pErr)
+	assert.Equal(26, pErr.Pos)
+	require.NotNil(pErr.Partial)
+	partial, ok := pErr.Partial.(*mql.LogicalExpr)
+	require.True(ok)
+	assert.Equal(mql.AndOp, partial.LogicalOp)
+	assert.Equal("name", partial.LeftExpr.(*mql.ComparisonExpr).Column)
+	assert.Equal("age", partial.RightExpr.(*mql.ComparisonExpr).Column)
+
+	// errors which aren't syntax errors aren't a ParseError
+	_, err = mql.Parse(`nickname="alice"`, testModel{})
+	require.Error(err)
+	assert.False(errors.As(err, &pErr))
+}
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name         string
+		query        string
+		model        any
+		opts         []mql.Option
+		wantErrIs    []error
+		wantContains []string
+	}{
+		{
+			name:  "valid",
+			query: `name="alice" and (age > 21 or length < 1.5)`,
+			model: testModel{},
+		},
+		{
+			name:  "valid-WithAllowEmptyQuery",
+			query: "  ",
+			model: testModel{},
+			opts:  []mql.Option{mql.WithAllowEmptyQuery()},
+		},
+		{
+			name:         "every-invalid-column-and-value",
+			query:        `nickname="alice" and (age > "old" or length < 1.5) or birth_place="boston"`,
+			model:        testModel{},
+			wantErrIs:    []error{mql.ErrInvalidColumn, mql.ErrInvalidParameter, mql.ErrInvalidColumn},
+			wantContains: []string{`"nickname"`, `"old"`, `"birth_place"`},
+		},
+		{
+			name:         "every-invalid-enum-value",
+			query:        `name="alcie" or name="bbo" or name="bob"`,
+			model:        testModel{},
+			opts:         []mql.Option{mql.WithEnum("name", []string{"alice", "bob"})},
+			wantErrIs:    []error{mql.ErrInvalidEnumValue, mql.ErrInvalidEnumValue},
+			wantContains: []string{`"alcie"`, `"bbo"`},
+		},
+		{
+			name:         "syntax-error",
+			query:        `name="alice" and (age > 21`,
+			model:        testModel{},
+			wantErrIs:    []error{mql.ErrMissingClosingParen},
+			wantContains: []string{"missing closing paren"},
+		},
+		{
+			name:         "every-syntax-error",
+			query:        `name= and nickname="alice" or (age> or length<1.5)`,
+			model:        testModel{},
+			wantErrIs:    []error{mql.ErrMissingComparisonValue, mql.ErrMissingComparisonValue, mql.ErrInvalidColumn},
+			wantContains: []string{`"name= "`, `"age> "`, `"nickname"`},
+		},
+		{
+			name:         "missing-query",
+			model:        testModel{},
+			wantErrIs:    []error{mql.ErrInvalidParameter},
+			wantContains: []string{"missing query"},
+		},
+		{
+			name:         "missing-model",
+			query:        `name="alice"`,
+			wantErrIs:    []error{mql.ErrInvalidParameter},
+			wantContains: []string{"missing model"},
+		},
+		{
+			name:         "invalid-model",
+			query:        `name="alice"`,
+			model:        1,
+			wantErrIs:    []error{mql.ErrInvalidParameter},
+			wantContains: []string{"model must be a struct"},
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			assert, require := assert.New(t), require.New(t)
+			errs := mql.Validate(tc.query, tc.model, tc.opts...)
+			require.Len(errs, len(tc.wantErrIs))
+			for i, err := range errs {
+				assert.ErrorIs(err, tc.wantErrIs[i])
+				assert.ErrorContains(err, tc.wantContains[i])
+			}
+		})
+	}
+}
+
+// staticDescriber is a ModelDescriber which doesn't use reflection
+type staticDescriber struct {
+	fields []mql.FieldDescriptor
+	err    error
+}
+
+func (d staticDescriber) DescribeModel(model any) ([]mql.FieldDescriptor, error) {
+	return d.fields, d.err
+}
+
+func TestParse_WithModelDescriber(t *testing.T) {
+	t.Parallel()
+	d := staticDescriber{
+		fields: []mql.FieldDescriptor{
+			{Name: "Name", Type: "string"},
+			{Name: "Age", Type: "*int"},
+			{Name: "CreatedAt", Type: "time.Time"},
+			{Name: "Labels", Type: "map[string]string"},
+		},
+	}
+	tests := []struct {
+		name            string
+		query           string
+		model           any
+		describer       mql.ModelDescriber
+		opts            []mql.Option
+		want            *mql.WhereClause
+		wantErrIs       error
+		wantErrContains string
+	}{
+		{
+			name:      "success",
+			query:     `name="alice" and age > 21 and created_at > "2023-01-01" and labels.env="prod"`,
+			model:     struct{}{},
+			describer: d,
+			want: &mql.WhereClause{
+				Condition: "(((name=? and age>?) and created_at>=?) and labels->>?=?)",
+				Args:      []any{"alice", 21, time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC), "env", "prod"},
+			},
+		},
+		{
+			name:      "success-reflect-describer",
+			query:     `name="alice"`,
+			model:     testModel{},
+			describer: mql.ReflectDescriber{},
+			want: &mql.WhereClause{
+				Condition: "name=?",
+				Args:      []any{"alice"},
+			},
+		},
+		{
+			name:            "err-ignored-field",
+			query:           `age > 21`,
+			model:           struct{}{},
+			describer:       d,
+			opts:            []mql.Option{mql.WithIgnoredFields("Age")},
+			wantErrIs:       mql.ErrInvalidColumn,
+			wantErrContains: `invalid column "age"`,
+		},
+		{
+			name:            "err-invalid-value",
+			query:           `age > "old"`,
+			model:           struct{}{},
+			describer:       d,
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: `"old"`,
+		},
+		{
+			name:            "err-describer",
+			query:           `name="alice"`,
+			model:           struct{}{},
+			describer:       staticDescriber{err: mql.ErrInvalidParameter},
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: "mql.modelValidators: invalid parameter",
+		},
+		{
+			name:            "err-missing-describer",
+			query:           `name="alice"`,
+			model:           struct{}{},
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: "missing model describer",
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			assert, require := assert.New(t), require.New(t)
+			opts := append([]mql.Option{mql.WithModelDescriber(tc.describer)}, tc.opts...)
+			whereClause, err := mql.Parse(tc.query, tc.model, opts...)
+			if tc.wantErrContains != "" {
+				require.Error(err)
+				assert.Empty(whereClause)
+				assert.ErrorIs(err, tc.wantErrIs)
+				assert.ErrorContains(err, tc.wantErrContains)
+				return
+			}
+			require.NoError(err)
 			assert.Equal(tc.want, whereClause)
 		})
 	}
 }
 
+// fixedNow returns 2023-06-02 15:30 UTC for WithTimeNowFunc
+func fixedNow() time.Time {
+	return time.Date(2023, 6, 2, 15, 30, 0, 0, time.UTC)
+}
+
 func pointer[T any](input T) *T {
 	return &input
 }
@@ -359,6 +1344,441 @@ func Fuzz_mqlParse(f *testing.F) {
 	})
 }
 
+func TestParse_WithContextConverter(t *testing.T) {
+	t.Parallel()
+	// converter records the context it's called with
+	converter := func(got *mql.ConvertContext) mql.ContextConvertFunc {
+		return func(ctx mql.ConvertContext, comparisonOp mql.ComparisonOp, value *string) (*mql.WhereClause, error) {
+			*got = ctx
+			return &mql.WhereClause{
+				Condition: fmt.Sprintf("%s%sto_timestamp(?)", ctx.ColumnName, comparisonOp),
+				Args:      []any{*value},
+			}, nil
+		}
+	}
+	tests := []struct {
+		name            string
+		query           string
+		model           any
+		column          string
+		opts            []mql.Option
+		want            *mql.WhereClause
+		wantCtx         mql.ConvertContext
+		wantErrIs       error
+		wantErrContains string
+	}{
+		{
+			name:   "success",
+			query:  `name="alice" and created_at > "2023-01-01"`,
+			model:  testModel{},
+			column: "CreatedAt",
+			want: &mql.WhereClause{
+				Condition: "(name=? and created_at>to_timestamp(?))",
+				Args:      []any{"alice", "2023-01-01"},
+			},
+			wantCtx: mql.ConvertContext{
+				Column:      "created_at",
+				ColumnName:  "created_at",
+				FieldName:   "CreatedAt",
+				FieldType:   "time.Time",
+				ReflectType: reflect.TypeOf(time.Time{}),
+				Pos:         17,
+				Context:     context.Background(),
+			},
+		},
+		{
+			name:   "success-column-map",
+			query:  `created > "2023-01-01"`,
+			model:  &testModel{},
+			column: "created_at",
+			opts:   []mql.Option{mql.WithColumnMap(map[string]string{"created": "created_at"})},
+			want: &mql.WhereClause{
+				Condition: "created_at>to_timestamp(?)",
+				Args:      []any{"2023-01-01"},
+			},
+			wantCtx: mql.ConvertContext{
+				Column:      "created",
+				ColumnName:  "created_at",
+				FieldName:   "CreatedAt",
+				FieldType:   "time.Time",
+				ReflectType: reflect.TypeOf(time.Time{}),
+				Context:     context.Background(),
+			},
+		},
+		{
+			name:   "success-model-describer",
+			query:  `birthday < "2000-01-01"`,
+			model:  struct{}{},
+			column: "birthday",
+			opts: []mql.Option{mql.WithModelDescriber(staticDescriber{
+				fields: []mql.FieldDescriptor{{Name: "Birthday", Type: "*time.Time"}},
+			})},
+			want: &mql.WhereClause{
+				Condition: "birthday<to_timestamp(?)",
+				Args:      []any{"2000-01-01"},
+			},
+			wantCtx: mql.ConvertContext{
+				Column:     "birthday",
+				ColumnName: "birthday",
+				FieldName:  "Birthday",
+				FieldType:  "*time.Time",
+				Context:    context.Background(),
+			},
+		},
+		{
+			name:            "err-invalid-column",
+			query:           `deleted_at > "2023-01-01"`,
+			model:           testModel{},
+			column:          "deleted_at",
+			wantErrIs:       mql.ErrInvalidColumn,
+			wantErrContains: `invalid column "deleted_at"`,
+		},
+		{
+			name:            "err-duplicated",
+			query:           `created_at > "2023-01-01"`,
+			model:           testModel{},
+			column:          "created_at",
+			opts:            []mql.Option{mql.WithContextConverter("CreatedAt", converter(&mql.ConvertContext{}))},
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: `duplicated convert for "CreatedAt"`,
+		},
+		{
+			name:            "err-missing-func",
+			query:           `created_at > "2023-01-01"`,
+			model:           testModel{},
+			opts:            []mql.Option{mql.WithContextConverter("created_at", nil)},
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: `missing ContextConvertFunc for "created_at"`,
+		},
+		{
+			name:            "err-missing-column",
+			query:           `created_at > "2023-01-01"`,
+			model:           testModel{},
+			opts:            []mql.Option{mql.WithContextConverter("", converter(&mql.ConvertContext{}))},
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: "missing column",
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert, require := assert.New(t), require.New(t)
+			var gotCtx mql.ConvertContext
+			opts := tc.opts
+			if tc.column != "" {
+				opts = append([]mql.Option{mql.WithContextConverter(tc.column, converter(&gotCtx))}, opts...)
+			}
+			got, err := mql.Parse(tc.query, tc.model, opts...)
+			if tc.wantErrContains != "" {
+				require.Error(err)
+				assert.Nil(got)
+				if tc.wantErrIs != nil {
+					assert.ErrorIs(err, tc.wantErrIs)
+				}
+				assert.ErrorContains(err, tc.wantErrContains)
+				return
+			}
+			require.NoError(err)
+			assert.Equal(tc.want, got)
+			assert.Equal(tc.wantCtx, gotCtx)
+		})
+	}
+}
+
+func TestParseContext(t *testing.T) {
+	t.Parallel()
+	type ctxKey struct{}
+	t.Run("success", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		ctx := context.WithValue(context.Background(), ctxKey{}, "tenant-1")
+		converter := func(c mql.ConvertContext, comparisonOp mql.ComparisonOp, value *string) (*mql.WhereClause, error) {
+			return &mql.WhereClause{
+				Condition: fmt.Sprintf("(tenant_id=? and %s%s?)", c.ColumnName, comparisonOp),
+				Args:      []any{c.Context.Value(ctxKey{}), *value},
+			}, nil
+		}
+		got, err := mql.ParseContext(ctx, `name="alice"`, testModel{}, mql.WithContextConverter("name", converter))
+		require.NoError(err)
+		assert.Equal(&mql.WhereClause{Condition: "(tenant_id=? and name=?)", Args: []any{"tenant-1", "alice"}}, got)
+	})
+	t.Run("err-canceled", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		got, err := mql.ParseContext(ctx, `name="alice"`, testModel{})
+		require.Error(err)
+		assert.Nil(got)
+		assert.ErrorIs(err, context.Canceled)
+	})
+	t.Run("err-canceled-by-converter", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		converter := func(c mql.ConvertContext, comparisonOp mql.ComparisonOp, value *string) (*mql.WhereClause, error) {
+			cancel()
+			return &mql.WhereClause{Condition: "name=?", Args: []any{*value}}, nil
+		}
+		got, err := mql.ParseContext(ctx, `name="alice" and email="alice@example.com"`, testModel{}, mql.WithContextConverter("name", converter))
+		require.Error(err)
+		assert.Nil(got)
+		assert.ErrorIs(err, context.Canceled)
+	})
+	t.Run("err-deadline-exceeded", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+		defer cancel()
+		got, err := mql.ParseContext(ctx, `name="alice"`, testModel{})
+		require.Error(err)
+		assert.Nil(got)
+		assert.ErrorIs(err, context.DeadlineExceeded)
+	})
+	t.Run("err-missing-context", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		var ctx context.Context
+		got, err := mql.ParseContext(ctx, `name="alice"`, testModel{})
+		require.Error(err)
+		assert.Nil(got)
+		assert.ErrorIs(err, mql.ErrInvalidParameter)
+		assert.ErrorContains(err, "missing context")
+	})
+}
+
+func TestParseFor(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name            string
+		parse           func(query string, opt ...mql.Option) (*mql.WhereClause, error)
+		query           string
+		opts            []mql.Option
+		want            *mql.WhereClause
+		wantErrIs       error
+		wantErrContains string
+	}{
+		{
+			name:  "struct",
+			parse: mql.ParseFor[testModel],
+			query: `name="alice" and age>21`,
+			want:  &mql.WhereClause{Condition: "(name=? and age>?)", Args: []any{"alice", 21}},
+		},
+		{
+			name:  "pointer",
+			parse: mql.ParseFor[*testModel],
+			query: `name="alice"`,
+			want:  &mql.WhereClause{Condition: "name=?", Args: []any{"alice"}},
+		},
+		{
+			name:  "with-opts",
+			parse: mql.ParseFor[testModel],
+			query: `name="alice"`,
+			opts:  []mql.Option{mql.WithPgPlaceholders()},
+			want:  &mql.WhereClause{Condition: "name=$1", Args: []any{"alice"}},
+		},
+		{
+			name:  "table-tag",
+			parse: mql.ParseFor[*userModel],
+			query: `name="alice"`,
+			want:  &mql.WhereClause{Condition: "users.name=?", Args: []any{"alice"}},
+		},
+		{
+			name:            "err-invalid-column",
+			parse:           mql.ParseFor[testModel],
+			query:           `nickname="alice"`,
+			wantErrIs:       mql.ErrInvalidColumn,
+			wantErrContains: `invalid column "nickname"`,
+		},
+		{
+			name:            "err-not-a-struct",
+			parse:           mql.ParseFor[string],
+			query:           `name="alice"`,
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: "model must be a struct",
+		},
+		{
+			name:            "err-missing-model",
+			parse:           mql.ParseFor[any],
+			query:           `name="alice"`,
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: "missing model",
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert, require := assert.New(t), require.New(t)
+			got, err := tc.parse(tc.query, tc.opts...)
+			if tc.wantErrContains != "" {
+				require.Error(err)
+				assert.Nil(got)
+				assert.ErrorIs(err, tc.wantErrIs)
+				assert.ErrorContains(err, tc.wantErrContains)
+				return
+			}
+			require.NoError(err)
+			assert.Equal(tc.want, got)
+		})
+	}
+}
+
+func TestParseReader(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name            string
+		r               io.Reader
+		opts            []mql.Option
+		want            *mql.WhereClause
+		wantErrIs       error
+		wantErrContains string
+	}{
+		{
+			name: "success",
+			r:    strings.NewReader(`name="alice" and age>21`),
+			want: &mql.WhereClause{Condition: "(name=? and age>?)", Args: []any{"alice", 21}},
+		},
+		{
+			name: "with-opts",
+			r:    strings.NewReader(`name="alice"`),
+			opts: []mql.Option{mql.WithPgPlaceholders()},
+			want: &mql.WhereClause{Condition: "name=$1", Args: []any{"alice"}},
+		},
+		{
+			name: "max-query-length-disabled",
+			r:    strings.NewReader(`name="` + strings.Repeat("a", 1000) + `"` + strings.Repeat(` or name="alice"`, 1000)),
+			opts: []mql.Option{mql.WithMaxQueryLength(0), mql.WithMaxTokens(0)},
+		},
+		{
+			name:            "err-too-long",
+			r:               endlessReader{},
+			opts:            []mql.Option{mql.WithMaxQueryLength(10)},
+			wantErrIs:       mql.ErrQueryTooLong,
+			wantErrContains: "more than 10 bytes",
+		},
+		{
+			name:            "err-read",
+			r:               iotest.ErrReader(errors.New("connection reset")),
+			wantErrContains: "connection reset",
+		},
+		{
+			name:            "err-parse",
+			r:               strings.NewReader(`name=`),
+			wantErrIs:       mql.ErrMissingComparisonValue,
+			wantErrContains: "missing comparison value",
+		},
+		{
+			name:            "err-missing-reader",
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: "missing reader",
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert, require := assert.New(t), require.New(t)
+			got, err := mql.ParseReader(tc.r, testModel{}, tc.opts...)
+			if tc.wantErrContains != "" {
+				require.Error(err)
+				assert.Nil(got)
+				if tc.wantErrIs != nil {
+					assert.ErrorIs(err, tc.wantErrIs)
+				}
+				assert.ErrorContains(err, tc.wantErrContains)
+				return
+			}
+			require.NoError(err)
+			if tc.want != nil {
+				assert.Equal(tc.want, got)
+			}
+		})
+	}
+}
+
+// endlessReader is an io.Reader which never ends
+type endlessReader struct{}
+
+func (endlessReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 'a'
+	}
+	return len(p), nil
+}
+
+// Fuzz_mqlParseWithInlineValues verifies that inlined values can't escape
+// their string literals: once the literals are removed, the condition must
+// only contain columns, operators and numbers.  The literals must also
+// unquote to the same values returned as args without WithInlineValues.
+func Fuzz_mqlParseWithInlineValues(f *testing.F) {
+	tc := []string{
+		`name="alice"`,
+		`name="alice's"`,
+		`name="'; drop table users; --"`,
+		`name="alice\\' or 1=1 --"`,
+		`name="it''s" or email%"'"`,
+		`name="?" and email="'?'"`,
+		`labels.env="prod' or '1'='1"`,
+		`age>21 and length<1.5`,
+		`length="NaN"`,
+		"name=`alice\x00`",
+	}
+	for _, tc := range tc {
+		f.Add(tc)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		where, err := mql.Parse(s, testModel{}, mql.WithInlineValues())
+		if err != nil {
+			return
+		}
+		assert.Empty(t, where.Args)
+		withArgs, err := mql.Parse(s, testModel{})
+		require.NoError(t, err)
+
+		var remaining strings.Builder
+		var literals []string
+		// the escape clause of like conditions isn't an inlined value
+		cond := strings.ReplaceAll(where.Condition, ` escape '\'`, " escape")
+		for i := 0; i < len(cond); i++ {
+			if cond[i] != '\'' {
+				remaining.WriteByte(cond[i])
+				continue
+			}
+			var lit strings.Builder
+			for i++; ; i++ {
+				require.Lessf(t, i, len(cond), "unterminated literal in: %s", cond)
+				if cond[i] == '\'' {
+					if i+1 < len(cond) && cond[i+1] == '\'' {
+						lit.WriteByte('\'')
+						i++
+						continue
+					}
+					break
+				}
+				lit.WriteByte(cond[i])
+			}
+			literals = append(literals, lit.String())
+			remaining.WriteString("?")
+		}
+		var stringArgs []string
+		for _, a := range withArgs.Args {
+			if s, ok := a.(string); ok {
+				stringArgs = append(stringArgs, s)
+			}
+		}
+		assert.Equal(t, stringArgs, literals)
+
+		allowed := map[string]bool{"and": true, "or": true, "like": true, "escape": true, "date": true}
+		modelType := reflect.TypeOf(testModel{})
+		for i := 0; i < modelType.NumField(); i++ {
+			allowed[strings.ToLower(modelType.Field(i).Name)] = true
+		}
+		for _, w := range strings.FieldsFunc(strings.ToLower(remaining.String()), func(r rune) bool {
+			return !(r >= 'a' && r <= 'z' || r == '_')
+		}) {
+			assert.Truef(t, allowed[strings.ReplaceAll(w, "_", "")], "unexpected word %q in: %s", w, where.Condition)
+		}
+	})
+}
+
 var sqlKeywordsExceptLike = []string{
 	"select", "from", "where", "join", "left", "right", "inner", "outer",
 	"on", "group", "by", "order", "having", "insert", "update", "delete",
@@ -5,6 +5,8 @@ package mql
 
 import (
 	"database/sql"
+	"fmt"
+	"math"
 	"reflect"
 	"testing"
 	"time"
@@ -37,7 +39,7 @@ func Test_exprToWhereClause(t *testing.T) {
 
 	tests := []struct {
 		name            string
-		expr            expr
+		expr            Expr
 		validators      map[string]validator
 		opt             []Option
 		want            *WhereClause
@@ -66,10 +68,10 @@ func Test_exprToWhereClause(t *testing.T) {
 		},
 		{
 			name: "invalid-float",
-			expr: &comparisonExpr{
-				column:       "length",
-				comparisonOp: "=",
-				value:        pointer("1.11."),
+			expr: &ComparisonExpr{
+				Column:       "length",
+				ComparisonOp: "=",
+				Value:        pointer("1.11."),
 			},
 			validators:      testValidators,
 			wantErrIs:       ErrInvalidParameter,
@@ -77,10 +79,10 @@ func Test_exprToWhereClause(t *testing.T) {
 		},
 		{
 			name: "invalid-int",
-			expr: &comparisonExpr{
-				column:       "age",
-				comparisonOp: "=",
-				value:        pointer("1.11"),
+			expr: &ComparisonExpr{
+				Column:       "age",
+				ComparisonOp: "=",
+				Value:        pointer("1.11"),
 			},
 			validators:      testValidators,
 			wantErrIs:       ErrInvalidParameter,
@@ -88,17 +90,17 @@ func Test_exprToWhereClause(t *testing.T) {
 		},
 		{
 			name: "err-invalid-logicalExpr-left",
-			expr: &logicalExpr{
-				leftExpr: &comparisonExpr{
-					column:       "name",
-					comparisonOp: "",
-					value:        nil,
+			expr: &LogicalExpr{
+				LeftExpr: &ComparisonExpr{
+					Column:       "name",
+					ComparisonOp: "",
+					Value:        nil,
 				},
-				logicalOp: "and",
-				rightExpr: &comparisonExpr{
-					column:       "name",
-					comparisonOp: "=",
-					value:        pointer("alice"),
+				LogicalOp: "and",
+				RightExpr: &ComparisonExpr{
+					Column:       "name",
+					ComparisonOp: "=",
+					Value:        pointer("alice"),
 				},
 			},
 			validators:      testValidators,
@@ -106,9 +108,9 @@ func Test_exprToWhereClause(t *testing.T) {
 		},
 		{
 			name: "err-missing-logicalOp",
-			expr: &logicalExpr{
-				leftExpr:  testExpr,
-				rightExpr: testExpr,
+			expr: &LogicalExpr{
+				LeftExpr:  testExpr,
+				RightExpr: testExpr,
 			},
 			validators:      testValidators,
 			wantErrIs:       ErrMissingLogicalOp,
@@ -153,6 +155,54 @@ func Test_fieldValidators(t *testing.T) {
 	})
 }
 
+func Test_cachedModelFields(t *testing.T) {
+	t.Parallel()
+	t.Run("cached", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		type cachedModel struct {
+			Name string
+			Age  int
+		}
+		m, err := cachedModelFields(reflect.ValueOf(cachedModel{}), getDefaultOptions())
+		require.NoError(err)
+		assert.Equal([]FieldDescriptor{{Name: "Name", Type: "string"}, {Name: "Age", Type: "int"}}, m.fields)
+		assert.Equal(reflect.TypeOf(0), m.types["age"])
+		cached, ok := modelCache.Load(reflect.TypeOf(cachedModel{}))
+		require.True(ok)
+		assert.Same(m, cached)
+
+		again, err := cachedModelFields(reflect.ValueOf(cachedModel{Name: "alice"}), getDefaultOptions())
+		require.NoError(err)
+		assert.Same(m, again)
+
+		_, err = cachedModelFields(reflect.ValueOf((*cachedModel)(nil)), getDefaultOptions())
+		require.Error(err)
+		assert.ErrorIs(err, ErrInvalidParameter)
+	})
+	t.Run("WithoutModelCache", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		type uncachedModel struct {
+			Name string
+		}
+		opts, err := getOpts(WithoutModelCache())
+		require.NoError(err)
+		m, err := cachedModelFields(reflect.ValueOf(uncachedModel{}), opts)
+		require.NoError(err)
+		assert.Equal([]FieldDescriptor{{Name: "Name", Type: "string"}}, m.fields)
+		_, ok := modelCache.Load(reflect.TypeOf(uncachedModel{}))
+		assert.False(ok)
+	})
+}
+
+func Test_replacePlaceholders(t *testing.T) {
+	t.Parallel()
+	pg := func(i int) string { return fmt.Sprintf("$%d", i+1) }
+	assert.Equal(t, "(a=$1 and b=$2)", replacePlaceholders("(a=? and b=?)", 2, pg))
+	assert.Equal(t, "(a=$1 and b=?)", replacePlaceholders("(a=? and b=?)", 1, pg))
+	assert.Equal(t, "a=1", replacePlaceholders("a=1", 2, pg))
+	assert.Equal(t, "$1$2", replacePlaceholders("??", 2, pg))
+}
+
 type invalidExpr struct{}
 
 func (*invalidExpr) Type() exprType {
@@ -162,3 +212,157 @@ func (*invalidExpr) Type() exprType {
 func (*invalidExpr) String() string {
 	return "unknown"
 }
+
+func (*invalidExpr) MQL() string {
+	return "unknown"
+}
+
+func Test_namedParams(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t,
+		[]string{"name_1", "age_1", "name_2", "users_name_1", "users_name_2", "__1"},
+		namedParams([]string{"name", "age", "Name", "users.name", "users_name", "é"}),
+	)
+	assert.Empty(t, namedParams(nil))
+}
+
+func Test_sqlLiteral(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name            string
+		value           any
+		want            string
+		wantErrContains string
+	}{
+		{name: "nil", value: nil, want: "null"},
+		{name: "string", value: "alice", want: "'alice'"},
+		{name: "string-with-quotes", value: `it's "ok"`, want: `'it''s "ok"'`},
+		{name: "int", value: -21, want: "-21"},
+		{name: "int64", value: int64(9223372036854775807), want: "9223372036854775807"},
+		{name: "uint8", value: uint8(21), want: "21"},
+		{name: "float32", value: float32(1.1), want: "1.1"},
+		{name: "float64", value: 1e21, want: "1e+21"},
+		{name: "bool", value: true, want: "true"},
+		{name: "time", value: time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC), want: "'2023-01-02T03:04:05Z'"},
+		{name: "err-backslash", value: `alice\`, wantErrContains: "contains a backslash"},
+		{name: "err-nul", value: "alice\x00", wantErrContains: "contains a NUL character"},
+		{name: "err-inf", value: math.Inf(1), wantErrContains: "value +Inf can't be inlined"},
+		{name: "err-unsupported", value: []byte("alice"), wantErrContains: "unsupported value type []uint8"},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			assert, require := assert.New(t), require.New(t)
+			got, err := sqlLiteral(tc.value, defaultDialect{})
+			if tc.wantErrContains != "" {
+				require.Error(err)
+				assert.Empty(got)
+				assert.ErrorIs(err, ErrInvalidParameter)
+				assert.ErrorContains(err, tc.wantErrContains)
+				return
+			}
+			require.NoError(err)
+			assert.Equal(tc.want, got)
+		})
+	}
+}
+
+func Test_parseTimeLiteral(t *testing.T) {
+	t.Parallel()
+	est := time.FixedZone("EST", -5*60*60)
+	now := time.Date(2023, 6, 1, 15, 30, 0, 0, est)
+	tests := []struct {
+		name         string
+		literal      string
+		want         time.Time
+		wantDateOnly bool
+		wantErr      bool
+	}{
+		{name: "date", literal: "2023-01-02", want: time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC), wantDateOnly: true},
+		{name: "rfc3339", literal: "2023-01-02T03:04:05Z", want: time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)},
+		{name: "rfc3339-nano", literal: "2023-01-02T03:04:05.000000006Z", want: time.Date(2023, 1, 2, 3, 4, 5, 6, time.UTC)},
+		{name: "space-with-zone", literal: "2023-01-02 03:04:05+00:00", want: time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)},
+		{name: "space-without-zone", literal: "2023-01-02 03:04:05", want: time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)},
+		{name: "t-without-zone", literal: "2023-01-02T03:04:05", want: time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)},
+		{name: "minutes", literal: "2023-12-01 14:01", want: time.Date(2023, 12, 1, 14, 1, 0, 0, time.UTC)},
+		{name: "now", literal: "now", want: now},
+		{name: "now-minus-duration", literal: "now-24h", want: now.Add(-24 * time.Hour)},
+		{name: "now-plus-duration", literal: "NOW+1h30m", want: now.Add(90 * time.Minute)},
+		{name: "now-minus-days", literal: "now-7d", want: now.AddDate(0, 0, -7)},
+		{name: "today", literal: "today", want: time.Date(2023, 6, 1, 0, 0, 0, 0, est), wantDateOnly: true},
+		{name: "today-minus-weeks", literal: "today-2w", want: time.Date(2023, 5, 18, 0, 0, 0, 0, est), wantDateOnly: true},
+		{name: "today-plus-duration", literal: "today+9h", want: time.Date(2023, 6, 1, 9, 0, 0, 0, est)},
+		{name: "err-invalid-month", literal: "2023-13-02", wantErr: true},
+		{name: "err-not-a-date", literal: "yesterday", wantErr: true},
+		{name: "err-missing-offset", literal: "now-", wantErr: true},
+		{name: "err-invalid-offset", literal: "now-1y", wantErr: true},
+		{name: "err-missing-sign", literal: "now1h", wantErr: true},
+		{name: "err-double-sign", literal: "now--1h", wantErr: true},
+		{name: "err-days-and-duration", literal: "today-1d2h", wantErr: true},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			assert, require := assert.New(t), require.New(t)
+			got, dateOnly, err := parseTimeLiteral(tc.literal, now)
+			if tc.wantErr {
+				require.Error(err)
+				assert.ErrorIs(err, ErrInvalidParameter)
+				assert.ErrorContains(err, fmt.Sprintf("value %q is not a date/time", tc.literal))
+				return
+			}
+			require.NoError(err)
+			assert.True(tc.want.Equal(got), "want %s, got %s", tc.want, got)
+			assert.Equal(tc.wantDateOnly, dateOnly)
+			assert.Equal(tc.wantDateOnly, isDateLiteral(tc.literal))
+		})
+	}
+}
+
+// Test_lintQuery_enumCaseMismatch lints the expr tree directly, since the
+// value of a case mismatched enum comparison isn't valid
+func Test_lintQuery_enumCaseMismatch(t *testing.T) {
+	t.Parallel()
+	opts, err := getOpts(
+		WithEnum("name", []string{"alice", "bob"}),
+		WithColumnMap(map[string]string{"nickname": "name"}),
+	)
+	require.NoError(t, err)
+	tests := []struct {
+		query string
+		want  []Diagnostic
+	}{
+		{query: `name="alice" or name%"li"`},
+		{query: `age=21 and name!="carol"`},
+		{
+			query: `age=21 and name="Alice"`,
+			want: []Diagnostic{
+				{Kind: EnumCaseMismatchDiagnostic, Message: `name="Alice" doesn't match the case of the enum value "alice"`, Pos: 11},
+			},
+		},
+		{
+			query: `nickname!="BOB"`,
+			want: []Diagnostic{
+				{Kind: EnumCaseMismatchDiagnostic, Message: `nickname!="BOB" doesn't match the case of the enum value "bob"`, Pos: 0},
+			},
+		},
+		{
+			query: `name%"LI"`,
+			want: []Diagnostic{
+				{Kind: EnumCaseMismatchDiagnostic, Message: `name%"LI" doesn't match the case of the enum value "alice"`, Pos: 0},
+			},
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.query, func(t *testing.T) {
+			t.Parallel()
+			assert, require := assert.New(t), require.New(t)
+			e, err := newParser(tc.query).parse()
+			require.NoError(err)
+			got, err := lintQuery(tc.query, e, nil, opts)
+			require.NoError(err)
+			assert.Equal(tc.want, got)
+		})
+	}
+}
@@ -0,0 +1,170 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+// Package mqldb provides helpers which use a database to inspect the where
+// clauses of mql queries before they're executed, so an API can warn its users
+// when a filter will match an enormous number of rows:
+//
+//	w, err := mql.Parse(`status="open"`, Ticket{}, mql.WithPgPlaceholders())
+//	if err != nil {
+//		return err
+//	}
+//	n, err := mqldb.EstimateRows(ctx, db, "tickets", w)
+//	if err != nil {
+//		return err
+//	}
+//	if n > 1_000_000 {
+//		// warn the user before executing the query
+//	}
+//
+// The package only depends on database/sql, so it can be used with any driver
+// of the supported dialects.
+package mqldb
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/mql"
+)
+
+// tableRegexp matches a table which is optionally qualified by its schema
+// (ie: tickets or public.tickets)
+var tableRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+// options are the options of the package's helpers
+type options struct {
+	withDialect mql.Dialect
+}
+
+// Option - how options are passed as args
+type Option func(*options) error
+
+func getOpts(opt ...Option) (options, error) {
+	opts := options{withDialect: mql.PostgresDialect{}}
+	for _, o := range opt {
+		if o == nil {
+			continue
+		}
+		if err := o(&opts); err != nil {
+			return opts, err
+		}
+	}
+	return opts, nil
+}
+
+// WithDialect provides the dialect of the database, which defaults to
+// mql.PostgresDialect.  Supported dialects: postgres and mysql.
+func WithDialect(d mql.Dialect) Option {
+	const op = "mqldb.WithDialect"
+	return func(o *options) error {
+		if d == nil {
+			return fmt.Errorf("%s: missing dialect: %w", op, mql.ErrInvalidParameter)
+		}
+		o.withDialect = d
+		return nil
+	}
+}
+
+// EstimateRows returns the number of rows of the table which the database's
+// query planner estimates are matched by the where clause, without executing
+// the query.  The estimate is based on the table statistics which are
+// collected by the database (pg_class and pg_stats for postgres, and the
+// index statistics for mysql), so it may be far from the actual number of
+// rows when the statistics are stale.  The where clause must use the
+// placeholders of the dialect and it can't use named params (see
+// mql.WithNamedParams) or have a Having clause.  Supported options:
+// WithDialect
+func EstimateRows(ctx context.Context, db *sql.DB, table string, w *mql.WhereClause, opt ...Option) (int64, error) {
+	const op = "mqldb.EstimateRows"
+	switch {
+	case ctx == nil:
+		return 0, fmt.Errorf("%s: missing context: %w", op, mql.ErrInvalidParameter)
+	case db == nil:
+		return 0, fmt.Errorf("%s: missing database: %w", op, mql.ErrInvalidParameter)
+	case !tableRegexp.MatchString(table):
+		return 0, fmt.Errorf("%s: invalid table %q: %w", op, table, mql.ErrInvalidParameter)
+	case w == nil:
+		return 0, fmt.Errorf("%s: missing where clause: %w", op, mql.ErrInvalidParameter)
+	case strings.TrimSpace(w.Condition) == "":
+		return 0, fmt.Errorf("%s: missing condition: %w", op, mql.ErrInvalidParameter)
+	case w.NamedArgs != nil:
+		return 0, fmt.Errorf("%s: named params aren't supported: %w", op, mql.ErrInvalidParameter)
+	case w.Having != nil:
+		return 0, fmt.Errorf("%s: having clauses aren't supported: %w", op, mql.ErrInvalidParameter)
+	}
+	opts, err := getOpts(opt...)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	var estimate func(plan []byte) (float64, error)
+	var explain string
+	switch name := opts.withDialect.Name(); name {
+	case "postgres":
+		explain, estimate = "explain (format json) select 1 from %s where %s", pgEstimate
+	case "mysql":
+		explain, estimate = "explain format=json select 1 from %s where %s", mySqlEstimate
+	default:
+		return 0, fmt.Errorf("%s: unsupported dialect %q: %w", op, name, mql.ErrInvalidParameter)
+	}
+	var plan []byte
+	if err := db.QueryRowContext(ctx, fmt.Sprintf(explain, table, w.Condition), w.Args...).Scan(&plan); err != nil {
+		return 0, fmt.Errorf("%s: unable to explain query: %w", op, err)
+	}
+	rows, err := estimate(plan)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return int64(math.Round(rows)), nil
+}
+
+// pgEstimate returns the estimated rows of a postgres json query plan
+func pgEstimate(plan []byte) (float64, error) {
+	const op = "mqldb.pgEstimate"
+	var p []struct {
+		Plan struct {
+			Rows *float64 `json:"Plan Rows"`
+		} `json:"Plan"`
+	}
+	if err := json.Unmarshal(plan, &p); err != nil {
+		return 0, fmt.Errorf("%s: unable to decode query plan: %w", op, err)
+	}
+	if len(p) == 0 || p[0].Plan.Rows == nil {
+		return 0, fmt.Errorf("%s: query plan is missing its rows", op)
+	}
+	return *p[0].Plan.Rows, nil
+}
+
+// mySqlEstimate returns the estimated rows of a mysql json query plan, which
+// are the rows examined by the table's scan filtered by the condition
+func mySqlEstimate(plan []byte) (float64, error) {
+	const op = "mqldb.mySqlEstimate"
+	var p struct {
+		QueryBlock struct {
+			Table *struct {
+				Rows     float64 `json:"rows_examined_per_scan"`
+				Filtered string  `json:"filtered"`
+			} `json:"table"`
+		} `json:"query_block"`
+	}
+	if err := json.Unmarshal(plan, &p); err != nil {
+		return 0, fmt.Errorf("%s: unable to decode query plan: %w", op, err)
+	}
+	if p.QueryBlock.Table == nil {
+		// the table is empty or the condition is impossible (ie: "no
+		// matching row in const table")
+		return 0, nil
+	}
+	filtered := 100.0
+	if p.QueryBlock.Table.Filtered != "" {
+		if _, err := fmt.Sscan(p.QueryBlock.Table.Filtered, &filtered); err != nil {
+			return 0, fmt.Errorf("%s: unable to decode filtered %q: %w", op, p.QueryBlock.Table.Filtered, err)
+		}
+	}
+	return p.QueryBlock.Table.Rows * filtered / 100, nil
+}
@@ -0,0 +1,226 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mqldb_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+
+	"github.com/hashicorp/mql"
+	"github.com/hashicorp/mql/mqldb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// planDriver is a database/sql driver whose queries return the query plan of
+// its dsn and record the last query and args
+type planDriver struct {
+	mu    sync.Mutex
+	plans map[string]string
+	query string
+	args  []any
+}
+
+var testDriver = &planDriver{plans: map[string]string{}}
+
+func init() {
+	sql.Register("mqldb_test", testDriver)
+}
+
+func (d *planDriver) Open(dsn string) (driver.Conn, error) { return &planConn{d: d, dsn: dsn}, nil }
+
+type planConn struct {
+	d   *planDriver
+	dsn string
+}
+
+func (c *planConn) Prepare(query string) (driver.Stmt, error) {
+	return &planStmt{c: c, query: query}, nil
+}
+func (c *planConn) Close() error              { return nil }
+func (c *planConn) Begin() (driver.Tx, error) { return nil, errors.New("not supported") }
+
+type planStmt struct {
+	c     *planConn
+	query string
+}
+
+func (s *planStmt) Close() error  { return nil }
+func (s *planStmt) NumInput() int { return -1 }
+func (s *planStmt) Exec([]driver.Value) (driver.Result, error) {
+	return nil, errors.New("not supported")
+}
+
+func (s *planStmt) Query(args []driver.Value) (driver.Rows, error) {
+	d := s.c.d
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.query, d.args = s.query, nil
+	for _, a := range args {
+		d.args = append(d.args, a)
+	}
+	plan, ok := d.plans[s.c.dsn]
+	if !ok {
+		return nil, errors.New("relation does not exist")
+	}
+	return &planRows{plan: plan}, nil
+}
+
+type planRows struct {
+	plan string
+	done bool
+}
+
+func (r *planRows) Columns() []string { return []string{"plan"} }
+func (r *planRows) Close() error      { return nil }
+func (r *planRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done, dest[0] = true, []byte(r.plan)
+	return nil
+}
+
+// testDB returns a database whose queries return the plan, or an error when
+// the plan is empty
+func testDB(t *testing.T, plan string) *sql.DB {
+	t.Helper()
+	if plan != "" {
+		testDriver.mu.Lock()
+		testDriver.plans[t.Name()] = plan
+		testDriver.mu.Unlock()
+	}
+	db, err := sql.Open("mqldb_test", t.Name())
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestEstimateRows(t *testing.T) {
+	ctx := context.Background()
+	w := &mql.WhereClause{Condition: "(name=$1 and age>$2)", Args: []any{"alice", 21}}
+	tests := []struct {
+		name            string
+		plan            string
+		table           string
+		where           *mql.WhereClause
+		opts            []mqldb.Option
+		want            int64
+		wantQuery       string
+		wantArgs        []any
+		wantErrIs       error
+		wantErrContains string
+	}{
+		{
+			name:      "postgres",
+			plan:      `[{"Plan": {"Node Type": "Seq Scan", "Plan Rows": 1234.6}}]`,
+			table:     "public.users",
+			where:     w,
+			want:      1235,
+			wantQuery: "explain (format json) select 1 from public.users where (name=$1 and age>$2)",
+			wantArgs:  []any{"alice", int64(21)},
+		},
+		{
+			name:      "mysql",
+			plan:      `{"query_block": {"table": {"table_name": "users", "rows_examined_per_scan": 2000, "filtered": "10.00"}}}`,
+			table:     "users",
+			where:     &mql.WhereClause{Condition: "name=?", Args: []any{"alice"}},
+			opts:      []mqldb.Option{mqldb.WithDialect(mql.MySqlDialect{})},
+			want:      200,
+			wantQuery: "explain format=json select 1 from users where name=?",
+			wantArgs:  []any{"alice"},
+		},
+		{
+			name:  "mysql-no-matching-rows",
+			plan:  `{"query_block": {"message": "no matching row in const table"}}`,
+			table: "users",
+			where: &mql.WhereClause{Condition: "id=?", Args: []any{-1}},
+			opts:  []mqldb.Option{mqldb.WithDialect(mql.MySqlDialect{})},
+			want:  0,
+		},
+		{
+			name:      "err-table",
+			table:     "users; drop table users",
+			where:     w,
+			wantErrIs: mql.ErrInvalidParameter,
+		},
+		{
+			name:      "err-missing-where-clause",
+			table:     "users",
+			wantErrIs: mql.ErrInvalidParameter,
+		},
+		{
+			name:      "err-named-args",
+			table:     "users",
+			where:     &mql.WhereClause{Condition: "name=:name_1", NamedArgs: map[string]any{"name_1": "alice"}},
+			wantErrIs: mql.ErrInvalidParameter,
+		},
+		{
+			name:      "err-having",
+			table:     "users",
+			where:     &mql.WhereClause{Condition: "1=1", Having: &mql.WhereClause{Condition: "count(*)>$1", Args: []any{1}}},
+			wantErrIs: mql.ErrInvalidParameter,
+		},
+		{
+			name:      "err-dialect",
+			table:     "users",
+			where:     w,
+			opts:      []mqldb.Option{mqldb.WithDialect(mql.SqliteDialect{})},
+			wantErrIs: mql.ErrInvalidParameter,
+		},
+		{
+			name:      "err-missing-dialect",
+			table:     "users",
+			where:     w,
+			opts:      []mqldb.Option{mqldb.WithDialect(nil)},
+			wantErrIs: mql.ErrInvalidParameter,
+		},
+		{
+			name:            "err-plan",
+			plan:            `[{"Plan": {}}]`,
+			table:           "users",
+			where:           w,
+			wantErrContains: "missing its rows",
+		},
+		{
+			name:            "err-explain",
+			table:           "missing",
+			where:           w,
+			wantErrContains: "relation does not exist",
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			assert, require := assert.New(t), require.New(t)
+			got, err := mqldb.EstimateRows(ctx, testDB(t, tc.plan), tc.table, tc.where, tc.opts...)
+			if tc.wantErrIs != nil || tc.wantErrContains != "" {
+				require.Error(err)
+				if tc.wantErrIs != nil {
+					assert.ErrorIs(err, tc.wantErrIs)
+				}
+				assert.ErrorContains(err, tc.wantErrContains)
+				assert.Zero(got)
+				return
+			}
+			require.NoError(err)
+			assert.Equal(tc.want, got)
+			if tc.wantQuery != "" {
+				testDriver.mu.Lock()
+				defer testDriver.mu.Unlock()
+				assert.Equal(tc.wantQuery, testDriver.query)
+				assert.Equal(tc.wantArgs, testDriver.args)
+			}
+		})
+	}
+	t.Run("err-missing-db", func(t *testing.T) {
+		_, err := mqldb.EstimateRows(ctx, nil, "users", w)
+		assert.ErrorIs(t, err, mql.ErrInvalidParameter)
+	})
+}
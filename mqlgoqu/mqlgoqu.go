@@ -0,0 +1,72 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+// Package mqlgoqu converts mql queries into goqu expressions, so a query can
+// be composed with a programmatically built statement:
+//
+//	where, err := mqlgoqu.Expression(`name="alice" and age > 21`, User{})
+//	if err != nil {
+//		return err
+//	}
+//	sql, args, err := goqu.Dialect("postgres").From("users").Where(where).
+//		Prepared(true).ToSQL()
+package mqlgoqu
+
+import (
+	"fmt"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+	"github.com/hashicorp/mql"
+)
+
+// Expression will parse the query, validate it using the model and convert
+// its expr tree into a goqu expression.  Placeholders are managed by goqu
+// (see goqu.SelectDataset.Prepared).  Supported options: the same options as
+// mql.ConvertExpr
+func Expression(query string, model any, opt ...mql.Option) (exp.Expression, error) {
+	const op = "mqlgoqu.Expression"
+	e, err := mql.ParseExpr(query)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	g, err := ExprExpression(e, model, opt...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return g, nil
+}
+
+// ExprExpression will validate the expr tree using the model and convert it
+// into a goqu expression.  It's helpful when the expr tree wasn't parsed from
+// a query (see mql.JsonApiFilterExpr).  Supported options: the same options
+// as mql.ConvertExpr
+func ExprExpression(e mql.Expr, model any, opt ...mql.Option) (exp.Expression, error) {
+	const op = "mqlgoqu.ExprExpression"
+	g, err := mql.ConvertExpr[exp.Expression](e, model, converter{}, opt...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return g, nil
+}
+
+// converter converts an expr tree into goqu expressions
+type converter struct{}
+
+// Comparison returns the comparison's where clause as a goqu literal
+func (converter) Comparison(w *mql.WhereClause) (exp.Expression, error) {
+	return goqu.L(w.Condition, w.Args...), nil
+}
+
+// Logical returns a goqu.And or goqu.Or of the left and right sides
+func (converter) Logical(op mql.LogicalOp, left, right exp.Expression) (exp.Expression, error) {
+	const fnOp = "mqlgoqu.(converter).Logical"
+	switch op {
+	case mql.AndOp:
+		return goqu.And(left, right), nil
+	case mql.OrOp:
+		return goqu.Or(left, right), nil
+	default:
+		return nil, fmt.Errorf("%s: %w %q", fnOp, mql.ErrInvalidLogicalOp, op)
+	}
+}
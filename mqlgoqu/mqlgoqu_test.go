@@ -0,0 +1,111 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mqlgoqu_test
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/doug-martin/goqu/v9"
+	_ "github.com/doug-martin/goqu/v9/dialect/postgres"
+	"github.com/hashicorp/mql"
+	"github.com/hashicorp/mql/mqlgoqu"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testUser struct {
+	ID   uint
+	Name string
+	Age  int
+}
+
+func TestExpression(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name            string
+		query           string
+		opts            []mql.Option
+		wantSql         string
+		wantArgs        []any
+		wantErrIs       error
+		wantErrContains string
+	}{
+		{
+			name:     "comparison",
+			query:    `name="alice"`,
+			wantSql:  `SELECT * FROM "users" WHERE name=$1 ORDER BY "id" ASC LIMIT $2`,
+			wantArgs: []any{"alice", int64(10)},
+		},
+		{
+			name:     "grouping",
+			query:    `name="alice" or (age > 21 and name % "bob")`,
+			wantSql:  `SELECT * FROM "users" WHERE (name=$1 OR (age>$2 AND name like $3 escape '\')) ORDER BY "id" ASC LIMIT $4`,
+			wantArgs: []any{"alice", int64(21), "%bob%", int64(10)},
+		},
+		{
+			name:     "column-map",
+			query:    `nickname="alice" and age >= 21`,
+			opts:     []mql.Option{mql.WithColumnMap(map[string]string{"nickname": "name"})},
+			wantSql:  `SELECT * FROM "users" WHERE (name=$1 AND age>=$2) ORDER BY "id" ASC LIMIT $3`,
+			wantArgs: []any{"alice", int64(21), int64(10)},
+		},
+		{
+			name:            "err-invalid-column",
+			query:           `email="alice"`,
+			wantErrIs:       mql.ErrInvalidColumn,
+			wantErrContains: `invalid column "email"`,
+		},
+		{
+			name:            "err-syntax",
+			query:           `name="alice" and`,
+			wantErrIs:       mql.ErrMissingRightSideExpr,
+			wantErrContains: "mqlgoqu.Expression",
+		},
+		{
+			name:            "err-placeholders",
+			query:           `name="alice"`,
+			opts:            []mql.Option{mql.WithSqlNamedArgs()},
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: "placeholder options are not supported",
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			assert, require := assert.New(t), require.New(t)
+			where, err := mqlgoqu.Expression(tc.query, testUser{}, tc.opts...)
+			if tc.wantErrContains != "" {
+				require.Error(err)
+				assert.Nil(where)
+				assert.ErrorIs(err, tc.wantErrIs)
+				assert.ErrorContains(err, tc.wantErrContains)
+				return
+			}
+			require.NoError(err)
+			gotSql, gotArgs, err := goqu.Dialect("postgres").
+				From("users").
+				Where(where).
+				Order(goqu.C("id").Asc()).
+				Limit(10).
+				Prepared(true).
+				ToSQL()
+			require.NoError(err)
+			assert.Equal(tc.wantSql, gotSql)
+			assert.Equal(tc.wantArgs, gotArgs)
+		})
+	}
+}
+
+func TestExprExpression(t *testing.T) {
+	t.Parallel()
+	e, err := mql.JsonApiFilterExpr(url.Values{"filter[name]": {"alice", "bob"}})
+	require.NoError(t, err)
+	where, err := mqlgoqu.ExprExpression(e, testUser{})
+	require.NoError(t, err)
+	gotSql, gotArgs, err := goqu.From("users").Select("id").Where(where, goqu.C("age").Gt(21)).Prepared(true).ToSQL()
+	require.NoError(t, err)
+	assert.Equal(t, `SELECT "id" FROM "users" WHERE ((name=? OR name=?) AND ("age" > ?))`, gotSql)
+	assert.Equal(t, []any{"alice", "bob", int64(21)}, gotArgs)
+}
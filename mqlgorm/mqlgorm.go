@@ -0,0 +1,51 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+// Package mqlgorm converts mql queries into gorm clauses and scopes, so a
+// query can be added to a gorm chain without any boilerplate:
+//
+//	var users []User
+//	err := db.Scopes(mqlgorm.Scope(`name="alice" and age > 21`, User{})).Find(&users).Error
+package mqlgorm
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/mql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Expression will parse the query using the model and return its where clause
+// as a gorm clause.Expression which can be passed to db.Where(...) or
+// db.Clauses(clause.Where{...}).  The where clause must use the default ?
+// placeholders or named params with an @ prefix (see mql.WithNamedParams),
+// since those are the placeholders gorm supports. Supported options: the
+// same options as mql.Parse.
+func Expression(query string, model any, opt ...mql.Option) (clause.Expression, error) {
+	const op = "mqlgorm.Expression"
+	w, err := mql.Parse(query, model, opt...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if w.NamedArgs != nil {
+		return clause.NamedExpr{SQL: w.Condition, Vars: []any{w.NamedArgs}}, nil
+	}
+	return clause.Expr{SQL: w.Condition, Vars: w.Args}, nil
+}
+
+// Scope will return a gorm scope which adds the query's where clause to the
+// statement.  If the query can't be parsed the error is added to the
+// statement (see gorm.DB.AddError), so it's returned by the chain's finisher
+// (ie: Find).  Supported options: the same options as Expression.
+func Scope(query string, model any, opt ...mql.Option) func(*gorm.DB) *gorm.DB {
+	const op = "mqlgorm.Scope"
+	return func(db *gorm.DB) *gorm.DB {
+		e, err := Expression(query, model, opt...)
+		if err != nil {
+			_ = db.AddError(fmt.Errorf("%s: %w", op, err))
+			return db
+		}
+		return db.Where(e)
+	}
+}
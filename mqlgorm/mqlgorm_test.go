@@ -0,0 +1,121 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mqlgorm_test
+
+import (
+	"testing"
+
+	"github.com/hashicorp/mql"
+	"github.com/hashicorp/mql/mqlgorm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type testUser struct {
+	ID   uint
+	Name string
+	Age  int
+}
+
+// testDB returns a db which only builds statements (see gorm.Session.DryRun),
+// so no database is needed.
+func testDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+	})
+	require.NoError(t, err)
+	return db
+}
+
+func TestExpression(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name            string
+		query           string
+		opts            []mql.Option
+		want            clause.Expression
+		wantErrIs       error
+		wantErrContains string
+	}{
+		{
+			name:  "args",
+			query: `name="alice" and age > 21`,
+			want:  clause.Expr{SQL: "(name=? and age>?)", Vars: []any{"alice", 21}},
+		},
+		{
+			name:  "named-params",
+			query: `name="alice" or name="bob"`,
+			opts:  []mql.Option{mql.WithNamedParams("@")},
+			want: clause.NamedExpr{
+				SQL:  "(name=@name_1 or name=@name_2)",
+				Vars: []any{map[string]any{"name_1": "alice", "name_2": "bob"}},
+			},
+		},
+		{
+			name:            "err-invalid-column",
+			query:           `email="alice"`,
+			wantErrIs:       mql.ErrInvalidColumn,
+			wantErrContains: `mqlgorm.Expression: mql.Parse`,
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			assert, require := assert.New(t), require.New(t)
+			got, err := mqlgorm.Expression(tc.query, testUser{}, tc.opts...)
+			if tc.wantErrContains != "" {
+				require.Error(err)
+				assert.Nil(got)
+				assert.ErrorIs(err, tc.wantErrIs)
+				assert.ErrorContains(err, tc.wantErrContains)
+				return
+			}
+			require.NoError(err)
+			assert.Equal(tc.want, got)
+		})
+	}
+}
+
+func TestScope(t *testing.T) {
+	t.Parallel()
+	db := testDB(t)
+	t.Run("where", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		var users []testUser
+		tx := db.Scopes(mqlgorm.Scope(`name="alice" and age > 21`, testUser{})).Order("id").Find(&users)
+		require.NoError(tx.Error)
+		assert.Equal(`SELECT * FROM "test_users" WHERE (name=$1 and age>$2) ORDER BY id`, tx.Statement.SQL.String())
+		assert.Equal([]any{"alice", 21}, tx.Statement.Vars)
+	})
+	t.Run("composes-with-where", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		var users []testUser
+		tx := db.Where("id > ?", 10).Scopes(mqlgorm.Scope(`name="alice" or name="bob"`, testUser{})).Find(&users)
+		require.NoError(tx.Error)
+		assert.Equal(`SELECT * FROM "test_users" WHERE id > $1 AND ((name=$2 or name=$3))`, tx.Statement.SQL.String())
+		assert.Equal([]any{10, "alice", "bob"}, tx.Statement.Vars)
+	})
+	t.Run("named-params", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		var users []testUser
+		tx := db.Scopes(mqlgorm.Scope(`name="alice"`, testUser{}, mql.WithNamedParams("@"))).Find(&users)
+		require.NoError(tx.Error)
+		assert.Equal(`SELECT * FROM "test_users" WHERE name=$1`, tx.Statement.SQL.String())
+		assert.Equal([]any{"alice"}, tx.Statement.Vars)
+	})
+	t.Run("err-invalid-query", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		var users []testUser
+		tx := db.Scopes(mqlgorm.Scope(`email="alice"`, testUser{})).Find(&users)
+		require.Error(tx.Error)
+		assert.ErrorIs(tx.Error, mql.ErrInvalidColumn)
+		assert.ErrorContains(tx.Error, "mqlgorm.Scope")
+		assert.Empty(users)
+	})
+}
@@ -0,0 +1,85 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql
+
+import (
+	"context"
+	"fmt"
+)
+
+// Parser parses queries for a model using options which are validated and
+// bound once (see NewParser), rather than on every call.  A Parser is safe for
+// concurrent use, provided that its options (ie: its converters) are.
+type Parser struct {
+	model any
+	// opt is the bound (already resolved) options
+	opt []Option
+}
+
+// NewParser returns a Parser for the model.  The options and model are
+// validated and the model is described once, so configuration errors are
+// returned by NewParser rather than when a query is parsed.  Supported
+// options: the same options as Parse.
+func NewParser(model any, opt ...Option) (*Parser, error) {
+	const op = "mql.NewParser"
+	if isNil(model) {
+		return nil, fmt.Errorf("%s: missing model: %w", op, ErrInvalidParameter)
+	}
+	opt, err := withModelTable(model, opt)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	opts, err := getOpts(opt...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if opts.withModelDescriber != nil {
+		// the model is described once, rather than on every call
+		fields, err := opts.withModelDescriber.DescribeModel(model)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		opts.withModelDescriber = fieldsDescriber(fields)
+	}
+	if _, err := modelValidators(model, withOptions(opts)); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return &Parser{model: model, opt: []Option{withOptions(opts)}}, nil
+}
+
+// Parse will parse the query and use the Parser's model and options to create
+// a where clause (see Parse).
+func (p *Parser) Parse(query string) (*WhereClause, error) {
+	const op = "mql.(Parser).Parse"
+	w, err := parse(nil, query, p.model, p.opt...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return w, nil
+}
+
+// ParseContext is the same as Parse, except that it stops parsing the query
+// once the context is canceled or its deadline is exceeded (see
+// ParseContext).
+func (p *Parser) ParseContext(ctx context.Context, query string) (*WhereClause, error) {
+	const op = "mql.(Parser).ParseContext"
+	if ctx == nil {
+		return nil, fmt.Errorf("%s: missing context: %w", op, ErrInvalidParameter)
+	}
+	w, err := parse(ctx, query, p.model, append(p.opt[:len(p.opt):len(p.opt)], withContext(ctx))...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return w, nil
+}
+
+// withOptions provides options which were already resolved, which replace any
+// previous options.  The options' maps are shared, so they must not be
+// followed by options which modify them (ie: WithConverter).
+func withOptions(opts options) Option {
+	return func(o *options) error {
+		*o = opts
+		return nil
+	}
+}
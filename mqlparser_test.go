@@ -0,0 +1,197 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/hashicorp/mql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingDescriber is a ModelDescriber which counts its calls
+type countingDescriber struct {
+	staticDescriber
+	calls *atomic.Int32
+}
+
+func (d countingDescriber) DescribeModel(model any) ([]mql.FieldDescriptor, error) {
+	d.calls.Add(1)
+	return d.staticDescriber.DescribeModel(model)
+}
+
+func TestNewParser(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name            string
+		model           any
+		opts            []mql.Option
+		query           string
+		want            *mql.WhereClause
+		wantErrIs       error
+		wantErrContains string
+	}{
+		{
+			name:  "success",
+			model: testModel{},
+			query: `name="alice" and age>21`,
+			want:  &mql.WhereClause{Condition: "(name=? and age>?)", Args: []any{"alice", 21}},
+		},
+		{
+			name:  "bound-options",
+			model: testModel{},
+			opts: []mql.Option{
+				mql.WithPgPlaceholders(),
+				mql.WithColumnMap(map[string]string{"nickname": "name"}),
+			},
+			query: `nickname="alice"`,
+			want:  &mql.WhereClause{Condition: "name=$1", Args: []any{"alice"}},
+		},
+		{
+			name:  "table-tag",
+			model: &userModel{},
+			query: `name="alice"`,
+			want:  &mql.WhereClause{Condition: "users.name=?", Args: []any{"alice"}},
+		},
+		{
+			name:            "err-missing-model",
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: "missing model",
+		},
+		{
+			name:            "err-not-a-struct",
+			model:           "users",
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: "model must be a struct",
+		},
+		{
+			name:            "err-invalid-option",
+			model:           testModel{},
+			opts:            []mql.Option{mql.WithConverter("name", nil)},
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: "missing ConvertToSqlFunc",
+		},
+		{
+			name:            "err-invalid-table-tag",
+			model:           invalidTableModel{},
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: "is not a valid table name",
+		},
+		{
+			name:            "err-describer",
+			model:           testModel{},
+			opts:            []mql.Option{mql.WithModelDescriber(staticDescriber{err: errors.New("unknown model")})},
+			wantErrContains: "unknown model",
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert, require := assert.New(t), require.New(t)
+			p, err := mql.NewParser(tc.model, tc.opts...)
+			if tc.wantErrContains != "" {
+				require.Error(err)
+				assert.Nil(p)
+				if tc.wantErrIs != nil {
+					assert.ErrorIs(err, tc.wantErrIs)
+				}
+				assert.ErrorContains(err, tc.wantErrContains)
+				return
+			}
+			require.NoError(err)
+			got, err := p.Parse(tc.query)
+			require.NoError(err)
+			assert.Equal(tc.want, got)
+		})
+	}
+}
+
+func TestParser_Parse(t *testing.T) {
+	t.Parallel()
+	t.Run("describes-model-once", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		d := countingDescriber{
+			staticDescriber: staticDescriber{fields: []mql.FieldDescriptor{{Name: "Name", Type: "string"}}},
+			calls:           &atomic.Int32{},
+		}
+		p, err := mql.NewParser(testModel{}, mql.WithModelDescriber(d))
+		require.NoError(err)
+		for i := 0; i < 3; i++ {
+			_, err := p.Parse(`name="alice"`)
+			require.NoError(err)
+		}
+		assert.Equal(int32(1), d.calls.Load())
+	})
+	t.Run("concurrent", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		p, err := mql.NewParser(testModel{}, mql.WithNamedParams(":"))
+		require.NoError(err)
+		var wg sync.WaitGroup
+		errs := make(chan error, 20)
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				name := fmt.Sprintf("user-%d", i)
+				w, err := p.Parse(fmt.Sprintf("name=%q", name))
+				switch {
+				case err != nil:
+					errs <- err
+				case w.NamedArgs["name_1"] != name:
+					errs <- fmt.Errorf("unexpected named args %v for %s", w.NamedArgs, name)
+				}
+			}(i)
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			assert.NoError(err)
+		}
+	})
+	t.Run("err-invalid-query", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		p, err := mql.NewParser(testModel{})
+		require.NoError(err)
+		got, err := p.Parse(`nickname="alice"`)
+		require.Error(err)
+		assert.Nil(got)
+		assert.ErrorIs(err, mql.ErrInvalidColumn)
+	})
+}
+
+func TestParser_ParseContext(t *testing.T) {
+	t.Parallel()
+	p, err := mql.NewParser(testModel{})
+	require.NoError(t, err)
+	t.Run("success", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		got, err := p.ParseContext(context.Background(), `name="alice"`)
+		require.NoError(err)
+		assert.Equal(&mql.WhereClause{Condition: "name=?", Args: []any{"alice"}}, got)
+	})
+	t.Run("err-canceled", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		got, err := p.ParseContext(ctx, `name="alice"`)
+		require.Error(err)
+		assert.Nil(got)
+		assert.ErrorIs(err, context.Canceled)
+	})
+	t.Run("err-missing-context", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		var ctx context.Context
+		got, err := p.ParseContext(ctx, `name="alice"`)
+		require.Error(err)
+		assert.Nil(got)
+		assert.ErrorIs(err, mql.ErrInvalidParameter)
+	})
+}
@@ -0,0 +1,202 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+// Package mqlproto allows generated protobuf messages to be used as mql
+// models, so APIs which define their resources as protos don't need to
+// maintain mirror structs.  Messages are described using their descriptors
+// (rather than their Go struct layout), so the fields of oneofs are columns
+// like any other field, google.protobuf.Timestamp and Duration fields are
+// date/time and duration columns, and wrapper (ie: google.protobuf.StringValue)
+// fields are nullable columns of the wrapped type.
+//
+//	w, err := mqlproto.Parse(`display_name="alice" and created_at > "2023-01-01"`, &pb.User{})
+package mqlproto
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/mql"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Describer is a mql.ModelDescriber for protobuf messages (see
+// mql.WithModelDescriber).  Fields are named using the Go name generated for
+// their proto name (ie: DisplayName for display_name), so they're queried
+// using their proto name.  Enums are described as strings, which are queried
+// using the names of their values (see Options).  Bytes, nested messages
+// (other than well-known types), repeated messages and maps with keys which
+// aren't strings aren't supported and they're omitted.
+type Describer struct{}
+
+var _ mql.ModelDescriber = Describer{}
+
+// DescribeModel returns the fields of the model, which must be a proto.Message
+func (Describer) DescribeModel(model any) ([]mql.FieldDescriptor, error) {
+	const op = "mqlproto.(Describer).DescribeModel"
+	m, ok := model.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("%s: model %T is not a proto.Message: %w", op, model, mql.ErrInvalidParameter)
+	}
+	fields := m.ProtoReflect().Descriptor().Fields()
+	descriptors := make([]mql.FieldDescriptor, 0, fields.Len())
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		typ, ok := fieldType(fd)
+		if !ok {
+			continue
+		}
+		descriptors = append(descriptors, mql.FieldDescriptor{Name: goCamelCase(string(fd.Name())), Type: typ})
+	}
+	return descriptors, nil
+}
+
+// Options returns the options required to parse queries using the message as
+// a model: the Describer and a mql.WithEnum(...) option for every enum field,
+// so its values must be the names of the enum's values.
+func Options(m proto.Message) ([]mql.Option, error) {
+	const op = "mqlproto.Options"
+	if m == nil {
+		return nil, fmt.Errorf("%s: missing message: %w", op, mql.ErrInvalidParameter)
+	}
+	opts := []mql.Option{mql.WithModelDescriber(Describer{})}
+	fields := m.ProtoReflect().Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		if fd.Kind() != protoreflect.EnumKind || fd.IsList() || fd.IsMap() {
+			continue
+		}
+		values := fd.Enum().Values()
+		names := make([]string, 0, values.Len())
+		for j := 0; j < values.Len(); j++ {
+			names = append(names, string(values.Get(j).Name()))
+		}
+		opts = append(opts, mql.WithEnum(string(fd.Name()), names))
+	}
+	return opts, nil
+}
+
+// Parse will parse the query using the message as its model and return a
+// where clause.  Supported options: the same options as mql.Parse, which are
+// applied after the message's options (see Options).
+func Parse(query string, m proto.Message, opt ...mql.Option) (*mql.WhereClause, error) {
+	const op = "mqlproto.Parse"
+	opts, err := Options(m)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	w, err := mql.Parse(query, m, append(opts, opt...)...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return w, nil
+}
+
+// wrapperTypes are the Go types of the values of the well-known wrapper
+// messages, which are nullable
+var wrapperTypes = map[protoreflect.FullName]string{
+	"google.protobuf.DoubleValue": "*float64",
+	"google.protobuf.FloatValue":  "*float32",
+	"google.protobuf.Int64Value":  "*int64",
+	"google.protobuf.UInt64Value": "*uint64",
+	"google.protobuf.Int32Value":  "*int32",
+	"google.protobuf.UInt32Value": "*uint32",
+	"google.protobuf.BoolValue":   "*bool",
+	"google.protobuf.StringValue": "*string",
+}
+
+// fieldType returns the Go type (using the same format as
+// reflect.Type.String()) which mql uses to validate the field's values and
+// false when the field isn't supported.
+func fieldType(fd protoreflect.FieldDescriptor) (string, bool) {
+	switch {
+	case fd.IsMap():
+		if fd.MapKey().Kind() != protoreflect.StringKind {
+			return "", false
+		}
+		v, ok := singularType(fd.MapValue())
+		if !ok {
+			return "", false
+		}
+		return "map[string]" + v, true
+	case fd.IsList():
+		if fd.Kind() == protoreflect.MessageKind || fd.Kind() == protoreflect.GroupKind {
+			return "", false
+		}
+		v, ok := singularType(fd)
+		if !ok {
+			return "", false
+		}
+		return "[]" + v, true
+	}
+	typ, ok := singularType(fd)
+	if ok && fd.HasPresence() && fd.Kind() != protoreflect.MessageKind {
+		// proto3 optional fields and oneof fields are nullable
+		typ = "*" + typ
+	}
+	return typ, ok
+}
+
+// singularType returns the Go type of a single value of the field
+func singularType(fd protoreflect.FieldDescriptor) (string, bool) {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return "bool", true
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return "int32", true
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return "int64", true
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return "uint32", true
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return "uint64", true
+	case protoreflect.FloatKind:
+		return "float32", true
+	case protoreflect.DoubleKind:
+		return "float64", true
+	case protoreflect.StringKind, protoreflect.EnumKind:
+		return "string", true
+	case protoreflect.MessageKind:
+		switch name := fd.Message().FullName(); name {
+		case "google.protobuf.Timestamp":
+			return "*time.Time", true
+		case "google.protobuf.Duration":
+			return "*time.Duration", true
+		default:
+			typ, ok := wrapperTypes[name]
+			return typ, ok
+		}
+	default:
+		return "", false
+	}
+}
+
+// goCamelCase returns the Go name generated by protoc-gen-go for a proto
+// field name (ie: DisplayName for display_name)
+func goCamelCase(s string) string {
+	var b []byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '_' && i == 0:
+			b = append(b, 'X')
+		case c == '_' && i+1 < len(s) && isASCIILower(s[i+1]):
+			// the underscore is dropped and the next letter is capitalized
+		case isASCIIDigit(c):
+			b = append(b, c)
+		default:
+			if isASCIILower(c) {
+				c -= 'a' - 'A'
+			}
+			b = append(b, c)
+			for ; i+1 < len(s) && isASCIILower(s[i+1]); i++ {
+				b = append(b, s[i+1])
+			}
+		}
+	}
+	return string(b)
+}
+
+func isASCIILower(c byte) bool { return 'a' <= c && c <= 'z' }
+
+func isASCIIDigit(c byte) bool { return '0' <= c && c <= '9' }
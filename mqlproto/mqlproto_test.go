@@ -0,0 +1,205 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mqlproto_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/mql"
+	"github.com/hashicorp/mql/mqlproto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/structpb"
+	_ "google.golang.org/protobuf/types/known/timestamppb"
+	_ "google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// testUserProto is the descriptor of:
+//
+//	message User {
+//	  enum Status { STATUS_UNSPECIFIED = 0; STATUS_ACTIVE = 1; }
+//	  string display_name = 1;
+//	  int32 age = 2;
+//	  google.protobuf.Timestamp created_at = 3;
+//	  google.protobuf.StringValue nickname = 4;
+//	  map<string, string> labels = 5;
+//	  repeated string tags = 6;
+//	  Status status = 7;
+//	  oneof contact { string email = 8; string phone = 9; }
+//	  bytes avatar = 10;
+//	  User manager = 11;
+//	  uint64 ip_v4_addr = 12;
+//	}
+const testUserProto = `
+name: "user.proto"
+package: "test"
+syntax: "proto3"
+dependency: "google/protobuf/timestamp.proto"
+dependency: "google/protobuf/wrappers.proto"
+message_type: {
+  name: "User"
+  field: { name: "display_name" number: 1 label: LABEL_OPTIONAL type: TYPE_STRING }
+  field: { name: "age" number: 2 label: LABEL_OPTIONAL type: TYPE_INT32 }
+  field: { name: "created_at" number: 3 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".google.protobuf.Timestamp" }
+  field: { name: "nickname" number: 4 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".google.protobuf.StringValue" }
+  field: { name: "labels" number: 5 label: LABEL_REPEATED type: TYPE_MESSAGE type_name: ".test.User.LabelsEntry" }
+  field: { name: "tags" number: 6 label: LABEL_REPEATED type: TYPE_STRING }
+  field: { name: "status" number: 7 label: LABEL_OPTIONAL type: TYPE_ENUM type_name: ".test.User.Status" }
+  field: { name: "email" number: 8 label: LABEL_OPTIONAL type: TYPE_STRING oneof_index: 0 }
+  field: { name: "phone" number: 9 label: LABEL_OPTIONAL type: TYPE_STRING oneof_index: 0 }
+  field: { name: "avatar" number: 10 label: LABEL_OPTIONAL type: TYPE_BYTES }
+  field: { name: "manager" number: 11 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".test.User" }
+  field: { name: "ip_v4_addr" number: 12 label: LABEL_OPTIONAL type: TYPE_UINT64 }
+  nested_type: {
+    name: "LabelsEntry"
+    field: { name: "key" number: 1 label: LABEL_OPTIONAL type: TYPE_STRING }
+    field: { name: "value" number: 2 label: LABEL_OPTIONAL type: TYPE_STRING }
+    options: { map_entry: true }
+  }
+  enum_type: {
+    name: "Status"
+    value: { name: "STATUS_UNSPECIFIED" number: 0 }
+    value: { name: "STATUS_ACTIVE" number: 1 }
+  }
+  oneof_decl: { name: "contact" }
+}
+`
+
+func testUser(t *testing.T) proto.Message {
+	t.Helper()
+	var fdp descriptorpb.FileDescriptorProto
+	require.NoError(t, prototext.Unmarshal([]byte(testUserProto), &fdp))
+	fd, err := protodesc.NewFile(&fdp, protoregistry.GlobalFiles)
+	require.NoError(t, err)
+	return dynamicpb.NewMessage(fd.Messages().ByName("User"))
+}
+
+func TestDescriber_DescribeModel(t *testing.T) {
+	t.Parallel()
+	t.Run("user", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		got, err := mqlproto.Describer{}.DescribeModel(testUser(t))
+		require.NoError(err)
+		assert.Equal([]mql.FieldDescriptor{
+			{Name: "DisplayName", Type: "string"},
+			{Name: "Age", Type: "int32"},
+			{Name: "CreatedAt", Type: "*time.Time"},
+			{Name: "Nickname", Type: "*string"},
+			{Name: "Labels", Type: "map[string]string"},
+			{Name: "Tags", Type: "[]string"},
+			{Name: "Status", Type: "string"},
+			{Name: "Email", Type: "*string"},
+			{Name: "Phone", Type: "*string"},
+			{Name: "IpV4Addr", Type: "uint64"},
+		}, got)
+	})
+	t.Run("generated-oneof", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		got, err := mqlproto.Describer{}.DescribeModel(&structpb.Value{})
+		require.NoError(err)
+		assert.Equal([]mql.FieldDescriptor{
+			{Name: "NullValue", Type: "*string"},
+			{Name: "NumberValue", Type: "*float64"},
+			{Name: "StringValue", Type: "*string"},
+			{Name: "BoolValue", Type: "*bool"},
+		}, got)
+	})
+	t.Run("not-a-message", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		_, err := mqlproto.Describer{}.DescribeModel(struct{ Name string }{})
+		require.Error(err)
+		assert.ErrorIs(err, mql.ErrInvalidParameter)
+	})
+}
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+	user := testUser(t)
+	tests := []struct {
+		name            string
+		query           string
+		opts            []mql.Option
+		want            *mql.WhereClause
+		wantErrIs       error
+		wantErrContains string
+	}{
+		{
+			name:  "proto-names",
+			query: `display_name="alice" and ip_v4_addr=1`,
+			want:  &mql.WhereClause{Condition: "(display_name=? and ip_v4_addr=?)", Args: []any{"alice", 1}},
+		},
+		{
+			name:  "timestamp",
+			query: `created_at>"2023-01-02T03:04:05Z"`,
+			want:  &mql.WhereClause{Condition: "created_at>?", Args: []any{time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)}},
+		},
+		{
+			name:  "wrapper",
+			query: `nickname%"ali"`,
+			want:  &mql.WhereClause{Condition: `nickname like ? escape '\'`, Args: []any{"%ali%"}},
+		},
+		{
+			name:  "oneof",
+			query: `email="alice@example.com" or phone="555"`,
+			want:  &mql.WhereClause{Condition: "(email=? or phone=?)", Args: []any{"alice@example.com", "555"}},
+		},
+		{
+			name:  "map",
+			query: `labels.env="prod"`,
+			want:  &mql.WhereClause{Condition: "labels->>?=?", Args: []any{"env", "prod"}},
+		},
+		{
+			name:  "enum",
+			query: `status="STATUS_ACTIVE"`,
+			want:  &mql.WhereClause{Condition: "status=?", Args: []any{"STATUS_ACTIVE"}},
+		},
+		{
+			name:            "invalid-enum",
+			query:           `status="ACTIVE"`,
+			wantErrIs:       mql.ErrInvalidEnumValue,
+			wantErrContains: "expected one of: STATUS_UNSPECIFIED, STATUS_ACTIVE",
+		},
+		{
+			name:            "unsupported-field",
+			query:           `avatar="a"`,
+			wantErrIs:       mql.ErrInvalidColumn,
+			wantErrContains: `invalid column "avatar"`,
+		},
+		{
+			name:  "with-opts",
+			query: `age>21`,
+			opts:  []mql.Option{mql.WithPgPlaceholders()},
+			want:  &mql.WhereClause{Condition: "age>$1", Args: []any{21}},
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert, require := assert.New(t), require.New(t)
+			got, err := mqlproto.Parse(tc.query, user, tc.opts...)
+			if tc.wantErrContains != "" {
+				require.Error(err)
+				assert.Empty(got)
+				assert.ErrorIs(err, tc.wantErrIs)
+				assert.ErrorContains(err, tc.wantErrContains)
+				return
+			}
+			require.NoError(err)
+			assert.Equal(tc.want, got)
+		})
+	}
+	t.Run("missing-message", func(t *testing.T) {
+		_, err := mqlproto.Parse(`age>21`, nil)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, mql.ErrInvalidParameter)
+	})
+}
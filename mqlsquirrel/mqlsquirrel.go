@@ -0,0 +1,71 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+// Package mqlsquirrel converts mql queries into squirrel expressions, so a
+// query can be composed with a programmatically built statement:
+//
+//	where, err := mqlsquirrel.Sqlizer(`name="alice" and age > 21`, User{})
+//	if err != nil {
+//		return err
+//	}
+//	sql, args, err := squirrel.Select("*").From("users").Where(where).
+//		PlaceholderFormat(squirrel.Dollar).ToSql()
+package mqlsquirrel
+
+import (
+	"fmt"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/hashicorp/mql"
+)
+
+// Sqlizer will parse the query, validate it using the model and convert its
+// expr tree into a squirrel.Sqlizer.  Placeholders are managed by squirrel
+// (see squirrel.StatementBuilderType.PlaceholderFormat).  Supported options:
+// the same options as mql.ConvertExpr
+func Sqlizer(query string, model any, opt ...mql.Option) (squirrel.Sqlizer, error) {
+	const op = "mqlsquirrel.Sqlizer"
+	e, err := mql.ParseExpr(query)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	s, err := ExprSqlizer(e, model, opt...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return s, nil
+}
+
+// ExprSqlizer will validate the expr tree using the model and convert it into
+// a squirrel.Sqlizer.  It's helpful when the expr tree wasn't parsed from a
+// query (see mql.JsonApiFilterExpr).  Supported options: the same options as
+// mql.ConvertExpr
+func ExprSqlizer(e mql.Expr, model any, opt ...mql.Option) (squirrel.Sqlizer, error) {
+	const op = "mqlsquirrel.ExprSqlizer"
+	s, err := mql.ConvertExpr[squirrel.Sqlizer](e, model, converter{}, opt...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return s, nil
+}
+
+// converter converts an expr tree into squirrel expressions
+type converter struct{}
+
+// Comparison returns the comparison's where clause as a squirrel.Expr
+func (converter) Comparison(w *mql.WhereClause) (squirrel.Sqlizer, error) {
+	return squirrel.Expr(w.Condition, w.Args...), nil
+}
+
+// Logical returns a squirrel.And or squirrel.Or of the left and right sides
+func (converter) Logical(op mql.LogicalOp, left, right squirrel.Sqlizer) (squirrel.Sqlizer, error) {
+	const fnOp = "mqlsquirrel.(converter).Logical"
+	switch op {
+	case mql.AndOp:
+		return squirrel.And{left, right}, nil
+	case mql.OrOp:
+		return squirrel.Or{left, right}, nil
+	default:
+		return nil, fmt.Errorf("%s: %w %q", fnOp, mql.ErrInvalidLogicalOp, op)
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mqlsquirrel_test
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/hashicorp/mql"
+	"github.com/hashicorp/mql/mqlsquirrel"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testUser struct {
+	ID        uint
+	Name      string
+	Age       int
+	CreatedAt string
+}
+
+func TestSqlizer(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name            string
+		query           string
+		opts            []mql.Option
+		wantSql         string
+		wantArgs        []any
+		wantErrIs       error
+		wantErrContains string
+	}{
+		{
+			name:     "comparison",
+			query:    `name="alice"`,
+			wantSql:  "SELECT * FROM users WHERE name=$1 ORDER BY id LIMIT 10",
+			wantArgs: []any{"alice"},
+		},
+		{
+			name:     "grouping",
+			query:    `name="alice" or (age > 21 and name % "bob")`,
+			wantSql:  "SELECT * FROM users WHERE (name=$1 OR (age>$2 AND name like $3 escape '\\')) ORDER BY id LIMIT 10",
+			wantArgs: []any{"alice", 21, "%bob%"},
+		},
+		{
+			name:     "column-map",
+			query:    `nickname="alice" and age >= 21`,
+			opts:     []mql.Option{mql.WithColumnMap(map[string]string{"nickname": "name"})},
+			wantSql:  "SELECT * FROM users WHERE (name=$1 AND age>=$2) ORDER BY id LIMIT 10",
+			wantArgs: []any{"alice", 21},
+		},
+		{
+			name:            "err-invalid-column",
+			query:           `email="alice"`,
+			wantErrIs:       mql.ErrInvalidColumn,
+			wantErrContains: `invalid column "email"`,
+		},
+		{
+			name:            "err-syntax",
+			query:           `name="alice" and`,
+			wantErrIs:       mql.ErrMissingRightSideExpr,
+			wantErrContains: "mqlsquirrel.Sqlizer",
+		},
+		{
+			name:            "err-placeholders",
+			query:           `name="alice"`,
+			opts:            []mql.Option{mql.WithPgPlaceholders()},
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: "placeholder options are not supported",
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			assert, require := assert.New(t), require.New(t)
+			where, err := mqlsquirrel.Sqlizer(tc.query, testUser{}, tc.opts...)
+			if tc.wantErrContains != "" {
+				require.Error(err)
+				assert.Nil(where)
+				assert.ErrorIs(err, tc.wantErrIs)
+				assert.ErrorContains(err, tc.wantErrContains)
+				return
+			}
+			require.NoError(err)
+			gotSql, gotArgs, err := squirrel.Select("*").
+				From("users").
+				Where(where).
+				OrderBy("id").
+				Limit(10).
+				PlaceholderFormat(squirrel.Dollar).
+				ToSql()
+			require.NoError(err)
+			assert.Equal(tc.wantSql, gotSql)
+			assert.Equal(tc.wantArgs, gotArgs)
+		})
+	}
+}
+
+func TestExprSqlizer(t *testing.T) {
+	t.Parallel()
+	e, err := mql.JsonApiFilterExpr(url.Values{"filter[name]": {"alice", "bob"}})
+	require.NoError(t, err)
+	where, err := mqlsquirrel.ExprSqlizer(e, testUser{})
+	require.NoError(t, err)
+	gotSql, gotArgs, err := squirrel.Select("id").From("users").Where(where).Where("age > ?", 21).ToSql()
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT id FROM users WHERE (name=? OR name=?) AND age > ?", gotSql)
+	assert.Equal(t, []any{"alice", "bob", 21}, gotArgs)
+}
@@ -0,0 +1,42 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql
+
+import (
+	"strings"
+)
+
+// WithNullSafeNotEqual will match NULL columns when using !=, so
+// status!="active" matches the rows where the status is NULL, which is what
+// users expect, rather than the SQL semantics which never match NULL.  The
+// condition is (status!=? or status is null), or status is distinct from ?
+// when using a postgres dialect (see WithDialect).  It applies to the columns
+// of the model and the keys of map fields (a missing key is NULL), but not to
+// the columns of converters or relationships.  Match uses the same semantics
+// for missing values (ie: nil pointers).
+func WithNullSafeNotEqual() Option {
+	return func(o *options) error {
+		o.withNullSafeNotEqual = true
+		return nil
+	}
+}
+
+// nullSafeCondition returns the where clause of the != comparison which also
+// matches when expr is NULL (see WithNullSafeNotEqual).  args are the args of
+// expr (ie: the key of a json lookup).  The where clause is returned as is
+// for other comparisons or without WithNullSafeNotEqual.
+func nullSafeCondition(w *WhereClause, comparisonOp ComparisonOp, expr string, args []any, opts options) *WhereClause {
+	if !opts.withNullSafeNotEqual || comparisonOp != NotEqualOp {
+		return w
+	}
+	// a single != comparison (ie: not a like pattern or a date's range) is
+	// distinct from the arg on postgres
+	if lhs, ok := strings.CutSuffix(w.Condition, string(NotEqualOp)+"?"); ok && opts.withDialect != nil && hasPgOperators(opts.withDialect) && !strings.Contains(lhs, " ") {
+		return &WhereClause{Condition: lhs + " is distinct from ?", Args: w.Args}
+	}
+	return &WhereClause{
+		Condition: "(" + w.Condition + " or " + expr + " is null)",
+		Args:      append(w.Args, args...),
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/mql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse_WithNullSafeNotEqual(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name  string
+		query string
+		opts  []mql.Option
+		want  *mql.WhereClause
+	}{
+		{
+			name:  "not-equal",
+			query: `name!="alice"`,
+			want:  &mql.WhereClause{Condition: "(name!=? or name is null)", Args: []any{"alice"}},
+		},
+		{
+			name:  "other-comparisons",
+			query: `name="alice" and age>21`,
+			want:  &mql.WhereClause{Condition: "(name=? and age>?)", Args: []any{"alice", 21}},
+		},
+		{
+			name:  "like-pattern",
+			query: `name!="al*"`,
+			opts:  []mql.Option{mql.WithGlobPatterns()},
+			want:  &mql.WhereClause{Condition: `(name not like ? escape '\' or name is null)`, Args: []any{"al%"}},
+		},
+		{
+			name:  "date",
+			query: `created_at!="2023-01-02"`,
+			want: &mql.WhereClause{
+				Condition: "((created_at<? or created_at>=?) or created_at is null)",
+				Args:      []any{time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC), time.Date(2023, 1, 3, 0, 0, 0, 0, time.UTC)},
+			},
+		},
+		{
+			name:  "map",
+			query: `labels.env!="prod"`,
+			want:  &mql.WhereClause{Condition: "(labels->>?!=? or labels->>? is null)", Args: []any{"env", "prod", "env"}},
+		},
+		{
+			name:  "postgres",
+			query: `name!="alice" and scores.math!=90`,
+			opts:  []mql.Option{mql.WithDialect(mql.PostgresDialect{}), mql.WithPgPlaceholders()},
+			want: &mql.WhereClause{
+				Condition: `("name" is distinct from $1 and ("scores"->>$2)::bigint is distinct from $3)`,
+				Args:      []any{"alice", "math", 90},
+			},
+		},
+		{
+			name:  "mysql",
+			query: `name!="alice"`,
+			opts:  []mql.Option{mql.WithDialect(mql.MySqlDialect{})},
+			want:  &mql.WhereClause{Condition: "(`name`!=? or `name` is null)", Args: []any{"alice"}},
+		},
+		{
+			name:  "named-params",
+			query: `name!="alice"`,
+			opts:  []mql.Option{mql.WithNamedParams(":")},
+			want:  &mql.WhereClause{Condition: "(name!=:name_1 or name is null)", NamedArgs: map[string]any{"name_1": "alice"}},
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert, require := assert.New(t), require.New(t)
+			got, err := mql.Parse(tc.query, testModel{}, append(tc.opts, mql.WithNullSafeNotEqual())...)
+			require.NoError(err)
+			assert.Equal(tc.want, got)
+		})
+	}
+	t.Run("match", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		ok, err := mql.Match(`email!="alice@example.com"`, testModel{})
+		require.NoError(err)
+		assert.False(ok)
+		ok, err = mql.Match(`email!="alice@example.com"`, testModel{}, mql.WithNullSafeNotEqual())
+		require.NoError(err)
+		assert.True(ok)
+		ok, err = mql.Match(`email="alice@example.com"`, testModel{}, mql.WithNullSafeNotEqual())
+		require.NoError(err)
+		assert.False(ok)
+	})
+}
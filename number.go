@@ -0,0 +1,270 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// numberLen returns the length of the number literal at the start of s: an
+// optional sign, digits which may be separated by underscores (ie: 1_000_000)
+// with an optional fraction and an optional exponent (ie: -1.5e6), or hex
+// digits prefixed by 0x (ie: 0xff).  An exponent is only part of the literal
+// when it has digits, so 1e is the literal 1 followed by e.  It reports false
+// when the literal is malformed: an underscore which isn't between digits or
+// a second decimal point.
+func numberLen(s string) (int, bool) {
+	i := 0
+	if i < len(s) && (s[i] == '-' || s[i] == '+') {
+		i++
+	}
+	if i+2 < len(s) && s[i] == '0' && (s[i+1] == 'x' || s[i+1] == 'X') && isHexDigit(s[i+2]) {
+		i, _, ok := scanDigits(s, i+2, isHexDigit)
+		return i, ok
+	}
+	i, intDigits, ok := scanDigits(s, i, isDecimalDigit)
+	if !ok {
+		return i, false
+	}
+	if i < len(s) && s[i] == '.' {
+		var fracDigits bool
+		i, fracDigits, ok = scanDigits(s, i+1, isDecimalDigit)
+		switch {
+		case !ok:
+			return i, false
+		case !intDigits && !fracDigits:
+			// a lone "." isn't a number, but it's still scanned as one
+			return i, i >= len(s) || s[i] != '.'
+		}
+	}
+	if i < len(s) && (s[i] == 'e' || s[i] == 'E') {
+		j := i + 1
+		if j < len(s) && (s[j] == '+' || s[j] == '-') {
+			j++
+		}
+		if j < len(s) && isDecimalDigit(s[j]) {
+			if i, _, ok = scanDigits(s, j, isDecimalDigit); !ok {
+				return i, false
+			}
+		}
+	}
+	if i < len(s) && s[i] == '.' {
+		return i, false
+	}
+	return i, true
+}
+
+// scanDigits scans the digits of s from i, which may be separated by
+// underscores.  It returns the index after the digits, reports if there were
+// any digits and reports false when an underscore isn't between digits.
+func scanDigits(s string, i int, isDigit func(byte) bool) (int, bool, bool) {
+	start := i
+	for i < len(s) {
+		switch {
+		case isDigit(s[i]):
+			i++
+		case s[i] == '_':
+			if i == start || i+1 >= len(s) || !isDigit(s[i+1]) {
+				return i, false, false
+			}
+			i++
+		default:
+			return i, i > start, true
+		}
+	}
+	return i, i > start, true
+}
+
+func isDecimalDigit(b byte) bool {
+	return b >= '0' && b <= '9'
+}
+
+func isHexDigit(b byte) bool {
+	return isDecimalDigit(b) || (b >= 'a' && b <= 'f') || (b >= 'A' && b <= 'F')
+}
+
+// numberLiteral returns the number literal s (see numberLen) without its
+// underscores and reports if it's a hex literal.  It reports false when s
+// isn't a number literal.
+func numberLiteral(s string) (string, bool, bool) {
+	n, ok := numberLen(s)
+	if !ok || n != len(s) || strings.IndexFunc(s, func(r rune) bool { return r >= '0' && r <= '9' }) < 0 {
+		return "", false, false
+	}
+	s = strings.ReplaceAll(s, "_", "")
+	unsigned := strings.TrimLeft(s, "+-")
+	return s, strings.HasPrefix(unsigned, "0x") || strings.HasPrefix(unsigned, "0X"), true
+}
+
+// intBounds are the min and max values of an int type
+type intBounds struct {
+	min int64
+	max uint64
+}
+
+// intTypes are the bounds of the int types by type
+var intTypes = map[string]intBounds{
+	"int":    {min: math.MinInt, max: math.MaxInt},
+	"int8":   {min: math.MinInt8, max: math.MaxInt8},
+	"int16":  {min: math.MinInt16, max: math.MaxInt16},
+	"int32":  {min: math.MinInt32, max: math.MaxInt32},
+	"int64":  {min: math.MinInt64, max: math.MaxInt64},
+	"uint":   {max: math.MaxUint},
+	"uint8":  {max: math.MaxUint8},
+	"uint16": {max: math.MaxUint16},
+	"uint32": {max: math.MaxUint32},
+	"uint64": {max: math.MaxUint64},
+}
+
+// intValidator returns a validateFunc which validates an int literal (see
+// parseIntLiteral) and returns an ErrValueOutOfRange when it doesn't fit the
+// int type (ie: 256 for a uint8).  The value is an int when it fits an int,
+// otherwise an int64 or a uint64 (ie: a uint64 greater than math.MaxInt64),
+// so it never loses precision.  When typed, the value is always an int64 or a
+// uint64 (see WithTypedArgs).
+func intValidator(fType string, typed bool) validateFunc {
+	const op = "mql.validateInt"
+	bounds, ok := intTypes[fType]
+	if !ok {
+		bounds = intTypes["int64"]
+	}
+	return func(s string) (any, error) {
+		neg, abs, err := parseIntLiteral(s)
+		switch {
+		case err != nil:
+			return 0, fmt.Errorf("%s: %w", op, err)
+		case neg && abs > uint64(-(bounds.min+1))+1, !neg && abs > bounds.max:
+			return 0, fmt.Errorf("%s: %w: %q isn't in the range of %s (%d to %d): %w", op, ErrValueOutOfRange, s, fType, bounds.min, bounds.max, ErrInvalidParameter)
+		}
+		if typed {
+			return int64Value(neg, abs), nil
+		}
+		return intValue(neg, abs), nil
+	}
+}
+
+// parseIntLiteral parses an int literal, which may be a number literal with
+// underscores, an exponent or a fraction as long as its value is a whole
+// number (ie: 1_000, 1e6 or 2.0) or a hex literal (ie: 0xff).  It returns the
+// sign and absolute value of the int, and an ErrValueOutOfRange when it
+// doesn't fit 64 bits.
+func parseIntLiteral(s string) (bool, uint64, error) {
+	const op = "mql.parseIntLiteral"
+	n, hex, ok := numberLiteral(s)
+	if !ok {
+		return false, 0, fmt.Errorf("%s: value %q is not an int: %w", op, s, ErrInvalidParameter)
+	}
+	neg := strings.HasPrefix(n, "-")
+	digits := strings.TrimLeft(n, "+-")
+	base := 10
+	if hex {
+		base, digits = 16, digits[2:]
+	}
+	abs, err := strconv.ParseUint(digits, base, 64)
+	switch {
+	case err == nil:
+		return neg && abs != 0, abs, nil
+	case errors.Is(err, strconv.ErrRange):
+		return false, 0, fmt.Errorf("%s: %w: %q doesn't fit 64 bits: %w", op, ErrValueOutOfRange, s, ErrInvalidParameter)
+	}
+	// the literal has a fraction or an exponent (ie: 1e6), so its value must
+	// be a whole number
+	r, ok := new(big.Rat).SetString(n)
+	if !ok || !r.IsInt() {
+		return false, 0, fmt.Errorf("%s: value %q is not an int: %w", op, s, ErrInvalidParameter)
+	}
+	i := r.Num()
+	if !new(big.Int).Abs(i).IsUint64() {
+		return false, 0, fmt.Errorf("%s: %w: %q doesn't fit 64 bits: %w", op, ErrValueOutOfRange, s, ErrInvalidParameter)
+	}
+	return i.Sign() < 0, new(big.Int).Abs(i).Uint64(), nil
+}
+
+// intValue returns the int with the sign and absolute value as an int when it
+// fits an int, otherwise as an int64 or a uint64
+func intValue(neg bool, abs uint64) any {
+	switch {
+	case neg && abs <= uint64(-(math.MinInt+1))+1:
+		return -int(abs-1) - 1
+	case !neg && abs <= math.MaxInt:
+		return int(abs)
+	default:
+		return int64Value(neg, abs)
+	}
+}
+
+// int64Value returns the int with the sign and absolute value as an int64,
+// or as a uint64 when it's greater than math.MaxInt64
+func int64Value(neg bool, abs uint64) any {
+	switch {
+	case neg:
+		return -int64(abs-1) - 1
+	case abs <= math.MaxInt64:
+		return int64(abs)
+	default:
+		return abs
+	}
+}
+
+// intParts returns the sign and absolute value of a validated int value (see
+// intValue) or of an int field's value (an int64 or uint64)
+func intParts(v any) (bool, uint64, bool) {
+	switch v := v.(type) {
+	case int:
+		return intParts(int64(v))
+	case int64:
+		if v < 0 {
+			return true, uint64(-(v + 1)) + 1, true
+		}
+		return false, uint64(v), true
+	case uint64:
+		return false, v, true
+	default:
+		return false, 0, false
+	}
+}
+
+// compareInts compares int values (see intParts) and returns -1, 0 or +1 when
+// a is less than, equal to or greater than b.  It reports false when either
+// isn't an int value.
+func compareInts(a, b any) (int, bool) {
+	aNeg, aAbs, aOk := intParts(a)
+	bNeg, bAbs, bOk := intParts(b)
+	switch {
+	case !aOk || !bOk:
+		return 0, false
+	case aNeg != bNeg && aNeg:
+		return -1, true
+	case aNeg != bNeg:
+		return 1, true
+	case aNeg:
+		return compareOrdered(bAbs, aAbs), true
+	default:
+		return compareOrdered(aAbs, bAbs), true
+	}
+}
+
+// validateFloat validates a float literal, which may be a number literal
+// with underscores or an exponent (ie: 1_000.5 or 1.5e-3), but not a hex
+// literal.
+func validateFloat(s string) (any, error) {
+	const op = "mql.validateFloat"
+	n, hex, ok := numberLiteral(s)
+	switch {
+	case ok && hex:
+		return nil, fmt.Errorf("%s: value %q is not float (hex numbers are only supported for int columns): %w", op, s, ErrInvalidParameter)
+	case ok:
+		s = n
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%s: value %q is not float: %w", op, s, ErrInvalidParameter)
+	}
+	return f, nil
+}
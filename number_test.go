@@ -0,0 +1,297 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/hashicorp/mql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type numberModel struct {
+	Count  int
+	Small  int8
+	Port   uint16
+	Size   uint64
+	Offset int64
+	Ratio  float64
+}
+
+func TestParse_numbers(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name            string
+		query           string
+		model           any
+		want            *mql.WhereClause
+		wantErrIs       error
+		wantErrContains string
+	}{
+		{
+			name:  "negative-int",
+			query: `count > -1`,
+			want:  &mql.WhereClause{Condition: "count>?", Args: []any{-1}},
+		},
+		{
+			name:  "negative-float",
+			query: `ratio>=-.5`,
+			want:  &mql.WhereClause{Condition: "ratio>=?", Args: []any{-0.5}},
+		},
+		{
+			name:  "exponents",
+			query: `count<1e3 and ratio>1.5e-3 and ratio<2E+2`,
+			want:  &mql.WhereClause{Condition: "((count<? and ratio>?) and ratio<?)", Args: []any{1000, 0.0015, 200.0}},
+		},
+		{
+			name:  "underscores",
+			query: `count<1_000 and ratio>1_000.5`,
+			want:  &mql.WhereClause{Condition: "(count<? and ratio>?)", Args: []any{1000, 1000.5}},
+		},
+		{
+			name:  "hex",
+			query: `count=0xff or count=-0X1_0`,
+			want:  &mql.WhereClause{Condition: "(count=? or count=?)", Args: []any{255, -16}},
+		},
+		{
+			name:  "whole-number-with-fraction",
+			query: `count=2.0`,
+			want:  &mql.WhereClause{Condition: "count=?", Args: []any{2}},
+		},
+		{
+			name:  "string-column",
+			query: `name=-1_000`,
+			model: testModel{},
+			want:  &mql.WhereClause{Condition: "name=?", Args: []any{"-1_000"}},
+		},
+		{
+			name:  "decimal",
+			query: `total>-1_000.50 and amount<1e3`,
+			model: invoiceModel{},
+			want:  &mql.WhereClause{Condition: "(total>? and amount<?)", Args: []any{"-1000.50", "1e3"}},
+		},
+		{
+			name:      "err-int-fraction",
+			query:     `count=1.5e-1`,
+			wantErrIs: mql.ErrInvalidParameter,
+		},
+		{
+			name:      "err-int-out-of-range",
+			query:     `count=1e30`,
+			wantErrIs: mql.ErrInvalidParameter,
+		},
+		{
+			name:      "err-hex-float",
+			query:     `ratio=0xff`,
+			wantErrIs: mql.ErrInvalidParameter,
+		},
+		{
+			name:      "err-hex-decimal",
+			query:     `total=0xff`,
+			model:     invoiceModel{},
+			wantErrIs: mql.ErrInvalidParameter,
+		},
+		{
+			name:      "err-invalid-number",
+			query:     `count=1__000`,
+			wantErrIs: mql.ErrInvalidNumber,
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert, require := assert.New(t), require.New(t)
+			model := tc.model
+			if model == nil {
+				model = numberModel{}
+			}
+			got, err := mql.Parse(tc.query, model)
+			if tc.wantErrIs != nil {
+				require.Error(err)
+				assert.ErrorIs(err, tc.wantErrIs)
+				if tc.wantErrContains != "" {
+					assert.ErrorContains(err, tc.wantErrContains)
+				}
+				assert.Nil(got)
+				return
+			}
+			require.NoError(err)
+			assert.Equal(tc.want, got)
+		})
+	}
+	t.Run("match", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		ok, err := mql.Match(`count>-1 and count<1e2 and ratio>=1_000.5`, numberModel{Count: 21, Ratio: 1000.5})
+		require.NoError(err)
+		assert.True(ok)
+	})
+	t.Run("mql", func(t *testing.T) {
+		e, err := mql.ParseExpr(`count>-1 and name="-a"`)
+		require.NoError(t, err)
+		assert.Equal(t, `count>-1 and name="-a"`, e.MQL())
+	})
+}
+
+func TestParse_intRanges(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name            string
+		query           string
+		want            *mql.WhereClause
+		wantErrContains string
+	}{
+		{
+			name:  "bounds",
+			query: `small=-128 or small=127 or port=65535 or offset=-9223372036854775808`,
+			want: &mql.WhereClause{
+				Condition: "(((small=? or small=?) or port=?) or offset=?)",
+				Args:      []any{-128, 127, 65535, math.MinInt64},
+			},
+		},
+		{
+			name:  "uint64-greater-than-max-int64",
+			query: `size>=18446744073709551615 and size>9_223_372_036_854_775_808`,
+			want: &mql.WhereClause{
+				Condition: "(size>=? and size>?)",
+				Args:      []any{uint64(math.MaxUint64), uint64(1 << 63)},
+			},
+		},
+		{
+			name:  "uint64-hex",
+			query: `size=0xffff_ffff_ffff_ffff`,
+			want:  &mql.WhereClause{Condition: "size=?", Args: []any{uint64(math.MaxUint64)}},
+		},
+		{
+			name:  "negative-zero-unsigned",
+			query: `port=-0`,
+			want:  &mql.WhereClause{Condition: "port=?", Args: []any{0}},
+		},
+		{
+			name:            "err-int8",
+			query:           `small=128`,
+			wantErrContains: `"128" isn't in the range of int8 (-128 to 127)`,
+		},
+		{
+			name:            "err-uint16",
+			query:           `port=6.5536e4`,
+			wantErrContains: `"6.5536e4" isn't in the range of uint16 (0 to 65535)`,
+		},
+		{
+			name:            "err-negative-unsigned",
+			query:           `size>-1`,
+			wantErrContains: `"-1" isn't in the range of uint64 (0 to 18446744073709551615)`,
+		},
+		{
+			name:            "err-int64",
+			query:           `offset=9223372036854775808`,
+			wantErrContains: `"9223372036854775808" isn't in the range of int64`,
+		},
+		{
+			name:            "err-64-bits",
+			query:           `size=18446744073709551616`,
+			wantErrContains: `"18446744073709551616" doesn't fit 64 bits`,
+		},
+		{
+			name:            "err-64-bits-exponent",
+			query:           `count=-1e20`,
+			wantErrContains: `"-1e20" doesn't fit 64 bits`,
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert, require := assert.New(t), require.New(t)
+			got, err := mql.Parse(tc.query, numberModel{})
+			if tc.wantErrContains != "" {
+				require.Error(err)
+				assert.ErrorIs(err, mql.ErrValueOutOfRange)
+				assert.ErrorIs(err, mql.ErrInvalidParameter)
+				assert.ErrorContains(err, tc.wantErrContains)
+				assert.Equal(mql.CodeValueOutOfRange, mql.ErrorCodeOf(err))
+				assert.Nil(got)
+				return
+			}
+			require.NoError(err)
+			assert.Equal(tc.want, got)
+		})
+	}
+	t.Run("map", func(t *testing.T) {
+		_, err := mql.Parse(`scores.math=1e19`, testModel{})
+		assert.ErrorIs(t, err, mql.ErrValueOutOfRange)
+	})
+	t.Run("match", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		m := numberModel{Size: math.MaxUint64, Offset: -5}
+		ok, err := mql.Match(`size>9223372036854775808 and size=18446744073709551615 and offset<-4 and offset>-1e1`, m)
+		require.NoError(err)
+		assert.True(ok)
+		ok, err = mql.Match(`size<9223372036854775808`, m)
+		require.NoError(err)
+		assert.False(ok)
+	})
+}
+
+func TestParse_WithTypedArgs(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name  string
+		query string
+		model any
+		want  *mql.WhereClause
+	}{
+		{
+			name:  "ints",
+			query: `count=1 and small>-1 and size<18446744073709551615`,
+			model: numberModel{},
+			want: &mql.WhereClause{
+				Condition: "((count=? and small>?) and size<?)",
+				Args:      []any{int64(1), int64(-1), uint64(math.MaxUint64)},
+			},
+		},
+		{
+			name:  "floats",
+			query: `ratio=1 and ratio<1e3`,
+			model: numberModel{},
+			want:  &mql.WhereClause{Condition: "(ratio=? and ratio<?)", Args: []any{1.0, 1000.0}},
+		},
+		{
+			name:  "strings",
+			query: `member_number=1 and name="alice" and age>21`,
+			model: testModel{},
+			want: &mql.WhereClause{
+				Condition: "((member_number=? and name=?) and age>?)",
+				Args:      []any{"1", "alice", int64(21)},
+			},
+		},
+		{
+			name:  "map",
+			query: `scores.math>=90`,
+			model: testModel{},
+			want: &mql.WhereClause{
+				Condition: "(scores->>?)::bigint>=?",
+				Args:      []any{"math", int64(90)},
+			},
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert, require := assert.New(t), require.New(t)
+			got, err := mql.Parse(tc.query, tc.model, mql.WithTypedArgs())
+			require.NoError(err)
+			assert.Equal(tc.want, got)
+		})
+	}
+	t.Run("match", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		ok, err := mql.Match(`count>=1 and size>1`, numberModel{Count: 1, Size: math.MaxUint64}, mql.WithTypedArgs())
+		require.NoError(err)
+		assert.True(ok)
+	})
+}
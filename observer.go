@@ -0,0 +1,165 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Observer is notified about every query parsed by Parse and ParseContext (see
+// WithObserver), so metrics and traces can be emitted about the filters users
+// run.  It's called synchronously once the query is parsed and converted (or
+// failed), so it should return quickly.
+type Observer interface {
+	ObserveParse(ctx context.Context, s ParseStats)
+}
+
+// ObserverFunc is an adapter which allows a func to be used as an Observer
+type ObserverFunc func(ctx context.Context, s ParseStats)
+
+// ObserveParse calls f(ctx, s)
+func (f ObserverFunc) ObserveParse(ctx context.Context, s ParseStats) {
+	f(ctx, s)
+}
+
+// ErrorCategory is a coarse category of the error returned when parsing a
+// query, which is suitable as a metric label
+type ErrorCategory string
+
+const (
+	// ErrorCategorySyntax is a query which can't be parsed (see ParseError)
+	ErrorCategorySyntax ErrorCategory = "syntax"
+
+	// ErrorCategoryColumn is a column which isn't part of the model or isn't
+	// authorized (see ErrInvalidColumn)
+	ErrorCategoryColumn ErrorCategory = "column"
+
+	// ErrorCategoryValue is a value or operator which isn't valid for its
+	// column (or an invalid parameter)
+	ErrorCategoryValue ErrorCategory = "value"
+
+	// ErrorCategoryLimit is a query which exceeds a limit (see
+	// ErrLimitExceeded)
+	ErrorCategoryLimit ErrorCategory = "limit"
+
+	// ErrorCategoryCanceled is a query whose context was done before it was
+	// parsed (see ParseContext)
+	ErrorCategoryCanceled ErrorCategory = "canceled"
+
+	// ErrorCategoryOther is any other error (ie: returned by a converter)
+	ErrorCategoryOther ErrorCategory = "other"
+)
+
+// ParseStats describes a parsed query and the result of parsing it
+type ParseStats struct {
+	// Duration is how long it took to parse and convert the query
+	Duration time.Duration
+
+	// QueryLength is the length of the query in bytes
+	QueryLength int
+
+	// Comparisons is the number of comparisons in the query
+	Comparisons int
+
+	// Depth is the depth of the query's expr tree, where a single comparison
+	// has a depth of 1.  It's 0 when the query couldn't be parsed.
+	Depth int
+
+	// ComparisonOps is the number of times each comparison operator is used
+	ComparisonOps map[ComparisonOp]int
+
+	// LogicalOps is the number of times each logical operator is used
+	LogicalOps map[LogicalOp]int
+
+	// Err is the error returned by Parse, if any
+	Err error
+
+	// ErrCategory is the category of Err, which is empty when there's no error
+	ErrCategory ErrorCategory
+
+	// ErrCode is the code of Err (see ErrorCodeOf), which is empty when
+	// there's no error or its code is unknown
+	ErrCode ErrorCode
+}
+
+// WithObserver provides an optional Observer which is notified about every
+// query parsed by Parse and ParseContext.
+func WithObserver(o Observer) Option {
+	const op = "mql.WithObserver"
+	return func(opts *options) error {
+		if isNil(o) {
+			return fmt.Errorf("%s: missing observer: %w", op, ErrInvalidParameter)
+		}
+		opts.withObserver = o
+		return nil
+	}
+}
+
+// newParseStats returns the stats of the parsed expr tree (which can be nil)
+func newParseStats(query string, e Expr, start time.Time, err error) ParseStats {
+	s := ParseStats{
+		Duration:      time.Since(start),
+		QueryLength:   len(query),
+		ComparisonOps: map[ComparisonOp]int{},
+		LogicalOps:    map[LogicalOp]int{},
+		Depth:         exprDepth(e),
+		Err:           err,
+		ErrCategory:   errorCategory(err),
+		ErrCode:       ErrorCodeOf(err),
+	}
+	walkExpr(e, func(e Expr) {
+		switch v := e.(type) {
+		case *ComparisonExpr:
+			s.Comparisons++
+			s.ComparisonOps[v.ComparisonOp]++
+		case *LogicalExpr:
+			s.LogicalOps[v.LogicalOp]++
+		}
+	})
+	return s
+}
+
+// exprDepth returns the depth of the expr tree
+func exprDepth(e Expr) int {
+	if isNil(e) {
+		return 0
+	}
+	l, ok := e.(*LogicalExpr)
+	if !ok {
+		return 1
+	}
+	left, right := exprDepth(l.LeftExpr), exprDepth(l.RightExpr)
+	if left > right {
+		return left + 1
+	}
+	return right + 1
+}
+
+// errorCategory returns the category of the error
+func errorCategory(err error) ErrorCategory {
+	var pErr *ParseError
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return ErrorCategoryCanceled
+	case errors.Is(err, ErrLimitExceeded):
+		return ErrorCategoryLimit
+	case errors.As(err, &pErr):
+		return ErrorCategorySyntax
+	case errors.Is(err, ErrInvalidColumn):
+		return ErrorCategoryColumn
+	case errors.Is(err, ErrInvalidParameter),
+		errors.Is(err, ErrInvalidComparisonOp),
+		errors.Is(err, ErrInvalidComparisonValueType),
+		errors.Is(err, ErrInvalidEnumValue),
+		errors.Is(err, ErrInvalidNumber):
+		return ErrorCategoryValue
+	default:
+		return ErrorCategoryOther
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/mql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse_WithObserver(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name            string
+		query           string
+		opts            []mql.Option
+		wantComparisons int
+		wantDepth       int
+		wantCmpOps      map[mql.ComparisonOp]int
+		wantLogicalOps  map[mql.LogicalOp]int
+		wantErrCategory mql.ErrorCategory
+		wantErrCode     mql.ErrorCode
+	}{
+		{
+			name:            "success",
+			query:           `name="alice" and (age>21 or age<10) and email%"example"`,
+			wantComparisons: 4,
+			wantDepth:       4,
+			wantCmpOps:      map[mql.ComparisonOp]int{mql.EqualOp: 1, mql.GreaterThanOp: 1, mql.LessThanOp: 1, mql.ContainsOp: 1},
+			wantLogicalOps:  map[mql.LogicalOp]int{mql.AndOp: 2, mql.OrOp: 1},
+		},
+		{
+			name:           "empty-query",
+			query:          "",
+			opts:           []mql.Option{mql.WithAllowEmptyQuery()},
+			wantCmpOps:     map[mql.ComparisonOp]int{},
+			wantLogicalOps: map[mql.LogicalOp]int{},
+		},
+		{
+			name:            "err-syntax",
+			query:           `name="alice" and`,
+			wantCmpOps:      map[mql.ComparisonOp]int{},
+			wantLogicalOps:  map[mql.LogicalOp]int{},
+			wantErrCategory: mql.ErrorCategorySyntax,
+			wantErrCode:     mql.CodeMissingRightSideExpr,
+		},
+		{
+			name:            "err-column",
+			query:           `nickname="alice"`,
+			wantComparisons: 1,
+			wantDepth:       1,
+			wantCmpOps:      map[mql.ComparisonOp]int{mql.EqualOp: 1},
+			wantLogicalOps:  map[mql.LogicalOp]int{},
+			wantErrCategory: mql.ErrorCategoryColumn,
+			wantErrCode:     mql.CodeInvalidColumn,
+		},
+		{
+			name:            "err-value",
+			query:           `age>"old"`,
+			wantComparisons: 1,
+			wantDepth:       1,
+			wantCmpOps:      map[mql.ComparisonOp]int{mql.GreaterThanOp: 1},
+			wantLogicalOps:  map[mql.LogicalOp]int{},
+			wantErrCategory: mql.ErrorCategoryValue,
+			wantErrCode:     mql.CodeInvalidParameter,
+		},
+		{
+			name:            "err-limit",
+			query:           `name="alice"`,
+			opts:            []mql.Option{mql.WithMaxTokens(2)},
+			wantCmpOps:      map[mql.ComparisonOp]int{},
+			wantLogicalOps:  map[mql.LogicalOp]int{},
+			wantErrCategory: mql.ErrorCategoryLimit,
+			wantErrCode:     mql.CodeTooManyTokens,
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert, require := assert.New(t), require.New(t)
+			var got []mql.ParseStats
+			observer := mql.ObserverFunc(func(ctx context.Context, s mql.ParseStats) {
+				assert.NotNil(ctx)
+				got = append(got, s)
+			})
+			_, err := mql.Parse(tc.query, testModel{}, append(tc.opts, mql.WithObserver(observer))...)
+			require.Len(got, 1)
+			s := got[0]
+			assert.Equal(len(tc.query), s.QueryLength)
+			assert.Equal(tc.wantComparisons, s.Comparisons)
+			assert.Equal(tc.wantDepth, s.Depth)
+			assert.Equal(tc.wantCmpOps, s.ComparisonOps)
+			assert.Equal(tc.wantLogicalOps, s.LogicalOps)
+			assert.Equal(tc.wantErrCategory, s.ErrCategory)
+			assert.Equal(tc.wantErrCode, s.ErrCode)
+			assert.GreaterOrEqual(s.Duration, time.Duration(0))
+			if tc.wantErrCategory == "" {
+				require.NoError(err)
+				assert.NoError(s.Err)
+				return
+			}
+			require.Error(err)
+			assert.ErrorIs(err, s.Err)
+		})
+	}
+	t.Run("canceled", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		var got mql.ParseStats
+		_, err := mql.ParseContext(ctx, `name="alice"`, testModel{}, mql.WithObserver(mql.ObserverFunc(func(observed context.Context, s mql.ParseStats) {
+			assert.Equal(ctx, observed)
+			got = s
+		})))
+		require.Error(err)
+		assert.Equal(mql.ErrorCategoryCanceled, got.ErrCategory)
+	})
+	t.Run("err-missing-observer", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		_, err := mql.Parse(`name="alice"`, testModel{}, mql.WithObserver(nil))
+		require.Error(err)
+		assert.ErrorIs(err, mql.ErrInvalidParameter)
+		assert.ErrorContains(err, "missing observer")
+	})
+}
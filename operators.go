@@ -0,0 +1,99 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/exp/slices"
+)
+
+// WithDisabledOperators will reject the comparisons which use one of the
+// comparison operators with an ErrDisabledComparisonOp (which is also an
+// ErrInvalidComparisonOp), so services can prohibit the operators which are
+// too expensive for them (ie: % on large text columns without an index) or
+// which their database doesn't support.
+func WithDisabledOperators(ops ...ComparisonOp) Option {
+	const op = "mql.WithDisabledOperators"
+	return func(o *options) error {
+		if len(ops) == 0 {
+			return fmt.Errorf("%s: missing comparison operators: %w", op, ErrInvalidParameter)
+		}
+		if o.withDisabledOps == nil {
+			o.withDisabledOps = make(map[ComparisonOp]struct{}, len(ops))
+		}
+		for _, c := range ops {
+			if !c.Valid() {
+				return fmt.Errorf("%s: %w %q", op, ErrInvalidComparisonOp, c)
+			}
+			o.withDisabledOps[c] = struct{}{}
+		}
+		return nil
+	}
+}
+
+// WithColumnOperators restricts the comparisons of the column (database
+// column or model field name) to the comparison operators (ie: only = and !=
+// for a hashed or encrypted column).  The comparisons of the column which use
+// other operators are rejected with an ErrDisabledComparisonOp (which is also
+// an ErrInvalidComparisonOp).  The operators of a map column apply to all of
+// its keys (ie: labels.env).
+func WithColumnOperators(column string, ops ...ComparisonOp) Option {
+	const op = "mql.WithColumnOperators"
+	return func(o *options) error {
+		switch {
+		case column == "":
+			return fmt.Errorf("%s: missing column: %w", op, ErrInvalidParameter)
+		case len(ops) == 0:
+			return fmt.Errorf("%s: missing comparison operators of column %q: %w", op, column, ErrInvalidParameter)
+		}
+		for _, c := range ops {
+			if !c.Valid() {
+				return fmt.Errorf("%s: %w %q for column %q", op, ErrInvalidComparisonOp, c, column)
+			}
+		}
+		if o.withColumnOps == nil {
+			o.withColumnOps = make(map[string][]ComparisonOp)
+		}
+		o.withColumnOps[strings.ToLower(strings.ReplaceAll(column, "_", ""))] = slices.Clone(ops)
+		return nil
+	}
+}
+
+// checkComparisonOp returns an ErrDisabledComparisonOp when the comparison's
+// operator is disabled or isn't one of the operators of its column.
+// Supported options: WithDisabledOperators, WithColumnOperators,
+// WithColumnMap
+func checkComparisonOp(e *ComparisonExpr, opts options) error {
+	const op = "mql.checkComparisonOp"
+	if _, ok := opts.withDisabledOps[e.ComparisonOp]; ok {
+		return fmt.Errorf("%s: %w %q for column %q: %w", op, ErrDisabledComparisonOp, e.ComparisonOp, e.Column, ErrInvalidComparisonOp)
+	}
+	if len(opts.withColumnOps) == 0 {
+		return nil
+	}
+	columnName := strings.ToLower(e.Column)
+	if n, ok := opts.withColumnMap[columnName]; ok {
+		columnName = n
+	}
+	ops, ok := opts.withColumnOps[strings.ToLower(strings.ReplaceAll(columnName, "_", ""))]
+	if !ok {
+		// the operators of a map column apply to its keys (ie: labels.env)
+		prefix, _, found := strings.Cut(columnName, ".")
+		if !found {
+			return nil
+		}
+		if n, ok := opts.withColumnMap[prefix]; ok {
+			prefix = n
+		}
+		if ops, ok = opts.withColumnOps[strings.ToLower(strings.ReplaceAll(prefix, "_", ""))]; !ok {
+			return nil
+		}
+	}
+	if slices.Contains(ops, e.ComparisonOp) {
+		return nil
+	}
+	return fmt.Errorf("%s: %w %q for column %q (expected one of: %s): %w", op, ErrDisabledComparisonOp, e.ComparisonOp, columnName, joinOps(ops), ErrInvalidComparisonOp)
+}
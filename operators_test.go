@@ -0,0 +1,137 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql_test
+
+import (
+	"testing"
+
+	"github.com/hashicorp/mql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse_WithDisabledOperators(t *testing.T) {
+	t.Parallel()
+	disabled := mql.WithDisabledOperators(mql.ContainsOp, mql.SimilarToOp)
+	tests := []struct {
+		name      string
+		query     string
+		want      *mql.WhereClause
+		wantErrIs error
+	}{
+		{
+			name:  "enabled",
+			query: `name="alice" and age>21`,
+			want:  &mql.WhereClause{Condition: "(name=? and age>?)", Args: []any{"alice", 21}},
+		},
+		{
+			name:      "disabled",
+			query:     `name="alice" and email%"example"`,
+			wantErrIs: mql.ErrDisabledComparisonOp,
+		},
+		{
+			name:      "disabled-map-key",
+			query:     `labels.env~%"prod"`,
+			wantErrIs: mql.ErrDisabledComparisonOp,
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert, require := assert.New(t), require.New(t)
+			got, err := mql.Parse(tc.query, testModel{}, disabled)
+			if tc.wantErrIs != nil {
+				require.Error(err)
+				assert.ErrorIs(err, tc.wantErrIs)
+				assert.ErrorIs(err, mql.ErrInvalidComparisonOp)
+				assert.Equal(mql.CodeDisabledComparisonOp, mql.ErrorCodeOf(err))
+				assert.Nil(got)
+				return
+			}
+			require.NoError(err)
+			assert.Equal(tc.want, got)
+		})
+	}
+	t.Run("match", func(t *testing.T) {
+		_, err := mql.Match(`name%"ali"`, testModel{Name: "alice"}, disabled)
+		assert.ErrorIs(t, err, mql.ErrDisabledComparisonOp)
+	})
+	t.Run("err-options", func(t *testing.T) {
+		_, err := mql.Parse(`name="alice"`, testModel{}, mql.WithDisabledOperators())
+		assert.ErrorIs(t, err, mql.ErrInvalidParameter)
+		_, err = mql.Parse(`name="alice"`, testModel{}, mql.WithDisabledOperators("=="))
+		assert.ErrorIs(t, err, mql.ErrInvalidComparisonOp)
+	})
+}
+
+func TestParse_WithColumnOperators(t *testing.T) {
+	t.Parallel()
+	opts := []mql.Option{
+		mql.WithColumnOperators("email", mql.EqualOp, mql.NotEqualOp),
+		mql.WithColumnOperators("Labels", mql.EqualOp),
+	}
+	tests := []struct {
+		name            string
+		query           string
+		opts            []mql.Option
+		want            *mql.WhereClause
+		wantErrContains string
+	}{
+		{
+			name:  "allowed",
+			query: `email="alice@example.com" and name%"ali" and labels.env="prod"`,
+			want: &mql.WhereClause{
+				Condition: "((email=? and name like ? escape '\\') and labels->>?=?)",
+				Args:      []any{"alice@example.com", "%ali%", "env", "prod"},
+			},
+		},
+		{
+			name:            "not-allowed",
+			query:           `email%"example.com"`,
+			wantErrContains: `"%" for column "email" (expected one of: = !=)`,
+		},
+		{
+			name:            "map-key",
+			query:           `labels.env!="prod"`,
+			wantErrContains: `"!=" for column "labels.env" (expected one of: =)`,
+		},
+		{
+			name:            "column-map",
+			query:           `mail>"a"`,
+			opts:            []mql.Option{mql.WithColumnMap(map[string]string{"mail": "email"})},
+			wantErrContains: `">" for column "email" (expected one of: = !=)`,
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert, require := assert.New(t), require.New(t)
+			got, err := mql.Parse(tc.query, testModel{}, append(tc.opts, opts...)...)
+			if tc.wantErrContains != "" {
+				require.Error(err)
+				assert.ErrorIs(err, mql.ErrDisabledComparisonOp)
+				assert.ErrorIs(err, mql.ErrInvalidComparisonOp)
+				assert.ErrorContains(err, tc.wantErrContains)
+				assert.Nil(got)
+				return
+			}
+			require.NoError(err)
+			assert.Equal(tc.want, got)
+		})
+	}
+	t.Run("err-options", func(t *testing.T) {
+		for _, o := range []mql.Option{
+			mql.WithColumnOperators("", mql.EqualOp),
+			mql.WithColumnOperators("email"),
+			mql.WithColumnOperators("email", "=="),
+		} {
+			_, err := mql.Parse(`email="alice@example.com"`, testModel{}, o)
+			assert.Error(t, err)
+		}
+		err := mql.ValidateOptions(testModel{}, mql.WithColumnOperators("nickname", mql.EqualOp))
+		assert.ErrorContains(t, err, `WithColumnOperators column "nickname" isn't a column of the model`)
+	})
+}
@@ -0,0 +1,158 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql
+
+import (
+	"fmt"
+	"strings"
+)
+
+// WithOptimize will simplify the expr tree before it's converted, so the where
+// clauses of machine-generated queries are smaller and more plan cache
+// friendly: repeated operands of a logical expr are removed (a=1 or a=1 is
+// a=1), operands absorbed by another operand are removed (a=1 or (a=1 and
+// b=2) is a=1) and nested logical exprs using the same operator are merged
+// into a single group ((a and b) and c is converted to: (a and b and c)).
+// Comparisons are compared using their validated values, so age=21 and
+// age=21.0 are the same comparison of a float column.  It's supported by
+// Parse, ParseContext, ToWhereClause, ConvertExpr and ParseListRequest.
+func WithOptimize() Option {
+	return func(o *options) error {
+		o.withOptimize = true
+		return nil
+	}
+}
+
+// optimizer simplifies expr trees (see WithOptimize)
+type optimizer struct {
+	linter
+}
+
+// optimizeExpr returns the simplified expr tree (see WithOptimize).  The expr
+// tree is not modified.
+func optimizeExpr(e Expr, fValidators map[string]validator, opts options) Expr {
+	o := optimizer{linter: linter{opts: opts, validators: fValidators}}
+	return o.optimize(e)
+}
+
+func (o *optimizer) optimize(e Expr) Expr {
+	le, ok := e.(*LogicalExpr)
+	if !ok || isNil(le.LeftExpr) || isNil(le.RightExpr) || le.LogicalOp == "" {
+		return e
+	}
+	var chain []Expr
+	chainOperands(le, le.LogicalOp, &chain)
+	var operands []Expr
+	for _, c := range chain {
+		// an operand may become a chain using the same operator once it's
+		// simplified (ie: (a and b or a and b) and c)
+		chainOperands(o.optimize(c), le.LogicalOp, &operands)
+	}
+
+	var (
+		kept []Expr
+		keys = make(map[string]bool, len(operands))
+	)
+	for _, operand := range operands {
+		k := o.key(operand)
+		if keys[k] {
+			continue
+		}
+		keys[k] = true
+		kept = append(kept, operand)
+	}
+	absorbed := kept[:0:0]
+	for _, operand := range kept {
+		if !o.isAbsorbed(operand, keys) {
+			absorbed = append(absorbed, operand)
+		}
+	}
+	return chainExprs(le.LogicalOp, absorbed)
+}
+
+// isAbsorbed reports if the operand is a logical expr whose operands include
+// one of its siblings (keys), so it doesn't change the result: a or (a and b)
+// is a, and a and (a or b) is a.
+func (o *optimizer) isAbsorbed(operand Expr, keys map[string]bool) bool {
+	le, ok := operand.(*LogicalExpr)
+	if !ok {
+		return false
+	}
+	var operands []Expr
+	chainOperands(le, le.LogicalOp, &operands)
+	for _, e := range operands {
+		if keys[o.key(e)] {
+			return true
+		}
+	}
+	return false
+}
+
+// key returns a string which is the same for equivalent exprs.  Comparisons
+// use their normalized column and validated value.
+func (o *optimizer) key(e Expr) string {
+	switch v := e.(type) {
+	case *ComparisonExpr:
+		if v.Value == nil {
+			return fmt.Sprintf("%s%s", v.Column, v.ComparisonOp)
+		}
+		if v.variable {
+			// variables are resolved once the expr tree is optimized
+			return fmt.Sprintf("%s%s:%s", o.column(v), v.ComparisonOp, *v.Value)
+		}
+		if val, ok := o.validators[o.column(v)]; ok && val.fn != nil {
+			if validated, err := val.fn(*v.Value); err == nil {
+				return fmt.Sprintf("%s%s%T:%v", o.column(v), v.ComparisonOp, validated, validated)
+			}
+		}
+		// map keys (labels.env) are case sensitive, so the column is used as is
+		return fmt.Sprintf("%s%s%q", v.Column, v.ComparisonOp, *v.Value)
+	case *LogicalExpr:
+		var operands []Expr
+		chainOperands(v, v.LogicalOp, &operands)
+		keys := make([]string, 0, len(operands))
+		for _, operand := range operands {
+			keys = append(keys, o.key(operand))
+		}
+		return fmt.Sprintf("(%s %s)", v.LogicalOp, strings.Join(keys, ", "))
+	default:
+		return fmt.Sprintf("%T:%p", v, v)
+	}
+}
+
+// chainExprs returns the operands combined with the logical operator, which is
+// grouped from the left just like a parsed query: (a and b) and c
+func chainExprs(lOp LogicalOp, operands []Expr) Expr {
+	e := operands[0]
+	for _, operand := range operands[1:] {
+		e = &LogicalExpr{LeftExpr: e, LogicalOp: lOp, RightExpr: operand}
+	}
+	return e
+}
+
+// chainWhereClause converts a chain of logical exprs using the same operator
+// into a single group: (a and b and c)
+func chainWhereClause(l *LogicalExpr, fValidators map[string]validator, opt ...Option) (*WhereClause, error) {
+	const op = "mql.chainWhereClause"
+	if l.LogicalOp == "" {
+		return nil, fmt.Errorf("%s: %w", op, ErrMissingLogicalOp)
+	}
+	var operands []Expr
+	chainOperands(l, l.LogicalOp, &operands)
+	var (
+		conditions = make([]string, 0, len(operands))
+		w          = &WhereClause{}
+	)
+	for _, e := range operands {
+		ew, err := exprToWhereClause(e, fValidators, opt...)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		conditions = append(conditions, ew.Condition)
+		w.Args = append(w.Args, ew.Args...)
+		w.argColumns = append(w.argColumns, ew.argColumns...)
+	}
+	w.Condition = "(" + strings.Join(conditions, " "+string(l.LogicalOp)+" ") + ")"
+	return w, nil
+}
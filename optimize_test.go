@@ -0,0 +1,139 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql_test
+
+import (
+	"testing"
+
+	"github.com/hashicorp/mql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithOptimize(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name            string
+		query           string
+		opts            []mql.Option
+		want            *mql.WhereClause
+		wantErrIs       error
+		wantErrContains string
+	}{
+		{
+			name:  "comparison",
+			query: `name="alice"`,
+			want:  &mql.WhereClause{Condition: "name=?", Args: []any{"alice"}},
+		},
+		{
+			name:  "merged-chain",
+			query: `(name="alice" and age>21) and (email="a@b.c" and age<65)`,
+			want: &mql.WhereClause{
+				Condition: "(name=? and age>? and email=? and age<?)",
+				Args:      []any{"alice", 21, "a@b.c", 65},
+			},
+		},
+		{
+			name:  "repeated-comparison",
+			query: `name="alice" or age>21 or NAME='alice'`,
+			want: &mql.WhereClause{
+				Condition: "(name=? or age>?)",
+				Args:      []any{"alice", 21},
+			},
+		},
+		{
+			name:  "repeated-comparison-validated-value",
+			query: `length=1 and length=1.0`,
+			want:  &mql.WhereClause{Condition: "length=?", Args: []any{float64(1)}},
+		},
+		{
+			name:  "repeated-group",
+			query: `(name="alice" and age>21) or (name="alice" and age>21)`,
+			want: &mql.WhereClause{
+				Condition: "(name=? and age>?)",
+				Args:      []any{"alice", 21},
+			},
+		},
+		{
+			name:  "simplified-operand-merged",
+			query: `((name="alice" and age>21) or (name="alice" and age>21)) and email="a@b.c"`,
+			want: &mql.WhereClause{
+				Condition: "(name=? and age>? and email=?)",
+				Args:      []any{"alice", 21, "a@b.c"},
+			},
+		},
+		{
+			name:  "absorbed-and",
+			query: `name="alice" or (name="alice" and age>21)`,
+			want:  &mql.WhereClause{Condition: "name=?", Args: []any{"alice"}},
+		},
+		{
+			name:  "absorbed-or",
+			query: `(age>21 or name="alice") and name="alice" and email="a@b.c"`,
+			want: &mql.WhereClause{
+				Condition: "(name=? and email=?)",
+				Args:      []any{"alice", "a@b.c"},
+			},
+		},
+		{
+			name:  "different-ops",
+			query: `name="alice" and (age>21 or age<10)`,
+			want: &mql.WhereClause{
+				Condition: "(name=? and (age>? or age<?))",
+				Args:      []any{"alice", 21, 10},
+			},
+		},
+		{
+			name:  "map-keys-are-case-sensitive",
+			query: `labels.env="prod" and labels.Env="prod"`,
+			want: &mql.WhereClause{
+				Condition: "(labels->>?=? and labels->>?=?)",
+				Args:      []any{"env", "prod", "Env", "prod"},
+			},
+		},
+		{
+			name:  "pg-placeholders",
+			query: `name="alice" or name="alice" or age>21`,
+			opts:  []mql.Option{mql.WithPgPlaceholders()},
+			want: &mql.WhereClause{
+				Condition: "(name=$1 or age>$2)",
+				Args:      []any{"alice", 21},
+			},
+		},
+		{
+			name:            "err-invalid-column",
+			query:           `name="alice" and nickname="alice"`,
+			wantErrIs:       mql.ErrInvalidColumn,
+			wantErrContains: `invalid column "nickname"`,
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert, require := assert.New(t), require.New(t)
+			got, err := mql.Parse(tc.query, testModel{}, append(tc.opts, mql.WithOptimize())...)
+			if tc.wantErrContains != "" {
+				require.Error(err)
+				assert.Empty(got)
+				assert.ErrorIs(err, tc.wantErrIs)
+				assert.ErrorContains(err, tc.wantErrContains)
+				return
+			}
+			require.NoError(err)
+			assert.Equal(tc.want, got)
+		})
+	}
+}
+
+func TestWithOptimize_ToWhereClause(t *testing.T) {
+	t.Parallel()
+	assert, require := assert.New(t), require.New(t)
+	e := mql.C("name").Eq("alice").And(mql.C("name").Eq("alice").And(mql.C("age").Gt(21)))
+	got, err := mql.ToWhereClause(e, testModel{}, mql.WithOptimize())
+	require.NoError(err)
+	assert.Equal(&mql.WhereClause{Condition: "(name=? and age>?)", Args: []any{"alice", 21}}, got)
+	// the expr isn't modified
+	assert.Equal(`name="alice" and (name="alice" and age>21)`, e.MQL())
+}
@@ -4,24 +4,106 @@
 package mql
 
 import (
+	"context"
 	"fmt"
+	"reflect"
+	"strings"
+	"time"
 )
 
 type options struct {
-	withSkipWhitespace     bool
 	withColumnMap          map[string]string
 	withValidateConvertFns map[string]ValidateConvertFunc
 	withIgnoredFields      []string
 	withPgPlaceholder      bool
+	withAllowEmptyQuery    bool
+	withLenientParsing     bool
+	withSqlNamedArgs       bool
+	withModelDescriber     ModelDescriber
+	withoutModelCache      bool
+	withInlineValues       bool
+	withNamedParams        string
+	// withNullSemantics and withColumnNullSemantics are only used when
+	// evaluating in memory (see Match and Filter)
+	withNullSemantics       NullSemantics
+	withColumnNullSemantics map[string]NullSemantics
+	withDefaultPageLimit    int
+	withMaxPageLimit        int
+	withTimeNowFunc         func() time.Time
+	withDurationUnit        time.Duration
+	withEnums               map[string][]string
+	withDecimalColumns      map[string]struct{}
+	withBinaryIPs           bool
+	withJsonArrayColumns    map[string]struct{}
+	withEmptyStringAsNull   map[string]struct{}
+	withFieldAuthorizer     FieldAuthorizer
+	withValueTransforms     map[string]ValueTransformFunc
+	withDefaultConverter    ValidateConvertFunc
+	withContextConvertFns   map[string]ContextConvertFunc
+	withQuotedColumnChars   string
+	withRawLikePatterns     bool
+	withGlobPatterns        bool
+	withSimilarityThreshold float64
+	withTableAlias          string
+	withTableName           string
+	// withModelTable is the table name of the model's struct tag (see
+	// withModelTable)
+	withModelTable    string
+	withRelationships map[string]Relationship
+	// withParseContext is the context provided to ParseContext (see
+	// withContext)
+	withParseContext context.Context
+	withLimits       limits
+	withObserver     Observer
+	withDebugLogger  DebugLogger
+	withMetadata     bool
+	withOptimize     bool
+	withDialect      Dialect
+	// withErrorMessages and withErrorMessageFunc provide the user facing
+	// messages of errors (see ErrorMessage)
+	withErrorMessages    map[ErrorCode]string
+	withErrorMessageFunc ErrorMessageFunc
+	// withoutStringRanges, withoutAllStringRanges and
+	// withStringRangeCollation configure the range comparisons of string
+	// columns (see WithoutStringRanges and WithStringRangeCollation)
+	withoutStringRanges      map[string]struct{}
+	withoutAllStringRanges   bool
+	withStringRangeCollation string
+	withTypedArgs            bool
+	withCostHints            map[string]int
+	withAggregates           map[string]string
+	withNullSafeNotEqual     bool
+	// withCollation is the collation of the withCollationColumns, or every
+	// string column when it's nil
+	withCollation            string
+	withCollationColumns     map[string]struct{}
+	withAccentInsensitive    map[string]struct{}
+	withAllAccentInsensitive bool
+	withDisabledOps          map[ComparisonOp]struct{}
+	withColumnOps            map[string][]ComparisonOp
+	withBlindIndexes         map[string]blindIndex
+	withSoftDelete           string
+	withVariables            map[string]any
+	withMacros               map[string]string
+	withFilterResolver       FilterResolverFunc
+	// withEnumFoldCase is only used by Lint, which reports case mismatched
+	// enum values as diagnostics rather than errors
+	withEnumFoldCase bool
 }
 
 // Option - how options are passed as args
 type Option func(*options) error
 
 func getDefaultOptions() options {
+	// maps are allocated by their options when needed, since options are
+	// read for every query (and reading a nil map is fine)
 	return options{
-		withColumnMap:          make(map[string]string),
-		withValidateConvertFns: make(map[string]ValidateConvertFunc),
+		withDefaultPageLimit:  DefaultPageLimit,
+		withMaxPageLimit:      MaxPageLimit,
+		withLimits:            defaultLimits(),
+		withTimeNowFunc:       time.Now,
+		withDurationUnit:      time.Nanosecond,
+		withQuotedColumnChars: DefaultQuotedColumnChars,
 	}
 }
 
@@ -36,25 +118,83 @@ func getOpts(opt ...Option) (options, error) {
 	return opts, nil
 }
 
-// withSkipWhitespace provides an option to request that whitespace be skipped
-func withSkipWhitespace() Option {
+// withContext provides the context of ParseContext, which is checked for
+// cancellation while the query is converted and provided to converters via
+// ConvertContext.Context
+func withContext(ctx context.Context) Option {
 	return func(o *options) error {
-		o.withSkipWhitespace = true
+		o.withParseContext = ctx
+		return nil
+	}
+}
+
+// withEnumFoldCase will ignore case when validating the values of enum columns
+// (see WithEnum), so Lint can report a case mismatch as a diagnostic
+func withEnumFoldCase() Option {
+	return func(o *options) error {
+		o.withEnumFoldCase = true
 		return nil
 	}
 }
 
 // WithColumnMap provides an optional map of columns from a column in the user
-// provided query to a column in the database model
+// provided query to a column in the database model.  Query columns are case
+// insensitive and several of them can be mapped to the same column (see
+// WithColumnAliases).  The maps of multiple options are merged, but a query
+// column can't be mapped to different columns.  Every column must be a field
+// of the model, which is validated before a query is converted (see
+// ColumnMapError).
 func WithColumnMap(m map[string]string) Option {
+	const op = "mql.WithColumnMap"
+	return func(o *options) error {
+		for queryColumn, column := range m {
+			if err := o.mapColumn(queryColumn, column); err != nil {
+				return fmt.Errorf("%s: %w", op, err)
+			}
+		}
+		return nil
+	}
+}
+
+// WithColumnAliases provides optional aliases of a column, which are query
+// columns mapped to the column (see WithColumnMap).  For example:
+// WithColumnAliases("name", "nickname", "display_name")
+func WithColumnAliases(column string, aliases ...string) Option {
+	const op = "mql.WithColumnAliases"
 	return func(o *options) error {
-		if !isNil(m) {
-			o.withColumnMap = m
+		if len(aliases) == 0 {
+			return fmt.Errorf("%s: missing aliases of column %q: %w", op, column, ErrInvalidParameter)
+		}
+		for _, a := range aliases {
+			if err := o.mapColumn(a, column); err != nil {
+				return fmt.Errorf("%s: %w", op, err)
+			}
 		}
 		return nil
 	}
 }
 
+// mapColumn maps the query column to the column.  The map is allocated by the
+// first mapping, so the maps provided by callers are never modified.
+func (o *options) mapColumn(queryColumn, column string) error {
+	const op = "mql.(options).mapColumn"
+	switch {
+	case queryColumn == "":
+		return fmt.Errorf("%s: missing query column of column %q: %w", op, column, ErrInvalidParameter)
+	case column == "":
+		return fmt.Errorf("%s: missing column of query column %q: %w", op, queryColumn, ErrInvalidParameter)
+	}
+	key := strings.ToLower(queryColumn)
+	if existing, ok := o.withColumnMap[key]; ok && existing != column {
+		return fmt.Errorf("%s: query column %q is mapped to both %q and %q: %w", op, queryColumn, existing, column, ErrInvalidParameter)
+	}
+	if o.withColumnMap == nil {
+		o.withColumnMap = make(map[string]string)
+	}
+	o.withColumnMap[key] = column
+	return nil
+}
+
 // ValidateConvertFunc validates the value and then converts the columnName,
 // comparisonOp and value to a WhereClause
 type ValidateConvertFunc func(columnName string, comparisonOp ComparisonOp, value *string) (*WhereClause, error)
@@ -70,6 +210,9 @@ func WithConverter(fieldName string, fn ValidateConvertFunc) Option {
 			if _, exists := o.withValidateConvertFns[fieldName]; exists {
 				return fmt.Errorf("%s: duplicated convert: %w", op, ErrInvalidParameter)
 			}
+			if o.withValidateConvertFns == nil {
+				o.withValidateConvertFns = make(map[string]ValidateConvertFunc)
+			}
 			o.withValidateConvertFns[fieldName] = fn
 		case fieldName == "" && !isNil(fn):
 			return fmt.Errorf("%s: missing field name: %w", op, ErrInvalidParameter)
@@ -80,6 +223,117 @@ func WithConverter(fieldName string, fn ValidateConvertFunc) Option {
 	}
 }
 
+// DefaultQuotedColumnChars are the characters which are legal in a quoted
+// column by default (in addition to letters, digits and underscores), so keys
+// of map fields can be quoted (ie: "labels.env"="prod")
+const DefaultQuotedColumnChars = "."
+
+// WithQuotedColumnChars provides the characters which are legal in a quoted
+// column (ie: `user name`="alice"), in addition to letters, digits and
+// underscores.  The default is DefaultQuotedColumnChars.  Columns which aren't
+// valid bare symbols (ie: ones with spaces) can only be used when quoted and
+// they must be a column of the model or be mapped to one (see WithColumnMap).
+func WithQuotedColumnChars(chars string) Option {
+	return func(o *options) error {
+		o.withQuotedColumnChars = chars
+		return nil
+	}
+}
+
+// ConvertContext describes the comparison being converted by a
+// ContextConvertFunc and the model's field it compares, so converters don't
+// need to introspect the model themselves.
+type ConvertContext struct {
+	// Column is the column identifier used in the query (ie: created_at)
+	Column string
+
+	// ColumnName is the database column once the column is resolved using
+	// WithColumnMap
+	ColumnName string
+
+	// FieldName is the name of the model's field (ie: CreatedAt)
+	FieldName string
+
+	// FieldType is the type of the model's field, using the same format as
+	// reflect.Type.String() (ie: *time.Time)
+	FieldType string
+
+	// ReflectType is the reflect.Type of the model's field.  It's nil when
+	// the model is described by a ModelDescriber (see WithModelDescriber).
+	ReflectType reflect.Type
+
+	// Pos is the byte offset of the column in the query.  It's 0 when the
+	// comparison wasn't parsed from a query (ie: built using C)
+	Pos int
+
+	// Context is the context provided to ParseContext, so converters can do
+	// lookups scoped to the request.  It's context.Background() otherwise.
+	Context context.Context
+}
+
+// ContextConvertFunc validates the value and then converts the comparison to
+// a WhereClause, just like a ValidateConvertFunc, but it receives the
+// comparison's ConvertContext instead of only its column name.
+type ContextConvertFunc func(ctx ConvertContext, comparisonOp ComparisonOp, value *string) (*WhereClause, error)
+
+// WithContextConverter provides an optional ContextConvertFunc for a column
+// (database column or model field name) of the model.  It's called once the
+// column is validated, with a ConvertContext describing the model's field.  A
+// converter provided via WithConverter for the same column identifier takes
+// precedence.
+func WithContextConverter(column string, fn ContextConvertFunc) Option {
+	const op = "mql.WithContextConverter"
+	return func(o *options) error {
+		switch {
+		case column == "":
+			return fmt.Errorf("%s: missing column: %w", op, ErrInvalidParameter)
+		case fn == nil:
+			return fmt.Errorf("%s: missing ContextConvertFunc for %q: %w", op, column, ErrInvalidParameter)
+		}
+		key := strings.ToLower(strings.ReplaceAll(column, "_", ""))
+		if o.withContextConvertFns == nil {
+			o.withContextConvertFns = make(map[string]ContextConvertFunc)
+		}
+		if _, exists := o.withContextConvertFns[key]; exists {
+			return fmt.Errorf("%s: duplicated convert for %q: %w", op, column, ErrInvalidParameter)
+		}
+		o.withContextConvertFns[key] = fn
+		return nil
+	}
+}
+
+// WithConverters provides optional ConvertFuncs for many column identifiers at
+// once (see WithConverter), so the same converters can be shared by every call.
+// A column can't have more than one converter.
+func WithConverters(converters map[string]ValidateConvertFunc) Option {
+	const op = "mql.WithConverters"
+	return func(o *options) error {
+		for fieldName, fn := range converters {
+			if err := WithConverter(fieldName, fn)(o); err != nil {
+				return fmt.Errorf("%s: %w", op, err)
+			}
+		}
+		return nil
+	}
+}
+
+// WithDefaultConverter provides an optional ConvertFunc which is used for every
+// column of the model without a converter (see WithConverter), so whole classes
+// of columns (ie: every timestamp) can be converted by a single func.  It's
+// called with the column's database name once the column is validated and if it
+// returns a nil WhereClause without an error, then the column is converted by
+// the default validation+conversion.  It's not used for map fields.
+func WithDefaultConverter(fn ValidateConvertFunc) Option {
+	const op = "mql.WithDefaultConverter"
+	return func(o *options) error {
+		if isNil(fn) {
+			return fmt.Errorf("%s: missing ConvertToSqlFunc: %w", op, ErrInvalidParameter)
+		}
+		o.withDefaultConverter = fn
+		return nil
+	}
+}
+
 // WithIgnoredFields provides an optional list of fields to ignore in the model
 // (your Go struct) when parsing. Note: Field names are case sensitive.
 func WithIgnoredFields(fieldName ...string) Option {
@@ -100,3 +354,424 @@ func WithPgPlaceholders() Option {
 		return nil
 	}
 }
+
+// WithSqlNamedArgs will use named parameter placeholders (@p1, @p2, etc) and
+// return the where clause args as sql.NamedArg, so they can be passed
+// directly to database/sql functions like QueryContext when using a driver
+// that supports named parameters. It cannot be used with WithPgPlaceholders.
+// See: https://pkg.go.dev/database/sql#Named
+func WithSqlNamedArgs() Option {
+	return func(o *options) error {
+		o.withSqlNamedArgs = true
+		return nil
+	}
+}
+
+// WithInlineValues will render the values directly in the where clause
+// condition as quoted SQL literals (and no args are returned) for engines and
+// tools which don't support bind parameters.  Strings are single quoted with
+// any single quotes doubled and strings containing a backslash or a NUL
+// character are rejected, since their meaning depends on the database's
+// settings.  Prefer bind parameters whenever possible.  It cannot be used
+// with WithPgPlaceholders or WithSqlNamedArgs.
+func WithInlineValues() Option {
+	return func(o *options) error {
+		o.withInlineValues = true
+		return nil
+	}
+}
+
+// WithRawLikePatterns will use the values of the contains operator (%) as raw
+// LIKE patterns, so users can use the % and _ wildcards (ie: name % "a_ice").
+// By default, the wildcards are escaped and matched literally using a like's
+// escape clause.  It's ignored by Match and Filter, which always match values
+// literally.
+func WithRawLikePatterns() Option {
+	return func(o *options) error {
+		o.withRawLikePatterns = true
+		return nil
+	}
+}
+
+// WithGlobPatterns will translate the * wildcard in values into a LIKE
+// wildcard, so users can use glob-style matching: name % "al*ce" matches
+// values containing al, followed by any characters, followed by ce and
+// name = "al*" matches values starting with al (!= matches the other values).
+// An asterisk can be matched using \*.  The LIKE wildcards (% and _) are still
+// matched literally.  Only the = and != comparisons of string columns are
+// converted into like conditions and only when their value contains a
+// wildcard.  It's ignored by Match and Filter and it cannot be used with
+// WithRawLikePatterns.
+func WithGlobPatterns() Option {
+	return func(o *options) error {
+		o.withGlobPatterns = true
+		return nil
+	}
+}
+
+// WithSimilarityThreshold provides the similarity threshold (greater than 0 and
+// at most 1) of the ~% operator, so its comparisons are converted into:
+// similarity(column, ?) > threshold.  By default, they're converted into the
+// pg_trgm % operator which uses the database's threshold (0.3 unless
+// pg_trgm.similarity_threshold is set) and Match and Filter use the
+// DefaultSimilarityThreshold.
+func WithSimilarityThreshold(threshold float64) Option {
+	const op = "mql.WithSimilarityThreshold"
+	return func(o *options) error {
+		if !(threshold > 0 && threshold <= 1) {
+			return fmt.Errorf("%s: threshold %v must be greater than 0 and at most 1: %w", op, threshold, ErrInvalidParameter)
+		}
+		o.withSimilarityThreshold = threshold
+		return nil
+	}
+}
+
+// WithTableAlias will prefix every column of the generated conditions and
+// clauses with the table alias (ie: u.name=?), so they can be used in a select
+// which joins tables with the same columns.  The alias can only contain
+// letters, digits and underscores.  Conditions generated by converters (see
+// WithConverter) aren't prefixed.
+func WithTableAlias(alias string) Option {
+	const op = "mql.WithTableAlias"
+	return func(o *options) error {
+		switch {
+		case alias == "":
+			return fmt.Errorf("%s: missing alias: %w", op, ErrInvalidParameter)
+		case !isIdentifier(alias):
+			return fmt.Errorf("%s: alias %q can only contain letters, digits and underscores: %w", op, alias, ErrInvalidParameter)
+		}
+		o.withTableAlias = alias
+		return nil
+	}
+}
+
+// WithTableName will prefix every column of the generated conditions and
+// clauses with the table name (ie: users.name=?), which may be qualified by a
+// schema (ie: public.users), so the same query can be used with views or CTEs.
+// The table name can also be defined by the model's struct tag (ie: _
+// struct{} `mql:"table=users"`), which is overridden by WithTableName, while
+// WithTableAlias overrides both of them.
+func WithTableName(table string) Option {
+	const op = "mql.WithTableName"
+	return func(o *options) error {
+		switch {
+		case table == "":
+			return fmt.Errorf("%s: missing table: %w", op, ErrInvalidParameter)
+		case !isTableName(table):
+			return fmt.Errorf("%s: table %q can only contain letters, digits and underscores, optionally qualified by a schema: %w", op, table, ErrInvalidParameter)
+		}
+		o.withTableName = table
+		return nil
+	}
+}
+
+// WithNamedParams will use named parameter placeholders which start with the
+// prefix (ie: ":" for sqlx) and are named after their column (name = :name_1
+// and age > :age_1).  The args are returned via WhereClause.NamedArgs instead
+// of WhereClause.Args.  It cannot be used with WithPgPlaceholders,
+// WithSqlNamedArgs or WithInlineValues.
+func WithNamedParams(prefix string) Option {
+	const op = "mql.WithNamedParams"
+	return func(o *options) error {
+		if prefix == "" {
+			return fmt.Errorf("%s: missing prefix: %w", op, ErrInvalidParameter)
+		}
+		o.withNamedParams = prefix
+		return nil
+	}
+}
+
+// WithNullSemantics provides an optional NullSemantics which defines how Match
+// and Filter compare nil values (nil pointers, invalid sql.Null* values and
+// missing map keys).  When columns are provided, it only applies to those
+// columns (database column or model field names) and overrides the default
+// for them; otherwise it replaces the default (SqlNulls).  It's ignored by
+// Parse, since the database decides how NULL is compared.
+func WithNullSemantics(s NullSemantics, columns ...string) Option {
+	const op = "mql.WithNullSemantics"
+	return func(o *options) error {
+		if !s.valid() {
+			return fmt.Errorf("%s: invalid null semantics %d: %w", op, s, ErrInvalidParameter)
+		}
+		if len(columns) == 0 {
+			o.withNullSemantics = s
+			return nil
+		}
+		if o.withColumnNullSemantics == nil {
+			o.withColumnNullSemantics = make(map[string]NullSemantics, len(columns))
+		}
+		for _, c := range columns {
+			if c == "" {
+				return fmt.Errorf("%s: missing column: %w", op, ErrInvalidParameter)
+			}
+			o.withColumnNullSemantics[strings.ToLower(strings.ReplaceAll(c, "_", ""))] = s
+		}
+		return nil
+	}
+}
+
+// WithPageLimits provides optional limits for ParsePage: the limit used when a
+// page size isn't provided and the largest page size allowed.  The default
+// limit must be greater than 0 and can't be greater than the max limit.
+func WithPageLimits(defaultLimit, maxLimit int) Option {
+	const op = "mql.WithPageLimits"
+	return func(o *options) error {
+		switch {
+		case defaultLimit < 1:
+			return fmt.Errorf("%s: default limit %d must be greater than 0: %w", op, defaultLimit, ErrInvalidParameter)
+		case defaultLimit > maxLimit:
+			return fmt.Errorf("%s: default limit %d is greater than the max limit %d: %w", op, defaultLimit, maxLimit, ErrInvalidParameter)
+		}
+		o.withDefaultPageLimit = defaultLimit
+		o.withMaxPageLimit = maxLimit
+		return nil
+	}
+}
+
+// WithTimeNowFunc provides an optional func which returns the current time
+// used to resolve relative time literals (now, now-24h, today, etc).  It's
+// called once per query, so every comparison uses the same time.  The
+// default is time.Now and it's helpful for testing or to resolve today in a
+// specific location (ie: time.Now().In(loc)).
+func WithTimeNowFunc(fn func() time.Time) Option {
+	const op = "mql.WithTimeNowFunc"
+	return func(o *options) error {
+		if fn == nil {
+			return fmt.Errorf("%s: missing time now func: %w", op, ErrInvalidParameter)
+		}
+		o.withTimeNowFunc = fn
+		return nil
+	}
+}
+
+// WithDurationUnit provides an optional unit for the args of time.Duration
+// fields, which are converted to an int64 number of units (ie: time.Second
+// when a column stores seconds).  Durations in a query must be a whole number
+// of units.  The default is time.Nanosecond, which matches how a
+// time.Duration is stored by database/sql.
+func WithDurationUnit(unit time.Duration) Option {
+	const op = "mql.WithDurationUnit"
+	return func(o *options) error {
+		if unit <= 0 {
+			return fmt.Errorf("%s: duration unit %s must be greater than 0: %w", op, unit, ErrInvalidParameter)
+		}
+		o.withDurationUnit = unit
+		return nil
+	}
+}
+
+// FieldAuthorizer is used to authorize a comparison of the column (the database
+// column, see WithColumnMap) using the comparison operator, so columns and
+// operators can be rejected based on the permissions of the requesting user.
+// See WithFieldAuthorizer
+type FieldAuthorizer func(column string, op ComparisonOp) error
+
+// WithFieldAuthorizer provides an optional FieldAuthorizer which is called for
+// every comparison of a query before it's validated.  When it returns an
+// error, the comparison is rejected with an error which wraps both
+// ErrInvalidColumn and the authorizer's error.
+func WithFieldAuthorizer(fn FieldAuthorizer) Option {
+	const op = "mql.WithFieldAuthorizer"
+	return func(o *options) error {
+		if fn == nil {
+			return fmt.Errorf("%s: missing field authorizer: %w", op, ErrInvalidParameter)
+		}
+		o.withFieldAuthorizer = fn
+		return nil
+	}
+}
+
+// WithEmptyStringAsNull provides the nullable columns (database column or model
+// field name) where an empty string value is compared to NULL, so email=""
+// is converted to: email is null and email!="" is converted to: email is not
+// null.  Only = and != are supported for an empty string value of the columns.
+func WithEmptyStringAsNull(columns ...string) Option {
+	const op = "mql.WithEmptyStringAsNull"
+	return func(o *options) error {
+		if o.withEmptyStringAsNull == nil {
+			o.withEmptyStringAsNull = make(map[string]struct{}, len(columns))
+		}
+		for _, c := range columns {
+			if c == "" {
+				return fmt.Errorf("%s: missing column: %w", op, ErrInvalidParameter)
+			}
+			o.withEmptyStringAsNull[strings.ToLower(strings.ReplaceAll(c, "_", ""))] = struct{}{}
+		}
+		return nil
+	}
+}
+
+// ValueTransformFunc transforms a comparison's value before it's validated and
+// converted.  See WithValueTransform
+type ValueTransformFunc func(value string) (string, error)
+
+// WithValueTransform provides an optional ValueTransformFunc for a column
+// (database column or model field name), which transforms the values of its
+// comparisons before they're validated and converted (ie: normalizing case,
+// trimming or hashing an email for a blind index column).  Unlike
+// WithConverter, the where clause is still generated by default.
+func WithValueTransform(column string, fn ValueTransformFunc) Option {
+	const op = "mql.WithValueTransform"
+	return func(o *options) error {
+		switch {
+		case column == "":
+			return fmt.Errorf("%s: missing column: %w", op, ErrInvalidParameter)
+		case fn == nil:
+			return fmt.Errorf("%s: missing transform func for %q: %w", op, column, ErrInvalidParameter)
+		}
+		key := strings.ToLower(strings.ReplaceAll(column, "_", ""))
+		if o.withValueTransforms == nil {
+			o.withValueTransforms = make(map[string]ValueTransformFunc)
+		}
+		if _, exists := o.withValueTransforms[key]; exists {
+			return fmt.Errorf("%s: duplicated value transform for %q: %w", op, column, ErrInvalidParameter)
+		}
+		o.withValueTransforms[key] = fn
+		return nil
+	}
+}
+
+// WithEnum provides an optional set of values allowed for a column (database
+// column or model field name).  Comparisons against the column must use one
+// of the values (or a part of one when using contains), otherwise an
+// ErrInvalidEnumValue error listing the valid values is returned, so typos fail
+// fast instead of matching nothing.  Values are case sensitive, but Lint
+// reports a value which only matches when ignoring case as an
+// EnumCaseMismatchDiagnostic rather than an error.
+func WithEnum(column string, values []string) Option {
+	const op = "mql.WithEnum"
+	return func(o *options) error {
+		switch {
+		case column == "":
+			return fmt.Errorf("%s: missing column: %w", op, ErrInvalidParameter)
+		case len(values) == 0:
+			return fmt.Errorf("%s: missing values for %q: %w", op, column, ErrInvalidParameter)
+		}
+		key := strings.ToLower(strings.ReplaceAll(column, "_", ""))
+		if o.withEnums == nil {
+			o.withEnums = make(map[string][]string)
+		}
+		if _, exists := o.withEnums[key]; exists {
+			return fmt.Errorf("%s: duplicated enum for %q: %w", op, column, ErrInvalidParameter)
+		}
+		o.withEnums[key] = values
+		return nil
+	}
+}
+
+// WithDecimalColumns provides an optional list of columns (model field names)
+// which are validated as decimals, regardless of their type (think: a string
+// field for a numeric column).  Decimal values are passed as string args, so
+// they never lose any precision.  For maps, it applies to their elements.
+func WithDecimalColumns(columns ...string) Option {
+	const op = "mql.WithDecimalColumns"
+	return func(o *options) error {
+		if o.withDecimalColumns == nil {
+			o.withDecimalColumns = make(map[string]struct{}, len(columns))
+		}
+		for _, c := range columns {
+			if c == "" {
+				return fmt.Errorf("%s: missing column: %w", op, ErrInvalidParameter)
+			}
+			o.withDecimalColumns[strings.ToLower(strings.ReplaceAll(c, "_", ""))] = struct{}{}
+		}
+		return nil
+	}
+}
+
+// WithBinaryIPs is used for databases without an inet type, where IP address
+// columns store the address bytes (4 bytes for IPv4 and 16 bytes for IPv6, ie:
+// MySQL's INET6_ATON).  The args of IP address columns are their bytes and a
+// containment (ip << "10.0.0.0/8") is converted to a range of addresses:
+// (ip>=? and ip<=?).
+func WithBinaryIPs() Option {
+	return func(o *options) error {
+		o.withBinaryIPs = true
+		return nil
+	}
+}
+
+// WithJsonArrayColumns provides the array columns which are stored in a json
+// column (ie: a []string field stored as a jsonb array) rather than a postgres
+// array, so tags @> "prod" is converted to: tags @> ? with the json array arg
+// ["prod"].
+func WithJsonArrayColumns(columns ...string) Option {
+	const op = "mql.WithJsonArrayColumns"
+	return func(o *options) error {
+		if o.withJsonArrayColumns == nil {
+			o.withJsonArrayColumns = make(map[string]struct{}, len(columns))
+		}
+		for _, c := range columns {
+			if c == "" {
+				return fmt.Errorf("%s: missing column: %w", op, ErrInvalidParameter)
+			}
+			o.withJsonArrayColumns[strings.ToLower(strings.ReplaceAll(c, "_", ""))] = struct{}{}
+		}
+		return nil
+	}
+}
+
+// WithModelDescriber provides an optional ModelDescriber which is used to
+// describe the fields of the model instead of reflection.
+func WithModelDescriber(d ModelDescriber) Option {
+	const op = "mql.WithModelDescriber"
+	return func(o *options) error {
+		if isNil(d) {
+			return fmt.Errorf("%s: missing model describer: %w", op, ErrInvalidParameter)
+		}
+		o.withModelDescriber = d
+		return nil
+	}
+}
+
+// WithoutModelCache disables the cache of the fields of models described
+// using reflection, so the model is reflected on every call.  By default, the
+// fields are cached by the model's type, since they never change; disabling
+// the cache is only helpful when there's an unbounded number of model types
+// (ie: types created at runtime using reflect.StructOf).
+func WithoutModelCache() Option {
+	return func(o *options) error {
+		o.withoutModelCache = true
+		return nil
+	}
+}
+
+// WithAllowEmptyQuery will allow an empty (or whitespace only) query, which
+// will result in a WhereClause with a condition of "1=1" (matching every row)
+// and no args, rather than an error. This is helpful when the query is an
+// optional parameter of your API.
+func WithAllowEmptyQuery() Option {
+	return func(o *options) error {
+		o.withAllowEmptyQuery = true
+		return nil
+	}
+}
+
+// WithLenientParsing will tolerate the mistakes of a query which is still
+// being typed by ignoring them: trailing logical operators (ie: `name="alice"
+// and`), missing closing parens at the end of the query, extra closing parens
+// at the end of the query and empty groups (ie: `()`).  A query which is
+// empty once they're ignored is an empty query (see WithAllowEmptyQuery).
+// It's meant for validating queries as they're typed, rather than for
+// queries which are executed, which should be parsed strictly.
+func WithLenientParsing() Option {
+	return func(o *options) error {
+		o.withLenientParsing = true
+		return nil
+	}
+}
+
+// WithTypedArgs will use the same Go type for every arg of a column type, so
+// the args are always one of: int64, float64, bool, time.Time or string.  By
+// default, the args of int columns are an int (or an int64/uint64 when they
+// don't fit one) and, with WithTypedArgs, they're always an int64 (or a
+// uint64 when they're greater than math.MaxInt64).  The args of string
+// columns are always a string, even when the value is a number (ie:
+// member_number=1 has the arg "1").  Drivers with strict typing and caches of
+// prepared statements' plans benefit from args which have the column's type.
+func WithTypedArgs() Option {
+	return func(o *options) error {
+		o.withTypedArgs = true
+		return nil
+	}
+}
@@ -0,0 +1,149 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SortDirection defines the direction of a sort key
+type SortDirection string
+
+const (
+	AscendingSort  SortDirection = "asc"
+	DescendingSort SortDirection = "desc"
+)
+
+// SortKey is a column of an order by clause and the direction it's sorted in
+type SortKey struct {
+	// Column is the database column
+	Column string
+	// Direction is the sort direction
+	Direction SortDirection
+}
+
+// OrderByClause contains a SQL order by clause
+type OrderByClause struct {
+	// Clause is the order by clause without the "order by" keywords, like:
+	// name asc, created_at desc
+	Clause string
+	// SortKeys are the columns of the clause, in order
+	SortKeys []SortKey
+}
+
+// ParseOrderBy will parse the comma separated list of sort keys and use the
+// provided database model to validate them and create an order by clause.
+// Each sort key is a column optionally followed by a direction (asc or desc),
+// or a column prefixed with "-" for a descending sort (ie: "name,
+// created_at desc" or "name,-created_at").  The default direction is asc.
+// Columns can only contain letters, digits and underscores, must be a field
+// of the model, can't be a map field and can only be used once.  Supported
+// options: WithColumnMap, WithIgnoreFields, WithModelDescriber,
+// WithAllowEmptyQuery (an empty order by returns an empty clause),
+// WithTableAlias and WithTableName (only the clause's columns are qualified,
+// not its SortKeys), WithStringRangeCollation
+func ParseOrderBy(orderBy string, model any, opt ...Option) (*OrderByClause, error) {
+	const op = "mql.ParseOrderBy"
+	opts, err := getOpts(opt...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	switch {
+	case strings.TrimSpace(orderBy) == "" && !opts.withAllowEmptyQuery:
+		return nil, fmt.Errorf("%s: missing order by: %w", op, ErrInvalidParameter)
+	case isNil(model):
+		return nil, fmt.Errorf("%s: missing model: %w", op, ErrInvalidParameter)
+	case strings.TrimSpace(orderBy) == "":
+		return &OrderByClause{}, nil
+	}
+	if opt, err = withModelTable(model, opt); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if opts, err = getOpts(opt...); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	fValidators, err := modelValidators(model, opt...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	items := strings.Split(orderBy, ",")
+	o := &OrderByClause{SortKeys: make([]SortKey, 0, len(items))}
+	used := make(map[string]bool, len(items))
+	clauses := make([]string, 0, len(items))
+	for _, item := range items {
+		k, err := parseSortKey(item)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		columnName := strings.ToLower(k.Column)
+		if n, ok := opts.withColumnMap[columnName]; ok {
+			columnName = n
+		}
+		fName := strings.ToLower(strings.ReplaceAll(columnName, "_", ""))
+		v, ok := fValidators[fName]
+		switch {
+		case !ok:
+			return nil, fmt.Errorf("%s: %w %q", op, ErrInvalidColumn, columnName)
+		case v.typ == "map":
+			return nil, fmt.Errorf("%s: %w %q: map fields can't be sorted", op, ErrInvalidColumn, columnName)
+		case used[fName]:
+			return nil, fmt.Errorf("%s: %w %q: column is sorted more than once", op, ErrInvalidOrderBy, columnName)
+		}
+		used[fName] = true
+		k.Column = columnName
+		o.SortKeys = append(o.SortKeys, k)
+		column := qualifyColumn(k.Column, opts)
+		if v.typ == "default" {
+			column = collate(column, opts)
+		}
+		clauses = append(clauses, fmt.Sprintf("%s %s", column, k.Direction))
+	}
+	o.Clause = strings.Join(clauses, ", ")
+	return o, nil
+}
+
+// parseSortKey will parse a single sort key: column [asc|desc] or -column
+func parseSortKey(s string) (SortKey, error) {
+	const op = "mql.parseSortKey"
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return SortKey{}, fmt.Errorf("%s: %w", op, ErrMissingColumn)
+	}
+	k := SortKey{Column: fields[0], Direction: AscendingSort}
+	if strings.HasPrefix(k.Column, "-") {
+		k.Column, k.Direction = k.Column[1:], DescendingSort
+	}
+	switch {
+	case k.Column == "":
+		return SortKey{}, fmt.Errorf("%s: %w in %q", op, ErrMissingColumn, strings.TrimSpace(s))
+	case !isIdentifier(k.Column):
+		return SortKey{}, fmt.Errorf("%s: %w %q: columns can only contain letters, digits and underscores", op, ErrInvalidColumn, k.Column)
+	case len(fields) > 2:
+		return SortKey{}, fmt.Errorf("%s: %w %q: expected a column and an optional direction", op, ErrInvalidOrderBy, strings.TrimSpace(s))
+	case len(fields) == 2 && k.Direction == DescendingSort:
+		return SortKey{}, fmt.Errorf("%s: %w %q: a direction can't be used with a \"-\" prefix", op, ErrInvalidOrderBy, strings.TrimSpace(s))
+	case len(fields) == 2:
+		switch d := SortDirection(strings.ToLower(fields[1])); d {
+		case AscendingSort, DescendingSort:
+			k.Direction = d
+		default:
+			return SortKey{}, fmt.Errorf("%s: %w %q: invalid direction %q", op, ErrInvalidOrderBy, strings.TrimSpace(s), fields[1])
+		}
+	}
+	return k, nil
+}
+
+// isIdentifier reports if s only contains letters, digits and underscores, so
+// it's safe to use as a column (ie: in an order by clause) or a table alias.
+func isIdentifier(s string) bool {
+	for _, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+		default:
+			return false
+		}
+	}
+	return true
+}
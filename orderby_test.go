@@ -0,0 +1,198 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql_test
+
+import (
+	"testing"
+
+	"github.com/hashicorp/mql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseOrderBy(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name            string
+		orderBy         string
+		model           any
+		opts            []mql.Option
+		want            *mql.OrderByClause
+		wantErrIs       error
+		wantErrContains string
+	}{
+		{
+			name:    "default-direction",
+			orderBy: "name",
+			model:   testModel{},
+			want: &mql.OrderByClause{
+				Clause:   "name asc",
+				SortKeys: []mql.SortKey{{Column: "name", Direction: mql.AscendingSort}},
+			},
+		},
+		{
+			name:    "directions",
+			orderBy: " Name ASC,  created_at desc ,age",
+			model:   testModel{},
+			want: &mql.OrderByClause{
+				Clause: "name asc, created_at desc, age asc",
+				SortKeys: []mql.SortKey{
+					{Column: "name", Direction: mql.AscendingSort},
+					{Column: "created_at", Direction: mql.DescendingSort},
+					{Column: "age", Direction: mql.AscendingSort},
+				},
+			},
+		},
+		{
+			name:    "minus-prefix",
+			orderBy: "-created_at,name",
+			model:   testModel{},
+			want: &mql.OrderByClause{
+				Clause: "created_at desc, name asc",
+				SortKeys: []mql.SortKey{
+					{Column: "created_at", Direction: mql.DescendingSort},
+					{Column: "name", Direction: mql.AscendingSort},
+				},
+			},
+		},
+		{
+			name:    "column-map",
+			orderBy: "nickname desc",
+			model:   testModel{},
+			opts:    []mql.Option{mql.WithColumnMap(map[string]string{"nickname": "name"})},
+			want: &mql.OrderByClause{
+				Clause:   "name desc",
+				SortKeys: []mql.SortKey{{Column: "name", Direction: mql.DescendingSort}},
+			},
+		},
+		{
+			name:    "WithTableAlias",
+			orderBy: "name desc, id",
+			model:   testModel{},
+			opts:    []mql.Option{mql.WithTableAlias("u")},
+			want: &mql.OrderByClause{
+				Clause: "u.name desc, u.id asc",
+				SortKeys: []mql.SortKey{
+					{Column: "name", Direction: mql.DescendingSort},
+					{Column: "id", Direction: mql.AscendingSort},
+				},
+			},
+		},
+		{
+			name:    "empty-WithAllowEmptyQuery",
+			orderBy: " ",
+			model:   testModel{},
+			opts:    []mql.Option{mql.WithAllowEmptyQuery()},
+			want:    &mql.OrderByClause{},
+		},
+		{
+			name:            "err-empty",
+			orderBy:         " ",
+			model:           testModel{},
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: "missing order by",
+		},
+		{
+			name:            "err-missing-model",
+			orderBy:         "name",
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: "missing model",
+		},
+		{
+			name:            "err-invalid-column",
+			orderBy:         "name, nickname",
+			model:           testModel{},
+			wantErrIs:       mql.ErrInvalidColumn,
+			wantErrContains: `invalid column "nickname"`,
+		},
+		{
+			name:            "err-ignored-field",
+			orderBy:         "email",
+			model:           testModel{},
+			opts:            []mql.Option{mql.WithIgnoredFields("Email")},
+			wantErrIs:       mql.ErrInvalidColumn,
+			wantErrContains: `invalid column "email"`,
+		},
+		{
+			name:            "err-injection",
+			orderBy:         "name; drop table users",
+			model:           testModel{},
+			wantErrIs:       mql.ErrInvalidColumn,
+			wantErrContains: "columns can only contain letters, digits and underscores",
+		},
+		{
+			name:            "err-expression",
+			orderBy:         "(case when 1=1 then name end)",
+			model:           testModel{},
+			wantErrIs:       mql.ErrInvalidColumn,
+			wantErrContains: "columns can only contain letters, digits and underscores",
+		},
+		{
+			name:            "err-map-field",
+			orderBy:         "labels",
+			model:           testModel{},
+			wantErrIs:       mql.ErrInvalidColumn,
+			wantErrContains: "map fields can't be sorted",
+		},
+		{
+			name:            "err-invalid-direction",
+			orderBy:         "name up",
+			model:           testModel{},
+			wantErrIs:       mql.ErrInvalidOrderBy,
+			wantErrContains: `invalid direction "up"`,
+		},
+		{
+			name:            "err-too-many-words",
+			orderBy:         "name asc nulls",
+			model:           testModel{},
+			wantErrIs:       mql.ErrInvalidOrderBy,
+			wantErrContains: "expected a column and an optional direction",
+		},
+		{
+			name:            "err-prefix-and-direction",
+			orderBy:         "-name asc",
+			model:           testModel{},
+			wantErrIs:       mql.ErrInvalidOrderBy,
+			wantErrContains: `a direction can't be used with a "-" prefix`,
+		},
+		{
+			name:            "err-duplicate",
+			orderBy:         "name, Name desc",
+			model:           testModel{},
+			wantErrIs:       mql.ErrInvalidOrderBy,
+			wantErrContains: "column is sorted more than once",
+		},
+		{
+			name:            "err-empty-sort-key",
+			orderBy:         "name,,age",
+			model:           testModel{},
+			wantErrIs:       mql.ErrMissingColumn,
+			wantErrContains: "missing column",
+		},
+		{
+			name:            "err-only-prefix",
+			orderBy:         "-",
+			model:           testModel{},
+			wantErrIs:       mql.ErrMissingColumn,
+			wantErrContains: `missing column in "-"`,
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert, require := assert.New(t), require.New(t)
+			got, err := mql.ParseOrderBy(tc.orderBy, tc.model, tc.opts...)
+			if tc.wantErrContains != "" {
+				require.Error(err)
+				assert.Empty(got)
+				assert.ErrorIs(err, tc.wantErrIs)
+				assert.ErrorContains(err, tc.wantErrContains)
+				return
+			}
+			require.NoError(err)
+			assert.Equal(tc.want, got)
+		})
+	}
+}
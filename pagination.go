@@ -0,0 +1,218 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultPageLimit is the limit used when a page size isn't provided.  See
+	// WithPageLimits
+	DefaultPageLimit = 100
+
+	// MaxPageLimit is the largest page size allowed.  See WithPageLimits
+	MaxPageLimit = 1000
+)
+
+// Page contains a validated limit and offset
+type Page struct {
+	// Limit is the max number of rows to return
+	Limit int
+	// Offset is the number of rows to skip
+	Offset int
+}
+
+// Clause returns the page as a SQL limit/offset clause, like: limit 10 offset 20
+func (p *Page) Clause() string {
+	if p.Offset == 0 {
+		return fmt.Sprintf("limit %d", p.Limit)
+	}
+	return fmt.Sprintf("limit %d offset %d", p.Limit, p.Offset)
+}
+
+// ParsePage will parse and validate the user provided limit (page size) and
+// offset.  An empty limit is the default limit and an empty offset is 0.  The
+// limit must be between 1 and the max limit, and the offset can't be
+// negative.  Supported options: WithPageLimits
+func ParsePage(limit, offset string, opt ...Option) (*Page, error) {
+	const op = "mql.ParsePage"
+	opts, err := getOpts(opt...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	p := &Page{Limit: opts.withDefaultPageLimit}
+	if limit = strings.TrimSpace(limit); limit != "" {
+		if p.Limit, err = strconv.Atoi(limit); err != nil {
+			return nil, fmt.Errorf("%s: limit %q is not an int: %w", op, limit, ErrInvalidParameter)
+		}
+	}
+	if offset = strings.TrimSpace(offset); offset != "" {
+		if p.Offset, err = strconv.Atoi(offset); err != nil {
+			return nil, fmt.Errorf("%s: offset %q is not an int: %w", op, offset, ErrInvalidParameter)
+		}
+	}
+	switch {
+	case p.Limit < 1:
+		return nil, fmt.Errorf("%s: limit %d must be greater than 0: %w", op, p.Limit, ErrInvalidParameter)
+	case p.Limit > opts.withMaxPageLimit:
+		return nil, fmt.Errorf("%s: limit %d is greater than the max limit of %d: %w", op, p.Limit, opts.withMaxPageLimit, ErrInvalidParameter)
+	case p.Offset < 0:
+		return nil, fmt.Errorf("%s: offset %d can't be negative: %w", op, p.Offset, ErrInvalidParameter)
+	}
+	return p, nil
+}
+
+// EncodeCursor will encode the values of the sort keys of the last row of a
+// page into an opaque cursor, which can be returned to the user and passed to
+// KeysetCondition to get the next page.  The values must be in the same order
+// as the sort keys and can't be nil (or invalid sql.Null* values), since NULL
+// can't be compared.
+func EncodeCursor(values ...any) (string, error) {
+	const op = "mql.EncodeCursor"
+	if len(values) == 0 {
+		return "", fmt.Errorf("%s: missing values: %w", op, ErrInvalidParameter)
+	}
+	encoded := make([]string, 0, len(values))
+	for i, v := range values {
+		rv := reflect.ValueOf(v)
+		fv, ok := fieldValue(rv)
+		if !ok {
+			return "", fmt.Errorf("%s: value %d is nil: %w", op, i, ErrInvalidParameter)
+		}
+		switch t := fv.(type) {
+		case time.Time:
+			encoded = append(encoded, t.Format(time.RFC3339Nano))
+		case int64:
+			if indirect(rv).Type() == reflect.TypeOf(time.Duration(0)) {
+				// durations are validated as duration literals (1h30m0s)
+				encoded = append(encoded, time.Duration(t).String())
+				break
+			}
+			encoded = append(encoded, fmt.Sprint(t))
+		default:
+			encoded = append(encoded, fmt.Sprint(t))
+		}
+	}
+	b, err := json.Marshal(encoded)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// decodeCursor returns the values of an encoded cursor
+func decodeCursor(cursor string) ([]string, error) {
+	const op = "mql.decodeCursor"
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, ErrInvalidCursor)
+	}
+	var values []string
+	if err := json.Unmarshal(b, &values); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, ErrInvalidCursor)
+	}
+	return values, nil
+}
+
+// KeysetCondition will use the order by clause and the cursor (see
+// EncodeCursor) to create a where clause which matches the rows after the
+// cursor, so pages can be fetched without an offset.  For the sort keys a asc,
+// b desc the condition is: (a>? or (a=? and b<?)).  The sort keys should end
+// with a unique column (ie: the primary key), otherwise rows with the same
+// sort key values may be skipped.  The cursor's values are validated using the
+// model and the condition uses ? placeholders, so it can be combined with a
+// where clause created by Parse.  Supported options: WithIgnoreFields,
+// WithModelDescriber, WithTableAlias, WithTableName, WithStringRangeCollation
+func KeysetCondition(orderBy *OrderByClause, cursor string, model any, opt ...Option) (*WhereClause, error) {
+	const op = "mql.KeysetCondition"
+	w, err := keysetWhereClause(orderBy, cursor, model, opt...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	w.argColumns = nil
+	return w, nil
+}
+
+// keysetWhereClause returns the keyset condition for KeysetCondition along
+// with the column of each arg.
+func keysetWhereClause(orderBy *OrderByClause, cursor string, model any, opt ...Option) (*WhereClause, error) {
+	const op = "mql.keysetWhereClause"
+	switch {
+	case orderBy == nil || len(orderBy.SortKeys) == 0:
+		return nil, fmt.Errorf("%s: missing order by: %w", op, ErrInvalidParameter)
+	case cursor == "":
+		return nil, fmt.Errorf("%s: missing cursor: %w", op, ErrInvalidParameter)
+	case isNil(model):
+		return nil, fmt.Errorf("%s: missing model: %w", op, ErrInvalidParameter)
+	}
+	values, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if len(values) != len(orderBy.SortKeys) {
+		return nil, fmt.Errorf("%s: %w: %d values for %d sort keys", op, ErrInvalidCursor, len(values), len(orderBy.SortKeys))
+	}
+	if opt, err = withModelTable(model, opt); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	opts, err := getOpts(opt...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	fValidators, err := modelValidators(model, opt...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	args := make([]any, 0, len(values))
+	columns := make([]string, 0, len(values))
+	for i, k := range orderBy.SortKeys {
+		v, ok := fValidators[strings.ToLower(strings.ReplaceAll(k.Column, "_", ""))]
+		if !ok || v.typ == "map" {
+			return nil, fmt.Errorf("%s: %w %q", op, ErrInvalidColumn, k.Column)
+		}
+		a, err := v.fn(values[i])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w: %w", op, ErrInvalidCursor, err)
+		}
+		args = append(args, a)
+		column := qualifyColumn(k.Column, opts)
+		if v.typ == "default" {
+			column = collate(column, opts)
+		}
+		columns = append(columns, column)
+	}
+	return keysetCondition(orderBy.SortKeys, columns, args), nil
+}
+
+// keysetCondition returns the condition for the sort keys starting with the
+// first one: (k1>? or (k1=? and <the condition for the remaining keys>)),
+// where columns are the keys' columns as they're used in the condition (ie:
+// qualified by their table)
+func keysetCondition(keys []SortKey, columns []string, args []any) *WhereClause {
+	cmp := ">"
+	if keys[0].Direction == DescendingSort {
+		cmp = "<"
+	}
+	column := columns[0]
+	if len(keys) == 1 {
+		return &WhereClause{
+			Condition:  fmt.Sprintf("%s%s?", column, cmp),
+			Args:       []any{args[0]},
+			argColumns: argColumns(keys[0].Column, 1),
+		}
+	}
+	rest := keysetCondition(keys[1:], columns[1:], args[1:])
+	return &WhereClause{
+		Condition:  fmt.Sprintf("(%s%s? or (%s=? and %s))", column, cmp, column, rest.Condition),
+		Args:       append([]any{args[0], args[0]}, rest.Args...),
+		argColumns: append(argColumns(keys[0].Column, 2), rest.argColumns...),
+	}
+}
@@ -0,0 +1,211 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/mql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePage(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name            string
+		limit           string
+		offset          string
+		opts            []mql.Option
+		want            *mql.Page
+		wantClause      string
+		wantErrIs       error
+		wantErrContains string
+	}{
+		{name: "defaults", want: &mql.Page{Limit: mql.DefaultPageLimit}, wantClause: "limit 100"},
+		{name: "limit-and-offset", limit: "10", offset: " 20 ", want: &mql.Page{Limit: 10, Offset: 20}, wantClause: "limit 10 offset 20"},
+		{name: "max-limit", limit: "1000", want: &mql.Page{Limit: mql.MaxPageLimit}, wantClause: "limit 1000"},
+		{
+			name:       "WithPageLimits",
+			opts:       []mql.Option{mql.WithPageLimits(25, 50)},
+			want:       &mql.Page{Limit: 25},
+			wantClause: "limit 25",
+		},
+		{
+			name:            "err-limit-not-int",
+			limit:           "ten",
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: `limit "ten" is not an int`,
+		},
+		{
+			name:            "err-offset-not-int",
+			offset:          "1; drop table users",
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: `offset "1; drop table users" is not an int`,
+		},
+		{
+			name:            "err-zero-limit",
+			limit:           "0",
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: "limit 0 must be greater than 0",
+		},
+		{
+			name:            "err-limit-too-large",
+			limit:           "51",
+			opts:            []mql.Option{mql.WithPageLimits(25, 50)},
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: "limit 51 is greater than the max limit of 50",
+		},
+		{
+			name:            "err-negative-offset",
+			offset:          "-1",
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: "offset -1 can't be negative",
+		},
+		{
+			name:            "err-WithPageLimits-zero-default",
+			opts:            []mql.Option{mql.WithPageLimits(0, 50)},
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: "default limit 0 must be greater than 0",
+		},
+		{
+			name:            "err-WithPageLimits-default-greater-than-max",
+			opts:            []mql.Option{mql.WithPageLimits(100, 50)},
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: "default limit 100 is greater than the max limit 50",
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert, require := assert.New(t), require.New(t)
+			got, err := mql.ParsePage(tc.limit, tc.offset, tc.opts...)
+			if tc.wantErrContains != "" {
+				require.Error(err)
+				assert.Empty(got)
+				assert.ErrorIs(err, tc.wantErrIs)
+				assert.ErrorContains(err, tc.wantErrContains)
+				return
+			}
+			require.NoError(err)
+			assert.Equal(tc.want, got)
+			assert.Equal(tc.wantClause, got.Clause())
+		})
+	}
+}
+
+func TestKeysetCondition(t *testing.T) {
+	t.Parallel()
+	createdAt := time.Date(2023, 6, 1, 23, 0, 0, 5, time.UTC)
+	t.Run("single-key", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		o, err := mql.ParseOrderBy("id", testModel{})
+		require.NoError(err)
+		c, err := mql.EncodeCursor(uint(42))
+		require.NoError(err)
+		got, err := mql.KeysetCondition(o, c, testModel{})
+		require.NoError(err)
+		assert.Equal(&mql.WhereClause{Condition: "id>?", Args: []any{42}}, got)
+	})
+	t.Run("mixed-directions", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		o, err := mql.ParseOrderBy("-created_at, length, id", testModel{})
+		require.NoError(err)
+		c, err := mql.EncodeCursor(&createdAt, float32(1.5), uint(42))
+		require.NoError(err)
+		got, err := mql.KeysetCondition(o, c, testModel{})
+		require.NoError(err)
+		assert.Equal(&mql.WhereClause{
+			Condition: "(created_at<? or (created_at=? and (length>? or (length=? and id>?))))",
+			Args:      []any{createdAt, createdAt, 1.5, 1.5, 42},
+		}, got)
+	})
+	t.Run("duration-key", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		o, err := mql.ParseOrderBy("timeout", durationModel{})
+		require.NoError(err)
+		c, err := mql.EncodeCursor(90 * time.Second)
+		require.NoError(err)
+		got, err := mql.KeysetCondition(o, c, durationModel{}, mql.WithDurationUnit(time.Second))
+		require.NoError(err)
+		assert.Equal(&mql.WhereClause{Condition: "timeout>?", Args: []any{int64(90)}}, got)
+	})
+	t.Run("WithTableAlias", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		o, err := mql.ParseOrderBy("name desc, id", testModel{}, mql.WithTableAlias("u"))
+		require.NoError(err)
+		c, err := mql.EncodeCursor("bob", 7)
+		require.NoError(err)
+		got, err := mql.KeysetCondition(o, c, testModel{}, mql.WithTableAlias("u"))
+		require.NoError(err)
+		assert.Equal(&mql.WhereClause{
+			Condition: "(u.name<? or (u.name=? and u.id>?))",
+			Args:      []any{"bob", "bob", 7},
+		}, got)
+	})
+	t.Run("combined-with-parse", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		w, err := mql.Parse(`name="alice"`, testModel{})
+		require.NoError(err)
+		o, err := mql.ParseOrderBy("name desc, id", testModel{})
+		require.NoError(err)
+		c, err := mql.EncodeCursor(sql.NullString{String: "bob", Valid: true}, 7)
+		require.NoError(err)
+		k, err := mql.KeysetCondition(o, c, testModel{})
+		require.NoError(err)
+		assert.Equal("(name<? or (name=? and id>?))", k.Condition)
+		assert.Equal([]any{"alice", "bob", "bob", 7}, append(w.Args, k.Args...))
+	})
+	t.Run("err-cursor-value-type", func(t *testing.T) {
+		o, err := mql.ParseOrderBy("id", testModel{})
+		require.NoError(t, err)
+		c, err := mql.EncodeCursor("alice")
+		require.NoError(t, err)
+		got, err := mql.KeysetCondition(o, c, testModel{})
+		require.Error(t, err)
+		assert.Nil(t, got)
+		assert.ErrorIs(t, err, mql.ErrInvalidCursor)
+		assert.ErrorIs(t, err, mql.ErrInvalidParameter)
+	})
+	t.Run("err-cursor-length", func(t *testing.T) {
+		o, err := mql.ParseOrderBy("name, id", testModel{})
+		require.NoError(t, err)
+		c, err := mql.EncodeCursor(1)
+		require.NoError(t, err)
+		_, err = mql.KeysetCondition(o, c, testModel{})
+		require.Error(t, err)
+		assert.ErrorIs(t, err, mql.ErrInvalidCursor)
+		assert.ErrorContains(t, err, "1 values for 2 sort keys")
+	})
+	t.Run("err-malformed-cursor", func(t *testing.T) {
+		o, err := mql.ParseOrderBy("id", testModel{})
+		require.NoError(t, err)
+		for _, c := range []string{"not base64!", "bm90IGpzb24"} {
+			_, err = mql.KeysetCondition(o, c, testModel{})
+			require.Error(t, err)
+			assert.ErrorIs(t, err, mql.ErrInvalidCursor)
+		}
+	})
+	t.Run("err-missing-params", func(t *testing.T) {
+		o, err := mql.ParseOrderBy("id", testModel{})
+		require.NoError(t, err)
+		_, err = mql.KeysetCondition(nil, "x", testModel{})
+		assert.ErrorContains(t, err, "missing order by")
+		_, err = mql.KeysetCondition(o, "", testModel{})
+		assert.ErrorContains(t, err, "missing cursor")
+		_, err = mql.KeysetCondition(o, "x", nil)
+		assert.ErrorContains(t, err, "missing model")
+	})
+	t.Run("err-encode-nil", func(t *testing.T) {
+		_, err := mql.EncodeCursor()
+		assert.ErrorContains(t, err, "missing values")
+		_, err = mql.EncodeCursor("alice", (*time.Time)(nil))
+		assert.ErrorContains(t, err, "value 1 is nil")
+		_, err = mql.EncodeCursor(sql.NullString{})
+		assert.ErrorContains(t, err, "value 0 is nil")
+	})
+}
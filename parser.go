@@ -4,137 +4,312 @@
 package mql
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"strings"
 )
 
 type parser struct {
-	l               *lexer
-	raw             string
-	currentToken    token
-	openLogicalExpr stack[struct{}] // something very simple to make sure every logical expr that's opened is closed.
+	l            *lexer
+	raw          string
+	currentToken token
+	currentPos   int // byte offset of the currentToken in raw
+
+	// ctx is checked for cancellation before scanning each token, when it's
+	// not nil (see ParseContext)
+	ctx context.Context
+
+	// limits bound the query and its tokens, where tokens is the number of
+	// tokens scanned (excluding whitespace)
+	limits limits
+	tokens int
+
+	// logger traces the expressions built, when it's not nil (see
+	// WithDebugLogger)
+	logger DebugLogger
+
+	// lenient ignores trailing logical operators, unbalanced trailing parens
+	// and empty groups (see WithLenientParsing)
+	lenient bool
+
+	// macros are the queries which can be used as operands, keyed by their
+	// lowercase name, and expanding are the macros being expanded (see
+	// WithMacro).  filterResolver resolves the saved filters used as operands
+	// (see WithFilterResolver).
+	macros         map[string]string
+	filterResolver FilterResolverFunc
+	expanding      []string
 }
 
 func newParser(s string) *parser {
 	return &parser{
-		l:   newLexer(s),
-		raw: s,
+		l:      newLexer(s),
+		raw:    s,
+		limits: defaultLimits(),
+	}
+}
+
+// configure will configure the parser (and its lexer) using the options:
+// WithMaxQueryLength, WithMaxTokens, WithMaxStringLength, WithDebugLogger and
+// WithLenientParsing, WithMacro and WithFilterResolver
+func (p *parser) configure(opts options) {
+	p.limits = opts.withLimits
+	p.logger, p.l.logger = opts.withDebugLogger, opts.withDebugLogger
+	p.lenient = opts.withLenientParsing
+	p.macros, p.filterResolver = opts.withMacros, opts.withFilterResolver
+}
+
+// debug will trace the parser's decisions using its logger (if it has one)
+func (p *parser) debug(msg string, args ...any) {
+	if p.logger != nil {
+		p.logger.Debug("mql: parser "+msg, args...)
 	}
 }
 
-func (p *parser) parse() (expr, error) {
+// parse will parse the raw query and any error returned will be a *ParseError
+func (p *parser) parse() (Expr, error) {
 	const op = "mql.(parser).parse"
-	lExpr, err := p.parseLogicalExpr()
-	if err != nil {
-		return nil, fmt.Errorf("%s: %w", op, err)
+	if err := p.limits.checkQuery(p.raw); err != nil {
+		return nil, &ParseError{Err: fmt.Errorf("%s: %w", op, err), Pos: p.limits.maxQueryLength}
 	}
-	r, err := root(lExpr, p.raw)
-	if err != nil {
-		return nil, fmt.Errorf("%s: %w", op, err)
+	r, err := p.parseExpr()
+	switch {
+	case errors.Is(err, ErrLimitExceeded):
+		// the partial expr isn't parsed, since it could use just as many
+		// resources as the query
+		return nil, &ParseError{Err: fmt.Errorf("%s: %w", op, err), Pos: p.currentPos}
+	case err != nil:
+		return nil, &ParseError{
+			Err:     fmt.Errorf("%s: %w", op, err),
+			Pos:     p.currentPos,
+			Partial: partialExpr(p.raw, p.currentPos),
+		}
+	case r == nil:
+		// a lenient parser ignored everything in the query
+		return nil, &ParseError{Err: fmt.Errorf("%s: %w nil in: %q", op, ErrMissingExpr, p.raw), Pos: 0}
 	}
 	return r, nil
 }
 
-// parseLogicalExpr will parse a logicalExpr until an eofToken is reached, which
-// may require it to parse a comparisonExpr and/or recursively parse
-// logicalExprs
-func (p *parser) parseLogicalExpr() (*logicalExpr, error) {
-	const op = "parseLogicalExpr"
-	logicExpr := &logicalExpr{}
-
-	if err := p.scan(withSkipWhitespace()); err != nil {
+// parseExpr will parse the raw query and return the root of its expr tree
+func (p *parser) parseExpr() (Expr, error) {
+	const op = "mql.(parser).parseExpr"
+	if err := p.scan(skipWhitespace); err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
-TkLoop:
-	for p.currentToken.Type != eofToken {
+	if p.currentToken.Type == eofToken {
+		return nil, fmt.Errorf("%s: %w nil in: %q", op, ErrMissingExpr, p.raw)
+	}
+	return p.parseLogicalExpr(0)
+}
+
+// partialExpr returns the expr tree for the longest prefix of raw (before pos)
+// which ends before a logical operator and can be parsed once any of its open
+// parens are closed.  It returns nil if there's no such prefix.
+func partialExpr(raw string, pos int) Expr {
+	if pos < 0 || pos > len(raw) {
+		return nil
+	}
+	prefix := raw[:pos]
+	tokens, positions, err := tokenize(prefix)
+	if err != nil {
+		return nil
+	}
+	// the candidates are the entire prefix and then everything before each
+	// of its logical operators, starting with the last one.
+	candidates := []int{len(tokens)}
+	for i := len(tokens) - 1; i >= 0; i-- {
+		if tokens[i].Type == andToken || tokens[i].Type == orToken {
+			candidates = append(candidates, i)
+		}
+	}
+	for _, c := range candidates {
+		depth := 0
+		for _, tk := range tokens[:c] {
+			switch tk.Type {
+			case startLogicalExprToken:
+				depth++
+			case endLogicalExprToken:
+				depth--
+			}
+		}
+		if depth < 0 {
+			continue
+		}
+		end := len(prefix)
+		if c < len(tokens) {
+			end = positions[c]
+		}
+		candidate := prefix[:end] + strings.Repeat(")", depth)
+		if strings.TrimSpace(candidate) == "" {
+			continue
+		}
+		if e, err := newParser(candidate).parseExpr(); err == nil {
+			return e
+		}
+	}
+	return nil
+}
+
+// parseLogicalExpr will parse a sequence of operands (comparisonExprs or
+// parenthesized logicalExprs) separated by logical operators, until an
+// eofToken or the closing paren of the current depth is reached.
+//
+// The grouping contract: "and" and "or" have the same precedence and a
+// sequence is grouped from the left, so "a and b or c" is parsed as
+// "(a and b) or c".  Parens group their contents into a single operand and
+// parens around a single comparison (or redundant parens) don't change the
+// tree.
+func (p *parser) parseLogicalExpr(depth int) (Expr, error) {
+	const op = "parseLogicalExpr"
+	var operands []Expr
+	var logicalOps []LogicalOp
+	for {
+		// first, we need an operand
 		switch p.currentToken.Type {
 		case startLogicalExprToken: // there's a opening paren: (
 			// so we've found a new logical expr to parse
-			e, err := p.parseLogicalExpr()
+			if err := p.scan(skipWhitespace); err != nil {
+				return nil, fmt.Errorf("%s: %w", op, err)
+			}
+			e, err := p.parseLogicalExpr(depth + 1)
 			if err != nil {
 				return nil, fmt.Errorf("%s: %w", op, err)
 			}
 			switch {
-			// start by assigning the left expr
-			case logicExpr.leftExpr == nil:
-				logicExpr.leftExpr = e
-				break TkLoop
-			// we should have a logical operator before the right side expr is assigned
-			case logicExpr.logicalOp == "":
-				return nil, fmt.Errorf("%s: %w before right side expression in: %q", op, ErrMissingLogicalOp, p.raw)
-			// finally, assign the right expr
-			case logicExpr.rightExpr == nil:
-				if e.rightExpr != nil {
-					// if e.rightExpr isn't nil, then we've got a complete
-					// expr (left + op + right) and we need to assign this to
-					// our rightExpr
-					logicExpr.rightExpr = e
-					break TkLoop
-				}
-				// otherwise, we need to assign the left side of e
-				logicExpr.rightExpr = e.leftExpr
-				break TkLoop
+			case e != nil:
+				operands = append(operands, e)
+			case len(logicalOps) > 0:
+				// an empty group, which a lenient parser ignores along with
+				// the logical operator before it
+				logicalOps = logicalOps[:len(logicalOps)-1]
 			}
-		case stringToken, numberToken, symbolToken:
-			if (logicExpr.leftExpr != nil && logicExpr.logicalOp == "") ||
-				(logicExpr.leftExpr != nil && logicExpr.rightExpr != nil) {
-				return nil, fmt.Errorf("%s: %w starting at %q in: %q", op, ErrUnexpectedExpr, p.currentToken.Value, p.raw)
+			// skip the closing paren
+			if err := p.scan(keepWhitespace); err != nil {
+				return nil, fmt.Errorf("%s: %w", op, err)
 			}
-			cmpExpr, err := p.parseComparisonExpr()
+		case stringToken, numberToken, symbolToken:
+			e, err := p.parseComparisonExpr()
 			if err != nil {
 				return nil, fmt.Errorf("%s: %w", op, err)
 			}
-			switch {
-			case logicExpr.leftExpr == nil:
-				logicExpr.leftExpr = cmpExpr
-			case logicExpr.rightExpr == nil:
-				logicExpr.rightExpr = cmpExpr
-				tmpExpr := &logicalExpr{
-					leftExpr:  logicExpr,
-					logicalOp: "",
-					rightExpr: nil,
-				}
-				logicExpr = tmpExpr
-			default:
-				return nil, fmt.Errorf("%s: %w at %q, but both left and right expressions already exist in: %q", op, ErrUnexpectedExpr, p.currentToken.Value, p.raw)
+			if p.logger != nil {
+				p.debug("built comparison", "expr", e.MQL(), "depth", depth)
 			}
+			operands = append(operands, e)
 		case endLogicalExprToken:
-			if logicExpr.leftExpr == nil {
-				return nil, fmt.Errorf("%s: %w %q but we haven't parsed a left side expression in: %q", op, ErrUnexpectedClosingParen, p.currentToken.Value, p.raw)
+			if p.lenient && (depth > 0 || p.trailingParens()) {
+				// an empty group or a trailing logical operator, which is
+				// ignored
+				return p.group(operands, logicalOps, depth), nil
 			}
-			return logicExpr, nil
+			return nil, fmt.Errorf("%s: %w %q but we haven't parsed a left side expression in: %q", op, ErrUnexpectedClosingParen, p.currentToken.Value, p.raw)
 		case andToken, orToken:
-			if logicExpr.logicalOp != "" {
-				return nil, fmt.Errorf("%s: %w %q when we've already parsed one for expr in: %q", op, ErrUnexpectedLogicalOp, p.currentToken.Value, p.raw)
+			return nil, fmt.Errorf("%s: %w %q when we've already parsed one for expr in: %q", op, ErrUnexpectedLogicalOp, p.currentToken.Value, p.raw)
+		case eofToken:
+			if p.lenient {
+				// a trailing logical operator, which is ignored
+				return p.group(operands, logicalOps, depth), nil
+			}
+			return nil, fmt.Errorf("%s: %w in: %q", op, ErrMissingRightSideExpr, p.raw)
+		default:
+			return nil, fmt.Errorf("%s: %w %q in: %q", op, ErrUnexpectedToken, p.currentToken.Value, p.raw)
+		}
+
+		// then, the operand must be followed by a logical operator, the closing
+		// paren for this depth or the end of the query
+		if p.currentToken.Type == whitespaceToken {
+			if err := p.scan(skipWhitespace); err != nil {
+				return nil, fmt.Errorf("%s: %w", op, err)
+			}
+		}
+		switch p.currentToken.Type {
+		case eofToken:
+			if depth > 0 && !p.lenient {
+				return nil, fmt.Errorf("%s: %w in: %q", op, ErrMissingClosingParen, p.raw)
+			}
+			return p.group(operands, logicalOps, depth), nil
+		case endLogicalExprToken:
+			if depth == 0 && !(p.lenient && p.trailingParens()) {
+				return nil, fmt.Errorf("%s: %w %q without an opening paren in: %q", op, ErrUnexpectedClosingParen, p.currentToken.Value, p.raw)
 			}
+			return p.group(operands, logicalOps, depth), nil
+		case andToken, orToken:
 			o, err := newLogicalOp(p.currentToken.Value)
 			if err != nil {
 				return nil, fmt.Errorf("%s: %w", op, err)
 			}
-			logicExpr.logicalOp = o
+			p.debug("found logical operator", "op", o, "depth", depth)
+			if len(operands) > len(logicalOps) {
+				logicalOps = append(logicalOps, o)
+			}
+			// otherwise, it follows an empty group which a lenient parser
+			// ignored, so it's ignored as well
+			if err := p.scan(skipWhitespace); err != nil {
+				return nil, fmt.Errorf("%s: %w", op, err)
+			}
+		case startLogicalExprToken:
+			return nil, fmt.Errorf("%s: %w before right side expression in: %q", op, ErrMissingLogicalOp, p.raw)
+		case stringToken, numberToken, symbolToken:
+			return nil, fmt.Errorf("%s: %w starting at %q in: %q", op, ErrUnexpectedExpr, p.currentToken.Value, p.raw)
 		default:
 			return nil, fmt.Errorf("%s: %w %q in: %q", op, ErrUnexpectedToken, p.currentToken.Value, p.raw)
 		}
-		if err := p.scan(withSkipWhitespace()); err != nil {
-			return nil, fmt.Errorf("%s: %w", op, err)
-		}
 	}
-	if p.openLogicalExpr.len() > 0 {
-		return nil, fmt.Errorf("%s: %w in: %q", op, ErrMissingClosingParen, p.raw)
+}
+
+// group will group the operands (see group) and trace the grouped expr.  It
+// returns nil when there are no operands, which is only the case when a
+// lenient parser ignored all of them.
+func (p *parser) group(operands []Expr, logicalOps []LogicalOp, depth int) Expr {
+	if len(operands) == 0 {
+		return nil
+	}
+	e := group(operands, logicalOps)
+	if p.logger != nil {
+		// the expr is only rendered when it's traced
+		p.debug("grouped operands", "operands", len(operands), "expr", e.MQL(), "depth", depth)
+	}
+	return e
+}
+
+// group will group the operands from the left, so operands a, b, c with
+// logical operators and, or are grouped as: (a and b) or c.  There must be one
+// less logical operator than operands and any trailing logical operator is
+// ignored.
+func group(operands []Expr, logicalOps []LogicalOp) Expr {
+	e := operands[0]
+	for i := 1; i < len(operands); i++ {
+		e = &LogicalExpr{
+			LeftExpr:  e,
+			LogicalOp: logicalOps[i-1],
+			RightExpr: operands[i],
+		}
 	}
-	return logicExpr, nil
+	return e
 }
 
 // parseComparisonExpr will parse a comparisonExpr until an eofToken is reached,
 // which may require it to parse logicalExpr
-func (p *parser) parseComparisonExpr() (expr, error) {
+func (p *parser) parseComparisonExpr() (Expr, error) {
 	const op = "mql.(parser).parseComparisonExpr"
-	cmpExpr := &comparisonExpr{}
+	cmpExpr := &ComparisonExpr{}
 
 	// our language (and this parser) def requires the tokens to be in the
 	// correct order: column, comparisonOp, value. Swapping this order where the
 	// value comes first (value, comparisonOp, column) is not supported
+	// any requires a list of values (ie: name any ("alice", "bob"))
+	var anyOp bool
 	for p.currentToken.Type != eofToken {
 		switch {
+		// a list of values, which is the shorthand for comparing the column to
+		// each of them (ie: name=("alice", "bob"))
+		case p.currentToken.Type == startLogicalExprToken && cmpExpr.ComparisonOp != "" && cmpExpr.Value == nil:
+			return p.parseValueList(cmpExpr)
+		case anyOp && cmpExpr.Value == nil && p.currentToken.Type != whitespaceToken:
+			return nil, fmt.Errorf("%s: %w %q (expected a list of values after \"any\") in: %q", op, ErrUnexpectedToken, p.currentToken.Value, p.raw)
+
 		case p.currentToken.Type == startLogicalExprToken:
 			switch {
 			case cmpExpr.isComplete():
@@ -148,84 +323,192 @@ func (p *parser) parseComparisonExpr() (expr, error) {
 			(p.currentToken.Type != whitespaceToken && p.currentToken.Type != endLogicalExprToken):
 			return nil, fmt.Errorf("%s: %w %s:%q in: %s", op, ErrUnexpectedToken, p.currentToken.Type, p.currentToken.Value, p.raw)
 
+		// we found a closing paren after a completed comparison expr, which
+		// is left for the logical expr to close
+		case cmpExpr.isComplete() && p.currentToken.Type == endLogicalExprToken:
+			return cmpExpr, nil
+
 		// we found whitespace, so check if there's a completed logical expr to return
 		case p.currentToken.Type == whitespaceToken:
-			if cmpExpr.column != "" && cmpExpr.comparisonOp != "" && cmpExpr.value != nil {
+			if cmpExpr.Column != "" && cmpExpr.ComparisonOp != "" && cmpExpr.Value != nil {
 				return cmpExpr, nil
 			}
 
 		// columns must come first, so handle those conditions
-		case cmpExpr.column == "" && p.currentToken.Type != symbolToken:
-			// this should be unreachable because parseComparisonExpr(...) is
-			// called when a symbolToken is the current token, but I've kept
-			// this case here for completeness
-			return nil, fmt.Errorf("%s: %w: we expected a %s and got %s == %s in: %q", op, ErrUnexpectedToken, symbolToken, p.currentToken.Type, p.currentToken.Value, p.raw)
-		case cmpExpr.column == "": // has to be stringToken representing the column
-			cmpExpr.column = p.currentToken.Value
+		case cmpExpr.Column == "" && p.currentToken.Type != symbolToken && p.currentToken.Type != stringToken:
+			return nil, fmt.Errorf("%s: %w: we expected a %s or %s and got %s == %s in: %q", op, ErrUnexpectedToken, symbolToken, stringToken, p.currentToken.Type, p.currentToken.Value, p.raw)
+		case cmpExpr.Column == "" && p.currentToken.Value == "":
+			return nil, fmt.Errorf("%s: %w in: %q", op, ErrMissingColumn, p.raw)
+		case cmpExpr.Column == "": // a symbolToken or a quoted column (stringToken)
+			cmpExpr.Column = p.currentToken.Value
+			cmpExpr.pos = p.currentPos
+			cmpExpr.quotedColumn = p.currentToken.Type == stringToken
+
+		// a column followed by the end of an operand is a macro (see
+		// WithMacro)
+		case p.isMacro(cmpExpr) && (p.currentToken.Type == andToken || p.currentToken.Type == orToken || p.currentToken.Type == endLogicalExprToken):
+			return p.expandMacro(cmpExpr)
 
 		// after columns, comparison operators must come next
-		case cmpExpr.comparisonOp == "":
+		case cmpExpr.ComparisonOp == "" && p.currentToken.Type == symbolToken && strings.EqualFold(p.currentToken.Value, "any"):
+			cmpExpr.ComparisonOp, anyOp = EqualOp, true
+		case cmpExpr.ComparisonOp == "":
 			c, err := newComparisonOp(p.currentToken.Value)
 			if err != nil {
 				return nil, fmt.Errorf("%s: %w %q in: %q", op, err, p.currentToken.Value, p.raw)
 			}
-			cmpExpr.comparisonOp = c
+			cmpExpr.ComparisonOp = c
 
 		// finally, values must come at the end
-		case cmpExpr.value == nil && (p.currentToken.Type != stringToken && p.currentToken.Type != numberToken && p.currentToken.Type != symbolToken):
-			return nil, fmt.Errorf("%s: %w %q in: %q", op, ErrUnexpectedToken, p.currentToken.Value, p.raw)
-		case cmpExpr.value == nil:
-			switch {
-			case p.currentToken.Type == symbolToken:
-				return nil, fmt.Errorf("%s: %w %s == %s (expected: %s or %s) in %q", op, ErrInvalidComparisonValueType, p.currentToken.Type, p.currentToken.Value, stringToken, numberToken, p.raw)
-			case p.currentToken.Type == stringToken, p.currentToken.Type == numberToken:
-				s := p.currentToken.Value
-				cmpExpr.value = &s
-			default:
-				return nil, fmt.Errorf("%s: %w of %s == %s", op, ErrUnexpectedToken, p.currentToken.Type, p.currentToken.Value)
+		case cmpExpr.Value == nil:
+			v, variable, err := p.parseValue()
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", op, err)
 			}
+			cmpExpr.Value, cmpExpr.variable = v, variable
 		}
-		if err := p.scan(); err != nil {
+		if err := p.scan(keepWhitespace); err != nil {
 			return nil, fmt.Errorf("%s: %w", op, err)
 		}
 	}
 
 	switch {
-	case cmpExpr.column != "" && cmpExpr.comparisonOp == "":
+	case p.isMacro(cmpExpr):
+		return p.expandMacro(cmpExpr)
+	case cmpExpr.Column != "" && cmpExpr.ComparisonOp == "":
 		return nil, fmt.Errorf("%s: %w in: %q", op, ErrMissingComparisonOp, p.raw)
+	case cmpExpr.Column != "" && cmpExpr.Value == nil:
+		return nil, fmt.Errorf("%s: %w in: %q", op, ErrMissingComparisonValue, p.raw)
 	default:
 		return cmpExpr, nil
 	}
 }
 
-// scan will get the next token from the lexer. Supported options:
-// withSkipWhitespace
-func (p *parser) scan(opt ...Option) error {
-	const op = "mql.(parser).scan"
+// parseValue will parse the current token as the value of a comparison.  It
+// reports if the value is the name of a variable (see WithVariables).
+func (p *parser) parseValue() (*string, bool, error) {
+	const op = "mql.(parser).parseValue"
+	switch {
+	case p.currentToken.Type == symbolToken && (isBoolLiteral(p.currentToken.Value) || isRelativeTimeLiteral(p.currentToken.Value)):
+		// bool and relative time literals are the only unquoted
+		// symbols allowed as values
+		s := strings.ToLower(p.currentToken.Value)
+		return &s, false, nil
+	case p.currentToken.Type == symbolToken && isVariable(p.currentToken.Value):
+		name := p.currentToken.Value[1:]
+		return &name, true, nil
+	case p.currentToken.Type == symbolToken:
+		return nil, false, fmt.Errorf("%s: %w %s == %s (expected: %s or %s) in %q", op, ErrInvalidComparisonValueType, p.currentToken.Type, p.currentToken.Value, stringToken, numberToken, p.raw)
+	case p.currentToken.Type == stringToken, p.currentToken.Type == numberToken:
+		s := p.currentToken.Value
+		return &s, false, nil
+	default:
+		return nil, false, fmt.Errorf("%s: %w %q in: %q", op, ErrUnexpectedToken, p.currentToken.Value, p.raw)
+	}
+}
 
-	opts, err := getOpts(opt...)
-	if err != nil {
-		return fmt.Errorf("%s: %w", op, err)
+// parseValueList will parse the list of values (ie: ("alice", "bob")) of the
+// comparison, starting at its opening paren, and return the comparisons of
+// its column to each of the values.  The comparisons are or'd for = and and'd
+// for !=, so name=("alice", "bob") is name="alice" or name="bob", and
+// name!=("alice", "bob") is name!="alice" and name!="bob".
+func (p *parser) parseValueList(c *ComparisonExpr) (Expr, error) {
+	const op = "mql.(parser).parseValueList"
+	var lOp LogicalOp
+	switch c.ComparisonOp {
+	case EqualOp:
+		lOp = OrOp
+	case NotEqualOp:
+		lOp = AndOp
+	default:
+		return nil, fmt.Errorf("%s: %w after %q (a list of values can only be compared using %q or %q) in: %q", op, ErrUnexpectedOpeningParen, c.ComparisonOp, EqualOp, NotEqualOp, p.raw)
+	}
+	var operands []Expr
+	for {
+		if err := p.scan(skipWhitespace); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		if p.currentToken.Type == endLogicalExprToken && len(operands) == 0 {
+			return nil, fmt.Errorf("%s: %w (empty list of values) in: %q", op, ErrMissingComparisonValue, p.raw)
+		}
+		v, variable, err := p.parseValue()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		operands = append(operands, &ComparisonExpr{
+			Column:       c.Column,
+			ComparisonOp: c.ComparisonOp,
+			Value:        v,
+			pos:          c.pos,
+			quotedColumn: c.quotedColumn,
+			variable:     variable,
+		})
+		if err := p.scan(skipWhitespace); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		switch p.currentToken.Type {
+		case commaToken:
+		case endLogicalExprToken:
+			// skip the closing paren, which ends the comparison
+			if err := p.scan(keepWhitespace); err != nil {
+				return nil, fmt.Errorf("%s: %w", op, err)
+			}
+			logicalOps := make([]LogicalOp, len(operands)-1)
+			for i := range logicalOps {
+				logicalOps[i] = lOp
+			}
+			return group(operands, logicalOps), nil
+		case eofToken:
+			return nil, fmt.Errorf("%s: %w in: %q", op, ErrMissingClosingParen, p.raw)
+		default:
+			return nil, fmt.Errorf("%s: %w %q (expected %q or %q) in: %q", op, ErrUnexpectedToken, p.currentToken.Value, ",", ")", p.raw)
+		}
 	}
+}
+
+// trailingParens reports if the rest of the query (from the current token)
+// only has closing parens and whitespace
+func (p *parser) trailingParens() bool {
+	return strings.TrimFunc(p.raw[p.currentPos:], func(r rune) bool {
+		return r == ')' || isSpace(r)
+	}) == ""
+}
+
+// scan modes: whether whitespace tokens are skipped or returned by scan
+const (
+	keepWhitespace = false
+	skipWhitespace = true
+)
 
+// scan will get the next token from the lexer, skipping any whitespace tokens
+// when skipWs is true.
+func (p *parser) scan(skipWs bool) error {
+	const op = "mql.(parser).scan"
+	var err error
+	if p.ctx != nil {
+		if err := p.ctx.Err(); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
 	if p.currentToken, err = p.l.nextToken(); err != nil {
+		p.currentPos = p.l.start
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	if opts.withSkipWhitespace {
+	if skipWs {
 		for p.currentToken.Type == whitespaceToken {
 			if p.currentToken, err = p.l.nextToken(); err != nil {
+				p.currentPos = p.l.start
 				return fmt.Errorf("%s: %w", op, err)
 			}
 		}
 	}
-
-	switch p.currentToken.Type {
-	case startLogicalExprToken:
-		p.openLogicalExpr.push(struct{}{})
-	case endLogicalExprToken:
-		p.openLogicalExpr.pop()
+	p.currentPos = p.l.lastTokenPos()
+	if p.currentToken.Type != whitespaceToken && p.currentToken.Type != eofToken {
+		p.tokens++
+		if err := p.limits.checkToken(p.currentToken, p.tokens); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
 	}
-
 	return nil
 }
@@ -16,115 +16,137 @@ func Test_parser(t *testing.T) {
 	tests := []struct {
 		name            string
 		raw             string
-		want            expr
+		want            Expr
 		wantErrIs       error
 		wantErrContains string
 	}{
 		{
 			name: "success-comparisonExpr",
 			raw:  "name=\"alice\"",
-			want: &comparisonExpr{
-				column:       "name",
-				comparisonOp: "=",
-				value:        pointer("alice"),
+			want: &ComparisonExpr{
+				Column:       "name",
+				ComparisonOp: "=",
+				Value:        pointer("alice"),
 			},
 		},
 		{
 			name: "success-comparisonExpr-with-whitespace",
 			raw:  "name= 	\"alice\"",
-			want: &comparisonExpr{
-				column:       "name",
-				comparisonOp: "=",
-				value:        pointer("alice"),
+			want: &ComparisonExpr{
+				Column:       "name",
+				ComparisonOp: "=",
+				Value:        pointer("alice"),
 			},
 		},
 		{
 			name: "success-comparisonExpr-with-parens",
 			raw:  "(name=\"alice\")",
-			want: &comparisonExpr{
-				column:       "name",
-				comparisonOp: "=",
-				value:        pointer("alice"),
+			want: &ComparisonExpr{
+				Column:       "name",
+				ComparisonOp: "=",
+				Value:        pointer("alice"),
 			},
 		},
 		{
 			name: "success-case-sensitive",
 			raw:  "FirstName=\"alice\"",
-			want: &comparisonExpr{
-				column:       "FirstName",
-				comparisonOp: "=",
-				value:        pointer("alice"),
+			want: &ComparisonExpr{
+				Column:       "FirstName",
+				ComparisonOp: "=",
+				Value:        pointer("alice"),
 			},
 		},
 		{
 			name: "success-quoted-value",
 			raw:  "name!=\"alice eve\"",
-			want: &comparisonExpr{
-				column:       "name",
-				comparisonOp: "!=",
-				value:        pointer("alice eve"),
+			want: &ComparisonExpr{
+				Column:       "name",
+				ComparisonOp: "!=",
+				Value:        pointer("alice eve"),
 			},
 		},
 		{
 			name: "success-quoted-empty-value",
 			raw:  "(name!=\"\" and description=\"eve\") or (name=\"alice\")",
-			want: &logicalExpr{
-				leftExpr: &logicalExpr{
-					leftExpr: &comparisonExpr{
-						column:       "name",
-						comparisonOp: "!=",
-						value:        pointer(""),
+			want: &LogicalExpr{
+				LeftExpr: &LogicalExpr{
+					LeftExpr: &ComparisonExpr{
+						Column:       "name",
+						ComparisonOp: "!=",
+						Value:        pointer(""),
 					},
-					logicalOp: "and",
-					rightExpr: &comparisonExpr{
-						column:       "description",
-						comparisonOp: "=",
-						value:        pointer("eve"),
+					LogicalOp: "and",
+					RightExpr: &ComparisonExpr{
+						Column:       "description",
+						ComparisonOp: "=",
+						Value:        pointer("eve"),
 					},
 				},
-				logicalOp: "or",
-				rightExpr: &comparisonExpr{
-					column:       "name",
-					comparisonOp: "=",
-					value:        pointer("alice"),
+				LogicalOp: "or",
+				RightExpr: &ComparisonExpr{
+					Column:       "name",
+					ComparisonOp: "=",
+					Value:        pointer("alice"),
 				},
 			},
 		},
 		{
 			name: "success-or-comparison",
 			raw:  "name=\"alice\" or version >= 110",
-			want: &logicalExpr{
-				leftExpr: &comparisonExpr{
-					column:       "name",
-					comparisonOp: "=",
-					value:        pointer("alice"),
+			want: &LogicalExpr{
+				LeftExpr: &ComparisonExpr{
+					Column:       "name",
+					ComparisonOp: "=",
+					Value:        pointer("alice"),
 				},
-				logicalOp: "or",
-				rightExpr: &comparisonExpr{
-					column:       "version",
-					comparisonOp: ">=",
-					value:        pointer("110"),
+				LogicalOp: "or",
+				RightExpr: &ComparisonExpr{
+					Column:       "version",
+					ComparisonOp: ">=",
+					Value:        pointer("110"),
 				},
 			},
 		},
 		{
 			name: "success-quoted-and-emits-string",
 			raw:  `name%"and"`,
-			want: &comparisonExpr{
-				column:       "name",
-				comparisonOp: "%",
-				value:        pointer("and"),
+			want: &ComparisonExpr{
+				Column:       "name",
+				ComparisonOp: "%",
+				Value:        pointer("and"),
 			},
 		},
 		{
 			name: "success-quoted-or-emits-string",
 			raw:  `name="or"`,
-			want: &comparisonExpr{
-				column:       "name",
-				comparisonOp: "=",
-				value:        pointer("or"),
+			want: &ComparisonExpr{
+				Column:       "name",
+				ComparisonOp: "=",
+				Value:        pointer("or"),
 			},
 		},
+		{
+			name: "success-quoted-column",
+			raw:  "`user name`=\"alice\"",
+			want: &ComparisonExpr{
+				Column:       "user name",
+				ComparisonOp: "=",
+				Value:        pointer("alice"),
+				quotedColumn: true,
+			},
+		},
+		{
+			name:            "err-number-column",
+			raw:             `1="alice"`,
+			wantErrIs:       ErrUnexpectedToken,
+			wantErrContains: "we expected a symbol or str and got num == 1",
+		},
+		{
+			name:            "err-empty-quoted-column",
+			raw:             `""="alice"`,
+			wantErrIs:       ErrMissingColumn,
+			wantErrContains: "missing column",
+		},
 		{
 			name:            "err-missing-logicalOp",
 			raw:             "name=\"alice\" (name=\"eve\")",
@@ -155,6 +177,12 @@ func Test_parser(t *testing.T) {
 			wantErrIs:       ErrMissingComparisonOp,
 			wantErrContains: "missing comparison operator in: \"name=\\\"alice\\\" or age\"",
 		},
+		{
+			name:            "err-missing-comparison-value",
+			raw:             "name=\"alice\" or age>",
+			wantErrIs:       ErrMissingComparisonValue,
+			wantErrContains: "missing comparison value in: \"name=\\\"alice\\\" or age>\"",
+		},
 		{
 			name:            "err-trailing-logical-op",
 			raw:             "name=\"alice\" or",
@@ -185,6 +213,18 @@ func Test_parser(t *testing.T) {
 			wantErrIs:       ErrUnexpectedClosingParen,
 			wantErrContains: `unexpected closing paren ")" but we haven't parsed a left side expression in: ")(name=alice)"`,
 		},
+		{
+			name:            "err-closing-paren-without-opening-paren",
+			raw:             "name=\"alice\") or age > 21",
+			wantErrIs:       ErrUnexpectedClosingParen,
+			wantErrContains: `unexpected closing paren ")" without an opening paren in: "name=\"alice\") or age > 21"`,
+		},
+		{
+			name:            "err-empty-parens",
+			raw:             "name=\"alice\" and ()",
+			wantErrIs:       ErrUnexpectedClosingParen,
+			wantErrContains: `unexpected closing paren ")" but we haven't parsed a left side expression`,
+		},
 		{
 			name:            "err-unexpected-opening-paren",
 			raw:             "((name=\"alice\")",
@@ -200,75 +240,75 @@ func Test_parser(t *testing.T) {
 		{
 			name: "success-double-parens",
 			raw:  "((name=\"alice\"))",
-			want: &comparisonExpr{
-				column:       "name",
-				comparisonOp: "=",
-				value:        pointer("alice"),
+			want: &ComparisonExpr{
+				Column:       "name",
+				ComparisonOp: "=",
+				Value:        pointer("alice"),
 			},
 		},
 		{
 			name: "success-logical-expr-with-contains",
 			raw:  "name=\"alice\" and address%\"my town\"",
-			want: &logicalExpr{
-				leftExpr: &comparisonExpr{
-					column:       "name",
-					comparisonOp: "=",
-					value:        pointer("alice"),
+			want: &LogicalExpr{
+				LeftExpr: &ComparisonExpr{
+					Column:       "name",
+					ComparisonOp: "=",
+					Value:        pointer("alice"),
 				},
-				logicalOp: "and",
-				rightExpr: &comparisonExpr{
-					column:       "address",
-					comparisonOp: "%",
-					value:        pointer("my town"),
+				LogicalOp: "and",
+				RightExpr: &ComparisonExpr{
+					Column:       "address",
+					ComparisonOp: "%",
+					Value:        pointer("my town"),
 				},
 			},
 		},
 		{
 			name: "nested-logical-expr",
 			raw:  "(name=\"alice\" and address%\"hometown\") or age > 21.5",
-			want: &logicalExpr{
-				leftExpr: &logicalExpr{
-					leftExpr: &comparisonExpr{
-						column:       "name",
-						comparisonOp: "=",
-						value:        pointer("alice"),
+			want: &LogicalExpr{
+				LeftExpr: &LogicalExpr{
+					LeftExpr: &ComparisonExpr{
+						Column:       "name",
+						ComparisonOp: "=",
+						Value:        pointer("alice"),
 					},
-					logicalOp: "and",
-					rightExpr: &comparisonExpr{
-						column:       "address",
-						comparisonOp: "%",
-						value:        pointer("hometown"),
+					LogicalOp: "and",
+					RightExpr: &ComparisonExpr{
+						Column:       "address",
+						ComparisonOp: "%",
+						Value:        pointer("hometown"),
 					},
 				},
-				logicalOp: "or",
-				rightExpr: &comparisonExpr{
-					column:       "age",
-					comparisonOp: ">",
-					value:        pointer("21.5"),
+				LogicalOp: "or",
+				RightExpr: &ComparisonExpr{
+					Column:       "age",
+					ComparisonOp: ">",
+					Value:        pointer("21.5"),
 				},
 			},
 		},
 		{
 			name: "reverse-nested-logical-expr",
 			raw:  "age > 21.5 or (name=\"alice\" and address%\"hometown\")",
-			want: &logicalExpr{
-				leftExpr: &comparisonExpr{
-					column:       "age",
-					comparisonOp: ">",
-					value:        pointer("21.5"),
+			want: &LogicalExpr{
+				LeftExpr: &ComparisonExpr{
+					Column:       "age",
+					ComparisonOp: ">",
+					Value:        pointer("21.5"),
 				},
-				logicalOp: "or",
-				rightExpr: &logicalExpr{
-					leftExpr: &comparisonExpr{
-						column:       "name",
-						comparisonOp: "=",
-						value:        pointer("alice"),
+				LogicalOp: "or",
+				RightExpr: &LogicalExpr{
+					LeftExpr: &ComparisonExpr{
+						Column:       "name",
+						ComparisonOp: "=",
+						Value:        pointer("alice"),
 					},
-					logicalOp: "and",
-					rightExpr: &comparisonExpr{
-						column:       "address",
-						comparisonOp: "%",
-						value:        pointer("hometown"),
+					LogicalOp: "and",
+					RightExpr: &ComparisonExpr{
+						Column:       "address",
+						ComparisonOp: "%",
+						Value:        pointer("hometown"),
 					},
 				},
 			},
@@ -276,17 +316,17 @@ func Test_parser(t *testing.T) {
 		{
 			name: "reverse-nested-logical-expr",
 			raw:  `name="one" or (created_at>"now()-interval '1 day'")`,
-			want: &logicalExpr{
-				leftExpr: &comparisonExpr{
-					column:       "name",
-					comparisonOp: "=",
-					value:        pointer("one"),
+			want: &LogicalExpr{
+				LeftExpr: &ComparisonExpr{
+					Column:       "name",
+					ComparisonOp: "=",
+					Value:        pointer("one"),
 				},
-				logicalOp: "or",
-				rightExpr: &comparisonExpr{
-					column:       "created_at",
-					comparisonOp: ">",
-					value:        pointer("now()-interval '1 day'"),
+				LogicalOp: "or",
+				RightExpr: &ComparisonExpr{
+					Column:       "created_at",
+					ComparisonOp: ">",
+					Value:        pointer("now()-interval '1 day'"),
 				},
 			},
 		},
@@ -307,11 +347,36 @@ func Test_parser(t *testing.T) {
 				return
 			}
 			require.NoErrorf(err, "unexpected err for %s, but got %v", tc.raw, e)
+			clearPositions(e)
 			assert.Equal(tc.want, e)
 		})
 	}
 }
 
+func Test_parserPositions(t *testing.T) {
+	t.Parallel()
+	p := newParser(`(name="alice" and  age>21) or  éa="eve"`)
+	e, err := p.parse()
+	require.NoError(t, err)
+	var got []int
+	walkExpr(e, func(e Expr) {
+		if c, ok := e.(*ComparisonExpr); ok {
+			got = append(got, c.pos)
+		}
+	})
+	assert.Equal(t, []int{1, 19, 31}, got)
+}
+
+// clearPositions will zero the positions in the expr tree, so trees can be
+// compared without specifying where every expr appeared in the query.
+func clearPositions(e Expr) {
+	walkExpr(e, func(e Expr) {
+		if c, ok := e.(*ComparisonExpr); ok {
+			c.pos = 0
+		}
+	})
+}
+
 // Fuzz_parserParse is primarily focused on finding panics
 func Fuzz_parserParse(f *testing.F) {
 	tc := []string{
@@ -341,12 +406,73 @@ func Fuzz_parserParse(f *testing.F) {
 
 func Test_scan(t *testing.T) {
 	t.Parallel()
-	// just negative tests
-	t.Run("err-scan-options", func(t *testing.T) {
-		p := newParser("name=alice")
-		err := p.scan(WithConverter("name", nil))
-		require.Error(t, err)
-		assert.ErrorIs(t, err, ErrInvalidParameter)
-		assert.ErrorContains(t, err, "missing ConvertToSqlFunc: invalid parameter")
+	t.Run("keep-whitespace", func(t *testing.T) {
+		p := newParser(" name")
+		require.NoError(t, p.scan(keepWhitespace))
+		assert.Equal(t, whitespaceToken, p.currentToken.Type)
 	})
+	t.Run("skip-whitespace", func(t *testing.T) {
+		p := newParser(" name")
+		require.NoError(t, p.scan(skipWhitespace))
+		assert.Equal(t, token{Type: symbolToken, Value: "name"}, p.currentToken)
+		assert.Equal(t, 1, p.currentPos)
+	})
+}
+
+func Test_partialExpr(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		raw     string
+		wantPos int
+		want    string
+	}{
+		{
+			name:    "missing-right-side",
+			raw:     `name="alice" and age>`,
+			wantPos: 21,
+			want:    "(comparisonExpr: name = alice)",
+		},
+		{
+			name:    "missing-closing-paren",
+			raw:     `(name="alice" or name="bob") and (age>21 or`,
+			wantPos: 43,
+			want:    "(logicalExpr: (logicalExpr: (comparisonExpr: name = alice) or (comparisonExpr: name = bob)) and (comparisonExpr: age > 21))",
+		},
+		{
+			name:    "lexer-error",
+			raw:     `name="alice" or name!bob`,
+			wantPos: 20,
+			want:    "(comparisonExpr: name = alice)",
+		},
+		{
+			name:    "unexpected-token",
+			raw:     `name="alice" age>21`,
+			wantPos: 13,
+			want:    "(comparisonExpr: name = alice)",
+		},
+		{
+			name:    "nothing-valid",
+			raw:     `name="alice`,
+			wantPos: 5,
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			assert, require := assert.New(t), require.New(t)
+			e, err := newParser(tc.raw).parse()
+			require.Error(err)
+			assert.Empty(e)
+			var pErr *ParseError
+			require.ErrorAs(err, &pErr)
+			assert.Equal(tc.wantPos, pErr.Pos)
+			if tc.want == "" {
+				assert.Nil(pErr.Partial)
+				return
+			}
+			require.NotNil(pErr.Partial)
+			assert.Equal(tc.want, pErr.Partial.String())
+		})
+	}
 }
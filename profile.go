@@ -0,0 +1,69 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql
+
+import (
+	"fmt"
+)
+
+// Profile is an immutable bundle of options (ie: a dialect, column maps,
+// converters and limits) which is validated once (see NewProfile) and then
+// provided to Parse (or any function which supports options) as a single
+// option (see WithProfile), so every call site uses the same configuration.
+// A Profile is safe for concurrent use, provided that its options (ie: its
+// converters) are.
+type Profile struct {
+	opt []Option
+}
+
+// NewProfile returns a Profile of the options, which are validated once so
+// configuration errors are returned by NewProfile rather than when a query is
+// parsed.  The options are copied, but their args (ie: the map of
+// WithColumnMap) must not be modified once the Profile is created.
+// Supported options: the same options as Parse.
+func NewProfile(opt ...Option) (*Profile, error) {
+	const op = "mql.NewProfile"
+	p, err := (&Profile{}).With(opt...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return p, nil
+}
+
+// With returns a new Profile with the profile's options followed by the
+// options, so a profile can be specialized (ie: for a model) without modifying
+// it.
+func (p *Profile) With(opt ...Option) (*Profile, error) {
+	const op = "mql.(Profile).With"
+	for i, o := range opt {
+		if o == nil {
+			return nil, fmt.Errorf("%s: option %d is nil: %w", op, i, ErrInvalidParameter)
+		}
+	}
+	all := make([]Option, 0, len(p.opt)+len(opt))
+	all = append(append(all, p.opt...), opt...)
+	if _, err := getOpts(all...); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return &Profile{opt: all}, nil
+}
+
+// WithProfile provides the options of the profile, which are applied in the
+// same order as they were provided to NewProfile.  Options provided after it
+// are applied on top of them (ie: WithColumnMap merges its map with the
+// profile's map), without modifying the profile.
+func WithProfile(p *Profile) Option {
+	const op = "mql.WithProfile"
+	return func(o *options) error {
+		if p == nil {
+			return fmt.Errorf("%s: missing profile: %w", op, ErrInvalidParameter)
+		}
+		for _, opt := range p.opt {
+			if err := opt(o); err != nil {
+				return fmt.Errorf("%s: %w", op, err)
+			}
+		}
+		return nil
+	}
+}
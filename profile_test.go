@@ -0,0 +1,115 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/hashicorp/mql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewProfile(t *testing.T) {
+	t.Parallel()
+	profile, err := mql.NewProfile(
+		mql.WithDialect(mql.PostgresDialect{}),
+		mql.WithColumnMap(map[string]string{"nickname": "name"}),
+		mql.WithMaxTokens(20),
+	)
+	require.NoError(t, err)
+	tests := []struct {
+		name      string
+		query     string
+		opts      []mql.Option
+		want      *mql.WhereClause
+		wantErrIs error
+	}{
+		{
+			name:  "profile",
+			query: `nickname="alice" and age>21`,
+			want:  &mql.WhereClause{Condition: `("name"=$1 and "age">$2)`, Args: []any{"alice", 21}},
+		},
+		{
+			name:  "options-after-profile",
+			query: `nick="alice" or nickname="bob"`,
+			opts:  []mql.Option{mql.WithColumnMap(map[string]string{"nick": "name"})},
+			want:  &mql.WhereClause{Condition: `("name"=$1 or "name"=$2)`, Args: []any{"alice", "bob"}},
+		},
+		{
+			name:      "err-profile-limit",
+			query:     `name="a" or name="b" or name="c" or name="d" or name="e" or name="f"`,
+			wantErrIs: mql.ErrTooManyTokens,
+		},
+		{
+			name:      "err-profile-conflict",
+			query:     `nickname="alice"`,
+			opts:      []mql.Option{mql.WithColumnMap(map[string]string{"nickname": "email"})},
+			wantErrIs: mql.ErrInvalidParameter,
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert, require := assert.New(t), require.New(t)
+			got, err := mql.Parse(tc.query, testModel{}, append([]mql.Option{mql.WithProfile(profile)}, tc.opts...)...)
+			if tc.wantErrIs != nil {
+				require.Error(err)
+				assert.ErrorIs(err, tc.wantErrIs)
+				assert.Nil(got)
+				return
+			}
+			require.NoError(err)
+			assert.Equal(tc.want, got)
+		})
+	}
+	t.Run("with", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		pg, err := profile.With(mql.WithColumnMap(map[string]string{"nick": "name"}))
+		require.NoError(err)
+		got, err := mql.Parse(`nick="alice"`, testModel{}, mql.WithProfile(pg))
+		require.NoError(err)
+		assert.Equal(`"name"=$1`, got.Condition)
+
+		// the original profile isn't modified
+		_, err = mql.Parse(`nick="alice"`, testModel{}, mql.WithProfile(profile))
+		assert.ErrorIs(err, mql.ErrInvalidColumn)
+	})
+	t.Run("parser", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		p, err := mql.NewParser(testModel{}, mql.WithProfile(profile))
+		require.NoError(err)
+		got, err := p.Parse(`nickname="alice"`)
+		require.NoError(err)
+		assert.Equal(`"name"=$1`, got.Condition)
+	})
+	t.Run("concurrent", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			i := i
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				column := fmt.Sprintf("nick_%d", i)
+				got, err := mql.Parse(column+`="alice"`, testModel{}, mql.WithProfile(profile), mql.WithColumnMap(map[string]string{column: "name"}))
+				assert.NoError(t, err)
+				assert.Equal(t, `"name"=$1`, got.Condition)
+			}()
+		}
+		wg.Wait()
+	})
+	t.Run("errors", func(t *testing.T) {
+		_, err := mql.NewProfile(mql.WithMaxTokens(-1))
+		assert.ErrorIs(t, err, mql.ErrInvalidParameter)
+		_, err = mql.NewProfile(nil)
+		assert.ErrorIs(t, err, mql.ErrInvalidParameter)
+		_, err = profile.With(mql.WithColumnMap(map[string]string{"nickname": "email"}))
+		assert.ErrorIs(t, err, mql.ErrInvalidParameter)
+		_, err = mql.Parse(`name="alice"`, testModel{}, mql.WithProfile(nil))
+		assert.ErrorIs(t, err, mql.ErrInvalidParameter)
+	})
+}
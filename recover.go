@@ -0,0 +1,234 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ParseExprRecover is the same as ParseExpr, except that it doesn't stop at
+// the first syntax error.  After an error, it skips to the next logical
+// operator and continues, so it returns the expr tree of every operand which
+// could be parsed along with the error of every one which couldn't (see
+// ParseError.Pos).  The logical operator after an operand which is skipped is
+// skipped with it (ie: the tree of `a=1 and b= or c=2` is `a=1 and c=2`).
+// Operands in parens are recovered the same way.  The expr tree is nil when
+// no operand could be parsed and there are no errors when the query is
+// valid.  Limit errors (see ErrLimitExceeded) aren't recovered from.
+// Supported options: the same options as ParseExpr.
+func ParseExprRecover(query string, opt ...Option) (Expr, []*ParseError) {
+	const op = "mql.ParseExprRecover"
+	opts, err := getOpts(opt...)
+	if err != nil {
+		return nil, []*ParseError{{Err: fmt.Errorf("%s: %w", op, err), Pos: -1}}
+	}
+	if query == "" {
+		return nil, []*ParseError{{Err: fmt.Errorf("%s: missing query: %w", op, ErrInvalidParameter), Pos: -1}}
+	}
+	e, errs := recoverExpr(query, opts)
+	for _, pErr := range errs {
+		pErr.Err = fmt.Errorf("%s: %w", op, pErr.Err)
+	}
+	return e, errs
+}
+
+// recoverExpr will parse the raw query, recovering from syntax errors (see
+// ParseExprRecover).  Supported options: WithMaxQueryLength, WithMaxTokens,
+// WithMaxStringLength and WithDebugLogger
+func recoverExpr(raw string, opts options) (Expr, []*ParseError) {
+	p := newParser(raw)
+	p.configure(opts)
+	e, err := p.parse()
+	if err == nil {
+		return e, nil
+	}
+	var pErr *ParseError
+	switch {
+	case !errors.As(err, &pErr):
+		return nil, []*ParseError{{Err: err, Pos: -1}}
+	case errors.Is(err, ErrLimitExceeded):
+		return nil, []*ParseError{pErr}
+	}
+	// the parser stops at the first error, so the limits of the rest of the
+	// query are checked before it's recovered
+	tokens, positions, _ := scanRecoverable(raw)
+	n := 0
+	for i, tk := range tokens {
+		if tk.Type == whitespaceToken {
+			continue
+		}
+		n++
+		if err := opts.withLimits.checkToken(tk, n); err != nil {
+			return nil, []*ParseError{{Err: err, Pos: positions[i]}}
+		}
+	}
+	return recoverOperands(raw, 0, opts)
+}
+
+// recoverOperands will split raw into its operands (separated by the logical
+// operators which aren't in parens) and parse each of them, returning the
+// expr tree of the operands which could be parsed along with the errors of
+// the others.  The errors' positions are offset by base, which is the byte
+// offset of raw in the query.
+func recoverOperands(raw string, base int, opts options) (Expr, []*ParseError) {
+	const op = "mql.recoverOperands"
+	tokens, positions, rest := scanRecoverable(raw)
+
+	// split the tokens into operands [start, end) and the logical operators
+	// between them.
+	type operand struct{ start, end int }
+	var (
+		operands   []operand
+		logicalOps []int // the index of each logical operator's token
+		depth      int
+		start      int
+	)
+	for i, tk := range tokens {
+		switch tk.Type {
+		case startLogicalExprToken:
+			depth++
+		case endLogicalExprToken:
+			if depth > 0 {
+				depth--
+			}
+		case andToken, orToken:
+			if depth == 0 {
+				operands = append(operands, operand{start: start, end: i})
+				logicalOps = append(logicalOps, i)
+				start = i + 1
+			}
+		}
+	}
+	operands = append(operands, operand{start: start, end: len(tokens)})
+
+	var (
+		exprs   []Expr
+		exprOps []LogicalOp
+		errs    []*ParseError
+		last    int // the index of the last operand which was parsed
+	)
+	for i, o := range operands {
+		from, to := len(raw), len(raw)
+		if o.start < len(tokens) {
+			from = positions[o.start]
+		}
+		if o.end < len(tokens) {
+			to = positions[o.end]
+		}
+		if i == len(operands)-1 && rest >= 0 {
+			// the rest of the query couldn't be scanned, so it's part of the
+			// last operand
+			to = len(raw)
+		}
+		text := raw[from:to]
+		var (
+			e         Expr
+			opErrs    []*ParseError
+			recovered bool
+		)
+		switch {
+		case strings.TrimSpace(text) == "" && len(operands) == 1:
+			opErrs = []*ParseError{{Err: fmt.Errorf("%s: %w nil in: %q", op, ErrMissingExpr, raw), Pos: base + from}}
+		case strings.TrimSpace(text) == "" && i < len(logicalOps):
+			tk := tokens[logicalOps[i]]
+			opErrs = []*ParseError{{Err: fmt.Errorf("%s: %w %q when we've already parsed one for expr in: %q", op, ErrUnexpectedLogicalOp, tk.Value, raw), Pos: base + positions[logicalOps[i]]}}
+		case strings.TrimSpace(text) == "":
+			opErrs = []*ParseError{{Err: fmt.Errorf("%s: %w in: %q", op, ErrMissingRightSideExpr, raw), Pos: base + len(raw)}}
+		default:
+			p := newParser(text)
+			p.configure(opts)
+			var err error
+			if e, err = p.parse(); err != nil {
+				e, opErrs, recovered = recoverParens(text, base+from, opts)
+				if !recovered {
+					var pErr *ParseError
+					errors.As(err, &pErr)
+					opErrs = []*ParseError{{Err: pErr.Err, Pos: base + from + pErr.Pos}}
+				}
+			}
+		}
+		errs = append(errs, opErrs...)
+		if e == nil {
+			continue
+		}
+		// an operand is joined to the previous operand which was parsed using
+		// the logical operator which followed that operand
+		if len(exprs) > 0 {
+			exprOps = append(exprOps, LogicalOp(tokens[logicalOps[last]].Value))
+		}
+		exprs = append(exprs, e)
+		last = i
+	}
+	if len(exprs) == 0 {
+		return nil, errs
+	}
+	return group(exprs, exprOps), errs
+}
+
+// recoverParens will recover the operand in text when it's in parens (which
+// may not be closed), returning its expr tree and errors (see
+// recoverOperands).  It reports false when the operand isn't in parens.
+func recoverParens(text string, base int, opts options) (Expr, []*ParseError, bool) {
+	const op = "mql.recoverParens"
+	tokens, positions, rest := scanRecoverable(text)
+	open := -1
+	for i, tk := range tokens {
+		if tk.Type != whitespaceToken {
+			open = i
+			break
+		}
+	}
+	if open < 0 || tokens[open].Type != startLogicalExprToken {
+		return nil, nil, false
+	}
+	depth := 0
+	for i := open; i < len(tokens); i++ {
+		switch tokens[i].Type {
+		case startLogicalExprToken:
+			depth++
+		case endLogicalExprToken:
+			depth--
+		}
+		if depth > 0 {
+			continue
+		}
+		for _, tk := range tokens[i+1:] {
+			if tk.Type != whitespaceToken {
+				// the parens are followed by something else
+				return nil, nil, false
+			}
+		}
+		e, errs := recoverOperands(text[positions[open]+1:positions[i]], base+positions[open]+1, opts)
+		return e, errs, true
+	}
+	e, errs := recoverOperands(text[positions[open]+1:], base+positions[open]+1, opts)
+	if rest >= 0 {
+		// the closing paren may be in the rest of text, which couldn't be
+		// scanned
+		return e, errs, true
+	}
+	errs = append(errs, &ParseError{Err: fmt.Errorf("%s: %w in: %q", op, ErrMissingClosingParen, text), Pos: base + len(text)})
+	return e, errs, true
+}
+
+// scanRecoverable will scan the tokens of raw (including whitespace) along
+// with their byte offsets, until raw can't be scanned any further.  rest is
+// the byte offset of the rest of raw which couldn't be scanned and it's -1
+// when all of raw was scanned.
+func scanRecoverable(raw string) (tokens []token, positions []int, rest int) {
+	l := newLexer(raw)
+	for {
+		tk, err := l.nextToken()
+		switch {
+		case err != nil:
+			return tokens, positions, l.start
+		case tk.Type == eofToken:
+			return tokens, positions, -1
+		}
+		tokens = append(tokens, tk)
+		positions = append(positions, l.lastTokenPos())
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql_test
+
+import (
+	"testing"
+
+	"github.com/hashicorp/mql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseExprRecover(t *testing.T) {
+	t.Parallel()
+	type wantErr struct {
+		is  error
+		pos int
+	}
+	tests := []struct {
+		name     string
+		query    string
+		opts     []mql.Option
+		wantMQL  string
+		wantErrs []wantErr
+	}{
+		{
+			name:    "valid",
+			query:   `name="alice" and age>21`,
+			wantMQL: `name="alice" and age>21`,
+		},
+		{
+			name:     "missing-value",
+			query:    `name="alice" and age> or email="bob"`,
+			wantMQL:  `name="alice" and email="bob"`,
+			wantErrs: []wantErr{{is: mql.ErrMissingComparisonValue, pos: 22}},
+		},
+		{
+			name:    "every-error",
+			query:   `name= and age>21 or email "bob" and length<`,
+			wantMQL: `age>21`,
+			wantErrs: []wantErr{
+				{is: mql.ErrMissingComparisonValue, pos: 6},
+				{is: mql.ErrInvalidComparisonOp, pos: 26},
+				{is: mql.ErrMissingComparisonValue, pos: 43},
+			},
+		},
+		{
+			name:     "missing-right-side",
+			query:    `name="alice" and`,
+			wantMQL:  `name="alice"`,
+			wantErrs: []wantErr{{is: mql.ErrMissingRightSideExpr, pos: 16}},
+		},
+		{
+			name:     "unexpected-logical-op",
+			query:    `or name="alice" and and age>21`,
+			wantMQL:  `name="alice" and age>21`,
+			wantErrs: []wantErr{{is: mql.ErrUnexpectedLogicalOp, pos: 0}, {is: mql.ErrUnexpectedLogicalOp, pos: 20}},
+		},
+		{
+			name:     "parens",
+			query:    `name="alice" and (age> or email="bob")`,
+			wantMQL:  `name="alice" and email="bob"`,
+			wantErrs: []wantErr{{is: mql.ErrMissingComparisonValue, pos: 23}},
+		},
+		{
+			name:     "missing-closing-paren",
+			query:    `(name="alice" or age>`,
+			wantMQL:  `name="alice"`,
+			wantErrs: []wantErr{{is: mql.ErrMissingComparisonValue, pos: 21}, {is: mql.ErrMissingClosingParen, pos: 21}},
+		},
+		{
+			name:     "unexpected-closing-paren",
+			query:    `name="alice") or age>21`,
+			wantMQL:  `age>21`,
+			wantErrs: []wantErr{{is: mql.ErrUnexpectedClosingParen, pos: 12}},
+		},
+		{
+			name:     "missing-end-of-string",
+			query:    `age>21 and name="alice`,
+			wantMQL:  `age>21`,
+			wantErrs: []wantErr{{is: mql.ErrMissingEndOfStringTokenDelimiter, pos: 16}},
+		},
+		{
+			name:     "nothing-recovered",
+			query:    `name=`,
+			wantErrs: []wantErr{{is: mql.ErrMissingComparisonValue, pos: 5}},
+		},
+		{
+			name:     "limit-exceeded",
+			query:    `name= and age>21 and email="bob"`,
+			opts:     []mql.Option{mql.WithMaxTokens(6)},
+			wantErrs: []wantErr{{is: mql.ErrTooManyTokens, pos: 17}},
+		},
+		{
+			name:     "missing-query",
+			wantErrs: []wantErr{{is: mql.ErrInvalidParameter, pos: -1}},
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert, require := assert.New(t), require.New(t)
+			e, errs := mql.ParseExprRecover(tc.query, tc.opts...)
+			require.Len(errs, len(tc.wantErrs))
+			for i, want := range tc.wantErrs {
+				assert.ErrorIs(errs[i], want.is)
+				assert.Equal(want.pos, errs[i].Pos, "error %d: %s", i, errs[i])
+			}
+			if tc.wantMQL == "" {
+				assert.Nil(e)
+				return
+			}
+			require.NotNil(e)
+			assert.Equal(tc.wantMQL, e.MQL())
+		})
+	}
+}
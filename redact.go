@@ -0,0 +1,105 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// inlineLiteralRegexp matches the SQL string and number literals of a
+// condition with inline values (see WithInlineValues)
+var inlineLiteralRegexp = regexp.MustCompile(`'(?:[^']|'')*'|\b[0-9]+(?:\.[0-9]+)?(?:[eE][+-]?[0-9]+)?\b`)
+
+// Redacted returns the where clause's condition with its placeholders replaced
+// by the type of their arg, so it can be logged without leaking the args (ie:
+// "(name=<string> and age><int>)").  Values which are inlined in the condition
+// (see WithInlineValues) are replaced by <string> or <number>.
+func (w WhereClause) Redacted() string {
+	switch placeholderStyleOf(&w) {
+	case questionPlaceholders:
+		return replacePlaceholders(w.Condition, len(w.Args), func(i int) string {
+			return argType(w.Args[i])
+		})
+	case pgPlaceholders:
+		return redactNumbered(w.Condition, pgPlaceholderRegexp, w.Args)
+	case sqlNamedPlaceholders, atPlaceholders:
+		return redactNumbered(w.Condition, sqlNamedPlaceholderRegexp, w.Args)
+	case namedPlaceholders:
+		names := make([]string, 0, len(w.NamedArgs))
+		for name := range w.NamedArgs {
+			names = append(names, name)
+		}
+		// longest first, so name_1 doesn't match the start of name_10
+		sort.Slice(names, func(i, j int) bool { return len(names[i]) > len(names[j]) })
+		condition := w.Condition
+		for _, name := range names {
+			// a param is preceded by its prefix (ie: ":"), while a column is
+			// preceded by whitespace or a paren (see renameNamedParams).
+			re := regexp.MustCompile(`[^\w\s(]` + regexp.QuoteMeta(name) + `\b`)
+			condition = re.ReplaceAllLiteralString(condition, argType(w.NamedArgs[name]))
+		}
+		return condition
+	default:
+		if w.Condition == matchAllCondition {
+			return w.Condition
+		}
+		var b strings.Builder
+		last := 0
+		for _, m := range inlineLiteralRegexp.FindAllStringIndex(w.Condition, -1) {
+			literal := w.Condition[m[0]:m[1]]
+			b.WriteString(w.Condition[last:m[0]])
+			switch {
+			case strings.HasSuffix(w.Condition[:m[0]], " escape "):
+				// the escape character of a like pattern isn't a value
+				b.WriteString(literal)
+			case literal[0] == '\'':
+				b.WriteString("<string>")
+			default:
+				b.WriteString("<number>")
+			}
+			last = m[1]
+		}
+		b.WriteString(w.Condition[last:])
+		return b.String()
+	}
+}
+
+// String returns the redacted where clause (see Redacted) along with its
+// number of args, which never includes the args, so the where clause can be
+// safely logged (ie: using %v).
+func (w WhereClause) String() string {
+	n := len(w.Args)
+	if len(w.NamedArgs) > 0 {
+		n = len(w.NamedArgs)
+	}
+	return fmt.Sprintf("%s [%d args]", w.Redacted(), n)
+}
+
+// redactNumbered returns the condition with its numbered placeholders (ie: $1
+// or @p1) replaced by the type of their arg
+func redactNumbered(condition string, re *regexp.Regexp, args []any) string {
+	return re.ReplaceAllStringFunc(condition, func(p string) string {
+		n, err := strconv.Atoi(strings.TrimLeft(p, "$@p"))
+		if err != nil || n < 1 || n > len(args) {
+			return p
+		}
+		return argType(args[n-1])
+	})
+}
+
+// argType returns the type of the arg as a placeholder (ie: <string>)
+func argType(a any) string {
+	if n, ok := a.(sql.NamedArg); ok {
+		a = n.Value
+	}
+	if a == nil {
+		return "<nil>"
+	}
+	return fmt.Sprintf("<%T>", a)
+}
@@ -0,0 +1,80 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/mql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWhereClause_Redacted(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name  string
+		query string
+		opts  []mql.Option
+		want  string
+	}{
+		{
+			name:  "question-placeholders",
+			query: `name="alice" and age>21 and length<1.5`,
+			want:  "((name=<string> and age><int>) and length<<float64>)",
+		},
+		{
+			name:  "pg-placeholders",
+			query: `name="alice" or email%"@example.com"`,
+			opts:  []mql.Option{mql.WithPgPlaceholders()},
+			want:  `(name=<string> or email like <string> escape '\')`,
+		},
+		{
+			name:  "sql-named-args",
+			query: `name="alice" and created_at>"2023-01-01T00:00:00Z"`,
+			opts:  []mql.Option{mql.WithSqlNamedArgs()},
+			want:  "(name=<string> and created_at><time.Time>)",
+		},
+		{
+			name:  "named-params",
+			query: `name="a" or name="b" or name="c" or name="d" or name="e" or name="f" or name="g" or name="h" or name="i" or name="j"`,
+			opts:  []mql.Option{mql.WithNamedParams(":")},
+			want:  "(((((((((name=<string> or name=<string>) or name=<string>) or name=<string>) or name=<string>) or name=<string>) or name=<string>) or name=<string>) or name=<string>) or name=<string>)",
+		},
+		{
+			name:  "inline-values",
+			query: `name="alice's" or age>21 or email%"bob"`,
+			opts:  []mql.Option{mql.WithInlineValues()},
+			want:  `((name=<string> or age><number>) or email like <string> escape '\')`,
+		},
+		{
+			name: "empty-query",
+			opts: []mql.Option{mql.WithAllowEmptyQuery()},
+			want: "1=1",
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert, require := assert.New(t), require.New(t)
+			w, err := mql.Parse(tc.query, testModel{}, tc.opts...)
+			require.NoError(err)
+			assert.Equal(tc.want, w.Redacted())
+		})
+	}
+	t.Run("String", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		w, err := mql.Parse(`name="alice" and age>21`, testModel{})
+		require.NoError(err)
+		assert.Equal("(name=<string> and age><int>) [2 args]", w.String())
+		assert.Equal(w.String(), fmt.Sprintf("%v", w))
+		assert.NotContains(fmt.Sprintf("%+v", *w), "alice")
+	})
+	t.Run("nil-arg", func(t *testing.T) {
+		w := mql.WhereClause{Condition: "name=?", Args: []any{nil}}
+		assert.Equal(t, "name=<nil>", w.Redacted())
+	})
+}
@@ -0,0 +1,135 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultReferencedColumn is the model's column referenced by a relationship's
+// foreign key, when Relationship.References isn't provided.
+const DefaultReferencedColumn = "id"
+
+// Relationship describes a child table of the model (ie: the roles of a user),
+// so a query can filter the model by the columns of its related rows (see
+// WithRelationship).
+type Relationship struct {
+	// Model is the child's database model, which is used to validate the
+	// child's columns.
+	Model any
+
+	// Table is the child's table, which defaults to the table of the child
+	// model's struct tag (ie: `mql:"table=roles"`).
+	Table string
+
+	// ForeignKey is the child's column which references the model (ie:
+	// user_id).
+	ForeignKey string
+
+	// References is the model's column referenced by the foreign key, which
+	// defaults to DefaultReferencedColumn.
+	References string
+}
+
+// WithRelationship registers a child table of the model by name, so a query
+// can compare the columns of the related rows using the name as a prefix:
+// roles.name="admin" is converted to:
+//
+//	exists (select 1 from roles where roles.user_id=users.id and roles.name=?)
+//
+// The referenced column is qualified by the model's table (see WithTableName
+// and WithTableAlias), which is required.  A comparison is true when any
+// related row matches it, so roles.name!="admin" matches users with a role
+// which isn't admin.
+func WithRelationship(name string, r Relationship) Option {
+	const op = "mql.WithRelationship"
+	return func(o *options) error {
+		switch {
+		case name == "":
+			return fmt.Errorf("%s: missing name: %w", op, ErrInvalidParameter)
+		case !isIdentifier(name):
+			return fmt.Errorf("%s: name %q can only contain letters, digits and underscores: %w", op, name, ErrInvalidParameter)
+		case isNil(r.Model):
+			return fmt.Errorf("%s: missing model for %q: %w", op, name, ErrInvalidParameter)
+		case r.ForeignKey == "" || !isIdentifier(r.ForeignKey):
+			return fmt.Errorf("%s: invalid foreign key %q for %q: %w", op, r.ForeignKey, name, ErrInvalidParameter)
+		case !isIdentifier(r.References):
+			return fmt.Errorf("%s: invalid referenced column %q for %q: %w", op, r.References, name, ErrInvalidParameter)
+		case r.Table != "" && !isTableName(r.Table):
+			return fmt.Errorf("%s: invalid table %q for %q: %w", op, r.Table, name, ErrInvalidParameter)
+		}
+		if r.Table == "" {
+			table, err := modelTable(r.Model)
+			switch {
+			case err != nil:
+				return fmt.Errorf("%s: %w", op, err)
+			case table == "":
+				return fmt.Errorf("%s: missing table for %q (see Relationship.Table): %w", op, name, ErrInvalidParameter)
+			}
+			r.Table = table
+		}
+		if r.References == "" {
+			r.References = DefaultReferencedColumn
+		}
+		key := strings.ToLower(name)
+		if o.withRelationships == nil {
+			o.withRelationships = make(map[string]Relationship)
+		}
+		if _, exists := o.withRelationships[key]; exists {
+			return fmt.Errorf("%s: duplicated relationship %q: %w", op, name, ErrInvalidParameter)
+		}
+		o.withRelationships[key] = r
+		return nil
+	}
+}
+
+// relationshipCondition returns the exists subquery which compares the
+// column of the relationship's rows (ie: name for roles.name).  The column is
+// validated using the relationship's model and only the WithModelDescriber and
+// WithDialect options are used for it.  Supported options: WithTableAlias,
+// WithTableName, WithModelDescriber, WithDialect
+func relationshipCondition(name, column string, e *ComparisonExpr, r Relationship, opts options) (*WhereClause, error) {
+	const op = "mql.relationshipCondition"
+	if columnQualifier(opts) == "" {
+		return nil, fmt.Errorf("%s: relationship %q requires the model's table (see WithTableName): %w", op, name, ErrInvalidParameter)
+	}
+	d := dialectOf(opts)
+	referenced := qualifyColumn(r.References, opts)
+	var childOpts []Option
+	if opts.withModelDescriber != nil {
+		childOpts = append(childOpts, WithModelDescriber(opts.withModelDescriber))
+	}
+	if opts.withDialect != nil {
+		childOpts = append(childOpts, WithDialect(opts.withDialect))
+	}
+	fValidators, err := modelValidators(r.Model, childOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	child := *e
+	child.Column = column
+	w, err := exprToWhereClause(&child, fValidators, append(childOpts, withTableQualifier(r.Table))...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: relationship %q: %w", op, name, err)
+	}
+	columns := make([]string, 0, len(w.argColumns))
+	for _, c := range w.argColumns {
+		columns = append(columns, name+"."+c)
+	}
+	return &WhereClause{
+		Condition:  fmt.Sprintf("exists (select 1 from %s where %s=%s and %s)", quoteIdentifiers(d, r.Table), quoteIdentifiers(d, r.Table+"."+r.ForeignKey), referenced, w.Condition),
+		Args:       w.Args,
+		argColumns: columns,
+	}, nil
+}
+
+// withTableQualifier qualifies the columns with the table, which (unlike
+// WithTableAlias) may be qualified by a schema.
+func withTableQualifier(table string) Option {
+	return func(o *options) error {
+		o.withTableAlias = table
+		return nil
+	}
+}
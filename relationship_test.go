@@ -0,0 +1,137 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/mql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type roleModel struct {
+	_         struct{} `mql:"table=roles"`
+	Name      string
+	GrantedAt time.Time
+	Labels    map[string]string
+}
+
+func TestParse_relationship(t *testing.T) {
+	t.Parallel()
+	roles := mql.WithRelationship("roles", mql.Relationship{Model: roleModel{}, ForeignKey: "user_id"})
+	tests := []struct {
+		name            string
+		query           string
+		model           any
+		opts            []mql.Option
+		want            *mql.WhereClause
+		wantErrIs       error
+		wantErrContains string
+	}{
+		{
+			name:  "exists",
+			query: `roles.name="admin"`,
+			model: userModel{},
+			opts:  []mql.Option{roles},
+			want: &mql.WhereClause{
+				Condition: "exists (select 1 from roles where roles.user_id=users.id and roles.name=?)",
+				Args:      []any{"admin"},
+			},
+		},
+		{
+			name:  "combined-WithTableAlias-WithNamedParams",
+			query: `name="alice" and (Roles.name="admin" or roles.labels.env="prod")`,
+			model: userModel{},
+			opts:  []mql.Option{roles, mql.WithTableAlias("u"), mql.WithNamedParams(":")},
+			want: &mql.WhereClause{
+				Condition: "(u.name=:name_1 and (exists (select 1 from roles where roles.user_id=u.id and roles.name=:roles_name_1) or exists (select 1 from roles where roles.user_id=u.id and roles.labels->>:roles_labels_1=:roles_labels_2)))",
+				NamedArgs: map[string]any{"name_1": "alice", "roles_name_1": "admin", "roles_labels_1": "env", "roles_labels_2": "prod"},
+			},
+		},
+		{
+			name:  "table-and-references",
+			query: `member_roles.granted_at>"2023-01-01"`,
+			model: testModel{},
+			opts: []mql.Option{
+				mql.WithTableName("members"),
+				mql.WithRelationship("member_roles", mql.Relationship{
+					Model:      roleModel{},
+					Table:      "auth.member_roles",
+					ForeignKey: "member_number",
+					References: "member_number",
+				}),
+			},
+			want: &mql.WhereClause{
+				Condition: "exists (select 1 from auth.member_roles where auth.member_roles.member_number=members.member_number and auth.member_roles.granted_at>=?)",
+				Args:      []any{time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC)},
+			},
+		},
+		{
+			name:            "err-missing-model-table",
+			query:           `roles.name="admin"`,
+			model:           testModel{},
+			opts:            []mql.Option{roles},
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: `relationship "roles" requires the model's table (see WithTableName)`,
+		},
+		{
+			name:            "err-invalid-column",
+			query:           `roles.email="admin"`,
+			model:           userModel{},
+			opts:            []mql.Option{roles},
+			wantErrIs:       mql.ErrInvalidColumn,
+			wantErrContains: `relationship "roles": mql.exprToWhereClause: invalid column "email"`,
+		},
+		{
+			name:            "err-not-registered",
+			query:           `roles.name="admin"`,
+			model:           userModel{},
+			wantErrIs:       mql.ErrInvalidColumn,
+			wantErrContains: `invalid column "roles.name"`,
+		},
+		{
+			name:            "err-missing-relationship-table",
+			query:           `roles.name="admin"`,
+			model:           userModel{},
+			opts:            []mql.Option{mql.WithRelationship("roles", mql.Relationship{Model: testModel{}, ForeignKey: "user_id"})},
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: `missing table for "roles" (see Relationship.Table)`,
+		},
+		{
+			name:            "err-missing-foreign-key",
+			query:           `roles.name="admin"`,
+			model:           userModel{},
+			opts:            []mql.Option{mql.WithRelationship("roles", mql.Relationship{Model: roleModel{}})},
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: `invalid foreign key "" for "roles"`,
+		},
+		{
+			name:            "err-duplicated",
+			query:           `roles.name="admin"`,
+			model:           userModel{},
+			opts:            []mql.Option{roles, roles},
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: `duplicated relationship "roles"`,
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert, require := assert.New(t), require.New(t)
+			got, err := mql.Parse(tc.query, tc.model, tc.opts...)
+			if tc.wantErrContains != "" {
+				require.Error(err)
+				assert.Empty(got)
+				assert.ErrorIs(err, tc.wantErrIs)
+				assert.ErrorContains(err, tc.wantErrContains)
+				return
+			}
+			require.NoError(err)
+			assert.Equal(tc.want, got)
+		})
+	}
+}
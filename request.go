@@ -0,0 +1,81 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql
+
+import (
+	"context"
+	"fmt"
+)
+
+// ParseRequest contains everything needed to parse a query (see Parse).  New
+// inputs are added as fields, so its callers aren't broken when they are.
+type ParseRequest struct {
+	// Query is the mql query, which can be empty when using
+	// WithAllowEmptyQuery
+	Query string
+	// Model is the database model used to validate the query
+	Model any
+	// Options are the same options as Parse
+	Options []Option
+}
+
+// ParseResult contains everything produced by parsing a query.  New outputs
+// are added as fields, so its callers aren't broken when they are.
+type ParseResult struct {
+	// Where is the where clause of the query
+	Where *WhereClause
+	// AST is the parsed expr tree of the query, which is nil when the query is
+	// empty (see WithAllowEmptyQuery)
+	AST Expr
+	// Diagnostics are the warnings about parts of the query which are valid,
+	// but likely not what the user intended (see Lint)
+	Diagnostics []Diagnostic
+	// Metadata describes what the where clause references (see
+	// WithMetadata), which is nil when the query is empty.  The Metadata of
+	// the Where clause is only set when using WithMetadata.
+	Metadata *ClauseMetadata
+}
+
+// Parse will parse the request's query and use its model to create its where
+// clause, along with its expr tree, diagnostics and metadata.  It stops once
+// the context is canceled or its deadline is exceeded (see ParseContext).
+// Supported options: the same options as Parse.
+func (r ParseRequest) Parse(ctx context.Context) (*ParseResult, error) {
+	const op = "mql.(ParseRequest).Parse"
+	if ctx == nil {
+		return nil, fmt.Errorf("%s: missing context: %w", op, ErrInvalidParameter)
+	}
+	opt := append(r.Options[:len(r.Options):len(r.Options)], withContext(ctx))
+	opts, err := getOpts(opt...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	withMetadata := opts.withMetadata
+	if !withMetadata {
+		opt = append(opt, WithMetadata())
+		opts.withMetadata = true
+	}
+	expr, w, err := observeParse(ctx, r.Query, r.Model, opts, opt...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	result := &ParseResult{Where: w, AST: expr, Metadata: w.Metadata}
+	if !withMetadata {
+		w.Metadata = nil
+		if w.Having != nil {
+			w.Having.Metadata = nil
+		}
+	}
+	if expr == nil {
+		return result, nil
+	}
+	fValidators, err := modelValidators(r.Model, opt...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if result.Diagnostics, err = lintQuery(r.Query, expr, fValidators, opts); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return result, nil
+}
@@ -0,0 +1,91 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/mql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRequest_Parse(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	t.Run("result", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		got, err := mql.ParseRequest{
+			Query:   `(name="alice") and age>21`,
+			Model:   testModel{},
+			Options: []mql.Option{mql.WithPgPlaceholders()},
+		}.Parse(ctx)
+		require.NoError(err)
+		assert.Equal(&mql.WhereClause{Condition: "(name=$1 and age>$2)", Args: []any{"alice", 21}}, got.Where)
+		require.NotNil(got.AST)
+		assert.Equal(`name="alice" and age>21`, got.AST.MQL())
+		require.Len(got.Diagnostics, 1)
+		assert.Equal(mql.RedundantParensDiagnostic, got.Diagnostics[0].Kind)
+		require.NotNil(got.Metadata)
+		assert.Equal([]string{"name", "age"}, got.Metadata.Columns)
+		assert.Equal([]mql.ComparisonOp{mql.EqualOp, mql.GreaterThanOp}, got.Metadata.ComparisonOps)
+	})
+	t.Run("with-metadata", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		got, err := mql.ParseRequest{
+			Query:   `name="alice"`,
+			Model:   testModel{},
+			Options: []mql.Option{mql.WithMetadata()},
+		}.Parse(ctx)
+		require.NoError(err)
+		require.NotNil(got.Metadata)
+		assert.Same(got.Metadata, got.Where.Metadata)
+		assert.Empty(got.Diagnostics)
+	})
+	t.Run("empty-query", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		got, err := mql.ParseRequest{
+			Model:   testModel{},
+			Options: []mql.Option{mql.WithAllowEmptyQuery()},
+		}.Parse(ctx)
+		require.NoError(err)
+		assert.Equal(&mql.ParseResult{Where: &mql.WhereClause{Condition: "1=1"}}, got)
+	})
+	t.Run("same-as-parse", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		opts := []mql.Option{mql.WithNamedParams(":"), mql.WithAggregate("count", "count(*)")}
+		want, err := mql.Parse(`name="alice" and count>1`, testModel{}, opts...)
+		require.NoError(err)
+		got, err := mql.ParseRequest{Query: `name="alice" and count>1`, Model: testModel{}, Options: opts}.Parse(ctx)
+		require.NoError(err)
+		assert.Equal(want, got.Where)
+	})
+	t.Run("errors", func(t *testing.T) {
+		canceled, cancel := context.WithCancel(ctx)
+		cancel()
+		tests := []struct {
+			name      string
+			ctx       context.Context
+			req       mql.ParseRequest
+			wantErrIs error
+		}{
+			{name: "missing-context", req: mql.ParseRequest{Query: `name="alice"`, Model: testModel{}}, wantErrIs: mql.ErrInvalidParameter},
+			{name: "missing-model", ctx: ctx, req: mql.ParseRequest{Query: `name="alice"`}, wantErrIs: mql.ErrInvalidParameter},
+			{name: "missing-query", ctx: ctx, req: mql.ParseRequest{Model: testModel{}}, wantErrIs: mql.ErrInvalidParameter},
+			{name: "invalid-column", ctx: ctx, req: mql.ParseRequest{Query: `nope="alice"`, Model: testModel{}}, wantErrIs: mql.ErrInvalidColumn},
+			{name: "canceled", ctx: canceled, req: mql.ParseRequest{Query: `name="alice"`, Model: testModel{}}, wantErrIs: context.Canceled},
+		}
+		for _, tc := range tests {
+			tc := tc
+			t.Run(tc.name, func(t *testing.T) {
+				assert, require := assert.New(t), require.New(t)
+				got, err := tc.req.Parse(tc.ctx)
+				require.Error(err)
+				assert.ErrorIs(err, tc.wantErrIs)
+				assert.Nil(got)
+			})
+		}
+	})
+}
@@ -0,0 +1,85 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// DefaultSimilarityThreshold is the similarity threshold used by Match and
+// Filter for the ~% operator when no threshold is provided (see
+// WithSimilarityThreshold).  It's the default threshold of the postgres
+// pg_trgm extension.
+const DefaultSimilarityThreshold = 0.3
+
+// similarityCondition returns the where clause which compares the column to
+// the value using the postgres pg_trgm extension: col % ? which uses the
+// database's threshold, or similarity(col, ?) > ? when a threshold is
+// provided.  Dialects without the postgres operators use a case insensitive
+// contains instead (see Dialect.ILike).  Supported options:
+// WithSimilarityThreshold, WithDialect
+func similarityCondition(columnName string, v any, opts options) *WhereClause {
+	d := dialectOf(opts)
+	switch {
+	case !hasPgOperators(d):
+		return &WhereClause{
+			Condition: d.ILike(columnName),
+			Args:      []any{"%" + likeEscaper.Replace(fmt.Sprintf("%s", v)) + "%"},
+		}
+	case opts.withSimilarityThreshold == 0:
+		return &WhereClause{
+			Condition: fmt.Sprintf("%s %% ?", columnName),
+			Args:      []any{v},
+		}
+	}
+	return &WhereClause{
+		Condition: fmt.Sprintf("similarity(%s, ?) > ?", columnName),
+		Args:      []any{v, opts.withSimilarityThreshold},
+	}
+}
+
+// similar reports if the similarity of a and b is greater than the threshold
+// (or the DefaultSimilarityThreshold when it's 0)
+func similar(a, b string, threshold float64) bool {
+	if threshold == 0 {
+		threshold = DefaultSimilarityThreshold
+	}
+	return similarity(a, b) > threshold
+}
+
+// similarity returns the trigram similarity of a and b (between 0 and 1), just
+// like the similarity function of the postgres pg_trgm extension: the number of
+// trigrams they share divided by the number of their distinct trigrams.
+func similarity(a, b string) float64 {
+	ta, tb := trigrams(a), trigrams(b)
+	if len(ta) == 0 || len(tb) == 0 {
+		return 0
+	}
+	shared := 0
+	for t := range ta {
+		if _, ok := tb[t]; ok {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(ta)+len(tb)-shared)
+}
+
+// trigrams returns the set of trigrams of s, where every word (a sequence of
+// letters and digits) is lowercased and padded with two spaces before it and
+// one space after it (like pg_trgm).
+func trigrams(s string) map[string]struct{} {
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	t := make(map[string]struct{})
+	for _, w := range words {
+		padded := []rune("  " + w + " ")
+		for i := 0; i+3 <= len(padded); i++ {
+			t[string(padded[i:i+3])] = struct{}{}
+		}
+	}
+	return t
+}
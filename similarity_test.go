@@ -0,0 +1,103 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql_test
+
+import (
+	"testing"
+
+	"github.com/hashicorp/mql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse_similarity(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name            string
+		query           string
+		opts            []mql.Option
+		want            *mql.WhereClause
+		wantErrIs       error
+		wantErrContains string
+	}{
+		{
+			name:  "similar",
+			query: `name ~% "alise" or labels.team~%"enginering"`,
+			want: &mql.WhereClause{
+				Condition: "(name % ? or labels->>? % ?)",
+				Args:      []any{"alise", "team", "enginering"},
+			},
+		},
+		{
+			name:  "WithSimilarityThreshold",
+			query: `name ~% "alise" or labels.team~%"enginering"`,
+			opts:  []mql.Option{mql.WithSimilarityThreshold(0.4)},
+			want: &mql.WhereClause{
+				Condition: "(similarity(name, ?) > ? or similarity(labels->>?, ?) > ?)",
+				Args:      []any{"alise", 0.4, "team", "enginering", 0.4},
+			},
+		},
+		{
+			name:            "err-not-a-string",
+			query:           `age ~% "21"`,
+			wantErrIs:       mql.ErrInvalidComparisonOp,
+			wantErrContains: `invalid comparison operator "~%" for column "age" (only supported for string columns)`,
+		},
+		{
+			name:            "err-map-not-a-string",
+			query:           `scores.math ~% "21"`,
+			wantErrIs:       mql.ErrInvalidComparisonOp,
+			wantErrContains: `invalid comparison operator "~%" for column scores.math (only supported for string columns)`,
+		},
+		{
+			name:            "err-invalid-threshold",
+			query:           `name ~% "alise"`,
+			opts:            []mql.Option{mql.WithSimilarityThreshold(1.5)},
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: "threshold 1.5 must be greater than 0 and at most 1",
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert, require := assert.New(t), require.New(t)
+			got, err := mql.Parse(tc.query, testModel{}, tc.opts...)
+			if tc.wantErrContains != "" {
+				require.Error(err)
+				assert.Empty(got)
+				assert.ErrorIs(err, tc.wantErrIs)
+				assert.ErrorContains(err, tc.wantErrContains)
+				return
+			}
+			require.NoError(err)
+			assert.Equal(tc.want, got)
+		})
+	}
+}
+
+func TestMatch_similarity(t *testing.T) {
+	t.Parallel()
+	item := testModel{Name: "Alice", Labels: map[string]string{"team": "engineering"}}
+	tests := []struct {
+		query string
+		opts  []mql.Option
+		want  bool
+	}{
+		{query: `name ~% "alice"`, want: true},
+		{query: `name ~% "alise"`, want: true},
+		{query: `name ~% "alise"`, opts: []mql.Option{mql.WithSimilarityThreshold(0.4)}, want: false},
+		{query: `name ~% "bob"`, want: false},
+		{query: `labels.team ~% "enginering"`, want: true},
+		{query: `labels.missing ~% "enginering"`, want: false},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.query, func(t *testing.T) {
+			got, err := mql.Match(tc.query, item, tc.opts...)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
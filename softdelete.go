@@ -0,0 +1,67 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql
+
+import (
+	"fmt"
+	"strings"
+)
+
+// WithSoftDelete provides the soft delete column of the model (ie:
+// deleted_at), so the where clause only matches the rows which aren't
+// deleted: name="alice" is converted to: (name=? and deleted_at is null).  The
+// condition isn't added when the query compares the column, so users can
+// still query the deleted rows (ie: deleted_at>"2024-01-01").  An empty query
+// (see WithAllowEmptyQuery) is converted to: deleted_at is null.  The
+// condition doesn't have any args, so it doesn't change the placeholders of
+// the where clause.
+func WithSoftDelete(column string) Option {
+	const op = "mql.WithSoftDelete"
+	return func(o *options) error {
+		if !isIdentifier(column) {
+			return fmt.Errorf("%s: column %q isn't an identifier: %w", op, column, ErrInvalidParameter)
+		}
+		o.withSoftDelete = column
+		return nil
+	}
+}
+
+// softDeleteCondition returns the where clause of the expr (which is nil for
+// an empty query) along with the soft delete condition of the model, unless
+// the expr compares the soft delete column.  The where clause is returned as
+// is without WithSoftDelete.  Supported options: WithSoftDelete,
+// WithColumnMap, WithTableAlias, WithTableName, WithDialect
+func softDeleteCondition(w *WhereClause, e Expr, model any, opts options) (*WhereClause, error) {
+	const op = "mql.softDeleteCondition"
+	if opts.withSoftDelete == "" {
+		return w, nil
+	}
+	deleted := strings.ToLower(strings.ReplaceAll(opts.withSoftDelete, "_", ""))
+	var compared bool
+	walkExpr(e, func(e Expr) {
+		if c, ok := e.(*ComparisonExpr); ok {
+			columnName := strings.ToLower(c.Column)
+			if n, ok := opts.withColumnMap[columnName]; ok {
+				columnName = n
+			}
+			compared = compared || strings.ToLower(strings.ReplaceAll(columnName, "_", "")) == deleted
+		}
+	})
+	if compared {
+		return w, nil
+	}
+	if opts.withModelTable == "" && !isNil(model) {
+		table, err := modelTable(model)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		opts.withModelTable = table
+	}
+	condition := qualifyColumn(opts.withSoftDelete, opts) + " is null"
+	if w.Condition != matchAllCondition {
+		condition = "(" + w.Condition + " and " + condition + ")"
+	}
+	w.Condition = condition
+	return w, nil
+}
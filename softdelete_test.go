@@ -0,0 +1,107 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql_test
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/mql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type softDeleteModel struct {
+	Name      string
+	DeletedAt *time.Time
+}
+
+func TestParse_WithSoftDelete(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name  string
+		query string
+		opts  []mql.Option
+		want  *mql.WhereClause
+	}{
+		{
+			name:  "appended",
+			query: `name="alice" or name="bob"`,
+			opts:  []mql.Option{mql.WithPgPlaceholders()},
+			want: &mql.WhereClause{
+				Condition: "((name=$1 or name=$2) and deleted_at is null)",
+				Args:      []any{"alice", "bob"},
+			},
+		},
+		{
+			name:  "column-compared",
+			query: `name="alice" and deleted_at>"2024-01-01"`,
+			want: &mql.WhereClause{
+				Condition: "(name=? and deleted_at>=?)",
+				Args:      []any{"alice", time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+			},
+		},
+		{
+			name:  "mapped-column-compared",
+			query: `removed<now`,
+			opts:  []mql.Option{mql.WithColumnMap(map[string]string{"removed": "deleted_at"})},
+		},
+		{
+			name:  "empty-query",
+			query: ` `,
+			opts:  []mql.Option{mql.WithAllowEmptyQuery()},
+			want:  &mql.WhereClause{Condition: "deleted_at is null"},
+		},
+		{
+			name:  "qualified",
+			query: `name="alice"`,
+			opts:  []mql.Option{mql.WithDialect(mql.PostgresDialect{}), mql.WithPgPlaceholders(), mql.WithTableAlias("a")},
+			want:  &mql.WhereClause{Condition: `("a"."name"=$1 and "a"."deleted_at" is null)`, Args: []any{"alice"}},
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert, require := assert.New(t), require.New(t)
+			got, err := mql.Parse(tc.query, softDeleteModel{}, append(tc.opts, mql.WithSoftDelete("deleted_at"))...)
+			require.NoError(err)
+			if tc.want == nil {
+				assert.NotContains(got.Condition, "is null")
+				return
+			}
+			assert.Equal(tc.want, got)
+		})
+	}
+	t.Run("json-api", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		got, err := mql.ParseJsonApiFilter(url.Values{}, softDeleteModel{}, mql.WithAllowEmptyQuery(), mql.WithSoftDelete("deleted_at"))
+		require.NoError(err)
+		assert.Equal(&mql.WhereClause{Condition: "deleted_at is null"}, got)
+	})
+	t.Run("list-query", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		q, err := mql.ParseListRequest(mql.ListRequest{Filter: `name="alice"`}, softDeleteModel{}, mql.WithSoftDelete("deleted_at"))
+		require.NoError(err)
+		assert.Equal("(name=? and deleted_at is null)", q.Where.Condition)
+		q, err = mql.ParseListRequest(mql.ListRequest{}, softDeleteModel{}, mql.WithSoftDelete("deleted_at"))
+		require.NoError(err)
+		assert.Equal("deleted_at is null", q.Where.Condition)
+	})
+	t.Run("model-table", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		type accountModel struct {
+			_    struct{} `mql:"table=accounts"`
+			Name string
+		}
+		got, err := mql.Parse(``, accountModel{}, mql.WithAllowEmptyQuery(), mql.WithSoftDelete("deleted_at"))
+		require.NoError(err)
+		assert.Equal(&mql.WhereClause{Condition: "accounts.deleted_at is null"}, got)
+	})
+	t.Run("err-column", func(t *testing.T) {
+		_, err := mql.Parse(`name="alice"`, softDeleteModel{}, mql.WithSoftDelete("deleted at"))
+		assert.ErrorIs(t, err, mql.ErrInvalidParameter)
+	})
+}
@@ -20,14 +20,6 @@ func (s *stack[T]) pop() (T, bool) {
 	return x, false
 }
 
-func (s *stack[T]) clear() {
-	s.data = nil
-}
-
 func (s *stack[T]) len() int {
 	return len(s.data)
 }
-
-func runesToString(s stack[rune]) string {
-	return string(s.data)
-}
@@ -0,0 +1,92 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// collationRegexp matches the collations of WithStringRangeCollation: a name
+// which is optionally double quoted (ie: "C" or utf8mb4_bin)
+var collationRegexp = regexp.MustCompile(`^(?:"[A-Za-z0-9_.@-]+"|[A-Za-z0-9_.@-]+)$`)
+
+// WithoutStringRanges will reject the range comparisons (>, >=, < and <=) of
+// the string columns (database column or model field name) with an
+// ErrInvalidComparisonOp, or of every string column when no columns are
+// provided.  Range comparisons of strings are lexicographic using the
+// database's collation, which may not be what users expect (ie: "Z" < "a"
+// with a binary collation).
+func WithoutStringRanges(columns ...string) Option {
+	const op = "mql.WithoutStringRanges"
+	return func(o *options) error {
+		if len(columns) == 0 {
+			o.withoutAllStringRanges = true
+			return nil
+		}
+		if o.withoutStringRanges == nil {
+			o.withoutStringRanges = make(map[string]struct{}, len(columns))
+		}
+		for _, c := range columns {
+			if c == "" {
+				return fmt.Errorf("%s: missing column: %w", op, ErrInvalidParameter)
+			}
+			o.withoutStringRanges[strings.ToLower(strings.ReplaceAll(c, "_", ""))] = struct{}{}
+		}
+		return nil
+	}
+}
+
+// WithStringRangeCollation provides an optional collation for the range
+// comparisons (>, >=, < and <=) of string columns, so their order doesn't
+// depend on the database's default collation: name>="m" is converted to:
+// name COLLATE "C">=?.  The same collation is used for the string columns of
+// order by clauses (see ParseOrderBy) and keyset conditions (see
+// KeysetCondition), so keyset pagination over text keys is deterministic.
+// The collation is used as is, so it must be quoted when the database
+// requires it (ie: "C" for postgres, utf8mb4_bin for mysql and BINARY for
+// sqlite).  A binary collation orders strings the same way as Match and
+// Filter, which compare them byte by byte.
+func WithStringRangeCollation(collation string) Option {
+	const op = "mql.WithStringRangeCollation"
+	return func(o *options) error {
+		if !collationRegexp.MatchString(collation) {
+			return fmt.Errorf("%s: invalid collation %q: %w", op, collation, ErrInvalidParameter)
+		}
+		o.withStringRangeCollation = collation
+		return nil
+	}
+}
+
+// isRangeOp reports if the comparison operator is a range comparison
+func isRangeOp(o ComparisonOp) bool {
+	switch o {
+	case GreaterThanOp, GreaterThanOrEqualOp, LessThanOp, LessThanOrEqualOp:
+		return true
+	default:
+		return false
+	}
+}
+
+// stringRangeColumn validates the range comparison of a string column and
+// returns its column, which uses the collation of the range comparisons.
+// Supported options: WithoutStringRanges, WithStringRangeCollation
+func stringRangeColumn(columnName string, comparisonOp ComparisonOp, v validator, opts options) (string, error) {
+	const op = "mql.stringRangeColumn"
+	_, disabled := opts.withoutStringRanges[strings.ToLower(strings.ReplaceAll(v.field.Name, "_", ""))]
+	if disabled || opts.withoutAllStringRanges {
+		return "", fmt.Errorf("%s: %w %q for string column %q (range comparisons of strings are disabled)", op, ErrInvalidComparisonOp, comparisonOp, columnName)
+	}
+	return collate(columnName, opts), nil
+}
+
+// collate returns the column using the collation of WithStringRangeCollation
+// or the column itself when there isn't one
+func collate(columnName string, opts options) string {
+	if opts.withStringRangeCollation == "" {
+		return columnName
+	}
+	return columnName + " COLLATE " + opts.withStringRangeCollation
+}
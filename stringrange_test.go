@@ -0,0 +1,124 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql_test
+
+import (
+	"testing"
+
+	"github.com/hashicorp/mql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse_stringRanges(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name            string
+		query           string
+		opts            []mql.Option
+		want            *mql.WhereClause
+		wantErrIs       error
+		wantErrContains string
+	}{
+		{
+			name:  "lexicographic-by-default",
+			query: `name>="m" and name<"n"`,
+			want:  &mql.WhereClause{Condition: "(name>=? and name<?)", Args: []any{"m", "n"}},
+		},
+		{
+			name:  "WithStringRangeCollation",
+			query: `name>="m" and name="alice" and age>21`,
+			opts:  []mql.Option{mql.WithStringRangeCollation(`"C"`)},
+			want:  &mql.WhereClause{Condition: `((name COLLATE "C">=? and name=?) and age>?)`, Args: []any{"m", "alice", 21}},
+		},
+		{
+			name:  "WithStringRangeCollation-qualified",
+			query: `email<"m"`,
+			opts:  []mql.Option{mql.WithStringRangeCollation("utf8mb4_bin"), mql.WithTableName("users")},
+			want:  &mql.WhereClause{Condition: "users.email COLLATE utf8mb4_bin<?", Args: []any{"m"}},
+		},
+		{
+			name:  "WithoutStringRanges-other-ops",
+			query: `name="alice" and name%"ali" and age>21`,
+			opts:  []mql.Option{mql.WithoutStringRanges()},
+			want:  &mql.WhereClause{Condition: `((name=? and name like ? escape '\') and age>?)`, Args: []any{"alice", "%ali%", 21}},
+		},
+		{
+			name:  "WithoutStringRanges-other-columns",
+			query: `name>"m"`,
+			opts:  []mql.Option{mql.WithoutStringRanges("email")},
+			want:  &mql.WhereClause{Condition: "name>?", Args: []any{"m"}},
+		},
+		{
+			name:            "err-WithoutStringRanges",
+			query:           `name>"m"`,
+			opts:            []mql.Option{mql.WithoutStringRanges()},
+			wantErrIs:       mql.ErrInvalidComparisonOp,
+			wantErrContains: `">" for string column "name" (range comparisons of strings are disabled)`,
+		},
+		{
+			name:            "err-WithoutStringRanges-column",
+			query:           `email<="m"`,
+			opts:            []mql.Option{mql.WithoutStringRanges("email")},
+			wantErrIs:       mql.ErrInvalidComparisonOp,
+			wantErrContains: `"<=" for string column "email"`,
+		},
+		{
+			name:      "err-WithoutStringRanges-missing-column",
+			query:     `name>"m"`,
+			opts:      []mql.Option{mql.WithoutStringRanges("")},
+			wantErrIs: mql.ErrInvalidParameter,
+		},
+		{
+			name:      "err-invalid-collation",
+			query:     `name>"m"`,
+			opts:      []mql.Option{mql.WithStringRangeCollation(`"C"; drop table users`)},
+			wantErrIs: mql.ErrInvalidParameter,
+		},
+		{
+			name:      "err-missing-collation",
+			query:     `name>"m"`,
+			opts:      []mql.Option{mql.WithStringRangeCollation("")},
+			wantErrIs: mql.ErrInvalidParameter,
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert, require := assert.New(t), require.New(t)
+			got, err := mql.Parse(tc.query, testModel{}, tc.opts...)
+			if tc.wantErrIs != nil {
+				require.Error(err)
+				assert.ErrorIs(err, tc.wantErrIs)
+				if tc.wantErrContains != "" {
+					assert.ErrorContains(err, tc.wantErrContains)
+				}
+				assert.Nil(got)
+				return
+			}
+			require.NoError(err)
+			assert.Equal(tc.want, got)
+		})
+	}
+	t.Run("keyset-pagination", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		opt := mql.WithStringRangeCollation(`"C"`)
+		o, err := mql.ParseOrderBy("name desc, id", testModel{}, opt)
+		require.NoError(err)
+		assert.Equal(`name COLLATE "C" desc, id asc`, o.Clause)
+		c, err := mql.EncodeCursor("bob", 7)
+		require.NoError(err)
+		got, err := mql.KeysetCondition(o, c, testModel{}, opt)
+		require.NoError(err)
+		assert.Equal(&mql.WhereClause{
+			Condition: `(name COLLATE "C"<? or (name COLLATE "C"=? and id>?))`,
+			Args:      []any{"bob", "bob", 7},
+		}, got)
+	})
+	t.Run("ValidateOptions", func(t *testing.T) {
+		err := mql.ValidateOptions(testModel{}, mql.WithoutStringRanges("nickname"))
+		assert.ErrorContains(t, err, `WithoutStringRanges column "nickname" isn't a column of the model`)
+	})
+}
@@ -0,0 +1,109 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+const (
+	// tagName is the struct tag used to describe the model (ie: `mql:"table=users"`)
+	tagName = "mql"
+
+	// tableTagKey is the key of the tag which defines the model's table name
+	tableTagKey = "table"
+)
+
+// qualifyColumn returns the column prefixed with the table alias or the
+// table name (if one was provided), which are quoted using the dialect.  The
+// alias is used before the name provided via WithTableName, which is used
+// before the model's table tag.  Supported options: WithTableAlias,
+// WithTableName, WithDialect
+func qualifyColumn(columnName string, opts options) string {
+	d := dialectOf(opts)
+	if table := columnQualifier(opts); table != "" {
+		return quoteIdentifiers(d, table) + "." + d.QuoteIdentifier(columnName)
+	}
+	return d.QuoteIdentifier(columnName)
+}
+
+// columnQualifier returns the table alias or table name used to qualify
+// columns (see qualifyColumn) and it's empty when there isn't one.
+func columnQualifier(opts options) string {
+	switch {
+	case opts.withTableAlias != "":
+		return opts.withTableAlias
+	case opts.withTableName != "":
+		return opts.withTableName
+	default:
+		return opts.withModelTable
+	}
+}
+
+// withModelTable returns the options along with an option which sets the
+// table name defined by the model's struct tag (see modelTable).  The options
+// are returned unchanged when the model doesn't have a table tag.
+func withModelTable(model any, opt []Option) ([]Option, error) {
+	const op = "mql.withModelTable"
+	table, err := modelTable(model)
+	switch {
+	case err != nil:
+		return nil, fmt.Errorf("%s: %w", op, err)
+	case table == "":
+		return opt, nil
+	}
+	// copied, so the caller's options are never modified
+	withTable := make([]Option, 0, len(opt)+1)
+	withTable = append(withTable, opt...)
+	return append(withTable, func(o *options) error {
+		o.withModelTable = table
+		return nil
+	}), nil
+}
+
+// modelTable returns the table name defined by the model's `mql:"table=..."`
+// struct tag, which can be set on any field (ie: _ struct{}
+// `mql:"table=users"`).  It returns an empty name when the model doesn't have
+// a table tag.
+func modelTable(model any) (string, error) {
+	const op = "mql.modelTable"
+	t := reflect.TypeOf(model)
+	if t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return "", nil
+	}
+	for i := 0; i < t.NumField(); i++ {
+		tag, ok := t.Field(i).Tag.Lookup(tagName)
+		if !ok {
+			continue
+		}
+		for _, kv := range strings.Split(tag, ",") {
+			k, v, _ := strings.Cut(strings.TrimSpace(kv), "=")
+			if k != tableTagKey {
+				continue
+			}
+			if !isTableName(v) {
+				return "", fmt.Errorf("%s: table %q of field %q is not a valid table name: %w", op, v, t.Field(i).Name, ErrInvalidParameter)
+			}
+			return v, nil
+		}
+	}
+	return "", nil
+}
+
+// isTableName reports if s is a table name, which may be qualified by a
+// schema (ie: public.users) and whose parts only contain letters, digits and
+// underscores.
+func isTableName(s string) bool {
+	for _, part := range strings.Split(s, ".") {
+		if part == "" || !isIdentifier(part) {
+			return false
+		}
+	}
+	return true
+}
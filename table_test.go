@@ -0,0 +1,132 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql_test
+
+import (
+	"testing"
+
+	"github.com/hashicorp/mql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type userModel struct {
+	_      struct{} `mql:"table=users"`
+	ID     uint
+	Name   string
+	Labels map[string]string
+}
+
+type invalidTableModel struct {
+	_    struct{} `mql:"table=users; drop table users"`
+	Name string
+}
+
+func TestParse_table(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name            string
+		query           string
+		model           any
+		opts            []mql.Option
+		want            *mql.WhereClause
+		wantErrIs       error
+		wantErrContains string
+	}{
+		{
+			name:  "table-tag",
+			query: `name="alice" and labels.env="prod"`,
+			model: userModel{},
+			want: &mql.WhereClause{
+				Condition: "(users.name=? and users.labels->>?=?)",
+				Args:      []any{"alice", "env", "prod"},
+			},
+		},
+		{
+			name:  "WithTableName",
+			query: `name="alice"`,
+			model: testModel{},
+			opts:  []mql.Option{mql.WithTableName("public.users")},
+			want:  &mql.WhereClause{Condition: "public.users.name=?", Args: []any{"alice"}},
+		},
+		{
+			name:  "WithTableName-overrides-table-tag",
+			query: `name="alice"`,
+			model: &userModel{},
+			opts:  []mql.Option{mql.WithTableName("active_users")},
+			want:  &mql.WhereClause{Condition: "active_users.name=?", Args: []any{"alice"}},
+		},
+		{
+			name:  "WithTableAlias-overrides-WithTableName",
+			query: `name="alice"`,
+			model: userModel{},
+			opts:  []mql.Option{mql.WithTableName("active_users"), mql.WithTableAlias("u")},
+			want:  &mql.WhereClause{Condition: "u.name=?", Args: []any{"alice"}},
+		},
+		{
+			name:            "err-blank-field-in-query",
+			query:           `_="alice"`,
+			model:           userModel{},
+			wantErrIs:       mql.ErrInvalidColumn,
+			wantErrContains: `invalid column "_"`,
+		},
+		{
+			name:            "err-invalid-table-tag",
+			query:           `name="alice"`,
+			model:           invalidTableModel{},
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: `table "users; drop table users" of field "_" is not a valid table name`,
+		},
+		{
+			name:            "err-WithTableName-invalid",
+			query:           `name="alice"`,
+			model:           testModel{},
+			opts:            []mql.Option{mql.WithTableName("public..users")},
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: `table "public..users" can only contain letters, digits and underscores`,
+		},
+		{
+			name:            "err-WithTableName-missing",
+			query:           `name="alice"`,
+			model:           testModel{},
+			opts:            []mql.Option{mql.WithTableName("")},
+			wantErrIs:       mql.ErrInvalidParameter,
+			wantErrContains: "missing table",
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert, require := assert.New(t), require.New(t)
+			got, err := mql.Parse(tc.query, tc.model, tc.opts...)
+			if tc.wantErrContains != "" {
+				require.Error(err)
+				assert.Empty(got)
+				assert.ErrorIs(err, tc.wantErrIs)
+				assert.ErrorContains(err, tc.wantErrContains)
+				return
+			}
+			require.NoError(err)
+			assert.Equal(tc.want, got)
+		})
+	}
+}
+
+func TestParseOrderBy_table(t *testing.T) {
+	t.Parallel()
+	assert, require := assert.New(t), require.New(t)
+	o, err := mql.ParseOrderBy("name desc", userModel{})
+	require.NoError(err)
+	assert.Equal(&mql.OrderByClause{
+		Clause:   "users.name desc",
+		SortKeys: []mql.SortKey{{Column: "name", Direction: mql.DescendingSort}},
+	}, o)
+
+	c, err := mql.EncodeCursor("bob")
+	require.NoError(err)
+	k, err := mql.KeysetCondition(o, c, userModel{})
+	require.NoError(err)
+	assert.Equal(&mql.WhereClause{Condition: "users.name<?", Args: []any{"bob"}}, k)
+}
@@ -0,0 +1,55 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package cockroach
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/stretchr/testify/require"
+)
+
+type user struct {
+	ID        uint
+	Name      string
+	Email     *string
+	Age       uint8
+	Labels    map[string]string
+	CreatedAt time.Time
+}
+
+const (
+	testDbDsn                 = "postgresql://root@localhost:9921/defaultdb?sslmode=disable"
+	testCreateTablesCockroach = `
+	DROP TABLE IF EXISTS "users";
+	CREATE TABLE "users" (
+		"id" int8,
+		"name" text,
+		"email" text,
+		"age" int2,
+		"labels" jsonb,
+		"created_at" timestamptz,
+		PRIMARY KEY ("id")
+		)`
+	testInsertUser = `insert into "users" ("id", "name", "email", "age", "labels", "created_at") values ($1, $2, $3, $4, $5, $6)`
+)
+
+// setupDB connects to the database (see DB_DSN) and creates the users table
+func setupDB(t *testing.T) *sql.DB {
+	t.Helper()
+	dsn := os.Getenv("DB_DSN")
+	if dsn == "" {
+		dsn = testDbDsn
+	}
+	db, err := sql.Open("pgx", dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	_, err = db.ExecContext(context.Background(), testCreateTablesCockroach)
+	require.NoError(t, err)
+	return db
+}
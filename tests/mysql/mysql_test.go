@@ -0,0 +1,149 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mysql
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-dbw"
+	"github.com/hashicorp/mql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gormMySql "gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func Test_mysql(t *testing.T) {
+	t.Parallel()
+	testCtx := context.Background()
+	db := setupDB(t)
+	rw := dbw.New(db)
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	one := &user{ID: 1, Name: "one", Email: pointer("one@example.com"), Age: 1, CreatedAt: now.Add(1 * 24 * time.Hour)}
+	two := &user{ID: 2, Name: "Two_%", Email: pointer("two@example.com"), Age: 2, CreatedAt: now.Add(2 * 24 * time.Hour)}
+	testInsertUser(t, rw, one)
+	testInsertUser(t, rw, two)
+	tests := []struct {
+		name  string
+		query string
+		opts  []mql.Option
+		want  []*user
+	}{
+		{
+			name:  "simple",
+			query: `name="one" and age>0`,
+			want:  []*user{one},
+		},
+		{
+			// the default collation ignores case
+			name:  "contains-ignores-case",
+			query: `name%"O"`,
+			want:  []*user{one, two},
+		},
+		{
+			name:  "contains-escaped-wildcards",
+			query: `name%"_%"`,
+			want:  []*user{two},
+		},
+		{
+			name:  "similar",
+			query: `name~%"TWO"`,
+			want:  []*user{two},
+		},
+		{
+			name:  "glob",
+			query: `name="t*"`,
+			opts:  []mql.Option{mql.WithGlobPatterns()},
+			want:  []*user{two},
+		},
+		{
+			name:  "date",
+			query: fmt.Sprintf(`created_at>%s`, now.Add(1*24*time.Hour).Format("2006-01-02")),
+			want:  []*user{two},
+		},
+		{
+			name:  "time",
+			query: fmt.Sprintf(`created_at>"%s"`, now.Add(36*time.Hour).Format(time.RFC3339)),
+			want:  []*user{two},
+		},
+		{
+			name:  "WithTableName",
+			query: `name="one"`,
+			opts:  []mql.Option{mql.WithTableName("users")},
+			want:  []*user{one},
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			assert, require := assert.New(t), require.New(t)
+			where, err := mql.Parse(tc.query, user{}, append(tc.opts, mql.WithDialect(mql.MySqlDialect{}))...)
+			require.NoError(err)
+			{
+				// test dbw
+				var found []*user
+				err = rw.SearchWhere(testCtx, &found, where.Condition, where.Args, dbw.WithOrder("id"))
+				require.NoError(err)
+				assert.Equal(tc.want, utc(found))
+			}
+			{
+				// test gorm
+				var found []*user
+				sqlDB, err := db.SqlDB(testCtx)
+				require.NoError(err)
+				gormDB, err := gorm.Open(gormMySql.New(gormMySql.Config{
+					Conn:                      sqlDB,
+					SkipInitializeWithVersion: true,
+				}), &gorm.Config{})
+				require.NoError(err)
+				err = gormDB.Where(where.Condition, where.Args...).Order("id").Find(&found).Error
+				require.NoError(err)
+				assert.Equal(tc.want, utc(found))
+			}
+			{
+				// test stdlib
+				var found []*user
+				d, err := db.SqlDB(testCtx)
+				require.NoError(err)
+				q := fmt.Sprintf("select id, name, email, age, birthday, created_at from users where %s order by id", where.Condition)
+				rows, err := d.QueryContext(testCtx, q, where.Args...)
+				require.NoError(err)
+				defer rows.Close()
+
+				for rows.Next() {
+					var u user
+					err := rows.Scan(
+						&u.ID,
+						&u.Name,
+						&u.Email,
+						&u.Age,
+						&u.Birthday,
+						&u.CreatedAt,
+					)
+					require.NoError(err)
+					found = append(found, &u)
+				}
+				require.NoError(rows.Err())
+				assert.Equal(tc.want, utc(found))
+			}
+		})
+	}
+}
+
+// utc returns the users with their times in UTC, so they can be compared with
+// the inserted users
+func utc(users []*user) []*user {
+	for _, u := range users {
+		u.CreatedAt = u.CreatedAt.UTC()
+	}
+	return users
+}
+
+func pointer[T any](input T) *T {
+	ret := input
+	return &ret
+}
@@ -0,0 +1,67 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mysql
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-dbw"
+	"github.com/stretchr/testify/require"
+	gormMySql "gorm.io/driver/mysql"
+)
+
+type user struct {
+	ID        uint
+	Name      string
+	Email     *string
+	Age       uint8
+	Birthday  *time.Time
+	CreatedAt time.Time
+}
+
+func testInsertUser(t *testing.T, rw dbw.Writer, u *user) {
+	t.Helper()
+	testCtx := context.Background()
+	require.NoError(t, rw.Create(testCtx, u))
+}
+
+const (
+	testDbDsn             = "go_db:go_db@tcp(localhost:9922)/go_db?parseTime=true&loc=UTC"
+	testCreateTablesMySql = `
+	CREATE TABLE users (
+		id bigint auto_increment,
+		name varchar(255),
+		email varchar(255),
+		age smallint,
+		birthday datetime(6),
+		created_at datetime(6),
+		PRIMARY KEY (id)
+		)`
+)
+
+// setupDB connects to the database (see DB_DSN) and creates the users table.
+// dbw doesn't support mysql connection urls, so the gorm mysql dialector is
+// used to open the database.
+func setupDB(t *testing.T) *dbw.DB {
+	t.Helper()
+	dsn := os.Getenv("DB_DSN")
+	if dsn == "" {
+		dsn = testDbDsn
+	}
+	db, err := dbw.OpenWith(gormMySql.Open(dsn))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close(context.Background()) })
+	if os.Getenv("DEBUG") != "" {
+		db.Debug(true)
+	}
+	rw := dbw.New(db)
+	_, err = rw.Exec(context.Background(), "DROP TABLE IF EXISTS users", nil)
+	require.NoError(t, err)
+	_, err = rw.Exec(context.Background(), testCreateTablesMySql, nil)
+	require.NoError(t, err)
+	return db
+}
@@ -0,0 +1,125 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package sqlite
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/mql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_sqlite(t *testing.T) {
+	t.Parallel()
+	testCtx := context.Background()
+	db := setupDB(t)
+	now := time.Now().UTC().Truncate(time.Second)
+	one := &user{ID: 1, Name: "one", Email: pointer("one@example.com"), Age: 1, Active: true, Labels: map[string]string{"env": "prod"}, Scores: map[string]int{"tier": 1}, CreatedAt: now.Add(1 * 24 * time.Hour)}
+	two := &user{ID: 2, Name: "two_%", Email: pointer("two@example.com"), Age: 2, Labels: map[string]string{"env": "dev"}, Scores: map[string]int{"tier": 10}, CreatedAt: now.Add(2 * 24 * time.Hour)}
+	for _, u := range []*user{one, two} {
+		labels, err := json.Marshal(u.Labels)
+		require.NoError(t, err)
+		scores, err := json.Marshal(u.Scores)
+		require.NoError(t, err)
+		_, err = db.ExecContext(testCtx, testInsertUser, u.ID, u.Name, u.Email, u.Age, u.Active, string(labels), string(scores), u.CreatedAt)
+		require.NoError(t, err)
+	}
+	tests := []struct {
+		name  string
+		query string
+		opts  []mql.Option
+		want  []*user
+	}{
+		{
+			name:  "simple",
+			query: `name="one" and age>0`,
+			want:  []*user{one},
+		},
+		{
+			name:  "contains",
+			query: `name%"O"`,
+			want:  []*user{one, two},
+		},
+		{
+			name:  "contains-escaped-wildcards",
+			query: `name%"_%"`,
+			want:  []*user{two},
+		},
+		{
+			name:  "similar",
+			query: `name~%"ON"`,
+			want:  []*user{one},
+		},
+		{
+			name:  "glob",
+			query: `name="t*"`,
+			opts:  []mql.Option{mql.WithGlobPatterns()},
+			want:  []*user{two},
+		},
+		{
+			name:  "bool",
+			query: `active=true`,
+			want:  []*user{one},
+		},
+		{
+			name:  "map",
+			query: `labels.env="prod" or age>=2`,
+			want:  []*user{one, two},
+		},
+		{
+			name:  "map-int",
+			query: `scores.tier>2`,
+			want:  []*user{two},
+		},
+		{
+			name:  "time",
+			query: fmt.Sprintf(`created_at>"%s"`, now.Add(36*time.Hour).Format(time.RFC3339)),
+			want:  []*user{two},
+		},
+		{
+			name:  "WithTableName",
+			query: `name="one"`,
+			opts:  []mql.Option{mql.WithTableName("users")},
+			want:  []*user{one},
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			assert, require := assert.New(t), require.New(t)
+			where, err := mql.Parse(tc.query, user{}, append(tc.opts, mql.WithDialect(mql.SqliteDialect{}))...)
+			require.NoError(err)
+			q := fmt.Sprintf(`select "id", "name", "email", "age", "active", "labels", "scores", "created_at" from "users" where %s order by "id"`, where.Condition)
+			rows, err := db.QueryContext(testCtx, q, where.Args...)
+			require.NoError(err)
+			defer rows.Close()
+
+			var found []*user
+			for rows.Next() {
+				var (
+					u      user
+					labels string
+					scores string
+				)
+				require.NoError(rows.Scan(&u.ID, &u.Name, &u.Email, &u.Age, &u.Active, &labels, &scores, &u.CreatedAt))
+				require.NoError(json.Unmarshal([]byte(labels), &u.Labels))
+				require.NoError(json.Unmarshal([]byte(scores), &u.Scores))
+				u.CreatedAt = u.CreatedAt.UTC()
+				found = append(found, &u)
+			}
+			require.NoError(rows.Err())
+			assert.Equal(tc.want, found)
+		})
+	}
+}
+
+func pointer[T any](input T) *T {
+	ret := input
+	return &ret
+}
@@ -0,0 +1,60 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+)
+
+type user struct {
+	ID        uint
+	Name      string
+	Email     *string
+	Age       uint8
+	Active    bool
+	Labels    map[string]string
+	Scores    map[string]int
+	CreatedAt time.Time
+}
+
+const (
+	testCreateTablesSqlite = `
+	DROP TABLE IF EXISTS "users";
+	CREATE TABLE "users" (
+		"id" integer,
+		"name" text,
+		"email" text,
+		"age" integer,
+		"active" integer,
+		"labels" text,
+		"scores" text,
+		"created_at" datetime,
+		PRIMARY KEY ("id")
+		)`
+	testInsertUser = `insert into "users" ("id", "name", "email", "age", "active", "labels", "scores", "created_at") values (?, ?, ?, ?, ?, ?, ?, ?)`
+)
+
+// setupDB opens the database (see DB_DSN, which defaults to a file in a temp
+// dir) and creates the users table
+func setupDB(t *testing.T) *sql.DB {
+	t.Helper()
+	dsn := os.Getenv("DB_DSN")
+	if dsn == "" {
+		dsn = filepath.Join(t.TempDir(), "mql.db")
+	}
+	db, err := sql.Open("sqlite", dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	_, err = db.ExecContext(context.Background(), testCreateTablesSqlite)
+	require.NoError(t, err)
+	return db
+}
@@ -26,8 +26,12 @@ const (
 	equalToken
 	notEqualToken
 	containsToken
+	containedByToken
+	arrayContainsToken
+	similarToken
 	numberToken
 	symbolToken
+	commaToken
 
 	// keywords
 	andToken
@@ -48,10 +52,14 @@ var tokenTypeToString = map[tokenType]string{
 	equalToken:              "eq",
 	notEqualToken:           "neq",
 	containsToken:           "contains",
+	containedByToken:        "containedby",
+	arrayContainsToken:      "arraycontains",
+	similarToken:            "similar",
 	andToken:                "and",
 	orToken:                 "or",
 	numberToken:             "num",
 	symbolToken:             "symbol",
+	commaToken:              "comma",
 }
 
 // String returns a string of the tokenType and will return "Unknown" for
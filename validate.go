@@ -6,8 +6,8 @@ package mql
 import (
 	"fmt"
 	"reflect"
-	"strconv"
 	"strings"
+	"time"
 
 	"golang.org/x/exp/slices"
 )
@@ -15,55 +15,219 @@ import (
 type validator struct {
 	fn  validateFunc
 	typ string
+	// elemTyp is the validator type of the map's or array's elements when typ
+	// is "map" or "array"
+	elemTyp string
+	// json reports if an array is stored in a json column (see
+	// WithJsonArrayColumns)
+	json bool
+	// field is the model's field and rType is its reflect.Type, which is only
+	// known when the model is described using reflection.
+	field FieldDescriptor
+	rType reflect.Type
 }
 
 // validateFunc is used to validate a column value by converting it as needed,
 // validating the value, and returning the converted value
 type validateFunc func(columnValue string) (columnVal any, err error)
 
-// fieldValidators takes a model and returns a map of field names to validate
-// functions.  Supported options: WithIgnoreFields
-func fieldValidators(model reflect.Value, opt ...Option) (map[string]validator, error) {
-	const op = "mql.fieldValidators"
+// modelValidators returns a map of field names to validate functions for the
+// model, which is described using the ModelDescriber provided via
+// WithModelDescriber or reflection by default.  The column map is validated
+// against the model (see ColumnMapError).  Supported options:
+// WithModelDescriber, WithIgnoreFields, WithColumnMap
+func modelValidators(model any, opt ...Option) (map[string]validator, error) {
+	const op = "mql.modelValidators"
+	opts, err := getOpts(opt...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	var fValidators map[string]validator
 	switch {
-	case !model.IsValid():
-		return nil, fmt.Errorf("%s: missing model: %w", op, ErrInvalidParameter)
-	case (model.Kind() != reflect.Struct && model.Kind() != reflect.Pointer),
-		model.Kind() == reflect.Pointer && model.Elem().Kind() != reflect.Struct:
-		return nil, fmt.Errorf("%s: model must be a struct or a pointer to a struct: %w", op, ErrInvalidParameter)
+	case opts.withModelDescriber == nil:
+		if fValidators, err = fieldValidators(reflect.ValueOf(model), opt...); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	default:
+		fields, err := opts.withModelDescriber.DescribeModel(model)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		fValidators = descriptorValidators(fields, nil, opts)
+	}
+	if err := validateColumnMap(fValidators, opts.withColumnMap); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return fValidators, nil
+}
+
+// validateColumnMap returns a *ColumnMapError when the column map maps query
+// columns to columns which don't have a validator (aren't fields of the model)
+func validateColumnMap(fValidators map[string]validator, columnMap map[string]string) error {
+	var invalid map[string]string
+	for queryColumn, column := range columnMap {
+		if _, ok := fValidators[strings.ToLower(strings.ReplaceAll(column, "_", ""))]; ok {
+			continue
+		}
+		if invalid == nil {
+			invalid = make(map[string]string)
+		}
+		invalid[queryColumn] = column
 	}
-	var m reflect.Value = model
-	if m.Kind() != reflect.Struct {
-		m = model.Elem()
+	if invalid != nil {
+		return &ColumnMapError{Mappings: invalid}
 	}
+	return nil
+}
 
+// fieldValidators takes a model and returns a map of field names to validate
+// functions.  Supported options: WithIgnoreFields, WithoutModelCache
+func fieldValidators(model reflect.Value, opt ...Option) (map[string]validator, error) {
+	const op = "mql.fieldValidators"
 	opts, err := getOpts(opt...)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
+	m, err := cachedModelFields(model, opts)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	fValidators := descriptorValidators(m.fields, m.keys, opts)
+	for fName, v := range fValidators {
+		v.rType = m.types[fName]
+		fValidators[fName] = v
+	}
+	return fValidators, nil
+}
 
-	fValidators := make(map[string]validator)
-	for i := 0; i < m.NumField(); i++ {
-		if slices.Contains(opts.withIgnoredFields, m.Type().Field(i).Name) {
+// descriptorValidators returns a map of field names to validate functions for
+// the fields, which are keyed by their lowercase names (keys are the
+// precomputed keys of the fields, when they're not nil). Supported options:
+// WithIgnoreFields, WithDecimalColumns, WithJsonArrayColumns
+func descriptorValidators(fields []FieldDescriptor, keys []string, opts options) map[string]validator {
+	// relative times (now, today) are resolved once, so every comparison in
+	// a query uses the same time.
+	now := opts.withTimeNowFunc()
+	fValidators := make(map[string]validator, len(fields))
+	for i, f := range fields {
+		if slices.Contains(opts.withIgnoredFields, f.Name) {
 			continue
 		}
 
-		fName := strings.ToLower(m.Type().Field(i).Name)
+		var fName string
+		if keys != nil {
+			fName = keys[i]
+		} else {
+			fName = strings.ToLower(f.Name)
+		}
 		// get a string val of the field type, then strip any leading '*' so we
 		// can simplify the switch below when dealing with types like *int and int.
-		fType := strings.TrimPrefix(m.Type().Field(i).Type.String(), "*")
-		switch fType {
-		case "float32", "float64":
-			fValidators[fName] = validator{fn: validateFloat, typ: "float"}
-		case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64":
-			fValidators[fName] = validator{fn: validateInt, typ: "int"}
-		case "time.Time":
-			fValidators[fName] = validator{fn: validateDefault, typ: "time"}
+		fType := strings.TrimPrefix(f.Type, "*")
+		// the normalized name is only needed by the column options
+		var normalized string
+		if len(opts.withDecimalColumns) > 0 || len(opts.withJsonArrayColumns) > 0 {
+			normalized = strings.ToLower(strings.ReplaceAll(f.Name, "_", ""))
+		}
+		_, isDecimal := opts.withDecimalColumns[normalized]
+		elemType, isArray := arrayElemType(fType)
+		var v validator
+		switch {
+		case strings.HasPrefix(fType, "map[string]"):
+			// maps keyed by strings (think: labels) are queried by key using
+			// column.key and their values are validated using their element
+			// type.
+			elem := typeValidator(strings.TrimPrefix(fType, "map[string]"), now, opts)
+			if isDecimal {
+				elem = validator{fn: validateDecimal, typ: "decimal"}
+			}
+			v = validator{fn: elem.fn, typ: "map", elemTyp: elem.typ}
+		case isArray:
+			// arrays are queried using @> and the value is validated using
+			// their element type.
+			elem := typeValidator(elemType, now, opts)
+			_, isJson := opts.withJsonArrayColumns[normalized]
+			v = validator{fn: elem.fn, typ: "array", elemTyp: elem.typ, json: isJson}
+		case isDecimal:
+			v = validator{fn: validateDecimal, typ: "decimal"}
 		default:
-			fValidators[fName] = validator{fn: validateDefault, typ: "default"}
+			v = typeValidator(fType, now, opts)
 		}
+		v.field = f
+		fValidators[fName] = v
 	}
-	return fValidators, nil
+	return fValidators
+}
+
+// typeValidator returns the validator for the string rep of a Go type (with
+// any leading '*' already removed) and registered field types (see
+// RegisterFieldType) take precedence.  Wrapper types (see wrappedType) are
+// validated using the type they wrap.  Relative times are resolved using now.
+// Supported options: WithDurationUnit
+func typeValidator(fType string, now time.Time, opts options) validator {
+	if _, ok := lookupFieldType(fType); !ok {
+		if wrapped, ok := wrappedType(fType); ok {
+			fType = wrapped
+		}
+	}
+	if _, ok := lookupFieldType(fType); ok {
+		// registered types use their type as the validator type, so their
+		// handler can be found when converting/comparing values.
+		return validator{fn: func(s string) (any, error) { return validateFieldType(fType, s) }, typ: fType}
+	}
+	if slices.Contains(decimalTypes, fType) {
+		return validator{fn: validateDecimal, typ: "decimal"}
+	}
+	switch fType {
+	case "float32", "float64":
+		return validator{fn: validateFloat, typ: "float"}
+	case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64":
+		return validator{fn: intValidator(fType, opts.withTypedArgs), typ: "int"}
+	case "time.Time":
+		return validator{fn: timeValidator(now), typ: "time"}
+	case "time.Duration":
+		return validator{fn: durationValidator(opts.withDurationUnit), typ: "duration"}
+	case "netip.Addr", "net.IP":
+		return validator{fn: validateIP, typ: "ip"}
+	case "bool":
+		return validator{fn: validateBool, typ: "bool"}
+	default:
+		return validator{fn: validateDefault, typ: "default"}
+	}
+}
+
+// wrapperTypes are the nullable wrapper types by the type they wrap
+var wrapperTypes = map[string]string{
+	"sql.NullString":  "string",
+	"sql.NullInt64":   "int64",
+	"sql.NullInt32":   "int32",
+	"sql.NullInt16":   "int16",
+	"sql.NullByte":    "uint8",
+	"sql.NullFloat64": "float64",
+	"sql.NullBool":    "bool",
+	"sql.NullTime":    "time.Time",
+
+	"wrapperspb.DoubleValue": "float64",
+	"wrapperspb.FloatValue":  "float32",
+	"wrapperspb.Int64Value":  "int64",
+	"wrapperspb.UInt64Value": "uint64",
+	"wrapperspb.Int32Value":  "int32",
+	"wrapperspb.UInt32Value": "uint32",
+	"wrapperspb.BoolValue":   "bool",
+	"wrapperspb.StringValue": "string",
+}
+
+// wrappedType returns the type wrapped by a nullable wrapper type: the
+// database/sql Null* types, the generic sql.Null[T] (go 1.22+) and the
+// protobuf wrapperspb types (except BytesValue).  It returns false when the
+// type isn't a wrapper type.
+func wrappedType(fType string) (string, bool) {
+	if t, ok := wrapperTypes[fType]; ok {
+		return t, true
+	}
+	if strings.HasPrefix(fType, "sql.Null[") && strings.HasSuffix(fType, "]") {
+		return strings.TrimPrefix(strings.TrimSuffix(strings.TrimPrefix(fType, "sql.Null["), "]"), "*"), true
+	}
+	return "", false
 }
 
 // by default, we'll use a no op validation
@@ -71,20 +235,67 @@ func validateDefault(s string) (any, error) {
 	return s, nil
 }
 
-func validateInt(s string) (any, error) {
-	const op = "mql.validateInt"
-	i, err := strconv.Atoi(s)
-	if err != nil {
-		return 0, fmt.Errorf("%s: value %q is not an int: %w", op, s, ErrInvalidParameter)
+func validateBool(s string) (any, error) {
+	const op = "mql.validateBool"
+	switch strings.ToLower(s) {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	default:
+		return nil, fmt.Errorf("%s: value %q is not a bool (true or false): %w", op, s, ErrInvalidParameter)
 	}
-	return i, nil
 }
 
-func validateFloat(s string) (any, error) {
-	const op = "mql.validateFloat"
-	f, err := strconv.ParseFloat(s, 64)
-	if err != nil {
-		return nil, fmt.Errorf("%s: value %q is not float: %w", op, s, ErrInvalidParameter)
+// durationValidator returns a validateFunc which converts a duration literal
+// (see time.ParseDuration) to an int64 number of units.  The duration must be a
+// whole number of units.
+func durationValidator(unit time.Duration) validateFunc {
+	const op = "mql.validateDuration"
+	return func(s string) (any, error) {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return nil, fmt.Errorf("%s: value %q is not a duration (ie: 30s, 1h30m): %w", op, s, ErrInvalidParameter)
+		}
+		if d%unit != 0 {
+			return nil, fmt.Errorf("%s: value %q is not a whole number of %s: %w", op, s, unit, ErrInvalidParameter)
+		}
+		return int64(d / unit), nil
+	}
+}
+
+// validateEnum validates the comparison's value when its column has an enum
+// (see WithEnum).  Supported options: WithEnum, WithColumnMap
+func validateEnum(e *ComparisonExpr, opts options) error {
+	const op = "mql.validateEnum"
+	if len(opts.withEnums) == 0 || e.Value == nil {
+		return nil
+	}
+	columnName := strings.ToLower(e.Column)
+	if n, ok := opts.withColumnMap[columnName]; ok {
+		columnName = n
+	}
+	values, ok := opts.withEnums[strings.ToLower(strings.ReplaceAll(columnName, "_", ""))]
+	if !ok {
+		return nil
+	}
+	value, candidates := *e.Value, values
+	if opts.withEnumFoldCase {
+		value, candidates = strings.ToLower(value), make([]string, 0, len(values))
+		for _, v := range values {
+			candidates = append(candidates, strings.ToLower(v))
+		}
+	}
+	if e.ComparisonOp == ContainsOp {
+		for _, v := range candidates {
+			if strings.Contains(v, value) {
+				return nil
+			}
+		}
+		return fmt.Errorf("%s: %w %q for column %q (expected a part of one of: %s): %w", op, ErrInvalidEnumValue, *e.Value, e.Column, strings.Join(values, ", "), ErrInvalidParameter)
+	}
+	if !slices.Contains(candidates, value) {
+		return fmt.Errorf("%s: %w %q for column %q (expected one of: %s): %w", op, ErrInvalidEnumValue, *e.Value, e.Column, strings.Join(values, ", "), ErrInvalidParameter)
 	}
-	return f, nil
+	return nil
 }
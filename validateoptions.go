@@ -0,0 +1,114 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+)
+
+// ValidateOptions validates the options and that they're consistent with the
+// model, so a configuration can be verified at startup rather than when a
+// user happens to query a misconfigured column.  Every problem is returned
+// (see errors.Join) and they're all an ErrInvalidParameter:
+//
+//   - an invalid option (ie: WithConverter with a nil converter)
+//   - a WithColumnMap (or WithColumnAliases) query column which is mapped to
+//     a column that isn't a field of the model, which is a *ColumnMapError
+//   - a WithIgnoredFields field which isn't a field of the model
+//   - a column of WithContextConverter, WithEnum, WithValueTransform,
+//     WithDecimalColumns, WithJsonArrayColumns, WithEmptyStringAsNull,
+//     WithoutStringRanges, WithCostHints, WithCollation,
+//     WithAccentInsensitive, WithColumnOperators or WithBlindIndex which
+//     isn't a column of the model (or is an ignored field)
+//
+// WithConverter columns aren't validated, since a converter can provide a
+// column which isn't a field of the model.  Supported options: the same
+// options as Parse.
+func ValidateOptions(model any, opt ...Option) error {
+	const op = "mql.ValidateOptions"
+	if isNil(model) {
+		return fmt.Errorf("%s: missing model: %w", op, ErrInvalidParameter)
+	}
+	opt, err := withModelTable(model, opt)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	opts, err := getOpts(opt...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	// every field is described, so ignored fields can be validated too, and
+	// the column map is validated below
+	allFields := opts
+	allFields.withIgnoredFields = nil
+	allFields.withColumnMap = nil
+	fValidators, err := modelValidators(model, withOptions(allFields))
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	var errs []error
+	invalid := func(format string, a ...any) {
+		errs = append(errs, fmt.Errorf("%s: %s: %w", op, fmt.Sprintf(format, a...), ErrInvalidParameter))
+	}
+	// columns are the model's columns by their normalized name (see
+	// WithEnum), excluding its ignored fields
+	columns := make(map[string]struct{}, len(fValidators))
+	colValidators := make(map[string]validator, len(fValidators))
+	for _, v := range fValidators {
+		if !slices.Contains(opts.withIgnoredFields, v.field.Name) {
+			normalized := strings.ToLower(strings.ReplaceAll(v.field.Name, "_", ""))
+			columns[normalized] = struct{}{}
+			colValidators[normalized] = v
+		}
+	}
+
+	for _, f := range opts.withIgnoredFields {
+		// ignored fields are case sensitive
+		if v, ok := fValidators[strings.ToLower(f)]; !ok || v.field.Name != f {
+			invalid("ignored field %q isn't a field of the model", f)
+		}
+	}
+	if err := validateColumnMap(colValidators, opts.withColumnMap); err != nil {
+		errs = append(errs, fmt.Errorf("%s: %w", op, err))
+	}
+	columnOptions := []struct {
+		name    string
+		columns []string
+	}{
+		{name: "WithContextConverter", columns: sortedKeys(opts.withContextConvertFns)},
+		{name: "WithEnum", columns: sortedKeys(opts.withEnums)},
+		{name: "WithValueTransform", columns: sortedKeys(opts.withValueTransforms)},
+		{name: "WithDecimalColumns", columns: sortedKeys(opts.withDecimalColumns)},
+		{name: "WithJsonArrayColumns", columns: sortedKeys(opts.withJsonArrayColumns)},
+		{name: "WithEmptyStringAsNull", columns: sortedKeys(opts.withEmptyStringAsNull)},
+		{name: "WithoutStringRanges", columns: sortedKeys(opts.withoutStringRanges)},
+		{name: "WithCostHints", columns: sortedKeys(opts.withCostHints)},
+		{name: "WithCollation", columns: sortedKeys(opts.withCollationColumns)},
+		{name: "WithAccentInsensitive", columns: sortedKeys(opts.withAccentInsensitive)},
+		{name: "WithColumnOperators", columns: sortedKeys(opts.withColumnOps)},
+		{name: "WithBlindIndex", columns: sortedKeys(opts.withBlindIndexes)},
+	}
+	for _, o := range columnOptions {
+		for _, c := range o.columns {
+			if _, ok := columns[c]; !ok {
+				invalid("%s column %q isn't a column of the model", o.name, c)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// sortedKeys returns the keys of the map in order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := maps.Keys(m)
+	sort.Strings(keys)
+	return keys
+}
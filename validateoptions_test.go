@@ -0,0 +1,115 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql_test
+
+import (
+	"testing"
+
+	"github.com/hashicorp/mql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateOptions(t *testing.T) {
+	t.Parallel()
+	converter := func(mql.ConvertContext, mql.ComparisonOp, *string) (*mql.WhereClause, error) {
+		return nil, nil
+	}
+	transform := func(v string) (string, error) { return v, nil }
+	tests := []struct {
+		name            string
+		model           any
+		opts            []mql.Option
+		wantErrContains []string
+	}{
+		{
+			name:  "valid",
+			model: testModel{},
+			opts: []mql.Option{
+				mql.WithColumnMap(map[string]string{"nickname": "name", "joined": "created_at", "birth": "Birthday"}),
+				mql.WithIgnoredFields("Email"),
+				mql.WithConverter("virtual", func(string, mql.ComparisonOp, *string) (*mql.WhereClause, error) { return nil, nil }),
+				mql.WithContextConverter("name", converter),
+				mql.WithEnum("member_number", []string{"a", "b"}),
+				mql.WithValueTransform("name", transform),
+				mql.WithDecimalColumns("length"),
+				mql.WithEmptyStringAsNull("MemberNumber"),
+			},
+		},
+		{
+			name:  "valid-without-options",
+			model: &testModel{},
+		},
+		{
+			name:            "err-missing-model",
+			wantErrContains: []string{"missing model"},
+		},
+		{
+			name:            "err-invalid-option",
+			model:           testModel{},
+			opts:            []mql.Option{mql.WithConverter("name", nil)},
+			wantErrContains: []string{"missing ConvertToSqlFunc"},
+		},
+		{
+			name:            "err-not-a-struct",
+			model:           "users",
+			wantErrContains: []string{"model must be a struct"},
+		},
+		{
+			name:  "err-column-map",
+			model: testModel{},
+			opts: []mql.Option{
+				mql.WithIgnoredFields("Email"),
+				mql.WithColumnMap(map[string]string{"Nickname": "name", "joined": "joined_at", "mail": "email"}),
+			},
+			wantErrContains: []string{
+				`invalid column map: columns which aren't fields of the model: "joined" -> "joined_at", "mail" -> "email"`,
+			},
+		},
+		{
+			name:            "err-ignored-fields",
+			model:           testModel{},
+			opts:            []mql.Option{mql.WithIgnoredFields("email", "Password")},
+			wantErrContains: []string{`ignored field "email" isn't a field of the model`, `ignored field "Password" isn't a field of the model`},
+		},
+		{
+			name:  "err-columns",
+			model: testModel{},
+			opts: []mql.Option{
+				mql.WithIgnoredFields("Email"),
+				mql.WithContextConverter("nickname", converter),
+				mql.WithEnum("status", []string{"active"}),
+				mql.WithValueTransform("email", transform),
+				mql.WithDecimalColumns("price"),
+				mql.WithJsonArrayColumns("tags"),
+				mql.WithEmptyStringAsNull("phone"),
+			},
+			wantErrContains: []string{
+				`WithContextConverter column "nickname" isn't a column of the model`,
+				`WithEnum column "status" isn't a column of the model`,
+				`WithValueTransform column "email" isn't a column of the model`,
+				`WithDecimalColumns column "price" isn't a column of the model`,
+				`WithJsonArrayColumns column "tags" isn't a column of the model`,
+				`WithEmptyStringAsNull column "phone" isn't a column of the model`,
+			},
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert, require := assert.New(t), require.New(t)
+			err := mql.ValidateOptions(tc.model, tc.opts...)
+			if len(tc.wantErrContains) > 0 {
+				require.Error(err)
+				assert.ErrorIs(err, mql.ErrInvalidParameter)
+				for _, want := range tc.wantErrContains {
+					assert.ErrorContains(err, want)
+				}
+				return
+			}
+			require.NoError(err)
+		})
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql_test
+
+import (
+	"testing"
+
+	"github.com/hashicorp/mql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse_valueLists(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name      string
+		query     string
+		want      *mql.WhereClause
+		wantErrIs error
+	}{
+		{
+			name:  "equal",
+			query: `name=("alice","bob","carol")`,
+			want: &mql.WhereClause{
+				Condition: "((name=? or name=?) or name=?)",
+				Args:      []any{"alice", "bob", "carol"},
+			},
+		},
+		{
+			name:  "any",
+			query: `name ANY ( "alice" , 'bob' )`,
+			want:  &mql.WhereClause{Condition: "(name=? or name=?)", Args: []any{"alice", "bob"}},
+		},
+		{
+			name:  "not-equal",
+			query: `age!=(21,22)`,
+			want:  &mql.WhereClause{Condition: "(age!=? and age!=?)", Args: []any{21, 22}},
+		},
+		{
+			name:  "single-value",
+			query: `name=("alice")`,
+			want:  &mql.WhereClause{Condition: "name=?", Args: []any{"alice"}},
+		},
+		{
+			name:  "with-other-comparisons",
+			query: `age>21 and name=("alice","bob") or (email="eve@example.com")`,
+			want: &mql.WhereClause{
+				Condition: "((age>? and (name=? or name=?)) or email=?)",
+				Args:      []any{21, "alice", "bob", "eve@example.com"},
+			},
+		},
+		{
+			name:      "err-empty",
+			query:     `name=()`,
+			wantErrIs: mql.ErrMissingComparisonValue,
+		},
+		{
+			name:      "err-trailing-comma",
+			query:     `name=("alice",)`,
+			wantErrIs: mql.ErrUnexpectedToken,
+		},
+		{
+			name:      "err-missing-comma",
+			query:     `name=("alice" "bob")`,
+			wantErrIs: mql.ErrUnexpectedToken,
+		},
+		{
+			name:      "err-missing-closing-paren",
+			query:     `name=("alice","bob"`,
+			wantErrIs: mql.ErrMissingClosingParen,
+		},
+		{
+			name:      "err-comparison-op",
+			query:     `age>(21,22)`,
+			wantErrIs: mql.ErrUnexpectedOpeningParen,
+		},
+		{
+			name:      "err-any-without-list",
+			query:     `name any "alice"`,
+			wantErrIs: mql.ErrUnexpectedToken,
+		},
+		{
+			name:      "err-unquoted-value",
+			query:     `name=(alice)`,
+			wantErrIs: mql.ErrInvalidComparisonValueType,
+		},
+		{
+			name:      "err-comma-outside-list",
+			query:     `name="alice", age=21`,
+			wantErrIs: mql.ErrUnexpectedToken,
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert, require := assert.New(t), require.New(t)
+			got, err := mql.Parse(tc.query, testModel{})
+			if tc.wantErrIs != nil {
+				require.Error(err)
+				assert.ErrorIs(err, tc.wantErrIs)
+				assert.Nil(got)
+				return
+			}
+			require.NoError(err)
+			assert.Equal(tc.want, got)
+		})
+	}
+	t.Run("match", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		ok, err := mql.Match(`name=("alice","bob") and age!=(1,2)`, testModel{Name: "bob", Age: 21})
+		require.NoError(err)
+		assert.True(ok)
+	})
+	t.Run("mql", func(t *testing.T) {
+		e, err := mql.ParseExpr(`name any ("alice","bob")`)
+		require.NoError(t, err)
+		assert.Equal(t, `name="alice" or name="bob"`, e.MQL())
+	})
+}
@@ -0,0 +1,100 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// WithVariables provides the values of the variables of a query, which are
+// unquoted names prefixed with a colon used as the value of a comparison (ie:
+// `owner_id=:current_user and created_at>:since`).  Variables are resolved
+// when the query is converted, so a saved query can be executed with
+// different values without building the query using string interpolation.  A
+// variable's value is validated like any other value of its column, so it
+// must be: a string, bool, int, uint, float, time.Time, time.Duration or a
+// fmt.Stringer.  Using a variable which isn't provided is an
+// ErrInvalidParameter, since it's never compared as a literal value.
+func WithVariables(vars map[string]any) Option {
+	const op = "mql.WithVariables"
+	return func(o *options) error {
+		for name := range vars {
+			if !isVariable(":" + name) {
+				return fmt.Errorf("%s: variable %q isn't an identifier: %w", op, name, ErrInvalidParameter)
+			}
+		}
+		o.withVariables = vars
+		return nil
+	}
+}
+
+// isVariable reports if s is a variable (ie: :current_user), which is a colon
+// followed by a letter or an underscore and then letters, digits and
+// underscores.
+func isVariable(s string) bool {
+	if len(s) < 2 || s[0] != ':' || (s[1] >= '0' && s[1] <= '9') {
+		return false
+	}
+	return isIdentifier(s[1:])
+}
+
+// resolveVariable returns the comparison with its variable replaced by the
+// variable's value.  The comparison isn't modified, so a copy is returned when
+// its value is a variable.  Supported options: WithVariables
+func resolveVariable(e *ComparisonExpr, opts options) (*ComparisonExpr, error) {
+	const op = "mql.resolveVariable"
+	if !e.variable || e.Value == nil {
+		return e, nil
+	}
+	raw, ok := opts.withVariables[*e.Value]
+	if !ok {
+		return nil, fmt.Errorf("%s: missing variable %q for column %q: %w", op, ":"+*e.Value, e.Column, ErrInvalidParameter)
+	}
+	v, err := variableValue(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%s: variable %q for column %q: %w", op, ":"+*e.Value, e.Column, err)
+	}
+	resolved := *e
+	resolved.Value, resolved.variable = &v, false
+	return &resolved, nil
+}
+
+// variableValue returns the value of a variable as the literal value of a
+// comparison, which is then validated using the column's validator
+func variableValue(v any) (string, error) {
+	const op = "mql.variableValue"
+	switch t := v.(type) {
+	case string:
+		return t, nil
+	case bool:
+		return strconv.FormatBool(t), nil
+	case time.Time:
+		return t.Format(time.RFC3339Nano), nil
+	case time.Duration:
+		return t.String(), nil
+	case fmt.Stringer:
+		if isNil(t) {
+			return "", fmt.Errorf("%s: nil %T: %w", op, v, ErrInvalidParameter)
+		}
+		return t.String(), nil
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(rv.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(rv.Float(), 'g', -1, rv.Type().Bits()), nil
+	case reflect.String:
+		return rv.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(rv.Bool()), nil
+	default:
+		return "", fmt.Errorf("%s: unsupported type %T: %w", op, v, ErrInvalidParameter)
+	}
+}
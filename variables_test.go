@@ -0,0 +1,143 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql_test
+
+import (
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/mql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse_WithVariables(t *testing.T) {
+	t.Parallel()
+	since := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	vars := map[string]any{
+		"current_user": "alice",
+		"min_age":      uint8(21),
+		"length":       1.5,
+		"since":        since,
+		"ip":           net.ParseIP("10.0.0.1"),
+		"nil_ip":       net.IP(nil),
+		"unsupported":  []string{"alice"},
+	}
+	tests := []struct {
+		name      string
+		query     string
+		opts      []mql.Option
+		want      *mql.WhereClause
+		wantErrIs error
+	}{
+		{
+			name:  "string",
+			query: `name = :current_user`,
+			want:  &mql.WhereClause{Condition: "name=?", Args: []any{"alice"}},
+		},
+		{
+			name:  "int-and-time",
+			query: `age>=:min_age and created_at>:since`,
+			want:  &mql.WhereClause{Condition: "(age>=? and created_at>?)", Args: []any{21, since}},
+		},
+		{
+			name:  "float",
+			query: `length<:length`,
+			want:  &mql.WhereClause{Condition: "length<?", Args: []any{1.5}},
+		},
+		{
+			name:  "stringer",
+			query: `name=:ip`,
+			want:  &mql.WhereClause{Condition: "name=?", Args: []any{"10.0.0.1"}},
+		},
+		{
+			name:  "list",
+			query: `name=(:current_user, "bob")`,
+			want:  &mql.WhereClause{Condition: "(name=? or name=?)", Args: []any{"alice", "bob"}},
+		},
+		{
+			name:  "quoted-isnt-a-variable",
+			query: `name=":current_user"`,
+			want:  &mql.WhereClause{Condition: "name=?", Args: []any{":current_user"}},
+		},
+		{
+			name:      "err-missing",
+			query:     `name=:missing`,
+			wantErrIs: mql.ErrInvalidParameter,
+		},
+		{
+			name:      "err-type",
+			query:     `age=:current_user`,
+			wantErrIs: mql.ErrInvalidParameter,
+		},
+		{
+			name:      "err-unsupported",
+			query:     `name=:unsupported`,
+			wantErrIs: mql.ErrInvalidParameter,
+		},
+		{
+			name:      "err-nil",
+			query:     `name=:nil_ip`,
+			wantErrIs: mql.ErrInvalidParameter,
+		},
+		{
+			name:      "err-name",
+			query:     `name=:1st`,
+			wantErrIs: mql.ErrInvalidComparisonValueType,
+		},
+		{
+			name:      "err-invalid-variable",
+			query:     `name=:ok`,
+			opts:      []mql.Option{mql.WithVariables(map[string]any{"not ok": "alice"})},
+			wantErrIs: mql.ErrInvalidParameter,
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert, require := assert.New(t), require.New(t)
+			got, err := mql.Parse(tc.query, testModel{}, append([]mql.Option{mql.WithVariables(vars)}, tc.opts...)...)
+			if tc.wantErrIs != nil {
+				require.Error(err)
+				assert.ErrorIs(err, tc.wantErrIs)
+				assert.Nil(got)
+				return
+			}
+			require.NoError(err)
+			assert.Equal(tc.want, got)
+		})
+	}
+	t.Run("without-variables", func(t *testing.T) {
+		_, err := mql.Parse(`name=:current_user`, testModel{})
+		assert.ErrorIs(t, err, mql.ErrInvalidParameter)
+	})
+	t.Run("saved-query", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		e, err := mql.ParseExpr(`name=:current_user and age>21`)
+		require.NoError(err)
+		assert.Equal(`name=:current_user and age>21`, e.MQL())
+
+		b, err := json.Marshal(e)
+		require.NoError(err)
+		assert.Contains(string(b), `"value":"current_user","variable":true`)
+		decoded, err := mql.UnmarshalExpr(b)
+		require.NoError(err)
+		assert.Equal(e.MQL(), decoded.MQL())
+
+		for user, want := range map[string]any{"alice": "alice", "bob": "bob"} {
+			got, err := mql.ToWhereClause(decoded, testModel{}, mql.WithVariables(map[string]any{"current_user": user}))
+			require.NoError(err)
+			assert.Equal([]any{want, 21}, got.Args)
+		}
+	})
+	t.Run("match", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		got, err := mql.Match(`name=:current_user`, testModel{Name: "alice"}, mql.WithVariables(vars))
+		require.NoError(err)
+		assert.True(got)
+	})
+}
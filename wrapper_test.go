@@ -0,0 +1,154 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mql_test
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/mql"
+	"github.com/hashicorp/mql/internal/wrapperspb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type wrapperModel struct {
+	Count    sql.NullInt64
+	Rank     sql.NullInt32
+	Level    sql.NullInt16
+	Flags    sql.NullByte
+	Score    sql.NullFloat64
+	Verified sql.NullBool
+	SeenAt   sql.NullTime
+	Nickname sql.NullString
+	Visits   *wrapperspb.Int64Value
+	Title    *wrapperspb.StringValue
+	Age      testNull[int]
+}
+
+// testNull mirrors sql.Null[T] (go 1.22+)
+type testNull[T any] struct {
+	V     T
+	Valid bool
+}
+
+func (n testNull[T]) Value() (driver.Value, error) {
+	if !n.Valid {
+		return nil, nil
+	}
+	return n.V, nil
+}
+
+// wrapperDescriber describes wrapperModel with its Age field as a sql.Null[int]
+type wrapperDescriber struct{}
+
+func (wrapperDescriber) DescribeModel(model any) ([]mql.FieldDescriptor, error) {
+	fields, err := mql.ReflectDescriber{}.DescribeModel(model)
+	if err != nil {
+		return nil, err
+	}
+	for i, f := range fields {
+		if f.Name == "Age" {
+			fields[i].Type = "sql.Null[int]"
+		}
+	}
+	return fields, nil
+}
+
+func TestParse_wrapperTypes(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name            string
+		query           string
+		want            *mql.WhereClause
+		wantErrIs       error
+		wantErrContains string
+	}{
+		{name: "sql.NullInt64", query: "count>1", want: &mql.WhereClause{Condition: "count>?", Args: []any{1}}},
+		{name: "sql.NullInt32", query: "rank>1", want: &mql.WhereClause{Condition: "rank>?", Args: []any{1}}},
+		{name: "sql.NullInt16", query: "level>1", want: &mql.WhereClause{Condition: "level>?", Args: []any{1}}},
+		{name: "sql.NullByte", query: "flags=1", want: &mql.WhereClause{Condition: "flags=?", Args: []any{1}}},
+		{name: "sql.NullFloat64", query: "score>1.5", want: &mql.WhereClause{Condition: "score>?", Args: []any{1.5}}},
+		{name: "sql.NullBool", query: "verified=true", want: &mql.WhereClause{Condition: "verified=?", Args: []any{true}}},
+		{
+			name:  "sql.NullTime",
+			query: `seen_at>"2023-01-02T03:04:05Z"`,
+			want:  &mql.WhereClause{Condition: "seen_at>?", Args: []any{time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)}},
+		},
+		{name: "sql.NullString", query: `nickname="alice"`, want: &mql.WhereClause{Condition: "nickname=?", Args: []any{"alice"}}},
+		{name: "wrapperspb.Int64Value", query: "visits>=10", want: &mql.WhereClause{Condition: "visits>=?", Args: []any{10}}},
+		{name: "wrapperspb.StringValue", query: `title="boss"`, want: &mql.WhereClause{Condition: "title=?", Args: []any{"boss"}}},
+		{name: "sql.Null[int]", query: "age>21", want: &mql.WhereClause{Condition: "age>?", Args: []any{21}}},
+		{name: "invalid-sql.NullInt64", wantErrIs: mql.ErrInvalidParameter, query: `count>"many"`, wantErrContains: `"many" in`},
+		{name: "invalid-sql.NullTime", wantErrIs: mql.ErrInvalidParameter, query: `seen_at>"yesterday"`, wantErrContains: `"yesterday" in`},
+		{name: "invalid-sql.NullBool", wantErrIs: mql.ErrInvalidComparisonOp, query: `verified>true`, wantErrContains: "for bool column"},
+		{name: "invalid-wrapperspb.Int64Value", wantErrIs: mql.ErrInvalidParameter, query: `visits="lots"`, wantErrContains: `"lots" in`},
+		{name: "invalid-sql.Null[int]", wantErrIs: mql.ErrInvalidParameter, query: `age="old"`, wantErrContains: `"old" in`},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert, require := assert.New(t), require.New(t)
+			got, err := mql.Parse(tc.query, wrapperModel{}, mql.WithModelDescriber(wrapperDescriber{}))
+			if tc.wantErrContains != "" {
+				require.Error(err)
+				assert.Empty(got)
+				assert.ErrorIs(err, tc.wantErrIs)
+				assert.ErrorContains(err, tc.wantErrContains)
+				return
+			}
+			require.NoError(err)
+			assert.Equal(tc.want, got)
+		})
+	}
+}
+
+func TestMatch_wrapperTypes(t *testing.T) {
+	t.Parallel()
+	item := wrapperModel{
+		Count:    sql.NullInt64{Int64: 10, Valid: true},
+		Rank:     sql.NullInt32{Int32: 2, Valid: true},
+		Level:    sql.NullInt16{},
+		Flags:    sql.NullByte{Byte: 3, Valid: true},
+		Score:    sql.NullFloat64{Float64: 2.5, Valid: true},
+		Verified: sql.NullBool{Bool: true, Valid: true},
+		SeenAt:   sql.NullTime{Time: time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC), Valid: true},
+		Nickname: sql.NullString{String: "alice", Valid: true},
+		Visits:   wrapperspb.Int64(9),
+		Age:      testNull[int]{V: 30, Valid: true},
+	}
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{query: "count>9", want: true},
+		{query: "count>10", want: false},
+		{query: "rank<3", want: true},
+		{query: "level>0", want: false},
+		{query: "flags=3", want: true},
+		{query: "score>2", want: true},
+		{query: "verified=true", want: true},
+		{query: `seen_at>"2023-01-01"`, want: true},
+		{query: `seen_at>"2023-01-02"`, want: false},
+		{query: `nickname="alice"`, want: true},
+		// 9 is compared as an int, which is less than 10 (but not as a string)
+		{query: "visits<10", want: true},
+		{query: `title="boss"`, want: false},
+		{query: "age>4", want: true},
+		{query: "age<30", want: false},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.query, func(t *testing.T) {
+			t.Parallel()
+			assert, require := assert.New(t), require.New(t)
+			got, err := mql.Match(tc.query, item, mql.WithModelDescriber(wrapperDescriber{}))
+			require.NoError(err)
+			assert.Equal(tc.want, got)
+		})
+	}
+}